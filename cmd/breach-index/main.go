@@ -0,0 +1,37 @@
+// Command breach-index converts a HIBP-style SHA-1 password hash corpus into the
+// binary index used by the server's offline breached-password check.
+//
+// Usage:
+//
+//	breach-index -out pwned.idx pwned-passwords-sha1-ordered-by-hash-v8.txt
+//	breach-index -out pwned.idx -chunk 8388608 ./hibp-ranges/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Zrossiz/gophkeeper/pkg/breach"
+)
+
+func main() {
+	out := flag.String("out", "pwned.idx", "path of the index to write")
+	chunk := flag.Int("chunk", breach.DefaultChunkRecords, "records sorted in memory per chunk")
+	tmp := flag.String("tmp", "", "directory for intermediate chunks (default: system temp dir)")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: breach-index [-out pwned.idx] [-chunk n] [-tmp dir] corpus...")
+		os.Exit(2)
+	}
+
+	builder := &breach.Builder{ChunkRecords: *chunk, TempDir: *tmp}
+	count, err := builder.Build(*out, flag.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "build index error:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("wrote %d hashes to %s\n", count, *out)
+}
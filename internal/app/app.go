@@ -6,8 +6,12 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"os"
 
 	_ "github.com/Zrossiz/gophkeeper/docs"
 	"github.com/Zrossiz/gophkeeper/internal/config"
@@ -17,7 +21,9 @@ import (
 	"github.com/Zrossiz/gophkeeper/internal/transport/http/handler"
 	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"github.com/Zrossiz/gophkeeper/internal/transport/http/router"
+	"github.com/Zrossiz/gophkeeper/pkg/breach"
 	"github.com/Zrossiz/gophkeeper/pkg/logger"
+	"github.com/Zrossiz/gophkeeper/pkg/ratelimit"
 	_ "github.com/lib/pq"
 	"go.uber.org/zap"
 )
@@ -27,10 +33,11 @@ import (
 //  1. Loads the application configuration.
 //  2. Initializes the logger.
 //  3. Establishes a connection to the PostgreSQL database.
-//  4. Initializes the authentication middleware and cryptographic module.
-//  5. Sets up the database storage, services, and HTTP handlers.
+//  4. Initializes the cryptographic module.
+//  5. Sets up the database storage, services, authentication middleware and HTTP handlers.
 //  6. Configures the HTTP router with middleware and handlers.
-//  7. Starts the HTTP server on the configured address.
+//  7. Resumes a key rotation and re-encryption job interrupted by the last shutdown.
+//  8. Starts the HTTP server on the configured address.
 //
 // If any initialization step fails, the function logs the error and terminates the application.
 //
@@ -39,6 +46,10 @@ import (
 // @description API для управления секретными данными (карточки, пароли и т.д.).
 // @host localhost:8080
 // @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access токен в формате "Bearer <токен>". Ключ хранилища передается в заголовке X-Vault-Key или в cookie "key"
 func Start() {
 	// Load configuration
 	cfg, err := config.New()
@@ -59,40 +70,87 @@ func Start() {
 	}
 	defer dbConn.Close()
 
-	// Initialize middleware and cryptographic module
-	authMiddleware := middleware.New(*cfg, log)
-	cryptoModule := cryptox.NewCryproModule()
+	// Initialize cryptographic module
+	cryptoModule, err := newCryptoModule(*cfg)
+	if err != nil {
+		log.Error("init crypto module error", zap.Error(err))
+		return
+	}
+
+	// Open the offline breached-password index, if configured
+	breachChecker := openBreachIndex(*cfg, log)
 
 	// Initialize database storage
 	dbStore := postgres.New(dbConn)
 
+	// Keep failed logins and authentication rate limits in memory
+	authLimits := ratelimit.NewMemoryStore()
+
 	// Initialize services
 	serv := service.New(service.Storage{
-		Card:     &dbStore.Card,
-		User:     &dbStore.User,
-		Binary:   &dbStore.Binary,
-		LogoPass: &dbStore.LogoPass,
-		Note:     &dbStore.Note,
-	}, *cfg, cryptoModule, log)
+		Card:             &dbStore.Card,
+		User:             &dbStore.User,
+		Binary:           &dbStore.Binary,
+		LogoPass:         &dbStore.LogoPass,
+		Note:             &dbStore.Note,
+		BankAccount:      &dbStore.BankAccount,
+		IdentityDocument: &dbStore.IdentityDocument,
+		Vault:            &dbStore.Vault,
+		Reencryption:     &dbStore.Reencryption,
+		Sharing:          &dbStore.Sharing,
+		Org:              &dbStore.Org,
+		Emergency:        &dbStore.Emergency,
+		RecoveryShare:    &dbStore.RecoveryShare,
+		Session:          &dbStore.User,
+		LoginAttempts:    authLimits,
+	}, *cfg, cryptoModule, breachChecker, log)
+
+	// Initialize middleware, which validates sessions of access tokens and throttles authentication
+	authMiddleware := middleware.New(*cfg, &serv.Session, authLimits, log)
 
 	// Initialize HTTP handlers
 	handler := handler.New(handler.Service{
-		Card:     &serv.Card,
-		User:     &serv.User,
-		Binary:   &serv.Binary,
-		LogoPass: &serv.LogoPass,
-		Note:     &serv.Note,
+		Card:             &serv.Card,
+		User:             &serv.User,
+		Binary:           &serv.Binary,
+		LogoPass:         &serv.LogoPass,
+		Note:             &serv.Note,
+		BankAccount:      &serv.BankAccount,
+		IdentityDocument: &serv.IdentityDocument,
+		Generator:        &serv.Generator,
+		Audit:            &serv.Audit,
+		Reencryption:     &serv.Reencryption,
+		Sharing:          &serv.Sharing,
+		Org:              &serv.Org,
+		Emergency:        &serv.Emergency,
+		RecoveryShare:    &serv.RecoveryShare,
+		Session:          &serv.Session,
 	}, log)
 
 	// Configure HTTP router
 	router := router.New(router.Handler{
-		Card:     &handler.Card,
-		User:     &handler.User,
-		Binary:   &handler.Binary,
-		LogoPass: &handler.LogoPass,
-		Note:     &handler.Note,
+		Card:             &handler.Card,
+		User:             &handler.User,
+		Binary:           &handler.Binary,
+		LogoPass:         &handler.LogoPass,
+		Note:             &handler.Note,
+		BankAccount:      &handler.BankAccount,
+		IdentityDocument: &handler.IdentityDocument,
+		Generator:        &handler.Generator,
+		Audit:            &handler.Audit,
+		Reencryption:     &handler.Reencryption,
+		Sharing:          &handler.Sharing,
+		Org:              &handler.Org,
+		Emergency:        &handler.Emergency,
+		RecoveryShare:    &handler.RecoveryShare,
+		Session:          &handler.Session,
 	}, authMiddleware)
 
+	// Resume a re-encryption job interrupted by the last shutdown
+	if err := serv.Reencryption.Resume(context.Background()); err != nil {
+		log.Error("resume reencryption job error", zap.Error(err))
+	}
+
 	// Start HTTP server
 	srv := &http.Server{
 		Addr:    cfg.ServerAddress,
@@ -104,3 +162,88 @@ func Start() {
 		log.Error("start web server error", zap.Error(err))
 	}
 }
+
+// newCryptoModule creates the cryptographic module from the KDF and cipher settings and the
+// configured server master key provider.
+func newCryptoModule(cfg config.Config) (*cryptox.CryptoModule, error) {
+	opts := cryptox.Options{
+		KDF: cryptox.KDFConfig{
+			Algorithm:     cfg.KDFAlgorithm,
+			Argon2Time:    uint32(cfg.KDFTime),
+			Argon2Memory:  uint32(cfg.KDFMemory),
+			Argon2Threads: uint8(cfg.KDFThreads),
+			ScryptLogN:    uint8(cfg.KDFScryptLogN),
+		},
+		Cipher: cfg.CipherAlgorithm,
+	}
+
+	keyProvider, err := newKeyProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts.KeyProvider = keyProvider
+
+	return cryptox.NewCryptoModule(opts)
+}
+
+// newKeyProvider creates the server master key provider selected by KEY_PROVIDER.
+//
+// Returns:
+//   - The provider, nil if no provider is configured, or an error if the provider cannot be created.
+func newKeyProvider(cfg config.Config) (cryptox.KeyProvider, error) {
+	switch cfg.KeyProvider {
+	case "":
+		return nil, nil
+	case "env":
+		return cryptox.NewEnvKeyProvider("SERVER_MASTER_KEY")
+	case "file":
+		return cryptox.NewFileKeyProvider(cfg.ServerKeyFile)
+	case "vault":
+		return cryptox.NewVaultTransitProvider(cryptox.VaultTransitConfig{
+			Address: cfg.VaultAddress,
+			Token:   cfg.VaultToken,
+			Mount:   cfg.VaultTransitMount,
+			Key:     cfg.VaultTransitKey,
+		})
+	case "pkcs11":
+		return cryptox.NewPKCS11KeyProvider(cryptox.PKCS11Config{
+			Module:     cfg.PKCS11Module,
+			TokenLabel: cfg.PKCS11TokenLabel,
+			PIN:        cfg.PKCS11PIN,
+			KeyLabel:   cfg.PKCS11KeyLabel,
+		})
+	default:
+		return nil, fmt.Errorf("unknown key provider %q", cfg.KeyProvider)
+	}
+}
+
+// openBreachIndex opens the breached-password index configured by BREACH_INDEX_PATH.
+// If the index does not exist yet and BREACH_CORPUS_PATH is set, the index is built from the corpus first.
+//
+// Returns:
+//   - A service.BreachChecker, or nil if the check is disabled or the index cannot be opened.
+func openBreachIndex(cfg config.Config, log *zap.Logger) service.BreachChecker {
+	if cfg.BreachIndexPath == "" {
+		return nil
+	}
+
+	_, err := os.Stat(cfg.BreachIndexPath)
+	if errors.Is(err, fs.ErrNotExist) && cfg.BreachCorpusPath != "" {
+		log.Sugar().Infof("building breached password index from %v", cfg.BreachCorpusPath)
+
+		count, err := breach.Build(cfg.BreachIndexPath, cfg.BreachCorpusPath)
+		if err != nil {
+			log.Error("build breached password index error", zap.Error(err))
+			return nil
+		}
+		log.Sugar().Infof("breached password index built: %d hashes", count)
+	}
+
+	index, err := breach.Open(cfg.BreachIndexPath)
+	if err != nil {
+		log.Error("open breached password index error", zap.Error(err))
+		return nil
+	}
+
+	return index
+}
@@ -3,7 +3,11 @@
 // such as user authentication, database operations, and server-side issues.
 package apperrors
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 var (
 	// ErrUserNotFound is returned when a requested user is not found in the system.
@@ -15,15 +19,91 @@ var (
 	// ErrHashPassword is returned when there is an error hashing a password.
 	ErrHashPassword = errors.New("error hash password")
 
+	// ErrUserKeysNotFound is returned when a user has no wrapped data encryption key yet.
+	ErrUserKeysNotFound = errors.New("user keys not found")
+
+	// ErrKeyDerivation is returned when the vault key cannot be derived or unwrapped with the user's password.
+	ErrKeyDerivation = errors.New("key derivation error")
+
 	// ErrDBQuery is returned when there is an error executing a database query.
 	ErrDBQuery = errors.New("db error")
 
 	// ErrJWTGeneration is returned when there is an error generating a JWT token.
 	ErrJWTGeneration = errors.New("jwt generation error")
 
+	// ErrInvalidRefreshToken is returned when a refresh token is malformed, expired, revoked or unknown.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again;
+	// its session is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+
+	// ErrSessionNotFound is returned when a session does not exist, or for an access token, is revoked or expired.
+	ErrSessionNotFound = errors.New("session not found")
+
 	// ErrInvalidPassword is returned when the provided login credentials are invalid.
 	ErrInvalidPassword = errors.New("invalid login or password")
 
+	// ErrRecoveryNotFound is returned when a user has no recovery key.
+	ErrRecoveryNotFound = errors.New("recovery key not found")
+
+	// ErrInvalidRecoveryKey is returned when an account cannot be recovered with the provided username and recovery key.
+	ErrInvalidRecoveryKey = errors.New("invalid username or recovery key")
+
+	// ErrInvalidIBAN is returned when an IBAN fails structure or checksum validation.
+	ErrInvalidIBAN = errors.New("invalid iban")
+
+	// ErrInvalidBIC is returned when a BIC (SWIFT code) has an invalid format.
+	ErrInvalidBIC = errors.New("invalid bic")
+
+	// ErrInvalidCountryCode is returned when a value is not an ISO 3166-1 alpha-2 country code.
+	ErrInvalidCountryCode = errors.New("invalid country code")
+
+	// ErrInvalidDate is returned when a date cannot be parsed or is out of the allowed range.
+	ErrInvalidDate = errors.New("invalid date")
+
+	// ErrInvalidDocumentType is returned when an identity document has an unsupported type.
+	ErrInvalidDocumentType = errors.New("invalid document type")
+
+	// ErrInvalidDocumentNumber is returned when an identity document number is missing.
+	ErrInvalidDocumentNumber = errors.New("invalid document number")
+
+	// ErrInvalidCardNumber is returned when a card number is malformed or fails the Luhn check.
+	ErrInvalidCardNumber = errors.New("invalid card number")
+
+	// ErrInvalidCVV is returned when a card security code has an invalid format.
+	ErrInvalidCVV = errors.New("invalid cvv")
+
+	// ErrCardExpired is returned when a card expiry date is in the past.
+	ErrCardExpired = errors.New("card expired")
+
+	// ErrCardNotFound is returned when a requested card does not exist or belongs to another user.
+	ErrCardNotFound = errors.New("card not found")
+
+	// ErrRecordsNotFound is returned when a user has no records of a kind.
+	ErrRecordsNotFound = errors.New("records not found")
+
+	// ErrInvalidPasswordPolicy is returned when a password or passphrase generation policy cannot be satisfied.
+	ErrInvalidPasswordPolicy = errors.New("invalid password policy")
+
+	// ErrBreachCheckUnavailable is returned when no breached-password index is configured.
+	ErrBreachCheckUnavailable = errors.New("breached password check is not configured")
+
+	// ErrInvalidURI is returned when a website URI or its match mode is invalid.
+	ErrInvalidURI = errors.New("invalid uri")
+
+	// ErrReencryptionJobNotFound is returned when the re-encryption job has never been started.
+	ErrReencryptionJobNotFound = errors.New("re-encryption job not found")
+
+	// ErrReencryptionJobRunning is returned when the re-encryption job is started while it is running.
+	ErrReencryptionJobRunning = errors.New("re-encryption job is already running")
+
+	// ErrReencryptionJobNotRunning is returned when the re-encryption job is paused or throttled while it is not running.
+	ErrReencryptionJobNotRunning = errors.New("re-encryption job is not running")
+
+	// ErrInvalidThrottle is returned when a re-encryption batch size or delay is negative or too large.
+	ErrInvalidThrottle = errors.New("invalid throttle")
+
 	// ErrInternalServer is a string error message for internal server errors.
 	// This is not an error type but a message that can be used in responses.
 	ErrInternalServer = "internal server error"
@@ -31,4 +111,176 @@ var (
 	// ErrInvalidRequestBody is a string error message for invalid request bodies.
 	// This is not an error type but a message that can be used in responses.
 	ErrInvalidRequestBody = "invalid request body"
+
+	// ErrKeyPairNotFound is returned when a user has no key pair for sharing yet, or it cannot be unwrapped
+	// with the vault key of their session; it is created or fixed on their next login.
+	ErrKeyPairNotFound = errors.New("key pair not found")
+
+	// ErrItemKeyNotFound is returned when a vault item is not shared and has no item key.
+	ErrItemKeyNotFound = errors.New("item key not found")
+
+	// ErrInvalidShare is returned when a share request has an unknown item type or permission, or targets its owner.
+	ErrInvalidShare = errors.New("invalid share")
+
+	// ErrShareRecipientNotFound is returned when the user an item is shared with does not exist or cannot receive shares.
+	ErrShareRecipientNotFound = errors.New("share recipient not found")
+
+	// ErrShareItemNotFound is returned when the item to share does not exist or belongs to another user.
+	ErrShareItemNotFound = errors.New("item to share not found")
+
+	// ErrShareNotFound is returned when a share does not exist or is not visible to the user.
+	ErrShareNotFound = errors.New("share not found")
+
+	// ErrShareReadOnly is returned when a recipient changes an item shared with read-only permission.
+	ErrShareReadOnly = errors.New("item is shared read-only")
+
+	// ErrInvalidSharedField is returned when a shared item is updated with a field it has no encrypted column for.
+	ErrInvalidSharedField = errors.New("invalid shared item field")
+
+	// ErrInvalidOrg is returned when an organisation, collection or member request has an empty name,
+	// a collection name already in use, an unknown role or item type, or an item field the item has no
+	// encrypted column for.
+	ErrInvalidOrg = errors.New("invalid organisation request")
+
+	// ErrOrgNotFound is returned when an organisation does not exist or the user is not a member of it.
+	ErrOrgNotFound = errors.New("organisation not found")
+
+	// ErrOrgForbidden is returned when a member's role does not allow an organisation operation.
+	ErrOrgForbidden = errors.New("organisation role does not allow this operation")
+
+	// ErrOrgMemberNotFound is returned when a user is not a member of the organisation, or cannot
+	// become one because they do not exist or have no key pair.
+	ErrOrgMemberNotFound = errors.New("organisation member not found")
+
+	// ErrOrgMemberExists is returned when a user added to an organisation is already a member.
+	ErrOrgMemberExists = errors.New("user is already an organisation member")
+
+	// ErrLastOrgOwner is returned when the last owner of an organisation would be removed or demoted.
+	ErrLastOrgOwner = errors.New("organisation must keep an owner")
+
+	// ErrOrgKeyChanged is returned when an organisation key or the item key of a collection item was
+	// rotated, or an item was added to a collection, while a request was processed; it can be retried.
+	ErrOrgKeyChanged = errors.New("organisation key changed")
+
+	// ErrCollectionNotFound is returned when a collection does not exist or is not visible to the user.
+	ErrCollectionNotFound = errors.New("collection not found")
+
+	// ErrCollectionItemNotFound is returned when an item is not in the collection, or an item added to a
+	// collection does not exist or belongs to another user.
+	ErrCollectionItemNotFound = errors.New("collection item not found")
+
+	// ErrItemInCollection is returned when an item added to a collection already is in one.
+	ErrItemInCollection = errors.New("item is already in a collection")
+
+	// ErrInvalidEmergencyAccess is returned when an emergency contact has an unknown access type, a wait
+	// period out of range, or is the grantor.
+	ErrInvalidEmergencyAccess = errors.New("invalid emergency access")
+
+	// ErrEmergencyContactNotFound is returned when the user designated as an emergency contact does not
+	// exist or has no key pair.
+	ErrEmergencyContactNotFound = errors.New("emergency contact not found")
+
+	// ErrEmergencyAccessExists is returned when a user is designated as an emergency contact twice.
+	ErrEmergencyAccessExists = errors.New("emergency contact already exists")
+
+	// ErrEmergencyAccessNotFound is returned when an emergency access does not exist or is not visible to the user.
+	ErrEmergencyAccessNotFound = errors.New("emergency access not found")
+
+	// ErrEmergencyAccessNotApproved is returned when a grantee uses an emergency access that was not
+	// requested, was rejected, or whose wait period has not elapsed.
+	ErrEmergencyAccessNotApproved = errors.New("emergency access not approved")
+
+	// ErrEmergencyTakeoverNotAllowed is returned when a grantee with view access takes over an account.
+	ErrEmergencyTakeoverNotAllowed = errors.New("emergency access does not allow takeover")
+
+	// ErrInvalidRecoveryShares is returned when a recovery key is split with a threshold out of range, or
+	// among officers that are missing, repeated or include the owner.
+	ErrInvalidRecoveryShares = errors.New("invalid recovery shares")
+
+	// ErrRecoveryOfficerNotFound is returned when a user chosen to hold a recovery share does not exist or
+	// has no key pair.
+	ErrRecoveryOfficerNotFound = errors.New("recovery officer not found")
+
+	// ErrRecoverySharesNotFound is returned when a recovery session is started for a user whose recovery
+	// key is not split into shares.
+	ErrRecoverySharesNotFound = errors.New("recovery shares not found")
+
+	// ErrRecoveryShareNotFound is returned when a user submitting a recovery share holds no share of the
+	// recovered account.
+	ErrRecoveryShareNotFound = errors.New("recovery share not found")
+
+	// ErrRecoverySessionNotFound is returned when a recovery session does not exist or has expired.
+	ErrRecoverySessionNotFound = errors.New("recovery session not found")
+
+	// ErrRecoveryShareSubmitted is returned when a recovery share is submitted to a session twice.
+	ErrRecoveryShareSubmitted = errors.New("recovery share already submitted")
+
+	// ErrNotEnoughRecoveryShares is returned when a recovery session is completed with fewer shares than
+	// the threshold.
+	ErrNotEnoughRecoveryShares = errors.New("not enough recovery shares")
+
+	// ErrTOTPNotFound is returned when a user has not enrolled a TOTP authenticator.
+	ErrTOTPNotFound = errors.New("two-factor authentication not enrolled")
+
+	// ErrTwoFactorEnabled is returned when a user who has two-factor authentication enabled enrolls again.
+	ErrTwoFactorEnabled = errors.New("two-factor authentication already enabled")
+
+	// ErrInvalidTwoFactorCode is returned when a TOTP or recovery code is wrong, expired or was used
+	// before.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+	// ErrLoginChallengeNotFound is returned when a login challenge token is malformed, unknown, expired
+	// or was used too many times.
+	ErrLoginChallengeNotFound = errors.New("login challenge not found")
+
+	// ErrLoginLocked is returned when a login is attempted too soon after failed logins, or while the
+	// account is locked; it is wrapped by LoginLockedError.
+	ErrLoginLocked = errors.New("too many failed logins, try again later")
+
+	// ErrWeakPassword is returned when a master password does not meet the password policy; it is
+	// wrapped by PasswordPolicyError.
+	ErrWeakPassword = errors.New("password does not meet the password policy")
 )
+
+// LoginLockedError is returned instead of a login check while a username has to wait after failed
+// logins. It matches ErrLoginLocked with errors.Is.
+type LoginLockedError struct {
+	RetryAfter time.Duration // Time until the next login may be attempted.
+}
+
+// Error returns the message of ErrLoginLocked.
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+// Unwrap returns ErrLoginLocked.
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// PasswordRuleFailure describes a rule of the master password policy a password failed.
+type PasswordRuleFailure struct {
+	Rule    string // Identifier of the rule, e.g. "min_length".
+	Message string // Requirement of the rule, completing the sentence "The password ...".
+}
+
+// PasswordPolicyError is returned when a master password does not meet the password policy. It lists
+// every failed rule and matches ErrWeakPassword with errors.Is.
+type PasswordPolicyError struct {
+	Failures []PasswordRuleFailure // The failed rules.
+}
+
+// Error returns the message of ErrWeakPassword followed by the failed rules.
+func (e *PasswordPolicyError) Error() string {
+	rules := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		rules[i] = failure.Rule
+	}
+
+	return ErrWeakPassword.Error() + ": " + strings.Join(rules, ", ")
+}
+
+// Unwrap returns ErrWeakPassword.
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +22,38 @@ type Config struct {
 	DBURI                string        // URI for connecting to the database.
 	LoggerLevel          string        // Logging level (e.g., DEBUG, INFO, ERROR).
 	Cost                 int           // Cost factor for cryptographic operations (e.g., bcrypt).
+	BreachIndexPath      string        // Path to the binary breached-password index; empty disables the check.
+	BreachCorpusPath     string        // Path to a HIBP-style hash file or directory used to build a missing index.
+	KDFAlgorithm         string        // Vault key derivation function for new keys: "argon2id" or "scrypt".
+	KDFTime              int           // Argon2id iterations.
+	KDFMemory            int           // Argon2id memory in KiB.
+	KDFThreads           int           // Argon2id parallelism.
+	KDFScryptLogN        int           // scrypt cost as log2(N).
+	CipherAlgorithm      string        // Cipher of newly encrypted vault values: "aes-256-gcm" or "xchacha20-poly1305".
+	KeyProvider          string        // Server master key provider: "" (none), "env", "file", "vault" or "pkcs11".
+	ServerKeyFile        string        // Path to the master key ring for the "file" provider.
+	VaultAddress         string        // HashiCorp Vault address for the "vault" provider.
+	VaultToken           string        // HashiCorp Vault token for the "vault" provider.
+	VaultTransitMount    string        // Mount path of the Vault transit secrets engine.
+	VaultTransitKey      string        // Name of the Vault transit key.
+	PKCS11Module         string        // Path to the PKCS#11 module for the "pkcs11" provider.
+	PKCS11TokenLabel     string        // Label of the PKCS#11 token; the first token if empty.
+	PKCS11PIN            string        // User PIN of the PKCS#11 token.
+	PKCS11KeyLabel       string        // Label of the master keys on the PKCS#11 token.
+	ReencryptBatchSize   int           // Rows processed per batch by the re-encryption job.
+	ReencryptBatchDelay  time.Duration // Pause between batches of the re-encryption job.
+	AdminUserIDs         []int64       // IDs of the users allowed to call the admin endpoints.
+	EmergencyWaitDays    int           // Default wait period of emergency access requests, in days.
+	AuthIPBurst          int           // Authentication requests allowed at once per client IP; 0 disables the limit.
+	AuthIPInterval       time.Duration // Time in which one more authentication request per client IP is allowed.
+	AuthUserBurst        int           // Authentication requests allowed at once per username; 0 disables the limit.
+	AuthUserInterval     time.Duration // Time in which one more authentication request per username is allowed.
+	LoginLockoutAttempts int           // Failed logins after which an account is locked; 0 disables the lockout.
+	LoginLockoutDuration time.Duration // Duration of an account lockout, which also caps the login delays.
+	LoginDelayBase       time.Duration // Delay after the first failed login, doubled by every further one; 0 disables the delays.
+	PasswordMinLength    int           // Minimum length of master passwords in characters; 0 disables the rule.
+	PasswordMinEntropy   int           // Minimum estimated entropy of master passwords in bits; 0 disables the rule.
+	PasswordDenylist     bool          // Whether common passwords are rejected as master passwords.
 }
 
 // New initializes and returns a new Config instance by reading values from environment variables.
@@ -37,6 +70,36 @@ func New() (*Config, error) {
 	cfg.RefreshSecret = getStringEnvOrDefault("REFRESH_SECRET", "refresh")
 	cfg.LoggerLevel = getStringEnvOrDefault("LOGGER_LEVEL", "DEBUG")
 	cfg.Cost = getIntEnvOrDefault("COST", 3)
+	cfg.BreachIndexPath = getStringEnvOrDefault("BREACH_INDEX_PATH", "")
+	cfg.BreachCorpusPath = getStringEnvOrDefault("BREACH_CORPUS_PATH", "")
+	cfg.KDFAlgorithm = getStringEnvOrDefault("KDF_ALGORITHM", "argon2id")
+	cfg.KDFTime = getIntEnvOrDefault("KDF_TIME", 3)
+	cfg.KDFMemory = getIntEnvOrDefault("KDF_MEMORY", 64*1024)
+	cfg.KDFThreads = getIntEnvOrDefault("KDF_THREADS", 2)
+	cfg.KDFScryptLogN = getIntEnvOrDefault("KDF_SCRYPT_LOG_N", 15)
+	cfg.CipherAlgorithm = getStringEnvOrDefault("CIPHER_ALGORITHM", "aes-256-gcm")
+	cfg.KeyProvider = getStringEnvOrDefault("KEY_PROVIDER", "")
+	cfg.ServerKeyFile = getStringEnvOrDefault("SERVER_KEY_FILE", "")
+	cfg.VaultAddress = getStringEnvOrDefault("VAULT_ADDR", "")
+	cfg.VaultToken = getStringEnvOrDefault("VAULT_TOKEN", "")
+	cfg.VaultTransitMount = getStringEnvOrDefault("VAULT_TRANSIT_MOUNT", "transit")
+	cfg.VaultTransitKey = getStringEnvOrDefault("VAULT_TRANSIT_KEY", "gophkeeper")
+	cfg.PKCS11Module = getStringEnvOrDefault("PKCS11_MODULE", "")
+	cfg.PKCS11TokenLabel = getStringEnvOrDefault("PKCS11_TOKEN_LABEL", "")
+	cfg.PKCS11PIN = getStringEnvOrDefault("PKCS11_PIN", "")
+	cfg.PKCS11KeyLabel = getStringEnvOrDefault("PKCS11_KEY_LABEL", "gophkeeper")
+	cfg.ReencryptBatchSize = getIntEnvOrDefault("REENCRYPT_BATCH_SIZE", 100)
+	cfg.EmergencyWaitDays = getIntEnvOrDefault("EMERGENCY_WAIT_DAYS", 7)
+	cfg.AuthIPBurst = getIntEnvOrDefault("AUTH_IP_BURST", 20)
+	cfg.AuthUserBurst = getIntEnvOrDefault("AUTH_USER_BURST", 5)
+	cfg.LoginLockoutAttempts = getIntEnvOrDefault("LOGIN_LOCKOUT_ATTEMPTS", 10)
+	cfg.PasswordMinLength = getIntEnvOrDefault("PASSWORD_MIN_LENGTH", 12)
+	cfg.PasswordMinEntropy = getIntEnvOrDefault("PASSWORD_MIN_ENTROPY", 50)
+
+	// A master key set directly in the environment keeps working without KEY_PROVIDER.
+	if cfg.KeyProvider == "" && os.Getenv("SERVER_MASTER_KEY") != "" {
+		cfg.KeyProvider = "env"
+	}
 
 	// Parse token durations from environment variables.
 	durationAccessSecret := getStringEnvOrDefault("DURATION_ACCESS", "24h")
@@ -53,6 +116,64 @@ func New() (*Config, error) {
 	}
 	cfg.DurationRefreshToken = parsedDurationRefresh
 
+	if cfg.ReencryptBatchSize < 1 {
+		return nil, fmt.Errorf("invalid re-encryption batch size")
+	}
+
+	parsedReencryptDelay, err := time.ParseDuration(getStringEnvOrDefault("REENCRYPT_BATCH_DELAY", "100ms"))
+	if err != nil || parsedReencryptDelay < 0 {
+		return nil, fmt.Errorf("invalid re-encryption batch delay")
+	}
+	cfg.ReencryptBatchDelay = parsedReencryptDelay
+
+	if cfg.EmergencyWaitDays < 0 {
+		return nil, fmt.Errorf("invalid emergency wait days")
+	}
+
+	if cfg.AuthIPBurst < 0 || cfg.AuthUserBurst < 0 || cfg.LoginLockoutAttempts < 0 {
+		return nil, fmt.Errorf("invalid authentication rate limits")
+	}
+
+	parsedAuthIPInterval, err := time.ParseDuration(getStringEnvOrDefault("AUTH_IP_INTERVAL", "3s"))
+	if err != nil || parsedAuthIPInterval < 0 {
+		return nil, fmt.Errorf("invalid authentication rate limit interval per ip")
+	}
+	cfg.AuthIPInterval = parsedAuthIPInterval
+
+	parsedAuthUserInterval, err := time.ParseDuration(getStringEnvOrDefault("AUTH_USER_INTERVAL", "12s"))
+	if err != nil || parsedAuthUserInterval < 0 {
+		return nil, fmt.Errorf("invalid authentication rate limit interval per username")
+	}
+	cfg.AuthUserInterval = parsedAuthUserInterval
+
+	parsedLockoutDuration, err := time.ParseDuration(getStringEnvOrDefault("LOGIN_LOCKOUT_DURATION", "15m"))
+	if err != nil || parsedLockoutDuration < 0 {
+		return nil, fmt.Errorf("invalid login lockout duration")
+	}
+	cfg.LoginLockoutDuration = parsedLockoutDuration
+
+	parsedDelayBase, err := time.ParseDuration(getStringEnvOrDefault("LOGIN_DELAY_BASE", "1s"))
+	if err != nil || parsedDelayBase < 0 {
+		return nil, fmt.Errorf("invalid login delay")
+	}
+	cfg.LoginDelayBase = parsedDelayBase
+
+	if cfg.PasswordMinLength < 0 || cfg.PasswordMinEntropy < 0 {
+		return nil, fmt.Errorf("invalid password policy")
+	}
+
+	passwordDenylist, err := strconv.ParseBool(getStringEnvOrDefault("PASSWORD_DENYLIST", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid password denylist setting")
+	}
+	cfg.PasswordDenylist = passwordDenylist
+
+	adminUserIDs, err := getInt64ListEnv("ADMIN_USER_IDS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin user ids")
+	}
+	cfg.AdminUserIDs = adminUserIDs
+
 	return &cfg, nil
 }
 
@@ -84,3 +205,23 @@ func getIntEnvOrDefault(envName string, defaultValue int) int {
 
 	return intValue
 }
+
+// getInt64ListEnv retrieves the value of an environment variable as a comma-separated list of integers.
+// An unset or empty variable yields an empty list.
+func getInt64ListEnv(envName string) ([]int64, error) {
+	var values []int64
+	for _, field := range strings.Split(os.Getenv(envName), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
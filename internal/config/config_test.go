@@ -18,6 +18,39 @@ func TestNewConfigWithDefaultValues(t *testing.T) {
 	t.Setenv("COST", "")
 	t.Setenv("DURATION_ACCESS", "")
 	t.Setenv("DURATION_REFRESH", "")
+	t.Setenv("BREACH_INDEX_PATH", "")
+	t.Setenv("BREACH_CORPUS_PATH", "")
+	t.Setenv("KDF_ALGORITHM", "")
+	t.Setenv("KDF_TIME", "")
+	t.Setenv("KDF_MEMORY", "")
+	t.Setenv("KDF_THREADS", "")
+	t.Setenv("KDF_SCRYPT_LOG_N", "")
+	t.Setenv("CIPHER_ALGORITHM", "")
+	t.Setenv("SERVER_MASTER_KEY", "")
+	t.Setenv("KEY_PROVIDER", "")
+	t.Setenv("SERVER_KEY_FILE", "")
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("VAULT_TRANSIT_MOUNT", "")
+	t.Setenv("VAULT_TRANSIT_KEY", "")
+	t.Setenv("PKCS11_MODULE", "")
+	t.Setenv("PKCS11_TOKEN_LABEL", "")
+	t.Setenv("PKCS11_PIN", "")
+	t.Setenv("PKCS11_KEY_LABEL", "")
+	t.Setenv("REENCRYPT_BATCH_SIZE", "")
+	t.Setenv("REENCRYPT_BATCH_DELAY", "")
+	t.Setenv("ADMIN_USER_IDS", "")
+	t.Setenv("EMERGENCY_WAIT_DAYS", "")
+	t.Setenv("AUTH_IP_BURST", "")
+	t.Setenv("AUTH_IP_INTERVAL", "")
+	t.Setenv("AUTH_USER_BURST", "")
+	t.Setenv("AUTH_USER_INTERVAL", "")
+	t.Setenv("LOGIN_LOCKOUT_ATTEMPTS", "")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "")
+	t.Setenv("LOGIN_DELAY_BASE", "")
+	t.Setenv("PASSWORD_MIN_LENGTH", "")
+	t.Setenv("PASSWORD_MIN_ENTROPY", "")
+	t.Setenv("PASSWORD_DENYLIST", "")
 
 	cfg, err := New()
 	require.NoError(t, err, "Should create config without error")
@@ -29,6 +62,38 @@ func TestNewConfigWithDefaultValues(t *testing.T) {
 	assert.Equal(t, "refresh", cfg.RefreshSecret)
 	assert.Equal(t, "DEBUG", cfg.LoggerLevel)
 	assert.Equal(t, 3, cfg.Cost)
+	assert.Empty(t, cfg.BreachIndexPath)
+	assert.Empty(t, cfg.BreachCorpusPath)
+	assert.Equal(t, "argon2id", cfg.KDFAlgorithm)
+	assert.Equal(t, 3, cfg.KDFTime)
+	assert.Equal(t, 65536, cfg.KDFMemory)
+	assert.Equal(t, 2, cfg.KDFThreads)
+	assert.Equal(t, 15, cfg.KDFScryptLogN)
+	assert.Equal(t, "aes-256-gcm", cfg.CipherAlgorithm)
+	assert.Empty(t, cfg.KeyProvider)
+	assert.Empty(t, cfg.ServerKeyFile)
+	assert.Empty(t, cfg.VaultAddress)
+	assert.Empty(t, cfg.VaultToken)
+	assert.Equal(t, "transit", cfg.VaultTransitMount)
+	assert.Equal(t, "gophkeeper", cfg.VaultTransitKey)
+	assert.Empty(t, cfg.PKCS11Module)
+	assert.Empty(t, cfg.PKCS11TokenLabel)
+	assert.Empty(t, cfg.PKCS11PIN)
+	assert.Equal(t, "gophkeeper", cfg.PKCS11KeyLabel)
+	assert.Equal(t, 100, cfg.ReencryptBatchSize)
+	assert.Equal(t, 100*time.Millisecond, cfg.ReencryptBatchDelay)
+	assert.Empty(t, cfg.AdminUserIDs)
+	assert.Equal(t, 7, cfg.EmergencyWaitDays)
+	assert.Equal(t, 20, cfg.AuthIPBurst)
+	assert.Equal(t, 3*time.Second, cfg.AuthIPInterval)
+	assert.Equal(t, 5, cfg.AuthUserBurst)
+	assert.Equal(t, 12*time.Second, cfg.AuthUserInterval)
+	assert.Equal(t, 10, cfg.LoginLockoutAttempts)
+	assert.Equal(t, 15*time.Minute, cfg.LoginLockoutDuration)
+	assert.Equal(t, time.Second, cfg.LoginDelayBase)
+	assert.Equal(t, 12, cfg.PasswordMinLength)
+	assert.Equal(t, 50, cfg.PasswordMinEntropy)
+	assert.True(t, cfg.PasswordDenylist)
 
 	expectedDurationAccess := 24 * time.Hour
 	assert.Equal(t, expectedDurationAccess, cfg.DurationAccessToken)
@@ -47,6 +112,38 @@ func TestNewConfigWithEnvValues(t *testing.T) {
 	t.Setenv("COST", "10")
 	t.Setenv("DURATION_ACCESS", "48h")
 	t.Setenv("DURATION_REFRESH", "1000h")
+	t.Setenv("BREACH_INDEX_PATH", "/var/lib/gophkeeper/pwned.idx")
+	t.Setenv("BREACH_CORPUS_PATH", "/var/lib/gophkeeper/pwned-passwords")
+	t.Setenv("KDF_ALGORITHM", "scrypt")
+	t.Setenv("KDF_TIME", "4")
+	t.Setenv("KDF_MEMORY", "131072")
+	t.Setenv("KDF_THREADS", "4")
+	t.Setenv("KDF_SCRYPT_LOG_N", "17")
+	t.Setenv("CIPHER_ALGORITHM", "xchacha20-poly1305")
+	t.Setenv("KEY_PROVIDER", "vault")
+	t.Setenv("SERVER_KEY_FILE", "/etc/gophkeeper/master.keys")
+	t.Setenv("VAULT_ADDR", "http://vault:8200")
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Setenv("VAULT_TRANSIT_MOUNT", "kms")
+	t.Setenv("VAULT_TRANSIT_KEY", "vault-key")
+	t.Setenv("PKCS11_MODULE", "/usr/lib/softhsm/libsofthsm2.so")
+	t.Setenv("PKCS11_TOKEN_LABEL", "gophkeeper-token")
+	t.Setenv("PKCS11_PIN", "1234")
+	t.Setenv("PKCS11_KEY_LABEL", "master")
+	t.Setenv("REENCRYPT_BATCH_SIZE", "500")
+	t.Setenv("REENCRYPT_BATCH_DELAY", "1s")
+	t.Setenv("ADMIN_USER_IDS", "1, 42")
+	t.Setenv("EMERGENCY_WAIT_DAYS", "14")
+	t.Setenv("AUTH_IP_BURST", "50")
+	t.Setenv("AUTH_IP_INTERVAL", "1s")
+	t.Setenv("AUTH_USER_BURST", "3")
+	t.Setenv("AUTH_USER_INTERVAL", "1m")
+	t.Setenv("LOGIN_LOCKOUT_ATTEMPTS", "5")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "1h")
+	t.Setenv("LOGIN_DELAY_BASE", "500ms")
+	t.Setenv("PASSWORD_MIN_LENGTH", "16")
+	t.Setenv("PASSWORD_MIN_ENTROPY", "70")
+	t.Setenv("PASSWORD_DENYLIST", "false")
 
 	cfg, err := New()
 	require.NoError(t, err, "Should create config without error")
@@ -58,6 +155,38 @@ func TestNewConfigWithEnvValues(t *testing.T) {
 	assert.Equal(t, "customRefreshSecret", cfg.RefreshSecret)
 	assert.Equal(t, "INFO", cfg.LoggerLevel)
 	assert.Equal(t, 10, cfg.Cost)
+	assert.Equal(t, "/var/lib/gophkeeper/pwned.idx", cfg.BreachIndexPath)
+	assert.Equal(t, "/var/lib/gophkeeper/pwned-passwords", cfg.BreachCorpusPath)
+	assert.Equal(t, "scrypt", cfg.KDFAlgorithm)
+	assert.Equal(t, 4, cfg.KDFTime)
+	assert.Equal(t, 131072, cfg.KDFMemory)
+	assert.Equal(t, 4, cfg.KDFThreads)
+	assert.Equal(t, 17, cfg.KDFScryptLogN)
+	assert.Equal(t, "xchacha20-poly1305", cfg.CipherAlgorithm)
+	assert.Equal(t, "vault", cfg.KeyProvider)
+	assert.Equal(t, "/etc/gophkeeper/master.keys", cfg.ServerKeyFile)
+	assert.Equal(t, "http://vault:8200", cfg.VaultAddress)
+	assert.Equal(t, "root", cfg.VaultToken)
+	assert.Equal(t, "kms", cfg.VaultTransitMount)
+	assert.Equal(t, "vault-key", cfg.VaultTransitKey)
+	assert.Equal(t, "/usr/lib/softhsm/libsofthsm2.so", cfg.PKCS11Module)
+	assert.Equal(t, "gophkeeper-token", cfg.PKCS11TokenLabel)
+	assert.Equal(t, "1234", cfg.PKCS11PIN)
+	assert.Equal(t, "master", cfg.PKCS11KeyLabel)
+	assert.Equal(t, 500, cfg.ReencryptBatchSize)
+	assert.Equal(t, time.Second, cfg.ReencryptBatchDelay)
+	assert.Equal(t, []int64{1, 42}, cfg.AdminUserIDs)
+	assert.Equal(t, 14, cfg.EmergencyWaitDays)
+	assert.Equal(t, 50, cfg.AuthIPBurst)
+	assert.Equal(t, time.Second, cfg.AuthIPInterval)
+	assert.Equal(t, 3, cfg.AuthUserBurst)
+	assert.Equal(t, time.Minute, cfg.AuthUserInterval)
+	assert.Equal(t, 5, cfg.LoginLockoutAttempts)
+	assert.Equal(t, time.Hour, cfg.LoginLockoutDuration)
+	assert.Equal(t, 500*time.Millisecond, cfg.LoginDelayBase)
+	assert.Equal(t, 16, cfg.PasswordMinLength)
+	assert.Equal(t, 70, cfg.PasswordMinEntropy)
+	assert.False(t, cfg.PasswordDenylist)
 
 	expectedDurationAccess := 48 * time.Hour
 	assert.Equal(t, expectedDurationAccess, cfg.DurationAccessToken)
@@ -66,6 +195,15 @@ func TestNewConfigWithEnvValues(t *testing.T) {
 	assert.Equal(t, expectedDurationRefresh, cfg.DurationRefreshToken)
 }
 
+func TestNewConfig_ServerMasterKeyImpliesEnvProvider(t *testing.T) {
+	t.Setenv("KEY_PROVIDER", "")
+	t.Setenv("SERVER_MASTER_KEY", "c2VydmVyLW1hc3Rlci1rZXktMzItYnl0ZXMtbG9uZyE=")
+
+	cfg, err := New()
+	require.NoError(t, err)
+	assert.Equal(t, "env", cfg.KeyProvider)
+}
+
 func TestNewConfigWithInvalidDuration(t *testing.T) {
 	t.Setenv("DURATION_ACCESS", "invalidDuration")
 	t.Setenv("DURATION_REFRESH", "invalidDuration")
@@ -75,6 +213,67 @@ func TestNewConfigWithInvalidDuration(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid access token duration")
 }
 
+func TestNewConfigWithInvalidReencryptThrottle(t *testing.T) {
+	t.Setenv("REENCRYPT_BATCH_SIZE", "0")
+
+	_, err := New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid re-encryption batch size")
+
+	t.Setenv("REENCRYPT_BATCH_SIZE", "")
+	t.Setenv("REENCRYPT_BATCH_DELAY", "-1s")
+
+	_, err = New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid re-encryption batch delay")
+}
+
+func TestNewConfigWithInvalidAdminUserIDs(t *testing.T) {
+	t.Setenv("ADMIN_USER_IDS", "1,admin")
+
+	_, err := New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid admin user ids")
+}
+
+func TestNewConfigWithInvalidEmergencyWaitDays(t *testing.T) {
+	t.Setenv("EMERGENCY_WAIT_DAYS", "-1")
+
+	_, err := New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid emergency wait days")
+}
+
+func TestNewConfigWithInvalidAuthThrottling(t *testing.T) {
+	t.Setenv("AUTH_USER_BURST", "-1")
+
+	_, err := New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid authentication rate limits")
+
+	t.Setenv("AUTH_USER_BURST", "")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "forever")
+
+	_, err = New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid login lockout duration")
+}
+
+func TestNewConfigWithInvalidPasswordPolicy(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "-8")
+
+	_, err := New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid password policy")
+
+	t.Setenv("PASSWORD_MIN_LENGTH", "")
+	t.Setenv("PASSWORD_DENYLIST", "maybe")
+
+	_, err = New()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid password denylist setting")
+}
+
 func TestGetStringEnvOrDefault(t *testing.T) {
 	assert.Equal(t, "localhost:9000", getStringEnvOrDefault("BFF_ADDRESS", "localhost:9000"))
 	assert.Equal(t, "localhost:8080", getStringEnvOrDefault("SERVER_ADDRESS", "localhost:8080"))
@@ -0,0 +1,159 @@
+package cryptox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// boundPrefix marks a text ciphertext whose associated data is its Binding, written with implied
+// AES-256-GCM before ciphertexts carried a header. Standard base64 has no '.', so legacy unbound
+// ciphertexts never carry it.
+const boundPrefix = "a1."
+
+// boundBinaryMagic marks a binary ciphertext in the headerless bound format; see boundPrefix.
+var boundBinaryMagic = []byte{0x00, 'g', 'k', 'a'}
+
+// Binding identifies where a ciphertext is stored. It is authenticated as AES-GCM associated data,
+// so a ciphertext only decrypts in the place it was written for: moving it to another column,
+// row or user makes decryption fail.
+type Binding struct {
+	UserID   int64  // Owner of the item.
+	ItemType string // Kind of item; the name of the table it is stored in.
+	ItemID   int64  // ID of the item.
+	Field    string // Encrypted field; the name of the column it is stored in.
+}
+
+// AAD returns the associated data encoding of the binding.
+func (b Binding) AAD() []byte {
+	return []byte("gophkeeper/v1|" +
+		strconv.FormatInt(b.UserID, 10) + "|" +
+		b.ItemType + "|" +
+		strconv.FormatInt(b.ItemID, 10) + "|" +
+		b.Field)
+}
+
+// EncryptBound encrypts a plaintext string bound to its storage location into a self-describing
+// ciphertext (see CiphertextHeader) with the module's cipher. Only keys produced by EncodeKey are
+// accepted: legacy secret phrases still decrypt old data, but nothing new is encrypted with them.
+//
+// Parameters:
+//   - plaintext: The value to encrypt.
+//   - key: The vault key.
+//   - binding: Where the ciphertext is stored.
+//
+// Returns:
+//   - The ciphertext in its stored string form, or ErrInvalidKey if the key is not an encoded key.
+func (c *CryptoModule) EncryptBound(plaintext, key string, binding Binding) (string, error) {
+	keyBytes, err := decodeKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := sealCiphertext(c.cipherName, keyBytes, []byte(plaintext), binding.AAD())
+	if err != nil {
+		return "", err
+	}
+
+	return cipherTextPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptBound decrypts a ciphertext produced by EncryptBound for the same binding, whatever
+// cipher it was written with. Bound ciphertexts without a header are decrypted with AES-256-GCM,
+// and ciphertexts written before bindings were introduced are still decrypted without associated
+// data, so that existing vaults can be migrated; see IsBound.
+//
+// Returns:
+//   - The plaintext, ErrKeyMismatch if the ciphertext was encrypted with another key, or an error
+//     if the ciphertext was moved from another location or it has been tampered with.
+func (c *CryptoModule) DecryptBound(ciphertext, key string, binding Binding) (string, error) {
+	encoded, tagged := strings.CutPrefix(ciphertext, cipherTextPrefix)
+	if !tagged {
+		var bound bool
+		if encoded, bound = strings.CutPrefix(ciphertext, boundPrefix); !bound {
+			return c.Decrypt(ciphertext, key)
+		}
+	}
+
+	keyBytes, err := c.deriveKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	var plaintext []byte
+	if tagged {
+		plaintext, err = openCiphertext(keyBytes, data, binding.AAD())
+	} else {
+		plaintext, err = open(keyBytes, data, binding.AAD())
+	}
+	if errors.Is(err, ErrKeyMismatch) || errors.Is(err, ErrUnsupportedCipher) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("cipher: message authentication failed: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// EncryptBinaryDataBound encrypts binary data bound to its storage location into a self-describing
+// ciphertext with the module's cipher. Legacy secret phrases are rejected as in EncryptBound.
+//
+// Returns:
+//   - The ciphertext, or ErrInvalidKey if the key is not an encoded key.
+func (c *CryptoModule) EncryptBinaryDataBound(plaintext []byte, key string, binding Binding) ([]byte, error) {
+	keyBytes, err := decodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return sealCiphertext(c.cipherName, keyBytes, plaintext, binding.AAD())
+}
+
+// DecryptBinaryDataBound decrypts binary data produced by EncryptBinaryDataBound for the same binding.
+// Older formats are decrypted as in DecryptBound.
+//
+// Returns:
+//   - The plaintext, ErrKeyMismatch if the ciphertext was encrypted with another key, or an error
+//     if the ciphertext was moved or tampered with.
+func (c *CryptoModule) DecryptBinaryDataBound(ciphertext []byte, key string, binding Binding) ([]byte, error) {
+	tagged := bytes.HasPrefix(ciphertext, cipherMagic)
+	data, bound := bytes.CutPrefix(ciphertext, boundBinaryMagic)
+	if !tagged && !bound {
+		return c.DecryptBinaryData(ciphertext, key)
+	}
+
+	keyBytes, err := c.deriveKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
+	if tagged {
+		plaintext, err = openCiphertext(keyBytes, ciphertext, binding.AAD())
+	} else {
+		plaintext, err = open(keyBytes, data, binding.AAD())
+	}
+	if errors.Is(err, ErrKeyMismatch) || errors.Is(err, ErrUnsupportedCipher) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cipher: message authentication failed: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// IsBound reports whether a text ciphertext was produced by EncryptBound, in the current or
+// the headerless format.
+func IsBound(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, cipherTextPrefix) || strings.HasPrefix(ciphertext, boundPrefix)
+}
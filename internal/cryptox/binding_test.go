@@ -0,0 +1,94 @@
+package cryptox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoModule_EncryptBound(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	key, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	cvv := Binding{UserID: 1, ItemType: "cards", ItemID: 10, Field: "cvv"}
+
+	encrypted, err := cryptoModule.EncryptBound("123", key, cvv)
+	require.NoError(t, err)
+	assert.True(t, IsBound(encrypted))
+
+	decrypted, err := cryptoModule.DecryptBound(encrypted, key, cvv)
+	require.NoError(t, err)
+	assert.Equal(t, "123", decrypted)
+
+	for name, moved := range map[string]Binding{
+		"other field": {UserID: 1, ItemType: "cards", ItemID: 10, Field: "num"},
+		"other item":  {UserID: 1, ItemType: "cards", ItemID: 11, Field: "cvv"},
+		"other user":  {UserID: 2, ItemType: "cards", ItemID: 10, Field: "cvv"},
+		"other type":  {UserID: 1, ItemType: "notes", ItemID: 10, Field: "cvv"},
+	} {
+		_, err := cryptoModule.DecryptBound(encrypted, key, moved)
+		assert.Error(t, err, "A ciphertext moved to %s should not decrypt", name)
+	}
+}
+
+func TestCryptoModule_EncryptBinaryDataBound(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	key, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	file := Binding{UserID: 1, ItemType: "binary_data", ItemID: 3, Field: "binary_data"}
+
+	encrypted, err := cryptoModule.EncryptBinaryDataBound([]byte{1, 2, 3}, key, file)
+	require.NoError(t, err)
+
+	decrypted, err := cryptoModule.DecryptBinaryDataBound(encrypted, key, file)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, decrypted)
+
+	_, err = cryptoModule.DecryptBinaryDataBound(encrypted, key, Binding{UserID: 1, ItemType: "binary_data", ItemID: 4, Field: "binary_data"})
+	assert.Error(t, err)
+}
+
+func TestCryptoModule_EncryptBound_RejectsLegacyKeys(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	binding := Binding{UserID: 1, ItemType: "notes", ItemID: 1, Field: "text_data"}
+
+	for _, key := range []string{"", "legacy phrase", keyPrefix + "tooshort"} {
+		_, err := cryptoModule.EncryptBound("secret", key, binding)
+		assert.ErrorIs(t, err, ErrInvalidKey, "%q should not encrypt new data", key)
+
+		_, err = cryptoModule.EncryptBinaryDataBound([]byte("secret"), key, binding)
+		assert.ErrorIs(t, err, ErrInvalidKey, "%q should not encrypt new data", key)
+	}
+
+	legacy, err := cryptoModule.Encrypt("old note", "legacy phrase")
+	require.NoError(t, err)
+	decrypted, err := cryptoModule.DecryptBound(legacy, "legacy phrase", binding)
+	require.NoError(t, err, "Data written with a legacy phrase should still decrypt for migration")
+	assert.Equal(t, "old note", decrypted)
+}
+
+func TestCryptoModule_DecryptBound_Legacy(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	key, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	binding := Binding{UserID: 1, ItemType: "notes", ItemID: 1, Field: "text_data"}
+
+	legacy, err := cryptoModule.Encrypt("old note", key)
+	require.NoError(t, err)
+	assert.False(t, IsBound(legacy))
+
+	decrypted, err := cryptoModule.DecryptBound(legacy, key, binding)
+	require.NoError(t, err, "Unbound ciphertexts should still decrypt for migration")
+	assert.Equal(t, "old note", decrypted)
+
+	legacyBinary, err := cryptoModule.EncryptBinaryData([]byte("old file"), key)
+	require.NoError(t, err)
+
+	decryptedBinary, err := cryptoModule.DecryptBinaryDataBound(legacyBinary, key, binding)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old file"), decryptedBinary)
+}
@@ -0,0 +1,222 @@
+package cryptox
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Supported ciphers of vault ciphertexts.
+const (
+	CipherAES256GCM         = "aes-256-gcm"
+	CipherXChaCha20Poly1305 = "xchacha20-poly1305"
+)
+
+// Algorithm IDs stored in ciphertext headers. IDs are never reused.
+const (
+	algAES256GCM         byte = 1
+	algXChaCha20Poly1305 byte = 2
+)
+
+// cipherVersion is the version of the ciphertext header written by sealCiphertext.
+const cipherVersion byte = 1
+
+// cipherMagic starts every self-describing ciphertext. Legacy untagged ciphertexts start with a
+// random nonce, so they are told apart by a magic value rather than by the version byte alone.
+var cipherMagic = []byte{0x00, 'g', 'k', 'e'}
+
+// cipherHeaderSize is the size of the header: magic, version, algorithm ID and key ID.
+const cipherHeaderSize = 4 + 1 + 1 + 4
+
+// cipherTextPrefix marks the string form of a self-describing ciphertext: the prefix followed by
+// the standard base64 encoding of its binary form.
+const cipherTextPrefix = "e."
+
+// ErrUnsupportedCipher is returned for an unknown cipher name, algorithm ID or header version.
+var ErrUnsupportedCipher = errors.New("unsupported cipher")
+
+// ErrKeyMismatch is returned when a ciphertext was encrypted with a different key than the one given.
+var ErrKeyMismatch = errors.New("ciphertext was encrypted with a different key")
+
+// CiphertextHeader describes a self-describing ciphertext. The header is written in clear before
+// the nonce and is authenticated together with the caller's associated data:
+//
+//	magic "\x00gke" | version (1 byte) | algorithm ID (1 byte) | key ID (4 bytes, big-endian) | nonce | sealed data
+type CiphertextHeader struct {
+	Version uint8  // Header version.
+	Cipher  string // Cipher name, e.g. CipherAES256GCM.
+	KeyID   uint32 // Fingerprint of the key the value is encrypted with; see KeyID.
+}
+
+// KeyID returns the fingerprint of a key stored in ciphertext headers. It tells which key a value
+// is encrypted with, so that a wrong key is reported as such and values left on an old key can be
+// found, without revealing anything about the key.
+func KeyID(key []byte) uint32 {
+	sum := sha256.Sum256(append([]byte("gophkeeper/key-id|"), key...))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// ParseCiphertextHeader parses the header of a self-describing ciphertext in its binary form.
+//
+// Returns:
+//   - The header, and false if data is a legacy untagged ciphertext.
+//   - ErrUnsupportedCipher if the header is of an unknown version or algorithm.
+func ParseCiphertextHeader(data []byte) (CiphertextHeader, bool, error) {
+	if !bytes.HasPrefix(data, cipherMagic) {
+		return CiphertextHeader{}, false, nil
+	}
+	if len(data) < cipherHeaderSize {
+		return CiphertextHeader{}, true, errors.New("invalid data")
+	}
+
+	header := CiphertextHeader{
+		Version: data[4],
+		KeyID:   binary.BigEndian.Uint32(data[6:cipherHeaderSize]),
+	}
+	if header.Version != cipherVersion {
+		return header, true, fmt.Errorf("%w: header version %d", ErrUnsupportedCipher, header.Version)
+	}
+
+	switch data[5] {
+	case algAES256GCM:
+		header.Cipher = CipherAES256GCM
+	case algXChaCha20Poly1305:
+		header.Cipher = CipherXChaCha20Poly1305
+	default:
+		return header, true, fmt.Errorf("%w: algorithm %d", ErrUnsupportedCipher, data[5])
+	}
+
+	return header, true, nil
+}
+
+// NeedsReencrypt reports whether a vault value in its stored string form is not in the format
+// EncryptBound writes now: it has no header, a header of an older version or another cipher than
+// the module's. Such values can only be re-encrypted with the owner's data key, i.e. on their next
+// login. Empty values are not encrypted and never need it.
+func (c *CryptoModule) NeedsReencrypt(ciphertext string) bool {
+	if ciphertext == "" {
+		return false
+	}
+
+	encoded, tagged := strings.CutPrefix(ciphertext, cipherTextPrefix)
+	if !tagged {
+		return true
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return true
+	}
+
+	return c.NeedsReencryptBinary(data)
+}
+
+// NeedsReencryptBinary reports whether a binary vault value is not in the format
+// EncryptBinaryDataBound writes now; see NeedsReencrypt.
+func (c *CryptoModule) NeedsReencryptBinary(ciphertext []byte) bool {
+	header, ok, err := ParseCiphertextHeader(ciphertext)
+	if !ok || err != nil {
+		return true
+	}
+
+	return header.Version != cipherVersion || header.Cipher != c.cipherName
+}
+
+// cipherID returns the algorithm ID of a cipher name; the empty name is CipherAES256GCM.
+func cipherID(name string) (byte, error) {
+	switch name {
+	case "", CipherAES256GCM:
+		return algAES256GCM, nil
+	case CipherXChaCha20Poly1305:
+		return algXChaCha20Poly1305, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedCipher, name)
+	}
+}
+
+// newAEAD creates the AEAD of a cipher name with the given key.
+func newAEAD(name string, key []byte) (cipher.AEAD, error) {
+	switch name {
+	case CipherAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedCipher, name)
+	}
+}
+
+// sealCiphertext encrypts plaintext with the named cipher into a self-describing ciphertext.
+// The header is authenticated together with aad.
+func sealCiphertext(cipherName string, key, plaintext, aad []byte) ([]byte, error) {
+	id, err := cipherID(cipherName)
+	if err != nil {
+		return nil, err
+	}
+	if cipherName == "" {
+		cipherName = CipherAES256GCM
+	}
+
+	aead, err := newAEAD(cipherName, key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, cipherHeaderSize, cipherHeaderSize+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(header, cipherMagic)
+	header[4] = cipherVersion
+	header[5] = id
+	binary.BigEndian.PutUint32(header[6:], KeyID(key))
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(header, nonce...)
+	return aead.Seal(out, nonce, plaintext, append(header[:cipherHeaderSize:cipherHeaderSize], aad...)), nil
+}
+
+// openCiphertext decrypts a ciphertext produced by sealCiphertext with the same associated data.
+//
+// Returns:
+//   - The plaintext, ErrKeyMismatch if the key ID in the header is not the one of key,
+//     ErrUnsupportedCipher for an unknown header, or an error if authentication fails.
+func openCiphertext(key, data, aad []byte) ([]byte, error) {
+	header, ok, err := ParseCiphertextHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid data")
+	}
+	if header.KeyID != KeyID(key) {
+		return nil, ErrKeyMismatch
+	}
+
+	aead, err := newAEAD(header.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+
+	body := data[cipherHeaderSize:]
+	if len(body) < aead.NonceSize() {
+		return nil, errors.New("invalid data")
+	}
+
+	nonce, sealed := body[:aead.NonceSize()], body[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, append(data[:cipherHeaderSize:cipherHeaderSize], aad...))
+}
@@ -0,0 +1,159 @@
+package cryptox
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoModule_Ciphers(t *testing.T) {
+	binding := Binding{UserID: 1, ItemType: "notes", ItemID: 2, Field: "text_data"}
+
+	for _, name := range []string{CipherAES256GCM, CipherXChaCha20Poly1305} {
+		t.Run(name, func(t *testing.T) {
+			cryptoModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), Cipher: name})
+			require.NoError(t, err)
+
+			key, err := cryptoModule.GenerateDataKey()
+			require.NoError(t, err)
+			keyBytes, err := decodeKey(key)
+			require.NoError(t, err)
+
+			encrypted, err := cryptoModule.EncryptBound("secret note", key, binding)
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(encrypted, cipherTextPrefix))
+
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, cipherTextPrefix))
+			require.NoError(t, err)
+			header, ok, err := ParseCiphertextHeader(data)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, CiphertextHeader{Version: 1, Cipher: name, KeyID: KeyID(keyBytes)}, header)
+
+			// Any module decrypts any cipher: the header names it.
+			decrypted, err := NewCryproModule().DecryptBound(encrypted, key, binding)
+			require.NoError(t, err)
+			assert.Equal(t, "secret note", decrypted)
+
+			blob, err := cryptoModule.EncryptBinaryDataBound([]byte{1, 2, 3}, key, binding)
+			require.NoError(t, err)
+			decryptedBlob, err := NewCryproModule().DecryptBinaryDataBound(blob, key, binding)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{1, 2, 3}, decryptedBlob)
+		})
+	}
+}
+
+func TestCryptoModule_DecryptBound_KeyMismatch(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	binding := Binding{UserID: 1, ItemType: "cards", ItemID: 1, Field: "num"}
+
+	key, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	otherKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	encrypted, err := cryptoModule.EncryptBound("4111111111111111", key, binding)
+	require.NoError(t, err)
+
+	_, err = cryptoModule.DecryptBound(encrypted, otherKey, binding)
+	assert.ErrorIs(t, err, ErrKeyMismatch)
+}
+
+func TestCryptoModule_DecryptBound_TamperedHeader(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	binding := Binding{UserID: 1, ItemType: "cards", ItemID: 1, Field: "num"}
+
+	key, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	blob, err := cryptoModule.EncryptBinaryDataBound([]byte("data"), key, binding)
+	require.NoError(t, err)
+
+	downgraded := append([]byte(nil), blob...)
+	downgraded[5] = algXChaCha20Poly1305
+	_, err = cryptoModule.DecryptBinaryDataBound(downgraded, key, binding)
+	assert.Error(t, err, "A ciphertext whose algorithm was changed should not decrypt")
+
+	unknown := append([]byte(nil), blob...)
+	unknown[5] = 0xff
+	_, err = cryptoModule.DecryptBinaryDataBound(unknown, key, binding)
+	assert.ErrorIs(t, err, ErrUnsupportedCipher)
+
+	future := append([]byte(nil), blob...)
+	future[4] = 2
+	_, err = cryptoModule.DecryptBinaryDataBound(future, key, binding)
+	assert.ErrorIs(t, err, ErrUnsupportedCipher)
+}
+
+func TestCryptoModule_DecryptBound_HeaderlessFormat(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	binding := Binding{UserID: 1, ItemType: "notes", ItemID: 1, Field: "title"}
+
+	key, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	keyBytes, err := decodeKey(key)
+	require.NoError(t, err)
+
+	sealed, err := seal(keyBytes, []byte("old title"), binding.AAD())
+	require.NoError(t, err)
+
+	decrypted, err := cryptoModule.DecryptBound(boundPrefix+base64.StdEncoding.EncodeToString(sealed), key, binding)
+	require.NoError(t, err, "Bound values written before headers were introduced should still decrypt")
+	assert.Equal(t, "old title", decrypted)
+
+	decryptedBlob, err := cryptoModule.DecryptBinaryDataBound(append(append([]byte{}, boundBinaryMagic...), sealed...), key, binding)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old title"), decryptedBlob)
+}
+
+func TestParseCiphertextHeader_Legacy(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	legacy, err := cryptoModule.EncryptBinaryData([]byte("data"), "secret")
+	require.NoError(t, err)
+
+	_, ok, err := ParseCiphertextHeader(legacy)
+	assert.NoError(t, err)
+	assert.False(t, ok, "Untagged ciphertexts have no header")
+}
+
+func TestNewCryptoModule_UnsupportedCipher(t *testing.T) {
+	_, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), Cipher: "des"})
+	assert.ErrorIs(t, err, ErrUnsupportedCipher)
+}
+
+func TestCryptoModule_NeedsReencrypt(t *testing.T) {
+	binding := Binding{UserID: 1, ItemType: "notes", ItemID: 1, Field: "title"}
+	aesModule := NewCryproModule()
+	chachaModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), Cipher: CipherXChaCha20Poly1305})
+	require.NoError(t, err)
+
+	key, err := aesModule.GenerateDataKey()
+	require.NoError(t, err)
+	keyBytes, err := decodeKey(key)
+	require.NoError(t, err)
+
+	current, err := aesModule.EncryptBound("title", key, binding)
+	require.NoError(t, err)
+	assert.False(t, aesModule.NeedsReencrypt(current))
+	assert.True(t, chachaModule.NeedsReencrypt(current), "A value of another cipher should be re-encrypted")
+	assert.False(t, aesModule.NeedsReencrypt(""))
+
+	legacy, err := aesModule.Encrypt("title", key)
+	require.NoError(t, err)
+	assert.True(t, aesModule.NeedsReencrypt(legacy))
+
+	sealed, err := seal(keyBytes, []byte("title"), binding.AAD())
+	require.NoError(t, err)
+	assert.True(t, aesModule.NeedsReencrypt(boundPrefix+base64.StdEncoding.EncodeToString(sealed)))
+
+	blob, err := chachaModule.EncryptBinaryDataBound([]byte("data"), key, binding)
+	require.NoError(t, err)
+	assert.False(t, chachaModule.NeedsReencryptBinary(blob))
+	assert.True(t, aesModule.NeedsReencryptBinary(blob))
+	assert.True(t, aesModule.NeedsReencryptBinary(append(append([]byte{}, boundBinaryMagic...), sealed...)))
+}
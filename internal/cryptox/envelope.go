@@ -0,0 +1,247 @@
+package cryptox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Prefixes of wrapped data keys: wrapped with the key-encryption key only, additionally wrapped
+// with the unversioned server master key of earlier releases (version 1 of a local key ring),
+// or additionally wrapped by a KeyProvider, in which case the prefix is followed by the
+// master key version: "sw2.<version>.<ciphertext>".
+const (
+	wrapPrefix            = "w1."
+	serverWrapPrefix      = "sw1."
+	versionedServerPrefix = "sw2."
+)
+
+// ErrServerKeyRequired is returned when a data key was wrapped with a server master key
+// but the module has no key provider configured.
+var ErrServerKeyRequired = errors.New("server master key required to unwrap data key")
+
+// GenerateDataKey generates a random data encryption key.
+//
+// Returns:
+//   - The key string accepted by Encrypt and Decrypt, or an error if the random source fails.
+func (c *CryptoModule) GenerateDataKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return EncodeKey(key), nil
+}
+
+// WrapKey encrypts a data key with a key-encryption key. If the module has a key provider,
+// the result is wrapped once more with the current server master key version, so the data key
+// can only be recovered with both the user's password and the server key.
+//
+// Parameters:
+//   - dataKey: The data key, as returned by GenerateDataKey.
+//   - kek: The key-encryption key, as returned by DeriveKey.
+//
+// Returns:
+//   - The wrapped key in its stored string form or an error if a key is invalid.
+func (c *CryptoModule) WrapKey(dataKey, kek string) (string, error) {
+	dataKeyBytes, err := decodeKey(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	kekBytes, err := decodeKey(kek)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(kekBytes, dataKeyBytes, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if c.keys == nil {
+		return wrapPrefix + base64.RawStdEncoding.EncodeToString(wrapped), nil
+	}
+
+	wrapped, version, err := c.keys.Encrypt(wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%d.%s", versionedServerPrefix, version, base64.RawStdEncoding.EncodeToString(wrapped)), nil
+}
+
+// UnwrapKey decrypts a data key wrapped by WrapKey.
+//
+// Parameters:
+//   - wrapped: The wrapped key in its stored string form.
+//   - kek: The key-encryption key, as returned by DeriveKey.
+//
+// Returns:
+//   - The data key string accepted by Encrypt and Decrypt.
+//   - ErrServerKeyRequired if the key was wrapped with a server master key but no key provider
+//     is configured, ErrUnknownKeyVersion if the provider no longer holds the master key version,
+//     or an error if the key-encryption key is wrong or the wrapped key is malformed.
+func (c *CryptoModule) UnwrapKey(wrapped, kek string) (string, error) {
+	kekBytes, err := decodeKey(kek)
+	if err != nil {
+		return "", err
+	}
+
+	version, encoded, err := parseWrappedKey(wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	if version > 0 {
+		if c.keys == nil {
+			return "", ErrServerKeyRequired
+		}
+		if data, err = c.keys.Decrypt(data, version); err != nil {
+			return "", err
+		}
+	}
+
+	dataKey, err := open(kekBytes, data, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(dataKey) != KeySize {
+		return "", ErrInvalidKey
+	}
+
+	return EncodeKey(dataKey), nil
+}
+
+// NeedsRewrap reports whether a wrapped data key should be wrapped again: the module has a key
+// provider and the key is not bound to its current master key version. Wrapped keys are checked
+// on login, which is how a master key rotation reaches every user.
+// Errors of the key provider are treated as "no", so that a KMS outage never blocks a login.
+func (c *CryptoModule) NeedsRewrap(wrapped string) bool {
+	if c.keys == nil {
+		return false
+	}
+
+	version, _, err := parseWrappedKey(wrapped)
+	if err != nil {
+		return false
+	}
+
+	current, err := c.keys.CurrentVersion()
+	if err != nil {
+		return false
+	}
+
+	return version < current
+}
+
+// RotateWrappedKey wraps a data key again with the current server master key version without
+// the user's key-encryption key: only the outer server layer is decrypted and encrypted again, so
+// a master key rotation can reach every user in the background instead of on their next login.
+// A key wrapped without a server layer gains one.
+//
+// Parameters:
+//   - wrapped: The wrapped key in its stored string form.
+//
+// Returns:
+//   - The re-wrapped key, or wrapped itself if NeedsRewrap reports it is up to date.
+//   - ErrUnknownKeyVersion if the provider no longer holds the master key version, or an error
+//     if the wrapped key is malformed or the provider fails.
+func (c *CryptoModule) RotateWrappedKey(wrapped string) (string, error) {
+	if !c.NeedsRewrap(wrapped) {
+		return wrapped, nil
+	}
+
+	version, encoded, err := parseWrappedKey(wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	if version > 0 {
+		if data, err = c.keys.Decrypt(data, version); err != nil {
+			return "", err
+		}
+	}
+
+	data, version, err = c.keys.Encrypt(data)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%d.%s", versionedServerPrefix, version, base64.RawStdEncoding.EncodeToString(data)), nil
+}
+
+// parseWrappedKey splits a wrapped key into the server master key version it is bound to
+// (0 if it is not server-bound) and its base64-encoded ciphertext.
+func parseWrappedKey(wrapped string) (int, string, error) {
+	switch {
+	case strings.HasPrefix(wrapped, versionedServerPrefix):
+		v, encoded, ok := strings.Cut(strings.TrimPrefix(wrapped, versionedServerPrefix), ".")
+		version, err := strconv.Atoi(v)
+		if !ok || err != nil || version < 1 {
+			return 0, "", fmt.Errorf("%w: malformed master key version", ErrInvalidKey)
+		}
+		return version, encoded, nil
+	case strings.HasPrefix(wrapped, serverWrapPrefix):
+		return 1, strings.TrimPrefix(wrapped, serverWrapPrefix), nil
+	case strings.HasPrefix(wrapped, wrapPrefix):
+		return 0, strings.TrimPrefix(wrapped, wrapPrefix), nil
+	default:
+		return 0, "", fmt.Errorf("%w: unknown wrapped key format", ErrInvalidKey)
+	}
+}
+
+// seal encrypts plaintext with AES-GCM, authenticating the associated data, and prepends the nonce.
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aesGCM.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open decrypts data produced by seal with the same associated data.
+func open(key, data, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := aesGCM.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("invalid data")
+	}
+
+	return aesGCM.Open(nil, data[:nonceSize], data[nonceSize:], aad)
+}
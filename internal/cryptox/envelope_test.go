@@ -0,0 +1,185 @@
+package cryptox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoModule_WrapUnwrapKey(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	dataKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	kek, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := cryptoModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapped, wrapPrefix), wrapped)
+	assert.NotContains(t, wrapped, strings.TrimPrefix(dataKey, keyPrefix))
+
+	unwrapped, err := cryptoModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	otherKEK, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	_, err = cryptoModule.UnwrapKey(wrapped, otherKEK)
+	assert.Error(t, err, "Unwrapping with a wrong KEK should fail")
+}
+
+// testKeyProvider returns a local key provider holding master key versions 1..n.
+func testKeyProvider(t *testing.T, n int) *LocalKeyProvider {
+	keys := make(map[int][]byte, n)
+	for version := 1; version <= n; version++ {
+		keys[version] = bytes.Repeat([]byte{byte(version)}, KeySize)
+	}
+
+	provider, err := NewLocalKeyProvider(keys)
+	require.NoError(t, err)
+
+	return provider
+}
+
+func TestCryptoModule_WrapKey_KeyProvider(t *testing.T) {
+	cryptoModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 2)})
+	require.NoError(t, err)
+
+	dataKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	kek, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := cryptoModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapped, versionedServerPrefix+"2."), wrapped)
+	assert.False(t, cryptoModule.NeedsRewrap(wrapped))
+
+	unwrapped, err := cryptoModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	_, err = NewCryproModule().UnwrapKey(wrapped, kek)
+	assert.ErrorIs(t, err, ErrServerKeyRequired)
+
+	rotatedOut, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 1)})
+	require.NoError(t, err)
+	_, err = rotatedOut.UnwrapKey(wrapped, kek)
+	assert.ErrorIs(t, err, ErrUnknownKeyVersion)
+}
+
+func TestCryptoModule_KeyRotation(t *testing.T) {
+	oldModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 1)})
+	require.NoError(t, err)
+	newModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 2)})
+	require.NoError(t, err)
+
+	dataKey, err := oldModule.GenerateDataKey()
+	require.NoError(t, err)
+	kek, err := oldModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := oldModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	assert.True(t, newModule.NeedsRewrap(wrapped), "A key bound to an old master key version should be re-wrapped")
+
+	unwrapped, err := newModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err, "Old master key versions should still unwrap")
+	assert.Equal(t, dataKey, unwrapped)
+
+	unbound, err := NewCryproModule().WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	assert.True(t, newModule.NeedsRewrap(unbound), "A key without server binding should be re-wrapped")
+	assert.False(t, NewCryproModule().NeedsRewrap(unbound))
+}
+
+func TestCryptoModule_RotateWrappedKey(t *testing.T) {
+	oldModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 1)})
+	require.NoError(t, err)
+	newModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 2)})
+	require.NoError(t, err)
+
+	dataKey, err := oldModule.GenerateDataKey()
+	require.NoError(t, err)
+	kek, err := oldModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	for name, wrap := range map[string]*CryptoModule{"old version": oldModule, "no server layer": NewCryproModule()} {
+		t.Run(name, func(t *testing.T) {
+			wrapped, err := wrap.WrapKey(dataKey, kek)
+			require.NoError(t, err)
+
+			rotated, err := newModule.RotateWrappedKey(wrapped)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(rotated, versionedServerPrefix+"2."), rotated)
+			assert.False(t, newModule.NeedsRewrap(rotated))
+
+			unwrapped, err := newModule.UnwrapKey(rotated, kek)
+			require.NoError(t, err, "A rotated key should unwrap with the same KEK")
+			assert.Equal(t, dataKey, unwrapped)
+		})
+	}
+
+	current, err := newModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	rotated, err := newModule.RotateWrappedKey(current)
+	require.NoError(t, err)
+	assert.Equal(t, current, rotated, "An up-to-date key should be left as is")
+
+	unbound, err := NewCryproModule().WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	rotated, err = NewCryproModule().RotateWrappedKey(unbound)
+	require.NoError(t, err)
+	assert.Equal(t, unbound, rotated, "Without a key provider there is nothing to rotate")
+}
+
+func TestCryptoModule_UnwrapKey_LegacyServerKey(t *testing.T) {
+	serverKey := bytes.Repeat([]byte{1}, KeySize)
+	dataKey, err := NewCryproModule().GenerateDataKey()
+	require.NoError(t, err)
+	kek, err := NewCryproModule().GenerateDataKey()
+	require.NoError(t, err)
+
+	dataKeyBytes, err := decodeKey(dataKey)
+	require.NoError(t, err)
+	kekBytes, err := decodeKey(kek)
+	require.NoError(t, err)
+
+	inner, err := seal(kekBytes, dataKeyBytes, nil)
+	require.NoError(t, err)
+	outer, err := seal(serverKey, inner, nil)
+	require.NoError(t, err)
+	wrapped := serverWrapPrefix + base64.RawStdEncoding.EncodeToString(outer)
+
+	cryptoModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 1)})
+	require.NoError(t, err)
+
+	unwrapped, err := cryptoModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err, "sw1 keys should unwrap with version 1 of the key ring")
+	assert.Equal(t, dataKey, unwrapped)
+}
+
+func TestCryptoModule_UnwrapKey_Invalid(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	kek, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	_, err = cryptoModule.UnwrapKey("garbage", kek)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = cryptoModule.UnwrapKey(wrapPrefix+"!!!", kek)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = cryptoModule.WrapKey("legacy-phrase", kek)
+	assert.ErrorIs(t, err, ErrInvalidKey, "Legacy secret phrases cannot be wrapped")
+}
+
+func TestNewCryptoModule_InvalidKDF(t *testing.T) {
+	_, err := NewCryptoModule(Options{KDF: KDFConfig{Algorithm: "md5"}})
+	assert.ErrorIs(t, err, ErrInvalidKDFParams)
+}
@@ -0,0 +1,287 @@
+package cryptox
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported key derivation functions.
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+)
+
+// Default KDF cost parameters (OWASP password storage recommendations).
+const (
+	DefaultArgon2Time    = 3
+	DefaultArgon2Memory  = 64 * 1024 // KiB
+	DefaultArgon2Threads = 2
+	DefaultScryptLogN    = 15
+	DefaultScryptR       = 8
+	DefaultScryptP       = 1
+)
+
+// KeySize is the size in bytes of a derived vault key (AES-256).
+const KeySize = 32
+
+// saltSize is the size in bytes of the random per-user KDF salt.
+const saltSize = 16
+
+// keyPrefix marks a key string carrying a raw (derived or data) key rather than a legacy secret phrase.
+const keyPrefix = "k1."
+
+// ErrInvalidKDFParams is returned when encoded KDF parameters cannot be parsed or are out of range.
+var ErrInvalidKDFParams = errors.New("invalid kdf parameters")
+
+// ErrInvalidKey is returned when a key string or a wrapped key is malformed.
+var ErrInvalidKey = errors.New("invalid encryption key")
+
+// KDFConfig selects the algorithm and cost used for newly generated KDF parameters.
+// Argon2id is the default; scrypt is the fallback for deployments that cannot afford
+// Argon2id's memory cost. Parameters already stored for a user are always honoured,
+// whatever the current configuration.
+type KDFConfig struct {
+	Algorithm     string // KDFArgon2id or KDFScrypt.
+	Argon2Time    uint32 // Argon2id passes over memory.
+	Argon2Memory  uint32 // Argon2id memory in KiB.
+	Argon2Threads uint8  // Argon2id parallelism.
+	ScryptLogN    uint8  // scrypt CPU/memory cost as log2(N).
+}
+
+// DefaultKDFConfig returns the default Argon2id configuration.
+func DefaultKDFConfig() KDFConfig {
+	return KDFConfig{
+		Algorithm:     KDFArgon2id,
+		Argon2Time:    DefaultArgon2Time,
+		Argon2Memory:  DefaultArgon2Memory,
+		Argon2Threads: DefaultArgon2Threads,
+		ScryptLogN:    DefaultScryptLogN,
+	}
+}
+
+// KDFParams are the per-user parameters of a key derivation.
+// They are stored in a PHC-like string form, e.g.
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<base64 salt>
+//	$scrypt$ln=15,r=8,p=1$<base64 salt>
+type KDFParams struct {
+	Algorithm string
+	Salt      []byte
+	Time      uint32 // Argon2id passes.
+	Memory    uint32 // Argon2id memory in KiB.
+	Threads   uint8  // Argon2id parallelism.
+	LogN      uint8  // scrypt log2(N).
+	R         int    // scrypt block size.
+	P         int    // scrypt parallelism.
+}
+
+// NewKDFParams generates fresh parameters with a random salt according to the configuration.
+//
+// Returns:
+//   - The generated parameters or an error wrapping ErrInvalidKDFParams if the configuration is invalid.
+func NewKDFParams(cfg KDFConfig) (KDFParams, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return KDFParams{}, err
+	}
+
+	var params KDFParams
+	switch cfg.Algorithm {
+	case "", KDFArgon2id:
+		params = KDFParams{
+			Algorithm: KDFArgon2id,
+			Salt:      salt,
+			Time:      cfg.Argon2Time,
+			Memory:    cfg.Argon2Memory,
+			Threads:   cfg.Argon2Threads,
+		}
+	case KDFScrypt:
+		params = KDFParams{
+			Algorithm: KDFScrypt,
+			Salt:      salt,
+			LogN:      cfg.ScryptLogN,
+			R:         DefaultScryptR,
+			P:         DefaultScryptP,
+		}
+	default:
+		return KDFParams{}, fmt.Errorf("%w: unknown algorithm %q", ErrInvalidKDFParams, cfg.Algorithm)
+	}
+
+	if err := params.validate(); err != nil {
+		return KDFParams{}, err
+	}
+
+	return params, nil
+}
+
+// ParseKDFParams parses parameters produced by KDFParams.String.
+//
+// Returns:
+//   - The parsed parameters or an error wrapping ErrInvalidKDFParams.
+func ParseKDFParams(encoded string) (KDFParams, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 4 || parts[0] != "" {
+		return KDFParams{}, fmt.Errorf("%w: malformed %q", ErrInvalidKDFParams, encoded)
+	}
+
+	params := KDFParams{Algorithm: parts[1]}
+	var cost, salt string
+
+	switch params.Algorithm {
+	case KDFArgon2id:
+		if len(parts) != 5 || parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+			return KDFParams{}, fmt.Errorf("%w: unsupported argon2id version", ErrInvalidKDFParams)
+		}
+		cost, salt = parts[3], parts[4]
+		if _, err := fmt.Sscanf(cost, "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+			return KDFParams{}, fmt.Errorf("%w: %v", ErrInvalidKDFParams, err)
+		}
+	case KDFScrypt:
+		if len(parts) != 4 {
+			return KDFParams{}, fmt.Errorf("%w: malformed %q", ErrInvalidKDFParams, encoded)
+		}
+		cost, salt = parts[2], parts[3]
+		if _, err := fmt.Sscanf(cost, "ln=%d,r=%d,p=%d", &params.LogN, &params.R, &params.P); err != nil {
+			return KDFParams{}, fmt.Errorf("%w: %v", ErrInvalidKDFParams, err)
+		}
+	default:
+		return KDFParams{}, fmt.Errorf("%w: unknown algorithm %q", ErrInvalidKDFParams, params.Algorithm)
+	}
+
+	decodedSalt, err := base64.RawStdEncoding.DecodeString(salt)
+	if err != nil {
+		return KDFParams{}, fmt.Errorf("%w: salt: %v", ErrInvalidKDFParams, err)
+	}
+	params.Salt = decodedSalt
+
+	if err := params.validate(); err != nil {
+		return KDFParams{}, err
+	}
+
+	return params, nil
+}
+
+// String encodes the parameters in their stored PHC-like form.
+func (p KDFParams) String() string {
+	salt := base64.RawStdEncoding.EncodeToString(p.Salt)
+
+	if p.Algorithm == KDFScrypt {
+		return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s", KDFScrypt, p.LogN, p.R, p.P, salt)
+	}
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s", KDFArgon2id, argon2.Version, p.Memory, p.Time, p.Threads, salt)
+}
+
+// Derive derives a KeySize-byte key from the secret.
+//
+// Returns:
+//   - The derived key or an error if the parameters are invalid.
+func (p KDFParams) Derive(secret string) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	if p.Algorithm == KDFScrypt {
+		return scrypt.Key([]byte(secret), p.Salt, 1<<p.LogN, p.R, p.P, KeySize)
+	}
+
+	return argon2.IDKey([]byte(secret), p.Salt, p.Time, p.Memory, p.Threads, KeySize), nil
+}
+
+// validate rejects parameters that are unusable or too weak to protect a vault.
+func (p KDFParams) validate() error {
+	if len(p.Salt) < 8 {
+		return fmt.Errorf("%w: salt too short", ErrInvalidKDFParams)
+	}
+
+	switch p.Algorithm {
+	case KDFArgon2id:
+		if p.Time < 1 || p.Threads < 1 || p.Memory < 8*uint32(p.Threads) {
+			return fmt.Errorf("%w: argon2id m=%d,t=%d,p=%d", ErrInvalidKDFParams, p.Memory, p.Time, p.Threads)
+		}
+	case KDFScrypt:
+		if p.LogN < 1 || p.LogN > 30 || p.R < 1 || p.P < 1 {
+			return fmt.Errorf("%w: scrypt ln=%d,r=%d,p=%d", ErrInvalidKDFParams, p.LogN, p.R, p.P)
+		}
+	default:
+		return fmt.Errorf("%w: unknown algorithm %q", ErrInvalidKDFParams, p.Algorithm)
+	}
+
+	return nil
+}
+
+// EncodeKey encodes a raw key as the key string passed to Encrypt and Decrypt.
+func EncodeKey(key []byte) string {
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(key)
+}
+
+// decodeKey decodes a key string produced by EncodeKey; legacy secret phrases are rejected.
+func decodeKey(key string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(key, keyPrefix)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	keyBytes, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(keyBytes) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	return keyBytes, nil
+}
+
+// NewKDFParams generates fresh KDF parameters according to the module's configuration.
+//
+// Returns:
+//   - The parameters in their stored string form or an error if the configuration is invalid.
+func (c *CryptoModule) NewKDFParams() (string, error) {
+	params, err := NewKDFParams(c.kdf)
+	if err != nil {
+		return "", err
+	}
+
+	return params.String(), nil
+}
+
+// DeriveKey derives the vault key from a user secret and the user's stored KDF parameters.
+//
+// Returns:
+//   - The encoded key string accepted by Encrypt and Decrypt, or an error if the parameters are invalid.
+func (c *CryptoModule) DeriveKey(secret, encodedParams string) (string, error) {
+	params, err := ParseKDFParams(encodedParams)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := params.Derive(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return EncodeKey(key), nil
+}
+
+// KDFOutdated reports whether stored KDF parameters differ from the module's configuration in
+// algorithm or cost, e.g. after the Argon2id memory was raised. The salt is ignored. Keys derived
+// with outdated parameters can only be wrapped again with the user's password, on their next login.
+// Parameters that cannot be parsed are reported as outdated.
+func (c *CryptoModule) KDFOutdated(encodedParams string) bool {
+	params, err := ParseKDFParams(encodedParams)
+	if err != nil {
+		return true
+	}
+
+	current, err := NewKDFParams(c.kdf)
+	if err != nil {
+		return false
+	}
+
+	current.Salt = params.Salt
+	return current.String() != params.String()
+}
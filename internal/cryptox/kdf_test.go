@@ -0,0 +1,150 @@
+package cryptox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDFConfig keeps the work factor low so that tests stay fast.
+func testKDFConfig(algorithm string) KDFConfig {
+	return KDFConfig{
+		Algorithm:     algorithm,
+		Argon2Time:    1,
+		Argon2Memory:  64,
+		Argon2Threads: 1,
+		ScryptLogN:    4,
+	}
+}
+
+func TestKDFParams_RoundTrip(t *testing.T) {
+	for _, algorithm := range []string{KDFArgon2id, KDFScrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			params, err := NewKDFParams(testKDFConfig(algorithm))
+			require.NoError(t, err)
+			assert.Len(t, params.Salt, saltSize)
+
+			encoded := params.String()
+			assert.True(t, strings.HasPrefix(encoded, "$"+algorithm+"$"), encoded)
+
+			parsed, err := ParseKDFParams(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, params, parsed)
+		})
+	}
+}
+
+func TestKDFParams_Encoding(t *testing.T) {
+	params := KDFParams{Algorithm: KDFArgon2id, Salt: []byte("0123456789abcdef"), Time: 3, Memory: 65536, Threads: 2}
+	assert.Equal(t, "$argon2id$v=19$m=65536,t=3,p=2$MDEyMzQ1Njc4OWFiY2RlZg", params.String())
+
+	params = KDFParams{Algorithm: KDFScrypt, Salt: []byte("0123456789abcdef"), LogN: 15, R: 8, P: 1}
+	assert.Equal(t, "$scrypt$ln=15,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg", params.String())
+}
+
+func TestParseKDFParams_Invalid(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"argon2id",
+		"$argon2id$v=16$m=65536,t=3,p=2$MDEyMzQ1Njc4OWFiY2RlZg",
+		"$argon2id$v=19$m=65536,t=0,p=2$MDEyMzQ1Njc4OWFiY2RlZg",
+		"$argon2id$v=19$m=65536,t=3,p=2$!!!",
+		"$argon2id$v=19$m=65536,t=3,p=2$c2hvcnQ",
+		"$scrypt$ln=0,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg",
+		"$pbkdf2$i=1000$MDEyMzQ1Njc4OWFiY2RlZg",
+	} {
+		_, err := ParseKDFParams(encoded)
+		assert.ErrorIs(t, err, ErrInvalidKDFParams, encoded)
+	}
+}
+
+func TestNewKDFParams_UnknownAlgorithm(t *testing.T) {
+	_, err := NewKDFParams(KDFConfig{Algorithm: "md5"})
+	assert.ErrorIs(t, err, ErrInvalidKDFParams)
+}
+
+func TestCryptoModule_DeriveKey(t *testing.T) {
+	for _, algorithm := range []string{KDFArgon2id, KDFScrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			cryptoModule, err := NewCryptoModule(Options{KDF: testKDFConfig(algorithm)})
+			require.NoError(t, err)
+
+			params, err := cryptoModule.NewKDFParams()
+			require.NoError(t, err)
+
+			key, err := cryptoModule.DeriveKey("correct horse battery staple", params)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(key, keyPrefix))
+
+			again, err := cryptoModule.DeriveKey("correct horse battery staple", params)
+			require.NoError(t, err)
+			assert.Equal(t, key, again, "Derivation should be deterministic for the same params")
+
+			other, err := cryptoModule.DeriveKey("wrong password", params)
+			require.NoError(t, err)
+			assert.NotEqual(t, key, other)
+
+			otherParams, err := cryptoModule.NewKDFParams()
+			require.NoError(t, err)
+			salted, err := cryptoModule.DeriveKey("correct horse battery staple", otherParams)
+			require.NoError(t, err)
+			assert.NotEqual(t, key, salted, "A different salt should give a different key")
+
+			encrypted, err := cryptoModule.Encrypt("secret", key)
+			require.NoError(t, err)
+
+			decrypted, err := cryptoModule.Decrypt(encrypted, key)
+			require.NoError(t, err)
+			assert.Equal(t, "secret", decrypted)
+
+			_, err = cryptoModule.Decrypt(encrypted, other)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCryptoModule_InvalidDerivedKey(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	_, err := cryptoModule.Encrypt("secret", keyPrefix+"tooshort")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = cryptoModule.DecryptBinaryData([]byte("data"), keyPrefix+"!!!")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestCryptoModule_LegacyKeyStillDecrypts(t *testing.T) {
+	cryptoModule := NewCryproModule()
+	legacyKey := cryptoModule.GenerateSecretPhrase("$2a$03$legacybcrypthash")
+
+	encrypted, err := cryptoModule.Encrypt("legacy secret", legacyKey)
+	require.NoError(t, err)
+
+	decrypted, err := cryptoModule.Decrypt(encrypted, legacyKey)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy secret", decrypted)
+}
+
+func TestCryptoModule_KDFOutdated(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	current, err := cryptoModule.NewKDFParams()
+	require.NoError(t, err)
+	assert.False(t, cryptoModule.KDFOutdated(current))
+
+	weaker := DefaultKDFConfig()
+	weaker.Argon2Memory = 32 * 1024
+	oldModule, err := NewCryptoModule(Options{KDF: weaker})
+	require.NoError(t, err)
+	old, err := oldModule.NewKDFParams()
+	require.NoError(t, err)
+	assert.True(t, cryptoModule.KDFOutdated(old), "Parameters with a lower cost should be outdated")
+
+	scryptModule, err := NewCryptoModule(Options{KDF: KDFConfig{Algorithm: KDFScrypt, ScryptLogN: DefaultScryptLogN}})
+	require.NoError(t, err)
+	assert.True(t, scryptModule.KDFOutdated(current), "Parameters of another algorithm should be outdated")
+
+	assert.True(t, cryptoModule.KDFOutdated("garbage"))
+}
@@ -0,0 +1,90 @@
+package cryptox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeyring(t *testing.T) {
+	key1 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeySize))
+	key2 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, KeySize))
+
+	provider, err := ParseKeyring(key1)
+	require.NoError(t, err)
+	version, err := provider.CurrentVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 1, version, "A bare key should be version 1")
+
+	provider, err = ParseKeyring("1:" + key1 + ",\n2:" + key2)
+	require.NoError(t, err)
+	version, err = provider.CurrentVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 2, version, "The highest version should be current")
+
+	ciphertext, version, err := provider.Encrypt([]byte("data key"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	plaintext, err := provider.Decrypt(ciphertext, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data key"), plaintext)
+
+	_, err = provider.Decrypt(ciphertext, 1)
+	assert.Error(t, err, "Decrypting with another version should fail")
+
+	_, err = provider.Decrypt(ciphertext, 3)
+	assert.ErrorIs(t, err, ErrUnknownKeyVersion)
+}
+
+func TestParseKeyring_Invalid(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeySize))
+
+	for _, encoded := range []string{
+		"",
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("short")),
+		"x:" + key,
+		"0:" + key,
+		"1:" + key + ",1:" + key,
+		key + "," + key,
+	} {
+		_, err := ParseKeyring(encoded)
+		assert.ErrorIs(t, err, ErrInvalidKey, encoded)
+	}
+}
+
+func TestNewEnvKeyProvider(t *testing.T) {
+	t.Setenv("TEST_MASTER_KEY", "3:"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, KeySize)))
+
+	provider, err := NewEnvKeyProvider("TEST_MASTER_KEY")
+	require.NoError(t, err)
+	version, err := provider.CurrentVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+
+	t.Setenv("TEST_MASTER_KEY", "")
+	_, err = NewEnvKeyProvider("TEST_MASTER_KEY")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestNewFileKeyProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.keys")
+	ring := "1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeySize)) + "\n" +
+		"2:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, KeySize)) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(ring), 0o600))
+
+	provider, err := NewFileKeyProvider(path)
+	require.NoError(t, err)
+	version, err := provider.CurrentVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = NewFileKeyProvider(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,246 @@
+//go:build pkcs11
+
+package cryptox
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// gcmIVSize and gcmTagBits are the AES-GCM parameters used inside the token.
+const (
+	gcmIVSize  = 12
+	gcmTagBits = 128
+)
+
+// PKCS11KeyProvider is a KeyProvider backed by a PKCS#11 token (an HSM, or SoftHSM for testing).
+// Master keys are AES-256 secret keys sharing the configured label; the CKA_ID of each key is its
+// version as a big-endian integer (e.g. `pkcs11-tool --keygen --key-type AES:32 --label gophkeeper --id 02`),
+// and the highest version is the current one. Encryption and decryption run inside the token,
+// so the master keys never leave it.
+type PKCS11KeyProvider struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+
+	mu      sync.Mutex // PKCS#11 sessions must not be used concurrently.
+	keys    map[int]pkcs11.ObjectHandle
+	current int
+}
+
+// NewPKCS11KeyProvider loads the PKCS#11 module, logs into the token and finds the master keys.
+//
+// Returns:
+//   - The provider, or an error if the module cannot be loaded, the token is not found,
+//     the PIN is wrong or the token holds no master key with the configured label.
+func NewPKCS11KeyProvider(cfg PKCS11Config) (*PKCS11KeyProvider, error) {
+	if cfg.Module == "" || cfg.KeyLabel == "" {
+		return nil, errors.New("pkcs11: module and key label are required")
+	}
+
+	ctx := pkcs11.New(cfg.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("pkcs11: cannot load module %s", cfg.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("pkcs11: initialize: %w", err)
+	}
+
+	provider := &PKCS11KeyProvider{ctx: ctx}
+	if err := provider.open(cfg); err != nil {
+		provider.Close()
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// open opens and logs into a session on the configured token and loads the master key handles.
+func (p *PKCS11KeyProvider) open(cfg PKCS11Config) error {
+	slot, err := p.findSlot(cfg.TokenLabel)
+	if err != nil {
+		return err
+	}
+
+	p.session, err = p.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("pkcs11: open session: %w", err)
+	}
+
+	if err := p.ctx.Login(p.session, pkcs11.CKU_USER, cfg.PIN); err != nil {
+		return fmt.Errorf("pkcs11: login: %w", err)
+	}
+
+	return p.loadKeys(cfg.KeyLabel)
+}
+
+// findSlot returns the slot holding the token with the given label, or the first slot with a token if the label is empty.
+func (p *PKCS11KeyProvider) findSlot(tokenLabel string) (uint, error) {
+	slots, err := p.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("pkcs11: list slots: %w", err)
+	}
+
+	for _, slot := range slots {
+		if tokenLabel == "" {
+			return slot, nil
+		}
+
+		info, err := p.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("pkcs11: token info: %w", err)
+		}
+		if strings.TrimRight(info.Label, " \x00") == tokenLabel {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("pkcs11: token %q not found", tokenLabel)
+}
+
+// loadKeys finds the AES secret keys with the given label and indexes them by the version in their CKA_ID.
+func (p *PKCS11KeyProvider) loadKeys(label string) error {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := p.ctx.FindObjectsInit(p.session, template); err != nil {
+		return fmt.Errorf("pkcs11: find keys: %w", err)
+	}
+
+	var handles []pkcs11.ObjectHandle
+	for {
+		batch, _, err := p.ctx.FindObjects(p.session, 16)
+		if err != nil {
+			_ = p.ctx.FindObjectsFinal(p.session)
+			return fmt.Errorf("pkcs11: find keys: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		handles = append(handles, batch...)
+	}
+	if err := p.ctx.FindObjectsFinal(p.session); err != nil {
+		return fmt.Errorf("pkcs11: find keys: %w", err)
+	}
+
+	p.keys = make(map[int]pkcs11.ObjectHandle, len(handles))
+	for _, handle := range handles {
+		attrs, err := p.ctx.GetAttributeValue(p.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+		})
+		if err != nil {
+			return fmt.Errorf("pkcs11: read key id: %w", err)
+		}
+
+		id := new(big.Int).SetBytes(attrs[0].Value)
+		if !id.IsInt64() || id.Int64() < 1 || id.Int64() > int64(^uint32(0)) {
+			return fmt.Errorf("%w: pkcs11 key %q has invalid id %x", ErrInvalidKey, label, attrs[0].Value)
+		}
+
+		version := int(id.Int64())
+		if _, ok := p.keys[version]; ok {
+			return fmt.Errorf("%w: duplicate pkcs11 key version %d", ErrInvalidKey, version)
+		}
+		p.keys[version] = handle
+		if version > p.current {
+			p.current = version
+		}
+	}
+
+	if len(p.keys) == 0 {
+		return fmt.Errorf("%w: no pkcs11 key labelled %q", ErrInvalidKey, label)
+	}
+
+	return nil
+}
+
+// Encrypt encrypts plaintext with AES-GCM inside the token under the current master key.
+// The random IV is prepended to the ciphertext.
+func (p *PKCS11KeyProvider) Encrypt(plaintext []byte) ([]byte, int, error) {
+	iv := make([]byte, gcmIVSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, 0, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	params := pkcs11.NewGCMParams(iv, nil, gcmTagBits)
+	defer params.Free()
+
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}
+	if err := p.ctx.EncryptInit(p.session, mechanism, p.keys[p.current]); err != nil {
+		return nil, 0, fmt.Errorf("pkcs11: encrypt init: %w", err)
+	}
+
+	ciphertext, err := p.ctx.Encrypt(p.session, plaintext)
+	if err != nil {
+		return nil, 0, fmt.Errorf("pkcs11: encrypt: %w", err)
+	}
+
+	return append(iv, ciphertext...), p.current, nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt inside the token under the given master key version.
+// Returns ErrUnknownKeyVersion if the token holds no key with that version.
+func (p *PKCS11KeyProvider) Decrypt(ciphertext []byte, version int) ([]byte, error) {
+	if len(ciphertext) < gcmIVSize {
+		return nil, errors.New("invalid data")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: v%d", ErrUnknownKeyVersion, version)
+	}
+
+	params := pkcs11.NewGCMParams(ciphertext[:gcmIVSize], nil, gcmTagBits)
+	defer params.Free()
+
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}
+	if err := p.ctx.DecryptInit(p.session, mechanism, key); err != nil {
+		return nil, fmt.Errorf("pkcs11: decrypt init: %w", err)
+	}
+
+	plaintext, err := p.ctx.Decrypt(p.session, ciphertext[gcmIVSize:])
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// CurrentVersion returns the highest master key version found on the token.
+func (p *PKCS11KeyProvider) CurrentVersion() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.current, nil
+}
+
+// Close logs out, closes the session and unloads the PKCS#11 module.
+func (p *PKCS11KeyProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.session != 0 {
+		_ = p.ctx.Logout(p.session)
+		_ = p.ctx.CloseSession(p.session)
+		p.session = 0
+	}
+
+	err := p.ctx.Finalize()
+	p.ctx.Destroy()
+
+	return err
+}
@@ -0,0 +1,14 @@
+package cryptox
+
+import "errors"
+
+// ErrPKCS11Unsupported is returned by NewPKCS11KeyProvider when the binary is built without the pkcs11 tag.
+var ErrPKCS11Unsupported = errors.New("pkcs11 support not compiled in; rebuild with -tags pkcs11")
+
+// PKCS11Config configures a PKCS11KeyProvider.
+type PKCS11Config struct {
+	Module     string // Path to the PKCS#11 module, e.g. /usr/lib/softhsm/libsofthsm2.so.
+	TokenLabel string // Label of the token holding the master keys; the first token if empty.
+	PIN        string // User PIN of the token.
+	KeyLabel   string // Label shared by all versions of the master key.
+}
@@ -0,0 +1,19 @@
+//go:build !pkcs11
+
+package cryptox
+
+// PKCS11KeyProvider is unavailable without the pkcs11 build tag.
+type PKCS11KeyProvider struct {
+	KeyProvider
+}
+
+// NewPKCS11KeyProvider always returns ErrPKCS11Unsupported; build with -tags pkcs11 (requires cgo)
+// to use a PKCS#11 token.
+func NewPKCS11KeyProvider(cfg PKCS11Config) (*PKCS11KeyProvider, error) {
+	return nil, ErrPKCS11Unsupported
+}
+
+// Close is a no-op.
+func (p *PKCS11KeyProvider) Close() error {
+	return nil
+}
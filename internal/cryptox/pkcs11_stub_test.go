@@ -0,0 +1,14 @@
+//go:build !pkcs11
+
+package cryptox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPKCS11KeyProvider_Unsupported(t *testing.T) {
+	_, err := NewPKCS11KeyProvider(PKCS11Config{Module: "/usr/lib/softhsm/libsofthsm2.so", KeyLabel: "gophkeeper"})
+	assert.ErrorIs(t, err, ErrPKCS11Unsupported)
+}
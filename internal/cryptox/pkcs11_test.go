@@ -0,0 +1,152 @@
+//go:build pkcs11
+
+package cryptox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// softHSMModules lists the usual locations of the SoftHSM v2 module.
+var softHSMModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+}
+
+// setupSoftHSM initializes a throwaway SoftHSM token and returns the path of the module.
+// The test is skipped if SoftHSM is not installed.
+func setupSoftHSM(t *testing.T) string {
+	module := os.Getenv("SOFTHSM2_MODULE")
+	for _, candidate := range softHSMModules {
+		if module != "" {
+			break
+		}
+		if _, err := os.Stat(candidate); err == nil {
+			module = candidate
+		}
+	}
+	if module == "" {
+		t.Skip("SoftHSM module not found")
+	}
+	if _, err := exec.LookPath("softhsm2-util"); err != nil {
+		t.Skip("softhsm2-util not found")
+	}
+
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "softhsm2.conf")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tokens"), 0o700))
+	require.NoError(t, os.WriteFile(conf, []byte(fmt.Sprintf("directories.tokendir = %s\n", filepath.Join(dir, "tokens"))), 0o600))
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	out, err := exec.Command("softhsm2-util", "--init-token", "--free", "--label", "test", "--pin", "1234", "--so-pin", "5678").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return module
+}
+
+// generateSoftHSMKey generates a persistent AES-256 key on the token with the given label and version as CKA_ID.
+func generateSoftHSMKey(t *testing.T, module, label string, version byte) {
+	ctx := pkcs11.New(module)
+	require.NotNil(t, ctx)
+	require.NoError(t, ctx.Initialize())
+	defer func() {
+		ctx.Finalize()
+		ctx.Destroy()
+	}()
+
+	slots, err := ctx.GetSlotList(true)
+	require.NoError(t, err)
+
+	var slot uint
+	for _, s := range slots {
+		info, err := ctx.GetTokenInfo(s)
+		require.NoError(t, err)
+		if strings.TrimRight(info.Label, " \x00") == "test" {
+			slot = s
+		}
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	require.NoError(t, err)
+	defer ctx.CloseSession(session)
+	require.NoError(t, ctx.Login(session, pkcs11.CKU_USER, "1234"))
+	defer ctx.Logout(session)
+
+	_, err = ctx.GenerateKey(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_KEY_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, KeySize),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+			pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{version}),
+		})
+	require.NoError(t, err)
+}
+
+func TestPKCS11KeyProvider_SoftHSM(t *testing.T) {
+	module := setupSoftHSM(t)
+	generateSoftHSMKey(t, module, "gophkeeper", 1)
+
+	cfg := PKCS11Config{Module: module, TokenLabel: "test", PIN: "1234", KeyLabel: "gophkeeper"}
+	provider, err := NewPKCS11KeyProvider(cfg)
+	require.NoError(t, err)
+
+	cryptoModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: provider})
+	require.NoError(t, err)
+
+	dataKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	kek, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := cryptoModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapped, versionedServerPrefix+"1."), wrapped)
+
+	unwrapped, err := cryptoModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+	require.NoError(t, provider.Close())
+
+	// Rotate: a new key version on the token becomes current, the old one still decrypts.
+	generateSoftHSMKey(t, module, "gophkeeper", 2)
+
+	rotated, err := NewPKCS11KeyProvider(cfg)
+	require.NoError(t, err)
+	defer rotated.Close()
+
+	rotatedModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: rotated})
+	require.NoError(t, err)
+	assert.True(t, rotatedModule.NeedsRewrap(wrapped))
+
+	unwrapped, err = rotatedModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+}
+
+func TestPKCS11KeyProvider_WrongPIN(t *testing.T) {
+	module := setupSoftHSM(t)
+	generateSoftHSMKey(t, module, "gophkeeper", 1)
+
+	_, err := NewPKCS11KeyProvider(PKCS11Config{Module: module, TokenLabel: "test", PIN: "0000", KeyLabel: "gophkeeper"})
+	assert.Error(t, err)
+
+	_, err = NewPKCS11KeyProvider(PKCS11Config{Module: module, TokenLabel: "test", PIN: "1234", KeyLabel: "missing"})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
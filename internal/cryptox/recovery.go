@@ -0,0 +1,171 @@
+package cryptox
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Sizes of the random secrets behind a recovery key and a recovery code.
+const (
+	RecoveryKeySize  = 32
+	RecoveryCodeSize = 10
+)
+
+// recoveryKEKInfo separates keys derived from recovery keys from any other use of the same bytes.
+const recoveryKEKInfo = "gophkeeper/recovery-kek"
+
+// ErrInvalidRecoveryKey is returned when a recovery key is malformed.
+var ErrInvalidRecoveryKey = errors.New("invalid recovery key")
+
+// recoveryEncoding is the alphabet of recovery keys and codes: RFC 4648 base32, which has no
+// easily confused characters such as 0/O or 1/l and is case-insensitive once normalized.
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryKey generates a random recovery key that can unwrap the user's data key
+// in place of their master password. The key is meant to be written down, so it is encoded
+// as upper-case base32 in groups of four characters separated by dashes.
+//
+// Returns:
+//   - The recovery key, or an error if the random source fails.
+func (c *CryptoModule) GenerateRecoveryKey() (string, error) {
+	return generateRecoverySecret(RecoveryKeySize)
+}
+
+// RecoveryKEK derives the key-encryption key wrapping the user's data key from a recovery key.
+// The recovery key already has full entropy, so it is expanded with HKDF-SHA256 instead of a
+// password KDF and no parameters are stored. Dashes, spaces and letter case are ignored.
+//
+// Returns:
+//   - The key-encryption key accepted by WrapKey and UnwrapKey.
+//   - ErrInvalidRecoveryKey if the recovery key is malformed.
+func (c *CryptoModule) RecoveryKEK(recoveryKey string) (string, error) {
+	secret, err := recoveryEncoding.DecodeString(normalizeRecoverySecret(recoveryKey))
+	if err != nil || len(secret) != RecoveryKeySize {
+		return "", ErrInvalidRecoveryKey
+	}
+
+	kek := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(recoveryKEKInfo)), kek); err != nil {
+		return "", err
+	}
+
+	return EncodeKey(kek), nil
+}
+
+// SplitRecoveryKey splits a recovery key into shares with SplitSecret, so that any threshold of them
+// reconstruct it. Each share is returned without its x coordinate and encoded like a data key, so it
+// can be sealed with SealKey; the share at index i has x coordinate i+1.
+//
+// Parameters:
+//   - recoveryKey: The recovery key, as returned by GenerateRecoveryKey.
+//   - shares: The number of shares.
+//   - threshold: The number of shares needed to reconstruct the recovery key.
+//
+// Returns:
+//   - The shares, ErrInvalidRecoveryKey if the recovery key is malformed, or ErrInvalidShares for
+//     invalid parameters.
+func (c *CryptoModule) SplitRecoveryKey(recoveryKey string, shares, threshold int) ([]string, error) {
+	secret, err := recoveryEncoding.DecodeString(normalizeRecoverySecret(recoveryKey))
+	if err != nil || len(secret) != RecoveryKeySize {
+		return nil, ErrInvalidRecoveryKey
+	}
+	defer clear(secret)
+
+	split, err := SplitSecret(secret, shares, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]string, len(split))
+	for i, share := range split {
+		encoded[i] = EncodeKey(share[1:])
+	}
+
+	return encoded, nil
+}
+
+// CombineRecoveryShares reconstructs a recovery key from shares returned by SplitRecoveryKey. Too few
+// shares give a different, well-formed recovery key, which fails to unwrap the data key.
+//
+// Parameters:
+//   - shares: The shares by x coordinate.
+//
+// Returns:
+//   - The recovery key in the form returned by GenerateRecoveryKey, or ErrInvalidShares if a share is
+//     malformed or there are fewer than two.
+func (c *CryptoModule) CombineRecoveryShares(shares map[int]string) (string, error) {
+	split := make([][]byte, 0, len(shares))
+	for x, share := range shares {
+		y, err := decodeKey(share)
+		if err != nil || x < 1 || x > MaxShares {
+			return "", ErrInvalidShares
+		}
+		split = append(split, append([]byte{byte(x)}, y...))
+	}
+
+	secret, err := CombineShares(split)
+	if err != nil {
+		return "", err
+	}
+	defer clear(secret)
+
+	return formatRecoverySecret(secret), nil
+}
+
+// GenerateRecoveryCode generates a random one-time recovery code, encoded like a recovery key.
+// Only the hash returned by HashRecoveryCode is stored.
+//
+// Returns:
+//   - The recovery code, or an error if the random source fails.
+func (c *CryptoModule) GenerateRecoveryCode() (string, error) {
+	return generateRecoverySecret(RecoveryCodeSize)
+}
+
+// HashRecoveryCode returns the hex-encoded SHA-256 hash a recovery code is stored and looked up by.
+// Codes are random, so a fast hash is sufficient. Dashes, spaces and letter case are ignored.
+func (c *CryptoModule) HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoverySecret(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoverySecret encodes size random bytes in groups of four base32 characters.
+func generateRecoverySecret(size int) (string, error) {
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return formatRecoverySecret(secret), nil
+}
+
+// formatRecoverySecret encodes a secret as upper-case base32 in groups of four characters.
+func formatRecoverySecret(secret []byte) string {
+	encoded := recoveryEncoding.EncodeToString(secret)
+
+	var b strings.Builder
+	for i := 0; i < len(encoded); i += 4 {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(encoded[i:min(i+4, len(encoded))])
+	}
+
+	return b.String()
+}
+
+// normalizeRecoverySecret removes separators and upper-cases a recovery key or code as typed by a user.
+func normalizeRecoverySecret(secret string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' || r == '\n' {
+			return -1
+		}
+		return r
+	}, secret))
+}
@@ -0,0 +1,90 @@
+package cryptox
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoModule_RecoveryKey(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	recoveryKey, err := cryptoModule.GenerateRecoveryKey()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^([A-Z2-7]{4}-){12}[A-Z2-7]{4}$`), recoveryKey)
+
+	other, err := cryptoModule.GenerateRecoveryKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, recoveryKey, other)
+
+	kek, err := cryptoModule.RecoveryKEK(recoveryKey)
+	require.NoError(t, err)
+
+	typed, err := cryptoModule.RecoveryKEK(strings.ToLower(strings.ReplaceAll(recoveryKey, "-", " ")))
+	require.NoError(t, err)
+	assert.Equal(t, kek, typed, "Separators and case should be ignored")
+
+	dataKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	wrapped, err := cryptoModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	unwrapped, err := cryptoModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	otherKEK, err := cryptoModule.RecoveryKEK(other)
+	require.NoError(t, err)
+	_, err = cryptoModule.UnwrapKey(wrapped, otherKEK)
+	assert.Error(t, err, "Another recovery key should not unwrap the data key")
+
+	for _, invalid := range []string{"", "ABCD-EFGH", recoveryKey + "-AAAA", "1111-" + recoveryKey[5:]} {
+		_, err = cryptoModule.RecoveryKEK(invalid)
+		assert.ErrorIs(t, err, ErrInvalidRecoveryKey, invalid)
+	}
+}
+
+func TestCryptoModule_SplitRecoveryKey(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	recoveryKey, err := cryptoModule.GenerateRecoveryKey()
+	require.NoError(t, err)
+
+	shares, err := cryptoModule.SplitRecoveryKey(strings.ToLower(recoveryKey), 4, 2)
+	require.NoError(t, err)
+	require.Len(t, shares, 4)
+
+	publicKey, _, err := cryptoModule.GenerateKeyPair()
+	require.NoError(t, err)
+	_, err = cryptoModule.SealKey(shares[0], publicKey)
+	require.NoError(t, err, "A share should be sealable to an officer")
+
+	combined, err := cryptoModule.CombineRecoveryShares(map[int]string{2: shares[1], 4: shares[3]})
+	require.NoError(t, err)
+	assert.Equal(t, recoveryKey, combined)
+
+	_, err = cryptoModule.CombineRecoveryShares(map[int]string{2: shares[1]})
+	assert.ErrorIs(t, err, ErrInvalidShares)
+	_, err = cryptoModule.CombineRecoveryShares(map[int]string{0: shares[0], 2: shares[1]})
+	assert.ErrorIs(t, err, ErrInvalidShares)
+	_, err = cryptoModule.SplitRecoveryKey("ABCD", 4, 2)
+	assert.ErrorIs(t, err, ErrInvalidRecoveryKey)
+}
+
+func TestCryptoModule_RecoveryCode(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	code, err := cryptoModule.GenerateRecoveryCode()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`), code)
+
+	hash := cryptoModule.HashRecoveryCode(code)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, cryptoModule.HashRecoveryCode(strings.ToLower(strings.ReplaceAll(code, "-", ""))))
+
+	other, err := cryptoModule.GenerateRecoveryCode()
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, cryptoModule.HashRecoveryCode(other))
+}
@@ -1,6 +1,12 @@
 // Package cryptox provides cryptographic utilities for encrypting and decrypting
-// text and binary data using AES-GCM (Advanced Encryption Standard with Galois/Counter Mode).
-// It also includes functionality for generating secure secret phrases and deriving keys.
+// text and binary data with an AEAD cipher: AES-256-GCM or XChaCha20-Poly1305.
+// Vault values are self-describing: a header names the format version, the cipher and the key
+// (see CiphertextHeader), so ciphers and keys can change while older values stay readable.
+// Vault items are encrypted with a random per-user data key, which is wrapped by a key-encryption
+// key derived from the user's password with a salted, tunable KDF (Argon2id or scrypt) and,
+// optionally, by a server master key held by a KeyProvider (a key file, an environment variable
+// or HashiCorp Vault transit). Legacy secret phrases are still accepted so that existing
+// ciphertexts can be migrated.
 package cryptox
 
 import (
@@ -12,23 +18,59 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // CryptoModule is a struct that provides methods for encryption, decryption,
 // and secure secret phrase generation.
-type CryptoModule struct{}
+type CryptoModule struct {
+	kdf        KDFConfig   // Configuration for newly generated KDF parameters.
+	keys       KeyProvider // Optional server master key provider wrapping data keys.
+	cipherName string      // Cipher of newly encrypted vault values.
+}
+
+// Options configures a CryptoModule.
+type Options struct {
+	KDF         KDFConfig   // Configuration for newly generated KDF parameters.
+	KeyProvider KeyProvider // Optional server master key provider; when set, data keys are also wrapped with it.
+	Cipher      string      // Cipher of newly encrypted vault values: CipherAES256GCM (default) or CipherXChaCha20Poly1305.
+}
 
-// NewCryproModule initializes and returns a new instance of CryptoModule.
+// NewCryproModule initializes and returns a new instance of CryptoModule using DefaultKDFConfig,
+// AES-256-GCM and no key provider.
 // Note: The function name contains a typo ("Crypro" instead of "Crypto").
 func NewCryproModule() *CryptoModule {
-	return &CryptoModule{}
+	return &CryptoModule{kdf: DefaultKDFConfig(), cipherName: CipherAES256GCM}
 }
 
-// Encrypt encrypts a plaintext string using AES-GCM and returns the result as a base64-encoded string.
+// NewCryptoModule initializes and returns a new instance of CryptoModule with the given options.
+// Returns ErrInvalidKDFParams if the KDF configuration is invalid, or ErrUnsupportedCipher
+// if the cipher is unknown.
+func NewCryptoModule(opts Options) (*CryptoModule, error) {
+	if _, err := NewKDFParams(opts.KDF); err != nil {
+		return nil, err
+	}
+
+	cipherName := opts.Cipher
+	if cipherName == "" {
+		cipherName = CipherAES256GCM
+	}
+	if _, err := cipherID(cipherName); err != nil {
+		return nil, err
+	}
+
+	return &CryptoModule{kdf: opts.KDF, keys: opts.KeyProvider, cipherName: cipherName}, nil
+}
+
+// Encrypt encrypts a plaintext string using AES-GCM and returns the result as a base64-encoded string
+// in the legacy untagged format. Vault values are encrypted with EncryptBound.
 // The key is used to derive the encryption key.
 // Returns an error if the encryption process fails.
 func (c *CryptoModule) Encrypt(plaintext, key string) (string, error) {
-	keyBytes := c.deriveKey(key)
+	keyBytes, err := c.deriveKey(key)
+	if err != nil {
+		return "", err
+	}
 
 	block, err := aes.NewCipher(keyBytes)
 	if err != nil {
@@ -55,7 +97,10 @@ func (c *CryptoModule) Encrypt(plaintext, key string) (string, error) {
 // The key is used to derive the decryption key.
 // Returns an error if the decryption process fails or if the input data is invalid.
 func (c *CryptoModule) Decrypt(encryptedText, key string) (string, error) {
-	keyBytes := c.deriveKey(key)
+	keyBytes, err := c.deriveKey(key)
+	if err != nil {
+		return "", err
+	}
 
 	data, err := base64.StdEncoding.DecodeString(encryptedText)
 	if err != nil {
@@ -97,20 +142,29 @@ func (c *CryptoModule) GenerateSecretPhrase(txt string) string {
 	return encoded[:14]
 }
 
-// deriveKey derives a 32-byte key from the provided password by truncating or padding
-// the password to 32 bytes. This is a simple key derivation method and may not be
-// suitable for all use cases.
-func (c *CryptoModule) deriveKey(password string) []byte {
-	hash := make([]byte, 32)
-	copy(hash, []byte(password))
-	return hash
+// deriveKey returns the AES key for a key string. Keys produced by DeriveKey (see EncodeKey)
+// are decoded as-is. Any other value is a legacy secret phrase, which is truncated or
+// zero-padded to 32 bytes; this path only exists so that data encrypted before the KDF
+// was introduced can still be decrypted and migrated. The bound encryption functions do not
+// use it, so no new data is written with a legacy phrase.
+func (c *CryptoModule) deriveKey(key string) ([]byte, error) {
+	if strings.HasPrefix(key, keyPrefix) {
+		return decodeKey(key)
+	}
+
+	hash := make([]byte, KeySize)
+	copy(hash, []byte(key))
+	return hash, nil
 }
 
 // EncryptBinaryData encrypts binary data using AES-GCM and returns the encrypted data
-// with the nonce prepended. The key is used to derive the encryption key.
+// with the nonce prepended, in the legacy untagged format. The key is used to derive the encryption key.
 // Returns an error if the encryption process fails.
 func (c *CryptoModule) EncryptBinaryData(plaintext []byte, key string) ([]byte, error) {
-	keyBytes := c.deriveKey(key)
+	keyBytes, err := c.deriveKey(key)
+	if err != nil {
+		return nil, err
+	}
 
 	block, err := aes.NewCipher(keyBytes)
 	if err != nil {
@@ -135,7 +189,10 @@ func (c *CryptoModule) EncryptBinaryData(plaintext []byte, key string) ([]byte,
 // to be prepended to the encrypted data. The key is used to derive the decryption key.
 // Returns an error if the decryption process fails or if the input data is invalid.
 func (c *CryptoModule) DecryptBinaryData(encryptedData []byte, key string) ([]byte, error) {
-	keyBytes := c.deriveKey(key)
+	keyBytes, err := c.deriveKey(key)
+	if err != nil {
+		return nil, err
+	}
 
 	block, err := aes.NewCipher(keyBytes)
 	if err != nil {
@@ -0,0 +1,79 @@
+package cryptox
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Prefixes of server secrets, secrets the server itself must read such as TOTP secrets: stored as is
+// when the module has no key provider, or encrypted with a server master key version:
+// "ss1.<version>.<ciphertext>".
+const (
+	plainSecretPrefix  = "ps1."
+	sealedSecretPrefix = "ss1."
+)
+
+// ErrInvalidServerSecret is returned when a stored server secret is malformed.
+var ErrInvalidServerSecret = errors.New("invalid server secret")
+
+// SealServerSecret prepares a secret the server must be able to read without the user's password,
+// such as a TOTP secret, for storage. If the module has a key provider, the secret is encrypted with
+// the current server master key version, so that it cannot be read from the database alone.
+//
+// Returns:
+//   - The secret in its stored string form, or an error if the key provider fails.
+func (c *CryptoModule) SealServerSecret(secret string) (string, error) {
+	if c.keys == nil {
+		return plainSecretPrefix + secret, nil
+	}
+
+	data, version, err := c.keys.Encrypt([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%d.%s", sealedSecretPrefix, version, base64.RawStdEncoding.EncodeToString(data)), nil
+}
+
+// OpenServerSecret returns a secret stored by SealServerSecret.
+//
+// Returns:
+//   - The secret.
+//   - ErrServerKeyRequired if it was encrypted with a server master key but no key provider is
+//     configured, ErrUnknownKeyVersion if the provider no longer holds the master key version, or
+//     ErrInvalidServerSecret if it is malformed.
+func (c *CryptoModule) OpenServerSecret(stored string) (string, error) {
+	if secret, ok := strings.CutPrefix(stored, plainSecretPrefix); ok {
+		return secret, nil
+	}
+
+	rest, ok := strings.CutPrefix(stored, sealedSecretPrefix)
+	if !ok {
+		return "", ErrInvalidServerSecret
+	}
+
+	v, encoded, ok := strings.Cut(rest, ".")
+	version, err := strconv.Atoi(v)
+	if !ok || err != nil || version < 1 {
+		return "", ErrInvalidServerSecret
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidServerSecret
+	}
+
+	if c.keys == nil {
+		return "", ErrServerKeyRequired
+	}
+
+	secret, err := c.keys.Decrypt(data, version)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
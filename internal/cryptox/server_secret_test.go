@@ -0,0 +1,37 @@
+package cryptox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoModule_ServerSecret(t *testing.T) {
+	plain := NewCryproModule()
+	stored, err := plain.SealServerSecret("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	secret, err := plain.OpenServerSecret(stored)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+
+	sealing, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: testKeyProvider(t, 2)})
+	require.NoError(t, err)
+	stored, err = sealing.SealServerSecret("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stored, sealedSecretPrefix+"2."), stored)
+	assert.NotContains(t, stored, "JBSWY3DPEHPK3PXP")
+
+	secret, err = sealing.OpenServerSecret(stored)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+
+	_, err = plain.OpenServerSecret(stored)
+	assert.ErrorIs(t, err, ErrServerKeyRequired)
+
+	for _, malformed := range []string{"", "JBSWY3DPEHPK3PXP", "ss1.x.abc", "ss1.2.!!"} {
+		_, err = sealing.OpenServerSecret(malformed)
+		assert.ErrorIs(t, err, ErrInvalidServerSecret, malformed)
+	}
+}
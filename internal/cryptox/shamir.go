@@ -0,0 +1,126 @@
+package cryptox
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+// MaxShares is the largest number of shares a secret can be split into: every share has a distinct
+// non-zero x coordinate in GF(256).
+const MaxShares = 255
+
+// ErrInvalidShares is returned when a secret cannot be split with the requested parameters or a set
+// of shares is malformed.
+var ErrInvalidShares = errors.New("invalid secret shares")
+
+// SplitSecret splits a secret into shares with Shamir's secret sharing over GF(256), so that any
+// threshold of them reconstruct the secret and fewer reveal nothing about it. Every byte of the secret
+// is the constant term of its own random polynomial of degree threshold-1.
+//
+// A share is the x coordinate it was evaluated at followed by one y coordinate per secret byte; the
+// x coordinate of the i-th share is i+1.
+//
+// Parameters:
+//   - secret: The secret to split.
+//   - shares: The number of shares, at most MaxShares.
+//   - threshold: The number of shares needed to reconstruct the secret, at least 2 and at most shares.
+//
+// Returns:
+//   - The shares, or ErrInvalidShares for invalid parameters, or an error if the random source fails.
+func SplitSecret(secret []byte, shares, threshold int) ([][]byte, error) {
+	if len(secret) == 0 || threshold < 2 || threshold > shares || shares > MaxShares {
+		return nil, ErrInvalidShares
+	}
+
+	out := make([][]byte, shares)
+	for i := range out {
+		out[i] = make([]byte, len(secret)+1)
+		out[i][0] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold-1)
+	for b, value := range secret {
+		if _, err := rand.Read(coefficients); err != nil {
+			return nil, err
+		}
+		for _, share := range out {
+			share[b+1] = evalPolynomial(value, coefficients, share[0])
+		}
+	}
+	clear(coefficients)
+
+	return out, nil
+}
+
+// CombineShares reconstructs a secret split by SplitSecret from at least threshold of its shares by
+// Lagrange interpolation at x = 0. Fewer shares, or shares of different secrets, give a wrong secret
+// rather than an error, so callers must verify the result.
+//
+// Returns:
+//   - The secret, or ErrInvalidShares if there are fewer than two shares, their lengths differ or two
+//     shares have the same x coordinate.
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 || len(shares[0]) < 2 {
+		return nil, ErrInvalidShares
+	}
+
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share) != len(shares[0]) || share[0] == 0 || seen[share[0]] {
+			return nil, ErrInvalidShares
+		}
+		seen[share[0]] = true
+	}
+
+	secret := make([]byte, len(shares[0])-1)
+	for i, share := range shares {
+		// basis is the Lagrange basis polynomial of the share evaluated at x = 0.
+		basis := byte(1)
+		for j, other := range shares {
+			if i != j {
+				basis = gfMul(basis, gfMul(other[0], gfInv(other[0]^share[0])))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= gfMul(share[b+1], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// evalPolynomial evaluates the polynomial with the given constant term and higher coefficients at x
+// with Horner's method.
+func evalPolynomial(constant byte, coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ coefficients[i]
+	}
+
+	return gfMul(y, x) ^ constant
+}
+
+// gfMul multiplies two elements of GF(256) with the AES reduction polynomial x^8+x^4+x^3+x+1.
+// It has no secret-dependent branches or table lookups.
+func gfMul(a, b byte) byte {
+	var p byte
+	for range 8 {
+		p ^= -(b & 1) & a
+		a = a<<1 ^ -(a>>7)&0x1b
+		b >>= 1
+	}
+
+	return p
+}
+
+// gfInv returns the multiplicative inverse of a non-zero element of GF(256) as a^254.
+func gfInv(a byte) byte {
+	square := gfMul(a, a)
+	inverse := square
+	for range 6 {
+		square = gfMul(square, square)
+		inverse = gfMul(inverse, square)
+	}
+
+	return inverse
+}
@@ -0,0 +1,65 @@
+package cryptox
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGFInv(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		assert.Equal(t, byte(1), gfMul(byte(a), gfInv(byte(a))), a)
+	}
+	assert.Equal(t, byte(0xc1), gfMul(0x57, 0x83), "FIPS-197 multiplication example")
+}
+
+func TestSplitSecret_AnyThresholdSubsetCombines(t *testing.T) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+
+	shares, err := SplitSecret(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+	for i, share := range shares {
+		assert.Equal(t, byte(i+1), share[0])
+		assert.Len(t, share, 33)
+	}
+
+	for _, subset := range [][]int{{0, 1, 2}, {0, 2, 4}, {4, 3, 1}, {0, 1, 2, 3, 4}} {
+		picked := make([][]byte, 0, len(subset))
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		combined, err := CombineShares(picked)
+		require.NoError(t, err)
+		assert.Equal(t, secret, combined, subset)
+	}
+
+	combined, err := CombineShares(shares[:2])
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, combined, "Fewer shares than the threshold should not reconstruct the secret")
+}
+
+func TestSplitSecret_Invalid(t *testing.T) {
+	for _, tc := range []struct{ shares, threshold int }{{3, 1}, {2, 3}, {256, 2}} {
+		_, err := SplitSecret([]byte("secret"), tc.shares, tc.threshold)
+		assert.ErrorIs(t, err, ErrInvalidShares, tc)
+	}
+	_, err := SplitSecret(nil, 3, 2)
+	assert.ErrorIs(t, err, ErrInvalidShares)
+
+	shares, err := SplitSecret([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+	for _, invalid := range [][][]byte{
+		{shares[0]},
+		{shares[0], shares[0]},
+		{shares[0], shares[1][:3]},
+		{shares[0], append([]byte{0}, shares[1][1:]...)},
+	} {
+		_, err = CombineShares(invalid)
+		assert.ErrorIs(t, err, ErrInvalidShares)
+	}
+}
@@ -0,0 +1,177 @@
+package cryptox
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Prefixes of X25519 public keys and of keys sealed to them.
+const (
+	publicKeyPrefix = "x1."
+	sealedKeyPrefix = "s1."
+)
+
+// sealInfo separates keys derived for sealing item keys from any other use of the shared secret.
+const sealInfo = "gophkeeper/sealed-key"
+
+// ErrInvalidPublicKey is returned when a public key is malformed.
+var ErrInvalidPublicKey = errors.New("invalid public key")
+
+// GenerateKeyPair generates an X25519 key pair used to share vault items. The private key is encoded
+// like a data key, so it can be wrapped with WrapKey by the owner's data key and stored.
+//
+// Returns:
+//   - The public key in its stored string form, the private key, or an error if the random source fails.
+func (c *CryptoModule) GenerateKeyPair() (string, string, error) {
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	publicKey := publicKeyPrefix + base64.RawURLEncoding.EncodeToString(private.PublicKey().Bytes())
+
+	return publicKey, EncodeKey(private.Bytes()), nil
+}
+
+// SealKey encrypts a key to a recipient's public key, so that only the holder of the matching private
+// key can recover it. An ephemeral X25519 key agreement with the recipient's key is expanded with
+// HKDF-SHA256 into a one-time AES-GCM key; the ephemeral public key is stored with the ciphertext.
+//
+// Parameters:
+//   - key: The key to seal, as returned by GenerateDataKey.
+//   - publicKey: The recipient's public key, as returned by GenerateKeyPair.
+//
+// Returns:
+//   - The sealed key in its stored string form.
+//   - ErrInvalidPublicKey if the public key is malformed, or an error if the key is invalid.
+func (c *CryptoModule) SealKey(key, publicKey string) (string, error) {
+	keyBytes, err := decodeKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	recipient, err := decodePublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return "", err
+	}
+
+	sealKey, err := deriveSealKey(secret, ephemeral.PublicKey(), recipient)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(sealKey, keyBytes, nil)
+	if err != nil {
+		return "", err
+	}
+
+	data := append(ephemeral.PublicKey().Bytes(), sealed...)
+
+	return sealedKeyPrefix + base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+// OpenSealedKey decrypts a key sealed by SealKey with the recipient's private key.
+//
+// Parameters:
+//   - sealed: The sealed key in its stored string form.
+//   - privateKey: The recipient's private key, as returned by GenerateKeyPair.
+//
+// Returns:
+//   - The key string accepted by Encrypt and Decrypt, or an error if the private key does not
+//     match or the sealed key is malformed.
+func (c *CryptoModule) OpenSealedKey(sealed, privateKey string) (string, error) {
+	privateBytes, err := decodeKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	private, err := ecdh.X25519().NewPrivateKey(privateBytes)
+	if err != nil {
+		return "", ErrInvalidKey
+	}
+
+	encoded, ok := strings.CutPrefix(sealed, sealedKeyPrefix)
+	if !ok {
+		return "", ErrInvalidKey
+	}
+
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < 32 {
+		return "", ErrInvalidKey
+	}
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(data[:32])
+	if err != nil {
+		return "", ErrInvalidKey
+	}
+
+	secret, err := private.ECDH(ephemeral)
+	if err != nil {
+		return "", ErrInvalidKey
+	}
+
+	sealKey, err := deriveSealKey(secret, ephemeral, private.PublicKey())
+	if err != nil {
+		return "", err
+	}
+
+	key, err := open(sealKey, data[32:], nil)
+	if err != nil {
+		return "", err
+	}
+	if len(key) != KeySize {
+		return "", ErrInvalidKey
+	}
+
+	return EncodeKey(key), nil
+}
+
+// deriveSealKey derives the AES key of a sealed key from an X25519 shared secret, salted with
+// the ephemeral and the recipient's public keys.
+func deriveSealKey(secret []byte, ephemeral, recipient *ecdh.PublicKey) ([]byte, error) {
+	salt := append(ephemeral.Bytes(), recipient.Bytes()...)
+
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(sealInfo)), key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// decodePublicKey decodes a public key produced by GenerateKeyPair.
+func decodePublicKey(publicKey string) (*ecdh.PublicKey, error) {
+	encoded, ok := strings.CutPrefix(publicKey, publicKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidPublicKey
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	key, err := ecdh.X25519().NewPublicKey(data)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	return key, nil
+}
@@ -0,0 +1,68 @@
+package cryptox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoModule_SealKey(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	publicKey, privateKey, err := cryptoModule.GenerateKeyPair()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(publicKey, publicKeyPrefix), publicKey)
+
+	itemKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	sealed, err := cryptoModule.SealKey(itemKey, publicKey)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sealed, sealedKeyPrefix), sealed)
+
+	again, err := cryptoModule.SealKey(itemKey, publicKey)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "Every seal should use a fresh ephemeral key")
+
+	opened, err := cryptoModule.OpenSealedKey(sealed, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, itemKey, opened)
+
+	_, otherPrivate, err := cryptoModule.GenerateKeyPair()
+	require.NoError(t, err)
+	_, err = cryptoModule.OpenSealedKey(sealed, otherPrivate)
+	assert.Error(t, err, "Another private key should not open the sealed key")
+
+	_, err = cryptoModule.OpenSealedKey(sealed[:len(sealed)-4], privateKey)
+	assert.Error(t, err, "A truncated sealed key should be rejected")
+}
+
+func TestCryptoModule_SealKey_InvalidPublicKey(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	itemKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	for _, publicKey := range []string{"", "x1.short", itemKey} {
+		_, err = cryptoModule.SealKey(itemKey, publicKey)
+		assert.ErrorIs(t, err, ErrInvalidPublicKey, publicKey)
+	}
+}
+
+func TestCryptoModule_KeyPair_WrapPrivateKey(t *testing.T) {
+	cryptoModule := NewCryproModule()
+
+	_, privateKey, err := cryptoModule.GenerateKeyPair()
+	require.NoError(t, err)
+	dataKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := cryptoModule.WrapKey(privateKey, dataKey)
+	require.NoError(t, err, "The private key should be wrappable by the vault data key")
+
+	unwrapped, err := cryptoModule.UnwrapKey(wrapped, dataKey)
+	require.NoError(t, err)
+	assert.Equal(t, privateKey, unwrapped)
+}
@@ -0,0 +1,190 @@
+package cryptox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default settings of the HashiCorp Vault transit key provider.
+const (
+	DefaultVaultTransitMount = "transit"
+	DefaultVaultTransitKey   = "gophkeeper"
+)
+
+// vaultVersionTTL is how long the latest transit key version is cached.
+const vaultVersionTTL = time.Minute
+
+// VaultTransitConfig configures a VaultTransitProvider.
+type VaultTransitConfig struct {
+	Address string        // Vault address, e.g. "https://vault.example.com:8200".
+	Token   string        // Vault token allowed to encrypt, decrypt and read the transit key.
+	Mount   string        // Mount path of the transit secrets engine; DefaultVaultTransitMount if empty.
+	Key     string        // Name of the transit key; DefaultVaultTransitKey if empty.
+	Timeout time.Duration // HTTP request timeout; 10 seconds if zero.
+}
+
+// VaultTransitProvider is a KeyProvider backed by the transit secrets engine of HashiCorp Vault.
+// The master key never leaves Vault, and key versions are those of the transit key, so it is
+// rotated with `vault write -f <mount>/keys/<key>/rotate`.
+type VaultTransitProvider struct {
+	cfg    VaultTransitConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	latest    int       // Cached latest transit key version.
+	fetchedAt time.Time // When latest was fetched.
+}
+
+// NewVaultTransitProvider creates a VaultTransitProvider.
+//
+// Returns:
+//   - The provider, or an error if the address or the token is missing.
+func NewVaultTransitProvider(cfg VaultTransitConfig) (*VaultTransitProvider, error) {
+	if cfg.Address == "" || cfg.Token == "" {
+		return nil, errors.New("vault transit: address and token are required")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = DefaultVaultTransitMount
+	}
+	if cfg.Key == "" {
+		cfg.Key = DefaultVaultTransitKey
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.Address = strings.TrimRight(cfg.Address, "/")
+
+	return &VaultTransitProvider{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+// Encrypt encrypts plaintext with the latest version of the transit key.
+// The returned ciphertext is Vault's "vault:v<N>:..." string.
+func (p *VaultTransitProvider) Encrypt(plaintext []byte) ([]byte, int, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+	if err := p.do(http.MethodPost, "encrypt", body, &resp); err != nil {
+		return nil, 0, err
+	}
+
+	version, err := vaultCiphertextVersion(resp.Data.Ciphertext)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return []byte(resp.Data.Ciphertext), version, nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt. The version is encoded in the
+// ciphertext itself and is only checked for consistency.
+func (p *VaultTransitProvider) Decrypt(ciphertext []byte, version int) ([]byte, error) {
+	embedded, err := vaultCiphertextVersion(string(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	if embedded != version {
+		return nil, fmt.Errorf("%w: ciphertext is v%d, expected v%d", ErrUnknownKeyVersion, embedded, version)
+	}
+
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+
+	body := map[string]string{"ciphertext": string(ciphertext)}
+	if err := p.do(http.MethodPost, "decrypt", body, &resp); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+// CurrentVersion returns the latest version of the transit key. The value is cached for a minute.
+func (p *VaultTransitProvider) CurrentVersion() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.latest > 0 && time.Since(p.fetchedAt) < vaultVersionTTL {
+		return p.latest, nil
+	}
+
+	var resp struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+		} `json:"data"`
+	}
+	if err := p.do(http.MethodGet, "keys", nil, &resp); err != nil {
+		return 0, err
+	}
+
+	p.latest, p.fetchedAt = resp.Data.LatestVersion, time.Now()
+
+	return p.latest, nil
+}
+
+// do calls the transit endpoint "<mount>/<action>/<key>" and decodes the JSON response into out.
+func (p *VaultTransitProvider) do(method, action string, body any, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", p.cfg.Address, p.cfg.Mount, action, p.cfg.Key)
+	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", p.cfg.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&vaultErr)
+		return fmt.Errorf("vault transit %s: status %d: %s", action, resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("vault transit %s: decode response: %w", action, err)
+	}
+
+	return nil
+}
+
+// vaultCiphertextVersion extracts N from a "vault:v<N>:..." ciphertext.
+func vaultCiphertextVersion(ciphertext string) (int, error) {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" || !strings.HasPrefix(parts[1], "v") {
+		return 0, fmt.Errorf("%w: malformed vault ciphertext", ErrInvalidKey)
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("%w: malformed vault ciphertext version", ErrInvalidKey)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,107 @@
+//go:build integration
+
+package cryptox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// setupVault starts a dev-mode Vault server with the transit engine and a "gophkeeper" key.
+func setupVault(t *testing.T) string {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "hashicorp/vault:1.15",
+		ExposedPorts: []string{"8200/tcp"},
+		Env: map[string]string{
+			"VAULT_DEV_ROOT_TOKEN_ID":  "root",
+			"VAULT_DEV_LISTEN_ADDRESS": "0.0.0.0:8200",
+		},
+		WaitingFor: wait.ForHTTP("/v1/sys/health").WithPort("8200/tcp"),
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	require.NoError(t, err, "Failed to start Vault container")
+	t.Cleanup(func() {
+		err := container.Terminate(ctx)
+		require.NoError(t, err, "Failed to stop container")
+	})
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err, "Failed to get container host")
+
+	port, err := container.MappedPort(ctx, "8200")
+	require.NoError(t, err, "Failed to get mapped port")
+
+	address := fmt.Sprintf("http://%s:%s", host, port.Port())
+
+	vaultRequest(t, address, "/v1/sys/mounts/transit", `{"type":"transit"}`)
+	vaultRequest(t, address, "/v1/transit/keys/gophkeeper", `{}`)
+
+	return address
+}
+
+// vaultRequest sends an authenticated POST request to Vault.
+func vaultRequest(t *testing.T, address, path, body string) {
+	req, err := http.NewRequest(http.MethodPost, address+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Vault-Token", "root")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Less(t, resp.StatusCode, 300, "Vault request %s failed", path)
+}
+
+func TestVaultTransitProvider_DevServer(t *testing.T) {
+	address := setupVault(t)
+
+	provider, err := NewVaultTransitProvider(VaultTransitConfig{Address: address, Token: "root"})
+	require.NoError(t, err)
+
+	cryptoModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: provider})
+	require.NoError(t, err)
+
+	dataKey, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+	kek, err := cryptoModule.GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := cryptoModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapped, versionedServerPrefix+"1."), wrapped)
+
+	unwrapped, err := cryptoModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	vaultRequest(t, address, "/v1/transit/keys/gophkeeper/rotate", `{}`)
+
+	rotated, err := NewVaultTransitProvider(VaultTransitConfig{Address: address, Token: "root"})
+	require.NoError(t, err)
+	rotatedModule, err := NewCryptoModule(Options{KDF: DefaultKDFConfig(), KeyProvider: rotated})
+	require.NoError(t, err)
+
+	assert.True(t, rotatedModule.NeedsRewrap(wrapped), "A key wrapped before the rotation should be re-wrapped")
+
+	unwrapped, err = rotatedModule.UnwrapKey(wrapped, kek)
+	require.NoError(t, err, "Old transit key versions should still decrypt")
+	assert.Equal(t, dataKey, unwrapped)
+
+	rewrapped, err := rotatedModule.WrapKey(dataKey, kek)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rewrapped, versionedServerPrefix+"2."), rewrapped)
+	assert.False(t, rotatedModule.NeedsRewrap(rewrapped))
+}
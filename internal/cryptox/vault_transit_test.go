@@ -0,0 +1,87 @@
+package cryptox
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransit emulates the subset of the Vault transit API used by VaultTransitProvider.
+// Ciphertexts are "vault:v<N>:<base64 plaintext>".
+type fakeTransit struct {
+	latest int
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "root" {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors":["permission denied"]}`)
+		return
+	}
+
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	switch r.URL.Path {
+	case "/v1/transit/encrypt/gophkeeper":
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"ciphertext": fmt.Sprintf("vault:v%d:%s", f.latest, body["plaintext"]),
+		}})
+	case "/v1/transit/decrypt/gophkeeper":
+		parts := strings.SplitN(body["ciphertext"], ":", 3)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"plaintext": parts[2]}})
+	case "/v1/transit/keys/gophkeeper":
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"latest_version": f.latest}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVaultTransitProvider(t *testing.T) {
+	server := httptest.NewServer(&fakeTransit{latest: 2})
+	defer server.Close()
+
+	provider, err := NewVaultTransitProvider(VaultTransitConfig{Address: server.URL + "/", Token: "root"})
+	require.NoError(t, err)
+
+	ciphertext, version, err := provider.Encrypt([]byte("data key"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.Equal(t, "vault:v2:"+base64.StdEncoding.EncodeToString([]byte("data key")), string(ciphertext))
+
+	plaintext, err := provider.Decrypt(ciphertext, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data key"), plaintext)
+
+	_, err = provider.Decrypt(ciphertext, 1)
+	assert.ErrorIs(t, err, ErrUnknownKeyVersion)
+
+	current, err := provider.CurrentVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 2, current)
+}
+
+func TestVaultTransitProvider_Errors(t *testing.T) {
+	server := httptest.NewServer(&fakeTransit{latest: 1})
+	defer server.Close()
+
+	_, err := NewVaultTransitProvider(VaultTransitConfig{Address: server.URL})
+	assert.Error(t, err, "A token should be required")
+
+	provider, err := NewVaultTransitProvider(VaultTransitConfig{Address: server.URL, Token: "wrong"})
+	require.NoError(t, err)
+
+	_, _, err = provider.Encrypt([]byte("data key"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+
+	_, err = provider.Decrypt([]byte("not-a-vault-ciphertext"), 1)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,70 @@
+package dto
+
+import "time"
+
+type AuditOptionsDTO struct {
+	StaleDays    int     `json:"stale_days"`
+	ExpiringDays int     `json:"expiring_days"`
+	MinEntropy   float64 `json:"min_entropy"`
+}
+
+type AuditReportDTO struct {
+	GeneratedAt       time.Time              `json:"generated_at"`
+	Options           AuditOptionsDTO        `json:"options"`
+	Summary           AuditSummaryDTO        `json:"summary"`
+	ReusedPasswords   []AuditReuseGroupDTO   `json:"reused_passwords"`
+	WeakPasswords     []AuditWeakPasswordDTO `json:"weak_passwords"`
+	BreachedPasswords []AuditEntryDTO        `json:"breached_passwords"`
+	StalePasswords    []AuditAgedEntryDTO    `json:"stale_passwords"`
+	ExpiringCards     []AuditCardDTO         `json:"expiring_cards"`
+	DecryptFailures   []AuditFailureDTO      `json:"decrypt_failures"`
+}
+
+type AuditSummaryDTO struct {
+	LogoPasses      int `json:"logo_passes"`
+	Cards           int `json:"cards"`
+	Reused          int `json:"reused"`
+	Weak            int `json:"weak"`
+	Breached        int `json:"breached"`
+	Stale           int `json:"stale"`
+	ExpiringCards   int `json:"expiring_cards"`
+	ExpiredCards    int `json:"expired_cards"`
+	DecryptFailures int `json:"decrypt_failures"`
+}
+
+type AuditEntryDTO struct {
+	ID       int    `json:"id"`
+	AppName  string `json:"app_name"`
+	Username string `json:"username"`
+}
+
+type AuditReuseGroupDTO struct {
+	Entries []AuditEntryDTO `json:"entries"`
+}
+
+type AuditWeakPasswordDTO struct {
+	AuditEntryDTO
+	EntropyBits float64 `json:"entropy_bits"`
+}
+
+type AuditAgedEntryDTO struct {
+	AuditEntryDTO
+	UpdatedAt time.Time `json:"updated_at"`
+	AgeDays   int       `json:"age_days"`
+}
+
+type AuditCardDTO struct {
+	ID       int    `json:"id"`
+	BankName string `json:"bank_name"`
+	Number   string `json:"num"`
+	Brand    string `json:"brand"`
+	ExpDate  string `json:"exp_date"`
+	Expired  bool   `json:"expired"`
+	DaysLeft int    `json:"days_left"`
+}
+
+type AuditFailureDTO struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
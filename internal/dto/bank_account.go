@@ -0,0 +1,20 @@
+package dto
+
+type CreateBankAccountDTO struct {
+	ID            int64  `json:"-"`
+	UserID        int    `json:"user_id"`
+	BankName      string `json:"bank_name"`
+	AccountHolder string `json:"account_holder"`
+	IBAN          string `json:"iban"`
+	BIC           string `json:"bic"`
+	Key           string
+}
+
+type UpdateBankAccountDTO struct {
+	UserID        int64  `json:"-"`
+	BankName      string `json:"bank_name"`
+	AccountHolder string `json:"account_holder"`
+	IBAN          string `json:"iban"`
+	BIC           string `json:"bic"`
+	Key           string
+}
@@ -8,6 +8,7 @@ type CreateBinaryDTO struct {
 }
 
 type SetStorageBinaryDTO struct {
+	ID     int64  `json:"-"`
 	UserID int    `json:"user_id"`
 	Title  string `json:"title"`
 	Data   []byte `json:"data"`
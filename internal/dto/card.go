@@ -1,19 +1,23 @@
 package dto
 
 type CreateCardDTO struct {
+	ID             int64  `json:"-"`
 	UserID         int    `json:"user_id"`
 	BankName       string `json:"bank_name"`
 	Num            string `json:"num"`
 	CVV            string `json:"cvv"`
 	ExpDate        string `json:"exp_date"`
 	CardHolderName string `json:"card_holder_name"`
+	Brand          string `json:"-"`
 	Key            string
 }
 
 type UpdateCardDTO struct {
+	UserID         int64  `json:"-"`
 	Num            string `json:"num"`
 	CVV            string `json:"cvv"`
 	ExpDate        string `json:"exp_date"`
 	CardHolderName string `json:"card_holder_name"`
+	Brand          string `json:"-"`
 	Key            string
 }
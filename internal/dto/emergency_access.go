@@ -0,0 +1,32 @@
+package dto
+
+import "github.com/Zrossiz/gophkeeper/internal/entities"
+
+// CreateEmergencyAccessDTO carries a request of the authenticated user to designate an emergency
+// contact. AccessType is "view" by default and a missing WaitDays takes the configured default.
+type CreateEmergencyAccessDTO struct {
+	GrantorID  int64  `json:"-"`
+	Key        string `json:"-"`
+	Username   string `json:"username"`
+	AccessType string `json:"access_type"`
+	WaitDays   *int   `json:"wait_days"`
+}
+
+// EmergencyTakeoverDTO carries a request of an emergency contact with takeover access to set a new
+// master password for the grantor's account.
+type EmergencyTakeoverDTO struct {
+	ID          int64  `json:"-"`
+	GranteeID   int64  `json:"-"`
+	Key         string `json:"-"`
+	NewPassword string `json:"new_password"`
+}
+
+// EmergencyVaultDTO is the decrypted vault of a grantor as seen by an emergency contact.
+type EmergencyVaultDTO struct {
+	LogoPasses        []entities.LogoPassword     `json:"logopasses"`
+	Cards             []entities.Card             `json:"cards"`
+	Notes             []entities.Note             `json:"notes"`
+	Binaries          []entities.BinaryData       `json:"binaries"`
+	BankAccounts      []entities.BankAccount      `json:"bank_accounts"`
+	IdentityDocuments []entities.IdentityDocument `json:"identity_documents"`
+}
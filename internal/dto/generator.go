@@ -0,0 +1,31 @@
+package dto
+
+type GeneratePasswordDTO struct {
+	Length           int               `json:"length"`
+	Classes          []string          `json:"classes"`
+	ExcludeAmbiguous bool              `json:"exclude_ambiguous"`
+	Exclude          string            `json:"exclude"`
+	SaveAs           *SaveGeneratedDTO `json:"save_as,omitempty"`
+	Key              string
+}
+
+type GeneratePassphraseDTO struct {
+	Words      int               `json:"words"`
+	Separator  string            `json:"separator"`
+	Capitalize bool              `json:"capitalize"`
+	WithNumber bool              `json:"with_number"`
+	SaveAs     *SaveGeneratedDTO `json:"save_as,omitempty"`
+	Key        string
+}
+
+type SaveGeneratedDTO struct {
+	UserId   int    `json:"user_id"`
+	AppName  string `json:"app_name"`
+	Username string `json:"username"`
+}
+
+type GeneratedSecretDTO struct {
+	Secret      string  `json:"secret"`
+	EntropyBits float64 `json:"entropy_bits"`
+	Saved       bool    `json:"saved"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+type CreateIdentityDocumentDTO struct {
+	ID             int64  `json:"-"`
+	UserID         int    `json:"user_id"`
+	DocType        string `json:"doc_type"`
+	Number         string `json:"number"`
+	HolderName     string `json:"holder_name"`
+	IssuingCountry string `json:"issuing_country"`
+	IssueDate      string `json:"issue_date"`
+	ExpiryDate     string `json:"expiry_date"`
+	Key            string
+}
+
+type UpdateIdentityDocumentDTO struct {
+	UserID         int64  `json:"-"`
+	Number         string `json:"number"`
+	HolderName     string `json:"holder_name"`
+	IssuingCountry string `json:"issuing_country"`
+	IssueDate      string `json:"issue_date"`
+	ExpiryDate     string `json:"expiry_date"`
+	Key            string
+}
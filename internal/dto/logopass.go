@@ -1,15 +1,40 @@
 package dto
 
+import "github.com/Zrossiz/gophkeeper/internal/entities"
+
 type CreateLogoPassDTO struct {
-	UserId   int    `json:"user_id"`
-	AppName  string `json:"app_name"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Key      string
+	ID            int64                  `json:"-"`
+	UserId        int                    `json:"user_id"`
+	AppName       string                 `json:"app_name"`
+	Username      string                 `json:"username"`
+	Password      string                 `json:"password"`
+	URIs          []entities.LogoPassURI `json:"uris"`
+	EncryptedURIs string                 `json:"-"`
+	Key           string
 }
 
 type UpdateLogoPassDTO struct {
-	Username string `json:"username"`
+	UserID        int64                  `json:"-"`
+	Username      string                 `json:"username"`
+	Password      string                 `json:"password"`
+	URIs          []entities.LogoPassURI `json:"uris"`
+	EncryptedURIs string                 `json:"-"`
+	Key           string
+}
+
+type LogoPassMatchDTO struct {
+	entities.LogoPassword
+	MatchedURI string `json:"matched_uri"`
+	Match      string `json:"match"`
+	Score      int    `json:"score"`
+}
+
+type CheckPasswordDTO struct {
 	Password string `json:"password"`
-	Key      string
+}
+
+type BreachCheckDTO struct {
+	Checked     bool   `json:"checked"`
+	Breached    bool   `json:"breached"`
+	Occurrences uint32 `json:"occurrences"`
 }
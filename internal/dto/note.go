@@ -1,6 +1,7 @@
 package dto
 
 type CreateNoteDTO struct {
+	ID       int64  `json:"-"`
 	UserID   int    `json:"user_id"`
 	Title    string `json:"title"`
 	TextData string `json:"text_data"`
@@ -8,6 +9,7 @@ type CreateNoteDTO struct {
 }
 
 type UpdateNoteDTO struct {
+	UserID   int64  `json:"-"`
 	Title    string `json:"title"`
 	TextData string `json:"text_data"`
 	Key      string
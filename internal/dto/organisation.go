@@ -0,0 +1,103 @@
+package dto
+
+import "github.com/Zrossiz/gophkeeper/internal/entities"
+
+// CreateOrgDTO carries a request to create an organisation, owned by the authenticated user.
+type CreateOrgDTO struct {
+	UserID int64  `json:"-"`
+	Name   string `json:"name"`
+}
+
+// AddOrgMemberDTO carries a request of an organisation admin to add a user with a role.
+type AddOrgMemberDTO struct {
+	OrgID    int64  `json:"-"`
+	ActorID  int64  `json:"-"`
+	Key      string `json:"-"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// UpdateOrgMemberDTO carries a request of an organisation admin to change the role of a member.
+type UpdateOrgMemberDTO struct {
+	OrgID   int64  `json:"-"`
+	ActorID int64  `json:"-"`
+	UserID  int64  `json:"-"`
+	Role    string `json:"role"`
+}
+
+// RemoveOrgMemberDTO carries a request to remove a member from an organisation, or to leave it if
+// UserID is ActorID.
+type RemoveOrgMemberDTO struct {
+	OrgID   int64
+	ActorID int64
+	UserID  int64
+	Key     string
+}
+
+// RewrappedKeyDTO is the item key of a collection item wrapped by a new organisation key. OldKey is
+// the item key wrapped by the previous organisation key, as it was read.
+type RewrappedKeyDTO struct {
+	ItemType string
+	ItemID   int64
+	OldKey   string
+	NewKey   string
+}
+
+// RotateOrgKeyDTO describes the removal of a member as stored. The organisation key is replaced:
+// Members holds the new key sealed to each remaining member by user ID, and Items the item keys of
+// every collection item not owned by the removed member, wrapped by the new key. KeyVersion is the
+// version of the organisation key that was replaced.
+type RotateOrgKeyDTO struct {
+	OrgID      int64
+	UserID     int64
+	KeyVersion int
+	Members    map[int64]string
+	Items      []RewrappedKeyDTO
+}
+
+// CreateCollectionDTO carries a request of an organisation admin to create a collection.
+type CreateCollectionDTO struct {
+	OrgID   int64  `json:"-"`
+	ActorID int64  `json:"-"`
+	Name    string `json:"name"`
+}
+
+// AddCollectionItemDTO carries a request of an organisation member to add one of their items to a collection.
+type AddCollectionItemDTO struct {
+	CollectionID int64  `json:"-"`
+	ActorID      int64  `json:"-"`
+	Key          string `json:"-"`
+	ItemType     string `json:"item_type"`
+	ItemID       int64  `json:"item_id"`
+}
+
+// StoreCollectionItemDTO describes a collection item as stored. WrappedKey is the item key wrapped
+// by the organisation key of version KeyVersion, and ItemKey the item key wrapped by the owner's data
+// key. NewItemKey is set when the item had no item key yet: the item is re-encrypted with it.
+type StoreCollectionItemDTO struct {
+	CollectionID int64
+	KeyVersion   int
+	OwnerID      int64
+	ItemType     string
+	ItemID       int64
+	WrappedKey   string
+	ItemKey      string
+	NewItemKey   bool
+}
+
+// CollectionItemDTO is a decrypted collection item. Fields holds its columns by name; binary values
+// are base64-encoded.
+type CollectionItemDTO struct {
+	entities.CollectionItem
+	Fields map[string]string `json:"fields"`
+}
+
+// UpdateCollectionItemDTO carries new values of the encrypted fields of a collection item.
+type UpdateCollectionItemDTO struct {
+	CollectionID int64             `json:"-"`
+	ActorID      int64             `json:"-"`
+	Key          string            `json:"-"`
+	ItemType     string            `json:"-"`
+	ItemID       int64             `json:"-"`
+	Fields       map[string]string `json:"fields"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// CreateRecoverySharesDTO carries a request of the authenticated user to split their recovery key
+// among officers, any Threshold of whom can reconstruct it.
+type CreateRecoverySharesDTO struct {
+	UserID    int64    `json:"-"`
+	Key       string   `json:"-"`
+	Threshold int      `json:"threshold"`
+	Officers  []string `json:"officers"`
+}
+
+// StartRecoverySessionDTO carries a request to start collecting the recovery shares of an account.
+type StartRecoverySessionDTO struct {
+	Username string `json:"username"`
+}
+
+// RecoverySessionDTO is a started recovery session. SessionKey opens the shares submitted to it and
+// is shown once.
+type RecoverySessionDTO struct {
+	ID         int64     `json:"id"`
+	SessionKey string    `json:"session_key"`
+	Threshold  int       `json:"threshold"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SubmitRecoveryShareDTO carries a request of a recovery officer to submit their share to a session.
+type SubmitRecoveryShareDTO struct {
+	SessionID int64
+	OfficerID int64
+	Key       string
+}
+
+// CompleteRecoveryDTO carries a request to reset the master password of an account with the shares
+// submitted to a recovery session.
+type CompleteRecoveryDTO struct {
+	SessionID   int64  `json:"-"`
+	SessionKey  string `json:"session_key"`
+	NewPassword string `json:"new_password"`
+	DeviceDTO
+}
@@ -0,0 +1,17 @@
+package dto
+
+// ReencryptionThrottleDTO sets how fast the re-encryption job walks the database.
+// Unset values keep the current setting, or the configured default for a new job.
+type ReencryptionThrottleDTO struct {
+	BatchSize    *int `json:"batch_size,omitempty"`     // Rows processed per batch.
+	BatchDelayMS *int `json:"batch_delay_ms,omitempty"` // Pause between batches, in milliseconds.
+}
+
+// VaultRowDTO carries the encrypted values of a vault row scanned by the re-encryption job,
+// in the column order of the table.
+type VaultRowDTO struct {
+	UserID int64
+	ItemID int64
+	Text   []string // Base64 ciphertexts.
+	Binary [][]byte // Raw ciphertexts.
+}
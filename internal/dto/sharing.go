@@ -0,0 +1,62 @@
+package dto
+
+import "github.com/Zrossiz/gophkeeper/internal/entities"
+
+// KeyPairDTO carries a user's public key and their private key wrapped by their vault data key.
+type KeyPairDTO struct {
+	PublicKey         string
+	WrappedPrivateKey string
+}
+
+// ShareItemDTO carries a request of an item owner to share it with another user.
+type ShareItemDTO struct {
+	OwnerID    int64  `json:"-"`
+	Key        string `json:"-"`
+	ItemType   string `json:"item_type"`
+	ItemID     int64  `json:"item_id"`
+	Username   string `json:"username"`
+	Permission string `json:"permission"`
+}
+
+// CreateShareDTO describes a share as stored. ItemKey is set when the item is shared for the first
+// time: it is the new item key wrapped by the owner's data key, and the item is re-encrypted with it.
+type CreateShareDTO struct {
+	OwnerID     int64
+	RecipientID int64
+	ItemType    string
+	ItemID      int64
+	Permission  string
+	WrappedKey  string // Item key sealed to the recipient's public key.
+	ItemKey     string
+}
+
+// RevokeShareDTO describes the revocation of a share as stored. The item is re-encrypted: with a new
+// item key wrapped by the owner's data key in ItemKey and sealed again for the remaining recipients
+// in Resealed (by share ID), or, if no recipient remains, with the owner's data key and ItemKey empty.
+// If the item is in an organisation collection, CollectionKey is the new item key wrapped by the
+// organisation key of version KeyVersion.
+type RevokeShareDTO struct {
+	ShareID       int64
+	OwnerID       int64
+	ItemType      string
+	ItemID        int64
+	ItemKey       string
+	Resealed      map[int64]string
+	CollectionKey string
+	KeyVersion    int
+}
+
+// SharedItemDTO is a decrypted vault item shared with the authenticated user. Fields holds its
+// columns by name; binary values are base64-encoded.
+type SharedItemDTO struct {
+	entities.ItemShare
+	Fields map[string]string `json:"fields"`
+}
+
+// UpdateSharedItemDTO carries new values of the encrypted fields of an item shared with write permission.
+type UpdateSharedItemDTO struct {
+	ShareID     int64             `json:"-"`
+	RecipientID int64             `json:"-"`
+	Key         string            `json:"-"`
+	Fields      map[string]string `json:"fields"`
+}
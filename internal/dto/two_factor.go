@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// LoginChallengeDTO is returned by a login whose password was verified when the user has
+// two-factor authentication enabled. The token is exchanged for the session tokens together
+// with a TOTP or recovery code before it expires.
+type LoginChallengeDTO struct {
+	Token     string    `json:"challenge_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TwoFactorLoginDTO carries the second step of a login with two-factor authentication.
+type TwoFactorLoginDTO struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // A TOTP code or an unused recovery code.
+	DeviceDTO
+}
+
+// TOTPEnrollmentDTO is a new TOTP secret, to be added to an authenticator app by its otpauth URI.
+type TOTPEnrollmentDTO struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TOTPCodeDTO carries the first code of a newly enrolled authenticator, which enables two-factor authentication.
+type TOTPCodeDTO struct {
+	UserID int64  `json:"-"`
+	Code   string `json:"code"`
+}
+
+// DisableTwoFactorDTO carries a request of the authenticated user to turn two-factor authentication off.
+type DisableTwoFactorDTO struct {
+	UserID   int64  `json:"-"`
+	Password string `json:"password"`
+	Code     string `json:"code"` // A TOTP code or an unused recovery code.
+}
@@ -1,12 +1,109 @@
 package dto
 
+import "time"
+
 type UserDTO struct {
-	Username string `json:"username"`
+	Username string       `json:"username"`
+	Password string       `json:"password"`
+	Keys     *UserKeysDTO `json:"-"`
+	Recovery *RecoveryDTO `json:"-"`
+	KeyPair  *KeyPairDTO  `json:"-"`
+	DeviceDTO
+}
+
+// DeviceDTO describes the device a user logs in from; it is stored with the session the login starts.
+// The device name is chosen by the client, the user agent and IP address are taken from the request.
+type DeviceDTO struct {
+	DeviceName string `json:"device_name,omitempty"`
+	UserAgent  string `json:"-"`
+	IP         string `json:"-"`
+}
+
+// UserKeysDTO carries a wrapped data encryption key and the KDF parameters of its key-encryption key.
+type UserKeysDTO struct {
+	KDF        string
+	WrappedKey string
+	AADBound   bool // Whether every vault ciphertext is bound to its location with associated data.
+}
+
+// ChangePasswordDTO carries a master password change request of the authenticated user.
+type ChangePasswordDTO struct {
+	UserID      int64  `json:"-"`
+	SessionID   string `json:"-"`
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// VaultRekeyDTO describes an atomic update of a user's vault keys.
+type VaultRekeyDTO struct {
+	UserID         int64
+	Keys           UserKeysDTO // New wrapped data key and the KDF parameters of its key-encryption key.
+	PasswordHash   string      // Optional new bcrypt password hash stored in the same transaction.
+	RecoveryKey    string      // Optional data key wrapped by a new recovery key, stored in the same transaction.
+	RevokeSessions bool        // Whether to revoke the sessions of the user in the same transaction.
+	KeepSessionID  string      // Optional session that RevokeSessions keeps, e.g. the one the change is made from.
+}
+
+// VaultFieldDTO identifies an encrypted value of a user's vault while it is re-encrypted.
+type VaultFieldDTO struct {
+	UserID int64
+	Table  string
+	ItemID int64
+	Column string
+}
+
+// RecoveryDTO carries what is stored of a recovery kit: the data key wrapped by the recovery key
+// and the hashes of the one-time recovery codes.
+type RecoveryDTO struct {
+	WrappedKey string
+	CodeHashes []string
+}
+
+// RecoveryKitDTO is a recovery key and one-time recovery codes, shown to the user once. A recovery
+// code replaces a two-factor code when the authenticator is lost.
+type RecoveryKitDTO struct {
+	RecoveryKey   string   `json:"recovery_key"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// RegenerateRecoveryDTO carries a request of the authenticated user to replace their recovery kit.
+type RegenerateRecoveryDTO struct {
+	UserID   int64  `json:"-"`
 	Password string `json:"password"`
 }
 
+// RecoverAccountDTO carries a master password reset with a recovery key.
+type RecoverAccountDTO struct {
+	Username    string `json:"username"`
+	RecoveryKey string `json:"recovery_key"`
+	NewPassword string `json:"new_password"`
+	DeviceDTO
+}
+
+// PasswordRuleDTO describes a rule of the master password policy a password failed.
+type PasswordRuleDTO struct {
+	Rule    string `json:"rule"`    // Identifier of the rule: min_length, min_entropy, common_password or not_username.
+	Message string `json:"message"` // Requirement of the rule.
+}
+
+// PasswordPolicyViolationDTO is the response to a master password that does not meet the password policy.
+type PasswordPolicyViolationDTO struct {
+	Error       string            `json:"error"`
+	FailedRules []PasswordRuleDTO `json:"failed_rules"`
+}
+
+// RefreshTokenDTO carries the refresh token of a client that does not keep cookies.
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type GeneratedJwt struct {
-	AccessToken  string
-	RefreshToken string
-	Hash         string `json:"hash"`
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time          `json:"-"`
+	RefreshExpiresAt time.Time          `json:"-"`
+	Hash             string             `json:"hash"`
+	RecoveryKey      string             `json:"recovery_key,omitempty"`
+	RecoveryCodes    []string           `json:"recovery_codes,omitempty"`
+	Challenge        *LoginChallengeDTO `json:"-"` // Set instead of the tokens when the login awaits a second factor.
 }
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+type BankAccount struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	BankName      string    `json:"bank_name"`
+	AccountHolder string    `json:"account_holder"`
+	IBAN          string    `json:"iban"`
+	BIC           string    `json:"bic"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -7,9 +7,10 @@ type Card struct {
 	UserID         int       `json:"user_id"`
 	BankName       string    `json:"bank_name"`
 	Number         string    `json:"num"`
-	CVV            string    `json:"cvv"`
+	CVV            string    `json:"cvv,omitempty"`
 	ExpDate        string    `json:"exp_date"`
 	CardHolderName string    `json:"card_holder_name"`
+	Brand          string    `json:"brand"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+// Access types of an emergency contact: view lets the contact read the vault, takeover also lets
+// them set a new master password for the account.
+const (
+	EmergencyAccessView     = "view"
+	EmergencyAccessTakeover = "takeover"
+)
+
+// Statuses of an emergency access. A requested access becomes approved when the grantor approves
+// it or does not reject it within its wait period.
+const (
+	EmergencyStatusIdle      = "idle"
+	EmergencyStatusRequested = "requested"
+	EmergencyStatusApproved  = "approved"
+)
+
+// EmergencyAccess designates a grantee as an emergency contact of a grantor. WrappedKey is the
+// grantor's data key sealed to the grantee's public key; it is only opened once access is approved.
+type EmergencyAccess struct {
+	ID              int64      `json:"id"`
+	GrantorID       int64      `json:"grantor_id"`
+	GrantorUsername string     `json:"grantor"`
+	GranteeID       int64      `json:"grantee_id"`
+	GranteeUsername string     `json:"grantee"`
+	AccessType      string     `json:"access_type"`
+	WaitDays        int        `json:"wait_days"`
+	Status          string     `json:"status"`
+	RequestedAt     *time.Time `json:"requested_at,omitempty"`
+	AvailableAt     *time.Time `json:"available_at,omitempty"` // When a requested access is approved unless rejected.
+	WrappedKey      string     `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+const (
+	DocumentTypePassport      = "passport"
+	DocumentTypeDriverLicence = "driver_licence"
+	DocumentTypeIDCard        = "id_card"
+)
+
+type IdentityDocument struct {
+	ID             int       `json:"id"`
+	UserID         int       `json:"user_id"`
+	DocType        string    `json:"doc_type"`
+	Number         string    `json:"number"`
+	HolderName     string    `json:"holder_name"`
+	IssuingCountry string    `json:"issuing_country"`
+	IssueDate      string    `json:"issue_date"`
+	ExpiryDate     string    `json:"expiry_date"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -3,11 +3,21 @@ package entities
 import "time"
 
 type LogoPassword struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	AppName   string    `json:"app_name"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            int           `json:"id"`
+	UserID        int           `json:"user_id"`
+	AppName       string        `json:"app_name"`
+	Username      string        `json:"username"`
+	Password      string        `json:"password"`
+	URIs          []LogoPassURI `json:"uris"`
+	EncryptedURIs string        `json:"-"`
+	Breached      bool          `json:"breached"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// LogoPassURI is a website address a login-password entry is used on,
+// with the mode used to match it against visited URLs (exact, host, domain or regex).
+type LogoPassURI struct {
+	URI   string `json:"uri"`
+	Match string `json:"match,omitempty"`
 }
@@ -0,0 +1,67 @@
+package entities
+
+import "time"
+
+// Roles of an organisation member, from the most to the least privileged. Owners manage everything,
+// including other owners; admins manage members and collections; members read, change and add items;
+// read-only members only read items.
+const (
+	OrgRoleOwner    = "owner"
+	OrgRoleAdmin    = "admin"
+	OrgRoleMember   = "member"
+	OrgRoleReadOnly = "read-only"
+)
+
+// Organisation is a team vault. Its items are encrypted with their item keys, which are stored
+// wrapped by the organisation key; the organisation key is sealed to the public key of each member.
+type Organisation struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Role       string    `json:"role"` // Role of the user the organisation was retrieved for.
+	KeyVersion int       `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// OrgMember is a user's membership of an organisation. WrappedKey is the organisation key sealed to
+// the member's public key; KeyVersion is the version of the organisation key at retrieval.
+type OrgMember struct {
+	OrgID      int64     `json:"org_id"`
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	PublicKey  string    `json:"-"`
+	WrappedKey string    `json:"-"`
+	KeyVersion int       `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Collection groups items of any type shared with every member of an organisation.
+type Collection struct {
+	ID        int64     `json:"id"`
+	OrgID     int64     `json:"org_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CollectionItem places a vault item in a collection. WrappedKey is the item key wrapped by the
+// organisation key.
+type CollectionItem struct {
+	CollectionID  int64     `json:"collection_id"`
+	ItemType      string    `json:"item_type"`
+	ItemID        int64     `json:"item_id"`
+	OwnerID       int64     `json:"owner_id"`
+	OwnerUsername string    `json:"owner"`
+	WrappedKey    string    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OrgItem is a collection item as stored: its unencrypted columns and the ciphertexts of its
+// encrypted columns, by column name.
+type OrgItem struct {
+	Item   CollectionItem
+	Plain  map[string]string
+	Text   map[string]string
+	Binary map[string][]byte
+}
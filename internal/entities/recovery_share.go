@@ -0,0 +1,39 @@
+package entities
+
+import "time"
+
+// RecoveryShare is one share of a user's recovery key, held by a recovery officer. Any Threshold
+// shares of the owner reconstruct the recovery key. SealedShare is the share sealed to the officer's
+// public key.
+type RecoveryShare struct {
+	ID              int64     `json:"id"`
+	OwnerID         int64     `json:"owner_id"`
+	OwnerUsername   string    `json:"owner"`
+	OfficerID       int64     `json:"officer_id"`
+	OfficerUsername string    `json:"officer"`
+	ShareIndex      int       `json:"index"`
+	Threshold       int       `json:"threshold"`
+	SealedShare     string    `json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// RecoverySession collects the recovery shares submitted by officers to recover an account. Shares
+// are sealed to the session's public key; its private key is only known to whoever started the session.
+type RecoverySession struct {
+	ID            int64     `json:"id"`
+	OwnerID       int64     `json:"owner_id"`
+	OwnerUsername string    `json:"owner"`
+	Threshold     int       `json:"threshold"`
+	Submitted     int       `json:"submitted"`
+	PublicKey     string    `json:"-"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RecoverySubmission is a recovery share submitted to a session, sealed to the session's public key.
+type RecoverySubmission struct {
+	SessionID   int64
+	ShareIndex  int
+	OfficerID   int64
+	SealedShare string
+}
@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// ReencryptionJob is the checkpoint of the background key rotation and re-encryption job.
+// The job walks user_keys and then every vault table in order of ID; Phase and LastID tell
+// where it stopped, so that it resumes there after a pause, a failure or a restart.
+type ReencryptionJob struct {
+	ID           int64      `json:"id"`
+	Status       string     `json:"status"`         // "running", "paused", "failed" or "completed".
+	Phase        string     `json:"phase"`          // Table being walked: "user_keys" or a vault table.
+	LastID       int64      `json:"last_id"`        // Last user or item ID processed in the phase.
+	Scanned      int64      `json:"scanned"`        // Rows processed over all phases.
+	Rewrapped    int64      `json:"rewrapped"`      // Data keys wrapped again with the current server master key.
+	KDFPending   int64      `json:"kdf_pending"`    // Data keys whose KDF parameters are upgraded on the owner's next login.
+	Outdated     int64      `json:"outdated"`       // Vault rows holding values in an older format or cipher.
+	Flagged      int64      `json:"flagged"`        // Users whose vault is re-encrypted on their next login.
+	BatchSize    int        `json:"batch_size"`     // Rows processed per batch.
+	BatchDelayMS int        `json:"batch_delay_ms"` // Pause between batches, in milliseconds.
+	Error        string     `json:"error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// Session is a login of a user on one device. Its refresh tokens are rotated on every refresh:
+// only CurrentTokenID is accepted, and presenting an already rotated token revokes the session.
+// Access tokens carry the session ID as their jti claim, so revoking a session logs the device out.
+type Session struct {
+	ID             string     `json:"id"`
+	UserID         int64      `json:"-"`
+	DeviceName     string     `json:"device_name"`
+	UserAgent      string     `json:"user_agent"`
+	IP             string     `json:"ip"`
+	CurrentTokenID string     `json:"-"`
+	Current        bool       `json:"current"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+}
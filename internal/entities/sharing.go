@@ -0,0 +1,54 @@
+package entities
+
+import "time"
+
+// Permissions of a recipient on a shared vault item.
+const (
+	SharePermissionRead  = "read"
+	SharePermissionWrite = "write"
+)
+
+// UserKeyPair holds a user's X25519 public key and their private key wrapped by their vault data key.
+// Vault items are shared with a user by sealing the item key to their public key.
+type UserKeyPair struct {
+	UserID            int       `json:"user_id"`
+	PublicKey         string    `json:"public_key"`
+	WrappedPrivateKey string    `json:"-"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ItemKey holds the key a shared vault item is encrypted with, wrapped by its owner's data key.
+type ItemKey struct {
+	ItemType   string    `json:"item_type"`
+	ItemID     int64     `json:"item_id"`
+	OwnerID    int64     `json:"owner_id"`
+	WrappedKey string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ItemShare grants a recipient access to a vault item of its owner. WrappedKey is the item key
+// sealed to the recipient's public key.
+type ItemShare struct {
+	ID                 int64     `json:"id"`
+	ItemType           string    `json:"item_type"`
+	ItemID             int64     `json:"item_id"`
+	OwnerID            int64     `json:"owner_id"`
+	OwnerUsername      string    `json:"owner"`
+	RecipientID        int64     `json:"recipient_id"`
+	RecipientUsername  string    `json:"recipient"`
+	RecipientPublicKey string    `json:"-"`
+	Permission         string    `json:"permission"`
+	WrappedKey         string    `json:"-"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// SharedItem is a vault item shared with a recipient as stored: its unencrypted columns and the
+// ciphertexts of its encrypted columns, by column name.
+type SharedItem struct {
+	Share  ItemShare
+	Plain  map[string]string
+	Text   map[string]string
+	Binary map[string][]byte
+}
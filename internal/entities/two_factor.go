@@ -0,0 +1,28 @@
+package entities
+
+import "time"
+
+// UserTOTP holds the TOTP secret of a user, in the form stored by CryptoModule.SealServerSecret.
+// Two-factor authentication is enabled once EnabledAt is set, after the user entered a first code.
+// LastStep is the time step of the last accepted code; codes of that step or earlier are rejected,
+// so that an intercepted code cannot be replayed.
+type UserTOTP struct {
+	UserID    int64
+	Secret    string
+	EnabledAt *time.Time
+	LastStep  int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LoginChallenge is a login whose password was verified but whose second factor is still missing.
+// WrappedKey is the user's data key wrapped by a one-time key that is only part of the challenge
+// token given to the client, so neither the database nor the token alone reveal the data key.
+type LoginChallenge struct {
+	ID         string
+	UserID     int64
+	WrappedKey string
+	Attempts   int
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
@@ -6,6 +6,7 @@ type User struct {
 	ID        int       `json:"id"`
 	Username  string    `json:"username"`
 	Password  string    `json:"password"`
+	KDF       string    `json:"-"` // KDF parameters of a password-derived vault key, used before data keys were introduced.
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
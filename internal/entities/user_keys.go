@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+// UserKeys holds a user's data encryption key wrapped by a key-encryption key
+// derived from their password with the stored KDF parameters.
+// AADBound is set once every ciphertext of the vault is bound to its location.
+// ReencryptPending is set by the re-encryption job when the vault holds values in an older
+// format or cipher; they are re-encrypted on the user's next login.
+type UserKeys struct {
+	UserID           int       `json:"user_id"`
+	KDF              string    `json:"kdf"`
+	WrappedKey       string    `json:"wrapped_key"`
+	AADBound         bool      `json:"aad_bound"`
+	ReencryptPending bool      `json:"reencrypt_pending"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
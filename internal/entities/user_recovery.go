@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// UserRecovery holds a user's data encryption key wrapped by a key-encryption key derived
+// from their recovery key, which can replace the master password to regain access to the vault.
+type UserRecovery struct {
+	UserID     int       `json:"user_id"`
+	WrappedKey string    `json:"wrapped_key"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,226 @@
+// Package service provides business logic for auditing the security of a user's vault.
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/validation"
+	"github.com/Zrossiz/gophkeeper/pkg/passgen"
+	"go.uber.org/zap"
+)
+
+// Default audit thresholds used when the corresponding option is not set.
+const (
+	DefaultAuditStaleDays    = 180
+	DefaultAuditExpiringDays = 60
+	DefaultAuditMinEntropy   = 60
+)
+
+// Item types reported in audit decrypt failures.
+const (
+	AuditItemLogoPass = "logopass"
+	AuditItemCard     = "card"
+)
+
+// AuditService builds security reports over a user's decrypted vault.
+type AuditService struct {
+	logoPass LogoPassAuditSource
+	card     CardAuditSource
+	log      *zap.Logger
+}
+
+// LogoPassAuditSource defines an interface for reading decrypted login-password entries together with decryption failures.
+type LogoPassAuditSource interface {
+	// GetAllWithFailures returns the decrypted entries and the entries that failed to decrypt.
+	GetAllWithFailures(ctx context.Context, userID int64, key string) ([]entities.LogoPassword, []entities.LogoPassword, error)
+}
+
+// CardAuditSource defines an interface for reading decrypted cards together with decryption failures.
+type CardAuditSource interface {
+	// GetAllWithFailures returns the decrypted cards and the cards that failed to decrypt.
+	GetAllWithFailures(ctx context.Context, userID int64, key string) ([]entities.Card, []entities.Card, error)
+}
+
+// NewAuditService creates a new instance of AuditService with the provided dependencies.
+//
+// Parameters:
+//   - logoPass: A LogoPassAuditSource, normally the LogoPassService.
+//   - card: A CardAuditSource, normally the CardService.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to an AuditService instance.
+func NewAuditService(logoPass LogoPassAuditSource, card CardAuditSource, log *zap.Logger) *AuditService {
+	return &AuditService{
+		logoPass: logoPass,
+		card:     card,
+		log:      log,
+	}
+}
+
+// Report decrypts a user's login-passwords and cards and builds a security report flagging
+// reused, weak, breached and stale passwords, cards that expire soon (or already expired)
+// and items that failed to decrypt. Passwords are never included in the report.
+//
+// Parameters:
+//   - userID: The ID of the user whose vault is audited.
+//   - key: The encryption key required for decryption.
+//   - opts: Report thresholds; zero values are replaced with the defaults.
+//
+// Returns:
+//   - A pointer to dto.AuditReportDTO or an error if the vault cannot be read.
+func (a *AuditService) Report(ctx context.Context, userID int64, key string, opts dto.AuditOptionsDTO) (*dto.AuditReportDTO, error) {
+	opts = withAuditDefaults(opts)
+	now := timeNow()
+
+	logoPasses, failedLogoPasses, err := a.logoPass.GetAllWithFailures(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, failedCards, err := a.card.GetAllWithFailures(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.AuditReportDTO{
+		GeneratedAt:       now,
+		Options:           opts,
+		ReusedPasswords:   reusedPasswords(logoPasses),
+		WeakPasswords:     []dto.AuditWeakPasswordDTO{},
+		BreachedPasswords: []dto.AuditEntryDTO{},
+		StalePasswords:    []dto.AuditAgedEntryDTO{},
+		ExpiringCards:     []dto.AuditCardDTO{},
+		DecryptFailures:   []dto.AuditFailureDTO{},
+	}
+
+	staleBefore := now.AddDate(0, 0, -opts.StaleDays)
+	for _, item := range logoPasses {
+		entry := auditEntry(item)
+
+		if entropy := passgen.EstimateEntropy(item.Password); entropy < opts.MinEntropy {
+			report.WeakPasswords = append(report.WeakPasswords, dto.AuditWeakPasswordDTO{
+				AuditEntryDTO: entry,
+				EntropyBits:   entropy,
+			})
+		}
+
+		if item.Breached {
+			report.BreachedPasswords = append(report.BreachedPasswords, entry)
+		}
+
+		if item.UpdatedAt.Before(staleBefore) {
+			report.StalePasswords = append(report.StalePasswords, dto.AuditAgedEntryDTO{
+				AuditEntryDTO: entry,
+				UpdatedAt:     item.UpdatedAt,
+				AgeDays:       int(now.Sub(item.UpdatedAt).Hours() / 24),
+			})
+		}
+	}
+
+	expiringBefore := now.AddDate(0, 0, opts.ExpiringDays)
+	for _, card := range cards {
+		expiresAt, err := validation.CardExpiry(card.ExpDate)
+		if err != nil {
+			a.log.Warn("audit: skip card with malformed expiry date", zap.Int("cardID", card.ID), zap.Error(err))
+			continue
+		}
+
+		if expiresAt.After(expiringBefore) {
+			continue
+		}
+
+		expired := !now.Before(expiresAt)
+		report.ExpiringCards = append(report.ExpiringCards, dto.AuditCardDTO{
+			ID:       card.ID,
+			BankName: card.BankName,
+			Number:   card.Number,
+			Brand:    card.Brand,
+			ExpDate:  card.ExpDate,
+			Expired:  expired,
+			DaysLeft: int(expiresAt.Sub(now).Hours() / 24),
+		})
+		if expired {
+			report.Summary.ExpiredCards++
+		}
+	}
+
+	for _, item := range failedLogoPasses {
+		report.DecryptFailures = append(report.DecryptFailures, dto.AuditFailureDTO{
+			Type: AuditItemLogoPass,
+			ID:   item.ID,
+			Name: item.AppName,
+		})
+	}
+	for _, card := range failedCards {
+		report.DecryptFailures = append(report.DecryptFailures, dto.AuditFailureDTO{
+			Type: AuditItemCard,
+			ID:   card.ID,
+			Name: card.BankName,
+		})
+	}
+
+	report.Summary.LogoPasses = len(logoPasses) + len(failedLogoPasses)
+	report.Summary.Cards = len(cards) + len(failedCards)
+	for _, group := range report.ReusedPasswords {
+		report.Summary.Reused += len(group.Entries)
+	}
+	report.Summary.Weak = len(report.WeakPasswords)
+	report.Summary.Breached = len(report.BreachedPasswords)
+	report.Summary.Stale = len(report.StalePasswords)
+	report.Summary.ExpiringCards = len(report.ExpiringCards) - report.Summary.ExpiredCards
+	report.Summary.DecryptFailures = len(report.DecryptFailures)
+
+	return report, nil
+}
+
+// reusedPasswords groups entries sharing the same password; only groups of two or more entries are returned.
+// Groups keep the order in which their first entry appears.
+func reusedPasswords(items []entities.LogoPassword) []dto.AuditReuseGroupDTO {
+	groups := make(map[string][]dto.AuditEntryDTO)
+	var order []string
+
+	for _, item := range items {
+		if _, ok := groups[item.Password]; !ok {
+			order = append(order, item.Password)
+		}
+		groups[item.Password] = append(groups[item.Password], auditEntry(item))
+	}
+
+	reused := make([]dto.AuditReuseGroupDTO, 0)
+	for _, password := range order {
+		if entries := groups[password]; len(entries) > 1 {
+			sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+			reused = append(reused, dto.AuditReuseGroupDTO{Entries: entries})
+		}
+	}
+
+	return reused
+}
+
+// auditEntry describes a login-password entry in the report without its password.
+func auditEntry(item entities.LogoPassword) dto.AuditEntryDTO {
+	return dto.AuditEntryDTO{
+		ID:       item.ID,
+		AppName:  item.AppName,
+		Username: item.Username,
+	}
+}
+
+// withAuditDefaults replaces unset (non-positive) audit options with the defaults.
+func withAuditDefaults(opts dto.AuditOptionsDTO) dto.AuditOptionsDTO {
+	if opts.StaleDays <= 0 {
+		opts.StaleDays = DefaultAuditStaleDays
+	}
+	if opts.ExpiringDays <= 0 {
+		opts.ExpiringDays = DefaultAuditExpiringDays
+	}
+	if opts.MinEntropy <= 0 {
+		opts.MinEntropy = DefaultAuditMinEntropy
+	}
+
+	return opts
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockLogoPassAuditSource struct {
+	mock.Mock
+}
+
+func (m *MockLogoPassAuditSource) GetAllWithFailures(
+	ctx context.Context,
+	userID int64,
+	key string,
+) ([]entities.LogoPassword, []entities.LogoPassword, error) {
+	args := m.Called(userID, key)
+	return args.Get(0).([]entities.LogoPassword), args.Get(1).([]entities.LogoPassword), args.Error(2)
+}
+
+type MockCardAuditSource struct {
+	mock.Mock
+}
+
+func (m *MockCardAuditSource) GetAllWithFailures(
+	ctx context.Context,
+	userID int64,
+	key string,
+) ([]entities.Card, []entities.Card, error) {
+	args := m.Called(userID, key)
+	return args.Get(0).([]entities.Card), args.Get(1).([]entities.Card), args.Error(2)
+}
+
+func pinTime(t *testing.T, now time.Time) {
+	t.Helper()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func TestAuditReport(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	pinTime(t, now)
+
+	logoPasses := new(MockLogoPassAuditSource)
+	cards := new(MockCardAuditSource)
+	service := NewAuditService(logoPasses, cards, zap.NewNop())
+
+	logoPasses.On("GetAllWithFailures", int64(1), "key").Return([]entities.LogoPassword{
+		{ID: 1, AppName: "github", Username: "alice", Password: "Tr0ub4dor&3-horse-staple", UpdatedAt: now.AddDate(0, 0, -10)},
+		{ID: 2, AppName: "gitlab", Username: "alice", Password: "Tr0ub4dor&3-horse-staple", UpdatedAt: now.AddDate(0, 0, -400)},
+		{ID: 3, AppName: "bank", Username: "alice", Password: "123456", Breached: true, UpdatedAt: now},
+	}, []entities.LogoPassword{
+		{ID: 4, AppName: "mail"},
+	}, nil)
+
+	cards.On("GetAllWithFailures", int64(1), "key").Return([]entities.Card{
+		{ID: 10, BankName: "expired", Number: "**** 1111", ExpDate: "02/26"},
+		{ID: 11, BankName: "expiring", Number: "**** 4444", ExpDate: "04/26"},
+		{ID: 12, BankName: "valid", Number: "**** 0004", ExpDate: "12/30"},
+		{ID: 13, BankName: "legacy", Number: "**** 0005", ExpDate: "2030-12"},
+	}, []entities.Card{
+		{ID: 14, BankName: "broken"},
+	}, nil)
+
+	report, err := service.Report(context.Background(), 1, "key", dto.AuditOptionsDTO{StaleDays: 365})
+	require.NoError(t, err)
+
+	assert.Equal(t, now, report.GeneratedAt)
+	assert.Equal(t, dto.AuditOptionsDTO{StaleDays: 365, ExpiringDays: 60, MinEntropy: 60}, report.Options)
+
+	require.Len(t, report.ReusedPasswords, 1)
+	assert.Equal(t, []dto.AuditEntryDTO{
+		{ID: 1, AppName: "github", Username: "alice"},
+		{ID: 2, AppName: "gitlab", Username: "alice"},
+	}, report.ReusedPasswords[0].Entries)
+
+	require.Len(t, report.WeakPasswords, 1)
+	assert.Equal(t, 3, report.WeakPasswords[0].ID)
+
+	assert.Equal(t, []dto.AuditEntryDTO{{ID: 3, AppName: "bank", Username: "alice"}}, report.BreachedPasswords)
+
+	require.Len(t, report.StalePasswords, 1)
+	assert.Equal(t, 2, report.StalePasswords[0].ID)
+	assert.Equal(t, 400, report.StalePasswords[0].AgeDays)
+
+	require.Len(t, report.ExpiringCards, 2)
+	assert.Equal(t, 10, report.ExpiringCards[0].ID)
+	assert.True(t, report.ExpiringCards[0].Expired)
+	assert.Equal(t, 11, report.ExpiringCards[1].ID)
+	assert.False(t, report.ExpiringCards[1].Expired)
+	assert.Equal(t, 46, report.ExpiringCards[1].DaysLeft)
+
+	assert.Equal(t, []dto.AuditFailureDTO{
+		{Type: AuditItemLogoPass, ID: 4, Name: "mail"},
+		{Type: AuditItemCard, ID: 14, Name: "broken"},
+	}, report.DecryptFailures)
+
+	assert.Equal(t, dto.AuditSummaryDTO{
+		LogoPasses:      4,
+		Cards:           5,
+		Reused:          2,
+		Weak:            1,
+		Breached:        1,
+		Stale:           1,
+		ExpiringCards:   1,
+		ExpiredCards:    1,
+		DecryptFailures: 2,
+	}, report.Summary)
+}
+
+func TestAuditReport_StorageError(t *testing.T) {
+	logoPasses := new(MockLogoPassAuditSource)
+	cards := new(MockCardAuditSource)
+	service := NewAuditService(logoPasses, cards, zap.NewNop())
+
+	logoPasses.On("GetAllWithFailures", int64(1), "key").
+		Return([]entities.LogoPassword(nil), []entities.LogoPassword(nil), errors.New("db error"))
+
+	report, err := service.Report(context.Background(), 1, "key", dto.AuditOptionsDTO{})
+
+	assert.Error(t, err)
+	assert.Nil(t, report)
+	cards.AssertNotCalled(t, "GetAllWithFailures", mock.Anything, mock.Anything)
+}
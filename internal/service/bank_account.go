@@ -0,0 +1,239 @@
+// Package service provides business logic for managing encrypted bank account storage.
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/validation"
+	"go.uber.org/zap"
+)
+
+// BankAccountService manages operations related to encrypted bank account storage, including
+// validation, encryption and decryption.
+type BankAccountService struct {
+	bankAccountStorage BankAccountStorage
+	cryptoModule       CryptoModule
+	itemKeys           *ItemKeys
+	log                *zap.Logger
+}
+
+// BankAccountStorage defines an interface for storing, retrieving, and updating encrypted bank accounts.
+type BankAccountStorage interface {
+	// NextID reserves the ID of the next bank account.
+	NextID(ctx context.Context) (int64, error)
+	// CreateBankAccount stores an encrypted bank account in the database.
+	CreateBankAccount(ctx context.Context, body dto.CreateBankAccountDTO) error
+	// GetAllBankAccountsByUserId retrieves all encrypted bank accounts associated with a given user ID.
+	GetAllBankAccountsByUserId(ctx context.Context, userID int64) ([]entities.BankAccount, error)
+	// UpdateBankAccount updates the encrypted bank account details for a specific account ID.
+	UpdateBankAccount(ctx context.Context, accountID int64, body dto.UpdateBankAccountDTO) error
+}
+
+// NewBankAccountService creates a new instance of BankAccountService with the provided dependencies.
+//
+// Parameters:
+//   - bankAccountStorage: An implementation of the BankAccountStorage interface for data persistence.
+//   - cryptoModule: An implementation of CryptoModule for encryption and decryption.
+//   - itemKeys: Resolves the item keys of shared bank accounts; nil if sharing is not used.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to a BankAccountService instance.
+func NewBankAccountService(
+	bankAccountStorage BankAccountStorage,
+	cryptoModule CryptoModule,
+	itemKeys *ItemKeys,
+	log *zap.Logger,
+) *BankAccountService {
+	return &BankAccountService{
+		bankAccountStorage: bankAccountStorage,
+		cryptoModule:       cryptoModule,
+		itemKeys:           itemKeys,
+		log:                log,
+	}
+}
+
+// Create validates bank account data, encrypts it and stores it securely.
+// The IBAN is normalized (spaces removed, upper-cased) before encryption.
+//
+// Parameters:
+//   - body: A dto.CreateBankAccountDTO containing bank account details and an encryption key.
+//
+// Returns:
+//   - A validation error (apperrors.ErrInvalidIBAN, apperrors.ErrInvalidBIC) or an error if
+//     encryption or storage fails.
+func (b *BankAccountService) Create(ctx context.Context, body dto.CreateBankAccountDTO) error {
+	iban, bic, err := validateBankAccount(body.IBAN, body.BIC)
+	if err != nil {
+		return err
+	}
+
+	body.ID, err = b.bankAccountStorage.NextID(ctx)
+	if err != nil {
+		return err
+	}
+	userID := int64(body.UserID)
+
+	encryptedAccountHolder, err := b.cryptoModule.EncryptBound(body.AccountHolder, body.Key, bind(userID, itemTypeBankAccount, body.ID, "account_holder"))
+	if err != nil {
+		return err
+	}
+
+	encryptedIBAN, err := b.cryptoModule.EncryptBound(iban, body.Key, bind(userID, itemTypeBankAccount, body.ID, "iban"))
+	if err != nil {
+		return err
+	}
+
+	encryptedBIC, err := b.cryptoModule.EncryptBound(bic, body.Key, bind(userID, itemTypeBankAccount, body.ID, "bic"))
+	if err != nil {
+		return err
+	}
+
+	body.AccountHolder = encryptedAccountHolder
+	body.IBAN = encryptedIBAN
+	body.BIC = encryptedBIC
+
+	return b.bankAccountStorage.CreateBankAccount(ctx, body)
+}
+
+// Update validates updated bank account data, encrypts it and stores it securely.
+//
+// Parameters:
+//   - accountID: The ID of the bank account to be updated.
+//   - body: A dto.UpdateBankAccountDTO containing updated bank account details and an encryption key.
+//
+// Returns:
+//   - A validation error or an error if encryption or storage fails.
+func (b *BankAccountService) Update(ctx context.Context, accountID int64, body dto.UpdateBankAccountDTO) error {
+	iban, bic, err := validateBankAccount(body.IBAN, body.BIC)
+	if err != nil {
+		return err
+	}
+
+	body.Key, err = b.itemKeys.key(ctx, body.UserID, itemTypeBankAccount, accountID, body.Key)
+	if err != nil {
+		return err
+	}
+
+	encryptedAccountHolder, err := b.cryptoModule.EncryptBound(body.AccountHolder, body.Key, bind(body.UserID, itemTypeBankAccount, accountID, "account_holder"))
+	if err != nil {
+		return err
+	}
+
+	encryptedIBAN, err := b.cryptoModule.EncryptBound(iban, body.Key, bind(body.UserID, itemTypeBankAccount, accountID, "iban"))
+	if err != nil {
+		return err
+	}
+
+	encryptedBIC, err := b.cryptoModule.EncryptBound(bic, body.Key, bind(body.UserID, itemTypeBankAccount, accountID, "bic"))
+	if err != nil {
+		return err
+	}
+
+	body.AccountHolder = encryptedAccountHolder
+	body.IBAN = encryptedIBAN
+	body.BIC = encryptedBIC
+
+	return b.bankAccountStorage.UpdateBankAccount(ctx, accountID, body)
+}
+
+// GetAll retrieves and decrypts all bank accounts for a given user.
+//
+// Parameters:
+//   - userID: The ID of the user whose bank accounts are being retrieved.
+//   - key: The encryption key required for decryption.
+//
+// Returns:
+//   - A slice of decrypted entities.BankAccount or an error if retrieval fails.
+func (b *BankAccountService) GetAll(ctx context.Context, userID int64, key string) ([]entities.BankAccount, error) {
+	encryptedData, err := b.bankAccountStorage.GetAllBankAccountsByUserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFor, err := b.itemKeys.resolve(ctx, userID, itemTypeBankAccount, key)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedData := b.decryptBankAccountArray(encryptedData, keyFor)
+	return decryptedData, nil
+}
+
+// decryptBankAccountArray decrypts an array of encrypted bank accounts.
+// Entries that fail to decrypt are skipped.
+//
+// Parameters:
+//   - accounts: A slice of encrypted entities.BankAccount.
+//   - keyFor: Returns the encryption key of a bank account by its ID.
+//
+// Returns:
+//   - A slice of decrypted entities.BankAccount.
+func (b *BankAccountService) decryptBankAccountArray(accounts []entities.BankAccount, keyFor func(int64) string) []entities.BankAccount {
+	decryptedData := make([]entities.BankAccount, 0, len(accounts))
+
+	for i := 0; i < len(accounts); i++ {
+		decryptedAccount, err := b.decryptBankAccount(accounts[i], keyFor(int64(accounts[i].ID)))
+		if err != nil {
+			continue
+		}
+		decryptedData = append(decryptedData, *decryptedAccount)
+	}
+
+	return decryptedData
+}
+
+// decryptBankAccount decrypts a single encrypted bank account entry.
+//
+// Parameters:
+//   - account: An encrypted entities.BankAccount instance.
+//   - key: The encryption key used for decryption.
+//
+// Returns:
+//   - A pointer to a decrypted entities.BankAccount or an error if decryption fails.
+func (b *BankAccountService) decryptBankAccount(account entities.BankAccount, key string) (*entities.BankAccount, error) {
+	userID, accountID := int64(account.UserID), int64(account.ID)
+
+	decryptedAccountHolder, err := b.cryptoModule.DecryptBound(account.AccountHolder, key, bind(userID, itemTypeBankAccount, accountID, "account_holder"))
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedIBAN, err := b.cryptoModule.DecryptBound(account.IBAN, key, bind(userID, itemTypeBankAccount, accountID, "iban"))
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedBIC, err := b.cryptoModule.DecryptBound(account.BIC, key, bind(userID, itemTypeBankAccount, accountID, "bic"))
+	if err != nil {
+		return nil, err
+	}
+
+	account.AccountHolder = decryptedAccountHolder
+	account.IBAN = decryptedIBAN
+	account.BIC = decryptedBIC
+
+	return &account, nil
+}
+
+// validateBankAccount validates and normalizes an IBAN and an optional BIC.
+//
+// Returns:
+//   - The normalized IBAN and BIC, or a validation error.
+func validateBankAccount(iban, bic string) (string, string, error) {
+	if err := validation.ValidateIBAN(iban); err != nil {
+		return "", "", err
+	}
+
+	bic = strings.ToUpper(strings.TrimSpace(bic))
+	if bic != "" {
+		if err := validation.ValidateBIC(bic); err != nil {
+			return "", "", err
+		}
+	}
+
+	return validation.NormalizeIBAN(iban), bic, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockBankAccountStorage struct {
+	mock.Mock
+}
+
+func (m *MockBankAccountStorage) NextID(ctx context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockBankAccountStorage) CreateBankAccount(ctx context.Context, body dto.CreateBankAccountDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockBankAccountStorage) GetAllBankAccountsByUserId(ctx context.Context, userID int64) ([]entities.BankAccount, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entities.BankAccount), args.Error(1)
+}
+
+func (m *MockBankAccountStorage) UpdateBankAccount(ctx context.Context, accountID int64, body dto.UpdateBankAccountDTO) error {
+	args := m.Called(accountID, body)
+	return args.Error(0)
+}
+
+func TestCreateBankAccount(t *testing.T) {
+	mockStorage := new(MockBankAccountStorage)
+	mockCrypto := new(MockCryptoModule)
+
+	service := NewBankAccountService(mockStorage, mockCrypto, nil, zap.NewNop())
+
+	body := dto.CreateBankAccountDTO{
+		UserID:        1,
+		BankName:      "Commerzbank",
+		AccountHolder: "John Doe",
+		IBAN:          "de89 3704 0044 0532 0130 00",
+		BIC:           "cobadeffxxx",
+		Key:           "secret",
+	}
+
+	mockStorage.On("NextID").Return(int64(5), nil)
+	mockCrypto.On("EncryptBound", "John Doe", "secret", mock.Anything).Return("encrypted_holder", nil)
+	mockCrypto.On("EncryptBound", "DE89370400440532013000", "secret", mock.Anything).Return("encrypted_iban", nil)
+	mockCrypto.On("EncryptBound", "COBADEFFXXX", "secret", mock.Anything).Return("encrypted_bic", nil)
+
+	mockStorage.On("CreateBankAccount", mock.MatchedBy(func(body dto.CreateBankAccountDTO) bool {
+		return body.ID == 5 && body.BankName == "Commerzbank" && body.IBAN == "encrypted_iban" && body.BIC == "encrypted_bic"
+	})).Return(nil)
+
+	err := service.Create(context.Background(), body)
+
+	assert.NoError(t, err)
+	mockCrypto.AssertExpectations(t)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetAllBankAccounts(t *testing.T) {
+	mockStorage := new(MockBankAccountStorage)
+	mockCrypto := new(MockCryptoModule)
+
+	service := NewBankAccountService(mockStorage, mockCrypto, nil, zap.NewNop())
+
+	mockStorage.On("GetAllBankAccountsByUserId", int64(1)).Return([]entities.BankAccount{
+		{ID: 1, AccountHolder: "enc_holder", IBAN: "enc_iban", BIC: "enc_bic"},
+		{ID: 2, AccountHolder: "broken", IBAN: "enc_iban", BIC: "enc_bic"},
+	}, nil)
+
+	mockCrypto.On("DecryptBound", "enc_holder", "secret", mock.Anything).Return("John Doe", nil)
+	mockCrypto.On("DecryptBound", "enc_iban", "secret", mock.Anything).Return("DE89370400440532013000", nil)
+	mockCrypto.On("DecryptBound", "enc_bic", "secret", mock.Anything).Return("COBADEFFXXX", nil)
+	mockCrypto.On("DecryptBound", "broken", "secret", mock.Anything).Return("", assert.AnError)
+
+	accounts, err := service.GetAll(context.Background(), 1, "secret")
+
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Equal(t, "DE89370400440532013000", accounts[0].IBAN)
+}
+
+func TestCreateBankAccount_InvalidIBAN(t *testing.T) {
+	mockCrypto := new(MockCryptoModule)
+	service := NewBankAccountService(nil, mockCrypto, nil, zap.NewNop())
+
+	err := service.Create(context.Background(), dto.CreateBankAccountDTO{
+		IBAN: "DE89370400440532013001",
+		Key:  "secret",
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidIBAN)
+	mockCrypto.AssertNotCalled(t, "EncryptBound", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -13,11 +13,14 @@ import (
 type BinaryService struct {
 	binaryStorage BinaryStorage
 	cryptoModule  CryptoModule
+	itemKeys      *ItemKeys
 	log           *zap.Logger
 }
 
 // BinaryStorage defines an interface for storing and retrieving encrypted binary data.
 type BinaryStorage interface {
+	// NextID reserves the ID of the next binary data entry.
+	NextID(ctx context.Context) (int64, error)
 	// Create stores encrypted binary data.
 	Create(ctx context.Context, body dto.SetStorageBinaryDTO) error
 	// GetAllByUser retrieves all binary data associated with a given user.
@@ -29,6 +32,7 @@ type BinaryStorage interface {
 // Parameters:
 //   - binaryStorage: An implementation of the BinaryStorage interface for data persistence.
 //   - cryptoModule: An implementation of CryptoModule for encryption and decryption.
+//   - itemKeys: Resolves the item keys of shared entries; nil if sharing is not used.
 //   - logger: A structured logger (zap.Logger) for logging events.
 //
 // Returns:
@@ -36,11 +40,13 @@ type BinaryStorage interface {
 func NewBinaryService(
 	binaryStorage BinaryStorage,
 	cryptoModule CryptoModule,
+	itemKeys *ItemKeys,
 	logger *zap.Logger,
 ) *BinaryService {
 	return &BinaryService{
 		binaryStorage: binaryStorage,
 		cryptoModule:  cryptoModule,
+		itemKeys:      itemKeys,
 		log:           logger,
 	}
 }
@@ -53,17 +59,24 @@ func NewBinaryService(
 // Returns:
 //   - An error if encryption or storage fails.
 func (b *BinaryService) Create(ctx context.Context, body dto.CreateBinaryDTO) error {
-	encryptedTitle, err := b.cryptoModule.Encrypt(body.Title, body.Key)
+	id, err := b.binaryStorage.NextID(ctx)
 	if err != nil {
 		return err
 	}
+	userID := int64(body.UserID)
 
-	encryptedBody, err := b.cryptoModule.EncryptBinaryData(body.Data, body.Key)
+	encryptedTitle, err := b.cryptoModule.EncryptBound(body.Title, body.Key, bind(userID, itemTypeBinary, id, "title"))
+	if err != nil {
+		return err
+	}
+
+	encryptedBody, err := b.cryptoModule.EncryptBinaryDataBound(body.Data, body.Key, bind(userID, itemTypeBinary, id, "binary_data"))
 	if err != nil {
 		return err
 	}
 
 	binariesBody := dto.SetStorageBinaryDTO{
+		ID:     id,
 		UserID: body.UserID,
 		Title:  encryptedTitle,
 		Data:   encryptedBody,
@@ -85,7 +98,12 @@ func (b *BinaryService) GetAll(ctx context.Context, userID int64, key string) ([
 		return nil, err
 	}
 
-	decryptedData := b.decryptBinaryArray(encryptedData, key)
+	keyFor, err := b.itemKeys.resolve(ctx, userID, itemTypeBinary, key)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedData := b.decryptBinaryArray(encryptedData, keyFor)
 
 	return decryptedData, nil
 }
@@ -94,18 +112,18 @@ func (b *BinaryService) GetAll(ctx context.Context, userID int64, key string) ([
 //
 // Parameters:
 //   - encryptedData: A slice of encrypted entities.BinaryData.
-//   - key: The encryption key used for decryption.
+//   - keyFor: Returns the encryption key of an entry by its ID.
 //
 // Returns:
 //   - A slice of decrypted entities.BinaryData.
 func (b *BinaryService) decryptBinaryArray(
 	encryptedData []entities.BinaryData,
-	key string,
+	keyFor func(int64) string,
 ) []entities.BinaryData {
 	decryptedData := make([]entities.BinaryData, 0, len(encryptedData))
 
 	for i := 0; i < len(encryptedData); i++ {
-		decryptedItem, err := b.decryptBinary(encryptedData[i], key)
+		decryptedItem, err := b.decryptBinary(encryptedData[i], keyFor(int64(encryptedData[i].ID)))
 		if err != nil {
 			continue
 		}
@@ -128,7 +146,11 @@ func (b *BinaryService) decryptBinary(
 	encryptedData entities.BinaryData,
 	key string,
 ) (*entities.BinaryData, error) {
-	decryptedTitle, err := b.cryptoModule.Decrypt(encryptedData.Title, key)
+	decryptedTitle, err := b.cryptoModule.DecryptBound(
+		encryptedData.Title,
+		key,
+		bind(int64(encryptedData.UserID), itemTypeBinary, int64(encryptedData.ID), "title"),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -3,10 +3,12 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"strings"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/validation"
 	"go.uber.org/zap"
 )
 
@@ -14,16 +16,21 @@ import (
 type CardService struct {
 	cardStorage  CardStorage
 	cryptoModule CryptoModule
+	itemKeys     *ItemKeys
 	log          *zap.Logger
 }
 
 // CardStorage defines an interface for storing, retrieving, and updating encrypted card data.
 type CardStorage interface {
+	// NextID reserves the ID of the next card.
+	NextID(ctx context.Context) (int64, error)
 	// CreateCard stores an encrypted card in the database.
 	CreateCard(ctx context.Context, body dto.CreateCardDTO) error
 	// GetAllCardsByUserId retrieves all encrypted cards associated with a given user ID.
 	GetAllCardsByUserId(ctx context.Context, userID int64) ([]entities.Card, error)
-	// UpdateCard updates the encrypted card details for a specific card ID.
+	// GetCardByID retrieves a single encrypted card by its ID, or nil if it does not exist.
+	GetCardByID(ctx context.Context, cardID int64) (*entities.Card, error)
+	// UpdateCard updates the encrypted card details for a specific card ID owned by body.UserID.
 	UpdateCard(ctx context.Context, cardID int64, body dto.UpdateCardDTO) error
 }
 
@@ -32,6 +39,7 @@ type CardStorage interface {
 // Parameters:
 //   - cardStorage: An implementation of the CardStorage interface for data persistence.
 //   - cryptoModule: An implementation of CryptoModule for encryption and decryption.
+//   - itemKeys: Resolves the item keys of shared cards; nil if sharing is not used.
 //   - log: A structured logger (zap.Logger) for logging events.
 //
 // Returns:
@@ -39,39 +47,58 @@ type CardStorage interface {
 func NewCardService(
 	cardStorage CardStorage,
 	cryptoModule CryptoModule,
+	itemKeys *ItemKeys,
 	log *zap.Logger,
 ) *CardService {
 	return &CardService{
 		cardStorage:  cardStorage,
 		cryptoModule: cryptoModule,
+		itemKeys:     itemKeys,
 		log:          log,
 	}
 }
 
-// Create encrypts card data and stores it securely.
+// Create validates card data, detects the card brand, encrypts the card and stores it securely.
+// The card number must pass the Luhn check, the expiry date must be a non-expired MM/YY value
+// and the CVV must match the brand (4 digits for American Express, 3 otherwise).
 //
 // Parameters:
 //   - body: A dto.CreateCardDTO containing card details and an encryption key.
 //
 // Returns:
-//   - An error if encryption or storage fails.
+//   - A validation error (apperrors.ErrInvalidCardNumber, apperrors.ErrInvalidCVV,
+//     apperrors.ErrInvalidDate, apperrors.ErrCardExpired) or an error if encryption or storage fails.
 func (c *CardService) Create(ctx context.Context, body dto.CreateCardDTO) error {
-	encryptedNum, err := c.cryptoModule.Encrypt(body.Num, body.Key)
+	num, expDate, brand, err := validateCard(body.Num, body.CVV, body.ExpDate)
 	if err != nil {
 		return err
 	}
+	body.Num = num
+	body.ExpDate = expDate
+	body.Brand = brand
 
-	encryptedCVV, err := c.cryptoModule.Encrypt(body.CVV, body.Key)
+	body.ID, err = c.cardStorage.NextID(ctx)
 	if err != nil {
 		return err
 	}
+	userID := int64(body.UserID)
 
-	encryptedExpDate, err := c.cryptoModule.Encrypt(body.ExpDate, body.Key)
+	encryptedNum, err := c.cryptoModule.EncryptBound(body.Num, body.Key, bind(userID, itemTypeCard, body.ID, "num"))
 	if err != nil {
 		return err
 	}
 
-	encryptedCardHolderName, err := c.cryptoModule.Encrypt(body.CardHolderName, body.Key)
+	encryptedCVV, err := c.cryptoModule.EncryptBound(body.CVV, body.Key, bind(userID, itemTypeCard, body.ID, "cvv"))
+	if err != nil {
+		return err
+	}
+
+	encryptedExpDate, err := c.cryptoModule.EncryptBound(body.ExpDate, body.Key, bind(userID, itemTypeCard, body.ID, "exp_date"))
+	if err != nil {
+		return err
+	}
+
+	encryptedCardHolderName, err := c.cryptoModule.EncryptBound(body.CardHolderName, body.Key, bind(userID, itemTypeCard, body.ID, "card_holder_name"))
 	if err != nil {
 		return err
 	}
@@ -84,31 +111,45 @@ func (c *CardService) Create(ctx context.Context, body dto.CreateCardDTO) error
 	return c.cardStorage.CreateCard(ctx, body)
 }
 
-// Update encrypts updated card data and stores it securely.
+// Update validates updated card data, re-detects the brand, encrypts the card and stores it securely.
+// A shared card is encrypted with its item key.
 //
 // Parameters:
 //   - cardID: The ID of the card to be updated.
 //   - body: A dto.UpdateCardDTO containing updated card details and an encryption key.
 //
 // Returns:
-//   - An error if encryption or storage fails.
+//   - A validation error or an error if encryption or storage fails.
 func (c *CardService) Update(ctx context.Context, cardID int64, body dto.UpdateCardDTO) error {
-	encryptedNum, err := c.cryptoModule.Encrypt(body.Num, body.Key)
+	num, expDate, brand, err := validateCard(body.Num, body.CVV, body.ExpDate)
+	if err != nil {
+		return err
+	}
+	body.Num = num
+	body.ExpDate = expDate
+	body.Brand = brand
+
+	body.Key, err = c.itemKeys.key(ctx, body.UserID, itemTypeCard, cardID, body.Key)
+	if err != nil {
+		return err
+	}
+
+	encryptedNum, err := c.cryptoModule.EncryptBound(body.Num, body.Key, bind(body.UserID, itemTypeCard, cardID, "num"))
 	if err != nil {
 		return err
 	}
 
-	encryptedCVV, err := c.cryptoModule.Encrypt(body.CVV, body.Key)
+	encryptedCVV, err := c.cryptoModule.EncryptBound(body.CVV, body.Key, bind(body.UserID, itemTypeCard, cardID, "cvv"))
 	if err != nil {
 		return err
 	}
 
-	encryptedExpDate, err := c.cryptoModule.Encrypt(body.ExpDate, body.Key)
+	encryptedExpDate, err := c.cryptoModule.EncryptBound(body.ExpDate, body.Key, bind(body.UserID, itemTypeCard, cardID, "exp_date"))
 	if err != nil {
 		return err
 	}
 
-	encryptedCardHolderName, err := c.cryptoModule.Encrypt(body.CardHolderName, body.Key)
+	encryptedCardHolderName, err := c.cryptoModule.EncryptBound(body.CardHolderName, body.Key, bind(body.UserID, itemTypeCard, cardID, "card_holder_name"))
 	if err != nil {
 		return err
 	}
@@ -122,47 +163,116 @@ func (c *CardService) Update(ctx context.Context, cardID int64, body dto.UpdateC
 }
 
 // GetAll retrieves and decrypts all card data for a given user.
+// Card numbers are masked down to their last four digits and CVVs are omitted;
+// use Reveal to obtain the full details of a single card.
 //
 // Parameters:
 //   - userID: The ID of the user whose card data is being retrieved.
 //   - key: The encryption key required for decryption.
 //
 // Returns:
-//   - A slice of decrypted entities.Card or an error if retrieval or decryption fails.
+//   - A slice of decrypted, masked entities.Card or an error if retrieval or decryption fails.
 func (c *CardService) GetAll(ctx context.Context, userID int64, key string) ([]entities.Card, error) {
-	encryptedData, err := c.cardStorage.GetAllCardsByUserId(ctx, userID)
+	decryptedData, failed, err := c.GetAllWithFailures(ctx, userID, key)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(encryptedData) == 0 {
-		return nil, fmt.Errorf("records not found")
+	if len(decryptedData) == 0 && len(failed) == 0 {
+		return nil, apperrors.ErrRecordsNotFound
 	}
 
-	decryptedData := c.decryptCardArray(encryptedData, key)
 	return decryptedData, nil
 }
 
+// GetAllWithFailures retrieves and decrypts all card data for a given user, also returning
+// the cards that could not be decrypted instead of silently dropping them.
+// Card numbers are masked and CVVs are omitted, as in GetAll.
+//
+// Parameters:
+//   - userID: The ID of the user whose card data is being retrieved.
+//   - key: The encryption key required for decryption.
+//
+// Returns:
+//   - A slice of decrypted, masked entities.Card.
+//   - A slice of still-encrypted cards that failed to decrypt.
+//   - An error if retrieval fails.
+func (c *CardService) GetAllWithFailures(
+	ctx context.Context,
+	userID int64,
+	key string,
+) ([]entities.Card, []entities.Card, error) {
+	encryptedData, err := c.cardStorage.GetAllCardsByUserId(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyFor, err := c.itemKeys.resolve(ctx, userID, itemTypeCard, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decryptedData, failed := c.decryptCardArray(encryptedData, keyFor)
+	for i := range decryptedData {
+		decryptedData[i].Number = validation.MaskCardNumber(decryptedData[i].Number)
+		decryptedData[i].CVV = ""
+	}
+
+	return decryptedData, failed, nil
+}
+
+// Reveal retrieves and decrypts a single card with its full number and CVV.
+//
+// Parameters:
+//   - userID: The ID of the user requesting the card; the card must belong to this user.
+//   - cardID: The ID of the card to reveal.
+//   - key: The encryption key required for decryption.
+//
+// Returns:
+//   - A pointer to the decrypted entities.Card.
+//   - apperrors.ErrCardNotFound if the card does not exist or belongs to another user,
+//     or an error if retrieval or decryption fails.
+func (c *CardService) Reveal(ctx context.Context, userID, cardID int64, key string) (*entities.Card, error) {
+	encryptedCard, err := c.cardStorage.GetCardByID(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if encryptedCard == nil || int64(encryptedCard.UserID) != userID {
+		return nil, apperrors.ErrCardNotFound
+	}
+
+	key, err = c.itemKeys.key(ctx, userID, itemTypeCard, cardID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.decryptCard(*encryptedCard, key)
+}
+
 // decryptCardArray decrypts an array of encrypted card data.
 //
 // Parameters:
 //   - cards: A slice of encrypted entities.Card.
-//   - key: The encryption key used for decryption.
+//   - keyFor: Returns the encryption key of a card by its ID.
 //
 // Returns:
 //   - A slice of decrypted entities.Card.
-func (c *CardService) decryptCardArray(cards []entities.Card, key string) []entities.Card {
+//   - A slice of the cards that failed to decrypt.
+func (c *CardService) decryptCardArray(cards []entities.Card, keyFor func(int64) string) ([]entities.Card, []entities.Card) {
 	decryptedData := make([]entities.Card, 0, len(cards))
+	var failed []entities.Card
 
 	for i := 0; i < len(cards); i++ {
-		decryptedCard, err := c.decryptCard(cards[i], key)
+		decryptedCard, err := c.decryptCard(cards[i], keyFor(int64(cards[i].ID)))
 		if err != nil {
+			failed = append(failed, cards[i])
 			continue
 		}
 		decryptedData = append(decryptedData, *decryptedCard)
 	}
 
-	return decryptedData
+	return decryptedData, failed
 }
 
 // decryptCard decrypts a single encrypted card entry.
@@ -174,22 +284,24 @@ func (c *CardService) decryptCardArray(cards []entities.Card, key string) []enti
 // Returns:
 //   - A pointer to a decrypted entities.Card or an error if decryption fails.
 func (c *CardService) decryptCard(card entities.Card, key string) (*entities.Card, error) {
-	decryptedNum, err := c.cryptoModule.Decrypt(card.Number, key)
+	userID, cardID := int64(card.UserID), int64(card.ID)
+
+	decryptedNum, err := c.cryptoModule.DecryptBound(card.Number, key, bind(userID, itemTypeCard, cardID, "num"))
 	if err != nil {
 		return nil, err
 	}
 
-	decryptedCVV, err := c.cryptoModule.Decrypt(card.CVV, key)
+	decryptedCVV, err := c.cryptoModule.DecryptBound(card.CVV, key, bind(userID, itemTypeCard, cardID, "cvv"))
 	if err != nil {
 		return nil, err
 	}
 
-	decryptedExpDate, err := c.cryptoModule.Decrypt(card.ExpDate, key)
+	decryptedExpDate, err := c.cryptoModule.DecryptBound(card.ExpDate, key, bind(userID, itemTypeCard, cardID, "exp_date"))
 	if err != nil {
 		return nil, err
 	}
 
-	decryptedCardHolderName, err := c.cryptoModule.Decrypt(card.CardHolderName, key)
+	decryptedCardHolderName, err := c.cryptoModule.DecryptBound(card.CardHolderName, key, bind(userID, itemTypeCard, cardID, "card_holder_name"))
 	if err != nil {
 		return nil, err
 	}
@@ -201,3 +313,27 @@ func (c *CardService) decryptCard(card entities.Card, key string) (*entities.Car
 
 	return &card, nil
 }
+
+// validateCard validates and normalizes card data and detects the card brand.
+//
+// Returns:
+//   - The normalized card number, the normalized MM/YY expiry date and the detected brand,
+//     or a validation error.
+func validateCard(num, cvv, expDate string) (string, string, string, error) {
+	if err := validation.ValidateCardNumber(num); err != nil {
+		return "", "", "", err
+	}
+	num = validation.NormalizeCardNumber(num)
+	brand := validation.DetectCardBrand(num)
+
+	if err := validation.ValidateCVV(cvv, brand); err != nil {
+		return "", "", "", err
+	}
+
+	expDate = strings.TrimSpace(expDate)
+	if _, err := validation.ParseCardExpiry(expDate, timeNow()); err != nil {
+		return "", "", "", err
+	}
+
+	return num, expDate, brand, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +17,11 @@ type MockCardStorage struct {
 	mock.Mock
 }
 
+func (m *MockCardStorage) NextID(ctx context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockCardStorage) CreateCard(ctx context.Context, body dto.CreateCardDTO) error {
 	args := m.Called(body)
 	return args.Error(0)
@@ -25,6 +32,14 @@ func (m *MockCardStorage) GetAllCardsByUserId(ctx context.Context, userID int64)
 	return args.Get(0).([]entities.Card), args.Error(1)
 }
 
+func (m *MockCardStorage) GetCardByID(ctx context.Context, cardID int64) (*entities.Card, error) {
+	args := m.Called(cardID)
+	if card, ok := args.Get(0).(*entities.Card); ok {
+		return card, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockCardStorage) UpdateCard(ctx context.Context, cardID int64, body dto.UpdateCardDTO) error {
 	args := m.Called(cardID, body)
 	return args.Error(0)
@@ -34,23 +49,23 @@ type MockCryptoModule struct {
 	mock.Mock
 }
 
-func (m *MockCryptoModule) Encrypt(data, key string) (string, error) {
-	args := m.Called(data, key)
+func (m *MockCryptoModule) EncryptBound(data, key string, binding cryptox.Binding) (string, error) {
+	args := m.Called(data, key, binding)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockCryptoModule) Decrypt(data, key string) (string, error) {
-	args := m.Called(data, key)
+func (m *MockCryptoModule) DecryptBound(data, key string, binding cryptox.Binding) (string, error) {
+	args := m.Called(data, key, binding)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockCryptoModule) EncryptBinaryData(data []byte, key string) ([]byte, error) {
-	args := m.Called(data, key)
+func (m *MockCryptoModule) EncryptBinaryDataBound(data []byte, key string, binding cryptox.Binding) ([]byte, error) {
+	args := m.Called(data, key, binding)
 	return args.Get(0).([]byte), args.Error(1)
 }
 
-func (m *MockCryptoModule) DecryptBinaryData(data []byte, key string) ([]byte, error) {
-	args := m.Called(data, key)
+func (m *MockCryptoModule) DecryptBinaryDataBound(data []byte, key string, binding cryptox.Binding) ([]byte, error) {
+	args := m.Called(data, key, binding)
 	return args.Get(0).([]byte), args.Error(1)
 }
 
@@ -59,27 +74,143 @@ func (m *MockCryptoModule) GenerateSecretPhrase(txt string) string {
 	return args.String(0)
 }
 
+func (m *MockCryptoModule) NewKDFParams() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) DeriveKey(secret, params string) (string, error) {
+	args := m.Called(secret, params)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) GenerateDataKey() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) WrapKey(dataKey, kek string) (string, error) {
+	args := m.Called(dataKey, kek)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) UnwrapKey(wrapped, kek string) (string, error) {
+	args := m.Called(wrapped, kek)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) NeedsRewrap(wrapped string) bool {
+	args := m.Called(wrapped)
+	return args.Bool(0)
+}
+
+func (m *MockCryptoModule) RotateWrappedKey(wrapped string) (string, error) {
+	args := m.Called(wrapped)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) KDFOutdated(params string) bool {
+	args := m.Called(params)
+	return args.Bool(0)
+}
+
+func (m *MockCryptoModule) NeedsReencrypt(ciphertext string) bool {
+	args := m.Called(ciphertext)
+	return args.Bool(0)
+}
+
+func (m *MockCryptoModule) NeedsReencryptBinary(ciphertext []byte) bool {
+	args := m.Called(ciphertext)
+	return args.Bool(0)
+}
+
+func (m *MockCryptoModule) GenerateRecoveryKey() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) RecoveryKEK(recoveryKey string) (string, error) {
+	args := m.Called(recoveryKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) GenerateRecoveryCode() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) SplitRecoveryKey(recoveryKey string, shares, threshold int) ([]string, error) {
+	args := m.Called(recoveryKey, shares, threshold)
+	if split, ok := args.Get(0).([]string); ok {
+		return split, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCryptoModule) CombineRecoveryShares(shares map[int]string) (string, error) {
+	args := m.Called(shares)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) HashRecoveryCode(code string) string {
+	args := m.Called(code)
+	return args.String(0)
+}
+
+func (m *MockCryptoModule) GenerateKeyPair() (string, string, error) {
+	args := m.Called()
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockCryptoModule) SealKey(key, publicKey string) (string, error) {
+	args := m.Called(key, publicKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) OpenSealedKey(sealed, privateKey string) (string, error) {
+	args := m.Called(sealed, privateKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) SealServerSecret(secret string) (string, error) {
+	args := m.Called(secret)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCryptoModule) OpenServerSecret(stored string) (string, error) {
+	args := m.Called(stored)
+	return args.String(0), args.Error(1)
+}
+
 func TestCreateCard(t *testing.T) {
 	mockStorage := new(MockCardStorage)
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewCardService(mockStorage, mockCrypto, logger)
+	service := NewCardService(mockStorage, mockCrypto, nil, logger)
 
 	cardDTO := dto.CreateCardDTO{
-		Num:            "1234 5678 9101 1121",
+		UserID:         7,
+		Num:            "4111 1111 1111 1111",
 		CVV:            "123",
-		ExpDate:        "12/25",
+		ExpDate:        "12/30",
 		CardHolderName: "John Doe",
 		Key:            "secret",
 	}
 
-	mockCrypto.On("Encrypt", "1234 5678 9101 1121", "secret").Return("encrypted_num", nil)
-	mockCrypto.On("Encrypt", "123", "secret").Return("encrypted_cvv", nil)
-	mockCrypto.On("Encrypt", "12/25", "secret").Return("encrypted_exp", nil)
-	mockCrypto.On("Encrypt", "John Doe", "secret").Return("encrypted_name", nil)
+	field := func(name string) cryptox.Binding {
+		return cryptox.Binding{UserID: 7, ItemType: "cards", ItemID: 42, Field: name}
+	}
+
+	mockStorage.On("NextID").Return(int64(42), nil)
+	mockCrypto.On("EncryptBound", "4111111111111111", "secret", field("num")).Return("encrypted_num", nil)
+	mockCrypto.On("EncryptBound", "123", "secret", field("cvv")).Return("encrypted_cvv", nil)
+	mockCrypto.On("EncryptBound", "12/30", "secret", field("exp_date")).Return("encrypted_exp", nil)
+	mockCrypto.On("EncryptBound", "John Doe", "secret", field("card_holder_name")).Return("encrypted_name", nil)
 
-	mockStorage.On("CreateCard", mock.Anything).Return(nil)
+	mockStorage.On("CreateCard", mock.MatchedBy(func(body dto.CreateCardDTO) bool {
+		return body.ID == 42 && body.Brand == "visa" && body.Num == "encrypted_num"
+	})).Return(nil)
 
 	err := service.Create(context.Background(), cardDTO)
 
@@ -93,22 +224,29 @@ func TestUpdateCard(t *testing.T) {
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewCardService(mockStorage, mockCrypto, logger)
+	service := NewCardService(mockStorage, mockCrypto, nil, logger)
 
 	cardDTO := dto.UpdateCardDTO{
-		Num:            "9876 5432 1098 7654",
+		UserID:         7,
+		Num:            "5555 5555 5555 4444",
 		CVV:            "321",
-		ExpDate:        "11/24",
+		ExpDate:        "11/29",
 		CardHolderName: "Jane Doe",
 		Key:            "secret",
 	}
 
-	mockCrypto.On("Encrypt", "9876 5432 1098 7654", "secret").Return("encrypted_num", nil)
-	mockCrypto.On("Encrypt", "321", "secret").Return("encrypted_cvv", nil)
-	mockCrypto.On("Encrypt", "11/24", "secret").Return("encrypted_exp", nil)
-	mockCrypto.On("Encrypt", "Jane Doe", "secret").Return("encrypted_name", nil)
+	field := func(name string) cryptox.Binding {
+		return cryptox.Binding{UserID: 7, ItemType: "cards", ItemID: 1, Field: name}
+	}
+
+	mockCrypto.On("EncryptBound", "5555555555554444", "secret", field("num")).Return("encrypted_num", nil)
+	mockCrypto.On("EncryptBound", "321", "secret", field("cvv")).Return("encrypted_cvv", nil)
+	mockCrypto.On("EncryptBound", "11/29", "secret", field("exp_date")).Return("encrypted_exp", nil)
+	mockCrypto.On("EncryptBound", "Jane Doe", "secret", field("card_holder_name")).Return("encrypted_name", nil)
 
-	mockStorage.On("UpdateCard", int64(1), mock.Anything).Return(nil)
+	mockStorage.On("UpdateCard", int64(1), mock.MatchedBy(func(body dto.UpdateCardDTO) bool {
+		return body.Brand == "mastercard"
+	})).Return(nil)
 
 	err := service.Update(context.Background(), 1, cardDTO)
 
@@ -122,7 +260,7 @@ func TestGetAllCards_Success(t *testing.T) {
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewCardService(mockStorage, mockCrypto, logger)
+	service := NewCardService(mockStorage, mockCrypto, nil, logger)
 
 	encryptedCards := []entities.Card{
 		{Number: "enc_1", CVV: "enc_2", ExpDate: "enc_3", CardHolderName: "enc_4"},
@@ -130,18 +268,18 @@ func TestGetAllCards_Success(t *testing.T) {
 
 	mockStorage.On("GetAllCardsByUserId", int64(1)).Return(encryptedCards, nil)
 
-	mockCrypto.On("Decrypt", "enc_1", "secret").Return("1234 5678 9101 1121", nil)
-	mockCrypto.On("Decrypt", "enc_2", "secret").Return("123", nil)
-	mockCrypto.On("Decrypt", "enc_3", "secret").Return("12/25", nil)
-	mockCrypto.On("Decrypt", "enc_4", "secret").Return("John Doe", nil)
+	mockCrypto.On("DecryptBound", "enc_1", "secret", mock.Anything).Return("4111111111111111", nil)
+	mockCrypto.On("DecryptBound", "enc_2", "secret", mock.Anything).Return("123", nil)
+	mockCrypto.On("DecryptBound", "enc_3", "secret", mock.Anything).Return("12/30", nil)
+	mockCrypto.On("DecryptBound", "enc_4", "secret", mock.Anything).Return("John Doe", nil)
 
 	cards, err := service.GetAll(context.Background(), 1, "secret")
 
 	assert.NoError(t, err)
 	assert.Len(t, cards, 1)
-	assert.Equal(t, "1234 5678 9101 1121", cards[0].Number)
-	assert.Equal(t, "123", cards[0].CVV)
-	assert.Equal(t, "12/25", cards[0].ExpDate)
+	assert.Equal(t, "**** **** **** 1111", cards[0].Number)
+	assert.Empty(t, cards[0].CVV)
+	assert.Equal(t, "12/30", cards[0].ExpDate)
 	assert.Equal(t, "John Doe", cards[0].CardHolderName)
 
 	mockStorage.AssertExpectations(t)
@@ -153,7 +291,7 @@ func TestGetAllCards_NotFound(t *testing.T) {
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewCardService(mockStorage, mockCrypto, logger)
+	service := NewCardService(mockStorage, mockCrypto, nil, logger)
 
 	mockStorage.On("GetAllCardsByUserId", int64(1)).Return([]entities.Card{}, nil)
 
@@ -165,3 +303,70 @@ func TestGetAllCards_NotFound(t *testing.T) {
 
 	mockStorage.AssertExpectations(t)
 }
+
+func TestCreateCard_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    dto.CreateCardDTO
+		wantErr error
+	}{
+		{
+			name:    "luhn check fails",
+			body:    dto.CreateCardDTO{Num: "4111 1111 1111 1112", CVV: "123", ExpDate: "12/30"},
+			wantErr: apperrors.ErrInvalidCardNumber,
+		},
+		{
+			name:    "amex requires four digit cvv",
+			body:    dto.CreateCardDTO{Num: "3782 822463 10005", CVV: "123", ExpDate: "12/30"},
+			wantErr: apperrors.ErrInvalidCVV,
+		},
+		{
+			name:    "malformed expiry",
+			body:    dto.CreateCardDTO{Num: "4111 1111 1111 1111", CVV: "123", ExpDate: "2030-12"},
+			wantErr: apperrors.ErrInvalidDate,
+		},
+		{
+			name:    "expired card",
+			body:    dto.CreateCardDTO{Num: "4111 1111 1111 1111", CVV: "123", ExpDate: "01/20"},
+			wantErr: apperrors.ErrCardExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockCardStorage)
+			mockCrypto := new(MockCryptoModule)
+			service := NewCardService(mockStorage, mockCrypto, nil, zap.NewNop())
+
+			err := service.Create(context.Background(), tt.body)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockStorage.AssertNotCalled(t, "CreateCard", mock.Anything)
+		})
+	}
+}
+
+func TestRevealCard(t *testing.T) {
+	mockStorage := new(MockCardStorage)
+	mockCrypto := new(MockCryptoModule)
+
+	service := NewCardService(mockStorage, mockCrypto, nil, zap.NewNop())
+
+	mockStorage.On("GetCardByID", int64(5)).Return(&entities.Card{
+		ID: 5, UserID: 1, Number: "enc_1", CVV: "enc_2", ExpDate: "enc_3", CardHolderName: "enc_4", Brand: "visa",
+	}, nil)
+
+	mockCrypto.On("DecryptBound", "enc_1", "secret", mock.Anything).Return("4111111111111111", nil)
+	mockCrypto.On("DecryptBound", "enc_2", "secret", mock.Anything).Return("123", nil)
+	mockCrypto.On("DecryptBound", "enc_3", "secret", mock.Anything).Return("12/30", nil)
+	mockCrypto.On("DecryptBound", "enc_4", "secret", mock.Anything).Return("John Doe", nil)
+
+	card, err := service.Reveal(context.Background(), 1, 5, "secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "4111111111111111", card.Number)
+	assert.Equal(t, "123", card.CVV)
+
+	_, err = service.Reveal(context.Background(), 2, 5, "secret")
+	assert.ErrorIs(t, err, apperrors.ErrCardNotFound)
+}
@@ -0,0 +1,512 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/config"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"go.uber.org/zap"
+)
+
+// MaxEmergencyWaitDays is the longest wait period an emergency contact can be designated with.
+const MaxEmergencyWaitDays = 90
+
+// EmergencyService lets users designate emergency contacts who can request access to their vault.
+//
+// The grantor's data key is sealed to the contact's public key when the contact is designated. The
+// contact requests access when they need it; unless the grantor rejects the request within its wait
+// period, the contact can open the data key and read the vault, or, with takeover access, set a new
+// master password for the account.
+type EmergencyService struct {
+	storage      EmergencyStorage
+	keys         EmergencyKeyStorage
+	vault        EmergencyVaultSources
+	accounts     EmergencyAccountService
+	cryptoModule CryptoModule
+	cfg          config.Config
+	log          *zap.Logger
+}
+
+// EmergencyStorage defines database operations of emergency access.
+type EmergencyStorage interface {
+	// CreateEmergencyAccess stores an emergency contact; apperrors.ErrEmergencyAccessExists if the contact exists.
+	CreateEmergencyAccess(ctx context.Context, access entities.EmergencyAccess) (*entities.EmergencyAccess, error)
+	// GetEmergencyAccess retrieves an emergency access; apperrors.ErrEmergencyAccessNotFound if it does not exist.
+	GetEmergencyAccess(ctx context.Context, id int64) (*entities.EmergencyAccess, error)
+	// ListByGrantor retrieves the emergency contacts designated by a user.
+	ListByGrantor(ctx context.Context, grantorID int64) ([]entities.EmergencyAccess, error)
+	// ListByGrantee retrieves the emergency accesses a user was designated for.
+	ListByGrantee(ctx context.Context, granteeID int64) ([]entities.EmergencyAccess, error)
+	// UpdateEmergencyStatus changes the status of an emergency access if it still is from.
+	UpdateEmergencyStatus(ctx context.Context, id int64, from, to string) error
+	// DeleteEmergencyAccess deletes an emergency contact of a grantor.
+	DeleteEmergencyAccess(ctx context.Context, id, grantorID int64) error
+}
+
+// EmergencyKeyStorage defines the lookup of the key pairs used by emergency access.
+type EmergencyKeyStorage interface {
+	keyPairStorage
+	// GetRecipient retrieves the key pair of a user by username; apperrors.ErrShareRecipientNotFound if there is none.
+	GetRecipient(ctx context.Context, username string) (*entities.UserKeyPair, error)
+}
+
+// EmergencyAccountService defines the account operation of a takeover.
+type EmergencyAccountService interface {
+	// ResetPassword sets a new master password for a user whose data key is known.
+	ResetPassword(ctx context.Context, userID int64, key, newPassword string) error
+}
+
+// EmergencyVaultSources are the services a grantor's vault is read from, normally the vault services.
+type EmergencyVaultSources struct {
+	LogoPass interface {
+		GetAll(ctx context.Context, userID int64, key string) ([]entities.LogoPassword, error)
+	}
+	Card interface {
+		GetAll(ctx context.Context, userID int64, key string) ([]entities.Card, error)
+	}
+	Note interface {
+		GetAll(ctx context.Context, userID int, key string) ([]entities.Note, error)
+	}
+	Binary interface {
+		GetAll(ctx context.Context, userID int64, key string) ([]entities.BinaryData, error)
+	}
+	BankAccount interface {
+		GetAll(ctx context.Context, userID int64, key string) ([]entities.BankAccount, error)
+	}
+	IdentityDocument interface {
+		GetAll(ctx context.Context, userID int64, key string) ([]entities.IdentityDocument, error)
+	}
+}
+
+// NewEmergencyService creates a new instance of EmergencyService.
+//
+// Parameters:
+//   - storage: An implementation of the EmergencyStorage interface.
+//   - keys: An implementation of the EmergencyKeyStorage interface.
+//   - vault: The services the grantor's vault is read from.
+//   - accounts: An EmergencyAccountService, normally the UserService.
+//   - cryptoModule: An implementation of CryptoModule for key unwrapping and sealing.
+//   - cfg: A configuration object holding the default wait period.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to an EmergencyService instance.
+func NewEmergencyService(
+	storage EmergencyStorage,
+	keys EmergencyKeyStorage,
+	vault EmergencyVaultSources,
+	accounts EmergencyAccountService,
+	cryptoModule CryptoModule,
+	cfg config.Config,
+	log *zap.Logger,
+) *EmergencyService {
+	return &EmergencyService{
+		storage:      storage,
+		keys:         keys,
+		vault:        vault,
+		accounts:     accounts,
+		cryptoModule: cryptoModule,
+		cfg:          cfg,
+		log:          log,
+	}
+}
+
+// Invite designates a user as an emergency contact of the authenticated user, sealing the user's
+// data key to the contact's public key.
+//
+// Parameters:
+//   - body: The grantor's ID and vault key, the contact's username, the access type ("view" by
+//     default) and the wait period in days (the configured default if not set).
+//
+// Returns:
+//   - The created emergency access.
+//   - apperrors.ErrInvalidEmergencyAccess for an unknown access type, a wait period out of range or
+//     the grantor themselves, apperrors.ErrEmergencyContactNotFound if the contact does not exist or
+//     has no key pair, apperrors.ErrKeyPairNotFound if the grantor's key pair cannot be unwrapped
+//     with the vault key, apperrors.ErrEmergencyAccessExists, or an error if storage fails.
+func (e *EmergencyService) Invite(ctx context.Context, body dto.CreateEmergencyAccessDTO) (*entities.EmergencyAccess, error) {
+	accessType := body.AccessType
+	if accessType == "" {
+		accessType = entities.EmergencyAccessView
+	}
+	if accessType != entities.EmergencyAccessView && accessType != entities.EmergencyAccessTakeover {
+		return nil, apperrors.ErrInvalidEmergencyAccess
+	}
+
+	waitDays := e.cfg.EmergencyWaitDays
+	if body.WaitDays != nil {
+		waitDays = *body.WaitDays
+	}
+	if waitDays < 0 || waitDays > MaxEmergencyWaitDays {
+		return nil, apperrors.ErrInvalidEmergencyAccess
+	}
+
+	recipient, err := e.keys.GetRecipient(ctx, strings.TrimSpace(body.Username))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrShareRecipientNotFound) {
+			return nil, apperrors.ErrEmergencyContactNotFound
+		}
+		return nil, err
+	}
+	granteeID := int64(recipient.UserID)
+	if granteeID == body.GrantorID {
+		return nil, apperrors.ErrInvalidEmergencyAccess
+	}
+
+	// The vault key is sealed as given, so make sure it is the grantor's data key.
+	if _, err := openPrivateKey(ctx, e.keys, e.cryptoModule, e.log, body.GrantorID, body.Key); err != nil {
+		return nil, err
+	}
+
+	sealed, err := e.cryptoModule.SealKey(body.Key, recipient.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := e.storage.CreateEmergencyAccess(ctx, entities.EmergencyAccess{
+		GrantorID:  body.GrantorID,
+		GranteeID:  granteeID,
+		AccessType: accessType,
+		WaitDays:   waitDays,
+		WrappedKey: sealed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.log.Info("emergency contact designated",
+		zap.Int64("accessID", access.ID), zap.Int64("grantorID", body.GrantorID), zap.Int64("granteeID", granteeID))
+
+	return present(access), nil
+}
+
+// ListContacts retrieves the emergency contacts designated by the authenticated user.
+//
+// Parameters:
+//   - grantorID: The ID of the user.
+//
+// Returns:
+//   - The emergency accesses with their effective status, or an error if retrieval fails.
+func (e *EmergencyService) ListContacts(ctx context.Context, grantorID int64) ([]entities.EmergencyAccess, error) {
+	return presentAll(e.storage.ListByGrantor(ctx, grantorID))
+}
+
+// ListGrantors retrieves the emergency accesses the authenticated user was designated for.
+//
+// Parameters:
+//   - granteeID: The ID of the user.
+//
+// Returns:
+//   - The emergency accesses with their effective status, or an error if retrieval fails.
+func (e *EmergencyService) ListGrantors(ctx context.Context, granteeID int64) ([]entities.EmergencyAccess, error) {
+	return presentAll(e.storage.ListByGrantee(ctx, granteeID))
+}
+
+// Delete removes an emergency contact of the authenticated user, whatever its status.
+//
+// Parameters:
+//   - grantorID: The ID of the user.
+//   - id: The ID of the emergency access.
+//
+// Returns:
+//   - apperrors.ErrEmergencyAccessNotFound if the user has no such contact, or an error if deletion fails.
+func (e *EmergencyService) Delete(ctx context.Context, grantorID, id int64) error {
+	if err := e.storage.DeleteEmergencyAccess(ctx, id, grantorID); err != nil {
+		return err
+	}
+
+	e.log.Info("emergency contact deleted", zap.Int64("accessID", id), zap.Int64("grantorID", grantorID))
+
+	return nil
+}
+
+// Approve grants a requested emergency access before its wait period ends.
+//
+// Parameters:
+//   - grantorID: The ID of the authenticated user.
+//   - id: The ID of the emergency access.
+//
+// Returns:
+//   - apperrors.ErrEmergencyAccessNotFound if the user has no such contact or it was not requested,
+//     or an error if storage fails.
+func (e *EmergencyService) Approve(ctx context.Context, grantorID, id int64) error {
+	access, err := e.grantorAccess(ctx, grantorID, id)
+	if err != nil {
+		return err
+	}
+	if access.Status != entities.EmergencyStatusRequested {
+		return apperrors.ErrEmergencyAccessNotFound
+	}
+
+	if err := e.storage.UpdateEmergencyStatus(ctx, id, access.Status, entities.EmergencyStatusApproved); err != nil {
+		return err
+	}
+
+	e.log.Info("emergency access approved", zap.Int64("accessID", id), zap.Int64("grantorID", grantorID))
+
+	return nil
+}
+
+// Reject rejects a requested emergency access or revokes an approved one; the contact stays
+// designated and can request access again.
+//
+// Parameters:
+//   - grantorID: The ID of the authenticated user.
+//   - id: The ID of the emergency access.
+//
+// Returns:
+//   - apperrors.ErrEmergencyAccessNotFound if the user has no such contact or it was not requested,
+//     or an error if storage fails.
+func (e *EmergencyService) Reject(ctx context.Context, grantorID, id int64) error {
+	access, err := e.grantorAccess(ctx, grantorID, id)
+	if err != nil {
+		return err
+	}
+	if access.Status == entities.EmergencyStatusIdle {
+		return apperrors.ErrEmergencyAccessNotFound
+	}
+
+	if err := e.storage.UpdateEmergencyStatus(ctx, id, access.Status, entities.EmergencyStatusIdle); err != nil {
+		return err
+	}
+
+	e.log.Info("emergency access rejected", zap.Int64("accessID", id), zap.Int64("grantorID", grantorID))
+
+	return nil
+}
+
+// Request requests emergency access to a grantor's vault, starting its wait period.
+//
+// Parameters:
+//   - granteeID: The ID of the authenticated user.
+//   - id: The ID of the emergency access.
+//
+// Returns:
+//   - The requested emergency access with the time it becomes available.
+//   - apperrors.ErrEmergencyAccessNotFound if the user is not the contact, apperrors.ErrEmergencyAccessExists
+//     if access was already requested, or an error if storage fails.
+func (e *EmergencyService) Request(ctx context.Context, granteeID, id int64) (*entities.EmergencyAccess, error) {
+	access, err := e.granteeAccess(ctx, granteeID, id)
+	if err != nil {
+		return nil, err
+	}
+	if access.Status != entities.EmergencyStatusIdle {
+		return nil, apperrors.ErrEmergencyAccessExists
+	}
+
+	if err := e.storage.UpdateEmergencyStatus(ctx, id, entities.EmergencyStatusIdle, entities.EmergencyStatusRequested); err != nil {
+		return nil, err
+	}
+
+	e.log.Info("emergency access requested",
+		zap.Int64("accessID", id), zap.Int64("grantorID", access.GrantorID), zap.Int64("granteeID", granteeID))
+
+	access, err = e.storage.GetEmergencyAccess(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return present(access), nil
+}
+
+// View decrypts the vault of a grantor for an emergency contact whose access is approved.
+//
+// Parameters:
+//   - granteeID: The ID of the authenticated user.
+//   - id: The ID of the emergency access.
+//   - key: The vault key of the authenticated user.
+//
+// Returns:
+//   - The grantor's decrypted vault.
+//   - apperrors.ErrEmergencyAccessNotFound, apperrors.ErrEmergencyAccessNotApproved,
+//     apperrors.ErrKeyPairNotFound if the user's key pair cannot be unwrapped with the vault key,
+//     apperrors.ErrKeyDerivation if the sealed data key no longer opens the grantor's vault, or an
+//     error if the vault cannot be read.
+func (e *EmergencyService) View(ctx context.Context, granteeID, id int64, key string) (*dto.EmergencyVaultDTO, error) {
+	access, err := e.granteeAccess(ctx, granteeID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := e.open(ctx, access, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var vault dto.EmergencyVaultDTO
+	grantorID := access.GrantorID
+	if vault.LogoPasses, err = vaultItems(e.vault.LogoPass.GetAll(ctx, grantorID, dataKey)); err != nil {
+		return nil, err
+	}
+	if vault.Cards, err = vaultItems(e.vault.Card.GetAll(ctx, grantorID, dataKey)); err != nil {
+		return nil, err
+	}
+	if vault.Notes, err = vaultItems(e.vault.Note.GetAll(ctx, int(grantorID), dataKey)); err != nil {
+		return nil, err
+	}
+	if vault.Binaries, err = vaultItems(e.vault.Binary.GetAll(ctx, grantorID, dataKey)); err != nil {
+		return nil, err
+	}
+	if vault.BankAccounts, err = vaultItems(e.vault.BankAccount.GetAll(ctx, grantorID, dataKey)); err != nil {
+		return nil, err
+	}
+	if vault.IdentityDocuments, err = vaultItems(e.vault.IdentityDocument.GetAll(ctx, grantorID, dataKey)); err != nil {
+		return nil, err
+	}
+
+	e.log.Info("emergency vault viewed", zap.Int64("accessID", id), zap.Int64("grantorID", grantorID), zap.Int64("granteeID", granteeID))
+
+	return &vault, nil
+}
+
+// vaultItems returns the items of a vault category read by View. A category without records is
+// returned as an empty slice, as some sources report it with apperrors.ErrRecordsNotFound.
+func vaultItems[T any](items []T, err error) ([]T, error) {
+	if errors.Is(err, apperrors.ErrRecordsNotFound) {
+		return []T{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return []T{}, nil
+	}
+
+	return items, nil
+}
+
+// Takeover sets a new master password for the account of a grantor, for an emergency contact with
+// approved takeover access. The grantor's data key is kept, so the vault stays readable with the new
+// password and the emergency accesses of other contacts stay valid. All sessions of the grantor are
+// revoked, so that nobody stays signed in to the account that was taken over.
+//
+// Parameters:
+//   - body: The ID of the emergency access, the contact's ID and vault key and the new master password.
+//
+// Returns:
+//   - apperrors.ErrEmergencyAccessNotFound, apperrors.ErrEmergencyAccessNotApproved,
+//     apperrors.ErrEmergencyTakeoverNotAllowed for view access, apperrors.ErrKeyPairNotFound,
+//     apperrors.ErrKeyDerivation, or an error if the password cannot be reset.
+func (e *EmergencyService) Takeover(ctx context.Context, body dto.EmergencyTakeoverDTO) error {
+	access, err := e.granteeAccess(ctx, body.GranteeID, body.ID)
+	if err != nil {
+		return err
+	}
+	if access.AccessType != entities.EmergencyAccessTakeover {
+		return apperrors.ErrEmergencyTakeoverNotAllowed
+	}
+
+	dataKey, err := e.open(ctx, access, body.Key)
+	if err != nil {
+		return err
+	}
+
+	if err := e.accounts.ResetPassword(ctx, access.GrantorID, dataKey, body.NewPassword); err != nil {
+		return err
+	}
+
+	e.log.Info("emergency takeover",
+		zap.Int64("accessID", body.ID), zap.Int64("grantorID", access.GrantorID), zap.Int64("granteeID", body.GranteeID))
+
+	return nil
+}
+
+// grantorAccess retrieves an emergency access designated by a grantor, as stored.
+//
+// Returns:
+//   - The emergency access, or apperrors.ErrEmergencyAccessNotFound if it is not the grantor's.
+func (e *EmergencyService) grantorAccess(ctx context.Context, grantorID, id int64) (*entities.EmergencyAccess, error) {
+	access, err := e.storage.GetEmergencyAccess(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if access.GrantorID != grantorID {
+		return nil, apperrors.ErrEmergencyAccessNotFound
+	}
+
+	return access, nil
+}
+
+// granteeAccess retrieves an emergency access a grantee was designated for, with its effective status.
+//
+// Returns:
+//   - The emergency access, or apperrors.ErrEmergencyAccessNotFound if it is not the grantee's.
+func (e *EmergencyService) granteeAccess(ctx context.Context, granteeID, id int64) (*entities.EmergencyAccess, error) {
+	access, err := e.storage.GetEmergencyAccess(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if access.GranteeID != granteeID {
+		return nil, apperrors.ErrEmergencyAccessNotFound
+	}
+
+	return present(access), nil
+}
+
+// open opens the grantor's data key of an approved emergency access with the grantee's private key
+// and makes sure it still is the grantor's data key.
+//
+// Parameters:
+//   - access: The emergency access, with its effective status.
+//   - key: The vault key of the grantee.
+//
+// Returns:
+//   - The grantor's data key, or apperrors.ErrEmergencyAccessNotApproved, apperrors.ErrKeyPairNotFound
+//     or apperrors.ErrKeyDerivation.
+func (e *EmergencyService) open(ctx context.Context, access *entities.EmergencyAccess, key string) (string, error) {
+	if access.Status != entities.EmergencyStatusApproved {
+		return "", apperrors.ErrEmergencyAccessNotApproved
+	}
+
+	privateKey, err := openPrivateKey(ctx, e.keys, e.cryptoModule, e.log, access.GranteeID, key)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := e.cryptoModule.OpenSealedKey(access.WrappedKey, privateKey)
+	if err != nil {
+		e.log.Warn("open emergency key error", zap.Int64("accessID", access.ID), zap.Error(err))
+		return "", apperrors.ErrKeyDerivation
+	}
+
+	// The grantor may have lost the key pair the access was designated with, e.g. by deleting it.
+	if _, err := openPrivateKey(ctx, e.keys, e.cryptoModule, e.log, access.GrantorID, dataKey); err != nil {
+		e.log.Warn("emergency key does not open the grantor's vault", zap.Int64("accessID", access.ID), zap.Error(err))
+		return "", apperrors.ErrKeyDerivation
+	}
+
+	return dataKey, nil
+}
+
+// present sets the time a requested emergency access becomes available and reports it as approved
+// once its wait period has passed.
+func present(access *entities.EmergencyAccess) *entities.EmergencyAccess {
+	if access.Status != entities.EmergencyStatusRequested || access.RequestedAt == nil {
+		return access
+	}
+
+	availableAt := access.RequestedAt.Add(time.Duration(access.WaitDays) * 24 * time.Hour)
+	access.AvailableAt = &availableAt
+	if !timeNow().Before(availableAt) {
+		access.Status = entities.EmergencyStatusApproved
+	}
+
+	return access
+}
+
+// presentAll applies present to every emergency access retrieved.
+func presentAll(accesses []entities.EmergencyAccess, err error) ([]entities.EmergencyAccess, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i := range accesses {
+		present(&accesses[i])
+	}
+
+	return accesses, nil
+}
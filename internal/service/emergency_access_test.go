@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/config"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockEmergencyStorage struct {
+	mock.Mock
+}
+
+func (m *MockEmergencyStorage) CreateEmergencyAccess(ctx context.Context, access entities.EmergencyAccess) (*entities.EmergencyAccess, error) {
+	args := m.Called(access)
+	if created, ok := args.Get(0).(*entities.EmergencyAccess); ok {
+		return created, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockEmergencyStorage) GetEmergencyAccess(ctx context.Context, id int64) (*entities.EmergencyAccess, error) {
+	args := m.Called(id)
+	if access, ok := args.Get(0).(*entities.EmergencyAccess); ok {
+		copied := *access
+		return &copied, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockEmergencyStorage) ListByGrantor(ctx context.Context, grantorID int64) ([]entities.EmergencyAccess, error) {
+	args := m.Called(grantorID)
+	return args.Get(0).([]entities.EmergencyAccess), args.Error(1)
+}
+
+func (m *MockEmergencyStorage) ListByGrantee(ctx context.Context, granteeID int64) ([]entities.EmergencyAccess, error) {
+	args := m.Called(granteeID)
+	return args.Get(0).([]entities.EmergencyAccess), args.Error(1)
+}
+
+func (m *MockEmergencyStorage) UpdateEmergencyStatus(ctx context.Context, id int64, from, to string) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+func (m *MockEmergencyStorage) DeleteEmergencyAccess(ctx context.Context, id, grantorID int64) error {
+	args := m.Called(id, grantorID)
+	return args.Error(0)
+}
+
+type MockEmergencyAccounts struct {
+	mock.Mock
+}
+
+func (m *MockEmergencyAccounts) ResetPassword(ctx context.Context, userID int64, key, newPassword string) error {
+	args := m.Called(userID, key, newPassword)
+	return args.Error(0)
+}
+
+// stubVaultSource returns its items for the grantor's data key and nothing for any other key. Like
+// the card and logopass services, it reports a category without records as not found.
+type stubVaultSource[T any] []T
+
+func (s stubVaultSource[T]) GetAll(ctx context.Context, userID int64, key string) ([]T, error) {
+	if userID != 1 || key != "k1.dek" {
+		return nil, nil
+	}
+	if len(s) == 0 {
+		return nil, apperrors.ErrRecordsNotFound
+	}
+	return s, nil
+}
+
+type stubNoteSource []entities.Note
+
+func (s stubNoteSource) GetAll(ctx context.Context, userID int, key string) ([]entities.Note, error) {
+	if userID != 1 || key != "k1.dek" {
+		return nil, nil
+	}
+	return s, nil
+}
+
+var testEmergencyConfig = config.Config{EmergencyWaitDays: 7}
+
+func newTestEmergencyService() (*EmergencyService, *MockEmergencyStorage, *MockSharingStorage, *MockCryptoModule, *MockEmergencyAccounts) {
+	mockStorage := new(MockEmergencyStorage)
+	mockKeys := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	mockAccounts := new(MockEmergencyAccounts)
+	vault := EmergencyVaultSources{
+		LogoPass:         stubVaultSource[entities.LogoPassword]{{ID: 1, AppName: "mail", Password: "secret"}},
+		Card:             stubVaultSource[entities.Card]{},
+		Note:             stubNoteSource{{ID: 2, Title: "will"}},
+		Binary:           stubVaultSource[entities.BinaryData]{},
+		BankAccount:      stubVaultSource[entities.BankAccount]{},
+		IdentityDocument: stubVaultSource[entities.IdentityDocument]{},
+	}
+	service := NewEmergencyService(mockStorage, mockKeys, vault, mockAccounts, mockCrypto, testEmergencyConfig, zap.NewNop())
+	return service, mockStorage, mockKeys, mockCrypto, mockAccounts
+}
+
+// pinEmergencyClock pins the clock to now for the duration of the test.
+func pinEmergencyClock(t *testing.T, now time.Time) {
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+// waitDays returns a pointer to an explicit wait period of an invitation.
+func waitDays(days int) *int {
+	return &days
+}
+
+// requestedAccess is an emergency access of grantee 2 to grantor 1 requested at requestedAt.
+func requestedAccess(accessType string, requestedAt time.Time) *entities.EmergencyAccess {
+	return &entities.EmergencyAccess{
+		ID:          5,
+		GrantorID:   1,
+		GranteeID:   2,
+		AccessType:  accessType,
+		WaitDays:    7,
+		Status:      entities.EmergencyStatusRequested,
+		RequestedAt: &requestedAt,
+		WrappedKey:  "s1.dek",
+	}
+}
+
+func TestEmergencyService_Invite_SealsDataKeyWithDefaults(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestEmergencyService()
+
+	mockKeys.On("GetRecipient", "bob").Return(&entities.UserKeyPair{UserID: 2, PublicKey: "x1.bob"}, nil)
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("SealKey", "k1.dek", "x1.bob").Return("s1.dek", nil)
+	mockStorage.On("CreateEmergencyAccess", entities.EmergencyAccess{
+		GrantorID:  1,
+		GranteeID:  2,
+		AccessType: entities.EmergencyAccessView,
+		WaitDays:   7,
+		WrappedKey: "s1.dek",
+	}).Return(&entities.EmergencyAccess{ID: 5, GrantorID: 1, GranteeID: 2, Status: entities.EmergencyStatusIdle}, nil)
+
+	access, err := service.Invite(context.Background(), dto.CreateEmergencyAccessDTO{GrantorID: 1, Key: "k1.dek", Username: " bob "})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), access.ID)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestEmergencyService_Invite_WithoutWaitPeriod(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestEmergencyService()
+
+	mockKeys.On("GetRecipient", "bob").Return(&entities.UserKeyPair{UserID: 2, PublicKey: "x1.bob"}, nil)
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("SealKey", "k1.dek", "x1.bob").Return("s1.dek", nil)
+	mockStorage.On("CreateEmergencyAccess", entities.EmergencyAccess{
+		GrantorID:  1,
+		GranteeID:  2,
+		AccessType: entities.EmergencyAccessTakeover,
+		WaitDays:   0,
+		WrappedKey: "s1.dek",
+	}).Return(&entities.EmergencyAccess{ID: 5, GrantorID: 1, GranteeID: 2, Status: entities.EmergencyStatusIdle}, nil)
+
+	_, err := service.Invite(context.Background(), dto.CreateEmergencyAccessDTO{
+		GrantorID:  1,
+		Key:        "k1.dek",
+		Username:   "bob",
+		AccessType: entities.EmergencyAccessTakeover,
+		WaitDays:   waitDays(0),
+	})
+
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestEmergencyService_Invite_Rejected(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestEmergencyService()
+
+	mockKeys.On("GetRecipient", "alice").Return(&entities.UserKeyPair{UserID: 1, PublicKey: "x1.alice"}, nil)
+	mockKeys.On("GetRecipient", "bob").Return(&entities.UserKeyPair{UserID: 2, PublicKey: "x1.bob"}, nil)
+	mockKeys.On("GetRecipient", "carol").Return(nil, apperrors.ErrShareRecipientNotFound)
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("UnwrapKey", "w1.private", "k1.wrong").Return("", assert.AnError)
+
+	cases := []struct {
+		body dto.CreateEmergencyAccessDTO
+		err  error
+	}{
+		{dto.CreateEmergencyAccessDTO{Username: "bob", AccessType: "admin"}, apperrors.ErrInvalidEmergencyAccess},
+		{dto.CreateEmergencyAccessDTO{Username: "bob", WaitDays: waitDays(MaxEmergencyWaitDays + 1)}, apperrors.ErrInvalidEmergencyAccess},
+		{dto.CreateEmergencyAccessDTO{Username: "bob", WaitDays: waitDays(-1)}, apperrors.ErrInvalidEmergencyAccess},
+		{dto.CreateEmergencyAccessDTO{Username: "alice"}, apperrors.ErrInvalidEmergencyAccess},
+		{dto.CreateEmergencyAccessDTO{Username: "carol"}, apperrors.ErrEmergencyContactNotFound},
+		{dto.CreateEmergencyAccessDTO{Username: "bob", Key: "k1.wrong"}, apperrors.ErrKeyPairNotFound},
+	}
+	for _, tc := range cases {
+		tc.body.GrantorID = 1
+		if tc.body.Key == "" {
+			tc.body.Key = "k1.dek"
+		}
+		_, err := service.Invite(context.Background(), tc.body)
+		assert.ErrorIs(t, err, tc.err, tc.body)
+	}
+
+	mockStorage.AssertNotCalled(t, "CreateEmergencyAccess", mock.Anything)
+}
+
+func TestEmergencyService_ListContacts_ApprovesAfterWaitPeriod(t *testing.T) {
+	service, mockStorage, _, _, _ := newTestEmergencyService()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	pinEmergencyClock(t, now)
+
+	mockStorage.On("ListByGrantor", int64(1)).Return([]entities.EmergencyAccess{
+		*requestedAccess(entities.EmergencyAccessView, now.AddDate(0, 0, -8)),
+		*requestedAccess(entities.EmergencyAccessView, now.AddDate(0, 0, -2)),
+		{ID: 6, GrantorID: 1, GranteeID: 3, WaitDays: 7, Status: entities.EmergencyStatusIdle},
+	}, nil)
+
+	accesses, err := service.ListContacts(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, accesses, 3)
+	assert.Equal(t, entities.EmergencyStatusApproved, accesses[0].Status, "A request not rejected within the wait period should be approved")
+	assert.Equal(t, entities.EmergencyStatusRequested, accesses[1].Status)
+	assert.Equal(t, now.AddDate(0, 0, 5), *accesses[1].AvailableAt)
+	assert.Nil(t, accesses[2].AvailableAt)
+}
+
+func TestEmergencyService_Request(t *testing.T) {
+	service, mockStorage, _, _, _ := newTestEmergencyService()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	pinEmergencyClock(t, now)
+
+	idle := &entities.EmergencyAccess{ID: 5, GrantorID: 1, GranteeID: 2, WaitDays: 7, Status: entities.EmergencyStatusIdle}
+	mockStorage.On("GetEmergencyAccess", int64(5)).Return(idle, nil).Once()
+	mockStorage.On("UpdateEmergencyStatus", int64(5), entities.EmergencyStatusIdle, entities.EmergencyStatusRequested).Return(nil)
+	mockStorage.On("GetEmergencyAccess", int64(5)).Return(requestedAccess(entities.EmergencyAccessView, now), nil)
+
+	access, err := service.Request(context.Background(), 2, 5)
+	require.NoError(t, err)
+	assert.Equal(t, entities.EmergencyStatusRequested, access.Status)
+	assert.Equal(t, now.AddDate(0, 0, 7), *access.AvailableAt)
+
+	_, err = service.Request(context.Background(), 2, 5)
+	assert.ErrorIs(t, err, apperrors.ErrEmergencyAccessExists, "A pending request should not restart the wait period")
+
+	_, err = service.Request(context.Background(), 3, 5)
+	assert.ErrorIs(t, err, apperrors.ErrEmergencyAccessNotFound, "Only the contact should request access")
+}
+
+func TestEmergencyService_ApproveAndReject(t *testing.T) {
+	service, mockStorage, _, _, _ := newTestEmergencyService()
+
+	mockStorage.On("GetEmergencyAccess", int64(5)).Return(requestedAccess(entities.EmergencyAccessView, time.Now()), nil)
+	mockStorage.On("GetEmergencyAccess", int64(6)).Return(&entities.EmergencyAccess{ID: 6, GrantorID: 1, Status: entities.EmergencyStatusIdle}, nil)
+	mockStorage.On("UpdateEmergencyStatus", int64(5), entities.EmergencyStatusRequested, entities.EmergencyStatusApproved).Return(nil)
+	mockStorage.On("UpdateEmergencyStatus", int64(5), entities.EmergencyStatusRequested, entities.EmergencyStatusIdle).Return(nil)
+
+	require.NoError(t, service.Approve(context.Background(), 1, 5))
+	require.NoError(t, service.Reject(context.Background(), 1, 5))
+
+	assert.ErrorIs(t, service.Approve(context.Background(), 2, 5), apperrors.ErrEmergencyAccessNotFound, "Only the grantor should approve")
+	assert.ErrorIs(t, service.Approve(context.Background(), 1, 6), apperrors.ErrEmergencyAccessNotFound)
+	assert.ErrorIs(t, service.Reject(context.Background(), 1, 6), apperrors.ErrEmergencyAccessNotFound)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestEmergencyService_View(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestEmergencyService()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	pinEmergencyClock(t, now)
+
+	mockStorage.On("GetEmergencyAccess", int64(5)).Return(requestedAccess(entities.EmergencyAccessView, now.AddDate(0, 0, -7)), nil)
+	mockStorage.On("GetEmergencyAccess", int64(6)).Return(requestedAccess(entities.EmergencyAccessView, now.AddDate(0, 0, -6)), nil)
+	expectKeyPair(mockKeys, mockCrypto, 2, "k2.dek")
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("OpenSealedKey", "s1.dek", "k1.private").Return("k1.dek", nil)
+
+	vault, err := service.View(context.Background(), 2, 5, "k2.dek")
+
+	require.NoError(t, err)
+	require.Len(t, vault.LogoPasses, 1)
+	assert.Equal(t, "secret", vault.LogoPasses[0].Password)
+	require.Len(t, vault.Notes, 1)
+
+	_, err = service.View(context.Background(), 2, 6, "k2.dek")
+	assert.ErrorIs(t, err, apperrors.ErrEmergencyAccessNotApproved, "The vault should stay closed during the wait period")
+}
+
+func TestEmergencyService_View_EmptyCategories(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestEmergencyService()
+	service.vault.LogoPass = stubVaultSource[entities.LogoPassword]{}
+
+	approved := requestedAccess(entities.EmergencyAccessView, time.Now())
+	approved.Status = entities.EmergencyStatusApproved
+	mockStorage.On("GetEmergencyAccess", int64(5)).Return(approved, nil)
+	expectKeyPair(mockKeys, mockCrypto, 2, "k2.dek")
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("OpenSealedKey", "s1.dek", "k1.private").Return("k1.dek", nil)
+
+	vault, err := service.View(context.Background(), 2, 5, "k2.dek")
+
+	require.NoError(t, err, "A grantor without logins or cards should still have a readable vault")
+	assert.Empty(t, vault.LogoPasses)
+	assert.NotNil(t, vault.Cards, "An empty category should be an empty list")
+	assert.Empty(t, vault.Cards)
+	assert.Len(t, vault.Notes, 1)
+}
+
+func TestEmergencyService_View_GrantorKeyChanged(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestEmergencyService()
+
+	approved := requestedAccess(entities.EmergencyAccessView, time.Now())
+	approved.Status = entities.EmergencyStatusApproved
+	mockStorage.On("GetEmergencyAccess", int64(5)).Return(approved, nil)
+	expectKeyPair(mockKeys, mockCrypto, 2, "k2.dek")
+	mockCrypto.On("OpenSealedKey", "s1.dek", "k1.private").Return("k1.stale", nil)
+	mockKeys.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1, WrappedPrivateKey: "w1.private"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.private", "k1.stale").Return("", assert.AnError)
+
+	_, err := service.View(context.Background(), 2, 5, "k2.dek")
+
+	assert.ErrorIs(t, err, apperrors.ErrKeyDerivation)
+}
+
+func TestEmergencyService_Takeover(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, mockAccounts := newTestEmergencyService()
+
+	takeover := requestedAccess(entities.EmergencyAccessTakeover, time.Now())
+	takeover.Status = entities.EmergencyStatusApproved
+	view := requestedAccess(entities.EmergencyAccessView, time.Now())
+	view.Status = entities.EmergencyStatusApproved
+	mockStorage.On("GetEmergencyAccess", int64(5)).Return(takeover, nil)
+	mockStorage.On("GetEmergencyAccess", int64(6)).Return(view, nil)
+	expectKeyPair(mockKeys, mockCrypto, 2, "k2.dek")
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("OpenSealedKey", "s1.dek", "k1.private").Return("k1.dek", nil)
+	mockAccounts.On("ResetPassword", int64(1), "k1.dek", "newpassword").Return(nil)
+
+	err := service.Takeover(context.Background(), dto.EmergencyTakeoverDTO{ID: 5, GranteeID: 2, Key: "k2.dek", NewPassword: "newpassword"})
+	require.NoError(t, err)
+
+	err = service.Takeover(context.Background(), dto.EmergencyTakeoverDTO{ID: 6, GranteeID: 2, Key: "k2.dek", NewPassword: "newpassword"})
+	assert.ErrorIs(t, err, apperrors.ErrEmergencyTakeoverNotAllowed)
+	mockAccounts.AssertNumberOfCalls(t, "ResetPassword", 1)
+}
@@ -0,0 +1,137 @@
+// Package service provides business logic for generating passwords and passphrases.
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/pkg/passgen"
+	"go.uber.org/zap"
+)
+
+// GeneratorService generates random passwords and passphrases and can store
+// the generated secret directly as a new login-password entry.
+type GeneratorService struct {
+	generator PasswordGenerator
+	logoPass  LogoPassCreator
+	log       *zap.Logger
+}
+
+// PasswordGenerator defines an interface for generating passwords and passphrases.
+type PasswordGenerator interface {
+	// Password generates a password according to the policy and returns it with its entropy in bits.
+	Password(p passgen.Policy) (string, float64, error)
+	// Passphrase generates a passphrase according to the policy and returns it with its entropy in bits.
+	Passphrase(p passgen.PassphrasePolicy) (string, float64, error)
+}
+
+// LogoPassCreator defines an interface for storing a new login-password entry.
+type LogoPassCreator interface {
+	// Create encrypts and stores a login-password entry.
+	Create(ctx context.Context, body dto.CreateLogoPassDTO) (*dto.BreachCheckDTO, error)
+}
+
+// NewGeneratorService creates a new instance of GeneratorService with the provided dependencies.
+//
+// Parameters:
+//   - generator: An implementation of PasswordGenerator producing the secrets.
+//   - logoPass: An implementation of LogoPassCreator used to save generated secrets.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to a GeneratorService instance.
+func NewGeneratorService(
+	generator PasswordGenerator,
+	logoPass LogoPassCreator,
+	log *zap.Logger,
+) *GeneratorService {
+	return &GeneratorService{
+		generator: generator,
+		logoPass:  logoPass,
+		log:       log,
+	}
+}
+
+// GeneratePassword generates a password according to the requested policy.
+// If body.SaveAs is set, the password is also stored as a new login-password entry.
+//
+// Parameters:
+//   - body: A dto.GeneratePasswordDTO containing the policy, an optional save target and an encryption key.
+//
+// Returns:
+//   - A pointer to dto.GeneratedSecretDTO with the password and its entropy estimate.
+//   - An error wrapping apperrors.ErrInvalidPasswordPolicy if the policy is invalid,
+//     or an error if saving the entry fails.
+func (g *GeneratorService) GeneratePassword(ctx context.Context, body dto.GeneratePasswordDTO) (*dto.GeneratedSecretDTO, error) {
+	secret, entropy, err := g.generator.Password(passgen.Policy{
+		Length:           body.Length,
+		Classes:          body.Classes,
+		ExcludeAmbiguous: body.ExcludeAmbiguous,
+		Exclude:          body.Exclude,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidPasswordPolicy, err)
+	}
+
+	return g.result(ctx, secret, entropy, body.SaveAs, body.Key)
+}
+
+// GeneratePassphrase generates a diceware-style passphrase according to the requested policy.
+// If body.SaveAs is set, the passphrase is also stored as a new login-password entry.
+//
+// Parameters:
+//   - body: A dto.GeneratePassphraseDTO containing the policy, an optional save target and an encryption key.
+//
+// Returns:
+//   - A pointer to dto.GeneratedSecretDTO with the passphrase and its entropy estimate.
+//   - An error wrapping apperrors.ErrInvalidPasswordPolicy if the policy is invalid,
+//     or an error if saving the entry fails.
+func (g *GeneratorService) GeneratePassphrase(ctx context.Context, body dto.GeneratePassphraseDTO) (*dto.GeneratedSecretDTO, error) {
+	secret, entropy, err := g.generator.Passphrase(passgen.PassphrasePolicy{
+		Words:      body.Words,
+		Separator:  body.Separator,
+		Capitalize: body.Capitalize,
+		WithNumber: body.WithNumber,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidPasswordPolicy, err)
+	}
+
+	return g.result(ctx, secret, entropy, body.SaveAs, body.Key)
+}
+
+// result builds the generation response and, when saveAs is set, stores the secret
+// as a new login-password entry encrypted with key.
+func (g *GeneratorService) result(
+	ctx context.Context,
+	secret string,
+	entropy float64,
+	saveAs *dto.SaveGeneratedDTO,
+	key string,
+) (*dto.GeneratedSecretDTO, error) {
+	generated := &dto.GeneratedSecretDTO{
+		Secret:      secret,
+		EntropyBits: entropy,
+	}
+
+	if saveAs == nil {
+		return generated, nil
+	}
+
+	_, err := g.logoPass.Create(ctx, dto.CreateLogoPassDTO{
+		UserId:   saveAs.UserId,
+		AppName:  saveAs.AppName,
+		Username: saveAs.Username,
+		Password: secret,
+		Key:      key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	generated.Saved = true
+
+	return generated, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/pkg/passgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockLogoPassCreator struct {
+	mock.Mock
+}
+
+func (m *MockLogoPassCreator) Create(ctx context.Context, body dto.CreateLogoPassDTO) (*dto.BreachCheckDTO, error) {
+	args := m.Called(body)
+	return &dto.BreachCheckDTO{}, args.Error(0)
+}
+
+func TestGeneratePassword(t *testing.T) {
+	creator := new(MockLogoPassCreator)
+	service := NewGeneratorService(passgen.New(), creator, zap.NewNop())
+
+	generated, err := service.GeneratePassword(context.Background(), dto.GeneratePasswordDTO{
+		Length:  16,
+		Classes: []string{passgen.ClassLower, passgen.ClassDigits},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, generated.Secret, 16)
+	assert.Greater(t, generated.EntropyBits, 80.0)
+	assert.False(t, generated.Saved)
+	creator.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestGeneratePassphrase_SaveAs(t *testing.T) {
+	creator := new(MockLogoPassCreator)
+	service := NewGeneratorService(passgen.New(), creator, zap.NewNop())
+
+	creator.On("Create", mock.MatchedBy(func(body dto.CreateLogoPassDTO) bool {
+		return body.UserId == 1 && body.AppName == "github" && body.Username == "octocat" &&
+			body.Password != "" && body.Key == "key"
+	})).Return(nil)
+
+	generated, err := service.GeneratePassphrase(context.Background(), dto.GeneratePassphraseDTO{
+		Words:  4,
+		SaveAs: &dto.SaveGeneratedDTO{UserId: 1, AppName: "github", Username: "octocat"},
+		Key:    "key",
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, generated.Saved)
+	creator.AssertExpectations(t)
+}
+
+func TestGeneratePassword_SaveError(t *testing.T) {
+	creator := new(MockLogoPassCreator)
+	service := NewGeneratorService(passgen.New(), creator, zap.NewNop())
+
+	creator.On("Create", mock.Anything).Return(errors.New("db error"))
+
+	generated, err := service.GeneratePassword(context.Background(), dto.GeneratePasswordDTO{
+		SaveAs: &dto.SaveGeneratedDTO{UserId: 1, AppName: "github"},
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, generated)
+}
+
+func TestGeneratePassword_InvalidPolicy(t *testing.T) {
+	service := NewGeneratorService(passgen.New(), new(MockLogoPassCreator), zap.NewNop())
+
+	_, err := service.GeneratePassword(context.Background(), dto.GeneratePasswordDTO{Length: 1000})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPasswordPolicy)
+
+	_, err = service.GeneratePassphrase(context.Background(), dto.GeneratePassphraseDTO{Words: 1})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPasswordPolicy)
+}
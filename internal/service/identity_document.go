@@ -0,0 +1,262 @@
+// Package service provides business logic for managing encrypted identity document storage.
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/validation"
+	"go.uber.org/zap"
+)
+
+// IdentityDocumentService manages operations related to encrypted identity documents
+// (passports, driver licences, ID cards), including validation, encryption and decryption.
+type IdentityDocumentService struct {
+	documentStorage IdentityDocumentStorage
+	cryptoModule    CryptoModule
+	itemKeys        *ItemKeys
+	log             *zap.Logger
+}
+
+// IdentityDocumentStorage defines an interface for storing, retrieving, and updating encrypted identity documents.
+type IdentityDocumentStorage interface {
+	// NextID reserves the ID of the next identity document.
+	NextID(ctx context.Context) (int64, error)
+	// CreateIdentityDocument stores an encrypted identity document in the database.
+	CreateIdentityDocument(ctx context.Context, body dto.CreateIdentityDocumentDTO) error
+	// GetAllIdentityDocumentsByUserId retrieves all encrypted identity documents associated with a given user ID.
+	GetAllIdentityDocumentsByUserId(ctx context.Context, userID int64) ([]entities.IdentityDocument, error)
+	// UpdateIdentityDocument updates the encrypted identity document details for a specific document ID.
+	UpdateIdentityDocument(ctx context.Context, documentID int64, body dto.UpdateIdentityDocumentDTO) error
+}
+
+// NewIdentityDocumentService creates a new instance of IdentityDocumentService with the provided dependencies.
+//
+// Parameters:
+//   - documentStorage: An implementation of the IdentityDocumentStorage interface for data persistence.
+//   - cryptoModule: An implementation of CryptoModule for encryption and decryption.
+//   - itemKeys: Resolves the item keys of shared identity documents; nil if sharing is not used.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to an IdentityDocumentService instance.
+func NewIdentityDocumentService(
+	documentStorage IdentityDocumentStorage,
+	cryptoModule CryptoModule,
+	itemKeys *ItemKeys,
+	log *zap.Logger,
+) *IdentityDocumentService {
+	return &IdentityDocumentService{
+		documentStorage: documentStorage,
+		cryptoModule:    cryptoModule,
+		itemKeys:        itemKeys,
+		log:             log,
+	}
+}
+
+// Create validates an identity document, encrypts it field by field and stores it securely.
+//
+// Parameters:
+//   - body: A dto.CreateIdentityDocumentDTO containing document details and an encryption key.
+//
+// Returns:
+//   - A validation error (apperrors.ErrInvalidDocumentType, apperrors.ErrInvalidCountryCode,
+//     apperrors.ErrInvalidDate) or an error if encryption or storage fails.
+func (i *IdentityDocumentService) Create(ctx context.Context, body dto.CreateIdentityDocumentDTO) error {
+	if !isDocumentType(body.DocType) {
+		return fmt.Errorf("%w: %q", apperrors.ErrInvalidDocumentType, body.DocType)
+	}
+
+	country, err := validateIdentityDocument(body.Number, body.IssuingCountry, body.IssueDate, body.ExpiryDate)
+	if err != nil {
+		return err
+	}
+	body.IssuingCountry = country
+
+	body.ID, err = i.documentStorage.NextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := i.encryptFields(int64(body.UserID), body.ID, body.Key, body.Number, body.HolderName, body.IssuingCountry, body.IssueDate, body.ExpiryDate)
+	if err != nil {
+		return err
+	}
+
+	body.Number = encrypted[0]
+	body.HolderName = encrypted[1]
+	body.IssuingCountry = encrypted[2]
+	body.IssueDate = encrypted[3]
+	body.ExpiryDate = encrypted[4]
+
+	return i.documentStorage.CreateIdentityDocument(ctx, body)
+}
+
+// Update validates updated identity document data, encrypts it and stores it securely.
+//
+// Parameters:
+//   - documentID: The ID of the document to be updated.
+//   - body: A dto.UpdateIdentityDocumentDTO containing updated document details and an encryption key.
+//
+// Returns:
+//   - A validation error or an error if encryption or storage fails.
+func (i *IdentityDocumentService) Update(ctx context.Context, documentID int64, body dto.UpdateIdentityDocumentDTO) error {
+	country, err := validateIdentityDocument(body.Number, body.IssuingCountry, body.IssueDate, body.ExpiryDate)
+	if err != nil {
+		return err
+	}
+	body.IssuingCountry = country
+
+	body.Key, err = i.itemKeys.key(ctx, body.UserID, itemTypeIdentityDocument, documentID, body.Key)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := i.encryptFields(body.UserID, documentID, body.Key, body.Number, body.HolderName, body.IssuingCountry, body.IssueDate, body.ExpiryDate)
+	if err != nil {
+		return err
+	}
+
+	body.Number = encrypted[0]
+	body.HolderName = encrypted[1]
+	body.IssuingCountry = encrypted[2]
+	body.IssueDate = encrypted[3]
+	body.ExpiryDate = encrypted[4]
+
+	return i.documentStorage.UpdateIdentityDocument(ctx, documentID, body)
+}
+
+// GetAll retrieves and decrypts all identity documents for a given user.
+//
+// Parameters:
+//   - userID: The ID of the user whose documents are being retrieved.
+//   - key: The encryption key required for decryption.
+//
+// Returns:
+//   - A slice of decrypted entities.IdentityDocument or an error if retrieval fails.
+func (i *IdentityDocumentService) GetAll(ctx context.Context, userID int64, key string) ([]entities.IdentityDocument, error) {
+	encryptedData, err := i.documentStorage.GetAllIdentityDocumentsByUserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFor, err := i.itemKeys.resolve(ctx, userID, itemTypeIdentityDocument, key)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedData := make([]entities.IdentityDocument, 0, len(encryptedData))
+	for _, document := range encryptedData {
+		decryptedDocument, err := i.decryptIdentityDocument(document, keyFor(int64(document.ID)))
+		if err != nil {
+			continue
+		}
+		decryptedData = append(decryptedData, *decryptedDocument)
+	}
+
+	return decryptedData, nil
+}
+
+// identityDocumentFields lists the encrypted columns of an identity document in the order
+// encryptFields and decryptIdentityDocument handle them.
+var identityDocumentFields = []string{"number", "holder_name", "issuing_country", "issue_date", "expiry_date"}
+
+// encryptFields encrypts the values of identityDocumentFields of a document with the provided key, preserving order.
+func (i *IdentityDocumentService) encryptFields(userID, documentID int64, key string, values ...string) ([]string, error) {
+	encrypted := make([]string, 0, len(values))
+	for n, value := range values {
+		binding := bind(userID, itemTypeIdentityDocument, documentID, identityDocumentFields[n])
+		encryptedValue, err := i.cryptoModule.EncryptBound(value, key, binding)
+		if err != nil {
+			return nil, err
+		}
+		encrypted = append(encrypted, encryptedValue)
+	}
+
+	return encrypted, nil
+}
+
+// decryptIdentityDocument decrypts a single encrypted identity document entry.
+//
+// Parameters:
+//   - document: An encrypted entities.IdentityDocument instance.
+//   - key: The encryption key used for decryption.
+//
+// Returns:
+//   - A pointer to a decrypted entities.IdentityDocument or an error if decryption fails.
+func (i *IdentityDocumentService) decryptIdentityDocument(
+	document entities.IdentityDocument,
+	key string,
+) (*entities.IdentityDocument, error) {
+	fields := []*string{
+		&document.Number,
+		&document.HolderName,
+		&document.IssuingCountry,
+		&document.IssueDate,
+		&document.ExpiryDate,
+	}
+
+	for n, field := range fields {
+		binding := bind(int64(document.UserID), itemTypeIdentityDocument, int64(document.ID), identityDocumentFields[n])
+		decrypted, err := i.cryptoModule.DecryptBound(*field, key, binding)
+		if err != nil {
+			return nil, err
+		}
+		*field = decrypted
+	}
+
+	return &document, nil
+}
+
+// validateIdentityDocument checks the document number, issuing country and dates.
+// The issue date is required and must not be in the future; the expiry date is optional
+// but, when present, must be after the issue date.
+//
+// Returns:
+//   - The normalized issuing country code, or a validation error.
+func validateIdentityDocument(number, issuingCountry, issueDate, expiryDate string) (string, error) {
+	if strings.TrimSpace(number) == "" {
+		return "", fmt.Errorf("%w: can not be empty", apperrors.ErrInvalidDocumentNumber)
+	}
+
+	country, err := validation.ValidateCountryCode(issuingCountry)
+	if err != nil {
+		return "", err
+	}
+
+	issued, err := validation.ParseDate(issueDate)
+	if err != nil {
+		return "", err
+	}
+
+	if issued.After(timeNow()) {
+		return "", fmt.Errorf("%w: issue date is in the future", apperrors.ErrInvalidDate)
+	}
+
+	if expiryDate != "" {
+		expires, err := validation.ParseDate(expiryDate)
+		if err != nil {
+			return "", err
+		}
+
+		if !expires.After(issued) {
+			return "", fmt.Errorf("%w: expiry date must be after issue date", apperrors.ErrInvalidDate)
+		}
+	}
+
+	return country, nil
+}
+
+// isDocumentType reports whether docType is one of the supported identity document types.
+func isDocumentType(docType string) bool {
+	switch docType {
+	case entities.DocumentTypePassport, entities.DocumentTypeDriverLicence, entities.DocumentTypeIDCard:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockIdentityDocumentStorage struct {
+	mock.Mock
+}
+
+func (m *MockIdentityDocumentStorage) NextID(ctx context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockIdentityDocumentStorage) CreateIdentityDocument(ctx context.Context, body dto.CreateIdentityDocumentDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockIdentityDocumentStorage) GetAllIdentityDocumentsByUserId(ctx context.Context, userID int64) ([]entities.IdentityDocument, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entities.IdentityDocument), args.Error(1)
+}
+
+func (m *MockIdentityDocumentStorage) UpdateIdentityDocument(ctx context.Context, documentID int64, body dto.UpdateIdentityDocumentDTO) error {
+	args := m.Called(documentID, body)
+	return args.Error(0)
+}
+
+func TestCreateIdentityDocument(t *testing.T) {
+	mockStorage := new(MockIdentityDocumentStorage)
+	mockCrypto := new(MockCryptoModule)
+
+	service := NewIdentityDocumentService(mockStorage, mockCrypto, nil, zap.NewNop())
+
+	body := dto.CreateIdentityDocumentDTO{
+		UserID:         1,
+		DocType:        entities.DocumentTypePassport,
+		Number:         "C01X00T47",
+		HolderName:     "John Doe",
+		IssuingCountry: "de",
+		IssueDate:      "2020-01-15",
+		ExpiryDate:     "2030-01-14",
+		Key:            "secret",
+	}
+
+	field := func(name string) cryptox.Binding {
+		return cryptox.Binding{UserID: 1, ItemType: "identity_documents", ItemID: 3, Field: name}
+	}
+
+	mockStorage.On("NextID").Return(int64(3), nil)
+	mockCrypto.On("EncryptBound", "C01X00T47", "secret", field("number")).Return("encrypted_number", nil)
+	mockCrypto.On("EncryptBound", "John Doe", "secret", field("holder_name")).Return("encrypted_holder", nil)
+	mockCrypto.On("EncryptBound", "DE", "secret", field("issuing_country")).Return("encrypted_country", nil)
+	mockCrypto.On("EncryptBound", "2020-01-15", "secret", field("issue_date")).Return("encrypted_issue", nil)
+	mockCrypto.On("EncryptBound", "2030-01-14", "secret", field("expiry_date")).Return("encrypted_expiry", nil)
+
+	mockStorage.On("CreateIdentityDocument", mock.MatchedBy(func(body dto.CreateIdentityDocumentDTO) bool {
+		return body.DocType == entities.DocumentTypePassport &&
+			body.Number == "encrypted_number" &&
+			body.IssuingCountry == "encrypted_country"
+	})).Return(nil)
+
+	err := service.Create(context.Background(), body)
+
+	assert.NoError(t, err)
+	mockCrypto.AssertExpectations(t)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestCreateIdentityDocument_ValidationErrors(t *testing.T) {
+	valid := dto.CreateIdentityDocumentDTO{
+		DocType:        entities.DocumentTypeDriverLicence,
+		Number:         "B072RRE2I55",
+		IssuingCountry: "DE",
+		IssueDate:      "2019-05-01",
+		ExpiryDate:     "2034-04-30",
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(body *dto.CreateIdentityDocumentDTO)
+		wantErr error
+	}{
+		{
+			name:    "unknown document type",
+			mutate:  func(body *dto.CreateIdentityDocumentDTO) { body.DocType = "library_card" },
+			wantErr: apperrors.ErrInvalidDocumentType,
+		},
+		{
+			name:    "empty number",
+			mutate:  func(body *dto.CreateIdentityDocumentDTO) { body.Number = " " },
+			wantErr: apperrors.ErrInvalidDocumentNumber,
+		},
+		{
+			name:    "unknown country",
+			mutate:  func(body *dto.CreateIdentityDocumentDTO) { body.IssuingCountry = "XX" },
+			wantErr: apperrors.ErrInvalidCountryCode,
+		},
+		{
+			name:    "malformed issue date",
+			mutate:  func(body *dto.CreateIdentityDocumentDTO) { body.IssueDate = "01/05/2019" },
+			wantErr: apperrors.ErrInvalidDate,
+		},
+		{
+			name: "issue date in the future",
+			mutate: func(body *dto.CreateIdentityDocumentDTO) {
+				body.IssueDate = time.Now().AddDate(1, 0, 0).Format("2006-01-02")
+			},
+			wantErr: apperrors.ErrInvalidDate,
+		},
+		{
+			name:    "expiry before issue",
+			mutate:  func(body *dto.CreateIdentityDocumentDTO) { body.ExpiryDate = "2018-05-01" },
+			wantErr: apperrors.ErrInvalidDate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockIdentityDocumentStorage)
+			mockCrypto := new(MockCryptoModule)
+			service := NewIdentityDocumentService(mockStorage, mockCrypto, nil, zap.NewNop())
+
+			body := valid
+			tt.mutate(&body)
+
+			err := service.Create(context.Background(), body)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockStorage.AssertNotCalled(t, "CreateIdentityDocument", mock.Anything)
+		})
+	}
+}
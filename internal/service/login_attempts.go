@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// LoginAttemptStorage counts the failed logins of usernames. ratelimit.MemoryStore implements it
+// for a single server; servers behind a load balancer need a store they share.
+type LoginAttemptStorage interface {
+	// AddFailure counts a failed login; failures are forgotten ttl after the last one.
+	AddFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (ratelimit.Failures, error)
+	// GetFailures returns the failed logins that are not forgotten yet.
+	GetFailures(ctx context.Context, key string, now time.Time) (ratelimit.Failures, error)
+	// ResetFailures forgets the failed logins, after a successful one.
+	ResetFailures(ctx context.Context, key string) error
+}
+
+// loginAttemptKey returns the key the failed logins of a username are counted by.
+func loginAttemptKey(username string) string {
+	return "login:" + username
+}
+
+// loginLockout returns the configured login delays and lockout.
+func (u *UserService) loginLockout() ratelimit.Lockout {
+	return ratelimit.Lockout{
+		Threshold: u.cfg.LoginLockoutAttempts,
+		Duration:  u.cfg.LoginLockoutDuration,
+		BaseDelay: u.cfg.LoginDelayBase,
+	}
+}
+
+// checkLoginAllowed checks that a username may attempt a login now. Every wrong password doubles
+// the time the username has to wait before its next login, and after LoginLockoutAttempts wrong
+// passwords it is locked for LoginLockoutDuration. The wait is enforced by rejecting early logins
+// instead of delaying responses, so that an attacker cannot tie up server connections.
+//
+// Returns:
+//   - *apperrors.LoginLockedError with the remaining wait, or apperrors.ErrDBQuery if the failed
+//     logins cannot be read.
+func (u *UserService) checkLoginAllowed(ctx context.Context, username string) error {
+	if u.loginAttempts == nil {
+		return nil
+	}
+
+	now := timeNow()
+	failures, err := u.loginAttempts.GetFailures(ctx, loginAttemptKey(username), now)
+	if err != nil {
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	if wait := u.loginLockout().RetryAfter(failures, now); wait > 0 {
+		u.log.Warn("Login throttled", zap.String("username", username), zap.Int("failures", failures.Count))
+		return &apperrors.LoginLockedError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// loginFailed counts a wrong password of a username. The failures are kept for the lockout
+// duration, so counting starts over once a lockout has ended.
+func (u *UserService) loginFailed(ctx context.Context, username string) {
+	if u.loginAttempts == nil {
+		return
+	}
+
+	failures, err := u.loginAttempts.AddFailure(ctx, loginAttemptKey(username), timeNow(), u.cfg.LoginLockoutDuration)
+	if err != nil {
+		u.log.Error(err.Error())
+		return
+	}
+
+	if threshold := u.cfg.LoginLockoutAttempts; threshold > 0 && failures.Count == threshold {
+		u.log.Warn("Account locked after failed logins", zap.String("username", username), zap.Int("failures", failures.Count))
+	}
+}
+
+// loginSucceeded forgets the failed logins of a username after a successful login: a correct
+// password, or for users with two-factor authentication, an accepted second factor.
+func (u *UserService) loginSucceeded(ctx context.Context, username string) {
+	if u.loginAttempts == nil {
+		return
+	}
+
+	if err := u.loginAttempts.ResetFailures(ctx, loginAttemptKey(username)); err != nil {
+		u.log.Error(err.Error())
+	}
+}
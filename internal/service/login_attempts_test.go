@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// pinClock pins timeNow to a clock the test can advance.
+func pinClock(t *testing.T) *time.Time {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+	return &now
+}
+
+func TestUserService_Login_ProgressiveDelay(t *testing.T) {
+	now := pinClock(t)
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	attempts := ratelimit.NewMemoryStore()
+	cfg := testUserConfig
+	cfg.LoginLockoutAttempts = 10
+	cfg.LoginLockoutDuration = 15 * time.Minute
+	cfg.LoginDelayBase = time.Second
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, attempts, cfg, zap.NewNop())
+
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "password123")}, nil)
+
+	_, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+
+	_, err = service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+	var locked *apperrors.LoginLockedError
+	require.True(t, errors.As(err, &locked), "A login right after a wrong password should be throttled")
+	assert.Equal(t, time.Second, locked.RetryAfter)
+	assert.ErrorIs(t, err, apperrors.ErrLoginLocked)
+	mockStorage.AssertNumberOfCalls(t, "GetUserByUsername", 1)
+
+	*now = now.Add(time.Second)
+	_, err = service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+
+	*now = now.Add(time.Second)
+	_, err = service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+	require.True(t, errors.As(err, &locked), "The delay should double with every wrong password")
+	assert.Equal(t, time.Second, locked.RetryAfter)
+
+	*now = now.Add(time.Second)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "w1.stored").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$stored").Return(false)
+
+	_, err = service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+
+	failures, err := attempts.GetFailures(context.Background(), loginAttemptKey("alice"), *now)
+	require.NoError(t, err)
+	assert.Zero(t, failures.Count, "A successful login should reset the failed logins")
+}
+
+func TestUserService_Login_Lockout(t *testing.T) {
+	now := pinClock(t)
+	mockStorage := new(MockUserStorage)
+	cfg := testUserConfig
+	cfg.LoginLockoutAttempts = 3
+	cfg.LoginLockoutDuration = 15 * time.Minute
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), ratelimit.NewMemoryStore(), cfg, zap.NewNop())
+
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "password123")}, nil)
+	mockStorage.On("GetUserByUsername", "bob").Return(&entities.User{ID: 2, Username: "bob", Password: bcryptHash(t, "password123")}, nil)
+
+	for range 3 {
+		_, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "wrong"})
+		assert.ErrorIs(t, err, apperrors.ErrInvalidPassword, "Without a base delay wrong passwords should not be throttled before the lockout")
+	}
+
+	*now = now.Add(14 * time.Minute)
+	_, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+	var locked *apperrors.LoginLockedError
+	require.True(t, errors.As(err, &locked), "The account should be locked after the threshold")
+	assert.Equal(t, time.Minute, locked.RetryAfter)
+
+	_, err = service.Login(context.Background(), dto.UserDTO{Username: "bob", Password: "wrong"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword, "Other accounts should not be locked")
+
+	*now = now.Add(time.Minute)
+	_, err = service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword, "The lockout should end after its duration")
+
+	_, err = service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword, "Counting should start over after a lockout")
+}
+
+func TestUserService_LoginTwoFactor_KeepsFailuresUntilSecondFactor(t *testing.T) {
+	step, code := pinTOTPTime(t)
+	service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+	attempts := ratelimit.NewMemoryStore()
+	service.loginAttempts = attempts
+	service.cfg.LoginLockoutAttempts = 10
+	service.cfg.LoginLockoutDuration = 15 * time.Minute
+	ctx := context.Background()
+
+	_, err := attempts.AddFailure(ctx, loginAttemptKey("alice"), timeNow(), 15*time.Minute)
+	require.NoError(t, err)
+
+	challenge := &entities.LoginChallenge{ID: "chal", UserID: 1, WrappedKey: "w1.challenge", ExpiresAt: timeNow().Add(time.Minute)}
+	mockCrypto.On("GenerateDataKey").Return("k1.challenge", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.challenge").Return("w1.challenge", nil)
+	mockStorage.On("CreateLoginChallenge", mock.Anything).Return(nil)
+	mockStorage.On("GetLoginChallenge", mock.Anything).Return(challenge, nil)
+	mockCrypto.On("UnwrapKey", "w1.challenge", "k1.challenge").Return("k1.dek", nil)
+	mockStorage.On("AddLoginChallengeAttempt", "chal").Return(1, nil)
+	mockCrypto.On("HashRecoveryCode", "000000").Return("wrong")
+	mockStorage.On("UseRecoveryCode", int64(1), "wrong").Return(false, nil)
+	mockStorage.On("UseTOTPStep", int64(1), step).Return(true, nil)
+	mockStorage.On("DeleteLoginChallenge", "chal").Return(true, nil)
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+
+	tokens, err := service.Login(ctx, dto.UserDTO{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+	failures, err := attempts.GetFailures(ctx, loginAttemptKey("alice"), timeNow())
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures.Count, "A correct password should not reset the failed logins before the second factor")
+
+	_, err = service.LoginTwoFactor(ctx, dto.TwoFactorLoginDTO{ChallengeToken: tokens.Challenge.Token, Code: "000000"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidTwoFactorCode)
+	failures, err = attempts.GetFailures(ctx, loginAttemptKey("alice"), timeNow())
+	require.NoError(t, err)
+	assert.Equal(t, 2, failures.Count, "A wrong code should count as a failed login")
+
+	_, err = service.LoginTwoFactor(ctx, dto.TwoFactorLoginDTO{ChallengeToken: tokens.Challenge.Token, Code: code})
+	require.NoError(t, err)
+	failures, err = attempts.GetFailures(ctx, loginAttemptKey("alice"), timeNow())
+	require.NoError(t, err)
+	assert.Zero(t, failures.Count, "An accepted second factor should reset the failed logins")
+}
@@ -3,22 +3,32 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/pkg/urimatch"
 	"go.uber.org/zap"
 )
 
 // LogoPassService handles operations related to encrypted username-password storage.
 type LogoPassService struct {
-	logoPassDB   LogoPassStorage
-	cryptoModule CryptoModule
-	log          *zap.Logger
+	logoPassDB    LogoPassStorage
+	cryptoModule  CryptoModule
+	breachChecker BreachChecker
+	itemKeys      *ItemKeys
+	log           *zap.Logger
 }
 
 // LogoPassStorage defines an interface for storing, retrieving, and updating encrypted username-password data.
 type LogoPassStorage interface {
+	// NextID reserves the ID of the next username-password entry.
+	NextID(ctx context.Context) (int64, error)
 	// CreateLogoPass stores an encrypted username-password entry.
 	CreateLogoPass(ctx context.Context, body dto.CreateLogoPassDTO) error
 	// GetAllByUser retrieves all encrypted username-password entries for a given user ID.
@@ -27,11 +37,19 @@ type LogoPassStorage interface {
 	UpdateLogoPass(ctx context.Context, userID int64, body dto.UpdateLogoPassDTO) error
 }
 
+// BreachChecker defines an interface for looking up passwords in a local breached-password corpus.
+type BreachChecker interface {
+	// Lookup returns how many times the password occurs in the corpus, or 0 if it does not.
+	Lookup(password string) (uint32, error)
+}
+
 // NewLogoPassService creates a new instance of LogoPassService with the provided dependencies.
 //
 // Parameters:
 //   - logoPassDB: An implementation of the LogoPassStorage interface for data persistence.
 //   - cryptoModule: An implementation of CryptoModule for encryption and decryption.
+//   - breachChecker: An optional BreachChecker; pass nil to disable breached-password checks.
+//   - itemKeys: Resolves the item keys of shared entries; nil if sharing is not used.
 //   - log: A structured logger (zap.Logger) for logging events.
 //
 // Returns:
@@ -39,31 +57,56 @@ type LogoPassStorage interface {
 func NewLogoPassService(
 	logoPassDB LogoPassStorage,
 	cryptoModule CryptoModule,
+	breachChecker BreachChecker,
+	itemKeys *ItemKeys,
 	log *zap.Logger,
 ) *LogoPassService {
 	return &LogoPassService{
-		logoPassDB:   logoPassDB,
-		cryptoModule: cryptoModule,
-		log:          log,
+		logoPassDB:    logoPassDB,
+		cryptoModule:  cryptoModule,
+		breachChecker: breachChecker,
+		itemKeys:      itemKeys,
+		log:           log,
 	}
 }
 
-// Create encrypts and stores a username-password entry securely.
+// Create validates the website URIs, encrypts and stores a username-password entry securely.
+// The password is checked against the breached-password corpus before it is encrypted;
+// a breached password is still stored, the caller is only warned.
 //
 // Parameters:
 //   - body: A dto.CreateLogoPassDTO containing username, password, and an encryption key.
 //
 // Returns:
-//   - An error if encryption or storage fails.
-func (l *LogoPassService) Create(ctx context.Context, body dto.CreateLogoPassDTO) error {
-	encryptedUsername, err := l.cryptoModule.Encrypt(body.Username, body.Key)
+//   - A pointer to dto.BreachCheckDTO describing whether the password is known to be breached.
+//   - An error wrapping apperrors.ErrInvalidURI for an invalid URI, or an error if encryption or storage fails.
+func (l *LogoPassService) Create(ctx context.Context, body dto.CreateLogoPassDTO) (*dto.BreachCheckDTO, error) {
+	uris, err := encodeURIs(body.URIs)
+	if err != nil {
+		return nil, err
+	}
+
+	body.ID, err = l.logoPassDB.NextID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID := int64(body.UserId)
+
+	body.EncryptedURIs, err = l.encryptURIs(uris, body.Key, bind(userID, itemTypeLogoPass, body.ID, "uris"))
+	if err != nil {
+		return nil, err
+	}
+
+	breach := l.checkBreach(body.Password)
+
+	encryptedUsername, err := l.cryptoModule.EncryptBound(body.Username, body.Key, bind(userID, itemTypeLogoPass, body.ID, "username"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	encryptedPassword, err := l.cryptoModule.Encrypt(body.Password, body.Key)
+	encryptedPassword, err := l.cryptoModule.EncryptBound(body.Password, body.Key, bind(userID, itemTypeLogoPass, body.ID, "password"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	body.Username = encryptedUsername
@@ -71,40 +114,84 @@ func (l *LogoPassService) Create(ctx context.Context, body dto.CreateLogoPassDTO
 
 	err = l.logoPassDB.CreateLogoPass(ctx, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return breach, nil
 }
 
-// Update encrypts and updates an existing username-password entry.
+// Update encrypts and updates an existing username-password entry. A shared entry is encrypted with its item key.
 //
 // Parameters:
-//   - userID: The ID of the user whose data is being updated.
+//   - id: The ID of the entry being updated.
 //   - body: A dto.UpdateLogoPassDTO containing updated username, password, and an encryption key.
 //
 // Returns:
-//   - An error if encryption or update fails.
-func (l *LogoPassService) Update(ctx context.Context, userID int64, body dto.UpdateLogoPassDTO) error {
-	encryptedUsername, err := l.cryptoModule.Encrypt(body.Username, body.Key)
+//   - A pointer to dto.BreachCheckDTO describing whether the new password is known to be breached.
+//   - An error wrapping apperrors.ErrInvalidURI for an invalid URI, or an error if encryption or update fails.
+func (l *LogoPassService) Update(ctx context.Context, id int64, body dto.UpdateLogoPassDTO) (*dto.BreachCheckDTO, error) {
+	uris, err := encodeURIs(body.URIs)
+	if err != nil {
+		return nil, err
+	}
+
+	body.Key, err = l.itemKeys.key(ctx, body.UserID, itemTypeLogoPass, id, body.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	body.EncryptedURIs, err = l.encryptURIs(uris, body.Key, bind(body.UserID, itemTypeLogoPass, id, "uris"))
+	if err != nil {
+		return nil, err
+	}
+
+	breach := l.checkBreach(body.Password)
+
+	encryptedUsername, err := l.cryptoModule.EncryptBound(body.Username, body.Key, bind(body.UserID, itemTypeLogoPass, id, "username"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	encryptedPassword, err := l.cryptoModule.Encrypt(body.Password, body.Key)
+	encryptedPassword, err := l.cryptoModule.EncryptBound(body.Password, body.Key, bind(body.UserID, itemTypeLogoPass, id, "password"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	body.Username = encryptedUsername
 	body.Password = encryptedPassword
 
-	err = l.logoPassDB.UpdateLogoPass(ctx, userID, body)
+	err = l.logoPassDB.UpdateLogoPass(ctx, id, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return breach, nil
+}
+
+// CheckBreach looks up a plaintext password in the local breached-password corpus.
+// Nothing leaves the server: the lookup is performed against an on-disk index.
+//
+// Parameters:
+//   - password: The plaintext password to check.
+//
+// Returns:
+//   - A pointer to dto.BreachCheckDTO with the number of occurrences in the corpus.
+//   - apperrors.ErrBreachCheckUnavailable if no corpus is configured, or an error if the lookup fails.
+func (l *LogoPassService) CheckBreach(password string) (*dto.BreachCheckDTO, error) {
+	if l.breachChecker == nil {
+		return nil, apperrors.ErrBreachCheckUnavailable
+	}
+
+	occurrences, err := l.breachChecker.Lookup(password)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &dto.BreachCheckDTO{
+		Checked:     true,
+		Breached:    occurrences > 0,
+		Occurrences: occurrences,
+	}, nil
 }
 
 // GetAll retrieves and decrypts all username-password entries for a given user.
@@ -116,44 +203,79 @@ func (l *LogoPassService) Update(ctx context.Context, userID int64, body dto.Upd
 // Returns:
 //   - A slice of decrypted entities.LogoPassword or an error if retrieval or decryption fails.
 func (l *LogoPassService) GetAll(ctx context.Context, userID int64, key string) ([]entities.LogoPassword, error) {
-	items, err := l.logoPassDB.GetAllByUser(ctx, userID)
+	decryptedData, failed, err := l.GetAllWithFailures(ctx, userID, key)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(items) == 0 {
-		return nil, fmt.Errorf("records not found")
+	if len(decryptedData) == 0 && len(failed) == 0 {
+		return nil, apperrors.ErrRecordsNotFound
 	}
 
-	decryptedData := l.decryptLogoPassArray(items, key)
-
 	return decryptedData, nil
 }
 
+// GetAllWithFailures retrieves and decrypts all username-password entries for a given user,
+// also returning the entries that could not be decrypted instead of silently dropping them.
+//
+// Parameters:
+//   - userID: The ID of the user whose data is being retrieved.
+//   - key: The encryption key required for decryption.
+//
+// Returns:
+//   - A slice of decrypted entities.LogoPassword, flagged if their password is known to be breached.
+//   - A slice of still-encrypted entries that failed to decrypt.
+//   - An error if retrieval fails.
+func (l *LogoPassService) GetAllWithFailures(
+	ctx context.Context,
+	userID int64,
+	key string,
+) ([]entities.LogoPassword, []entities.LogoPassword, error) {
+	items, err := l.logoPassDB.GetAllByUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyFor, err := l.itemKeys.resolve(ctx, userID, itemTypeLogoPass, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decryptedData, failed := l.decryptLogoPassArray(items, keyFor)
+	for i := range decryptedData {
+		decryptedData[i].Breached = l.checkBreach(decryptedData[i].Password).Breached
+	}
+
+	return decryptedData, failed, nil
+}
+
 // decryptLogoPassArray decrypts an array of encrypted username-password entries.
 //
 // Parameters:
 //   - encryptedData: A slice of encrypted entities.LogoPassword.
-//   - key: The encryption key used for decryption.
+//   - keyFor: Returns the encryption key of an entry by its ID.
 //
 // Returns:
 //   - A slice of decrypted entities.LogoPassword.
+//   - A slice of the entries that failed to decrypt.
 func (l *LogoPassService) decryptLogoPassArray(
 	encryptedData []entities.LogoPassword,
-	key string,
-) []entities.LogoPassword {
+	keyFor func(int64) string,
+) ([]entities.LogoPassword, []entities.LogoPassword) {
 	decryptedData := make([]entities.LogoPassword, 0, len(encryptedData))
+	var failed []entities.LogoPassword
 
 	for i := 0; i < len(encryptedData); i++ {
-		decryptedItem, err := l.decryptLogoPass(encryptedData[i], key)
+		decryptedItem, err := l.decryptLogoPass(encryptedData[i], keyFor(int64(encryptedData[i].ID)))
 		if err != nil {
+			failed = append(failed, encryptedData[i])
 			continue
 		}
 
 		decryptedData = append(decryptedData, *decryptedItem)
 	}
 
-	return decryptedData
+	return decryptedData, failed
 }
 
 // decryptLogoPass decrypts a single encrypted username-password entry.
@@ -168,12 +290,14 @@ func (l *LogoPassService) decryptLogoPass(
 	logopass entities.LogoPassword,
 	key string,
 ) (*entities.LogoPassword, error) {
-	decryptedLogin, err := l.cryptoModule.Decrypt(logopass.Username, key)
+	userID, id := int64(logopass.UserID), int64(logopass.ID)
+
+	decryptedLogin, err := l.cryptoModule.DecryptBound(logopass.Username, key, bind(userID, itemTypeLogoPass, id, "username"))
 	if err != nil {
 		return nil, err
 	}
 
-	decryptedPassword, err := l.cryptoModule.Decrypt(logopass.Password, key)
+	decryptedPassword, err := l.cryptoModule.DecryptBound(logopass.Password, key, bind(userID, itemTypeLogoPass, id, "password"))
 	if err != nil {
 		return nil, err
 	}
@@ -181,5 +305,124 @@ func (l *LogoPassService) decryptLogoPass(
 	logopass.Username = decryptedLogin
 	logopass.Password = decryptedPassword
 
+	logopass.URIs = []entities.LogoPassURI{}
+	if logopass.EncryptedURIs != "" {
+		decryptedURIs, err := l.cryptoModule.DecryptBound(logopass.EncryptedURIs, key, bind(userID, itemTypeLogoPass, id, "uris"))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(decryptedURIs), &logopass.URIs); err != nil {
+			return nil, fmt.Errorf("decode uris: %w", err)
+		}
+		logopass.EncryptedURIs = ""
+	}
+
 	return &logopass, nil
 }
+
+// Match finds the user's username-password entries whose website URIs match the given URL.
+// Every entry is scored by its most specific matching URI (exact > host > domain > regex);
+// results are ordered from the most to the least specific match.
+//
+// Parameters:
+//   - userID: The ID of the user whose entries are searched.
+//   - key: The encryption key required for decryption.
+//   - rawURL: The URL of the website the client is on.
+//
+// Returns:
+//   - A slice of dto.LogoPassMatchDTO ranked by specificity (empty if nothing matches).
+//   - An error wrapping apperrors.ErrInvalidURI if rawURL is invalid, or an error if retrieval fails.
+func (l *LogoPassService) Match(ctx context.Context, userID int64, key, rawURL string) ([]dto.LogoPassMatchDTO, error) {
+	target, err := urimatch.Parse(rawURL)
+	if err != nil || target.Hostname() == "" {
+		return nil, fmt.Errorf("%w: %q", apperrors.ErrInvalidURI, rawURL)
+	}
+
+	items, _, err := l.GetAllWithFailures(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]dto.LogoPassMatchDTO, 0)
+	for _, item := range items {
+		best := dto.LogoPassMatchDTO{LogoPassword: item}
+
+		for _, uri := range item.URIs {
+			score, err := urimatch.Match(uri.Match, uri.URI, target)
+			if err != nil {
+				l.log.Warn("skip invalid stored uri", zap.Int("logoPassID", item.ID), zap.Error(err))
+				continue
+			}
+
+			if score > best.Score {
+				best.Score = score
+				best.MatchedURI = uri.URI
+				best.Match = uri.Match
+			}
+		}
+
+		if best.Score > urimatch.ScoreNone {
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches, nil
+}
+
+// encodeURIs validates website URIs, fills in the default match mode and encodes them as a JSON list.
+//
+// Returns:
+//   - The encoded list, or an empty string if there are no URIs.
+//   - An error wrapping apperrors.ErrInvalidURI for an invalid URI.
+func encodeURIs(uris []entities.LogoPassURI) (string, error) {
+	if len(uris) == 0 {
+		return "", nil
+	}
+
+	normalized := make([]entities.LogoPassURI, 0, len(uris))
+	for _, uri := range uris {
+		if uri.Match == "" {
+			uri.Match = urimatch.DefaultMode
+		}
+
+		if err := urimatch.Validate(uri.Match, uri.URI); err != nil {
+			return "", fmt.Errorf("%w: %v", apperrors.ErrInvalidURI, err)
+		}
+		normalized = append(normalized, uri)
+	}
+
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// encryptURIs encrypts a URI list encoded by encodeURIs; an empty list is stored as is.
+func (l *LogoPassService) encryptURIs(uris, key string, binding cryptox.Binding) (string, error) {
+	if uris == "" {
+		return "", nil
+	}
+
+	return l.cryptoModule.EncryptBound(uris, key, binding)
+}
+
+// checkBreach checks a password against the breached-password corpus for write and list operations.
+// A missing corpus or a failed lookup never blocks the operation: the result is reported as unchecked.
+func (l *LogoPassService) checkBreach(password string) *dto.BreachCheckDTO {
+	result, err := l.CheckBreach(password)
+	if err != nil {
+		if !errors.Is(err, apperrors.ErrBreachCheckUnavailable) {
+			l.log.Warn("breached password check failed", zap.Error(err))
+		}
+		return &dto.BreachCheckDTO{}
+	}
+
+	return result
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockLogoPassStorage struct {
+	mock.Mock
+}
+
+func (m *MockLogoPassStorage) NextID(ctx context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLogoPassStorage) CreateLogoPass(ctx context.Context, body dto.CreateLogoPassDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockLogoPassStorage) GetAllByUser(ctx context.Context, userID int64) ([]entities.LogoPassword, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entities.LogoPassword), args.Error(1)
+}
+
+func (m *MockLogoPassStorage) UpdateLogoPass(ctx context.Context, userID int64, body dto.UpdateLogoPassDTO) error {
+	args := m.Called(userID, body)
+	return args.Error(0)
+}
+
+type MockBreachChecker struct {
+	mock.Mock
+}
+
+func (m *MockBreachChecker) Lookup(password string) (uint32, error) {
+	args := m.Called(password)
+	return args.Get(0).(uint32), args.Error(1)
+}
+
+func TestCreateLogoPass_Breached(t *testing.T) {
+	mockStorage := new(MockLogoPassStorage)
+	mockCrypto := new(MockCryptoModule)
+	mockBreach := new(MockBreachChecker)
+	service := NewLogoPassService(mockStorage, mockCrypto, mockBreach, nil, zap.NewNop())
+
+	body := dto.CreateLogoPassDTO{UserId: 1, AppName: "github", Username: "octocat", Password: "password", Key: "key"}
+
+	mockBreach.On("Lookup", "password").Return(uint32(9545824), nil)
+	mockStorage.On("NextID").Return(int64(2), nil)
+	mockCrypto.On("EncryptBound", "octocat", "key", cryptox.Binding{UserID: 1, ItemType: "passwords", ItemID: 2, Field: "username"}).
+		Return("enc_user", nil)
+	mockCrypto.On("EncryptBound", "password", "key", cryptox.Binding{UserID: 1, ItemType: "passwords", ItemID: 2, Field: "password"}).
+		Return("enc_pass", nil)
+	mockStorage.On("CreateLogoPass", mock.MatchedBy(func(b dto.CreateLogoPassDTO) bool {
+		return b.ID == 2 && b.Username == "enc_user" && b.Password == "enc_pass"
+	})).Return(nil)
+
+	breach, err := service.Create(context.Background(), body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &dto.BreachCheckDTO{Checked: true, Breached: true, Occurrences: 9545824}, breach)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestUpdateLogoPass_BreachLookupFails(t *testing.T) {
+	mockStorage := new(MockLogoPassStorage)
+	mockCrypto := new(MockCryptoModule)
+	mockBreach := new(MockBreachChecker)
+	service := NewLogoPassService(mockStorage, mockCrypto, mockBreach, nil, zap.NewNop())
+
+	body := dto.UpdateLogoPassDTO{Username: "octocat", Password: "s3cret", Key: "key"}
+
+	mockBreach.On("Lookup", "s3cret").Return(uint32(0), errors.New("read error"))
+	mockCrypto.On("EncryptBound", mock.Anything, "key", mock.Anything).Return("enc", nil)
+	mockStorage.On("UpdateLogoPass", int64(1), mock.Anything).Return(nil)
+
+	breach, err := service.Update(context.Background(), 1, body)
+
+	assert.NoError(t, err)
+	assert.False(t, breach.Checked)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestCheckBreach_NotConfigured(t *testing.T) {
+	service := NewLogoPassService(new(MockLogoPassStorage), new(MockCryptoModule), nil, nil, zap.NewNop())
+
+	_, err := service.CheckBreach("password")
+	assert.ErrorIs(t, err, apperrors.ErrBreachCheckUnavailable)
+}
+
+func TestGetAllLogoPass_FlagsBreached(t *testing.T) {
+	mockStorage := new(MockLogoPassStorage)
+	mockCrypto := new(MockCryptoModule)
+	mockBreach := new(MockBreachChecker)
+	service := NewLogoPassService(mockStorage, mockCrypto, mockBreach, nil, zap.NewNop())
+
+	mockStorage.On("GetAllByUser", int64(1)).Return([]entities.LogoPassword{
+		{ID: 1, Username: "enc_user1", Password: "enc_pass1"},
+		{ID: 2, Username: "enc_user2", Password: "enc_pass2"},
+	}, nil)
+	mockCrypto.On("DecryptBound", "enc_user1", "key", mock.Anything).Return("alice", nil)
+	mockCrypto.On("DecryptBound", "enc_pass1", "key", mock.Anything).Return("123456", nil)
+	mockCrypto.On("DecryptBound", "enc_user2", "key", mock.Anything).Return("bob", nil)
+	mockCrypto.On("DecryptBound", "enc_pass2", "key", mock.Anything).Return("correct horse battery staple", nil)
+	mockBreach.On("Lookup", "123456").Return(uint32(37359195), nil)
+	mockBreach.On("Lookup", "correct horse battery staple").Return(uint32(0), nil)
+
+	items, err := service.GetAll(context.Background(), 1, "key")
+
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.True(t, items[0].Breached)
+	assert.False(t, items[1].Breached)
+}
+
+func TestCreateLogoPass_URIs(t *testing.T) {
+	mockStorage := new(MockLogoPassStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewLogoPassService(mockStorage, mockCrypto, nil, nil, zap.NewNop())
+
+	body := dto.CreateLogoPassDTO{
+		UserId:   1,
+		AppName:  "github",
+		Username: "octocat",
+		Password: "s3cret",
+		URIs:     []entities.LogoPassURI{{URI: "github.com"}, {URI: "https://gist.github.com", Match: "host"}},
+		Key:      "key",
+	}
+
+	mockStorage.On("NextID").Return(int64(2), nil)
+	mockCrypto.On(
+		"EncryptBound",
+		`[{"uri":"github.com","match":"domain"},{"uri":"https://gist.github.com","match":"host"}]`,
+		"key",
+		cryptox.Binding{UserID: 1, ItemType: "passwords", ItemID: 2, Field: "uris"},
+	).Return("enc_uris", nil)
+	mockCrypto.On("EncryptBound", mock.Anything, "key", mock.Anything).Return("enc", nil)
+	mockStorage.On("CreateLogoPass", mock.MatchedBy(func(b dto.CreateLogoPassDTO) bool {
+		return b.EncryptedURIs == "enc_uris"
+	})).Return(nil)
+
+	_, err := service.Create(context.Background(), body)
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestCreateLogoPass_InvalidURI(t *testing.T) {
+	mockStorage := new(MockLogoPassStorage)
+	service := NewLogoPassService(mockStorage, new(MockCryptoModule), nil, nil, zap.NewNop())
+
+	_, err := service.Create(context.Background(), dto.CreateLogoPassDTO{
+		URIs: []entities.LogoPassURI{{URI: "(", Match: "regex"}},
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidURI)
+	mockStorage.AssertNotCalled(t, "CreateLogoPass", mock.Anything)
+}
+
+func TestMatchLogoPass(t *testing.T) {
+	mockStorage := new(MockLogoPassStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewLogoPassService(mockStorage, mockCrypto, nil, nil, zap.NewNop())
+
+	mockStorage.On("GetAllByUser", int64(1)).Return([]entities.LogoPassword{
+		{ID: 1, AppName: "domain", Username: "u1", Password: "p1", EncryptedURIs: "uris1"},
+		{ID: 2, AppName: "exact", Username: "u2", Password: "p2", EncryptedURIs: "uris2"},
+		{ID: 3, AppName: "other", Username: "u3", Password: "p3", EncryptedURIs: "uris3"},
+		{ID: 4, AppName: "no uris", Username: "u4", Password: "p4"},
+	}, nil)
+	for _, v := range []string{"u1", "p1", "u2", "p2", "u3", "p3", "u4", "p4"} {
+		mockCrypto.On("DecryptBound", v, "key", mock.Anything).Return(v, nil)
+	}
+	mockCrypto.On("DecryptBound", "uris1", "key", mock.Anything).Return(`[{"uri":"example.com","match":"domain"}]`, nil)
+	mockCrypto.On("DecryptBound", "uris2", "key", mock.Anything).Return(`[{"uri":"example.com","match":"domain"},{"uri":"https://login.example.com/","match":"exact"}]`, nil)
+	mockCrypto.On("DecryptBound", "uris3", "key", mock.Anything).Return(`[{"uri":"example.org","match":"host"}]`, nil)
+
+	matches, err := service.Match(context.Background(), 1, "key", "https://login.example.com")
+
+	assert.NoError(t, err)
+	assert.Len(t, matches, 2)
+	assert.Equal(t, 2, matches[0].ID)
+	assert.Equal(t, "exact", matches[0].Match)
+	assert.Equal(t, "https://login.example.com/", matches[0].MatchedURI)
+	assert.Equal(t, 1, matches[1].ID)
+	assert.Equal(t, "domain", matches[1].Match)
+	assert.Greater(t, matches[0].Score, matches[1].Score)
+}
+
+func TestMatchLogoPass_InvalidURL(t *testing.T) {
+	service := NewLogoPassService(new(MockLogoPassStorage), new(MockCryptoModule), nil, nil, zap.NewNop())
+
+	_, err := service.Match(context.Background(), 1, "key", "://")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidURI)
+}
@@ -12,14 +12,17 @@ import (
 type NoteService struct {
 	noteDB       NoteStorage
 	cryptoModule CryptoModule
+	itemKeys     *ItemKeys
 	log          *zap.Logger
 }
 
 // NoteStorage defines an interface for storing, retrieving, and updating encrypted notes.
 type NoteStorage interface {
+	// NextID reserves the ID of the next note.
+	NextID(ctx context.Context) (int64, error)
 	// Create stores an encrypted note entry.
 	Create(ctx context.Context, body dto.CreateNoteDTO) error
-	// Update modifies an existing encrypted note.
+	// Update modifies an existing encrypted note owned by body.UserID.
 	Update(ctx context.Context, noteID int, body dto.UpdateNoteDTO) error
 	// GetAllByUser retrieves all encrypted notes for a given user ID.
 	GetAllByUser(ctx context.Context, userID int) ([]entities.Note, error)
@@ -30,6 +33,7 @@ type NoteStorage interface {
 // Parameters:
 //   - db: An implementation of the NoteStorage interface for data persistence.
 //   - cryptoModule: An implementation of CryptoModule for encryption and decryption.
+//   - itemKeys: Resolves the item keys of shared notes; nil if sharing is not used.
 //   - log: A structured logger (zap.Logger) for logging events.
 //
 // Returns:
@@ -37,11 +41,13 @@ type NoteStorage interface {
 func NewNoteService(
 	db NoteStorage,
 	cryptoModule CryptoModule,
+	itemKeys *ItemKeys,
 	log *zap.Logger,
 ) *NoteService {
 	return &NoteService{
 		noteDB:       db,
 		cryptoModule: cryptoModule,
+		itemKeys:     itemKeys,
 		log:          log,
 	}
 }
@@ -54,12 +60,19 @@ func NewNoteService(
 // Returns:
 //   - An error if encryption or storage fails.
 func (n *NoteService) Create(ctx context.Context, body dto.CreateNoteDTO) error {
-	encryptedTitle, err := n.cryptoModule.Encrypt(body.Title, body.Key)
+	var err error
+	body.ID, err = n.noteDB.NextID(ctx)
 	if err != nil {
 		return err
 	}
+	userID := int64(body.UserID)
 
-	encryptedTextData, err := n.cryptoModule.Encrypt(body.TextData, body.Key)
+	encryptedTitle, err := n.cryptoModule.EncryptBound(body.Title, body.Key, bind(userID, itemTypeNote, body.ID, "title"))
+	if err != nil {
+		return err
+	}
+
+	encryptedTextData, err := n.cryptoModule.EncryptBound(body.TextData, body.Key, bind(userID, itemTypeNote, body.ID, "text_data"))
 	if err != nil {
 		return err
 	}
@@ -70,7 +83,7 @@ func (n *NoteService) Create(ctx context.Context, body dto.CreateNoteDTO) error
 	return n.noteDB.Create(ctx, body)
 }
 
-// Update encrypts and updates an existing note entry.
+// Update encrypts and updates an existing note entry. A shared note is encrypted with its item key.
 //
 // Parameters:
 //   - noteID: The ID of the note being updated.
@@ -79,12 +92,18 @@ func (n *NoteService) Create(ctx context.Context, body dto.CreateNoteDTO) error
 // Returns:
 //   - An error if encryption or update fails.
 func (n *NoteService) Update(ctx context.Context, noteID int, body dto.UpdateNoteDTO) error {
-	encryptedTitle, err := n.cryptoModule.Encrypt(body.Title, body.Key)
+	var err error
+	body.Key, err = n.itemKeys.key(ctx, body.UserID, itemTypeNote, int64(noteID), body.Key)
+	if err != nil {
+		return err
+	}
+
+	encryptedTitle, err := n.cryptoModule.EncryptBound(body.Title, body.Key, bind(body.UserID, itemTypeNote, int64(noteID), "title"))
 	if err != nil {
 		return err
 	}
 
-	encryptedTextData, err := n.cryptoModule.Encrypt(body.TextData, body.Key)
+	encryptedTextData, err := n.cryptoModule.EncryptBound(body.TextData, body.Key, bind(body.UserID, itemTypeNote, int64(noteID), "text_data"))
 	if err != nil {
 		return err
 	}
@@ -109,7 +128,12 @@ func (n *NoteService) GetAll(ctx context.Context, userID int, key string) ([]ent
 		return nil, err
 	}
 
-	decryptedData := n.decryptNotesArray(encryptedData, key)
+	keyFor, err := n.itemKeys.resolve(ctx, int64(userID), itemTypeNote, key)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedData := n.decryptNotesArray(encryptedData, keyFor)
 
 	return decryptedData, nil
 }
@@ -118,18 +142,18 @@ func (n *NoteService) GetAll(ctx context.Context, userID int, key string) ([]ent
 //
 // Parameters:
 //   - encryptedData: A slice of encrypted entities.Note.
-//   - key: The encryption key used for decryption.
+//   - keyFor: Returns the encryption key of a note by its ID.
 //
 // Returns:
 //   - A slice of decrypted entities.Note.
 func (n *NoteService) decryptNotesArray(
 	encryptedData []entities.Note,
-	key string,
+	keyFor func(int64) string,
 ) []entities.Note {
 	decryptedData := make([]entities.Note, 0, len(encryptedData))
 
 	for i := 0; i < len(encryptedData); i++ {
-		decryptedNote, err := n.decryptNote(encryptedData[i], key)
+		decryptedNote, err := n.decryptNote(encryptedData[i], keyFor(int64(encryptedData[i].ID)))
 		if err != nil {
 			continue
 		}
@@ -152,12 +176,14 @@ func (n *NoteService) decryptNote(
 	encryptedNote entities.Note,
 	key string,
 ) (*entities.Note, error) {
-	decryptedTitle, err := n.cryptoModule.Decrypt(encryptedNote.Title, key)
+	userID, noteID := int64(encryptedNote.UserID), int64(encryptedNote.ID)
+
+	decryptedTitle, err := n.cryptoModule.DecryptBound(encryptedNote.Title, key, bind(userID, itemTypeNote, noteID, "title"))
 	if err != nil {
 		return nil, err
 	}
 
-	decryptedTextData, err := n.cryptoModule.Decrypt(encryptedNote.TextData, key)
+	decryptedTextData, err := n.cryptoModule.DecryptBound(encryptedNote.TextData, key, bind(userID, itemTypeNote, noteID, "text_data"))
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,11 @@ type MockNoteStorage struct {
 	mock.Mock
 }
 
+func (m *MockNoteStorage) NextID(ctx context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockNoteStorage) Create(ctx context.Context, body dto.CreateNoteDTO) error {
 	args := m.Called(body)
 	return args.Error(0)
@@ -35,18 +41,24 @@ func TestCreateNote(t *testing.T) {
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewNoteService(mockStorage, mockCrypto, logger)
+	service := NewNoteService(mockStorage, mockCrypto, nil, logger)
 
 	noteDTO := dto.CreateNoteDTO{
+		UserID:   1,
 		Title:    "My Note",
 		TextData: "This is a test note",
 		Key:      "secret",
 	}
 
-	mockCrypto.On("Encrypt", "My Note", "secret").Return("enc_title", nil)
-	mockCrypto.On("Encrypt", "This is a test note", "secret").Return("enc_text", nil)
+	mockStorage.On("NextID").Return(int64(9), nil)
+	mockCrypto.On("EncryptBound", "My Note", "secret", cryptox.Binding{UserID: 1, ItemType: "notes", ItemID: 9, Field: "title"}).
+		Return("enc_title", nil)
+	mockCrypto.On("EncryptBound", "This is a test note", "secret", cryptox.Binding{UserID: 1, ItemType: "notes", ItemID: 9, Field: "text_data"}).
+		Return("enc_text", nil)
 
-	mockStorage.On("Create", mock.Anything).Return(nil)
+	mockStorage.On("Create", mock.MatchedBy(func(body dto.CreateNoteDTO) bool {
+		return body.ID == 9 && body.Title == "enc_title" && body.TextData == "enc_text"
+	})).Return(nil)
 
 	err := service.Create(context.Background(), noteDTO)
 
@@ -60,7 +72,7 @@ func TestUpdateNote(t *testing.T) {
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewNoteService(mockStorage, mockCrypto, logger)
+	service := NewNoteService(mockStorage, mockCrypto, nil, logger)
 
 	noteDTO := dto.UpdateNoteDTO{
 		Title:    "Updated Title",
@@ -70,8 +82,8 @@ func TestUpdateNote(t *testing.T) {
 
 	noteID := 1
 
-	mockCrypto.On("Encrypt", "Updated Title", "secret").Return("enc_title", nil)
-	mockCrypto.On("Encrypt", "Updated text", "secret").Return("enc_text", nil)
+	mockCrypto.On("EncryptBound", "Updated Title", "secret", mock.Anything).Return("enc_title", nil)
+	mockCrypto.On("EncryptBound", "Updated text", "secret", mock.Anything).Return("enc_text", nil)
 
 	mockStorage.On("Update", noteID, mock.Anything).Return(nil)
 
@@ -87,7 +99,7 @@ func TestGetAllNotes_Success(t *testing.T) {
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewNoteService(mockStorage, mockCrypto, logger)
+	service := NewNoteService(mockStorage, mockCrypto, nil, logger)
 
 	userID := 1
 	encryptionKey := "secret"
@@ -99,10 +111,10 @@ func TestGetAllNotes_Success(t *testing.T) {
 
 	mockStorage.On("GetAllByUser", userID).Return(encryptedNotes, nil)
 
-	mockCrypto.On("Decrypt", "enc_title1", encryptionKey).Return("Title 1", nil)
-	mockCrypto.On("Decrypt", "enc_text1", encryptionKey).Return("Text 1", nil)
-	mockCrypto.On("Decrypt", "enc_title2", encryptionKey).Return("Title 2", nil)
-	mockCrypto.On("Decrypt", "enc_text2", encryptionKey).Return("Text 2", nil)
+	mockCrypto.On("DecryptBound", "enc_title1", encryptionKey, mock.Anything).Return("Title 1", nil)
+	mockCrypto.On("DecryptBound", "enc_text1", encryptionKey, mock.Anything).Return("Text 1", nil)
+	mockCrypto.On("DecryptBound", "enc_title2", encryptionKey, mock.Anything).Return("Title 2", nil)
+	mockCrypto.On("DecryptBound", "enc_text2", encryptionKey, mock.Anything).Return("Text 2", nil)
 
 	notes, err := service.GetAll(context.Background(), userID, encryptionKey)
 
@@ -122,7 +134,7 @@ func TestGetAllNotes_NotFound(t *testing.T) {
 	mockCrypto := new(MockCryptoModule)
 	logger := zap.NewNop()
 
-	service := NewNoteService(mockStorage, mockCrypto, logger)
+	service := NewNoteService(mockStorage, mockCrypto, nil, logger)
 
 	mockStorage.On("GetAllByUser", 1).Return([]entities.Note{}, nil)
 
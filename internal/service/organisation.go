@@ -0,0 +1,721 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"go.uber.org/zap"
+)
+
+// orgRoles ranks the roles of organisation members; a higher rank grants every right of a lower one.
+var orgRoles = map[string]int{
+	entities.OrgRoleReadOnly: 1,
+	entities.OrgRoleMember:   2,
+	entities.OrgRoleAdmin:    3,
+	entities.OrgRoleOwner:    4,
+}
+
+// hasOrgRole reports whether role grants the rights of minimum.
+func hasOrgRole(role, minimum string) bool {
+	return orgRoles[role] >= orgRoles[minimum]
+}
+
+// OrgService manages organisation vaults: organisations, their members and collections of items
+// shared with every member.
+//
+// Every organisation has a random organisation key sealed to the public key of each member. An item
+// added to a collection is encrypted with its item key, as a shared item is, and the item key is
+// stored wrapped by the organisation key. Removing a member replaces the organisation key, so the
+// member cannot unwrap item keys added later with a key they kept.
+type OrgService struct {
+	storage      OrgStorage
+	keys         OrgKeyStorage
+	cryptoModule CryptoModule
+	log          *zap.Logger
+}
+
+// OrgKeyStorage defines the lookup of the key pairs and item keys used by organisation vaults.
+type OrgKeyStorage interface {
+	keyPairStorage
+	// GetRecipient retrieves the key pair of a user by username; apperrors.ErrShareRecipientNotFound if there is none.
+	GetRecipient(ctx context.Context, username string) (*entities.UserKeyPair, error)
+	// GetItemKey retrieves the item key of an item; apperrors.ErrItemKeyNotFound if it has none.
+	GetItemKey(ctx context.Context, itemType string, itemID int64) (*entities.ItemKey, error)
+}
+
+// OrgStorage defines database operations of organisation vaults.
+type OrgStorage interface {
+	// CreateOrg stores an organisation with its creator as its owner.
+	CreateOrg(ctx context.Context, name string, ownerID int64, wrappedKey string) (*entities.Organisation, error)
+	// ListOrgs retrieves the organisations a user is a member of, with their role.
+	ListOrgs(ctx context.Context, userID int64) ([]entities.Organisation, error)
+	// GetMember retrieves a user's membership; apperrors.ErrOrgMemberNotFound if the user is not a member.
+	GetMember(ctx context.Context, orgID, userID int64) (*entities.OrgMember, error)
+	// ListMembers retrieves the members of an organisation with their public keys.
+	ListMembers(ctx context.Context, orgID int64) ([]entities.OrgMember, error)
+	// AddMember adds a user to an organisation with the organisation key sealed to them.
+	AddMember(ctx context.Context, member entities.OrgMember) error
+	// UpdateMemberRole changes the role of a member.
+	UpdateMemberRole(ctx context.Context, orgID, userID int64, role string) error
+	// RemoveMember removes a member and their collection items and replaces the organisation key.
+	RemoveMember(ctx context.Context, body dto.RotateOrgKeyDTO) error
+	// CreateCollection stores a collection; apperrors.ErrInvalidOrg if the name is already used.
+	CreateCollection(ctx context.Context, orgID int64, name string) (*entities.Collection, error)
+	// ListCollections retrieves the collections of an organisation.
+	ListCollections(ctx context.Context, orgID int64) ([]entities.Collection, error)
+	// GetCollection retrieves a collection; apperrors.ErrCollectionNotFound if it does not exist.
+	GetCollection(ctx context.Context, collectionID int64) (*entities.Collection, error)
+	// DeleteCollection deletes a collection, leaving its items in their owners' vaults.
+	DeleteCollection(ctx context.Context, collectionID int64) error
+	// ListOrgItems retrieves the items of every collection of an organisation with their wrapped item keys.
+	ListOrgItems(ctx context.Context, orgID int64) ([]entities.CollectionItem, error)
+	// AddCollectionItem adds an item to a collection, re-encrypting it with a new item key if needed.
+	AddCollectionItem(
+		ctx context.Context,
+		body dto.StoreCollectionItemDTO,
+		reencryptText func(dto.VaultFieldDTO, string) (string, error),
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+	) error
+	// RemoveCollectionItem removes an item from a collection, leaving it in its owner's vault.
+	RemoveCollectionItem(ctx context.Context, collectionID int64, itemType string, itemID int64) error
+	// ListCollectionItems retrieves the items of a collection, as stored.
+	ListCollectionItems(ctx context.Context, collectionID int64) ([]entities.OrgItem, error)
+	// GetCollectionItem retrieves an item of a collection, as stored.
+	GetCollectionItem(ctx context.Context, collectionID int64, itemType string, itemID int64) (*entities.OrgItem, error)
+	// UpdateCollectionItem stores new ciphertexts of a collection item encrypted with the item key wrapped as wrappedKey.
+	UpdateCollectionItem(
+		ctx context.Context,
+		collectionID int64,
+		itemType string,
+		itemID int64,
+		wrappedKey string,
+		text map[string]string,
+		binary map[string][]byte,
+	) error
+}
+
+// NewOrgService creates a new instance of OrgService.
+//
+// Parameters:
+//   - storage: An implementation of the OrgStorage interface.
+//   - keys: An implementation of the OrgKeyStorage interface.
+//   - cryptoModule: An implementation of CryptoModule for encryption, key wrapping and sealing.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to an OrgService instance.
+func NewOrgService(storage OrgStorage, keys OrgKeyStorage, cryptoModule CryptoModule, log *zap.Logger) *OrgService {
+	return &OrgService{
+		storage:      storage,
+		keys:         keys,
+		cryptoModule: cryptoModule,
+		log:          log,
+	}
+}
+
+// Create creates an organisation owned by the authenticated user, with a new organisation key sealed
+// to the user's public key.
+//
+// Parameters:
+//   - body: The creator's ID and the name of the organisation.
+//
+// Returns:
+//   - The created organisation.
+//   - apperrors.ErrInvalidOrg for an empty name, apperrors.ErrKeyPairNotFound if the user has no key
+//     pair, or an error if storage fails.
+func (o *OrgService) Create(ctx context.Context, body dto.CreateOrgDTO) (*entities.Organisation, error) {
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return nil, apperrors.ErrInvalidOrg
+	}
+
+	pair, err := o.keys.GetKeyPair(ctx, body.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgKey, err := o.cryptoModule.GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := o.cryptoModule.SealKey(orgKey, pair.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := o.storage.CreateOrg(ctx, name, body.UserID, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	o.log.Info("organisation created", zap.Int64("orgID", org.ID), zap.Int64("ownerID", body.UserID))
+
+	return org, nil
+}
+
+// List retrieves the organisations the authenticated user is a member of.
+//
+// Parameters:
+//   - userID: The ID of the user.
+//
+// Returns:
+//   - The organisations with the user's role, or an error if retrieval fails.
+func (o *OrgService) List(ctx context.Context, userID int64) ([]entities.Organisation, error) {
+	return o.storage.ListOrgs(ctx, userID)
+}
+
+// ListMembers retrieves the members of an organisation the authenticated user is a member of.
+//
+// Parameters:
+//   - orgID: The ID of the organisation.
+//   - actorID: The ID of the authenticated user.
+//
+// Returns:
+//   - The members, or apperrors.ErrOrgNotFound if the user is not a member, or an error if retrieval fails.
+func (o *OrgService) ListMembers(ctx context.Context, orgID, actorID int64) ([]entities.OrgMember, error) {
+	if _, err := o.member(ctx, orgID, actorID, entities.OrgRoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	return o.storage.ListMembers(ctx, orgID)
+}
+
+// AddMember adds a user to an organisation, sealing the organisation key to their public key. Admins
+// add members of any role but owner; owners add owners too.
+//
+// Parameters:
+//   - body: The organisation ID, the admin's ID and vault key, the username and the role ("member"
+//     by default).
+//
+// Returns:
+//   - The added member.
+//   - apperrors.ErrInvalidOrg for an unknown role, apperrors.ErrOrgNotFound, apperrors.ErrOrgForbidden,
+//     apperrors.ErrOrgMemberNotFound if the user does not exist or has no key pair,
+//     apperrors.ErrOrgMemberExists, apperrors.ErrKeyPairNotFound if the admin's key pair cannot be
+//     unwrapped with their vault key, apperrors.ErrOrgKeyChanged, or an error if storage fails.
+func (o *OrgService) AddMember(ctx context.Context, body dto.AddOrgMemberDTO) (*entities.OrgMember, error) {
+	if body.Role == "" {
+		body.Role = entities.OrgRoleMember
+	}
+	if _, ok := orgRoles[body.Role]; !ok {
+		return nil, apperrors.ErrInvalidOrg
+	}
+
+	actor, err := o.member(ctx, body.OrgID, body.ActorID, entities.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if body.Role == entities.OrgRoleOwner && actor.Role != entities.OrgRoleOwner {
+		return nil, apperrors.ErrOrgForbidden
+	}
+
+	orgKey, err := o.orgKey(ctx, actor, body.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := o.keys.GetRecipient(ctx, body.Username)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrShareRecipientNotFound) {
+			return nil, apperrors.ErrOrgMemberNotFound
+		}
+		return nil, err
+	}
+
+	member := entities.OrgMember{
+		OrgID:      body.OrgID,
+		UserID:     int64(recipient.UserID),
+		Username:   body.Username,
+		Role:       body.Role,
+		KeyVersion: actor.KeyVersion,
+	}
+	if member.WrappedKey, err = o.cryptoModule.SealKey(orgKey, recipient.PublicKey); err != nil {
+		return nil, err
+	}
+
+	if err := o.storage.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+
+	o.log.Info("organisation member added",
+		zap.Int64("orgID", member.OrgID),
+		zap.Int64("userID", member.UserID),
+		zap.String("role", member.Role),
+	)
+
+	return &member, nil
+}
+
+// UpdateMember changes the role of an organisation member. Admins change the roles of members but
+// owners; owners change any role. An organisation always keeps an owner.
+//
+// Parameters:
+//   - body: The organisation ID, the admin's ID, the member's ID and the new role.
+//
+// Returns:
+//   - apperrors.ErrInvalidOrg for an unknown role, apperrors.ErrOrgNotFound, apperrors.ErrOrgForbidden,
+//     apperrors.ErrOrgMemberNotFound, apperrors.ErrLastOrgOwner, or an error if storage fails.
+func (o *OrgService) UpdateMember(ctx context.Context, body dto.UpdateOrgMemberDTO) error {
+	if _, ok := orgRoles[body.Role]; !ok {
+		return apperrors.ErrInvalidOrg
+	}
+
+	actor, err := o.member(ctx, body.OrgID, body.ActorID, entities.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	target, err := o.storage.GetMember(ctx, body.OrgID, body.UserID)
+	if err != nil {
+		return err
+	}
+	if (target.Role == entities.OrgRoleOwner || body.Role == entities.OrgRoleOwner) && actor.Role != entities.OrgRoleOwner {
+		return apperrors.ErrOrgForbidden
+	}
+
+	if err := o.storage.UpdateMemberRole(ctx, body.OrgID, body.UserID, body.Role); err != nil {
+		return err
+	}
+
+	o.log.Info("organisation member updated",
+		zap.Int64("orgID", body.OrgID),
+		zap.Int64("userID", body.UserID),
+		zap.String("role", body.Role),
+	)
+
+	return nil
+}
+
+// RemoveMember removes a member from an organisation, or lets the authenticated user leave it. The
+// member's items are removed from its collections, and the organisation key is replaced by a new key
+// sealed to the remaining members and wrapping the item keys of the remaining items. Item keys are
+// not replaced: the owners of items the removed member could read revoke that access by removing
+// and adding the items again.
+//
+// Parameters:
+//   - body: The organisation ID, the acting user's ID and vault key, and the ID of the member to remove.
+//
+// Returns:
+//   - apperrors.ErrOrgNotFound, apperrors.ErrOrgForbidden unless an admin removes a member who is not
+//     an owner, an owner removes anyone or a member leaves, apperrors.ErrOrgMemberNotFound,
+//     apperrors.ErrLastOrgOwner, apperrors.ErrKeyPairNotFound, apperrors.ErrOrgKeyChanged if the
+//     organisation changed meanwhile, or an error if storage fails.
+func (o *OrgService) RemoveMember(ctx context.Context, body dto.RemoveOrgMemberDTO) error {
+	actor, err := o.member(ctx, body.OrgID, body.ActorID, entities.OrgRoleReadOnly)
+	if err != nil {
+		return err
+	}
+
+	target := actor
+	if body.UserID != body.ActorID {
+		if !hasOrgRole(actor.Role, entities.OrgRoleAdmin) {
+			return apperrors.ErrOrgForbidden
+		}
+		if target, err = o.storage.GetMember(ctx, body.OrgID, body.UserID); err != nil {
+			return err
+		}
+		if target.Role == entities.OrgRoleOwner && actor.Role != entities.OrgRoleOwner {
+			return apperrors.ErrOrgForbidden
+		}
+	}
+
+	orgKey, err := o.orgKey(ctx, actor, body.Key)
+	if err != nil {
+		return err
+	}
+
+	newKey, err := o.cryptoModule.GenerateDataKey()
+	if err != nil {
+		return err
+	}
+
+	rotate := dto.RotateOrgKeyDTO{
+		OrgID:      body.OrgID,
+		UserID:     body.UserID,
+		KeyVersion: actor.KeyVersion,
+		Members:    make(map[int64]string),
+	}
+
+	members, err := o.storage.ListMembers(ctx, body.OrgID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.UserID == body.UserID {
+			continue
+		}
+		if rotate.Members[member.UserID], err = o.cryptoModule.SealKey(newKey, member.PublicKey); err != nil {
+			return err
+		}
+	}
+
+	items, err := o.storage.ListOrgItems(ctx, body.OrgID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.OwnerID == body.UserID {
+			continue
+		}
+		itemKey, err := o.cryptoModule.UnwrapKey(item.WrappedKey, orgKey)
+		if err != nil {
+			return err
+		}
+		rewrapped := dto.RewrappedKeyDTO{ItemType: item.ItemType, ItemID: item.ItemID, OldKey: item.WrappedKey}
+		if rewrapped.NewKey, err = o.cryptoModule.WrapKey(itemKey, newKey); err != nil {
+			return err
+		}
+		rotate.Items = append(rotate.Items, rewrapped)
+	}
+
+	if err := o.storage.RemoveMember(ctx, rotate); err != nil {
+		return err
+	}
+
+	o.log.Info("organisation member removed",
+		zap.Int64("orgID", body.OrgID),
+		zap.Int64("userID", body.UserID),
+		zap.Int64("actorID", body.ActorID),
+	)
+
+	return nil
+}
+
+// CreateCollection creates a collection of an organisation; only admins and owners create collections.
+//
+// Parameters:
+//   - body: The organisation ID, the admin's ID and the name of the collection.
+//
+// Returns:
+//   - The created collection.
+//   - apperrors.ErrInvalidOrg for an empty or already used name, apperrors.ErrOrgNotFound,
+//     apperrors.ErrOrgForbidden, or an error if storage fails.
+func (o *OrgService) CreateCollection(ctx context.Context, body dto.CreateCollectionDTO) (*entities.Collection, error) {
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return nil, apperrors.ErrInvalidOrg
+	}
+
+	if _, err := o.member(ctx, body.OrgID, body.ActorID, entities.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return o.storage.CreateCollection(ctx, body.OrgID, name)
+}
+
+// ListCollections retrieves the collections of an organisation the authenticated user is a member of.
+//
+// Parameters:
+//   - orgID: The ID of the organisation.
+//   - actorID: The ID of the authenticated user.
+//
+// Returns:
+//   - The collections, or apperrors.ErrOrgNotFound if the user is not a member, or an error if retrieval fails.
+func (o *OrgService) ListCollections(ctx context.Context, orgID, actorID int64) ([]entities.Collection, error) {
+	if _, err := o.member(ctx, orgID, actorID, entities.OrgRoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	return o.storage.ListCollections(ctx, orgID)
+}
+
+// DeleteCollection deletes a collection; only admins and owners delete collections. Its items stay
+// in their owners' vaults.
+//
+// Parameters:
+//   - collectionID: The ID of the collection.
+//   - actorID: The ID of the authenticated user.
+//
+// Returns:
+//   - apperrors.ErrCollectionNotFound, apperrors.ErrOrgForbidden, or an error if storage fails.
+func (o *OrgService) DeleteCollection(ctx context.Context, collectionID, actorID int64) error {
+	if _, err := o.collection(ctx, collectionID, actorID, entities.OrgRoleAdmin); err != nil {
+		return err
+	}
+
+	return o.storage.DeleteCollection(ctx, collectionID)
+}
+
+// AddItem adds an item of the authenticated user to a collection. An item without an item key is
+// re-encrypted with a new one, as on its first share; the item key is wrapped by the organisation key.
+//
+// Parameters:
+//   - body: The collection ID, the user's ID and vault key, the item type ("logopass", "card",
+//     "note", "binary", "bank_account" or "identity_document") and the item ID.
+//
+// Returns:
+//   - The added collection item.
+//   - apperrors.ErrInvalidOrg for an unknown item type, apperrors.ErrCollectionNotFound,
+//     apperrors.ErrOrgForbidden for read-only members, apperrors.ErrCollectionItemNotFound if the user
+//     has no such item, apperrors.ErrItemInCollection, apperrors.ErrKeyPairNotFound,
+//     apperrors.ErrOrgKeyChanged, or an error if re-encryption or storage fails.
+func (o *OrgService) AddItem(ctx context.Context, body dto.AddCollectionItemDTO) (*entities.CollectionItem, error) {
+	itemType, ok := sharedItemTypes[body.ItemType]
+	if !ok {
+		return nil, apperrors.ErrInvalidOrg
+	}
+
+	member, err := o.collection(ctx, body.CollectionID, body.ActorID, entities.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	// Opening the organisation key also makes sure the vault key is the user's data key.
+	orgKey, err := o.orgKey(ctx, member, body.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	store := dto.StoreCollectionItemDTO{
+		CollectionID: body.CollectionID,
+		KeyVersion:   member.KeyVersion,
+		OwnerID:      body.ActorID,
+		ItemType:     itemType,
+		ItemID:       body.ItemID,
+	}
+
+	var (
+		itemKey string
+
+		reencryptText   func(dto.VaultFieldDTO, string) (string, error)
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error)
+	)
+
+	stored, err := o.keys.GetItemKey(ctx, itemType, body.ItemID)
+	switch {
+	case errors.Is(err, apperrors.ErrItemKeyNotFound):
+		if itemKey, err = o.cryptoModule.GenerateDataKey(); err != nil {
+			return nil, err
+		}
+		if store.ItemKey, err = o.cryptoModule.WrapKey(itemKey, body.Key); err != nil {
+			return nil, err
+		}
+		store.NewItemKey = true
+		reencryptText, reencryptBinary = newReencryptors(o.cryptoModule, body.Key, itemKey)
+	case err != nil:
+		return nil, err
+	case stored.OwnerID != body.ActorID:
+		return nil, apperrors.ErrCollectionItemNotFound
+	default:
+		if itemKey, err = o.cryptoModule.UnwrapKey(stored.WrappedKey, body.Key); err != nil {
+			return nil, err
+		}
+		store.ItemKey = stored.WrappedKey
+	}
+
+	if store.WrappedKey, err = o.cryptoModule.WrapKey(itemKey, orgKey); err != nil {
+		return nil, err
+	}
+
+	if err := o.storage.AddCollectionItem(ctx, store, reencryptText, reencryptBinary); err != nil {
+		return nil, err
+	}
+
+	o.log.Info("collection item added",
+		zap.Int64("collectionID", store.CollectionID),
+		zap.String("itemType", itemType),
+		zap.Int64("itemID", store.ItemID),
+	)
+
+	return &entities.CollectionItem{
+		CollectionID: store.CollectionID,
+		ItemType:     body.ItemType,
+		ItemID:       store.ItemID,
+		OwnerID:      store.OwnerID,
+	}, nil
+}
+
+// RemoveItem removes an item from a collection. The item's owner removes it with any role; admins
+// and owners remove any item. The item stays encrypted with its item key in its owner's vault.
+//
+// Parameters:
+//   - collectionID: The ID of the collection.
+//   - actorID: The ID of the authenticated user.
+//   - itemType: The item type, as accepted by AddItem.
+//   - itemID: The ID of the item.
+//
+// Returns:
+//   - apperrors.ErrInvalidOrg for an unknown item type, apperrors.ErrCollectionNotFound,
+//     apperrors.ErrCollectionItemNotFound, apperrors.ErrOrgForbidden, or an error if storage fails.
+func (o *OrgService) RemoveItem(ctx context.Context, collectionID, actorID int64, itemType string, itemID int64) error {
+	table, ok := sharedItemTypes[itemType]
+	if !ok {
+		return apperrors.ErrInvalidOrg
+	}
+
+	member, err := o.collection(ctx, collectionID, actorID, entities.OrgRoleReadOnly)
+	if err != nil {
+		return err
+	}
+
+	item, err := o.storage.GetCollectionItem(ctx, collectionID, table, itemID)
+	if err != nil {
+		return err
+	}
+	if item.Item.OwnerID != actorID && !hasOrgRole(member.Role, entities.OrgRoleAdmin) {
+		return apperrors.ErrOrgForbidden
+	}
+
+	return o.storage.RemoveCollectionItem(ctx, collectionID, table, itemID)
+}
+
+// ListItems retrieves and decrypts the items of a collection. Items that cannot be decrypted are
+// logged and skipped.
+//
+// Parameters:
+//   - collectionID: The ID of the collection.
+//   - actorID: The ID of the authenticated user.
+//   - key: The user's vault key.
+//
+// Returns:
+//   - The decrypted items; binary fields are base64-encoded.
+//   - apperrors.ErrCollectionNotFound, apperrors.ErrKeyPairNotFound, or an error if retrieval fails.
+func (o *OrgService) ListItems(ctx context.Context, collectionID, actorID int64, key string) ([]dto.CollectionItemDTO, error) {
+	member, err := o.collection(ctx, collectionID, actorID, entities.OrgRoleReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	orgKey, err := o.orgKey(ctx, member, key)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := o.storage.ListCollectionItems(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.CollectionItemDTO, 0, len(items))
+	for _, item := range items {
+		fields, err := o.decryptItem(item, orgKey)
+		if err != nil {
+			o.log.Warn("decrypt collection item error",
+				zap.Int64("collectionID", collectionID),
+				zap.String("itemType", item.Item.ItemType),
+				zap.Int64("itemID", item.Item.ItemID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		collectionItem := item.Item
+		collectionItem.ItemType = apiItemType(collectionItem.ItemType)
+		result = append(result, dto.CollectionItemDTO{CollectionItem: collectionItem, Fields: fields})
+	}
+
+	return result, nil
+}
+
+// UpdateItem changes encrypted fields of a collection item; read-only members cannot change items.
+// Values are stored as given, encrypted with the item key; binary fields are base64-encoded.
+//
+// Parameters:
+//   - body: The collection ID, the user's ID and vault key, the item type and ID, and the new values
+//     by field name.
+//
+// Returns:
+//   - apperrors.ErrInvalidOrg for an unknown item type or a field the item has no encrypted column
+//     for, apperrors.ErrCollectionNotFound, apperrors.ErrOrgForbidden,
+//     apperrors.ErrCollectionItemNotFound, apperrors.ErrKeyPairNotFound, apperrors.ErrOrgKeyChanged,
+//     or an error if storage fails.
+func (o *OrgService) UpdateItem(ctx context.Context, body dto.UpdateCollectionItemDTO) error {
+	itemType, ok := sharedItemTypes[body.ItemType]
+	if !ok {
+		return apperrors.ErrInvalidOrg
+	}
+
+	member, err := o.collection(ctx, body.CollectionID, body.ActorID, entities.OrgRoleMember)
+	if err != nil {
+		return err
+	}
+
+	orgKey, err := o.orgKey(ctx, member, body.Key)
+	if err != nil {
+		return err
+	}
+
+	item, err := o.storage.GetCollectionItem(ctx, body.CollectionID, itemType, body.ItemID)
+	if err != nil {
+		return err
+	}
+
+	itemKey, err := o.cryptoModule.UnwrapKey(item.Item.WrappedKey, orgKey)
+	if err != nil {
+		return err
+	}
+
+	text, binary, err := encryptItemFields(o.cryptoModule, item.Item.OwnerID, itemType, body.ItemID, itemKey, body.Fields, item.Text, item.Binary)
+	if err != nil {
+		if errors.Is(err, errInvalidField) {
+			return apperrors.ErrInvalidOrg
+		}
+		return err
+	}
+
+	return o.storage.UpdateCollectionItem(ctx, body.CollectionID, itemType, body.ItemID, item.Item.WrappedKey, text, binary)
+}
+
+// member retrieves the membership of the authenticated user and checks their role.
+//
+// Returns:
+//   - The membership, apperrors.ErrOrgNotFound if the user is not a member, so that organisations of
+//     others are not disclosed, or apperrors.ErrOrgForbidden if their role is below minimum.
+func (o *OrgService) member(ctx context.Context, orgID, userID int64, minimum string) (*entities.OrgMember, error) {
+	member, err := o.storage.GetMember(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrOrgMemberNotFound) {
+			return nil, apperrors.ErrOrgNotFound
+		}
+		return nil, err
+	}
+	if !hasOrgRole(member.Role, minimum) {
+		return nil, apperrors.ErrOrgForbidden
+	}
+
+	return member, nil
+}
+
+// collection retrieves the authenticated user's membership of the organisation of a collection and
+// checks their role; see member. Collections of other organisations are apperrors.ErrCollectionNotFound.
+func (o *OrgService) collection(ctx context.Context, collectionID, userID int64, minimum string) (*entities.OrgMember, error) {
+	collection, err := o.storage.GetCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := o.member(ctx, collection.OrgID, userID, minimum)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrOrgNotFound) {
+			return nil, apperrors.ErrCollectionNotFound
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// orgKey opens the organisation key sealed to a member with their private key.
+func (o *OrgService) orgKey(ctx context.Context, member *entities.OrgMember, key string) (string, error) {
+	privateKey, err := openPrivateKey(ctx, o.keys, o.cryptoModule, o.log, member.UserID, key)
+	if err != nil {
+		return "", err
+	}
+
+	return o.cryptoModule.OpenSealedKey(member.WrappedKey, privateKey)
+}
+
+// decryptItem unwraps the item key of a collection item with the organisation key and decrypts its fields.
+func (o *OrgService) decryptItem(item entities.OrgItem, orgKey string) (map[string]string, error) {
+	itemKey, err := o.cryptoModule.UnwrapKey(item.Item.WrappedKey, orgKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptItemFields(o.cryptoModule, item.Item.OwnerID, item.Item.ItemType, item.Item.ItemID, itemKey, item.Plain, item.Text, item.Binary)
+}
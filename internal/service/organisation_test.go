@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockOrgStorage struct {
+	mock.Mock
+}
+
+func (m *MockOrgStorage) CreateOrg(ctx context.Context, name string, ownerID int64, wrappedKey string) (*entities.Organisation, error) {
+	args := m.Called(name, ownerID, wrappedKey)
+	if org, ok := args.Get(0).(*entities.Organisation); ok {
+		return org, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgStorage) ListOrgs(ctx context.Context, userID int64) ([]entities.Organisation, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entities.Organisation), args.Error(1)
+}
+
+func (m *MockOrgStorage) GetMember(ctx context.Context, orgID, userID int64) (*entities.OrgMember, error) {
+	args := m.Called(orgID, userID)
+	if member, ok := args.Get(0).(*entities.OrgMember); ok {
+		return member, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgStorage) ListMembers(ctx context.Context, orgID int64) ([]entities.OrgMember, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]entities.OrgMember), args.Error(1)
+}
+
+func (m *MockOrgStorage) AddMember(ctx context.Context, member entities.OrgMember) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockOrgStorage) UpdateMemberRole(ctx context.Context, orgID, userID int64, role string) error {
+	args := m.Called(orgID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrgStorage) RemoveMember(ctx context.Context, body dto.RotateOrgKeyDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockOrgStorage) CreateCollection(ctx context.Context, orgID int64, name string) (*entities.Collection, error) {
+	args := m.Called(orgID, name)
+	if collection, ok := args.Get(0).(*entities.Collection); ok {
+		return collection, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgStorage) ListCollections(ctx context.Context, orgID int64) ([]entities.Collection, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]entities.Collection), args.Error(1)
+}
+
+func (m *MockOrgStorage) GetCollection(ctx context.Context, collectionID int64) (*entities.Collection, error) {
+	args := m.Called(collectionID)
+	if collection, ok := args.Get(0).(*entities.Collection); ok {
+		return collection, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgStorage) DeleteCollection(ctx context.Context, collectionID int64) error {
+	args := m.Called(collectionID)
+	return args.Error(0)
+}
+
+func (m *MockOrgStorage) ListOrgItems(ctx context.Context, orgID int64) ([]entities.CollectionItem, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]entities.CollectionItem), args.Error(1)
+}
+
+func (m *MockOrgStorage) AddCollectionItem(
+	ctx context.Context,
+	body dto.StoreCollectionItemDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) error {
+	args := m.Called(body, reencryptText, reencryptBinary)
+	return args.Error(0)
+}
+
+func (m *MockOrgStorage) RemoveCollectionItem(ctx context.Context, collectionID int64, itemType string, itemID int64) error {
+	args := m.Called(collectionID, itemType, itemID)
+	return args.Error(0)
+}
+
+func (m *MockOrgStorage) ListCollectionItems(ctx context.Context, collectionID int64) ([]entities.OrgItem, error) {
+	args := m.Called(collectionID)
+	return args.Get(0).([]entities.OrgItem), args.Error(1)
+}
+
+func (m *MockOrgStorage) GetCollectionItem(ctx context.Context, collectionID int64, itemType string, itemID int64) (*entities.OrgItem, error) {
+	args := m.Called(collectionID, itemType, itemID)
+	if item, ok := args.Get(0).(*entities.OrgItem); ok {
+		return item, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgStorage) UpdateCollectionItem(
+	ctx context.Context,
+	collectionID int64,
+	itemType string,
+	itemID int64,
+	wrappedKey string,
+	text map[string]string,
+	binary map[string][]byte,
+) error {
+	args := m.Called(collectionID, itemType, itemID, wrappedKey, text, binary)
+	return args.Error(0)
+}
+
+// newOrgTest returns an OrgService with mocked storage, key storage and crypto module.
+func newOrgTest() (*OrgService, *MockOrgStorage, *MockSharingStorage, *MockCryptoModule) {
+	mockStorage := new(MockOrgStorage)
+	mockKeys := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	return NewOrgService(mockStorage, mockKeys, mockCrypto, zap.NewNop()), mockStorage, mockKeys, mockCrypto
+}
+
+// expectOrgKey sets up user 1 as a member of organisation 3 with role, whose sealed organisation key
+// of version 2 opens with the vault key "k1.dek".
+func expectOrgKey(mockStorage *MockOrgStorage, mockKeys *MockSharingStorage, mockCrypto *MockCryptoModule, role string) {
+	mockStorage.On("GetMember", int64(3), int64(1)).
+		Return(&entities.OrgMember{OrgID: 3, UserID: 1, Role: role, WrappedKey: "s1.org", KeyVersion: 2}, nil)
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("OpenSealedKey", "s1.org", "k1.private").Return("k1.org", nil)
+}
+
+func TestOrgService_Create(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto := newOrgTest()
+
+	mockKeys.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1, PublicKey: "x1.alice"}, nil)
+	mockCrypto.On("GenerateDataKey").Return("k1.org", nil)
+	mockCrypto.On("SealKey", "k1.org", "x1.alice").Return("s1.org", nil)
+	mockStorage.On("CreateOrg", "Team", int64(1), "s1.org").Return(&entities.Organisation{ID: 3, Name: "Team", Role: "owner"}, nil)
+
+	org, err := service.Create(context.Background(), dto.CreateOrgDTO{UserID: 1, Name: " Team "})
+
+	require.NoError(t, err)
+	assert.Equal(t, "owner", org.Role)
+
+	_, err = service.Create(context.Background(), dto.CreateOrgDTO{UserID: 1, Name: " "})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidOrg)
+	mockStorage.AssertNumberOfCalls(t, "CreateOrg", 1)
+}
+
+func TestOrgService_AddMember(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto := newOrgTest()
+
+	expectOrgKey(mockStorage, mockKeys, mockCrypto, entities.OrgRoleAdmin)
+	mockKeys.On("GetRecipient", "bob").Return(&entities.UserKeyPair{UserID: 2, PublicKey: "x1.bob"}, nil)
+	mockCrypto.On("SealKey", "k1.org", "x1.bob").Return("s1.bob", nil)
+	mockStorage.On("AddMember", entities.OrgMember{
+		OrgID:      3,
+		UserID:     2,
+		Username:   "bob",
+		Role:       "member",
+		WrappedKey: "s1.bob",
+		KeyVersion: 2,
+	}).Return(nil)
+
+	member, err := service.AddMember(context.Background(), dto.AddOrgMemberDTO{OrgID: 3, ActorID: 1, Key: "k1.dek", Username: "bob"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "member", member.Role, "Members should be added with the member role by default")
+	mockStorage.AssertExpectations(t)
+}
+
+func TestOrgService_AddMember_Rejected(t *testing.T) {
+	service, mockStorage, _, _ := newOrgTest()
+
+	mockStorage.On("GetMember", int64(3), int64(1)).Return(&entities.OrgMember{OrgID: 3, UserID: 1, Role: "admin"}, nil)
+	mockStorage.On("GetMember", int64(3), int64(4)).Return(&entities.OrgMember{OrgID: 3, UserID: 4, Role: "member"}, nil)
+	mockStorage.On("GetMember", int64(3), int64(5)).Return(nil, apperrors.ErrOrgMemberNotFound)
+
+	cases := []struct {
+		name string
+		body dto.AddOrgMemberDTO
+		err  error
+	}{
+		{"unknown role", dto.AddOrgMemberDTO{OrgID: 3, ActorID: 1, Username: "bob", Role: "guest"}, apperrors.ErrInvalidOrg},
+		{"owner by an admin", dto.AddOrgMemberDTO{OrgID: 3, ActorID: 1, Username: "bob", Role: "owner"}, apperrors.ErrOrgForbidden},
+		{"by a member", dto.AddOrgMemberDTO{OrgID: 3, ActorID: 4, Username: "bob"}, apperrors.ErrOrgForbidden},
+		{"by a non-member", dto.AddOrgMemberDTO{OrgID: 3, ActorID: 5, Username: "bob"}, apperrors.ErrOrgNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.AddMember(context.Background(), tc.body)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+	mockStorage.AssertNotCalled(t, "AddMember", mock.Anything)
+}
+
+func TestOrgService_UpdateMember_OwnerRequiresOwner(t *testing.T) {
+	service, mockStorage, _, _ := newOrgTest()
+
+	mockStorage.On("GetMember", int64(3), int64(1)).Return(&entities.OrgMember{OrgID: 3, UserID: 1, Role: "admin"}, nil)
+	mockStorage.On("GetMember", int64(3), int64(2)).Return(&entities.OrgMember{OrgID: 3, UserID: 2, Role: "owner"}, nil)
+	mockStorage.On("GetMember", int64(3), int64(4)).Return(&entities.OrgMember{OrgID: 3, UserID: 4, Role: "member"}, nil)
+	mockStorage.On("UpdateMemberRole", int64(3), int64(4), "read-only").Return(nil)
+
+	err := service.UpdateMember(context.Background(), dto.UpdateOrgMemberDTO{OrgID: 3, ActorID: 1, UserID: 2, Role: "member"})
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden, "Admins should not demote owners")
+
+	err = service.UpdateMember(context.Background(), dto.UpdateOrgMemberDTO{OrgID: 3, ActorID: 1, UserID: 4, Role: "owner"})
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden, "Admins should not promote owners")
+
+	err = service.UpdateMember(context.Background(), dto.UpdateOrgMemberDTO{OrgID: 3, ActorID: 1, UserID: 4, Role: "read-only"})
+	require.NoError(t, err)
+	mockStorage.AssertNumberOfCalls(t, "UpdateMemberRole", 1)
+}
+
+func TestOrgService_RemoveMember_RotatesOrgKey(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto := newOrgTest()
+
+	expectOrgKey(mockStorage, mockKeys, mockCrypto, entities.OrgRoleOwner)
+	mockStorage.On("GetMember", int64(3), int64(2)).Return(&entities.OrgMember{OrgID: 3, UserID: 2, Role: "admin"}, nil)
+	mockCrypto.On("GenerateDataKey").Return("k1.rotated", nil)
+	mockStorage.On("ListMembers", int64(3)).Return([]entities.OrgMember{
+		{OrgID: 3, UserID: 1, PublicKey: "x1.alice"},
+		{OrgID: 3, UserID: 2, PublicKey: "x1.bob"},
+		{OrgID: 3, UserID: 4, PublicKey: "x1.carol"},
+	}, nil)
+	mockCrypto.On("SealKey", "k1.rotated", "x1.alice").Return("s1.alice", nil)
+	mockCrypto.On("SealKey", "k1.rotated", "x1.carol").Return("s1.carol", nil)
+	mockStorage.On("ListOrgItems", int64(3)).Return([]entities.CollectionItem{
+		{ItemType: "notes", ItemID: 5, OwnerID: 1, WrappedKey: "w1.note"},
+		{ItemType: "cards", ItemID: 6, OwnerID: 2, WrappedKey: "w1.card"},
+	}, nil)
+	mockCrypto.On("UnwrapKey", "w1.note", "k1.org").Return("k1.note", nil)
+	mockCrypto.On("WrapKey", "k1.note", "k1.rotated").Return("w1.rotated", nil)
+	mockStorage.On("RemoveMember", dto.RotateOrgKeyDTO{
+		OrgID:      3,
+		UserID:     2,
+		KeyVersion: 2,
+		Members:    map[int64]string{1: "s1.alice", 4: "s1.carol"},
+		Items:      []dto.RewrappedKeyDTO{{ItemType: "notes", ItemID: 5, OldKey: "w1.note", NewKey: "w1.rotated"}},
+	}).Return(nil)
+
+	err := service.RemoveMember(context.Background(), dto.RemoveOrgMemberDTO{OrgID: 3, ActorID: 1, UserID: 2, Key: "k1.dek"})
+
+	require.NoError(t, err, "The removed member's items should be dropped and the others rewrapped")
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertNotCalled(t, "SealKey", "k1.rotated", "x1.bob")
+}
+
+func TestOrgService_RemoveMember_Forbidden(t *testing.T) {
+	service, mockStorage, _, _ := newOrgTest()
+
+	mockStorage.On("GetMember", int64(3), int64(1)).Return(&entities.OrgMember{OrgID: 3, UserID: 1, Role: "admin"}, nil)
+	mockStorage.On("GetMember", int64(3), int64(2)).Return(&entities.OrgMember{OrgID: 3, UserID: 2, Role: "owner"}, nil)
+	mockStorage.On("GetMember", int64(3), int64(4)).Return(&entities.OrgMember{OrgID: 3, UserID: 4, Role: "member"}, nil)
+
+	err := service.RemoveMember(context.Background(), dto.RemoveOrgMemberDTO{OrgID: 3, ActorID: 1, UserID: 2})
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden, "Admins should not remove owners")
+
+	err = service.RemoveMember(context.Background(), dto.RemoveOrgMemberDTO{OrgID: 3, ActorID: 4, UserID: 1})
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden, "Members should not remove others")
+	mockStorage.AssertNotCalled(t, "RemoveMember", mock.Anything)
+}
+
+func TestOrgService_AddItem_CreatesItemKey(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto := newOrgTest()
+
+	mockStorage.On("GetCollection", int64(7)).Return(&entities.Collection{ID: 7, OrgID: 3}, nil)
+	expectOrgKey(mockStorage, mockKeys, mockCrypto, entities.OrgRoleMember)
+	mockKeys.On("GetItemKey", "notes", int64(5)).Return(nil, apperrors.ErrItemKeyNotFound)
+	mockCrypto.On("GenerateDataKey").Return("k1.item", nil)
+	mockCrypto.On("WrapKey", "k1.item", "k1.dek").Return("w1.item", nil)
+	mockCrypto.On("WrapKey", "k1.item", "k1.org").Return("w1.org", nil)
+	title := cryptox.Binding{UserID: 1, ItemType: "notes", ItemID: 5, Field: "title"}
+	mockCrypto.On("DecryptBound", "dek_cipher", "k1.dek", title).Return("plain", nil)
+	mockCrypto.On("EncryptBound", "plain", "k1.item", title).Return("item_cipher", nil)
+	mockStorage.On("AddCollectionItem", dto.StoreCollectionItemDTO{
+		CollectionID: 7,
+		KeyVersion:   2,
+		OwnerID:      1,
+		ItemType:     "notes",
+		ItemID:       5,
+		WrappedKey:   "w1.org",
+		ItemKey:      "w1.item",
+		NewItemKey:   true,
+	}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText := args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+			text, err := reencryptText(dto.VaultFieldDTO{UserID: 1, Table: "notes", ItemID: 5, Column: "title"}, "dek_cipher")
+			assert.NoError(t, err)
+			assert.Equal(t, "item_cipher", text)
+		}).
+		Return(nil)
+
+	item, err := service.AddItem(context.Background(), dto.AddCollectionItemDTO{
+		CollectionID: 7,
+		ActorID:      1,
+		Key:          "k1.dek",
+		ItemType:     "note",
+		ItemID:       5,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "note", item.ItemType)
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestOrgService_AddItem_Rejected(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto := newOrgTest()
+
+	mockStorage.On("GetCollection", int64(7)).Return(&entities.Collection{ID: 7, OrgID: 3}, nil)
+	mockStorage.On("GetCollection", int64(8)).Return(&entities.Collection{ID: 8, OrgID: 9}, nil)
+	mockStorage.On("GetMember", int64(9), int64(1)).Return(nil, apperrors.ErrOrgMemberNotFound)
+	expectOrgKey(mockStorage, mockKeys, mockCrypto, entities.OrgRoleMember)
+	mockKeys.On("GetItemKey", "cards", int64(6)).Return(&entities.ItemKey{OwnerID: 2, WrappedKey: "w1.other"}, nil)
+
+	cases := []struct {
+		name string
+		body dto.AddCollectionItemDTO
+		err  error
+	}{
+		{"unknown type", dto.AddCollectionItemDTO{CollectionID: 7, ActorID: 1, Key: "k1.dek", ItemType: "wallet", ItemID: 5}, apperrors.ErrInvalidOrg},
+		{"other organisation", dto.AddCollectionItemDTO{CollectionID: 8, ActorID: 1, Key: "k1.dek", ItemType: "note", ItemID: 5}, apperrors.ErrCollectionNotFound},
+		{"other owner", dto.AddCollectionItemDTO{CollectionID: 7, ActorID: 1, Key: "k1.dek", ItemType: "card", ItemID: 6}, apperrors.ErrCollectionItemNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.AddItem(context.Background(), tc.body)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+	mockStorage.AssertNotCalled(t, "AddCollectionItem", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrgService_ListItems(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto := newOrgTest()
+
+	mockStorage.On("GetCollection", int64(7)).Return(&entities.Collection{ID: 7, OrgID: 3}, nil)
+	expectOrgKey(mockStorage, mockKeys, mockCrypto, entities.OrgRoleReadOnly)
+	login := entities.OrgItem{
+		Item:  entities.CollectionItem{CollectionID: 7, ItemType: "passwords", ItemID: 5, OwnerID: 2, WrappedKey: "w1.login"},
+		Plain: map[string]string{"app_name": "mail"},
+		Text:  map[string]string{"password": "password_cipher"},
+	}
+	stale := entities.OrgItem{
+		Item: entities.CollectionItem{CollectionID: 7, ItemType: "notes", ItemID: 6, OwnerID: 2, WrappedKey: "w1.stale"},
+		Text: map[string]string{"title": "title_cipher"},
+	}
+	mockStorage.On("ListCollectionItems", int64(7)).Return([]entities.OrgItem{login, stale}, nil)
+	mockCrypto.On("UnwrapKey", "w1.login", "k1.org").Return("k1.login", nil)
+	mockCrypto.On("UnwrapKey", "w1.stale", "k1.org").Return("", errors.New("cipher: message authentication failed"))
+	mockCrypto.On("DecryptBound", "password_cipher", "k1.login", cryptox.Binding{UserID: 2, ItemType: "passwords", ItemID: 5, Field: "password"}).
+		Return("secret", nil)
+
+	items, err := service.ListItems(context.Background(), 7, 1, "k1.dek")
+
+	require.NoError(t, err)
+	require.Len(t, items, 1, "Items that cannot be decrypted should be skipped")
+	assert.Equal(t, "logopass", items[0].ItemType)
+	assert.Equal(t, map[string]string{"app_name": "mail", "password": "secret"}, items[0].Fields)
+}
+
+func TestOrgService_UpdateItem(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto := newOrgTest()
+
+	mockStorage.On("GetCollection", int64(7)).Return(&entities.Collection{ID: 7, OrgID: 3}, nil)
+	expectOrgKey(mockStorage, mockKeys, mockCrypto, entities.OrgRoleMember)
+	mockStorage.On("GetCollectionItem", int64(7), "notes", int64(5)).Return(&entities.OrgItem{
+		Item: entities.CollectionItem{CollectionID: 7, ItemType: "notes", ItemID: 5, OwnerID: 2, WrappedKey: "w1.note"},
+		Text: map[string]string{"title": "title_cipher", "text_data": "text_cipher"},
+	}, nil)
+	mockCrypto.On("UnwrapKey", "w1.note", "k1.org").Return("k1.note", nil)
+	mockCrypto.On("EncryptBound", "new text", "k1.note", cryptox.Binding{UserID: 2, ItemType: "notes", ItemID: 5, Field: "text_data"}).
+		Return("new_cipher", nil)
+	mockStorage.On("UpdateCollectionItem", int64(7), "notes", int64(5), "w1.note", map[string]string{"text_data": "new_cipher"}, map[string][]byte{}).
+		Return(nil)
+
+	body := dto.UpdateCollectionItemDTO{CollectionID: 7, ActorID: 1, Key: "k1.dek", ItemType: "note", ItemID: 5}
+
+	body.Fields = map[string]string{"text_data": "new text"}
+	require.NoError(t, service.UpdateItem(context.Background(), body), "Members should change items of others")
+
+	body.Fields = map[string]string{"user_id": "3"}
+	assert.ErrorIs(t, service.UpdateItem(context.Background(), body), apperrors.ErrInvalidOrg)
+	mockStorage.AssertNumberOfCalls(t, "UpdateCollectionItem", 1)
+}
+
+func TestOrgService_ReadOnlyMember(t *testing.T) {
+	service, mockStorage, _, _ := newOrgTest()
+
+	mockStorage.On("GetCollection", int64(7)).Return(&entities.Collection{ID: 7, OrgID: 3}, nil)
+	mockStorage.On("GetMember", int64(3), int64(1)).Return(&entities.OrgMember{OrgID: 3, UserID: 1, Role: "read-only"}, nil)
+	mockStorage.On("GetCollectionItem", int64(7), "notes", int64(5)).Return(&entities.OrgItem{
+		Item: entities.CollectionItem{CollectionID: 7, ItemType: "notes", ItemID: 5, OwnerID: 2},
+	}, nil)
+
+	err := service.UpdateItem(context.Background(), dto.UpdateCollectionItemDTO{CollectionID: 7, ActorID: 1, ItemType: "note", ItemID: 5})
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden)
+
+	_, err = service.AddItem(context.Background(), dto.AddCollectionItemDTO{CollectionID: 7, ActorID: 1, ItemType: "note", ItemID: 6})
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden)
+
+	err = service.RemoveItem(context.Background(), 7, 1, "note", 5)
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden, "Only the owner of an item or an admin should remove it")
+
+	err = service.DeleteCollection(context.Background(), 7, 1)
+	assert.ErrorIs(t, err, apperrors.ErrOrgForbidden)
+
+	mockStorage.AssertNotCalled(t, "RemoveCollectionItem", mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "DeleteCollection", mock.Anything)
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"go.uber.org/zap"
+)
+
+// MaxRecoveryOfficers is the largest number of officers a recovery key can be split among.
+const MaxRecoveryOfficers = 10
+
+// RecoverySessionTTL is how long a recovery session collects shares before it expires.
+const RecoverySessionTTL = 24 * time.Hour
+
+// RecoveryShareService splits users' recovery keys among recovery officers with Shamir's secret
+// sharing, so that any threshold of the officers together can recover the account.
+//
+// Splitting issues a new recovery key that is never shown: each officer receives one share of it,
+// sealed to their public key. To recover the account, someone starts a recovery session and receives
+// its private session key; officers open their shares and submit them sealed to the session's public
+// key, and once enough shares are submitted the holder of the session key reconstructs the recovery
+// key and resets the master password as with Recover. The server never stores a share in the clear.
+type RecoveryShareService struct {
+	storage      RecoveryShareStorage
+	keys         RecoveryShareKeyStorage
+	accounts     RecoveryAccountService
+	cryptoModule CryptoModule
+	log          *zap.Logger
+}
+
+// RecoveryShareStorage defines database operations of recovery shares and recovery sessions.
+type RecoveryShareStorage interface {
+	// ReplaceRecoveryShares replaces a user's recovery key with one split into shares.
+	ReplaceRecoveryShares(ctx context.Context, ownerID int64, wrappedKey string, shares []entities.RecoveryShare) error
+	// ListSharesByOwner retrieves the shares of a user's recovery key.
+	ListSharesByOwner(ctx context.Context, ownerID int64) ([]entities.RecoveryShare, error)
+	// ListSharesByOfficer retrieves the recovery shares held by a user.
+	ListSharesByOfficer(ctx context.Context, officerID int64) ([]entities.RecoveryShare, error)
+	// GetOfficerShare retrieves the share of a user held by an officer; apperrors.ErrRecoveryShareNotFound if there is none.
+	GetOfficerShare(ctx context.Context, ownerID, officerID int64) (*entities.RecoveryShare, error)
+	// CreateRecoverySession starts a recovery session; apperrors.ErrRecoverySharesNotFound if the user has no shares.
+	CreateRecoverySession(ctx context.Context, username, publicKey string, expiresAt time.Time) (*entities.RecoverySession, error)
+	// GetRecoverySession retrieves an unexpired recovery session; apperrors.ErrRecoverySessionNotFound if there is none.
+	GetRecoverySession(ctx context.Context, id int64) (*entities.RecoverySession, error)
+	// AddRecoverySubmission stores a submitted share; apperrors.ErrRecoveryShareSubmitted if it was submitted before.
+	AddRecoverySubmission(ctx context.Context, submission entities.RecoverySubmission) error
+	// ListRecoverySubmissions retrieves the shares submitted to a session.
+	ListRecoverySubmissions(ctx context.Context, sessionID int64) ([]entities.RecoverySubmission, error)
+}
+
+// RecoveryShareKeyStorage defines the lookup of the key pairs of owners and officers.
+type RecoveryShareKeyStorage interface {
+	keyPairStorage
+	// GetRecipient retrieves the key pair of a user by username; apperrors.ErrShareRecipientNotFound if there is none.
+	GetRecipient(ctx context.Context, username string) (*entities.UserKeyPair, error)
+}
+
+// RecoveryAccountService defines the account operation completing a recovery.
+type RecoveryAccountService interface {
+	// Recover resets a forgotten master password with the recovery key.
+	Recover(ctx context.Context, body dto.RecoverAccountDTO) (*dto.GeneratedJwt, error)
+}
+
+// NewRecoveryShareService creates a new instance of RecoveryShareService.
+//
+// Parameters:
+//   - storage: An implementation of the RecoveryShareStorage interface.
+//   - keys: An implementation of the RecoveryShareKeyStorage interface.
+//   - accounts: A RecoveryAccountService, normally the UserService.
+//   - cryptoModule: An implementation of CryptoModule for splitting, wrapping and sealing keys.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to a RecoveryShareService instance.
+func NewRecoveryShareService(
+	storage RecoveryShareStorage,
+	keys RecoveryShareKeyStorage,
+	accounts RecoveryAccountService,
+	cryptoModule CryptoModule,
+	log *zap.Logger,
+) *RecoveryShareService {
+	return &RecoveryShareService{
+		storage:      storage,
+		keys:         keys,
+		accounts:     accounts,
+		cryptoModule: cryptoModule,
+		log:          log,
+	}
+}
+
+// Create replaces the recovery key of the authenticated user with a new one split among officers, any
+// threshold of whom can reconstruct it. The new recovery key is not returned; the previous recovery
+// key and its shares stop working. Recovery codes are kept.
+//
+// Parameters:
+//   - body: The user's ID and vault key, the threshold and the usernames of the officers.
+//
+// Returns:
+//   - The shares, without their secrets.
+//   - apperrors.ErrInvalidRecoveryShares for a threshold out of range or officers missing, repeated,
+//     more than MaxRecoveryOfficers or including the user, apperrors.ErrRecoveryOfficerNotFound if an
+//     officer does not exist or has no key pair, apperrors.ErrKeyPairNotFound if the user's key pair
+//     cannot be unwrapped with the vault key, or an error if storage fails.
+func (r *RecoveryShareService) Create(ctx context.Context, body dto.CreateRecoverySharesDTO) ([]entities.RecoveryShare, error) {
+	officers := make([]string, 0, len(body.Officers))
+	seen := make(map[string]bool, len(body.Officers))
+	for _, username := range body.Officers {
+		username = strings.TrimSpace(username)
+		if username == "" || seen[username] {
+			return nil, apperrors.ErrInvalidRecoveryShares
+		}
+		seen[username] = true
+		officers = append(officers, username)
+	}
+	if len(officers) > MaxRecoveryOfficers || body.Threshold < 2 || body.Threshold > len(officers) {
+		return nil, apperrors.ErrInvalidRecoveryShares
+	}
+
+	recipients := make([]*entities.UserKeyPair, 0, len(officers))
+	for _, username := range officers {
+		recipient, err := r.keys.GetRecipient(ctx, username)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrShareRecipientNotFound) {
+				return nil, apperrors.ErrRecoveryOfficerNotFound
+			}
+			return nil, err
+		}
+		if int64(recipient.UserID) == body.UserID {
+			return nil, apperrors.ErrInvalidRecoveryShares
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	// The recovery key wraps the vault key as given, so make sure it is the user's data key.
+	if _, err := openPrivateKey(ctx, r.keys, r.cryptoModule, r.log, body.UserID, body.Key); err != nil {
+		return nil, err
+	}
+
+	recoveryKey, wrapped, err := newRecoveryKey(r.cryptoModule, body.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	split, err := r.cryptoModule.SplitRecoveryKey(recoveryKey, len(recipients), body.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]entities.RecoveryShare, 0, len(recipients))
+	for i, recipient := range recipients {
+		sealed, err := r.cryptoModule.SealKey(split[i], recipient.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, entities.RecoveryShare{
+			OwnerID:     body.UserID,
+			OfficerID:   int64(recipient.UserID),
+			ShareIndex:  i + 1,
+			Threshold:   body.Threshold,
+			SealedShare: sealed,
+		})
+	}
+
+	if err := r.storage.ReplaceRecoveryShares(ctx, body.UserID, wrapped, shares); err != nil {
+		return nil, err
+	}
+
+	r.log.Info("recovery key split",
+		zap.Int64("userID", body.UserID), zap.Int("shares", len(shares)), zap.Int("threshold", body.Threshold))
+
+	return r.storage.ListSharesByOwner(ctx, body.UserID)
+}
+
+// List retrieves the shares of the authenticated user's recovery key.
+//
+// Parameters:
+//   - ownerID: The ID of the user.
+//
+// Returns:
+//   - The shares with their officers, or an error if retrieval fails.
+func (r *RecoveryShareService) List(ctx context.Context, ownerID int64) ([]entities.RecoveryShare, error) {
+	return r.storage.ListSharesByOwner(ctx, ownerID)
+}
+
+// ListHeld retrieves the recovery shares held by the authenticated user as an officer.
+//
+// Parameters:
+//   - officerID: The ID of the user.
+//
+// Returns:
+//   - The shares with their owners, or an error if retrieval fails.
+func (r *RecoveryShareService) ListHeld(ctx context.Context, officerID int64) ([]entities.RecoveryShare, error) {
+	return r.storage.ListSharesByOfficer(ctx, officerID)
+}
+
+// StartSession starts a recovery session of an account whose recovery key is split into shares. The
+// session's private key is returned once; it is needed to complete the recovery.
+//
+// Parameters:
+//   - username: The username of the account.
+//
+// Returns:
+//   - The session with its session key.
+//   - apperrors.ErrRecoverySharesNotFound if the user does not exist or has no shares, or an error if
+//     the session cannot be created.
+func (r *RecoveryShareService) StartSession(ctx context.Context, username string) (*dto.RecoverySessionDTO, error) {
+	publicKey, privateKey, err := r.cryptoModule.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := r.storage.CreateRecoverySession(ctx, strings.TrimSpace(username), publicKey, timeNow().Add(RecoverySessionTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	r.log.Info("recovery session started", zap.Int64("sessionID", session.ID), zap.Int64("userID", session.OwnerID))
+
+	return &dto.RecoverySessionDTO{
+		ID:         session.ID,
+		SessionKey: privateKey,
+		Threshold:  session.Threshold,
+		ExpiresAt:  session.ExpiresAt,
+	}, nil
+}
+
+// Submit opens the authenticated officer's share of the account a recovery session recovers and
+// stores it sealed to the session's public key.
+//
+// Parameters:
+//   - body: The session ID and the officer's ID and vault key.
+//
+// Returns:
+//   - The session with the number of shares submitted.
+//   - apperrors.ErrRecoverySessionNotFound, apperrors.ErrRecoveryShareNotFound if the officer holds no
+//     share of the account, apperrors.ErrKeyPairNotFound if the officer's key pair cannot be unwrapped
+//     with the vault key, apperrors.ErrKeyDerivation if the share cannot be opened,
+//     apperrors.ErrRecoveryShareSubmitted, or an error if storage fails.
+func (r *RecoveryShareService) Submit(ctx context.Context, body dto.SubmitRecoveryShareDTO) (*entities.RecoverySession, error) {
+	session, err := r.storage.GetRecoverySession(ctx, body.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := r.storage.GetOfficerShare(ctx, session.OwnerID, body.OfficerID)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := openPrivateKey(ctx, r.keys, r.cryptoModule, r.log, body.OfficerID, body.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	part, err := r.cryptoModule.OpenSealedKey(share.SealedShare, privateKey)
+	if err != nil {
+		r.log.Warn("open recovery share error", zap.Int64("shareID", share.ID), zap.Error(err))
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	sealed, err := r.cryptoModule.SealKey(part, session.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.storage.AddRecoverySubmission(ctx, entities.RecoverySubmission{
+		SessionID:   session.ID,
+		ShareIndex:  share.ShareIndex,
+		OfficerID:   body.OfficerID,
+		SealedShare: sealed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.log.Info("recovery share submitted",
+		zap.Int64("sessionID", session.ID), zap.Int64("userID", session.OwnerID), zap.Int64("officerID", body.OfficerID))
+
+	return r.storage.GetRecoverySession(ctx, session.ID)
+}
+
+// Complete reconstructs the recovery key from the shares submitted to a recovery session and resets
+// the master password of the account with it, as Recover does. Recover replaces the recovery key, so
+// the shares and sessions of the account are deleted and the new recovery key is returned instead; it
+// can be split again.
+//
+// Parameters:
+//   - body: The session ID, the session key and the new master password.
+//
+// Returns:
+//   - The tokens of the account, its data key and its new recovery key.
+//   - apperrors.ErrRecoverySessionNotFound, apperrors.ErrNotEnoughRecoveryShares,
+//     apperrors.ErrInvalidRecoveryKey if the session key is wrong or the shares do not reconstruct the
+//     recovery key, or an error if the reset fails.
+func (r *RecoveryShareService) Complete(ctx context.Context, body dto.CompleteRecoveryDTO) (*dto.GeneratedJwt, error) {
+	session, err := r.storage.GetRecoverySession(ctx, body.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions, err := r.storage.ListRecoverySubmissions(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(submissions) < session.Threshold {
+		return nil, apperrors.ErrNotEnoughRecoveryShares
+	}
+
+	parts := make(map[int]string, len(submissions))
+	for _, submission := range submissions {
+		part, err := r.cryptoModule.OpenSealedKey(submission.SealedShare, body.SessionKey)
+		if err != nil {
+			return nil, apperrors.ErrInvalidRecoveryKey
+		}
+		parts[submission.ShareIndex] = part
+	}
+
+	recoveryKey, err := r.cryptoModule.CombineRecoveryShares(parts)
+	if err != nil {
+		return nil, apperrors.ErrInvalidRecoveryKey
+	}
+
+	tokens, err := r.accounts.Recover(ctx, dto.RecoverAccountDTO{
+		Username:    session.OwnerUsername,
+		RecoveryKey: recoveryKey,
+		NewPassword: body.NewPassword,
+		DeviceDTO:   body.DeviceDTO,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.log.Info("account recovered with recovery shares", zap.Int64("sessionID", session.ID), zap.Int64("userID", session.OwnerID))
+
+	return tokens, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockRecoveryShareStorage struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryShareStorage) ReplaceRecoveryShares(ctx context.Context, ownerID int64, wrappedKey string, shares []entities.RecoveryShare) error {
+	args := m.Called(ownerID, wrappedKey, shares)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryShareStorage) ListSharesByOwner(ctx context.Context, ownerID int64) ([]entities.RecoveryShare, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]entities.RecoveryShare), args.Error(1)
+}
+
+func (m *MockRecoveryShareStorage) ListSharesByOfficer(ctx context.Context, officerID int64) ([]entities.RecoveryShare, error) {
+	args := m.Called(officerID)
+	return args.Get(0).([]entities.RecoveryShare), args.Error(1)
+}
+
+func (m *MockRecoveryShareStorage) GetOfficerShare(ctx context.Context, ownerID, officerID int64) (*entities.RecoveryShare, error) {
+	args := m.Called(ownerID, officerID)
+	if share, ok := args.Get(0).(*entities.RecoveryShare); ok {
+		return share, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecoveryShareStorage) CreateRecoverySession(ctx context.Context, username, publicKey string, expiresAt time.Time) (*entities.RecoverySession, error) {
+	args := m.Called(username, publicKey, expiresAt)
+	if session, ok := args.Get(0).(*entities.RecoverySession); ok {
+		return session, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecoveryShareStorage) GetRecoverySession(ctx context.Context, id int64) (*entities.RecoverySession, error) {
+	args := m.Called(id)
+	if session, ok := args.Get(0).(*entities.RecoverySession); ok {
+		return session, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecoveryShareStorage) AddRecoverySubmission(ctx context.Context, submission entities.RecoverySubmission) error {
+	args := m.Called(submission)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryShareStorage) ListRecoverySubmissions(ctx context.Context, sessionID int64) ([]entities.RecoverySubmission, error) {
+	args := m.Called(sessionID)
+	return args.Get(0).([]entities.RecoverySubmission), args.Error(1)
+}
+
+type MockRecoveryAccounts struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryAccounts) Recover(ctx context.Context, body dto.RecoverAccountDTO) (*dto.GeneratedJwt, error) {
+	args := m.Called(body)
+	if tokens, ok := args.Get(0).(*dto.GeneratedJwt); ok {
+		return tokens, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func newTestRecoveryShareService() (*RecoveryShareService, *MockRecoveryShareStorage, *MockSharingStorage, *MockCryptoModule, *MockRecoveryAccounts) {
+	mockStorage := new(MockRecoveryShareStorage)
+	mockKeys := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	mockAccounts := new(MockRecoveryAccounts)
+	service := NewRecoveryShareService(mockStorage, mockKeys, mockAccounts, mockCrypto, zap.NewNop())
+	return service, mockStorage, mockKeys, mockCrypto, mockAccounts
+}
+
+func TestRecoveryShareService_Create_SealsSharesToOfficers(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestRecoveryShareService()
+
+	mockKeys.On("GetRecipient", "bob").Return(&entities.UserKeyPair{UserID: 2, PublicKey: "x1.bob"}, nil)
+	mockKeys.On("GetRecipient", "carol").Return(&entities.UserKeyPair{UserID: 3, PublicKey: "x1.carol"}, nil)
+	mockKeys.On("GetRecipient", "dave").Return(&entities.UserKeyPair{UserID: 4, PublicKey: "x1.dave"}, nil)
+	expectKeyPair(mockKeys, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("GenerateRecoveryKey").Return("AAAA-BBBB", nil)
+	mockCrypto.On("RecoveryKEK", "AAAA-BBBB").Return("k1.rkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.rkek").Return("w1.recovery", nil)
+	mockCrypto.On("SplitRecoveryKey", "AAAA-BBBB", 3, 2).Return([]string{"k1.share1", "k1.share2", "k1.share3"}, nil)
+	mockCrypto.On("SealKey", "k1.share1", "x1.bob").Return("s1.bob", nil)
+	mockCrypto.On("SealKey", "k1.share2", "x1.carol").Return("s1.carol", nil)
+	mockCrypto.On("SealKey", "k1.share3", "x1.dave").Return("s1.dave", nil)
+	mockStorage.On("ReplaceRecoveryShares", int64(1), "w1.recovery", []entities.RecoveryShare{
+		{OwnerID: 1, OfficerID: 2, ShareIndex: 1, Threshold: 2, SealedShare: "s1.bob"},
+		{OwnerID: 1, OfficerID: 3, ShareIndex: 2, Threshold: 2, SealedShare: "s1.carol"},
+		{OwnerID: 1, OfficerID: 4, ShareIndex: 3, Threshold: 2, SealedShare: "s1.dave"},
+	}).Return(nil)
+	mockStorage.On("ListSharesByOwner", int64(1)).Return([]entities.RecoveryShare{{ID: 7, OfficerUsername: "bob"}}, nil)
+
+	shares, err := service.Create(context.Background(), dto.CreateRecoverySharesDTO{
+		UserID:    1,
+		Key:       "k1.dek",
+		Threshold: 2,
+		Officers:  []string{"bob", " carol", "dave"},
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, shares, 1)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestRecoveryShareService_Create_Rejected(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestRecoveryShareService()
+
+	mockKeys.On("GetRecipient", "alice").Return(&entities.UserKeyPair{UserID: 1, PublicKey: "x1.alice"}, nil)
+	mockKeys.On("GetRecipient", "bob").Return(&entities.UserKeyPair{UserID: 2, PublicKey: "x1.bob"}, nil)
+	mockKeys.On("GetRecipient", "carol").Return(nil, apperrors.ErrShareRecipientNotFound)
+
+	cases := []struct {
+		body dto.CreateRecoverySharesDTO
+		err  error
+	}{
+		{dto.CreateRecoverySharesDTO{Threshold: 1, Officers: []string{"bob", "carol"}}, apperrors.ErrInvalidRecoveryShares},
+		{dto.CreateRecoverySharesDTO{Threshold: 3, Officers: []string{"bob", "carol"}}, apperrors.ErrInvalidRecoveryShares},
+		{dto.CreateRecoverySharesDTO{Threshold: 2, Officers: []string{"bob", "bob"}}, apperrors.ErrInvalidRecoveryShares},
+		{dto.CreateRecoverySharesDTO{Threshold: 2, Officers: []string{"bob", ""}}, apperrors.ErrInvalidRecoveryShares},
+		{dto.CreateRecoverySharesDTO{Threshold: 2, Officers: []string{"bob", "alice"}}, apperrors.ErrInvalidRecoveryShares},
+		{dto.CreateRecoverySharesDTO{Threshold: 2, Officers: []string{"bob", "carol"}}, apperrors.ErrRecoveryOfficerNotFound},
+	}
+	for _, tc := range cases {
+		tc.body.UserID = 1
+		tc.body.Key = "k1.dek"
+		_, err := service.Create(context.Background(), tc.body)
+		assert.ErrorIs(t, err, tc.err, tc.body)
+	}
+
+	mockCrypto.AssertNotCalled(t, "GenerateRecoveryKey")
+	mockStorage.AssertNotCalled(t, "ReplaceRecoveryShares", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecoveryShareService_StartSession(t *testing.T) {
+	service, mockStorage, _, mockCrypto, _ := newTestRecoveryShareService()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	mockCrypto.On("GenerateKeyPair").Return("x1.session", "k1.session", nil)
+	mockStorage.On("CreateRecoverySession", "alice", "x1.session", now.Add(RecoverySessionTTL)).
+		Return(&entities.RecoverySession{ID: 9, OwnerID: 1, Threshold: 2, ExpiresAt: now.Add(RecoverySessionTTL)}, nil)
+	mockStorage.On("CreateRecoverySession", "bob", "x1.session", mock.Anything).Return(nil, apperrors.ErrRecoverySharesNotFound)
+
+	session, err := service.StartSession(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, dto.RecoverySessionDTO{ID: 9, SessionKey: "k1.session", Threshold: 2, ExpiresAt: now.Add(RecoverySessionTTL)}, *session)
+
+	_, err = service.StartSession(context.Background(), "bob")
+	assert.ErrorIs(t, err, apperrors.ErrRecoverySharesNotFound)
+}
+
+func TestRecoveryShareService_Submit_ResealsShareToSession(t *testing.T) {
+	service, mockStorage, mockKeys, mockCrypto, _ := newTestRecoveryShareService()
+
+	session := &entities.RecoverySession{ID: 9, OwnerID: 1, Threshold: 2, PublicKey: "x1.session"}
+	mockStorage.On("GetRecoverySession", int64(9)).Return(session, nil)
+	mockStorage.On("GetOfficerShare", int64(1), int64(2)).Return(&entities.RecoveryShare{ID: 7, ShareIndex: 3, SealedShare: "s1.bob"}, nil)
+	mockStorage.On("GetOfficerShare", int64(1), int64(5)).Return(nil, apperrors.ErrRecoveryShareNotFound)
+	expectKeyPair(mockKeys, mockCrypto, 2, "k2.dek")
+	mockCrypto.On("OpenSealedKey", "s1.bob", "k1.private").Return("k1.share3", nil)
+	mockCrypto.On("SealKey", "k1.share3", "x1.session").Return("s1.session", nil)
+	mockStorage.On("AddRecoverySubmission", entities.RecoverySubmission{SessionID: 9, ShareIndex: 3, OfficerID: 2, SealedShare: "s1.session"}).Return(nil)
+
+	_, err := service.Submit(context.Background(), dto.SubmitRecoveryShareDTO{SessionID: 9, OfficerID: 2, Key: "k2.dek"})
+	require.NoError(t, err)
+
+	_, err = service.Submit(context.Background(), dto.SubmitRecoveryShareDTO{SessionID: 9, OfficerID: 5, Key: "k5.dek"})
+	assert.ErrorIs(t, err, apperrors.ErrRecoveryShareNotFound)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestRecoveryShareService_Complete(t *testing.T) {
+	service, mockStorage, _, mockCrypto, mockAccounts := newTestRecoveryShareService()
+
+	mockStorage.On("GetRecoverySession", int64(9)).Return(&entities.RecoverySession{ID: 9, OwnerID: 1, OwnerUsername: "alice", Threshold: 2}, nil)
+	mockStorage.On("ListRecoverySubmissions", int64(9)).Return([]entities.RecoverySubmission{
+		{SessionID: 9, ShareIndex: 1, SealedShare: "s1.first"},
+		{SessionID: 9, ShareIndex: 3, SealedShare: "s1.third"},
+	}, nil)
+	mockCrypto.On("OpenSealedKey", "s1.first", "k1.session").Return("k1.share1", nil)
+	mockCrypto.On("OpenSealedKey", "s1.third", "k1.session").Return("k1.share3", nil)
+	mockCrypto.On("OpenSealedKey", mock.Anything, "k1.wrong").Return("", assert.AnError)
+	mockCrypto.On("CombineRecoveryShares", map[int]string{1: "k1.share1", 3: "k1.share3"}).Return("AAAA-BBBB", nil)
+	mockAccounts.On("Recover", dto.RecoverAccountDTO{Username: "alice", RecoveryKey: "AAAA-BBBB", NewPassword: "newpassword"}).
+		Return(&dto.GeneratedJwt{Hash: "k1.dek", RecoveryKey: "CCCC-DDDD"}, nil)
+
+	tokens, err := service.Complete(context.Background(), dto.CompleteRecoveryDTO{SessionID: 9, SessionKey: "k1.session", NewPassword: "newpassword"})
+	require.NoError(t, err)
+	assert.Equal(t, "CCCC-DDDD", tokens.RecoveryKey)
+
+	_, err = service.Complete(context.Background(), dto.CompleteRecoveryDTO{SessionID: 9, SessionKey: "k1.wrong", NewPassword: "newpassword"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRecoveryKey)
+	mockAccounts.AssertNumberOfCalls(t, "Recover", 1)
+}
+
+func TestRecoveryShareService_Complete_NotEnoughShares(t *testing.T) {
+	service, mockStorage, _, mockCrypto, _ := newTestRecoveryShareService()
+
+	mockStorage.On("GetRecoverySession", int64(9)).Return(&entities.RecoverySession{ID: 9, OwnerID: 1, OwnerUsername: "alice", Threshold: 2}, nil)
+	mockStorage.On("ListRecoverySubmissions", int64(9)).Return([]entities.RecoverySubmission{{SessionID: 9, ShareIndex: 1, SealedShare: "s1.first"}}, nil)
+
+	_, err := service.Complete(context.Background(), dto.CompleteRecoveryDTO{SessionID: 9, SessionKey: "k1.session", NewPassword: "newpassword"})
+
+	assert.ErrorIs(t, err, apperrors.ErrNotEnoughRecoveryShares)
+	mockCrypto.AssertNotCalled(t, "OpenSealedKey", mock.Anything, mock.Anything)
+}
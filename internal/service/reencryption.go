@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/config"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"go.uber.org/zap"
+)
+
+// Statuses of the re-encryption job.
+const (
+	ReencryptionRunning   = "running"
+	ReencryptionPaused    = "paused"
+	ReencryptionFailed    = "failed"
+	ReencryptionCompleted = "completed"
+)
+
+// reencryptionPhaseKeys is the first phase of the re-encryption job, walking the wrapped data keys.
+const reencryptionPhaseKeys = "user_keys"
+
+// reencryptionPhases lists the tables walked by the re-encryption job, in order.
+var reencryptionPhases = []string{
+	reencryptionPhaseKeys,
+	itemTypeBinary,
+	itemTypeLogoPass,
+	itemTypeCard,
+	itemTypeNote,
+	itemTypeBankAccount,
+	itemTypeIdentityDocument,
+}
+
+// Upper bounds of the re-encryption throttle.
+const (
+	maxReencryptionBatchSize    = 10000
+	maxReencryptionBatchDelayMS = 60000
+)
+
+// ReencryptionService runs the background key rotation and re-encryption job.
+//
+// Vault items are encrypted with per-user data keys that the server only sees while the user
+// is logged in, so the job splits the work in two. Data keys still wrapped with an old server
+// master key version are wrapped again right away, which needs no password. Vault rows holding
+// values in an older format or cipher, and data keys whose KDF parameters are outdated, can only
+// be upgraded with the owner's password or data key: their vaults are flagged and brought up to
+// date on the owner's next login (see UserService.Login).
+//
+// The job walks user_keys and then every vault table in batches of IDs, storing a checkpoint
+// after each batch, so it can be paused, resumed after a failure and survives restarts.
+// The batch size and the delay between batches throttle the load it puts on the database.
+type ReencryptionService struct {
+	log          *zap.Logger         // Logger for structured logging.
+	storage      ReencryptionStorage // Storage of job checkpoints and batches.
+	cryptoModule CryptoModule        // Cryptographic module re-wrapping keys and inspecting ciphertexts.
+	cfg          config.Config       // Application configuration holding the default throttle.
+	state        *reencryptionState  // The job running in this process; shared by copies of the service.
+}
+
+// reencryptionState tracks the job running in this process.
+type reencryptionState struct {
+	mu  sync.Mutex       // Guards run.
+	run *reencryptionRun // The running job, nil if none.
+}
+
+// reencryptionRun controls a job running in the background.
+type reencryptionRun struct {
+	cancel       context.CancelFunc // Pauses the job.
+	done         chan struct{}      // Closed once the job has stored its last checkpoint.
+	batchSize    int                // Current batch size, read before every batch.
+	batchDelayMS int                // Current delay between batches in milliseconds.
+}
+
+// ReencryptionStorage defines database operations of the re-encryption job.
+type ReencryptionStorage interface {
+	// GetLatestJob retrieves the latest job; apperrors.ErrReencryptionJobNotFound if there is none.
+	GetLatestJob(ctx context.Context) (*entities.ReencryptionJob, error)
+	// CreateJob inserts a new job.
+	CreateJob(ctx context.Context, job entities.ReencryptionJob) (*entities.ReencryptionJob, error)
+	// SaveJob stores the checkpoint of a job.
+	SaveJob(ctx context.Context, job entities.ReencryptionJob) error
+	// ListUserKeys retrieves up to limit wrapped data keys of users with an ID above afterUserID, in order.
+	ListUserKeys(ctx context.Context, afterUserID int64, limit int) ([]entities.UserKeys, error)
+	// ReplaceWrappedKey replaces a wrapped data key unless it changed since it was read.
+	ReplaceWrappedKey(ctx context.Context, userID int64, oldWrapped, newWrapped string) (bool, error)
+	// ListVaultRows retrieves up to limit rows of a vault table with an ID above afterID, in order.
+	ListVaultRows(ctx context.Context, table string, afterID int64, limit int) ([]dto.VaultRowDTO, error)
+	// FlagReencrypt flags the vaults of users for re-encryption on their next login.
+	FlagReencrypt(ctx context.Context, userIDs []int64) (int64, error)
+}
+
+// NewReencryptionService initializes and returns a new ReencryptionService instance.
+//
+// Parameters:
+//   - storage: Implementation of ReencryptionStorage interface.
+//   - cryptoModule: Cryptographic module re-wrapping keys with the current server master key.
+//   - cfg: Application configuration holding the default batch size and delay.
+//   - logger: Structured logger (zap.Logger).
+//
+// Returns:
+//   - A pointer to a fully initialized ReencryptionService instance.
+func NewReencryptionService(
+	storage ReencryptionStorage,
+	cryptoModule CryptoModule,
+	cfg config.Config,
+	logger *zap.Logger,
+) *ReencryptionService {
+	return &ReencryptionService{
+		log:          logger,
+		storage:      storage,
+		cryptoModule: cryptoModule,
+		cfg:          cfg,
+		state:        &reencryptionState{},
+	}
+}
+
+// Status returns the progress of the latest re-encryption job.
+//
+// Returns:
+//   - The job checkpoint, or apperrors.ErrReencryptionJobNotFound if the job was never started.
+func (s *ReencryptionService) Status(ctx context.Context) (*entities.ReencryptionJob, error) {
+	job, err := s.storage.GetLatestJob(ctx)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrReencryptionJobNotFound) {
+			return nil, err
+		}
+		s.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	return job, nil
+}
+
+// Start starts the re-encryption job in the background. A paused, failed or interrupted job is
+// resumed from its checkpoint; once the latest job has completed, a new one walks every table again.
+//
+// Parameters:
+//   - throttle: Batch size and delay; unset values keep those of the resumed job or the configured defaults.
+//
+// Returns:
+//   - The job as started.
+//   - apperrors.ErrInvalidThrottle, apperrors.ErrReencryptionJobRunning if the job already runs
+//     in this process, or an error if the checkpoint cannot be stored.
+func (s *ReencryptionService) Start(ctx context.Context, throttle dto.ReencryptionThrottleDTO) (*entities.ReencryptionJob, error) {
+	if err := validateThrottle(throttle); err != nil {
+		return nil, err
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	if s.state.run != nil {
+		return nil, apperrors.ErrReencryptionJobRunning
+	}
+
+	job, err := s.storage.GetLatestJob(ctx)
+	switch {
+	case errors.Is(err, apperrors.ErrReencryptionJobNotFound) || err == nil && job.Status == ReencryptionCompleted:
+		job, err = s.storage.CreateJob(ctx, entities.ReencryptionJob{
+			Status:       ReencryptionRunning,
+			Phase:        reencryptionPhases[0],
+			BatchSize:    s.cfg.ReencryptBatchSize,
+			BatchDelayMS: int(s.cfg.ReencryptBatchDelay / time.Millisecond),
+		})
+	case err == nil:
+		job.Status, job.Error = ReencryptionRunning, ""
+		err = s.storage.SaveJob(ctx, *job)
+	}
+	if err != nil {
+		s.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	applyThrottle(&job.BatchSize, &job.BatchDelayMS, throttle)
+
+	s.launch(*job)
+	s.log.Info("reencryption job started", zap.Int64("jobID", job.ID), zap.String("phase", job.Phase), zap.Int64("lastID", job.LastID))
+
+	return job, nil
+}
+
+// Resume resumes a job that was running when the server stopped. It is called on startup.
+//
+// Returns:
+//   - An error if the latest job cannot be read.
+func (s *ReencryptionService) Resume(ctx context.Context) error {
+	job, err := s.storage.GetLatestJob(ctx)
+	if errors.Is(err, apperrors.ErrReencryptionJobNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status != ReencryptionRunning {
+		return nil
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	if s.state.run == nil {
+		s.launch(*job)
+		s.log.Info("reencryption job resumed", zap.Int64("jobID", job.ID), zap.String("phase", job.Phase), zap.Int64("lastID", job.LastID))
+	}
+
+	return nil
+}
+
+// Pause stops the running job after its current batch; Start resumes it from there.
+//
+// Returns:
+//   - The paused job, or apperrors.ErrReencryptionJobNotRunning if no job runs in this process.
+func (s *ReencryptionService) Pause(ctx context.Context) (*entities.ReencryptionJob, error) {
+	s.state.mu.Lock()
+	run := s.state.run
+	s.state.mu.Unlock()
+
+	if run == nil {
+		return nil, apperrors.ErrReencryptionJobNotRunning
+	}
+
+	run.cancel()
+	<-run.done
+
+	return s.Status(ctx)
+}
+
+// Throttle changes the batch size and delay of the running job, from its next batch on.
+//
+// Parameters:
+//   - throttle: The new batch size and delay; unset values keep the current ones.
+//
+// Returns:
+//   - The throttle in effect.
+//   - apperrors.ErrInvalidThrottle, or apperrors.ErrReencryptionJobNotRunning if no job runs in this process.
+func (s *ReencryptionService) Throttle(throttle dto.ReencryptionThrottleDTO) (dto.ReencryptionThrottleDTO, error) {
+	if err := validateThrottle(throttle); err != nil {
+		return dto.ReencryptionThrottleDTO{}, err
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	run := s.state.run
+	if run == nil {
+		return dto.ReencryptionThrottleDTO{}, apperrors.ErrReencryptionJobNotRunning
+	}
+
+	applyThrottle(&run.batchSize, &run.batchDelayMS, throttle)
+
+	return dto.ReencryptionThrottleDTO{BatchSize: &run.batchSize, BatchDelayMS: &run.batchDelayMS}, nil
+}
+
+// launch runs the job in the background. The caller holds s.state.mu.
+func (s *ReencryptionService) launch(job entities.ReencryptionJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &reencryptionRun{
+		cancel:       cancel,
+		done:         make(chan struct{}),
+		batchSize:    job.BatchSize,
+		batchDelayMS: job.BatchDelayMS,
+	}
+	s.state.run = run
+
+	go func() {
+		defer close(run.done)
+
+		s.process(ctx, run, job)
+
+		s.state.mu.Lock()
+		if s.state.run == run {
+			s.state.run = nil
+		}
+		s.state.mu.Unlock()
+	}()
+}
+
+// process runs the job batch by batch until it completes, fails or is paused, storing a
+// checkpoint after every batch.
+func (s *ReencryptionService) process(ctx context.Context, run *reencryptionRun, job entities.ReencryptionJob) {
+	save := func() {
+		if err := s.storage.SaveJob(context.WithoutCancel(ctx), job); err != nil {
+			s.log.Error("save reencryption job error", zap.Int64("jobID", job.ID), zap.Error(err))
+		}
+	}
+
+	for {
+		s.state.mu.Lock()
+		job.BatchSize, job.BatchDelayMS = run.batchSize, run.batchDelayMS
+		s.state.mu.Unlock()
+
+		completed, err := s.processBatch(ctx, &job)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			job.Status = ReencryptionPaused
+		case err != nil:
+			job.Status, job.Error = ReencryptionFailed, err.Error()
+			s.log.Error("reencryption job failed", zap.Int64("jobID", job.ID), zap.String("phase", job.Phase), zap.Error(err))
+		case completed:
+			finishedAt := timeNow()
+			job.Status, job.FinishedAt = ReencryptionCompleted, &finishedAt
+			s.log.Info("reencryption job completed",
+				zap.Int64("jobID", job.ID),
+				zap.Int64("rewrapped", job.Rewrapped),
+				zap.Int64("flagged", job.Flagged),
+			)
+		}
+		if job.Status != ReencryptionRunning {
+			save()
+			return
+		}
+		save()
+
+		select {
+		case <-ctx.Done():
+			job.Status = ReencryptionPaused
+			save()
+			return
+		case <-time.After(time.Duration(job.BatchDelayMS) * time.Millisecond):
+		}
+	}
+}
+
+// processBatch processes the next batch of the current phase and moves to the next phase once
+// the current one is exhausted.
+//
+// Returns:
+//   - Whether the last phase is complete, or an error; the job is only advanced past the rows
+//     that were fully processed.
+func (s *ReencryptionService) processBatch(ctx context.Context, job *entities.ReencryptionJob) (bool, error) {
+	var (
+		n   int
+		err error
+	)
+
+	if job.Phase == reencryptionPhaseKeys {
+		n, err = s.rewrapKeys(ctx, job)
+	} else {
+		n, err = s.scanVault(ctx, job)
+	}
+	if err != nil {
+		return false, err
+	}
+	if n == job.BatchSize {
+		return false, nil
+	}
+
+	for i, phase := range reencryptionPhases {
+		if phase == job.Phase {
+			if i == len(reencryptionPhases)-1 {
+				return true, nil
+			}
+			job.Phase, job.LastID = reencryptionPhases[i+1], 0
+			return false, nil
+		}
+	}
+
+	return false, fmt.Errorf("unknown reencryption phase %q", job.Phase)
+}
+
+// rewrapKeys wraps a batch of data keys again with the current server master key version and
+// counts the keys whose KDF parameters are outdated.
+//
+// Returns:
+//   - The number of keys in the batch, or an error.
+func (s *ReencryptionService) rewrapKeys(ctx context.Context, job *entities.ReencryptionJob) (int, error) {
+	keys, err := s.storage.ListUserKeys(ctx, job.LastID, job.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, k := range keys {
+		rotated, err := s.cryptoModule.RotateWrappedKey(k.WrappedKey)
+		if err != nil {
+			return 0, fmt.Errorf("rewrap data key of user %d: %w", k.UserID, err)
+		}
+
+		if rotated != k.WrappedKey {
+			replaced, err := s.storage.ReplaceWrappedKey(ctx, int64(k.UserID), k.WrappedKey, rotated)
+			if err != nil {
+				return 0, err
+			}
+			if replaced {
+				job.Rewrapped++
+			}
+		}
+
+		if s.cryptoModule.KDFOutdated(k.KDF) {
+			job.KDFPending++
+		}
+
+		job.LastID = int64(k.UserID)
+		job.Scanned++
+	}
+
+	return len(keys), nil
+}
+
+// scanVault looks for values in an older format or cipher in a batch of rows of the current
+// vault table and flags their owners' vaults for re-encryption.
+//
+// Returns:
+//   - The number of rows in the batch, or an error.
+func (s *ReencryptionService) scanVault(ctx context.Context, job *entities.ReencryptionJob) (int, error) {
+	rows, err := s.storage.ListVaultRows(ctx, job.Phase, job.LastID, job.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	progress := *job
+	var owners []int64
+	seen := make(map[int64]bool)
+
+	for _, row := range rows {
+		if s.rowOutdated(row) {
+			progress.Outdated++
+			if !seen[row.UserID] {
+				seen[row.UserID] = true
+				owners = append(owners, row.UserID)
+			}
+		}
+
+		progress.LastID = row.ItemID
+		progress.Scanned++
+	}
+
+	if len(owners) > 0 {
+		flagged, err := s.storage.FlagReencrypt(ctx, owners)
+		if err != nil {
+			return 0, err
+		}
+		progress.Flagged += flagged
+	}
+
+	*job = progress
+
+	return len(rows), nil
+}
+
+// rowOutdated reports whether any value of a vault row should be re-encrypted.
+func (s *ReencryptionService) rowOutdated(row dto.VaultRowDTO) bool {
+	for _, value := range row.Text {
+		if s.cryptoModule.NeedsReencrypt(value) {
+			return true
+		}
+	}
+	for _, value := range row.Binary {
+		if s.cryptoModule.NeedsReencryptBinary(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateThrottle rejects a batch size or delay out of range.
+func validateThrottle(throttle dto.ReencryptionThrottleDTO) error {
+	if size := throttle.BatchSize; size != nil && (*size < 1 || *size > maxReencryptionBatchSize) {
+		return fmt.Errorf("%w: batch size must be between 1 and %d", apperrors.ErrInvalidThrottle, maxReencryptionBatchSize)
+	}
+	if delay := throttle.BatchDelayMS; delay != nil && (*delay < 0 || *delay > maxReencryptionBatchDelayMS) {
+		return fmt.Errorf("%w: batch delay must be between 0 and %d ms", apperrors.ErrInvalidThrottle, maxReencryptionBatchDelayMS)
+	}
+
+	return nil
+}
+
+// applyThrottle overrides a batch size and delay with the values set in throttle.
+func applyThrottle(batchSize, batchDelayMS *int, throttle dto.ReencryptionThrottleDTO) {
+	if throttle.BatchSize != nil {
+		*batchSize = *throttle.BatchSize
+	}
+	if throttle.BatchDelayMS != nil {
+		*batchDelayMS = *throttle.BatchDelayMS
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/config"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockReencryptionStorage struct {
+	mock.Mock
+}
+
+func (m *MockReencryptionStorage) GetLatestJob(ctx context.Context) (*entities.ReencryptionJob, error) {
+	args := m.Called()
+	if job, ok := args.Get(0).(*entities.ReencryptionJob); ok {
+		return job, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReencryptionStorage) CreateJob(ctx context.Context, job entities.ReencryptionJob) (*entities.ReencryptionJob, error) {
+	args := m.Called(job)
+	if created, ok := args.Get(0).(*entities.ReencryptionJob); ok {
+		return created, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReencryptionStorage) SaveJob(ctx context.Context, job entities.ReencryptionJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockReencryptionStorage) ListUserKeys(ctx context.Context, afterUserID int64, limit int) ([]entities.UserKeys, error) {
+	args := m.Called(afterUserID, limit)
+	if keys, ok := args.Get(0).([]entities.UserKeys); ok {
+		return keys, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReencryptionStorage) ReplaceWrappedKey(ctx context.Context, userID int64, oldWrapped, newWrapped string) (bool, error) {
+	args := m.Called(userID, oldWrapped, newWrapped)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReencryptionStorage) ListVaultRows(ctx context.Context, table string, afterID int64, limit int) ([]dto.VaultRowDTO, error) {
+	args := m.Called(table, afterID, limit)
+	if rows, ok := args.Get(0).([]dto.VaultRowDTO); ok {
+		return rows, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReencryptionStorage) FlagReencrypt(ctx context.Context, userIDs []int64) (int64, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// savedJobs returns the checkpoints stored through SaveJob, in order.
+func (m *MockReencryptionStorage) savedJobs() []entities.ReencryptionJob {
+	var jobs []entities.ReencryptionJob
+	for _, call := range m.Calls {
+		if call.Method == "SaveJob" {
+			jobs = append(jobs, call.Arguments.Get(0).(entities.ReencryptionJob))
+		}
+	}
+	return jobs
+}
+
+var testReencryptionConfig = config.Config{ReencryptBatchSize: 2}
+
+// runJob runs a job synchronously until it stops.
+func runJob(service *ReencryptionService, job entities.ReencryptionJob) {
+	service.process(context.Background(), &reencryptionRun{batchSize: job.BatchSize, batchDelayMS: job.BatchDelayMS}, job)
+}
+
+func TestReencryptionService_Process(t *testing.T) {
+	mockStorage := new(MockReencryptionStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewReencryptionService(mockStorage, mockCrypto, testReencryptionConfig, zap.NewNop())
+
+	mockStorage.On("ListUserKeys", int64(0), 2).Return([]entities.UserKeys{
+		{UserID: 1, KDF: "$argon2id$current", WrappedKey: "sw2.2.current"},
+		{UserID: 2, KDF: "$argon2id$weak", WrappedKey: "sw2.1.old"},
+	}, nil)
+	mockStorage.On("ListUserKeys", int64(2), 2).Return([]entities.UserKeys{}, nil)
+	mockCrypto.On("RotateWrappedKey", "sw2.2.current").Return("sw2.2.current", nil)
+	mockCrypto.On("RotateWrappedKey", "sw2.1.old").Return("sw2.2.rotated", nil)
+	mockCrypto.On("KDFOutdated", "$argon2id$current").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$weak").Return(true)
+	mockStorage.On("ReplaceWrappedKey", int64(2), "sw2.1.old", "sw2.2.rotated").Return(true, nil)
+
+	mockStorage.On("ListVaultRows", itemTypeBinary, int64(0), 2).Return([]dto.VaultRowDTO{
+		{UserID: 1, ItemID: 10, Text: []string{"e.current"}, Binary: [][]byte{[]byte("current")}},
+		{UserID: 2, ItemID: 11, Text: []string{"e.current"}, Binary: [][]byte{[]byte("legacy")}},
+	}, nil)
+	mockStorage.On("ListVaultRows", itemTypeBinary, int64(11), 2).Return([]dto.VaultRowDTO{
+		{UserID: 2, ItemID: 12, Text: []string{"a1.headerless"}, Binary: [][]byte{[]byte("current")}},
+	}, nil)
+	mockStorage.On("ListVaultRows", mock.Anything, int64(0), 2).Return([]dto.VaultRowDTO{}, nil)
+	mockCrypto.On("NeedsReencrypt", "e.current").Return(false)
+	mockCrypto.On("NeedsReencrypt", "a1.headerless").Return(true)
+	mockCrypto.On("NeedsReencryptBinary", []byte("current")).Return(false)
+	mockCrypto.On("NeedsReencryptBinary", []byte("legacy")).Return(true)
+	mockStorage.On("FlagReencrypt", []int64{2}).Return(int64(1), nil).Once()
+	mockStorage.On("FlagReencrypt", []int64{2}).Return(int64(0), nil).Once()
+	mockStorage.On("SaveJob", mock.Anything).Return(nil)
+
+	runJob(service, entities.ReencryptionJob{ID: 1, Status: ReencryptionRunning, Phase: reencryptionPhaseKeys, BatchSize: 2})
+
+	saved := mockStorage.savedJobs()
+	require.NotEmpty(t, saved)
+	assert.Equal(t, entities.ReencryptionJob{ID: 1, Status: ReencryptionRunning, Phase: reencryptionPhaseKeys, LastID: 2,
+		Scanned: 2, Rewrapped: 1, KDFPending: 1, BatchSize: 2}, saved[0], "A checkpoint should be stored after every batch")
+
+	last := saved[len(saved)-1]
+	assert.Equal(t, ReencryptionCompleted, last.Status)
+	assert.NotNil(t, last.FinishedAt)
+	assert.Equal(t, itemTypeIdentityDocument, last.Phase, "Every vault table should be walked")
+	assert.Equal(t, int64(5), last.Scanned)
+	assert.Equal(t, int64(1), last.Rewrapped)
+	assert.Equal(t, int64(1), last.KDFPending)
+	assert.Equal(t, int64(2), last.Outdated)
+	assert.Equal(t, int64(1), last.Flagged, "A user should only be flagged once")
+	mockStorage.AssertExpectations(t)
+}
+
+func TestReencryptionService_Process_Failure(t *testing.T) {
+	mockStorage := new(MockReencryptionStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewReencryptionService(mockStorage, mockCrypto, testReencryptionConfig, zap.NewNop())
+
+	mockStorage.On("ListVaultRows", itemTypeCard, int64(5), 2).Return([]dto.VaultRowDTO{
+		{UserID: 3, ItemID: 6, Text: []string{"legacy"}},
+	}, nil)
+	mockCrypto.On("NeedsReencrypt", "legacy").Return(true)
+	mockStorage.On("FlagReencrypt", []int64{3}).Return(int64(0), errors.New("connection reset"))
+	mockStorage.On("SaveJob", mock.Anything).Return(nil)
+
+	runJob(service, entities.ReencryptionJob{ID: 1, Status: ReencryptionRunning, Phase: itemTypeCard, LastID: 5, Scanned: 40, BatchSize: 2})
+
+	saved := mockStorage.savedJobs()
+	require.Len(t, saved, 1)
+	assert.Equal(t, ReencryptionFailed, saved[0].Status)
+	assert.Contains(t, saved[0].Error, "connection reset")
+	assert.Equal(t, int64(5), saved[0].LastID, "The checkpoint should not move past rows that were not processed")
+	assert.Equal(t, int64(40), saved[0].Scanned)
+	assert.Zero(t, saved[0].Outdated)
+}
+
+func TestReencryptionService_StartPause(t *testing.T) {
+	mockStorage := new(MockReencryptionStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewReencryptionService(mockStorage, mockCrypto, testReencryptionConfig, zap.NewNop())
+
+	delay := 60000
+	created := &entities.ReencryptionJob{ID: 1, Status: ReencryptionRunning, Phase: reencryptionPhaseKeys, BatchSize: 2}
+	mockStorage.On("GetLatestJob").Return(nil, apperrors.ErrReencryptionJobNotFound).Once()
+	mockStorage.On("CreateJob", entities.ReencryptionJob{Status: ReencryptionRunning, Phase: reencryptionPhaseKeys, BatchSize: 2}).Return(created, nil)
+	mockStorage.On("ListUserKeys", int64(0), 2).Return([]entities.UserKeys{
+		{UserID: 1, KDF: "$argon2id$current", WrappedKey: "w1.a"},
+		{UserID: 2, KDF: "$argon2id$current", WrappedKey: "w1.b"},
+	}, nil)
+	mockCrypto.On("RotateWrappedKey", "w1.a").Return("w1.a", nil)
+	mockCrypto.On("RotateWrappedKey", "w1.b").Return("w1.b", nil)
+	mockCrypto.On("KDFOutdated", "$argon2id$current").Return(false)
+
+	checkpoints := make(chan struct{}, 10)
+	mockStorage.On("SaveJob", mock.Anything).Run(func(mock.Arguments) { checkpoints <- struct{}{} }).Return(nil)
+
+	job, err := service.Start(context.Background(), dto.ReencryptionThrottleDTO{BatchDelayMS: &delay})
+	require.NoError(t, err)
+	assert.Equal(t, delay, job.BatchDelayMS, "The throttle should override the defaults")
+	<-checkpoints
+
+	_, err = service.Start(context.Background(), dto.ReencryptionThrottleDTO{})
+	assert.ErrorIs(t, err, apperrors.ErrReencryptionJobRunning)
+
+	size := 5
+	throttle, err := service.Throttle(dto.ReencryptionThrottleDTO{BatchSize: &size})
+	require.NoError(t, err)
+	assert.Equal(t, 5, *throttle.BatchSize)
+	assert.Equal(t, delay, *throttle.BatchDelayMS)
+
+	paused := &entities.ReencryptionJob{ID: 1, Status: ReencryptionPaused}
+	mockStorage.On("GetLatestJob").Return(paused, nil)
+
+	status, err := service.Pause(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, paused, status)
+
+	saved := mockStorage.savedJobs()
+	last := saved[len(saved)-1]
+	assert.Equal(t, ReencryptionPaused, last.Status)
+	assert.Equal(t, int64(2), last.LastID, "A paused job should resume after the last batch")
+
+	_, err = service.Pause(context.Background())
+	assert.ErrorIs(t, err, apperrors.ErrReencryptionJobNotRunning)
+	_, err = service.Throttle(dto.ReencryptionThrottleDTO{BatchSize: &size})
+	assert.ErrorIs(t, err, apperrors.ErrReencryptionJobNotRunning)
+}
+
+func TestReencryptionService_Start_InvalidThrottle(t *testing.T) {
+	service := NewReencryptionService(new(MockReencryptionStorage), new(MockCryptoModule), testReencryptionConfig, zap.NewNop())
+
+	zero, negative := 0, -1
+	_, err := service.Start(context.Background(), dto.ReencryptionThrottleDTO{BatchSize: &zero})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidThrottle)
+
+	_, err = service.Start(context.Background(), dto.ReencryptionThrottleDTO{BatchDelayMS: &negative})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidThrottle)
+}
+
+func TestReencryptionService_Status_NotFound(t *testing.T) {
+	mockStorage := new(MockReencryptionStorage)
+	service := NewReencryptionService(mockStorage, new(MockCryptoModule), testReencryptionConfig, zap.NewNop())
+
+	mockStorage.On("GetLatestJob").Return(nil, apperrors.ErrReencryptionJobNotFound)
+
+	_, err := service.Status(context.Background())
+	assert.ErrorIs(t, err, apperrors.ErrReencryptionJobNotFound)
+}
@@ -2,40 +2,125 @@
 package service
 
 import (
+	"time"
+
 	"github.com/Zrossiz/gophkeeper/internal/config"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
+	"github.com/Zrossiz/gophkeeper/pkg/passgen"
 	"go.uber.org/zap"
 )
 
 // Service aggregates all individual services responsible for managing different types of data.
 type Service struct {
-	User     UserService     // Handles user authentication and management.
-	LogoPass LogoPassService // Manages encrypted login-password storage.
-	Binary   BinaryService   // Manages encrypted binary file storage.
-	Card     CardService     // Handles encrypted card data storage.
-	Note     NoteService     // Manages encrypted note storage.
+	User             UserService             // Handles user authentication and management.
+	LogoPass         LogoPassService         // Manages encrypted login-password storage.
+	Binary           BinaryService           // Manages encrypted binary file storage.
+	Card             CardService             // Handles encrypted card data storage.
+	Note             NoteService             // Manages encrypted note storage.
+	BankAccount      BankAccountService      // Handles encrypted bank account storage.
+	IdentityDocument IdentityDocumentService // Handles encrypted identity document storage.
+	Generator        GeneratorService        // Generates passwords and passphrases.
+	Audit            AuditService            // Builds vault security reports.
+	Reencryption     ReencryptionService     // Runs the background key rotation and re-encryption job.
+	Sharing          SharingService          // Shares vault items with other users.
+	Org              OrgService              // Manages organisation vaults, their members and collections.
+	Emergency        EmergencyService        // Manages emergency contacts and their access to vaults.
+	RecoveryShare    RecoveryShareService    // Splits recovery keys among recovery officers.
+	Session          SessionService          // Lists, revokes and validates login sessions.
 }
 
 // Storage defines interfaces for data persistence layers corresponding to different services.
 type Storage struct {
-	Binary   BinaryStorage   // Interface for binary data storage operations.
-	User     UserStorage     // Interface for user data storage operations.
-	LogoPass LogoPassStorage // Interface for login-password storage operations.
-	Card     CardStorage     // Interface for card data storage operations.
-	Note     NoteStorage     // Interface for note storage operations.
+	Binary           BinaryStorage           // Interface for binary data storage operations.
+	User             UserStorage             // Interface for user data storage operations.
+	LogoPass         LogoPassStorage         // Interface for login-password storage operations.
+	Card             CardStorage             // Interface for card data storage operations.
+	Note             NoteStorage             // Interface for note storage operations.
+	BankAccount      BankAccountStorage      // Interface for bank account storage operations.
+	IdentityDocument IdentityDocumentStorage // Interface for identity document storage operations.
+	Vault            VaultStorage            // Interface for whole-vault re-encryption.
+	Reencryption     ReencryptionStorage     // Interface for the background re-encryption job.
+	Sharing          SharingStorage          // Interface for key pairs, item keys and shares of shared vault items.
+	Org              OrgStorage              // Interface for organisations, members and collections.
+	Emergency        EmergencyStorage        // Interface for emergency contacts and their access requests.
+	RecoveryShare    RecoveryShareStorage    // Interface for recovery shares and recovery sessions.
+	Session          SessionStorage          // Interface for the login sessions of users.
+	LoginAttempts    LoginAttemptStorage     // Interface for the failed logins of usernames; nil disables login lockout.
+}
+
+// timeNow returns the current time; it is a variable so that tests can pin the clock.
+var timeNow = time.Now
+
+// Item types bound into ciphertexts. They are the names of the tables the items are stored in,
+// which is how VaultStorage reports fields when re-encrypting a vault.
+const (
+	itemTypeCard             = "cards"
+	itemTypeLogoPass         = "passwords"
+	itemTypeNote             = "notes"
+	itemTypeBinary           = "binary_data"
+	itemTypeBankAccount      = "bank_accounts"
+	itemTypeIdentityDocument = "identity_documents"
+)
+
+// bind returns the binding of an encrypted field of a vault item; field is the name of its column.
+func bind(userID int64, itemType string, itemID int64, field string) cryptox.Binding {
+	return cryptox.Binding{UserID: userID, ItemType: itemType, ItemID: itemID, Field: field}
 }
 
 // CryptoModule defines an interface for cryptographic operations used throughout the services.
 type CryptoModule interface {
-	// Encrypt encrypts the given plaintext using the provided key.
-	Encrypt(plaintext, key string) (string, error)
-	// Decrypt decrypts the given encrypted text using the provided key.
-	Decrypt(encryptedText, key string) (string, error)
+	// EncryptBound encrypts the given plaintext using the provided key, bound to where it is stored.
+	EncryptBound(plaintext, key string, binding cryptox.Binding) (string, error)
+	// DecryptBound decrypts the given encrypted text using the provided key and the binding it was encrypted with.
+	DecryptBound(encryptedText, key string, binding cryptox.Binding) (string, error)
 	// GenerateSecretPhrase generates a secret phrase from the provided text.
 	GenerateSecretPhrase(txt string) string
-	// EncryptBinaryData encrypts binary data using the provided key.
-	EncryptBinaryData(plaintext []byte, key string) ([]byte, error)
-	// DecryptBinaryData decrypts binary data using the provided key.
-	DecryptBinaryData(encryptedData []byte, key string) ([]byte, error)
+	// EncryptBinaryDataBound encrypts binary data using the provided key, bound to where it is stored.
+	EncryptBinaryDataBound(plaintext []byte, key string, binding cryptox.Binding) ([]byte, error)
+	// DecryptBinaryDataBound decrypts binary data using the provided key and the binding it was encrypted with.
+	DecryptBinaryDataBound(encryptedData []byte, key string, binding cryptox.Binding) ([]byte, error)
+	// NewKDFParams generates fresh, salted key derivation parameters in their stored form.
+	NewKDFParams() (string, error)
+	// DeriveKey derives a key-encryption key from a secret and stored key derivation parameters.
+	DeriveKey(secret, params string) (string, error)
+	// GenerateDataKey generates a random data encryption key.
+	GenerateDataKey() (string, error)
+	// WrapKey encrypts a data key with a key-encryption key.
+	WrapKey(dataKey, kek string) (string, error)
+	// UnwrapKey decrypts a data key wrapped by WrapKey.
+	UnwrapKey(wrapped, kek string) (string, error)
+	// NeedsRewrap reports whether a wrapped data key is not bound to the current server master key version.
+	NeedsRewrap(wrapped string) bool
+	// RotateWrappedKey wraps a data key again with the current server master key version, without the user's password.
+	RotateWrappedKey(wrapped string) (string, error)
+	// KDFOutdated reports whether stored KDF parameters differ from the configured algorithm or cost.
+	KDFOutdated(params string) bool
+	// NeedsReencrypt reports whether a text ciphertext is not in the current format and cipher.
+	NeedsReencrypt(ciphertext string) bool
+	// NeedsReencryptBinary reports whether a binary ciphertext is not in the current format and cipher.
+	NeedsReencryptBinary(ciphertext []byte) bool
+	// GenerateRecoveryKey generates a random recovery key.
+	GenerateRecoveryKey() (string, error)
+	// RecoveryKEK derives the key-encryption key wrapping the data key from a recovery key.
+	RecoveryKEK(recoveryKey string) (string, error)
+	// GenerateRecoveryCode generates a random one-time recovery code.
+	GenerateRecoveryCode() (string, error)
+	// HashRecoveryCode returns the hash a recovery code is stored by.
+	HashRecoveryCode(code string) string
+	// SplitRecoveryKey splits a recovery key into shares, any threshold of which reconstruct it.
+	SplitRecoveryKey(recoveryKey string, shares, threshold int) ([]string, error)
+	// CombineRecoveryShares reconstructs a recovery key from its shares by x coordinate.
+	CombineRecoveryShares(shares map[int]string) (string, error)
+	// GenerateKeyPair generates an X25519 key pair used to share vault items.
+	GenerateKeyPair() (publicKey, privateKey string, err error)
+	// SealKey encrypts a key to a recipient's public key.
+	SealKey(key, publicKey string) (string, error)
+	// OpenSealedKey decrypts a key sealed by SealKey with the recipient's private key.
+	OpenSealedKey(sealed, privateKey string) (string, error)
+	// SealServerSecret prepares a secret the server must read without the user's password, e.g. a TOTP secret, for storage.
+	SealServerSecret(secret string) (string, error)
+	// OpenServerSecret returns a secret stored by SealServerSecret.
+	OpenServerSecret(stored string) (string, error)
 }
 
 // New initializes and returns a Service instance with all dependencies injected.
@@ -44,6 +129,7 @@ type CryptoModule interface {
 //   - store: A Storage instance containing implementations of various storage interfaces.
 //   - cfg: A configuration object containing application settings.
 //   - cryptoModule: An implementation of the CryptoModule interface for encryption and decryption.
+//   - breachChecker: An optional BreachChecker for the offline breached-password check; nil disables it.
 //   - logger: A structured logger (zap.Logger) for logging events.
 //
 // Returns:
@@ -52,13 +138,41 @@ func New(
 	store Storage,
 	cfg config.Config,
 	cryptoModule CryptoModule,
+	breachChecker BreachChecker,
 	logger *zap.Logger,
 ) *Service {
+	itemKeys := NewItemKeys(store.Sharing, cryptoModule, logger)
+	logoPass := NewLogoPassService(store.LogoPass, cryptoModule, breachChecker, itemKeys, logger)
+	card := NewCardService(store.Card, cryptoModule, itemKeys, logger)
+	note := NewNoteService(store.Note, cryptoModule, itemKeys, logger)
+	binary := NewBinaryService(store.Binary, cryptoModule, itemKeys, logger)
+	bankAccount := NewBankAccountService(store.BankAccount, cryptoModule, itemKeys, logger)
+	identityDocument := NewIdentityDocumentService(store.IdentityDocument, cryptoModule, itemKeys, logger)
+	user := NewUserService(store.User, store.Vault, cryptoModule, store.LoginAttempts, cfg, logger)
+	vault := EmergencyVaultSources{
+		LogoPass:         logoPass,
+		Card:             card,
+		Note:             note,
+		Binary:           binary,
+		BankAccount:      bankAccount,
+		IdentityDocument: identityDocument,
+	}
+
 	return &Service{
-		User:     *NewUserService(store.User, cryptoModule, cfg, logger),
-		Binary:   *NewBinaryService(store.Binary, cryptoModule, logger),
-		Card:     *NewCardService(store.Card, cryptoModule, logger),
-		LogoPass: *NewLogoPassService(store.LogoPass, cryptoModule, logger),
-		Note:     *NewNoteService(store.Note, cryptoModule, logger),
+		User:             *user,
+		Binary:           *binary,
+		Card:             *card,
+		LogoPass:         *logoPass,
+		Note:             *note,
+		BankAccount:      *bankAccount,
+		IdentityDocument: *identityDocument,
+		Generator:        *NewGeneratorService(passgen.New(), logoPass, logger),
+		Audit:            *NewAuditService(logoPass, card, logger),
+		Reencryption:     *NewReencryptionService(store.Reencryption, cryptoModule, cfg, logger),
+		Sharing:          *NewSharingService(store.Sharing, cryptoModule, logger),
+		Org:              *NewOrgService(store.Org, store.Sharing, cryptoModule, logger),
+		Emergency:        *NewEmergencyService(store.Emergency, store.Sharing, vault, user, cryptoModule, cfg, logger),
+		RecoveryShare:    *NewRecoveryShareService(store.RecoveryShare, store.Sharing, user, cryptoModule, logger),
+		Session:          *NewSessionService(store.Session, logger),
 	}
 }
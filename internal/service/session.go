@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"go.uber.org/zap"
+)
+
+// SessionService lists and revokes the sessions of a user and validates the session of every
+// authenticated request. Sessions are started by UserService on login.
+type SessionService struct {
+	storage SessionStorage
+	log     *zap.Logger
+}
+
+// SessionStorage defines database operations on the active sessions of a user.
+type SessionStorage interface {
+	// ListSessions retrieves the active sessions of a user.
+	ListSessions(ctx context.Context, userID int64) ([]entities.Session, error)
+	// TouchSession records the use of an active session; apperrors.ErrSessionNotFound if there is none.
+	TouchSession(ctx context.Context, userID int64, id string) error
+	// RevokeUserSession revokes an active session of a user; apperrors.ErrSessionNotFound if there is none.
+	RevokeUserSession(ctx context.Context, userID int64, id string) error
+}
+
+// NewSessionService creates a new instance of SessionService.
+//
+// Parameters:
+//   - storage: An implementation of the SessionStorage interface.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to a SessionService instance.
+func NewSessionService(storage SessionStorage, log *zap.Logger) *SessionService {
+	return &SessionService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+// List retrieves the active sessions of a user, marking the one the request was made with.
+//
+// Parameters:
+//   - userID: The ID of the authenticated user.
+//   - currentID: The ID of the session of the request.
+//
+// Returns:
+//   - The user's active sessions, most recently seen first.
+//   - apperrors.ErrDBQuery if the sessions cannot be retrieved.
+func (s *SessionService) List(ctx context.Context, userID int64, currentID string) ([]entities.Session, error) {
+	sessions, err := s.storage.ListSessions(ctx, userID)
+	if err != nil {
+		s.log.Error("list sessions error", zap.Int64("userID", userID), zap.Error(err))
+		return nil, apperrors.ErrDBQuery
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return sessions, nil
+}
+
+// Revoke revokes an active session of a user, e.g. of a lost device. Its access and refresh
+// tokens are not accepted anymore.
+//
+// Parameters:
+//   - userID: The ID of the authenticated user.
+//   - id: The ID of the session to revoke.
+//
+// Returns:
+//   - apperrors.ErrSessionNotFound if the user has no such active session, or apperrors.ErrDBQuery.
+func (s *SessionService) Revoke(ctx context.Context, userID int64, id string) error {
+	err := s.storage.RevokeUserSession(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return err
+		}
+		s.log.Error("revoke session error", zap.Int64("userID", userID), zap.Error(err))
+		return apperrors.ErrDBQuery
+	}
+
+	s.log.Info("session revoked", zap.Int64("userID", userID), zap.String("sessionID", id))
+
+	return nil
+}
+
+// Validate checks that the session of an access token is still active and records its use.
+//
+// Parameters:
+//   - userID: The ID of the user the access token was issued to.
+//   - id: The ID of the session, the jti claim of the access token.
+//
+// Returns:
+//   - apperrors.ErrSessionNotFound if the session was logged out, revoked or has expired, or apperrors.ErrDBQuery.
+func (s *SessionService) Validate(ctx context.Context, userID int64, id string) error {
+	err := s.storage.TouchSession(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return err
+		}
+		s.log.Error("touch session error", zap.Int64("userID", userID), zap.Error(err))
+		return apperrors.ErrDBQuery
+	}
+
+	return nil
+}
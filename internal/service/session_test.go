@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockSessionStorage struct {
+	mock.Mock
+}
+
+func (m *MockSessionStorage) ListSessions(ctx context.Context, userID int64) ([]entities.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entities.Session), args.Error(1)
+}
+
+func (m *MockSessionStorage) TouchSession(ctx context.Context, userID int64, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockSessionStorage) RevokeUserSession(ctx context.Context, userID int64, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func TestSessionService_List_MarksCurrentSession(t *testing.T) {
+	mockStorage := new(MockSessionStorage)
+	service := NewSessionService(mockStorage, zap.NewNop())
+
+	mockStorage.On("ListSessions", int64(1)).Return([]entities.Session{{ID: "phone"}, {ID: "laptop"}}, nil)
+
+	sessions, err := service.List(context.Background(), 1, "laptop")
+
+	require.NoError(t, err)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestSessionService_Revoke(t *testing.T) {
+	mockStorage := new(MockSessionStorage)
+	service := NewSessionService(mockStorage, zap.NewNop())
+
+	mockStorage.On("RevokeUserSession", int64(1), "phone").Return(nil)
+	mockStorage.On("RevokeUserSession", int64(1), "other").Return(apperrors.ErrSessionNotFound)
+
+	assert.NoError(t, service.Revoke(context.Background(), 1, "phone"))
+	assert.ErrorIs(t, service.Revoke(context.Background(), 1, "other"), apperrors.ErrSessionNotFound)
+}
+
+func TestSessionService_Validate(t *testing.T) {
+	mockStorage := new(MockSessionStorage)
+	service := NewSessionService(mockStorage, zap.NewNop())
+
+	mockStorage.On("TouchSession", int64(1), "active").Return(nil)
+	mockStorage.On("TouchSession", int64(1), "revoked").Return(apperrors.ErrSessionNotFound)
+	mockStorage.On("TouchSession", int64(1), "broken").Return(errors.New("connection reset"))
+
+	assert.NoError(t, service.Validate(context.Background(), 1, "active"))
+	assert.ErrorIs(t, service.Validate(context.Background(), 1, "revoked"), apperrors.ErrSessionNotFound)
+	assert.ErrorIs(t, service.Validate(context.Background(), 1, "broken"), apperrors.ErrDBQuery)
+}
@@ -0,0 +1,647 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"maps"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"go.uber.org/zap"
+)
+
+// sharedItemTypes maps the item types accepted by the sharing API to the tables the items are stored in.
+var sharedItemTypes = map[string]string{
+	"logopass": itemTypeLogoPass,
+	"card":     itemTypeCard,
+	"note":     itemTypeNote,
+	"binary":   itemTypeBinary,
+
+	"bank_account":      itemTypeBankAccount,
+	"identity_document": itemTypeIdentityDocument,
+}
+
+// ItemKeyStorage defines the lookup of the item keys of shared vault items.
+type ItemKeyStorage interface {
+	// GetItemKeys retrieves the item keys of the shared items of one type owned by a user, by item ID.
+	GetItemKeys(ctx context.Context, ownerID int64, itemType string) (map[int64]string, error)
+}
+
+// ItemKeys resolves the key each vault item is encrypted with for its owner. Items are encrypted with
+// their owner's data key until they are shared; a shared item is encrypted with its own item key,
+// stored wrapped by the owner's data key, so that the key can be handed to recipients.
+type ItemKeys struct {
+	storage      ItemKeyStorage
+	cryptoModule CryptoModule
+	log          *zap.Logger
+}
+
+// NewItemKeys creates a new instance of ItemKeys.
+//
+// Parameters:
+//   - storage: An implementation of the ItemKeyStorage interface.
+//   - cryptoModule: An implementation of CryptoModule unwrapping the item keys.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to an ItemKeys instance.
+func NewItemKeys(storage ItemKeyStorage, cryptoModule CryptoModule, log *zap.Logger) *ItemKeys {
+	return &ItemKeys{
+		storage:      storage,
+		cryptoModule: cryptoModule,
+		log:          log,
+	}
+}
+
+// resolve returns a function giving the key of each item of one type owned by a user: its item key
+// if it is shared, the owner's data key otherwise. An item key that cannot be unwrapped with dataKey
+// resolves to an empty key, so the item fails to decrypt like any other undecryptable item.
+// A nil ItemKeys resolves every item to dataKey.
+//
+// Returns:
+//   - The resolving function, or an error if the item keys cannot be retrieved.
+func (k *ItemKeys) resolve(ctx context.Context, ownerID int64, itemType, dataKey string) (func(itemID int64) string, error) {
+	if k == nil {
+		return func(int64) string { return dataKey }, nil
+	}
+
+	wrapped, err := k.storage.GetItemKeys(ctx, ownerID, itemType)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[int64]string, len(wrapped))
+	for itemID, wrappedKey := range wrapped {
+		key, err := k.cryptoModule.UnwrapKey(wrappedKey, dataKey)
+		if err != nil {
+			k.log.Warn("unwrap item key error", zap.String("itemType", itemType), zap.Int64("itemID", itemID), zap.Error(err))
+		}
+		keys[itemID] = key
+	}
+
+	return func(itemID int64) string {
+		if key, ok := keys[itemID]; ok {
+			return key
+		}
+		return dataKey
+	}, nil
+}
+
+// key returns the key of a single item owned by a user: its item key if it is shared, the owner's
+// data key otherwise. Unlike resolve, an item key that cannot be unwrapped with dataKey is an error,
+// since the key is also used to encrypt the item and an empty key must never be.
+//
+// Returns:
+//   - The key of the item, apperrors.ErrKeyDerivation if its item key cannot be unwrapped, or an
+//     error if the item keys cannot be retrieved.
+func (k *ItemKeys) key(ctx context.Context, ownerID int64, itemType string, itemID int64, dataKey string) (string, error) {
+	if k == nil {
+		return dataKey, nil
+	}
+
+	wrapped, err := k.storage.GetItemKeys(ctx, ownerID, itemType)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, ok := wrapped[itemID]
+	if !ok {
+		return dataKey, nil
+	}
+
+	key, err := k.cryptoModule.UnwrapKey(wrappedKey, dataKey)
+	if err != nil {
+		k.log.Warn("unwrap item key error", zap.String("itemType", itemType), zap.Int64("itemID", itemID), zap.Error(err))
+		return "", apperrors.ErrKeyDerivation
+	}
+
+	return key, nil
+}
+
+// SharingService shares vault items with other users.
+//
+// Every user has an X25519 key pair whose private key is wrapped by their data key. Sharing an item
+// for the first time re-encrypts it with a random item key, stored wrapped by the owner's data key;
+// the item key is then sealed to the public key of each recipient. The server only sees the item key
+// while the owner or a recipient is logged in. Revoking a share rotates the item key, so a revoked
+// recipient cannot decrypt the item again with a key they kept.
+type SharingService struct {
+	storage      SharingStorage
+	cryptoModule CryptoModule
+	log          *zap.Logger
+}
+
+// SharingStorage defines database operations of vault item sharing.
+type SharingStorage interface {
+	ItemKeyStorage
+	// GetKeyPair retrieves a user's key pair; apperrors.ErrKeyPairNotFound if there is none.
+	GetKeyPair(ctx context.Context, userID int64) (*entities.UserKeyPair, error)
+	// GetRecipient retrieves the key pair of a user by username; apperrors.ErrShareRecipientNotFound if there is none.
+	GetRecipient(ctx context.Context, username string) (*entities.UserKeyPair, error)
+	// GetItemKey retrieves the item key of a shared item; apperrors.ErrItemKeyNotFound if the item is not shared.
+	GetItemKey(ctx context.Context, itemType string, itemID int64) (*entities.ItemKey, error)
+	// CreateShare stores a share, re-encrypting the item with its new item key on its first share.
+	CreateShare(
+		ctx context.Context,
+		body dto.CreateShareDTO,
+		reencryptText func(dto.VaultFieldDTO, string) (string, error),
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+	) (*entities.ItemShare, error)
+	// GetShare retrieves a share by its ID; apperrors.ErrShareNotFound if it does not exist.
+	GetShare(ctx context.Context, shareID int64) (*entities.ItemShare, error)
+	// ListSharesByOwner retrieves the shares of every item owned by a user.
+	ListSharesByOwner(ctx context.Context, ownerID int64) ([]entities.ItemShare, error)
+	// ListItemShares retrieves the shares of one item with the recipients' public keys.
+	ListItemShares(ctx context.Context, itemType string, itemID int64) ([]entities.ItemShare, error)
+	// RevokeShare deletes a share and re-encrypts the item with a new item key or the owner's data key.
+	RevokeShare(
+		ctx context.Context,
+		body dto.RevokeShareDTO,
+		reencryptText func(dto.VaultFieldDTO, string) (string, error),
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+	) error
+	// ListSharedWith retrieves every item shared with a user, as stored.
+	ListSharedWith(ctx context.Context, recipientID int64) ([]entities.SharedItem, error)
+	// GetSharedWith retrieves an item shared with a user, as stored.
+	GetSharedWith(ctx context.Context, shareID, recipientID int64) (*entities.SharedItem, error)
+	// GetItemOrgMember retrieves the owner's membership of the organisation whose collection holds an
+	// item; apperrors.ErrCollectionItemNotFound if the item is not in a collection.
+	GetItemOrgMember(ctx context.Context, itemType string, itemID, ownerID int64) (*entities.OrgMember, error)
+	// UpdateSharedItem stores new ciphertexts of an item shared with write permission.
+	UpdateSharedItem(
+		ctx context.Context,
+		shareID, recipientID int64,
+		wrappedKey string,
+		text map[string]string,
+		binary map[string][]byte,
+	) error
+}
+
+// NewSharingService creates a new instance of SharingService.
+//
+// Parameters:
+//   - storage: An implementation of the SharingStorage interface.
+//   - cryptoModule: An implementation of CryptoModule for encryption, key wrapping and sealing.
+//   - log: A structured logger (zap.Logger) for logging events.
+//
+// Returns:
+//   - A pointer to a SharingService instance.
+func NewSharingService(storage SharingStorage, cryptoModule CryptoModule, log *zap.Logger) *SharingService {
+	return &SharingService{
+		storage:      storage,
+		cryptoModule: cryptoModule,
+		log:          log,
+	}
+}
+
+// Share shares an item of the authenticated user with another user, or changes the permission of an
+// existing share. On the first share of an item, it is re-encrypted with a new item key in the same
+// transaction as the share is stored.
+//
+// Parameters:
+//   - body: The owner's ID and vault key, the item type ("logopass", "card", "note", "binary",
+//     "bank_account" or "identity_document"), the item ID, the recipient's username and the permission ("read", the default, or "write").
+//
+// Returns:
+//   - The stored share.
+//   - apperrors.ErrInvalidShare for an unknown item type or permission or a share with oneself,
+//     apperrors.ErrKeyPairNotFound if the owner's key pair cannot be unwrapped with their vault key,
+//     apperrors.ErrShareRecipientNotFound, apperrors.ErrShareItemNotFound, or an error if storage fails.
+func (s *SharingService) Share(ctx context.Context, body dto.ShareItemDTO) (*entities.ItemShare, error) {
+	itemType, ok := sharedItemTypes[body.ItemType]
+	if !ok {
+		return nil, apperrors.ErrInvalidShare
+	}
+
+	if body.Permission == "" {
+		body.Permission = entities.SharePermissionRead
+	}
+	if body.Permission != entities.SharePermissionRead && body.Permission != entities.SharePermissionWrite {
+		return nil, apperrors.ErrInvalidShare
+	}
+
+	// The owner's private key is only unwrapped to make sure the vault key is their data key: an item
+	// key wrapped by a legacy vault key would be lost when the vault is migrated to a data key.
+	if _, err := s.privateKey(ctx, body.OwnerID, body.Key); err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.storage.GetRecipient(ctx, body.Username)
+	if err != nil {
+		return nil, err
+	}
+	if int64(recipient.UserID) == body.OwnerID {
+		return nil, apperrors.ErrInvalidShare
+	}
+
+	create := dto.CreateShareDTO{
+		OwnerID:     body.OwnerID,
+		RecipientID: int64(recipient.UserID),
+		ItemType:    itemType,
+		ItemID:      body.ItemID,
+		Permission:  body.Permission,
+	}
+
+	var (
+		itemKey string
+
+		reencryptText   func(dto.VaultFieldDTO, string) (string, error)
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error)
+	)
+
+	stored, err := s.storage.GetItemKey(ctx, itemType, body.ItemID)
+	switch {
+	case errors.Is(err, apperrors.ErrItemKeyNotFound):
+		if itemKey, err = s.cryptoModule.GenerateDataKey(); err != nil {
+			return nil, err
+		}
+		if create.ItemKey, err = s.cryptoModule.WrapKey(itemKey, body.Key); err != nil {
+			return nil, err
+		}
+		reencryptText, reencryptBinary = newReencryptors(s.cryptoModule, body.Key, itemKey)
+	case err != nil:
+		return nil, err
+	case stored.OwnerID != body.OwnerID:
+		return nil, apperrors.ErrShareItemNotFound
+	default:
+		if itemKey, err = s.cryptoModule.UnwrapKey(stored.WrappedKey, body.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	if create.WrappedKey, err = s.cryptoModule.SealKey(itemKey, recipient.PublicKey); err != nil {
+		return nil, err
+	}
+
+	share, err := s.storage.CreateShare(ctx, create, reencryptText, reencryptBinary)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("item shared",
+		zap.Int64("ownerID", share.OwnerID),
+		zap.Int64("recipientID", share.RecipientID),
+		zap.String("itemType", share.ItemType),
+		zap.Int64("itemID", share.ItemID),
+	)
+
+	return apiShare(*share), nil
+}
+
+// ListShares retrieves the shares of every item of the authenticated user.
+//
+// Parameters:
+//   - ownerID: The ID of the owner.
+//
+// Returns:
+//   - The shares, or an error if retrieval fails.
+func (s *SharingService) ListShares(ctx context.Context, ownerID int64) ([]entities.ItemShare, error) {
+	shares, err := s.storage.ListSharesByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.ItemShare, 0, len(shares))
+	for _, share := range shares {
+		result = append(result, *apiShare(share))
+	}
+
+	return result, nil
+}
+
+// Revoke revokes a share of an item of the authenticated user. The item is re-encrypted with a new
+// item key sealed again for the remaining recipients and wrapped by the organisation key if the item
+// is in a collection or, if neither remains, with the owner's data key, so the revoked recipient
+// cannot decrypt it again with the item key.
+//
+// Parameters:
+//   - ownerID: The ID of the owner.
+//   - shareID: The ID of the share.
+//   - key: The owner's vault key.
+//
+// Returns:
+//   - apperrors.ErrShareNotFound if the owner has no such share, apperrors.ErrKeyDerivation if the item
+//     key cannot be unwrapped with the vault key, apperrors.ErrOrgKeyChanged if the item's collection
+//     changed meanwhile, or an error if re-encryption or storage fails.
+func (s *SharingService) Revoke(ctx context.Context, ownerID, shareID int64, key string) error {
+	share, err := s.storage.GetShare(ctx, shareID)
+	if err != nil {
+		return err
+	}
+	if share.OwnerID != ownerID {
+		return apperrors.ErrShareNotFound
+	}
+
+	stored, err := s.storage.GetItemKey(ctx, share.ItemType, share.ItemID)
+	if err != nil {
+		return err
+	}
+
+	oldKey, err := s.cryptoModule.UnwrapKey(stored.WrappedKey, key)
+	if err != nil {
+		s.log.Warn("unwrap item key error", zap.Int64("shareID", shareID), zap.Error(err))
+		return apperrors.ErrKeyDerivation
+	}
+
+	shares, err := s.storage.ListItemShares(ctx, share.ItemType, share.ItemID)
+	if err != nil {
+		return err
+	}
+
+	revoke := dto.RevokeShareDTO{
+		ShareID:  share.ID,
+		OwnerID:  ownerID,
+		ItemType: share.ItemType,
+		ItemID:   share.ItemID,
+		Resealed: make(map[int64]string),
+	}
+
+	// An item in an organisation collection keeps its item key for the organisation.
+	member, err := s.storage.GetItemOrgMember(ctx, share.ItemType, share.ItemID, ownerID)
+	inCollection := err == nil
+	if err != nil && !errors.Is(err, apperrors.ErrCollectionItemNotFound) {
+		return err
+	}
+
+	newKey := key
+	if len(shares) > 1 || inCollection {
+		if newKey, err = s.cryptoModule.GenerateDataKey(); err != nil {
+			return err
+		}
+		if revoke.ItemKey, err = s.cryptoModule.WrapKey(newKey, key); err != nil {
+			return err
+		}
+		for _, other := range shares {
+			if other.ID == share.ID {
+				continue
+			}
+			if revoke.Resealed[other.ID], err = s.cryptoModule.SealKey(newKey, other.RecipientPublicKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	if inCollection {
+		privateKey, err := s.privateKey(ctx, ownerID, key)
+		if err != nil {
+			return err
+		}
+		orgKey, err := s.cryptoModule.OpenSealedKey(member.WrappedKey, privateKey)
+		if err != nil {
+			return err
+		}
+		if revoke.CollectionKey, err = s.cryptoModule.WrapKey(newKey, orgKey); err != nil {
+			return err
+		}
+		revoke.KeyVersion = member.KeyVersion
+	}
+
+	reencryptText, reencryptBinary := newReencryptors(s.cryptoModule, oldKey, newKey)
+	if err := s.storage.RevokeShare(ctx, revoke, reencryptText, reencryptBinary); err != nil {
+		return err
+	}
+
+	s.log.Info("item share revoked",
+		zap.Int64("ownerID", ownerID),
+		zap.Int64("recipientID", share.RecipientID),
+		zap.String("itemType", share.ItemType),
+		zap.Int64("itemID", share.ItemID),
+	)
+
+	return nil
+}
+
+// ListReceived retrieves and decrypts every item shared with the authenticated user. Items that
+// cannot be decrypted are logged and skipped.
+//
+// Parameters:
+//   - recipientID: The ID of the recipient.
+//   - key: The recipient's vault key.
+//
+// Returns:
+//   - The decrypted items; binary fields are base64-encoded.
+//   - apperrors.ErrKeyPairNotFound if the recipient's key pair cannot be unwrapped with their vault key,
+//     or an error if retrieval fails.
+func (s *SharingService) ListReceived(ctx context.Context, recipientID int64, key string) ([]dto.SharedItemDTO, error) {
+	privateKey, err := s.privateKey(ctx, recipientID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.storage.ListSharedWith(ctx, recipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.SharedItemDTO, 0, len(items))
+	for _, item := range items {
+		decrypted, err := s.decryptSharedItem(item, privateKey)
+		if err != nil {
+			s.log.Warn("decrypt shared item error", zap.Int64("shareID", item.Share.ID), zap.Error(err))
+			continue
+		}
+		result = append(result, *decrypted)
+	}
+
+	return result, nil
+}
+
+// UpdateReceived changes encrypted fields of an item shared with the authenticated user with write
+// permission. Values are stored as given, encrypted with the item key; binary fields are base64-encoded.
+//
+// Parameters:
+//   - body: The share ID, the recipient's ID and vault key, and the new values by field name.
+//
+// Returns:
+//   - apperrors.ErrShareNotFound, apperrors.ErrShareReadOnly, apperrors.ErrInvalidSharedField for a field
+//     the item has no encrypted column for, apperrors.ErrKeyPairNotFound, or an error if storage fails.
+func (s *SharingService) UpdateReceived(ctx context.Context, body dto.UpdateSharedItemDTO) error {
+	item, err := s.storage.GetSharedWith(ctx, body.ShareID, body.RecipientID)
+	if err != nil {
+		return err
+	}
+	if item.Share.Permission != entities.SharePermissionWrite {
+		return apperrors.ErrShareReadOnly
+	}
+
+	privateKey, err := s.privateKey(ctx, body.RecipientID, body.Key)
+	if err != nil {
+		return err
+	}
+
+	itemKey, err := s.cryptoModule.OpenSealedKey(item.Share.WrappedKey, privateKey)
+	if err != nil {
+		return err
+	}
+
+	share := item.Share
+	text, binary, err := encryptItemFields(s.cryptoModule, share.OwnerID, share.ItemType, share.ItemID, itemKey, body.Fields, item.Text, item.Binary)
+	if err != nil {
+		if errors.Is(err, errInvalidField) {
+			return apperrors.ErrInvalidSharedField
+		}
+		return err
+	}
+
+	return s.storage.UpdateSharedItem(ctx, share.ID, body.RecipientID, share.WrappedKey, text, binary)
+}
+
+// privateKey unwraps a user's private key with their vault key; see openPrivateKey.
+func (s *SharingService) privateKey(ctx context.Context, userID int64, key string) (string, error) {
+	return openPrivateKey(ctx, s.storage, s.cryptoModule, s.log, userID, key)
+}
+
+// decryptSharedItem opens the sealed item key of a shared item and decrypts its encrypted fields.
+func (s *SharingService) decryptSharedItem(item entities.SharedItem, privateKey string) (*dto.SharedItemDTO, error) {
+	share := item.Share
+
+	itemKey, err := s.cryptoModule.OpenSealedKey(share.WrappedKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := decryptItemFields(s.cryptoModule, share.OwnerID, share.ItemType, share.ItemID, itemKey, item.Plain, item.Text, item.Binary)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.SharedItemDTO{ItemShare: *apiShare(share), Fields: fields}, nil
+}
+
+// apiShare returns a share with its item type as accepted by the sharing API instead of its table name.
+func apiShare(share entities.ItemShare) *entities.ItemShare {
+	share.ItemType = apiItemType(share.ItemType)
+	return &share
+}
+
+// apiItemType returns the item type accepted by the sharing and organisation APIs for a table name.
+func apiItemType(table string) string {
+	for apiType, name := range sharedItemTypes {
+		if name == table {
+			return apiType
+		}
+	}
+
+	return table
+}
+
+// keyPairStorage defines the lookup of users' key pairs.
+type keyPairStorage interface {
+	GetKeyPair(ctx context.Context, userID int64) (*entities.UserKeyPair, error)
+}
+
+// openPrivateKey unwraps a user's private key with their vault key.
+//
+// Returns:
+//   - The private key, or apperrors.ErrKeyPairNotFound if the user has no key pair or it cannot be
+//     unwrapped with the vault key.
+func openPrivateKey(
+	ctx context.Context,
+	storage keyPairStorage,
+	cryptoModule CryptoModule,
+	log *zap.Logger,
+	userID int64,
+	key string,
+) (string, error) {
+	pair, err := storage.GetKeyPair(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	privateKey, err := cryptoModule.UnwrapKey(pair.WrappedPrivateKey, key)
+	if err != nil {
+		log.Warn("unwrap private key error", zap.Int64("userID", userID), zap.Error(err))
+		return "", apperrors.ErrKeyPairNotFound
+	}
+
+	return privateKey, nil
+}
+
+// errInvalidField is returned by encryptItemFields for a field the item has no encrypted column for.
+var errInvalidField = errors.New("invalid item field")
+
+// decryptItemFields decrypts the encrypted columns of an item with its item key and returns them with
+// its unencrypted columns, by column name; binary values are base64-encoded.
+func decryptItemFields(
+	cryptoModule CryptoModule,
+	ownerID int64,
+	itemType string,
+	itemID int64,
+	itemKey string,
+	plain, text map[string]string,
+	binary map[string][]byte,
+) (map[string]string, error) {
+	fields := maps.Clone(plain)
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+
+	var err error
+	for field, ciphertext := range text {
+		if ciphertext == "" {
+			fields[field] = ""
+			continue
+		}
+		if fields[field], err = cryptoModule.DecryptBound(ciphertext, itemKey, bind(ownerID, itemType, itemID, field)); err != nil {
+			return nil, err
+		}
+	}
+
+	for field, ciphertext := range binary {
+		plaintext, err := cryptoModule.DecryptBinaryDataBound(ciphertext, itemKey, bind(ownerID, itemType, itemID, field))
+		if err != nil {
+			return nil, err
+		}
+		fields[field] = base64.StdEncoding.EncodeToString(plaintext)
+	}
+
+	return fields, nil
+}
+
+// encryptItemFields encrypts new values of fields of an item with its item key. text and binary are
+// the item's encrypted columns as stored; binary values are base64-encoded.
+//
+// Returns:
+//   - The text and binary ciphertexts by column name, or errInvalidField for a field that is not an
+//     encrypted column or an invalid base64 value.
+func encryptItemFields(
+	cryptoModule CryptoModule,
+	ownerID int64,
+	itemType string,
+	itemID int64,
+	itemKey string,
+	fields, text map[string]string,
+	binary map[string][]byte,
+) (map[string]string, map[string][]byte, error) {
+	encryptedText := make(map[string]string)
+	encryptedBinary := make(map[string][]byte)
+
+	var err error
+	for field, value := range fields {
+		binding := bind(ownerID, itemType, itemID, field)
+
+		if _, ok := text[field]; ok {
+			if encryptedText[field], err = cryptoModule.EncryptBound(value, itemKey, binding); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if _, ok := binary[field]; ok {
+			data, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, nil, errInvalidField
+			}
+			if encryptedBinary[field], err = cryptoModule.EncryptBinaryDataBound(data, itemKey, binding); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		return nil, nil, errInvalidField
+	}
+
+	return encryptedText, encryptedBinary, nil
+}
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockSharingStorage struct {
+	mock.Mock
+}
+
+func (m *MockSharingStorage) GetItemKeys(ctx context.Context, ownerID int64, itemType string) (map[int64]string, error) {
+	args := m.Called(ownerID, itemType)
+	if keys, ok := args.Get(0).(map[int64]string); ok {
+		return keys, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) GetKeyPair(ctx context.Context, userID int64) (*entities.UserKeyPair, error) {
+	args := m.Called(userID)
+	if pair, ok := args.Get(0).(*entities.UserKeyPair); ok {
+		return pair, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) GetRecipient(ctx context.Context, username string) (*entities.UserKeyPair, error) {
+	args := m.Called(username)
+	if pair, ok := args.Get(0).(*entities.UserKeyPair); ok {
+		return pair, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) GetItemKey(ctx context.Context, itemType string, itemID int64) (*entities.ItemKey, error) {
+	args := m.Called(itemType, itemID)
+	if key, ok := args.Get(0).(*entities.ItemKey); ok {
+		return key, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) CreateShare(
+	ctx context.Context,
+	body dto.CreateShareDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) (*entities.ItemShare, error) {
+	args := m.Called(body, reencryptText, reencryptBinary)
+	if share, ok := args.Get(0).(*entities.ItemShare); ok {
+		return share, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) GetShare(ctx context.Context, shareID int64) (*entities.ItemShare, error) {
+	args := m.Called(shareID)
+	if share, ok := args.Get(0).(*entities.ItemShare); ok {
+		return share, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) ListSharesByOwner(ctx context.Context, ownerID int64) ([]entities.ItemShare, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]entities.ItemShare), args.Error(1)
+}
+
+func (m *MockSharingStorage) ListItemShares(ctx context.Context, itemType string, itemID int64) ([]entities.ItemShare, error) {
+	args := m.Called(itemType, itemID)
+	return args.Get(0).([]entities.ItemShare), args.Error(1)
+}
+
+func (m *MockSharingStorage) RevokeShare(
+	ctx context.Context,
+	body dto.RevokeShareDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) error {
+	args := m.Called(body, reencryptText, reencryptBinary)
+	return args.Error(0)
+}
+
+func (m *MockSharingStorage) ListSharedWith(ctx context.Context, recipientID int64) ([]entities.SharedItem, error) {
+	args := m.Called(recipientID)
+	return args.Get(0).([]entities.SharedItem), args.Error(1)
+}
+
+func (m *MockSharingStorage) GetSharedWith(ctx context.Context, shareID, recipientID int64) (*entities.SharedItem, error) {
+	args := m.Called(shareID, recipientID)
+	if item, ok := args.Get(0).(*entities.SharedItem); ok {
+		return item, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) GetItemOrgMember(ctx context.Context, itemType string, itemID, ownerID int64) (*entities.OrgMember, error) {
+	args := m.Called(itemType, itemID, ownerID)
+	if member, ok := args.Get(0).(*entities.OrgMember); ok {
+		return member, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingStorage) UpdateSharedItem(
+	ctx context.Context,
+	shareID, recipientID int64,
+	wrappedKey string,
+	text map[string]string,
+	binary map[string][]byte,
+) error {
+	args := m.Called(shareID, recipientID, wrappedKey, text, binary)
+	return args.Error(0)
+}
+
+// expectKeyPair sets up a key pair of userID whose private key unwraps with key.
+func expectKeyPair(mockStorage *MockSharingStorage, mockCrypto *MockCryptoModule, userID int64, key string) {
+	mockStorage.On("GetKeyPair", userID).Return(&entities.UserKeyPair{UserID: int(userID), WrappedPrivateKey: "w1.private"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.private", key).Return("k1.private", nil)
+}
+
+func TestSharingService_Share_FirstShareReencryptsItem(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	expectKeyPair(mockStorage, mockCrypto, 1, "k1.dek")
+	mockStorage.On("GetRecipient", "bob").Return(&entities.UserKeyPair{UserID: 2, PublicKey: "x1.bob"}, nil)
+	mockStorage.On("GetItemKey", "notes", int64(5)).Return(nil, apperrors.ErrItemKeyNotFound)
+	mockCrypto.On("GenerateDataKey").Return("k1.item", nil)
+	mockCrypto.On("WrapKey", "k1.item", "k1.dek").Return("w1.item", nil)
+	mockCrypto.On("SealKey", "k1.item", "x1.bob").Return("s1.bob", nil)
+	title := cryptox.Binding{UserID: 1, ItemType: "notes", ItemID: 5, Field: "title"}
+	mockCrypto.On("DecryptBound", "dek_cipher", "k1.dek", title).Return("plain", nil)
+	mockCrypto.On("EncryptBound", "plain", "k1.item", title).Return("item_cipher", nil)
+
+	mockStorage.On("CreateShare", dto.CreateShareDTO{
+		OwnerID:     1,
+		RecipientID: 2,
+		ItemType:    "notes",
+		ItemID:      5,
+		Permission:  "read",
+		WrappedKey:  "s1.bob",
+		ItemKey:     "w1.item",
+	}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText := args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+			text, err := reencryptText(dto.VaultFieldDTO{UserID: 1, Table: "notes", ItemID: 5, Column: "title"}, "dek_cipher")
+			assert.NoError(t, err)
+			assert.Equal(t, "item_cipher", text)
+		}).
+		Return(&entities.ItemShare{ID: 9, ItemType: "notes", ItemID: 5, OwnerID: 1, RecipientID: 2, Permission: "read"}, nil)
+
+	share, err := service.Share(context.Background(), dto.ShareItemDTO{
+		OwnerID:  1,
+		Key:      "k1.dek",
+		ItemType: "note",
+		ItemID:   5,
+		Username: "bob",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "note", share.ItemType, "The API item type should be returned")
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestSharingService_Share_ReusesItemKey(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	expectKeyPair(mockStorage, mockCrypto, 1, "k1.dek")
+	mockStorage.On("GetRecipient", "carol").Return(&entities.UserKeyPair{UserID: 3, PublicKey: "x1.carol"}, nil)
+	mockStorage.On("GetItemKey", "cards", int64(5)).Return(&entities.ItemKey{OwnerID: 1, WrappedKey: "w1.item"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.item", "k1.dek").Return("k1.item", nil)
+	mockCrypto.On("SealKey", "k1.item", "x1.carol").Return("s1.carol", nil)
+	mockStorage.On("CreateShare", dto.CreateShareDTO{
+		OwnerID:     1,
+		RecipientID: 3,
+		ItemType:    "cards",
+		ItemID:      5,
+		Permission:  "write",
+		WrappedKey:  "s1.carol",
+	}, mock.Anything, mock.Anything).Return(&entities.ItemShare{ID: 10, ItemType: "cards"}, nil)
+
+	_, err := service.Share(context.Background(), dto.ShareItemDTO{
+		OwnerID:    1,
+		Key:        "k1.dek",
+		ItemType:   "card",
+		ItemID:     5,
+		Username:   "carol",
+		Permission: "write",
+	})
+
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertNotCalled(t, "GenerateDataKey")
+}
+
+func TestSharingService_Share_Rejected(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	expectKeyPair(mockStorage, mockCrypto, 1, "k1.dek")
+	mockStorage.On("GetKeyPair", int64(4)).Return(&entities.UserKeyPair{UserID: 4, WrappedPrivateKey: "w1.legacy"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.legacy", "legacy").Return("", errors.New("cipher: message authentication failed"))
+	mockStorage.On("GetRecipient", "alice").Return(&entities.UserKeyPair{UserID: 1, PublicKey: "x1.alice"}, nil)
+
+	cases := []struct {
+		name string
+		body dto.ShareItemDTO
+		err  error
+	}{
+		{"unknown type", dto.ShareItemDTO{OwnerID: 1, Key: "k1.dek", ItemType: "wallet", Username: "bob"}, apperrors.ErrInvalidShare},
+		{"unknown permission", dto.ShareItemDTO{OwnerID: 1, Key: "k1.dek", ItemType: "note", Username: "bob", Permission: "admin"}, apperrors.ErrInvalidShare},
+		{"legacy vault key", dto.ShareItemDTO{OwnerID: 4, Key: "legacy", ItemType: "note", Username: "bob"}, apperrors.ErrKeyPairNotFound},
+		{"oneself", dto.ShareItemDTO{OwnerID: 1, Key: "k1.dek", ItemType: "note", Username: "alice"}, apperrors.ErrInvalidShare},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.Share(context.Background(), tc.body)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+	mockStorage.AssertNotCalled(t, "CreateShare", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSharingService_Revoke_LastShareReturnsToDataKey(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	share := entities.ItemShare{ID: 9, ItemType: "notes", ItemID: 5, OwnerID: 1, RecipientID: 2}
+	mockStorage.On("GetShare", int64(9)).Return(&share, nil)
+	mockStorage.On("GetItemKey", "notes", int64(5)).Return(&entities.ItemKey{OwnerID: 1, WrappedKey: "w1.item"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.item", "k1.dek").Return("k1.item", nil)
+	mockStorage.On("ListItemShares", "notes", int64(5)).Return([]entities.ItemShare{share}, nil)
+	mockStorage.On("GetItemOrgMember", "notes", int64(5), int64(1)).Return(nil, apperrors.ErrCollectionItemNotFound)
+	title := cryptox.Binding{UserID: 1, ItemType: "notes", ItemID: 5, Field: "title"}
+	mockCrypto.On("DecryptBound", "item_cipher", "k1.item", title).Return("plain", nil)
+	mockCrypto.On("EncryptBound", "plain", "k1.dek", title).Return("dek_cipher", nil)
+	mockStorage.On("RevokeShare", dto.RevokeShareDTO{
+		ShareID:  9,
+		OwnerID:  1,
+		ItemType: "notes",
+		ItemID:   5,
+		Resealed: map[int64]string{},
+	}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText := args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+			text, err := reencryptText(dto.VaultFieldDTO{UserID: 1, Table: "notes", ItemID: 5, Column: "title"}, "item_cipher")
+			assert.NoError(t, err)
+			assert.Equal(t, "dek_cipher", text)
+		}).
+		Return(nil)
+
+	err := service.Revoke(context.Background(), 1, 9, "k1.dek")
+
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestSharingService_Revoke_RotatesItemKey(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	share := entities.ItemShare{ID: 9, ItemType: "notes", ItemID: 5, OwnerID: 1, RecipientID: 2}
+	other := entities.ItemShare{ID: 10, ItemType: "notes", ItemID: 5, OwnerID: 1, RecipientID: 3, RecipientPublicKey: "x1.carol"}
+	mockStorage.On("GetShare", int64(9)).Return(&share, nil)
+	mockStorage.On("GetItemKey", "notes", int64(5)).Return(&entities.ItemKey{OwnerID: 1, WrappedKey: "w1.item"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.item", "k1.dek").Return("k1.item", nil)
+	mockStorage.On("ListItemShares", "notes", int64(5)).Return([]entities.ItemShare{share, other}, nil)
+	mockStorage.On("GetItemOrgMember", "notes", int64(5), int64(1)).Return(nil, apperrors.ErrCollectionItemNotFound)
+	mockCrypto.On("GenerateDataKey").Return("k1.rotated", nil)
+	mockCrypto.On("WrapKey", "k1.rotated", "k1.dek").Return("w1.rotated", nil)
+	mockCrypto.On("SealKey", "k1.rotated", "x1.carol").Return("s1.carol", nil)
+	mockStorage.On("RevokeShare", dto.RevokeShareDTO{
+		ShareID:  9,
+		OwnerID:  1,
+		ItemType: "notes",
+		ItemID:   5,
+		ItemKey:  "w1.rotated",
+		Resealed: map[int64]string{10: "s1.carol"},
+	}, mock.Anything, mock.Anything).Return(nil)
+
+	err := service.Revoke(context.Background(), 1, 9, "k1.dek")
+
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestSharingService_Revoke_RewrapsCollectionItemKey(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	share := entities.ItemShare{ID: 9, ItemType: "notes", ItemID: 5, OwnerID: 1, RecipientID: 2}
+	mockStorage.On("GetShare", int64(9)).Return(&share, nil)
+	mockStorage.On("GetItemKey", "notes", int64(5)).Return(&entities.ItemKey{OwnerID: 1, WrappedKey: "w1.item"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.item", "k1.dek").Return("k1.item", nil)
+	mockStorage.On("ListItemShares", "notes", int64(5)).Return([]entities.ItemShare{share}, nil)
+	mockStorage.On("GetItemOrgMember", "notes", int64(5), int64(1)).
+		Return(&entities.OrgMember{OrgID: 3, UserID: 1, WrappedKey: "s1.org", KeyVersion: 2}, nil)
+	mockCrypto.On("GenerateDataKey").Return("k1.rotated", nil)
+	mockCrypto.On("WrapKey", "k1.rotated", "k1.dek").Return("w1.rotated", nil)
+	expectKeyPair(mockStorage, mockCrypto, 1, "k1.dek")
+	mockCrypto.On("OpenSealedKey", "s1.org", "k1.private").Return("k1.org", nil)
+	mockCrypto.On("WrapKey", "k1.rotated", "k1.org").Return("w1.org", nil)
+	mockStorage.On("RevokeShare", dto.RevokeShareDTO{
+		ShareID:       9,
+		OwnerID:       1,
+		ItemType:      "notes",
+		ItemID:        5,
+		ItemKey:       "w1.rotated",
+		Resealed:      map[int64]string{},
+		CollectionKey: "w1.org",
+		KeyVersion:    2,
+	}, mock.Anything, mock.Anything).Return(nil)
+
+	err := service.Revoke(context.Background(), 1, 9, "k1.dek")
+
+	require.NoError(t, err, "An item in a collection should keep an item key after its last share is revoked")
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestSharingService_Revoke_OtherOwner(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	service := NewSharingService(mockStorage, new(MockCryptoModule), zap.NewNop())
+
+	mockStorage.On("GetShare", int64(9)).Return(&entities.ItemShare{ID: 9, OwnerID: 2}, nil)
+
+	err := service.Revoke(context.Background(), 1, 9, "k1.dek")
+
+	assert.ErrorIs(t, err, apperrors.ErrShareNotFound)
+	mockStorage.AssertNotCalled(t, "RevokeShare", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSharingService_ListReceived(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	expectKeyPair(mockStorage, mockCrypto, 2, "k1.bob")
+	file := entities.SharedItem{
+		Share:  entities.ItemShare{ID: 9, ItemType: "binary_data", ItemID: 5, OwnerID: 1, WrappedKey: "s1.file"},
+		Text:   map[string]string{"title": "title_cipher"},
+		Binary: map[string][]byte{"binary_data": []byte("blob_cipher")},
+	}
+	revoked := entities.SharedItem{
+		Share: entities.ItemShare{ID: 10, ItemType: "notes", ItemID: 6, OwnerID: 1, WrappedKey: "s1.stale"},
+		Text:  map[string]string{"title": "other_cipher"},
+	}
+	mockStorage.On("ListSharedWith", int64(2)).Return([]entities.SharedItem{file, revoked}, nil)
+	mockCrypto.On("OpenSealedKey", "s1.file", "k1.private").Return("k1.item", nil)
+	mockCrypto.On("OpenSealedKey", "s1.stale", "k1.private").Return("", errors.New("cipher: message authentication failed"))
+	mockCrypto.On("DecryptBound", "title_cipher", "k1.item", cryptox.Binding{UserID: 1, ItemType: "binary_data", ItemID: 5, Field: "title"}).
+		Return("report.pdf", nil)
+	mockCrypto.On("DecryptBinaryDataBound", []byte("blob_cipher"), "k1.item", cryptox.Binding{UserID: 1, ItemType: "binary_data", ItemID: 5, Field: "binary_data"}).
+		Return([]byte("blob"), nil)
+
+	items, err := service.ListReceived(context.Background(), 2, "k1.bob")
+
+	require.NoError(t, err)
+	require.Len(t, items, 1, "Items that cannot be decrypted should be skipped")
+	assert.Equal(t, "binary", items[0].ItemType)
+	assert.Equal(t, map[string]string{
+		"title":       "report.pdf",
+		"binary_data": base64.StdEncoding.EncodeToString([]byte("blob")),
+	}, items[0].Fields)
+}
+
+func TestSharingService_UpdateReceived(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewSharingService(mockStorage, mockCrypto, zap.NewNop())
+
+	item := entities.SharedItem{
+		Share: entities.ItemShare{ID: 9, ItemType: "notes", ItemID: 5, OwnerID: 1, Permission: "write", WrappedKey: "s1.note"},
+		Text:  map[string]string{"title": "title_cipher", "text_data": "text_cipher"},
+	}
+	mockStorage.On("GetSharedWith", int64(9), int64(2)).Return(&item, nil)
+	expectKeyPair(mockStorage, mockCrypto, 2, "k1.bob")
+	mockCrypto.On("OpenSealedKey", "s1.note", "k1.private").Return("k1.item", nil)
+	mockCrypto.On("EncryptBound", "new text", "k1.item", cryptox.Binding{UserID: 1, ItemType: "notes", ItemID: 5, Field: "text_data"}).
+		Return("new_cipher", nil)
+	mockStorage.On("UpdateSharedItem", int64(9), int64(2), "s1.note", map[string]string{"text_data": "new_cipher"}, map[string][]byte{}).
+		Return(nil)
+
+	err := service.UpdateReceived(context.Background(), dto.UpdateSharedItemDTO{
+		ShareID:     9,
+		RecipientID: 2,
+		Key:         "k1.bob",
+		Fields:      map[string]string{"text_data": "new text"},
+	})
+	require.NoError(t, err)
+
+	err = service.UpdateReceived(context.Background(), dto.UpdateSharedItemDTO{
+		ShareID:     9,
+		RecipientID: 2,
+		Key:         "k1.bob",
+		Fields:      map[string]string{"user_id": "3"},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSharedField)
+	mockStorage.AssertNumberOfCalls(t, "UpdateSharedItem", 1)
+}
+
+func TestSharingService_UpdateReceived_ReadOnly(t *testing.T) {
+	mockStorage := new(MockSharingStorage)
+	service := NewSharingService(mockStorage, new(MockCryptoModule), zap.NewNop())
+
+	mockStorage.On("GetSharedWith", int64(9), int64(2)).Return(&entities.SharedItem{
+		Share: entities.ItemShare{ID: 9, Permission: "read"},
+	}, nil)
+
+	err := service.UpdateReceived(context.Background(), dto.UpdateSharedItemDTO{ShareID: 9, RecipientID: 2, Fields: map[string]string{"title": "x"}})
+
+	assert.ErrorIs(t, err, apperrors.ErrShareReadOnly)
+}
+
+func TestCardService_Update_SharedCardWrongKey(t *testing.T) {
+	mockStorage := new(MockCardStorage)
+	mockSharing := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewCardService(mockStorage, mockCrypto, NewItemKeys(mockSharing, mockCrypto, zap.NewNop()), zap.NewNop())
+
+	mockSharing.On("GetItemKeys", int64(1), "cards").Return(map[int64]string{2: "w1.item"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.item", "k1.stale").Return("", assert.AnError)
+
+	err := service.Update(context.Background(), 2, dto.UpdateCardDTO{
+		UserID:         1,
+		Num:            "5555 5555 5555 4444",
+		CVV:            "321",
+		ExpDate:        "11/29",
+		CardHolderName: "Jane Doe",
+		Key:            "k1.stale",
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrKeyDerivation)
+	mockCrypto.AssertNotCalled(t, "EncryptBound", mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "UpdateCard", mock.Anything, mock.Anything)
+}
+
+func TestCardService_GetAll_DecryptsSharedCardWithItemKey(t *testing.T) {
+	mockStorage := new(MockCardStorage)
+	mockSharing := new(MockSharingStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewCardService(mockStorage, mockCrypto, NewItemKeys(mockSharing, mockCrypto, zap.NewNop()), zap.NewNop())
+
+	mockStorage.On("GetAllCardsByUserId", int64(1)).Return([]entities.Card{
+		{ID: 1, UserID: 1, Number: "n1", CVV: "c1", ExpDate: "e1", CardHolderName: "h1"},
+		{ID: 2, UserID: 1, Number: "n2", CVV: "c2", ExpDate: "e2", CardHolderName: "h2"},
+	}, nil)
+	mockSharing.On("GetItemKeys", int64(1), "cards").Return(map[int64]string{2: "w1.item"}, nil)
+	mockCrypto.On("UnwrapKey", "w1.item", "k1.dek").Return("k1.item", nil)
+	for _, c := range []struct {
+		id  int64
+		key string
+		n   string
+	}{{1, "k1.dek", "1"}, {2, "k1.item", "2"}} {
+		mockCrypto.On("DecryptBound", "n"+c.n, c.key, bind(1, "cards", c.id, "num")).Return("4111111111111111", nil)
+		mockCrypto.On("DecryptBound", "c"+c.n, c.key, bind(1, "cards", c.id, "cvv")).Return("123", nil)
+		mockCrypto.On("DecryptBound", "e"+c.n, c.key, bind(1, "cards", c.id, "exp_date")).Return("12/30", nil)
+		mockCrypto.On("DecryptBound", "h"+c.n, c.key, bind(1, "cards", c.id, "card_holder_name")).Return("ALICE", nil)
+	}
+
+	cards, failed, err := service.GetAllWithFailures(context.Background(), 1, "k1.dek")
+
+	require.NoError(t, err)
+	assert.Len(t, cards, 2, "Both the own and the shared card should be decrypted")
+	assert.Empty(t, failed)
+	mockCrypto.AssertExpectations(t)
+}
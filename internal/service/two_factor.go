@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/pkg/totp"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// totpIssuer is the service name authenticator apps show next to the account.
+const totpIssuer = "GophKeeper"
+
+// loginChallengeTTL is how long a login challenge waits for its second factor.
+const loginChallengeTTL = 5 * time.Minute
+
+// maxLoginChallengeAttempts is the number of codes that are checked for a login challenge before it
+// is dropped, so that a stolen password does not allow guessing TOTP codes.
+const maxLoginChallengeAttempts = 5
+
+// EnrollTOTP generates a new TOTP secret for a user. Two-factor authentication is only enabled once
+// ConfirmTOTP receives a first code of it; until then, enrolling again replaces the secret.
+//
+// Parameters:
+//   - userID: The ID of the authenticated user.
+//
+// Returns:
+//   - The secret and its otpauth URI, to be added to an authenticator app.
+//   - apperrors.ErrTwoFactorEnabled if two-factor authentication is already enabled, or an error if
+//     the secret cannot be generated or stored.
+func (u *UserService) EnrollTOTP(ctx context.Context, userID int64) (*dto.TOTPEnrollmentDTO, error) {
+	user, err := u.dbUser.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			return nil, err
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	stored, err := u.cryptoModule.SealServerSecret(secret)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	if err := u.dbUser.SaveTOTPSecret(ctx, userID, stored); err != nil {
+		if errors.Is(err, apperrors.ErrTwoFactorEnabled) {
+			return nil, err
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	return &dto.TOTPEnrollmentDTO{Secret: secret, URI: totp.URI(totpIssuer, user.Username, secret)}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication with the first code of a newly enrolled
+// authenticator. The recovery codes of the user's recovery kit replace a TOTP code when the
+// authenticator is lost.
+//
+// Parameters:
+//   - body: The ID of the authenticated user and the code shown by the authenticator.
+//
+// Returns:
+//   - apperrors.ErrTOTPNotFound if the user has not enrolled, apperrors.ErrTwoFactorEnabled if it is
+//     already enabled, apperrors.ErrInvalidTwoFactorCode if the code is wrong, or another error.
+func (u *UserService) ConfirmTOTP(ctx context.Context, body dto.TOTPCodeDTO) error {
+	stored, err := u.dbUser.GetTOTP(ctx, body.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTOTPNotFound) {
+			return err
+		}
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	if stored.EnabledAt != nil {
+		return apperrors.ErrTwoFactorEnabled
+	}
+
+	step, ok, err := u.validateTOTP(stored, body.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.ErrInvalidTwoFactorCode
+	}
+
+	if err := u.dbUser.EnableTOTP(ctx, body.UserID, step); err != nil {
+		if errors.Is(err, apperrors.ErrTwoFactorEnabled) || errors.Is(err, apperrors.ErrTOTPNotFound) {
+			return err
+		}
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	u.log.Info("two-factor authentication enabled", zap.Int64("userID", body.UserID))
+
+	return nil
+}
+
+// DisableTOTP turns two-factor authentication off. Both the master password and a second factor
+// are required, so that neither a stolen session nor a stolen password alone can turn it off.
+//
+// Parameters:
+//   - body: The ID of the authenticated user, their password and a TOTP or recovery code.
+//
+// Returns:
+//   - apperrors.ErrInvalidPassword if the password is wrong, apperrors.ErrTOTPNotFound if two-factor
+//     authentication is not enabled, apperrors.ErrInvalidTwoFactorCode if the code is wrong, or another error.
+func (u *UserService) DisableTOTP(ctx context.Context, body dto.DisableTwoFactorDTO) error {
+	user, err := u.dbUser.GetUserByID(ctx, body.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			return err
+		}
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)); err != nil {
+		return apperrors.ErrInvalidPassword
+	}
+
+	if err := u.verifySecondFactor(ctx, body.UserID, body.Code); err != nil {
+		return err
+	}
+
+	if err := u.dbUser.DeleteTOTP(ctx, body.UserID); err != nil {
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	u.log.Info("two-factor authentication disabled", zap.Int64("userID", body.UserID))
+
+	return nil
+}
+
+// LoginTwoFactor completes a login with two-factor authentication: the challenge returned by Login
+// is exchanged for a new session with a TOTP code or an unused recovery code. Every code is counted
+// before it is checked, and after maxLoginChallengeAttempts wrong codes the challenge is dropped and
+// the password must be entered again. Wrong codes count as failed logins of the user, and the failed
+// logins are only forgotten once the second factor is accepted, so that knowing the password does not
+// allow guessing codes without running into the login lockout.
+//
+// Parameters:
+//   - body: The challenge token, the code and the device the user logs in from.
+//
+// Returns:
+//   - A pointer to GeneratedJwt struct containing access and refresh tokens and the data key.
+//   - apperrors.ErrLoginChallengeNotFound if the challenge is unknown, expired or dropped,
+//     *apperrors.LoginLockedError if the user has to wait before the next login,
+//     apperrors.ErrInvalidTwoFactorCode if the code is wrong, or another error.
+func (u *UserService) LoginTwoFactor(ctx context.Context, body dto.TwoFactorLoginDTO) (*dto.GeneratedJwt, error) {
+	id, challengeKey, ok := strings.Cut(body.ChallengeToken, ".")
+	if !ok || id == "" || challengeKey == "" {
+		return nil, apperrors.ErrLoginChallengeNotFound
+	}
+
+	challenge, err := u.dbUser.GetLoginChallenge(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrLoginChallengeNotFound) {
+			return nil, err
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	key, err := u.cryptoModule.UnwrapKey(challenge.WrappedKey, challengeKey)
+	if err != nil {
+		return nil, apperrors.ErrLoginChallengeNotFound
+	}
+
+	user, err := u.dbUser.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	if err := u.checkLoginAllowed(ctx, user.Username); err != nil {
+		return nil, err
+	}
+
+	attempts, err := u.dbUser.AddLoginChallengeAttempt(ctx, challenge.ID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrLoginChallengeNotFound) {
+			return nil, err
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+	if attempts > maxLoginChallengeAttempts {
+		u.dropLoginChallenge(ctx, challenge)
+		return nil, apperrors.ErrLoginChallengeNotFound
+	}
+
+	if err := u.verifySecondFactor(ctx, challenge.UserID, body.Code); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidTwoFactorCode) {
+			u.loginFailed(ctx, user.Username)
+			if attempts == maxLoginChallengeAttempts {
+				u.dropLoginChallenge(ctx, challenge)
+			}
+		}
+		return nil, err
+	}
+
+	deleted, err := u.dbUser.DeleteLoginChallenge(ctx, challenge.ID)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+	if !deleted {
+		return nil, apperrors.ErrLoginChallengeNotFound
+	}
+
+	u.loginSucceeded(ctx, user.Username)
+
+	return u.generateTokens(ctx, user, key, body.DeviceDTO)
+}
+
+// twoFactorEnabled reports whether a user has two-factor authentication enabled.
+func (u *UserService) twoFactorEnabled(ctx context.Context, userID int64) (bool, error) {
+	stored, err := u.dbUser.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTOTPNotFound) {
+			return false, nil
+		}
+		u.log.Error(err.Error())
+		return false, apperrors.ErrDBQuery
+	}
+
+	return stored.EnabledAt != nil, nil
+}
+
+// startLoginChallenge stores a login of a user whose password was verified until the second factor
+// is provided. The data key is wrapped with a random one-time key that is only part of the returned
+// token, which is "<challenge ID>.<one-time key>".
+//
+// Returns:
+//   - The challenge token and its expiration time, or an error if the challenge cannot be stored.
+func (u *UserService) startLoginChallenge(ctx context.Context, user *entities.User, key string) (*dto.LoginChallengeDTO, error) {
+	id, err := newTokenID()
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrJWTGeneration
+	}
+
+	challengeKey, err := u.cryptoModule.GenerateDataKey()
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	wrapped, err := u.cryptoModule.WrapKey(key, challengeKey)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	challenge := entities.LoginChallenge{
+		ID:         id,
+		UserID:     int64(user.ID),
+		WrappedKey: wrapped,
+		ExpiresAt:  timeNow().Add(loginChallengeTTL),
+	}
+
+	if err := u.dbUser.CreateLoginChallenge(ctx, challenge); err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	return &dto.LoginChallengeDTO{Token: id + "." + challengeKey, ExpiresAt: challenge.ExpiresAt}, nil
+}
+
+// dropLoginChallenge deletes a login challenge that ran out of attempts. Failures are only logged:
+// the login is rejected either way.
+func (u *UserService) dropLoginChallenge(ctx context.Context, challenge *entities.LoginChallenge) {
+	u.log.Warn("login challenge dropped after too many wrong codes", zap.Int64("userID", challenge.UserID))
+	if _, err := u.dbUser.DeleteLoginChallenge(ctx, challenge.ID); err != nil {
+		u.log.Error(err.Error())
+	}
+}
+
+// verifySecondFactor checks a TOTP code, which must not have been used before, or else spends an
+// unused recovery code of the user's recovery kit, for a user with two-factor authentication enabled.
+//
+// Returns:
+//   - apperrors.ErrTOTPNotFound if two-factor authentication is not enabled,
+//     apperrors.ErrInvalidTwoFactorCode if the code is neither, or another error.
+func (u *UserService) verifySecondFactor(ctx context.Context, userID int64, code string) error {
+	stored, err := u.dbUser.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTOTPNotFound) {
+			return err
+		}
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	if stored.EnabledAt == nil {
+		return apperrors.ErrTOTPNotFound
+	}
+
+	step, ok, err := u.validateTOTP(stored, code)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		fresh, err := u.dbUser.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			u.log.Error(err.Error())
+			return apperrors.ErrDBQuery
+		}
+		if !fresh {
+			return apperrors.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	spent, err := u.dbUser.UseRecoveryCode(ctx, userID, u.cryptoModule.HashRecoveryCode(code))
+	if err != nil {
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+	if !spent {
+		return apperrors.ErrInvalidTwoFactorCode
+	}
+
+	u.log.Info("recovery code used as second factor", zap.Int64("userID", userID))
+
+	return nil
+}
+
+// validateTOTP checks a code against a stored TOTP secret at the current time.
+//
+// Returns:
+//   - The time step of the code and whether it is valid, or apperrors.ErrKeyDerivation if the secret
+//     cannot be read.
+func (u *UserService) validateTOTP(stored *entities.UserTOTP, code string) (int64, bool, error) {
+	secret, err := u.cryptoModule.OpenServerSecret(stored.Secret)
+	if err != nil {
+		u.log.Error(err.Error())
+		return 0, false, apperrors.ErrKeyDerivation
+	}
+
+	step, ok, err := totp.Validate(secret, code, timeNow())
+	if err != nil {
+		u.log.Error(err.Error())
+		return 0, false, apperrors.ErrKeyDerivation
+	}
+
+	return step, ok, nil
+}
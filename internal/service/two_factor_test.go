@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testTOTPSecret is the TOTP secret of the two-factor tests, stored as "ps1.totp".
+var testTOTPSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// pinTOTPTime pins timeNow and returns the current TOTP step and its code.
+func pinTOTPTime(t *testing.T) (int64, string) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	step := totp.Step(now)
+	code, err := totp.Code(testTOTPSecret, step)
+	require.NoError(t, err)
+
+	return step, code
+}
+
+// enabledTOTP returns the stored TOTP secret of a user with two-factor authentication enabled.
+func enabledTOTP(userID int64) *entities.UserTOTP {
+	enabledAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &entities.UserTOTP{UserID: userID, Secret: "ps1.totp", EnabledAt: &enabledAt}
+}
+
+func TestUserService_EnrollTOTP(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice"}, nil)
+	mockCrypto.On("SealServerSecret", mock.AnythingOfType("string")).Return("ps1.sealed", nil)
+	mockStorage.On("SaveTOTPSecret", int64(1), "ps1.sealed").Return(nil).Once()
+
+	enrollment, err := service.EnrollTOTP(context.Background(), 1)
+	require.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/GophKeeper:alice?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+	mockCrypto.AssertCalled(t, "SealServerSecret", enrollment.Secret)
+
+	mockStorage.On("SaveTOTPSecret", int64(1), "ps1.sealed").Return(apperrors.ErrTwoFactorEnabled)
+
+	_, err = service.EnrollTOTP(context.Background(), 1)
+	assert.ErrorIs(t, err, apperrors.ErrTwoFactorEnabled)
+}
+
+func TestUserService_ConfirmTOTP(t *testing.T) {
+	step, code := pinTOTPTime(t)
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetTOTP", int64(1)).Return(&entities.UserTOTP{UserID: 1, Secret: "ps1.totp"}, nil)
+	mockCrypto.On("OpenServerSecret", "ps1.totp").Return(testTOTPSecret, nil)
+	mockStorage.On("EnableTOTP", int64(1), step).Return(nil)
+
+	err := service.ConfirmTOTP(context.Background(), dto.TOTPCodeDTO{UserID: 1, Code: "000000"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidTwoFactorCode)
+	mockStorage.AssertNotCalled(t, "EnableTOTP", mock.Anything, mock.Anything)
+
+	err = service.ConfirmTOTP(context.Background(), dto.TOTPCodeDTO{UserID: 1, Code: code})
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertNotCalled(t, "GenerateRecoveryCode")
+}
+
+func TestUserService_ConfirmTOTP_AlreadyEnabled(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetTOTP", int64(1)).Return(enabledTOTP(1), nil)
+	mockStorage.On("GetTOTP", int64(2)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	err := service.ConfirmTOTP(context.Background(), dto.TOTPCodeDTO{UserID: 1, Code: "123456"})
+	assert.ErrorIs(t, err, apperrors.ErrTwoFactorEnabled)
+
+	err = service.ConfirmTOTP(context.Background(), dto.TOTPCodeDTO{UserID: 2, Code: "123456"})
+	assert.ErrorIs(t, err, apperrors.ErrTOTPNotFound)
+}
+
+// newTwoFactorLoginService returns a UserService whose user alice has two-factor authentication
+// enabled and the data key "k1.dek".
+func newTwoFactorLoginService(t *testing.T) (*UserService, *MockUserStorage, *MockCryptoModule) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "password123")}, nil)
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice"}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockStorage.On("GetTOTP", int64(1)).Return(enabledTOTP(1), nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "w1.stored").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$stored").Return(false)
+	mockCrypto.On("OpenServerSecret", "ps1.totp").Return(testTOTPSecret, nil)
+
+	return service, mockStorage, mockCrypto
+}
+
+func TestUserService_Login_TwoFactorChallenge(t *testing.T) {
+	pinTOTPTime(t)
+	service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+
+	mockCrypto.On("GenerateDataKey").Return("k1.challenge", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.challenge").Return("w1.challenge", nil)
+	mockStorage.On("CreateLoginChallenge", mock.MatchedBy(func(challenge entities.LoginChallenge) bool {
+		return challenge.UserID == 1 && challenge.ID != "" && challenge.WrappedKey == "w1.challenge" &&
+			challenge.ExpiresAt.Equal(timeNow().Add(loginChallengeTTL))
+	})).Return(nil)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+
+	require.NotNil(t, tokens.Challenge)
+	assert.Empty(t, tokens.AccessToken, "No tokens should be issued before the second factor")
+	assert.Empty(t, tokens.Hash, "The data key should not be returned before the second factor")
+	assert.Regexp(t, `^[A-Za-z0-9_-]+\.k1\.challenge$`, tokens.Challenge.Token)
+	mockStorage.AssertNotCalled(t, "CreateSession", mock.Anything)
+}
+
+func TestUserService_LoginTwoFactor(t *testing.T) {
+	step, code := pinTOTPTime(t)
+	challenge := &entities.LoginChallenge{ID: "chal", UserID: 1, WrappedKey: "w1.challenge", ExpiresAt: timeNow().Add(time.Minute)}
+
+	t.Run("totp code", func(t *testing.T) {
+		service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+		mockStorage.On("GetLoginChallenge", "chal").Return(challenge, nil)
+		mockCrypto.On("UnwrapKey", "w1.challenge", "k1.challenge").Return("k1.dek", nil)
+		mockStorage.On("AddLoginChallengeAttempt", "chal").Return(1, nil)
+		mockStorage.On("UseTOTPStep", int64(1), step).Return(true, nil)
+		mockStorage.On("DeleteLoginChallenge", "chal").Return(true, nil)
+		mockStorage.On("CreateSession", mock.MatchedBy(func(session entities.Session) bool {
+			return session.UserID == 1 && session.DeviceName == "phone"
+		})).Return(nil)
+
+		tokens, err := service.LoginTwoFactor(context.Background(), dto.TwoFactorLoginDTO{
+			ChallengeToken: "chal.k1.challenge",
+			Code:           code,
+			DeviceDTO:      dto.DeviceDTO{DeviceName: "phone"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "k1.dek", tokens.Hash)
+		assert.NotEmpty(t, tokens.AccessToken)
+	})
+
+	t.Run("replayed totp code", func(t *testing.T) {
+		service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+		mockStorage.On("GetLoginChallenge", "chal").Return(challenge, nil)
+		mockCrypto.On("UnwrapKey", "w1.challenge", "k1.challenge").Return("k1.dek", nil)
+		mockStorage.On("AddLoginChallengeAttempt", "chal").Return(1, nil)
+		mockStorage.On("UseTOTPStep", int64(1), step).Return(false, nil)
+
+		_, err := service.LoginTwoFactor(context.Background(), dto.TwoFactorLoginDTO{ChallengeToken: "chal.k1.challenge", Code: code})
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTwoFactorCode)
+		mockStorage.AssertNotCalled(t, "CreateSession", mock.Anything)
+		mockStorage.AssertNotCalled(t, "DeleteLoginChallenge", mock.Anything)
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+		mockStorage.On("GetLoginChallenge", "chal").Return(challenge, nil)
+		mockCrypto.On("UnwrapKey", "w1.challenge", "k1.challenge").Return("k1.dek", nil)
+		mockStorage.On("AddLoginChallengeAttempt", "chal").Return(1, nil)
+		mockCrypto.On("HashRecoveryCode", "CCCC-DDDD").Return("kit-hash")
+		mockStorage.On("UseRecoveryCode", int64(1), "kit-hash").Return(true, nil)
+		mockStorage.On("DeleteLoginChallenge", "chal").Return(true, nil)
+		mockStorage.On("CreateSession", mock.Anything).Return(nil)
+
+		_, err := service.LoginTwoFactor(context.Background(), dto.TwoFactorLoginDTO{ChallengeToken: "chal.k1.challenge", Code: "CCCC-DDDD"})
+		require.NoError(t, err, "A recovery code issued at registration should replace a TOTP code")
+	})
+
+	t.Run("too many wrong codes", func(t *testing.T) {
+		service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+		mockStorage.On("GetLoginChallenge", "chal").Return(challenge, nil)
+		mockCrypto.On("UnwrapKey", "w1.challenge", "k1.challenge").Return("k1.dek", nil)
+		mockCrypto.On("HashRecoveryCode", "999999").Return("wrong")
+		mockStorage.On("UseRecoveryCode", int64(1), "wrong").Return(false, nil)
+		mockStorage.On("AddLoginChallengeAttempt", "chal").Return(maxLoginChallengeAttempts, nil)
+		mockStorage.On("DeleteLoginChallenge", "chal").Return(true, nil)
+
+		_, err := service.LoginTwoFactor(context.Background(), dto.TwoFactorLoginDTO{ChallengeToken: "chal.k1.challenge", Code: "999999"})
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTwoFactorCode)
+		mockStorage.AssertCalled(t, "DeleteLoginChallenge", "chal")
+	})
+
+	t.Run("attempts used up", func(t *testing.T) {
+		service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+		mockStorage.On("GetLoginChallenge", "chal").Return(challenge, nil)
+		mockCrypto.On("UnwrapKey", "w1.challenge", "k1.challenge").Return("k1.dek", nil)
+		mockStorage.On("AddLoginChallengeAttempt", "chal").Return(maxLoginChallengeAttempts+1, nil)
+		mockStorage.On("DeleteLoginChallenge", "chal").Return(true, nil)
+
+		_, err := service.LoginTwoFactor(context.Background(), dto.TwoFactorLoginDTO{ChallengeToken: "chal.k1.challenge", Code: code})
+		assert.ErrorIs(t, err, apperrors.ErrLoginChallengeNotFound, "A request racing past the limit should not have its code checked")
+		mockStorage.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything)
+		mockStorage.AssertNotCalled(t, "CreateSession", mock.Anything)
+	})
+
+	t.Run("invalid challenge", func(t *testing.T) {
+		service, mockStorage, mockCrypto := newTwoFactorLoginService(t)
+		mockStorage.On("GetLoginChallenge", "gone").Return(nil, apperrors.ErrLoginChallengeNotFound)
+		mockStorage.On("GetLoginChallenge", "chal").Return(challenge, nil)
+		mockCrypto.On("UnwrapKey", "w1.challenge", "k1.forged").Return("", assert.AnError)
+
+		for _, token := range []string{"", "chal", "gone.k1.challenge", "chal.k1.forged"} {
+			_, err := service.LoginTwoFactor(context.Background(), dto.TwoFactorLoginDTO{ChallengeToken: token, Code: code})
+			assert.ErrorIs(t, err, apperrors.ErrLoginChallengeNotFound, token)
+		}
+		mockStorage.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything)
+		mockStorage.AssertNotCalled(t, "AddLoginChallengeAttempt", mock.Anything)
+	})
+}
+
+func TestUserService_DisableTOTP(t *testing.T) {
+	step, code := pinTOTPTime(t)
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "password123")}, nil)
+	mockStorage.On("GetTOTP", int64(1)).Return(enabledTOTP(1), nil)
+	mockCrypto.On("OpenServerSecret", "ps1.totp").Return(testTOTPSecret, nil)
+	mockStorage.On("UseTOTPStep", int64(1), step).Return(true, nil)
+	mockStorage.On("DeleteTOTP", int64(1)).Return(nil)
+
+	err := service.DisableTOTP(context.Background(), dto.DisableTwoFactorDTO{UserID: 1, Password: "wrong", Code: code})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+	mockStorage.AssertNotCalled(t, "DeleteTOTP", mock.Anything)
+
+	err = service.DisableTOTP(context.Background(), dto.DisableTwoFactorDTO{UserID: 1, Password: "password123", Code: code})
+	require.NoError(t, err)
+	mockStorage.AssertCalled(t, "DeleteTOTP", int64(1))
+}
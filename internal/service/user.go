@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/Zrossiz/gophkeeper/internal/apperrors"
@@ -9,16 +13,19 @@ import (
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
 	"github.com/Zrossiz/gophkeeper/internal/utils"
+	"github.com/Zrossiz/gophkeeper/internal/validation"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // UserService handles user authentication, registration, and JWT generation.
 type UserService struct {
-	log          *zap.Logger   // Logger for structured logging.
-	dbUser       UserStorage   // Database storage interface for user data.
-	cfg          config.Config // Application configuration.
-	cryptoModule CryptoModule  // Cryptographic module for password security.
+	log           *zap.Logger         // Logger for structured logging.
+	dbUser        UserStorage         // Database storage interface for user data.
+	vault         VaultStorage        // Storage used to re-encrypt a user's vault.
+	loginAttempts LoginAttemptStorage // Failed logins by username; nil disables login delays and lockout.
+	cfg           config.Config       // Application configuration.
+	cryptoModule  CryptoModule        // Cryptographic module for password security.
 }
 
 // UserStorage defines database operations related to user management.
@@ -27,13 +34,76 @@ type UserStorage interface {
 	Create(ctx context.Context, body dto.UserDTO) error
 	// GetUserByUsername retrieves a user by their username.
 	GetUserByUsername(ctx context.Context, username string) (*entities.User, error)
+	// GetUserByID retrieves a user by their ID.
+	GetUserByID(ctx context.Context, userID int64) (*entities.User, error)
+	// GetUserKeys retrieves the user's wrapped data key; apperrors.ErrUserKeysNotFound if there is none.
+	GetUserKeys(ctx context.Context, userID int64) (*entities.UserKeys, error)
+	// GetRecovery retrieves the user's recovery-wrapped data key; apperrors.ErrRecoveryNotFound if there is none.
+	GetRecovery(ctx context.Context, userID int64) (*entities.UserRecovery, error)
+	// ReplaceRecovery atomically replaces the user's recovery key and recovery codes.
+	ReplaceRecovery(ctx context.Context, userID int64, body dto.RecoveryDTO) error
+	// UseRecoveryCode spends a recovery code of the user; false if it does not exist or was used before.
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	// GetKeyPair retrieves the user's sharing key pair; apperrors.ErrKeyPairNotFound if there is none.
+	GetKeyPair(ctx context.Context, userID int64) (*entities.UserKeyPair, error)
+	// CreateKeyPair stores the sharing key pair of a user who has none.
+	CreateKeyPair(ctx context.Context, userID int64, body dto.KeyPairDTO) error
+	// CreateSession stores a new session.
+	CreateSession(ctx context.Context, session entities.Session) error
+	// GetSession retrieves a session; apperrors.ErrSessionNotFound if there is none.
+	GetSession(ctx context.Context, id string) (*entities.Session, error)
+	// RotateRefreshToken replaces the current refresh token of a valid session; apperrors.ErrRefreshTokenReused
+	// if oldTokenID is not current anymore.
+	RotateRefreshToken(ctx context.Context, id, oldTokenID, newTokenID string, expiresAt time.Time) error
+	// RevokeSession revokes a session.
+	RevokeSession(ctx context.Context, id string) error
+	// RevokeUserSession revokes an active session of a user; apperrors.ErrSessionNotFound if there is none.
+	RevokeUserSession(ctx context.Context, userID int64, id string) error
+	// GetTOTP retrieves the TOTP secret of a user; apperrors.ErrTOTPNotFound if there is none.
+	GetTOTP(ctx context.Context, userID int64) (*entities.UserTOTP, error)
+	// SaveTOTPSecret stores a TOTP secret that is not enabled yet; apperrors.ErrTwoFactorEnabled if one is enabled.
+	SaveTOTPSecret(ctx context.Context, userID int64, secret string) error
+	// EnableTOTP enables two-factor authentication.
+	EnableTOTP(ctx context.Context, userID int64, step int64) error
+	// UseTOTPStep records the step of an accepted TOTP code; false if it was used before.
+	UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error)
+	// DeleteTOTP turns two-factor authentication off.
+	DeleteTOTP(ctx context.Context, userID int64) error
+	// CreateLoginChallenge stores a login awaiting its second factor.
+	CreateLoginChallenge(ctx context.Context, challenge entities.LoginChallenge) error
+	// GetLoginChallenge retrieves a login challenge that has not expired; apperrors.ErrLoginChallengeNotFound if there is none.
+	GetLoginChallenge(ctx context.Context, id string) (*entities.LoginChallenge, error)
+	// AddLoginChallengeAttempt counts a code entered for a login challenge and returns the count.
+	AddLoginChallengeAttempt(ctx context.Context, id string) (int, error)
+	// DeleteLoginChallenge deletes a login challenge; false if it did not exist.
+	DeleteLoginChallenge(ctx context.Context, id string) (bool, error)
+}
+
+// recoveryCodeCount is the number of one-time recovery codes in a recovery kit.
+const recoveryCodeCount = 10
+
+// maxDeviceFieldLen is the maximum length, in characters, of the device name and user agent of a session.
+const maxDeviceFieldLen = 255
+
+// VaultStorage defines operations spanning every encrypted table of a user's vault.
+type VaultStorage interface {
+	// Rekey atomically stores a new wrapped data key and optional password hash and, if
+	// reencryptText is set, re-encrypts every value of the user's vault.
+	Rekey(
+		ctx context.Context,
+		body dto.VaultRekeyDTO,
+		reencryptText func(dto.VaultFieldDTO, string) (string, error),
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+	) error
 }
 
 // NewUserService initializes and returns a new UserService instance.
 //
 // Parameters:
 //   - dbUser: Implementation of UserStorage interface.
+//   - vault: Implementation of VaultStorage interface used to migrate vaults to data keys.
 //   - cryptoModule: Cryptographic module for password security.
+//   - loginAttempts: Optional store of failed logins for login delays and lockout; nil disables them.
 //   - cfg: Application configuration.
 //   - logger: Structured logger (zap.Logger).
 //
@@ -41,27 +111,62 @@ type UserStorage interface {
 //   - A pointer to a fully initialized UserService instance.
 func NewUserService(
 	dbUser UserStorage,
+	vault VaultStorage,
 	cryptoModule CryptoModule,
+	loginAttempts LoginAttemptStorage,
 	cfg config.Config,
 	logger *zap.Logger,
 ) *UserService {
 	return &UserService{
-		dbUser:       dbUser,
-		cryptoModule: cryptoModule,
-		log:          logger,
-		cfg:          cfg,
+		dbUser:        dbUser,
+		vault:         vault,
+		loginAttempts: loginAttempts,
+		cryptoModule:  cryptoModule,
+		log:           logger,
+		cfg:           cfg,
 	}
 }
 
 // Registration registers a new user, hashes their password, and generates JWT tokens.
+// A random data key is generated for the user's vault and stored wrapped by a key-encryption
+// key derived from the password with freshly generated, salted KDF parameters. A recovery kit
+// is generated as well: a recovery key that also unwraps the data key and one-time recovery
+// codes. Both are only returned here, the server keeps the wrapped key and the code hashes.
+// The key pair used to share vault items is generated with its private key wrapped by the data key.
+// The password must meet the master password policy.
 //
 // Parameters:
 //   - registrationDTO: Contains user registration details (username, password).
 //
 // Returns:
-//   - A pointer to GeneratedJwt struct containing access and refresh tokens.
-//   - An error if user creation fails or token generation fails.
+//   - A pointer to GeneratedJwt struct containing access and refresh tokens, the data key and the recovery kit.
+//   - *apperrors.PasswordPolicyError if the password does not meet the policy.
+//   - An error if user creation fails, key generation fails or token generation fails.
 func (u *UserService) Registration(ctx context.Context, registrationDTO dto.UserDTO) (*dto.GeneratedJwt, error) {
+	if err := u.checkPasswordPolicy(registrationDTO.Password, registrationDTO.Username); err != nil {
+		return nil, err
+	}
+
+	key, keys, err := u.newUserKeys(registrationDTO.Password)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+	registrationDTO.Keys = keys
+
+	kit, recovery, err := u.newRecoveryKit(key)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+	registrationDTO.Recovery = recovery
+
+	registrationDTO.KeyPair, err = u.newKeyPair(key)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
 	hashedPassword, err := hashPassword(registrationDTO.Password, u.cfg.Cost)
 	if err != nil {
 		return nil, apperrors.ErrHashPassword
@@ -80,70 +185,777 @@ func (u *UserService) Registration(ctx context.Context, registrationDTO dto.User
 		return nil, apperrors.ErrDBQuery
 	}
 
-	JWTAccessProps := utils.GenerateJWTProps{
-		Secret:   []byte(u.cfg.AccessSecret),
-		Exprires: time.Now().Add(15 * time.Minute),
-		UserID:   int64(createdUser.ID),
-		Username: createdUser.Username,
+	generatedTokens, err := u.generateTokens(ctx, createdUser, key, registrationDTO.DeviceDTO)
+	if err != nil {
+		return nil, err
 	}
+	generatedTokens.RecoveryKey = kit.RecoveryKey
+	generatedTokens.RecoveryCodes = kit.RecoveryCodes
 
-	accessToken, err := utils.GenerateJWT(JWTAccessProps)
+	return generatedTokens, nil
+}
+
+// Login authenticates a user and generates JWT tokens.
+// Users whose vault is not yet encrypted with a data key are migrated transparently: see vaultKey.
+// If the user has two-factor authentication enabled, no tokens are issued yet: a login challenge is
+// returned instead, which LoginTwoFactor exchanges for the tokens together with a second factor.
+// Wrong passwords delay the next login of the username progressively and finally lock it for a
+// while: see checkLoginAllowed.
+//
+// Parameters:
+//   - loginDTO: Contains user login details (username, password).
+//
+// Returns:
+//   - A pointer to GeneratedJwt struct containing access and refresh tokens and the data key, or
+//     only the login challenge.
+//   - *apperrors.LoginLockedError if the username has to wait after failed logins; the password is
+//     not checked then.
+//   - An error if authentication or key unwrapping fails.
+func (u *UserService) Login(ctx context.Context, loginDTO dto.UserDTO) (*dto.GeneratedJwt, error) {
+	if err := u.checkLoginAllowed(ctx, loginDTO.Username); err != nil {
+		return nil, err
+	}
+
+	curUser, err := u.dbUser.GetUserByUsername(ctx, loginDTO.Username)
 	if err != nil {
 		u.log.Error(err.Error())
-		return nil, apperrors.ErrJWTGeneration
+		return nil, apperrors.ErrDBQuery
 	}
 
-	JWTRefreshProps := utils.GenerateJWTProps{
-		Secret:   []byte(u.cfg.RefreshSecret),
-		Exprires: time.Now().Add(24 * 30 * time.Hour),
-		UserID:   int64(createdUser.ID),
-		Username: createdUser.Username,
+	if curUser == nil {
+		return nil, apperrors.ErrUserNotFound
 	}
 
-	refreshToken, err := utils.GenerateJWT(JWTRefreshProps)
+	err = bcrypt.CompareHashAndPassword([]byte(curUser.Password), []byte(loginDTO.Password))
+	if err != nil {
+		u.loginFailed(ctx, loginDTO.Username)
+		return nil, apperrors.ErrInvalidPassword
+	}
+
+	key, err := u.vaultKey(ctx, curUser, loginDTO.Password)
 	if err != nil {
 		u.log.Error(err.Error())
-		return nil, apperrors.ErrJWTGeneration
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	enabled, err := u.twoFactorEnabled(ctx, int64(curUser.ID))
+	if err != nil {
+		return nil, err
 	}
 
-	generatedTokens := dto.GeneratedJwt{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		Hash:         u.cryptoModule.GenerateSecretPhrase(createdUser.Password),
+	if enabled {
+		challenge, err := u.startLoginChallenge(ctx, curUser, key)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.GeneratedJwt{Challenge: challenge}, nil
 	}
 
-	return &generatedTokens, nil
+	u.loginSucceeded(ctx, loginDTO.Username)
+
+	return u.generateTokens(ctx, curUser, key, loginDTO.DeviceDTO)
 }
 
-// Login authenticates a user and generates JWT tokens.
+// ChangePassword changes a user's master password. The old password is verified, the bcrypt hash
+// is replaced and the user's data key is re-wrapped with a key-encryption key derived from the new
+// password, all in one transaction; vault items do not need to be re-encrypted. A vault that is not
+// encrypted with a data key yet, whose ciphertexts are not bound to their location yet or that was
+// flagged by the re-encryption job is re-encrypted in the same transaction, and the other sessions
+// of the user are revoked. The new password must meet the master password policy.
 //
 // Parameters:
-//   - loginDTO: Contains user login details (username, password).
+//   - body: Contains the authenticated user's ID and session ID and the old and new passwords.
 //
 // Returns:
-//   - A pointer to GeneratedJwt struct containing access and refresh tokens.
-//   - An error if authentication fails.
-func (u *UserService) Login(ctx context.Context, loginDTO dto.UserDTO) (*dto.GeneratedJwt, error) {
-	curUser, err := u.dbUser.GetUserByUsername(ctx, loginDTO.Username)
+//   - The data key to be used for encryption and decryption from now on.
+//   - apperrors.ErrInvalidPassword if the old password is wrong, *apperrors.PasswordPolicyError if the
+//     new password does not meet the policy, or an error if the change fails; nothing is changed in that case.
+func (u *UserService) ChangePassword(ctx context.Context, body dto.ChangePasswordDTO) (string, error) {
+	user, err := u.dbUser.GetUserByID(ctx, body.UserID)
 	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			return "", err
+		}
 		u.log.Error(err.Error())
-		return nil, apperrors.ErrDBQuery
+		return "", apperrors.ErrDBQuery
 	}
 
-	if curUser == nil {
-		return nil, apperrors.ErrUserNotFound
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.OldPassword))
+	if err != nil {
+		return "", apperrors.ErrInvalidPassword
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(curUser.Password), []byte(loginDTO.Password))
+	if err := u.checkPasswordPolicy(body.NewPassword, user.Username); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := hashPassword(body.NewPassword, u.cfg.Cost)
+	if err != nil {
+		return "", apperrors.ErrHashPassword
+	}
+
+	var (
+		reencryptText   func(dto.VaultFieldDTO, string) (string, error)
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error)
+	)
+
+	key, stored, err := u.unwrapDataKey(ctx, user, body.OldPassword)
+	switch {
+	case errors.Is(err, apperrors.ErrUserKeysNotFound):
+		oldKey, err := u.legacyVaultKey(user, body.OldPassword)
+		if err != nil {
+			u.log.Error(err.Error())
+			return "", apperrors.ErrKeyDerivation
+		}
+		if key, err = u.cryptoModule.GenerateDataKey(); err != nil {
+			u.log.Error(err.Error())
+			return "", apperrors.ErrKeyDerivation
+		}
+		reencryptText, reencryptBinary = u.reencryptors(oldKey, key)
+	case err != nil:
+		u.log.Error(err.Error())
+		return "", apperrors.ErrKeyDerivation
+	case !stored.AADBound || stored.ReencryptPending:
+		reencryptText, reencryptBinary = u.reencryptors(key, key)
+	}
+
+	keys, err := u.wrapDataKey(key, body.NewPassword)
+	if err != nil {
+		u.log.Error(err.Error())
+		return "", apperrors.ErrKeyDerivation
+	}
+
+	err = u.vault.Rekey(ctx, dto.VaultRekeyDTO{
+		UserID:         body.UserID,
+		Keys:           *keys,
+		PasswordHash:   hashedPassword,
+		RevokeSessions: true,
+		KeepSessionID:  body.SessionID,
+	}, reencryptText, reencryptBinary)
+	if err != nil {
+		u.log.Error("change password error", zap.Int64("userID", body.UserID), zap.Error(err))
+		return "", apperrors.ErrDBQuery
+	}
+
+	return key, nil
+}
+
+// RegenerateRecovery replaces the recovery key and recovery codes of the authenticated user, e.g. when
+// the recovery kit was lost, all codes are spent or the account predates recovery keys. The password is
+// verified and the data key unwrapped with it, so the new recovery key wraps the same data key; the
+// previous recovery key and codes stop working.
+//
+// Parameters:
+//   - body: Contains the authenticated user's ID and their password.
+//
+// Returns:
+//   - The new recovery kit, to be shown to the user once.
+//   - apperrors.ErrInvalidPassword if the password is wrong, or an error if the kit cannot be generated or stored.
+func (u *UserService) RegenerateRecovery(ctx context.Context, body dto.RegenerateRecoveryDTO) (*dto.RecoveryKitDTO, error) {
+	user, err := u.dbUser.GetUserByID(ctx, body.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			return nil, err
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password))
 	if err != nil {
 		return nil, apperrors.ErrInvalidPassword
 	}
 
+	key, _, err := u.unwrapDataKey(ctx, user, body.Password)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	kit, recovery, err := u.newRecoveryKit(key)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	if err := u.dbUser.ReplaceRecovery(ctx, body.UserID, *recovery); err != nil {
+		u.log.Error("replace recovery error", zap.Int64("userID", body.UserID), zap.Error(err))
+		return nil, apperrors.ErrDBQuery
+	}
+
+	return kit, nil
+}
+
+// Recover resets a forgotten master password with the recovery key. The data key is unwrapped with
+// the recovery key instead of the password, so no vault data is lost: the password hash is replaced
+// and the data key is wrapped with the new password in one transaction, as on ChangePassword. The
+// recovery key that was used is replaced by a new one and the existing sessions are revoked in the same
+// transaction; recovery codes are kept. Unknown users, users without a recovery key and wrong recovery keys are reported alike.
+//
+// Parameters:
+//   - body: Contains the username, the recovery key and the new password.
+//
+// Returns:
+//   - A pointer to GeneratedJwt struct containing access and refresh tokens, the data key and the new recovery key.
+//   - apperrors.ErrInvalidRecoveryKey if the account cannot be recovered with the given key,
+//     *apperrors.PasswordPolicyError if the new password does not meet the master password policy, or
+//     an error if the reset fails; nothing is changed in that case.
+func (u *UserService) Recover(ctx context.Context, body dto.RecoverAccountDTO) (*dto.GeneratedJwt, error) {
+	user, err := u.dbUser.GetUserByUsername(ctx, body.Username)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			return nil, apperrors.ErrInvalidRecoveryKey
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+	userID := int64(user.ID)
+
+	recovery, err := u.dbUser.GetRecovery(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrRecoveryNotFound) {
+			return nil, apperrors.ErrInvalidRecoveryKey
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	kek, err := u.cryptoModule.RecoveryKEK(body.RecoveryKey)
+	if err != nil {
+		return nil, apperrors.ErrInvalidRecoveryKey
+	}
+
+	key, err := u.cryptoModule.UnwrapKey(recovery.WrappedKey, kek)
+	if err != nil {
+		u.log.Info("recovery key rejected", zap.Int64("userID", userID), zap.Error(err))
+		return nil, apperrors.ErrInvalidRecoveryKey
+	}
+
+	if err := u.checkPasswordPolicy(body.NewPassword, user.Username); err != nil {
+		return nil, err
+	}
+
+	recoveryKey, wrappedRecovery, err := newRecoveryKey(u.cryptoModule, key)
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrKeyDerivation
+	}
+
+	if err := u.resetPassword(ctx, userID, key, body.NewPassword, wrappedRecovery); err != nil {
+		return nil, err
+	}
+
+	u.log.Info("account recovered", zap.Int64("userID", userID))
+
+	generatedTokens, err := u.generateTokens(ctx, user, key, body.DeviceDTO)
+	if err != nil {
+		return nil, err
+	}
+	generatedTokens.RecoveryKey = recoveryKey
+
+	return generatedTokens, nil
+}
+
+// ResetPassword sets a new master password for a user whose data key is known without their
+// password, e.g. by an emergency contact taking over the account. The password hash is replaced and
+// the data key is wrapped with the new password in one transaction, as on Recover, and all sessions
+// of the user are revoked; the recovery key and codes are kept.
+//
+// Parameters:
+//   - userID: The ID of the user.
+//   - key: The user's data key; the caller makes sure it is theirs.
+//   - newPassword: The new master password.
+//
+// Returns:
+//   - *apperrors.PasswordPolicyError if the new password does not meet the master password policy, or
+//     an error if the reset fails; nothing is changed in that case.
+func (u *UserService) ResetPassword(ctx context.Context, userID int64, key, newPassword string) error {
+	user, err := u.dbUser.GetUserByID(ctx, userID)
+	if err != nil {
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	if err := u.checkPasswordPolicy(newPassword, user.Username); err != nil {
+		return err
+	}
+
+	if err := u.resetPassword(ctx, userID, key, newPassword, ""); err != nil {
+		return err
+	}
+
+	u.log.Info("master password reset", zap.Int64("userID", userID))
+
+	return nil
+}
+
+// resetPassword replaces a user's password hash and wraps their data key with the new password, and
+// with a new recovery key if wrappedRecovery is set, in one transaction that also revokes all sessions
+// of the user, so that nobody stays signed in with the old password. A vault whose ciphertexts are
+// not bound to their location yet or that was flagged by the re-encryption job is re-encrypted as well.
+//
+// Returns:
+//   - apperrors.ErrDBQuery, apperrors.ErrHashPassword or apperrors.ErrKeyDerivation if the reset fails.
+func (u *UserService) resetPassword(ctx context.Context, userID int64, key, newPassword, wrappedRecovery string) error {
+	stored, err := u.dbUser.GetUserKeys(ctx, userID)
+	if err != nil {
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	hashedPassword, err := hashPassword(newPassword, u.cfg.Cost)
+	if err != nil {
+		return apperrors.ErrHashPassword
+	}
+
+	keys, err := u.wrapDataKey(key, newPassword)
+	if err != nil {
+		u.log.Error(err.Error())
+		return apperrors.ErrKeyDerivation
+	}
+
+	var (
+		reencryptText   func(dto.VaultFieldDTO, string) (string, error)
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error)
+	)
+	if !stored.AADBound || stored.ReencryptPending {
+		reencryptText, reencryptBinary = u.reencryptors(key, key)
+	}
+
+	err = u.vault.Rekey(ctx, dto.VaultRekeyDTO{
+		UserID:         userID,
+		Keys:           *keys,
+		PasswordHash:   hashedPassword,
+		RecoveryKey:    wrappedRecovery,
+		RevokeSessions: true,
+	}, reencryptText, reencryptBinary)
+	if err != nil {
+		u.log.Error("reset password error", zap.Int64("userID", userID), zap.Error(err))
+		return apperrors.ErrDBQuery
+	}
+
+	return nil
+}
+
+// vaultKey returns the user's data key, unwrapped with the key-encryption key derived from their password.
+//
+// Users without a data key have their vault encrypted directly with a password-derived key
+// (users.kdf) or, for the oldest accounts, with the secret phrase derived from the bcrypt hash.
+// For them a data key is generated and the whole vault is re-encrypted with it in a single
+// transaction. If the migration fails, the old key is returned so the user keeps access to their
+// data and the migration is retried on the next login. Vaults that already have a data key are
+// brought up to date by upgradeVault. Users registered before sharing existed get a key pair
+// once their data key is known, see ensureKeyPair.
+//
+// Parameters:
+//   - user: The authenticated user.
+//   - password: The user's plain text password.
+//
+// Returns:
+//   - The vault key to be used for encryption and decryption.
+//   - An error if the key cannot be derived or unwrapped.
+func (u *UserService) vaultKey(ctx context.Context, user *entities.User, password string) (string, error) {
+	key, stored, err := u.unwrapDataKey(ctx, user, password)
+	if err == nil {
+		u.upgradeVault(ctx, user, key, password, stored)
+		u.ensureKeyPair(ctx, int64(user.ID), key)
+		return key, nil
+	}
+	if !errors.Is(err, apperrors.ErrUserKeysNotFound) {
+		return "", err
+	}
+
+	oldKey, err := u.legacyVaultKey(user, password)
+	if err != nil {
+		return "", err
+	}
+
+	key, newKeys, err := u.newUserKeys(password)
+	if err != nil {
+		return "", err
+	}
+
+	reencryptText, reencryptBinary := u.reencryptors(oldKey, key)
+	err = u.vault.Rekey(ctx, dto.VaultRekeyDTO{UserID: int64(user.ID), Keys: *newKeys}, reencryptText, reencryptBinary)
+	if err != nil {
+		u.log.Error("migrate vault to data key error", zap.Int("userID", user.ID), zap.Error(err))
+		return oldKey, nil
+	}
+
+	u.log.Info("vault migrated to data key", zap.Int("userID", user.ID))
+	u.ensureKeyPair(ctx, int64(user.ID), key)
+
+	return key, nil
+}
+
+// unwrapDataKey unwraps the user's data key with the key-encryption key derived from their password.
+//
+// Returns:
+//   - The data key, or apperrors.ErrUserKeysNotFound if the user has no data key yet.
+//   - The stored keys the data key was unwrapped from.
+func (u *UserService) unwrapDataKey(
+	ctx context.Context,
+	user *entities.User,
+	password string,
+) (string, *entities.UserKeys, error) {
+	keys, err := u.dbUser.GetUserKeys(ctx, int64(user.ID))
+	if err != nil {
+		return "", nil, err
+	}
+
+	kek, err := u.cryptoModule.DeriveKey(password, keys.KDF)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := u.cryptoModule.UnwrapKey(keys.WrappedKey, kek)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, keys, nil
+}
+
+// upgradeVault brings a vault encrypted with a data key up to date in a single transaction:
+// the data key is wrapped again if it is not bound to the current server master key version
+// after a rotation or its KDF parameters are outdated, and the ciphertexts of a vault written
+// before they were bound to their location, or flagged by the re-encryption job as holding values
+// in an older format or cipher, are re-encrypted. Failures are only logged: the stored vault
+// stays readable and the upgrade is retried on the next login.
+func (u *UserService) upgradeVault(
+	ctx context.Context,
+	user *entities.User,
+	key, password string,
+	stored *entities.UserKeys,
+) {
+	rewrap := u.cryptoModule.NeedsRewrap(stored.WrappedKey) || u.cryptoModule.KDFOutdated(stored.KDF)
+	reencrypt := !stored.AADBound || stored.ReencryptPending
+	if !rewrap && !reencrypt {
+		return
+	}
+
+	var (
+		keys = &dto.UserKeysDTO{KDF: stored.KDF, WrappedKey: stored.WrappedKey, AADBound: true}
+		err  error
+
+		reencryptText   func(dto.VaultFieldDTO, string) (string, error)
+		reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error)
+	)
+
+	if rewrap {
+		keys, err = u.wrapDataKey(key, password)
+	}
+	if reencrypt {
+		reencryptText, reencryptBinary = u.reencryptors(key, key)
+	}
+	if err == nil {
+		err = u.vault.Rekey(ctx, dto.VaultRekeyDTO{UserID: int64(user.ID), Keys: *keys}, reencryptText, reencryptBinary)
+	}
+	if err != nil {
+		u.log.Error("upgrade vault error", zap.Int("userID", user.ID), zap.Error(err))
+		return
+	}
+
+	u.log.Info("vault upgraded",
+		zap.Int("userID", user.ID),
+		zap.Bool("rewrapped", rewrap),
+		zap.Bool("reencrypted", reencrypt),
+	)
+}
+
+// ensureKeyPair creates the sharing key pair of a user who has none, with its private key wrapped by
+// their data key. Failures are only logged: the key pair is created on a later login.
+func (u *UserService) ensureKeyPair(ctx context.Context, userID int64, key string) {
+	_, err := u.dbUser.GetKeyPair(ctx, userID)
+	if !errors.Is(err, apperrors.ErrKeyPairNotFound) {
+		if err != nil {
+			u.log.Error("get key pair error", zap.Int64("userID", userID), zap.Error(err))
+		}
+		return
+	}
+
+	pair, err := u.newKeyPair(key)
+	if err == nil {
+		err = u.dbUser.CreateKeyPair(ctx, userID, *pair)
+	}
+	if err != nil {
+		u.log.Error("create key pair error", zap.Int64("userID", userID), zap.Error(err))
+		return
+	}
+
+	u.log.Info("key pair created", zap.Int64("userID", userID))
+}
+
+// newKeyPair generates a sharing key pair and wraps its private key with the data key.
+//
+// Returns:
+//   - The public key and the wrapped private key, or an error.
+func (u *UserService) newKeyPair(key string) (*dto.KeyPairDTO, error) {
+	publicKey, privateKey, err := u.cryptoModule.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := u.cryptoModule.WrapKey(privateKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.KeyPairDTO{PublicKey: publicKey, WrappedPrivateKey: wrapped}, nil
+}
+
+// legacyVaultKey returns the key a vault without a data key is encrypted with: the key derived
+// from the password with users.kdf, or the secret phrase derived from the bcrypt hash.
+func (u *UserService) legacyVaultKey(user *entities.User, password string) (string, error) {
+	if user.KDF != "" {
+		return u.cryptoModule.DeriveKey(password, user.KDF)
+	}
+
+	return u.cryptoModule.GenerateSecretPhrase(user.Password), nil
+}
+
+// newUserKeys generates a random data key and wraps it with a key-encryption key derived
+// from the password with fresh KDF parameters.
+//
+// Returns:
+//   - The data key, the wrapped key with its KDF parameters, or an error.
+func (u *UserService) newUserKeys(password string) (string, *dto.UserKeysDTO, error) {
+	key, err := u.cryptoModule.GenerateDataKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	keys, err := u.wrapDataKey(key, password)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, keys, nil
+}
+
+// wrapDataKey wraps a data key with a key-encryption key derived from the password with fresh KDF parameters.
+// The keys are marked as AADBound: every caller stores them together with a vault that is empty or
+// re-encrypted with bindings in the same transaction.
+//
+// Returns:
+//   - The wrapped key with its KDF parameters, or an error.
+func (u *UserService) wrapDataKey(key, password string) (*dto.UserKeysDTO, error) {
+	kdf, err := u.cryptoModule.NewKDFParams()
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := u.cryptoModule.DeriveKey(password, kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := u.cryptoModule.WrapKey(key, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserKeysDTO{KDF: kdf, WrappedKey: wrapped, AADBound: true}, nil
+}
+
+// newRecoveryKit generates a recovery key wrapping the data key and recoveryCodeCount one-time recovery codes.
+//
+// Returns:
+//   - The recovery kit to be shown to the user, what is stored of it, or an error.
+func (u *UserService) newRecoveryKit(key string) (*dto.RecoveryKitDTO, *dto.RecoveryDTO, error) {
+	recoveryKey, wrapped, err := newRecoveryKey(u.cryptoModule, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kit := &dto.RecoveryKitDTO{RecoveryKey: recoveryKey, RecoveryCodes: make([]string, 0, recoveryCodeCount)}
+	recovery := &dto.RecoveryDTO{WrappedKey: wrapped, CodeHashes: make([]string, 0, recoveryCodeCount)}
+	for range recoveryCodeCount {
+		code, err := u.cryptoModule.GenerateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		kit.RecoveryCodes = append(kit.RecoveryCodes, code)
+		recovery.CodeHashes = append(recovery.CodeHashes, u.cryptoModule.HashRecoveryCode(code))
+	}
+
+	return kit, recovery, nil
+}
+
+// newRecoveryKey generates a recovery key and wraps the data key with the key-encryption key derived from it.
+//
+// Returns:
+//   - The recovery key, the data key wrapped by it, or an error.
+func newRecoveryKey(cryptoModule CryptoModule, key string) (string, string, error) {
+	recoveryKey, err := cryptoModule.GenerateRecoveryKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	kek, err := cryptoModule.RecoveryKEK(recoveryKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	wrapped, err := cryptoModule.WrapKey(key, kek)
+	if err != nil {
+		return "", "", err
+	}
+
+	return recoveryKey, wrapped, nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair of the same session.
+// Refresh tokens are rotated: the presented token is replaced by the new one and cannot be used again.
+// A rotated token presented again means it was stolen, or the new one was, so the whole session is
+// revoked and the user has to log in again.
+//
+// Parameters:
+//   - refreshToken: The refresh token issued on login or on the previous refresh.
+//
+// Returns:
+//   - A pointer to GeneratedJwt struct containing the new access and refresh tokens; the data key is not included.
+//   - apperrors.ErrInvalidRefreshToken if the token is malformed, expired or its session is revoked or unknown,
+//     apperrors.ErrRefreshTokenReused if it was rotated already, or an error if the refresh fails.
+func (u *UserService) Refresh(ctx context.Context, refreshToken string) (*dto.GeneratedJwt, error) {
+	claims, err := utils.ParseJWT(refreshToken, []byte(u.cfg.RefreshSecret))
+	if err != nil || claims.ID == "" || claims.SessionID == "" {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	session, err := u.dbUser.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return nil, apperrors.ErrInvalidRefreshToken
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	if session.UserID != claims.UserID || session.RevokedAt != nil || !timeNow().Before(session.ExpiresAt) {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	if session.CurrentTokenID != claims.ID {
+		return nil, u.revokeReusedSession(ctx, session)
+	}
+
+	tokenID, err := newTokenID()
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrJWTGeneration
+	}
+
+	expiresAt := timeNow().Add(u.cfg.DurationRefreshToken)
+	err = u.dbUser.RotateRefreshToken(ctx, session.ID, claims.ID, tokenID, expiresAt)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrRefreshTokenReused) {
+			return nil, u.revokeReusedSession(ctx, session)
+		}
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+
+	user := &entities.User{ID: int(claims.UserID), Username: claims.Username}
+	return u.signTokens(user, session.ID, tokenID, expiresAt)
+}
+
+// revokeReusedSession revokes the session of a refresh token that was presented after being rotated.
+//
+// Returns:
+//   - apperrors.ErrRefreshTokenReused, or apperrors.ErrDBQuery if the session cannot be revoked.
+func (u *UserService) revokeReusedSession(ctx context.Context, session *entities.Session) error {
+	u.log.Warn("refresh token reused, revoking its session",
+		zap.Int64("userID", session.UserID), zap.String("sessionID", session.ID))
+
+	if err := u.dbUser.RevokeSession(ctx, session.ID); err != nil {
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	return apperrors.ErrRefreshTokenReused
+}
+
+// Logout ends a session of a user: its access and refresh tokens are not accepted anymore.
+//
+// Parameters:
+//   - userID: The ID of the authenticated user.
+//   - sessionID: The ID of the session to end, the jti claim of the user's access token.
+//
+// Returns:
+//   - apperrors.ErrSessionNotFound if the user has no such active session, or an error if the logout fails.
+func (u *UserService) Logout(ctx context.Context, userID int64, sessionID string) error {
+	err := u.dbUser.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return err
+		}
+		u.log.Error(err.Error())
+		return apperrors.ErrDBQuery
+	}
+
+	return nil
+}
+
+// generateTokens starts a new session of an authenticated user on the given device and generates
+// its access and refresh tokens.
+//
+// Returns:
+//   - A pointer to GeneratedJwt struct containing access and refresh tokens and the data key.
+//   - apperrors.ErrJWTGeneration if a token cannot be signed, or apperrors.ErrDBQuery if the
+//     session cannot be stored.
+func (u *UserService) generateTokens(ctx context.Context, user *entities.User, key string, device dto.DeviceDTO) (*dto.GeneratedJwt, error) {
+	sessionID, err := newTokenID()
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrJWTGeneration
+	}
+
+	tokenID, err := newTokenID()
+	if err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrJWTGeneration
+	}
+
+	session := entities.Session{
+		ID:             sessionID,
+		UserID:         int64(user.ID),
+		DeviceName:     truncate(strings.TrimSpace(device.DeviceName), maxDeviceFieldLen),
+		UserAgent:      truncate(device.UserAgent, maxDeviceFieldLen),
+		IP:             device.IP,
+		CurrentTokenID: tokenID,
+		ExpiresAt:      timeNow().Add(u.cfg.DurationRefreshToken),
+	}
+
+	generatedTokens, err := u.signTokens(user, session.ID, tokenID, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.dbUser.CreateSession(ctx, session); err != nil {
+		u.log.Error(err.Error())
+		return nil, apperrors.ErrDBQuery
+	}
+	generatedTokens.Hash = key
+
+	return generatedTokens, nil
+}
+
+// signTokens signs an access token valid for config.Config.DurationAccessToken and a refresh token
+// that expires at refreshExpiresAt, both of the given session. The access token's jti claim is the
+// session ID, which is checked on every request; the refresh token's is tokenID.
+//
+// Returns:
+//   - A pointer to GeneratedJwt struct containing access and refresh tokens and their expiration times.
+//   - apperrors.ErrJWTGeneration if a token cannot be signed.
+func (u *UserService) signTokens(user *entities.User, sessionID, tokenID string, refreshExpiresAt time.Time) (*dto.GeneratedJwt, error) {
+	accessExpiresAt := timeNow().Add(u.cfg.DurationAccessToken)
 	JWTAccessProps := utils.GenerateJWTProps{
 		Secret:   []byte(u.cfg.AccessSecret),
-		Exprires: time.Now().Add(15 * time.Minute),
-		UserID:   int64(curUser.ID),
-		Username: curUser.Username,
+		Exprires: accessExpiresAt,
+		UserID:   int64(user.ID),
+		Username: user.Username,
+		ID:       sessionID,
 	}
 
 	accessToken, err := utils.GenerateJWT(JWTAccessProps)
@@ -153,10 +965,12 @@ func (u *UserService) Login(ctx context.Context, loginDTO dto.UserDTO) (*dto.Gen
 	}
 
 	JWTRefreshProps := utils.GenerateJWTProps{
-		Secret:   []byte(u.cfg.RefreshSecret),
-		Exprires: time.Now().Add(24 * 30 * time.Hour),
-		UserID:   int64(curUser.ID),
-		Username: curUser.Username,
+		Secret:    []byte(u.cfg.RefreshSecret),
+		Exprires:  refreshExpiresAt,
+		UserID:    int64(user.ID),
+		Username:  user.Username,
+		ID:        tokenID,
+		SessionID: sessionID,
 	}
 
 	refreshToken, err := utils.GenerateJWT(JWTRefreshProps)
@@ -165,12 +979,70 @@ func (u *UserService) Login(ctx context.Context, loginDTO dto.UserDTO) (*dto.Gen
 		return nil, apperrors.ErrJWTGeneration
 	}
 
-	generatedTokens := dto.GeneratedJwt{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+	return &dto.GeneratedJwt{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// newTokenID returns a random, URL-safe ID of a refresh token or session.
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
 	}
 
-	return &generatedTokens, nil
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+// reencryptors returns the functions re-encrypting the user's vault from oldKey to newKey; see newReencryptors.
+func (u *UserService) reencryptors(oldKey, newKey string) (
+	func(dto.VaultFieldDTO, string) (string, error),
+	func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) {
+	return newReencryptors(u.cryptoModule, oldKey, newKey)
+}
+
+// newReencryptors returns functions decrypting text and binary ciphertexts with oldKey and
+// encrypting them again with newKey bound to their location, as expected by VaultStorage.Rekey.
+// Unbound ciphertexts are accepted on decryption, so the same functions migrate legacy vaults.
+func newReencryptors(cryptoModule CryptoModule, oldKey, newKey string) (
+	func(dto.VaultFieldDTO, string) (string, error),
+	func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) {
+	reencryptText := func(field dto.VaultFieldDTO, ciphertext string) (string, error) {
+		binding := bind(field.UserID, field.Table, field.ItemID, field.Column)
+		plaintext, err := cryptoModule.DecryptBound(ciphertext, oldKey, binding)
+		if err != nil {
+			return "", err
+		}
+		return cryptoModule.EncryptBound(plaintext, newKey, binding)
+	}
+
+	reencryptBinary := func(field dto.VaultFieldDTO, ciphertext []byte) ([]byte, error) {
+		binding := bind(field.UserID, field.Table, field.ItemID, field.Column)
+		plaintext, err := cryptoModule.DecryptBinaryDataBound(ciphertext, oldKey, binding)
+		if err != nil {
+			return nil, err
+		}
+		return cryptoModule.EncryptBinaryDataBound(plaintext, newKey, binding)
+	}
+
+	return reencryptText, reencryptBinary
+}
+
+// checkPasswordPolicy checks a new master password against the configured master password policy.
+//
+// Returns:
+//   - *apperrors.PasswordPolicyError listing the failed rules, or nil.
+func (u *UserService) checkPasswordPolicy(password, username string) error {
+	return validation.ValidateMasterPassword(validation.PasswordPolicy{
+		MinLength:  u.cfg.PasswordMinLength,
+		MinEntropy: u.cfg.PasswordMinEntropy,
+		Denylist:   u.cfg.PasswordDenylist,
+	}, password, username)
 }
 
 // hashPassword hashes a given password using bcrypt.
@@ -190,3 +1062,13 @@ func hashPassword(password string, cost int) (string, error) {
 
 	return string(hashedPassword), nil
 }
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n])
+}
@@ -0,0 +1,1061 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/config"
+	"github.com/Zrossiz/gophkeeper/internal/cryptox"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/utils"
+	"github.com/Zrossiz/gophkeeper/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockUserStorage struct {
+	mock.Mock
+}
+
+func (m *MockUserStorage) Create(ctx context.Context, body dto.UserDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) GetUserByUsername(ctx context.Context, username string) (*entities.User, error) {
+	args := m.Called(username)
+	if user, ok := args.Get(0).(*entities.User); ok {
+		return user, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) GetUserByID(ctx context.Context, userID int64) (*entities.User, error) {
+	args := m.Called(userID)
+	if user, ok := args.Get(0).(*entities.User); ok {
+		return user, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) GetUserKeys(ctx context.Context, userID int64) (*entities.UserKeys, error) {
+	args := m.Called(userID)
+	if keys, ok := args.Get(0).(*entities.UserKeys); ok {
+		return keys, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) GetRecovery(ctx context.Context, userID int64) (*entities.UserRecovery, error) {
+	args := m.Called(userID)
+	if recovery, ok := args.Get(0).(*entities.UserRecovery); ok {
+		return recovery, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) ReplaceRecovery(ctx context.Context, userID int64, body dto.RecoveryDTO) error {
+	args := m.Called(userID, body)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) GetKeyPair(ctx context.Context, userID int64) (*entities.UserKeyPair, error) {
+	args := m.Called(userID)
+	if pair, ok := args.Get(0).(*entities.UserKeyPair); ok {
+		return pair, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) CreateKeyPair(ctx context.Context, userID int64, body dto.KeyPairDTO) error {
+	args := m.Called(userID, body)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) CreateSession(ctx context.Context, session entities.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) GetSession(ctx context.Context, id string) (*entities.Session, error) {
+	args := m.Called(id)
+	if session, ok := args.Get(0).(*entities.Session); ok {
+		return session, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) RotateRefreshToken(ctx context.Context, id, oldTokenID, newTokenID string, expiresAt time.Time) error {
+	args := m.Called(id, oldTokenID, newTokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) RevokeSession(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) RevokeUserSession(ctx context.Context, userID int64, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) GetTOTP(ctx context.Context, userID int64) (*entities.UserTOTP, error) {
+	args := m.Called(userID)
+	if stored, ok := args.Get(0).(*entities.UserTOTP); ok {
+		return stored, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) SaveTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	args := m.Called(userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) EnableTOTP(ctx context.Context, userID int64, step int64) error {
+	args := m.Called(userID, step)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserStorage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserStorage) DeleteTOTP(ctx context.Context, userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) CreateLoginChallenge(ctx context.Context, challenge entities.LoginChallenge) error {
+	args := m.Called(challenge)
+	return args.Error(0)
+}
+
+func (m *MockUserStorage) GetLoginChallenge(ctx context.Context, id string) (*entities.LoginChallenge, error) {
+	args := m.Called(id)
+	if challenge, ok := args.Get(0).(*entities.LoginChallenge); ok {
+		return challenge, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStorage) AddLoginChallengeAttempt(ctx context.Context, id string) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserStorage) DeleteLoginChallenge(ctx context.Context, id string) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockVaultStorage struct {
+	mock.Mock
+}
+
+func (m *MockVaultStorage) Rekey(
+	ctx context.Context,
+	body dto.VaultRekeyDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) error {
+	args := m.Called(body, reencryptText, reencryptBinary)
+	return args.Error(0)
+}
+
+var testUserConfig = config.Config{
+	AccessSecret:         "access",
+	RefreshSecret:        "refresh",
+	DurationAccessToken:  15 * time.Minute,
+	DurationRefreshToken: 720 * time.Hour,
+	Cost:                 bcrypt.MinCost,
+}
+
+func bcryptHash(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	return string(hash)
+}
+
+// expectNewUserKeys sets up the crypto calls generating and wrapping a new data key.
+func expectNewUserKeys(mockCrypto *MockCryptoModule, password string) {
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", password, "$argon2id$new").Return("k1.kek", nil)
+	mockCrypto.On("GenerateDataKey").Return("k1.dek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.kek").Return("w1.wrapped", nil)
+}
+
+var newUserKeys = dto.UserKeysDTO{KDF: "$argon2id$new", WrappedKey: "w1.wrapped", AADBound: true}
+
+// expectNewRecoveryKit sets up the crypto calls generating a recovery key wrapping dataKey and recovery codes.
+func expectNewRecoveryKit(mockCrypto *MockCryptoModule, dataKey string) {
+	mockCrypto.On("GenerateRecoveryKey").Return("AAAA-BBBB", nil)
+	mockCrypto.On("RecoveryKEK", "AAAA-BBBB").Return("k1.rkek", nil)
+	mockCrypto.On("WrapKey", dataKey, "k1.rkek").Return("w1.recovery", nil)
+	mockCrypto.On("GenerateRecoveryCode").Return("CODE-CODE", nil)
+	mockCrypto.On("HashRecoveryCode", "CODE-CODE").Return("hash")
+}
+
+func TestUserService_Registration_StoresWrappedDataKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	expectNewUserKeys(mockCrypto, "password123")
+	expectNewRecoveryKit(mockCrypto, "k1.dek")
+	mockCrypto.On("GenerateKeyPair").Return("x1.public", "k1.private", nil)
+	mockCrypto.On("WrapKey", "k1.private", "k1.dek").Return("w1.private", nil)
+	mockStorage.On("Create", mock.MatchedBy(func(body dto.UserDTO) bool {
+		return body.Username == "alice" &&
+			body.KeyPair != nil && *body.KeyPair == dto.KeyPairDTO{PublicKey: "x1.public", WrappedPrivateKey: "w1.private"} &&
+			body.Keys != nil && *body.Keys == newUserKeys &&
+			body.Recovery != nil && body.Recovery.WrappedKey == "w1.recovery" &&
+			len(body.Recovery.CodeHashes) == recoveryCodeCount && body.Recovery.CodeHashes[0] == "hash" &&
+			bcrypt.CompareHashAndPassword([]byte(body.Password), []byte("password123")) == nil
+	})).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice"}, nil)
+
+	tokens, err := service.Registration(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash, "The data key should be returned, not the KEK")
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Equal(t, "AAAA-BBBB", tokens.RecoveryKey, "The recovery key should be shown once")
+	assert.Len(t, tokens.RecoveryCodes, recoveryCodeCount)
+	mockStorage.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestUserService_Registration_KDFError(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockCrypto.On("GenerateDataKey").Return("k1.dek", nil)
+	mockCrypto.On("NewKDFParams").Return("", errors.New("bad config"))
+
+	_, err := service.Registration(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	assert.ErrorIs(t, err, apperrors.ErrKeyDerivation)
+	mockStorage.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUserService_Login_UnwrapsDataKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.MatchedBy(func(session entities.Session) bool {
+		return session.UserID == 1 && session.ID != "" && session.CurrentTokenID != "" &&
+			session.DeviceName == "laptop" && session.UserAgent == "curl/8.0" && session.IP == "192.0.2.1"
+	})).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "w1.stored").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$stored").Return(false)
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{
+		Username:  "alice",
+		Password:  "password123",
+		DeviceDTO: dto.DeviceDTO{DeviceName: " laptop ", UserAgent: "curl/8.0", IP: "192.0.2.1"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash)
+	mockVault.AssertNotCalled(t, "Rekey", mock.Anything, mock.Anything, mock.Anything)
+
+	session := mockStorage.Calls[len(mockStorage.Calls)-1].Arguments.Get(0).(entities.Session)
+	access, err := utils.ParseJWT(tokens.AccessToken, []byte("access"))
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, access.ID, "The access token should identify its session")
+}
+
+func TestUserService_Login_CreatesMissingKeyPair(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "password123")}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(nil, apperrors.ErrKeyPairNotFound)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "w1.stored").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$stored").Return(false)
+	mockCrypto.On("GenerateKeyPair").Return("x1.public", "k1.private", nil)
+	mockCrypto.On("WrapKey", "k1.private", "k1.dek").Return("w1.private", nil)
+	mockStorage.On("CreateKeyPair", int64(1), dto.KeyPairDTO{PublicKey: "x1.public", WrappedPrivateKey: "w1.private"}).Return(errors.New("db down"))
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err, "A key pair that cannot be stored should not fail the login")
+	assert.Equal(t, "k1.dek", tokens.Hash)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestUserService_Login_RewrapsAfterServerKeyRotation(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "sw2.1.stored", AADBound: true}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "sw2.1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "sw2.1.stored").Return(true)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$new").Return("k1.newkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.newkek").Return("sw2.2.rewrapped", nil)
+
+	var reencryptText func(dto.VaultFieldDTO, string) (string, error)
+	mockVault.On("Rekey", dto.VaultRekeyDTO{
+		UserID: 1,
+		Keys:   dto.UserKeysDTO{KDF: "$argon2id$new", WrappedKey: "sw2.2.rewrapped", AADBound: true},
+	}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText = args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+		}).
+		Return(nil)
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash, "The data key should not change")
+	assert.Nil(t, reencryptText, "Vault items should not be re-encrypted")
+	mockVault.AssertExpectations(t)
+}
+
+func TestUserService_Login_RewrapFailureKeepsLogin(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "sw2.1.stored", AADBound: true}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "sw2.1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "sw2.1.stored").Return(true)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$new").Return("k1.newkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.newkek").Return("", errors.New("kms unavailable"))
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash)
+	mockVault.AssertNotCalled(t, "Rekey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_Login_BindsUnboundVault(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored"}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "w1.stored").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$stored").Return(false)
+
+	title := cryptox.Binding{UserID: 1, ItemType: "notes", ItemID: 5, Field: "title"}
+	mockCrypto.On("DecryptBound", "unbound_cipher", "k1.dek", title).Return("plain", nil)
+	mockCrypto.On("EncryptBound", "plain", "k1.dek", title).Return("bound_cipher", nil)
+
+	mockVault.On("Rekey", dto.VaultRekeyDTO{
+		UserID: 1,
+		Keys:   dto.UserKeysDTO{KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true},
+	}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText := args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+			text, err := reencryptText(dto.VaultFieldDTO{UserID: 1, Table: "notes", ItemID: 5, Column: "title"}, "unbound_cipher")
+			assert.NoError(t, err)
+			assert.Equal(t, "bound_cipher", text)
+		}).
+		Return(nil)
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash, "The data key should not change")
+	mockVault.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestUserService_Login_RewrapsOutdatedKDF(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$weak", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$weak").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "w1.stored").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$weak").Return(true)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$new").Return("k1.newkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.newkek").Return("w1.rewrapped", nil)
+
+	mockVault.On("Rekey", dto.VaultRekeyDTO{
+		UserID: 1,
+		Keys:   dto.UserKeysDTO{KDF: "$argon2id$new", WrappedKey: "w1.rewrapped", AADBound: true},
+	}, mock.Anything, mock.Anything).Return(nil)
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash)
+	mockVault.AssertExpectations(t)
+}
+
+func TestUserService_Login_ReencryptsFlaggedVault(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+	mockStorage.On("GetKeyPair", int64(1)).Return(&entities.UserKeyPair{UserID: 1}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{
+		UserID:           1,
+		KDF:              "$argon2id$stored",
+		WrappedKey:       "w1.stored",
+		AADBound:         true,
+		ReencryptPending: true,
+	}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	mockCrypto.On("NeedsRewrap", "w1.stored").Return(false)
+	mockCrypto.On("KDFOutdated", "$argon2id$stored").Return(false)
+
+	var reencryptText func(dto.VaultFieldDTO, string) (string, error)
+	mockVault.On("Rekey", dto.VaultRekeyDTO{
+		UserID: 1,
+		Keys:   dto.UserKeysDTO{KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true},
+	}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText = args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+		}).
+		Return(nil)
+	mockStorage.On("GetTOTP", int64(1)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	_, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.NotNil(t, reencryptText, "A flagged vault should be re-encrypted")
+	mockVault.AssertExpectations(t)
+}
+
+func TestUserService_Login_UnwrapError(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "sw1.stored"}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "sw1.stored", "k1.kek").Return("", errors.New("server master key required"))
+
+	_, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	assert.ErrorIs(t, err, apperrors.ErrKeyDerivation)
+}
+
+func TestUserService_Login_InvalidPassword(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{
+		ID:       1,
+		Username: "alice",
+		Password: bcryptHash(t, "password123"),
+	}, nil)
+
+	_, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "wrong"})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+	mockStorage.AssertNotCalled(t, "GetUserKeys", mock.Anything)
+}
+
+func TestUserService_Login_MigratesLegacyVault(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	hash := bcryptHash(t, "password123")
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 7, Username: "alice", Password: hash}, nil)
+	mockStorage.On("GetUserKeys", int64(7)).Return(nil, apperrors.ErrUserKeysNotFound)
+	mockStorage.On("GetKeyPair", int64(7)).Return(&entities.UserKeyPair{UserID: 7}, nil)
+	mockCrypto.On("GenerateSecretPhrase", hash).Return("legacy")
+	expectNewUserKeys(mockCrypto, "password123")
+	cvv := cryptox.Binding{UserID: 7, ItemType: "cards", ItemID: 3, Field: "cvv"}
+	file := cryptox.Binding{UserID: 7, ItemType: "binary_data", ItemID: 4, Field: "binary_data"}
+	mockCrypto.On("DecryptBound", "old_cipher", "legacy", cvv).Return("plain", nil)
+	mockCrypto.On("EncryptBound", "plain", "k1.dek", cvv).Return("new_cipher", nil)
+	mockCrypto.On("DecryptBinaryDataBound", []byte("old_blob"), "legacy", file).Return([]byte("blob"), nil)
+	mockCrypto.On("EncryptBinaryDataBound", []byte("blob"), "k1.dek", file).Return([]byte("new_blob"), nil)
+
+	mockVault.On("Rekey", dto.VaultRekeyDTO{UserID: 7, Keys: newUserKeys}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText := args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+			reencryptBinary := args.Get(2).(func(dto.VaultFieldDTO, []byte) ([]byte, error))
+
+			text, err := reencryptText(dto.VaultFieldDTO{UserID: 7, Table: "cards", ItemID: 3, Column: "cvv"}, "old_cipher")
+			assert.NoError(t, err)
+			assert.Equal(t, "new_cipher", text)
+
+			blob, err := reencryptBinary(dto.VaultFieldDTO{UserID: 7, Table: "binary_data", ItemID: 4, Column: "binary_data"}, []byte("old_blob"))
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("new_blob"), blob)
+		}).
+		Return(nil)
+	mockStorage.On("GetTOTP", int64(7)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash)
+	mockVault.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestUserService_Login_MigratesPasswordDerivedVault(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	hash := bcryptHash(t, "password123")
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 7, Username: "alice", Password: hash, KDF: "$argon2id$old"}, nil)
+	mockStorage.On("GetUserKeys", int64(7)).Return(nil, apperrors.ErrUserKeysNotFound)
+	mockStorage.On("GetKeyPair", int64(7)).Return(&entities.UserKeyPair{UserID: 7}, nil)
+	mockCrypto.On("GenerateSecretPhrase", hash).Return("legacy")
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$old").Return("k1.old", nil)
+	expectNewUserKeys(mockCrypto, "password123")
+	mockCrypto.On("DecryptBound", "old_cipher", "k1.old", mock.Anything).Return("plain", nil)
+	mockCrypto.On("EncryptBound", "plain", "k1.dek", mock.Anything).Return("new_cipher", nil)
+
+	mockVault.On("Rekey", dto.VaultRekeyDTO{UserID: 7, Keys: newUserKeys}, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText := args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+			text, err := reencryptText(dto.VaultFieldDTO{UserID: 7, Table: "notes", ItemID: 1, Column: "title"}, "old_cipher")
+			assert.NoError(t, err)
+			assert.Equal(t, "new_cipher", text)
+		}).
+		Return(nil)
+	mockStorage.On("GetTOTP", int64(7)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash)
+	mockVault.AssertExpectations(t)
+}
+
+func TestUserService_Login_MigrationFailureKeepsOldKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	hash := bcryptHash(t, "password123")
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 7, Username: "alice", Password: hash}, nil)
+	mockStorage.On("GetUserKeys", int64(7)).Return(nil, apperrors.ErrUserKeysNotFound)
+	mockCrypto.On("GenerateSecretPhrase", hash).Return("legacy")
+	expectNewUserKeys(mockCrypto, "password123")
+	mockVault.On("Rekey", dto.VaultRekeyDTO{UserID: 7, Keys: newUserKeys}, mock.Anything, mock.Anything).Return(errors.New("tx failed"))
+	mockStorage.On("GetTOTP", int64(7)).Return(nil, apperrors.ErrTOTPNotFound)
+
+	tokens, err := service.Login(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", tokens.Hash)
+}
+
+func TestUserService_ChangePassword_RewrapsDataKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "old-password")}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockCrypto.On("DeriveKey", "old-password", "$argon2id$stored").Return("k1.oldkek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.oldkek").Return("k1.dek", nil)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "new-password", "$argon2id$new").Return("k1.newkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.newkek").Return("w1.rewrapped", nil)
+
+	var reencryptText func(dto.VaultFieldDTO, string) (string, error)
+	mockVault.On("Rekey", mock.MatchedBy(func(body dto.VaultRekeyDTO) bool {
+		return body.UserID == 1 && body.RevokeSessions && body.KeepSessionID == "phone" &&
+			body.Keys == dto.UserKeysDTO{KDF: "$argon2id$new", WrappedKey: "w1.rewrapped", AADBound: true} &&
+			bcrypt.CompareHashAndPassword([]byte(body.PasswordHash), []byte("new-password")) == nil
+	}), mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText = args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+		}).
+		Return(nil)
+
+	key, err := service.ChangePassword(context.Background(), dto.ChangePasswordDTO{
+		UserID:      1,
+		SessionID:   "phone",
+		OldPassword: "old-password",
+		NewPassword: "new-password",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", key, "The data key should not change")
+	assert.Nil(t, reencryptText, "Vault items should not be re-encrypted")
+	mockVault.AssertExpectations(t)
+}
+
+func TestUserService_ChangePassword_MigratesLegacyVault(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	hash := bcryptHash(t, "old-password")
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice", Password: hash}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(nil, apperrors.ErrUserKeysNotFound)
+	mockCrypto.On("GenerateSecretPhrase", hash).Return("legacy")
+	mockCrypto.On("GenerateDataKey").Return("k1.dek", nil)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "new-password", "$argon2id$new").Return("k1.newkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.newkek").Return("w1.wrapped", nil)
+	mockCrypto.On("DecryptBound", "old_cipher", "legacy", mock.Anything).Return("plain", nil)
+	mockCrypto.On("EncryptBound", "plain", "k1.dek", mock.Anything).Return("new_cipher", nil)
+
+	mockVault.On("Rekey", mock.MatchedBy(func(body dto.VaultRekeyDTO) bool {
+		return body.UserID == 1 && body.Keys.WrappedKey == "w1.wrapped" && body.PasswordHash != ""
+	}), mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			reencryptText := args.Get(1).(func(dto.VaultFieldDTO, string) (string, error))
+			text, err := reencryptText(dto.VaultFieldDTO{UserID: 1, Table: "notes", ItemID: 1, Column: "title"}, "old_cipher")
+			assert.NoError(t, err)
+			assert.Equal(t, "new_cipher", text)
+		}).
+		Return(nil)
+
+	key, err := service.ChangePassword(context.Background(), dto.ChangePasswordDTO{
+		UserID:      1,
+		OldPassword: "old-password",
+		NewPassword: "new-password",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", key)
+	mockVault.AssertExpectations(t)
+}
+
+func TestUserService_ChangePassword_WrongOldPassword(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	service := NewUserService(mockStorage, mockVault, new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "old-password")}, nil)
+
+	_, err := service.ChangePassword(context.Background(), dto.ChangePasswordDTO{
+		UserID:      1,
+		OldPassword: "wrong",
+		NewPassword: "new-password",
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+	mockVault.AssertNotCalled(t, "Rekey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_ChangePassword_RekeyError(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "old-password")}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockCrypto.On("DeriveKey", "old-password", "$argon2id$stored").Return("k1.oldkek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.oldkek").Return("k1.dek", nil)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "new-password", "$argon2id$new").Return("k1.newkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.newkek").Return("w1.rewrapped", nil)
+	mockVault.On("Rekey", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("tx failed"))
+
+	_, err := service.ChangePassword(context.Background(), dto.ChangePasswordDTO{
+		UserID:      1,
+		OldPassword: "old-password",
+		NewPassword: "new-password",
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrDBQuery)
+}
+
+func TestUserService_Recover_ResetsPasswordWithRecoveryKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("CreateSession", mock.Anything).Return(nil)
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "forgotten")}, nil)
+	mockStorage.On("GetRecovery", int64(1)).Return(&entities.UserRecovery{UserID: 1, WrappedKey: "w1.old-recovery"}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockCrypto.On("RecoveryKEK", "OLD-KEY").Return("k1.old-rkek", nil)
+	mockCrypto.On("UnwrapKey", "w1.old-recovery", "k1.old-rkek").Return("k1.dek", nil)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "newpassword", "$argon2id$new").Return("k1.kek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.kek").Return("w1.wrapped", nil)
+	mockCrypto.On("GenerateRecoveryKey").Return("AAAA-BBBB", nil)
+	mockCrypto.On("RecoveryKEK", "AAAA-BBBB").Return("k1.rkek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.rkek").Return("w1.recovery", nil)
+	mockVault.On("Rekey", mock.MatchedBy(func(body dto.VaultRekeyDTO) bool {
+		return body.UserID == 1 && body.Keys == newUserKeys && body.RecoveryKey == "w1.recovery" && body.RevokeSessions &&
+			bcrypt.CompareHashAndPassword([]byte(body.PasswordHash), []byte("newpassword")) == nil
+	}), mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		assert.Nil(t, args.Get(1), "An up-to-date vault should not be re-encrypted")
+	})
+
+	tokens, err := service.Recover(context.Background(), dto.RecoverAccountDTO{Username: "alice", RecoveryKey: "OLD-KEY", NewPassword: "newpassword"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "k1.dek", tokens.Hash, "The vault data key should be kept")
+	assert.Equal(t, "AAAA-BBBB", tokens.RecoveryKey, "The used recovery key should be replaced")
+	assert.NotEmpty(t, tokens.AccessToken)
+	mockVault.AssertExpectations(t)
+	mockCrypto.AssertExpectations(t)
+}
+
+func TestUserService_Recover_InvalidRecoveryKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice"}, nil)
+	mockStorage.On("GetUserByUsername", "bob").Return(nil, apperrors.ErrUserNotFound)
+	mockStorage.On("GetRecovery", int64(1)).Return(&entities.UserRecovery{UserID: 1, WrappedKey: "w1.recovery"}, nil)
+	mockCrypto.On("RecoveryKEK", "WRONG-KEY").Return("k1.wrong", nil)
+	mockCrypto.On("RecoveryKEK", "0000").Return("", cryptox.ErrInvalidRecoveryKey)
+	mockCrypto.On("UnwrapKey", "w1.recovery", "k1.wrong").Return("", errors.New("cipher: message authentication failed"))
+
+	for _, body := range []dto.RecoverAccountDTO{
+		{Username: "alice", RecoveryKey: "WRONG-KEY", NewPassword: "newpassword"},
+		{Username: "alice", RecoveryKey: "0000", NewPassword: "newpassword"},
+		{Username: "bob", RecoveryKey: "WRONG-KEY", NewPassword: "newpassword"},
+	} {
+		_, err := service.Recover(context.Background(), body)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRecoveryKey, body)
+	}
+
+	mockVault.AssertNotCalled(t, "Rekey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_Recover_NoRecoveryKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByUsername", "alice").Return(&entities.User{ID: 1, Username: "alice"}, nil)
+	mockStorage.On("GetRecovery", int64(1)).Return(nil, apperrors.ErrRecoveryNotFound)
+
+	_, err := service.Recover(context.Background(), dto.RecoverAccountDTO{Username: "alice", RecoveryKey: "AAAA-BBBB", NewPassword: "newpassword"})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidRecoveryKey)
+	mockCrypto.AssertNotCalled(t, "RecoveryKEK", mock.Anything)
+}
+
+// strictPasswordPolicy is testUserConfig with the default master password policy.
+var strictPasswordPolicy = func() config.Config {
+	cfg := testUserConfig
+	cfg.PasswordMinLength = 12
+	cfg.PasswordMinEntropy = 50
+	cfg.PasswordDenylist = true
+	return cfg
+}()
+
+func TestUserService_Registration_WeakPassword(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, strictPasswordPolicy, zap.NewNop())
+
+	_, err := service.Registration(context.Background(), dto.UserDTO{Username: "alice", Password: "password123"})
+
+	var policyErr *apperrors.PasswordPolicyError
+	require.True(t, errors.As(err, &policyErr))
+	assert.Equal(t, []apperrors.PasswordRuleFailure{
+		{Rule: validation.PasswordRuleMinLength, Message: "must be at least 12 characters long"},
+		{Rule: validation.PasswordRuleDenylist, Message: "is one of the most common passwords"},
+	}, policyErr.Failures)
+	mockStorage.AssertNotCalled(t, "Create", mock.Anything)
+	mockCrypto.AssertNotCalled(t, "GenerateDataKey")
+}
+
+func TestUserService_ChangePassword_WeakPassword(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	service := NewUserService(mockStorage, mockVault, new(MockCryptoModule), nil, strictPasswordPolicy, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice.liddell", Password: bcryptHash(t, "old-password-1")}, nil)
+
+	_, err := service.ChangePassword(context.Background(), dto.ChangePasswordDTO{
+		UserID:      1,
+		OldPassword: "old-password-1",
+		NewPassword: "Alice.Liddell",
+	})
+
+	assert.ErrorIs(t, err, apperrors.ErrWeakPassword)
+	mockVault.AssertNotCalled(t, "Rekey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_ResetPassword_WeakPassword(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	service := NewUserService(mockStorage, mockVault, new(MockCryptoModule), nil, strictPasswordPolicy, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice"}, nil)
+
+	err := service.ResetPassword(context.Background(), 1, "k1.dek", "qwertyuiop")
+
+	assert.ErrorIs(t, err, apperrors.ErrWeakPassword)
+	mockStorage.AssertNotCalled(t, "GetUserKeys", mock.Anything)
+	mockVault.AssertNotCalled(t, "Rekey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_ResetPassword_KeepsRecoveryKey(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockVault := new(MockVaultStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, mockVault, mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice"}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockCrypto.On("NewKDFParams").Return("$argon2id$new", nil)
+	mockCrypto.On("DeriveKey", "newpassword", "$argon2id$new").Return("k1.kek", nil)
+	mockCrypto.On("WrapKey", "k1.dek", "k1.kek").Return("w1.wrapped", nil)
+	mockVault.On("Rekey", mock.MatchedBy(func(body dto.VaultRekeyDTO) bool {
+		return body.UserID == 1 && body.Keys == newUserKeys && body.RecoveryKey == "" && body.RevokeSessions &&
+			bcrypt.CompareHashAndPassword([]byte(body.PasswordHash), []byte("newpassword")) == nil
+	}), mock.Anything, mock.Anything).Return(nil)
+
+	err := service.ResetPassword(context.Background(), 1, "k1.dek", "newpassword")
+
+	require.NoError(t, err)
+	mockVault.AssertExpectations(t)
+	mockCrypto.AssertNotCalled(t, "GenerateRecoveryKey")
+}
+
+func TestUserService_RegenerateRecovery(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	mockCrypto := new(MockCryptoModule)
+	service := NewUserService(mockStorage, new(MockVaultStorage), mockCrypto, nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "password123")}, nil)
+	mockStorage.On("GetUserKeys", int64(1)).Return(&entities.UserKeys{UserID: 1, KDF: "$argon2id$stored", WrappedKey: "w1.stored", AADBound: true}, nil)
+	mockCrypto.On("DeriveKey", "password123", "$argon2id$stored").Return("k1.kek", nil)
+	mockCrypto.On("UnwrapKey", "w1.stored", "k1.kek").Return("k1.dek", nil)
+	expectNewRecoveryKit(mockCrypto, "k1.dek")
+	mockStorage.On("ReplaceRecovery", int64(1), mock.MatchedBy(func(body dto.RecoveryDTO) bool {
+		return body.WrappedKey == "w1.recovery" && len(body.CodeHashes) == recoveryCodeCount
+	})).Return(nil)
+
+	kit, err := service.RegenerateRecovery(context.Background(), dto.RegenerateRecoveryDTO{UserID: 1, Password: "password123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "AAAA-BBBB", kit.RecoveryKey)
+	assert.Len(t, kit.RecoveryCodes, recoveryCodeCount)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestUserService_RegenerateRecovery_WrongPassword(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("GetUserByID", int64(1)).Return(&entities.User{ID: 1, Username: "alice", Password: bcryptHash(t, "password123")}, nil)
+
+	_, err := service.RegenerateRecovery(context.Background(), dto.RegenerateRecoveryDTO{UserID: 1, Password: "wrong"})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+	mockStorage.AssertNotCalled(t, "ReplaceRecovery", mock.Anything, mock.Anything)
+}
+
+// refreshToken signs a refresh token of the given session as issued for alice.
+func refreshToken(t *testing.T, secret, sessionID, tokenID string) string {
+	t.Helper()
+	token, err := utils.GenerateJWT(utils.GenerateJWTProps{
+		Secret:    []byte(secret),
+		Exprires:  time.Now().Add(time.Hour),
+		UserID:    1,
+		Username:  "alice",
+		ID:        tokenID,
+		SessionID: sessionID,
+	})
+	require.NoError(t, err)
+	return token
+}
+
+func TestUserService_Refresh_RotatesToken(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+	now := time.Now().Truncate(time.Second)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	mockStorage.On("GetSession", "fam").Return(&entities.Session{ID: "fam", UserID: 1, CurrentTokenID: "t1", ExpiresAt: now.Add(time.Hour)}, nil)
+	mockStorage.On("RotateRefreshToken", "fam", "t1", mock.Anything, now.Add(testUserConfig.DurationRefreshToken)).Return(nil)
+
+	tokens, err := service.Refresh(context.Background(), refreshToken(t, "refresh", "fam", "t1"))
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Empty(t, tokens.Hash, "The data key is not known on refresh")
+	assert.Equal(t, now.Add(testUserConfig.DurationAccessToken), tokens.AccessExpiresAt)
+
+	claims, err := utils.ParseJWT(tokens.RefreshToken, []byte("refresh"))
+	require.NoError(t, err)
+	assert.Equal(t, "fam", claims.SessionID)
+	assert.Equal(t, mockStorage.Calls[1].Arguments.String(2), claims.ID, "The new token should be the session's current one")
+	assert.NotEqual(t, "t1", claims.ID)
+}
+
+func TestUserService_Refresh_ReusedTokenRevokesSession(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+
+	session := &entities.Session{ID: "fam", UserID: 1, CurrentTokenID: "t2", ExpiresAt: time.Now().Add(time.Hour)}
+	mockStorage.On("GetSession", "fam").Return(session, nil)
+	mockStorage.On("RevokeSession", "fam").Return(nil)
+
+	_, err := service.Refresh(context.Background(), refreshToken(t, "refresh", "fam", "t1"))
+
+	assert.ErrorIs(t, err, apperrors.ErrRefreshTokenReused)
+	mockStorage.AssertCalled(t, "RevokeSession", "fam")
+	mockStorage.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_Refresh_ConcurrentRotationRevokesSession(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+
+	session := &entities.Session{ID: "fam", UserID: 1, CurrentTokenID: "t1", ExpiresAt: time.Now().Add(time.Hour)}
+	mockStorage.On("GetSession", "fam").Return(session, nil)
+	mockStorage.On("RotateRefreshToken", "fam", "t1", mock.Anything, mock.Anything).Return(apperrors.ErrRefreshTokenReused)
+	mockStorage.On("RevokeSession", "fam").Return(nil)
+
+	_, err := service.Refresh(context.Background(), refreshToken(t, "refresh", "fam", "t1"))
+
+	assert.ErrorIs(t, err, apperrors.ErrRefreshTokenReused)
+	mockStorage.AssertCalled(t, "RevokeSession", "fam")
+}
+
+func TestUserService_Refresh_InvalidToken(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+	revokedAt := time.Now()
+
+	mockStorage.On("GetSession", "revoked").Return(&entities.Session{ID: "revoked", UserID: 1, CurrentTokenID: "t1", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}, nil)
+	mockStorage.On("GetSession", "expired").Return(&entities.Session{ID: "expired", UserID: 1, CurrentTokenID: "t1", ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+	mockStorage.On("GetSession", "other").Return(&entities.Session{ID: "other", UserID: 2, CurrentTokenID: "t1", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	mockStorage.On("GetSession", "unknown").Return(nil, apperrors.ErrSessionNotFound)
+
+	for _, token := range []string{
+		"garbage",
+		refreshToken(t, "access", "revoked", "t1"),
+		refreshToken(t, "refresh", "", "t1"),
+		refreshToken(t, "refresh", "revoked", "t1"),
+		refreshToken(t, "refresh", "expired", "t1"),
+		refreshToken(t, "refresh", "other", "t1"),
+		refreshToken(t, "refresh", "unknown", "t1"),
+	} {
+		_, err := service.Refresh(context.Background(), token)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRefreshToken)
+	}
+
+	mockStorage.AssertNotCalled(t, "RevokeSession", mock.Anything)
+}
+
+func TestUserService_Logout(t *testing.T) {
+	mockStorage := new(MockUserStorage)
+	service := NewUserService(mockStorage, new(MockVaultStorage), new(MockCryptoModule), nil, testUserConfig, zap.NewNop())
+
+	mockStorage.On("RevokeUserSession", int64(1), "fam").Return(nil)
+	mockStorage.On("RevokeUserSession", int64(1), "gone").Return(apperrors.ErrSessionNotFound)
+	mockStorage.On("RevokeUserSession", int64(1), "broken").Return(errors.New("connection reset"))
+
+	assert.NoError(t, service.Logout(context.Background(), 1, "fam"))
+	assert.ErrorIs(t, service.Logout(context.Background(), 1, "gone"), apperrors.ErrSessionNotFound)
+	assert.ErrorIs(t, service.Logout(context.Background(), 1, "broken"), apperrors.ErrDBQuery)
+}
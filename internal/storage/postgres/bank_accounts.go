@@ -0,0 +1,130 @@
+// Package postgres provides database storage implementations for various entities.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// BankAccountStorage provides methods for managing bank account data in the PostgreSQL database.
+type BankAccountStorage struct {
+	db *sql.DB // SQL database connection.
+}
+
+// NewBankAccountStorage initializes and returns a new BankAccountStorage instance.
+//
+// Parameters:
+//   - db: An active SQL database connection.
+//
+// Returns:
+//   - A pointer to an initialized BankAccountStorage instance.
+func NewBankAccountStorage(db *sql.DB) *BankAccountStorage {
+	return &BankAccountStorage{db: db}
+}
+
+// NextID reserves the ID of the next bank account, so that its fields can be encrypted
+// bound to the ID before the row is inserted.
+//
+// Returns:
+//   - The reserved ID or an error if the query fails.
+func (b *BankAccountStorage) NextID(ctx context.Context) (int64, error) {
+	return nextID(ctx, b.db, "bank_accounts")
+}
+
+// CreateBankAccount inserts a new bank account record into the database.
+//
+// Parameters:
+//   - body: A CreateBankAccountDTO struct containing the ID reserved by NextID, bank name, account holder, IBAN and BIC.
+//
+// Returns:
+//   - An error if the operation fails.
+func (b *BankAccountStorage) CreateBankAccount(ctx context.Context, body dto.CreateBankAccountDTO) error {
+	query := `
+		INSERT INTO bank_accounts (id, user_id, bank_name, account_holder, iban, bic)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := b.db.ExecContext(
+		ctx,
+		query,
+		body.ID,
+		body.UserID,
+		body.BankName,
+		body.AccountHolder,
+		body.IBAN,
+		body.BIC,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAllBankAccountsByUserId retrieves all stored bank accounts for a given user.
+//
+// Parameters:
+//   - userID: The unique identifier of the user.
+//
+// Returns:
+//   - A slice of BankAccount entities containing the user's stored bank accounts.
+//   - An error if the retrieval fails.
+func (b *BankAccountStorage) GetAllBankAccountsByUserId(ctx context.Context, userID int64) ([]entities.BankAccount, error) {
+	query := `SELECT id, user_id, bank_name, account_holder, iban, bic, created_at, updated_at
+              FROM bank_accounts WHERE user_id = $1`
+
+	rows, err := b.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []entities.BankAccount
+	for rows.Next() {
+		var account entities.BankAccount
+		err := rows.Scan(
+			&account.ID,
+			&account.UserID,
+			&account.BankName,
+			&account.AccountHolder,
+			&account.IBAN,
+			&account.BIC,
+			&account.CreatedAt,
+			&account.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// UpdateBankAccount modifies an existing bank account record of the user in the database.
+//
+// Parameters:
+//   - accountID: The unique identifier of the bank account to be updated.
+//   - body: An UpdateBankAccountDTO struct containing the owner's ID and the updated bank account details.
+//
+// Returns:
+//   - An error if the update operation fails.
+func (b *BankAccountStorage) UpdateBankAccount(ctx context.Context, accountID int64, body dto.UpdateBankAccountDTO) error {
+	query := `UPDATE bank_accounts
+              SET bank_name = $1, account_holder = $2, iban = $3, bic = $4, updated_at = NOW()
+              WHERE id = $5 AND user_id = $6`
+
+	_, err := b.db.ExecContext(ctx, query, body.BankName, body.AccountHolder, body.IBAN, body.BIC, accountID, body.UserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
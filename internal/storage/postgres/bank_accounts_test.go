@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBankAccountStorage_CreateBankAccount(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewBankAccountStorage(db)
+
+	body := dto.CreateBankAccountDTO{
+		UserID:        1,
+		BankName:      "Test Bank",
+		AccountHolder: "Test User",
+		IBAN:          "DE89370400440532013000",
+		BIC:           "COBADEFFXXX",
+	}
+
+	body.ID = reserveID(t, storage)
+	err := storage.CreateBankAccount(context.Background(), body)
+	assert.NoError(t, err, "CreateBankAccount should not return an error")
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM bank_accounts WHERE user_id = $1 AND bank_name = $2", body.UserID, body.BankName).Scan(&count)
+	assert.NoError(t, err, "Failed to query bank_accounts table")
+	assert.Equal(t, 1, count, "Expected one bank account to be inserted")
+}
+
+func TestBankAccountStorage_GetAllBankAccountsByUserId(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewBankAccountStorage(db)
+
+	body := dto.CreateBankAccountDTO{
+		UserID:        1,
+		BankName:      "Test Bank",
+		AccountHolder: "Test User",
+		IBAN:          "DE89370400440532013000",
+		BIC:           "COBADEFFXXX",
+	}
+
+	body.ID = reserveID(t, storage)
+	err := storage.CreateBankAccount(context.Background(), body)
+	assert.NoError(t, err, "CreateBankAccount should not return an error")
+
+	accounts, err := storage.GetAllBankAccountsByUserId(context.Background(), int64(body.UserID))
+	assert.NoError(t, err, "GetAllBankAccountsByUserId should not return an error")
+	assert.Len(t, accounts, 1, "Expected one bank account for the user")
+
+	assert.Equal(t, body.AccountHolder, accounts[0].AccountHolder, "Account holder should match")
+	assert.Equal(t, body.IBAN, accounts[0].IBAN, "IBAN should match")
+	assert.Equal(t, body.BIC, accounts[0].BIC, "BIC should match")
+}
+
+func TestBankAccountStorage_UpdateBankAccount(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewBankAccountStorage(db)
+
+	body := dto.CreateBankAccountDTO{
+		UserID:        1,
+		BankName:      "Test Bank",
+		AccountHolder: "Test User",
+		IBAN:          "DE89370400440532013000",
+		BIC:           "COBADEFFXXX",
+	}
+
+	body.ID = reserveID(t, storage)
+	err := storage.CreateBankAccount(context.Background(), body)
+	assert.NoError(t, err, "CreateBankAccount should not return an error")
+
+	updateBody := dto.UpdateBankAccountDTO{
+		UserID:        1,
+		BankName:      "Updated Bank",
+		AccountHolder: "Updated User",
+		IBAN:          "GB82WEST12345698765432",
+		BIC:           "NWBKGB2L",
+	}
+
+	err = storage.UpdateBankAccount(context.Background(), 1, updateBody)
+	assert.NoError(t, err, "UpdateBankAccount should not return an error")
+
+	var updated entities.BankAccount
+	err = db.QueryRow("SELECT bank_name, account_holder, iban, bic FROM bank_accounts WHERE id = $1", 1).
+		Scan(&updated.BankName, &updated.AccountHolder, &updated.IBAN, &updated.BIC)
+	assert.NoError(t, err, "Failed to query updated bank account")
+
+	assert.Equal(t, updateBody.BankName, updated.BankName, "Bank name should be updated")
+	assert.Equal(t, updateBody.AccountHolder, updated.AccountHolder, "Account holder should be updated")
+	assert.Equal(t, updateBody.IBAN, updated.IBAN, "IBAN should be updated")
+	assert.Equal(t, updateBody.BIC, updated.BIC, "BIC should be updated")
+}
@@ -27,19 +27,28 @@ func NewBinaryStorage(db *sql.DB) *BinaryStorage {
 	return &BinaryStorage{db: db}
 }
 
+// NextID reserves the ID of the next binary data record, so that its fields can be encrypted
+// bound to the ID before the row is inserted.
+//
+// Returns:
+//   - The reserved ID or an error if the query fails.
+func (b *BinaryStorage) NextID(ctx context.Context) (int64, error) {
+	return nextID(ctx, b.db, "binary_data")
+}
+
 // Create inserts a new binary data record into the database.
 //
 // Parameters:
-//   - body: A SetStorageBinaryDTO struct containing user ID, title, and binary data.
+//   - body: A SetStorageBinaryDTO struct containing the ID reserved by NextID, user ID, title, and binary data.
 //
 // Returns:
 //   - An error if the operation fails.
 func (b *BinaryStorage) Create(ctx context.Context, body dto.SetStorageBinaryDTO) error {
 	query := `
-		INSERT INTO binary_data (user_id, title, binary_data, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO binary_data (id, user_id, title, binary_data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	_, err := b.db.ExecContext(ctx, query, body.UserID, body.Title, body.Data, time.Now(), time.Now())
+	_, err := b.db.ExecContext(ctx, query, body.ID, body.UserID, body.Title, body.Data, time.Now(), time.Now())
 	if err != nil {
 		return err
 	}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
 	"testing"
 
 	"github.com/Zrossiz/gophkeeper/internal/dto"
@@ -50,11 +49,7 @@ func setupDB(t *testing.T) (*sql.DB, func()) {
 	db, err := sql.Open("postgres", dsn)
 	require.NoError(t, err, "Failed to connect to PostgreSQL")
 
-	path := "/Users/zrossiz/Desktop/GoProjects/praktikum/projects/gophkeeper/migrations/1_init.sql"
-
-	query, _ := os.ReadFile(path)
-
-	_, err = db.Exec(string(query))
+	err = applyMigrations(db)
 	require.NoError(t, err, "Failed to migrate")
 
 	insertUserQuery := `INSERT INTO users (username, password) VALUES ('test', 'test')`
@@ -78,6 +73,7 @@ func TestBinaryStorage_Create(t *testing.T) {
 		Data:   []byte("test data"),
 	}
 
+	body.ID = reserveID(t, storage)
 	err := storage.Create(context.Background(), body)
 	assert.NoError(t, err, "Create should insert binary data without error")
 
@@ -98,6 +94,7 @@ func TestBinaryStorage_Update(t *testing.T) {
 		Title:  "test title",
 		Data:   []byte("initial data"),
 	}
+	createBody.ID = reserveID(t, storage)
 	err := storage.Create(context.Background(), createBody)
 	assert.NoError(t, err, "Create should insert binary data without error")
 
@@ -127,6 +124,7 @@ func TestBinaryStorage_GetAllByUser(t *testing.T) {
 		{UserID: int(userID), Title: "title2", Data: []byte("data2")},
 	}
 	for _, body := range bodies {
+		body.ID = reserveID(t, storage)
 		err := storage.Create(context.Background(), body)
 		assert.NoError(t, err, "Create should insert binary data without error")
 	}
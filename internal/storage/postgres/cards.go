@@ -4,6 +4,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
@@ -25,28 +26,40 @@ func NewCardStorage(db *sql.DB) *CardStorage {
 	return &CardStorage{db: db}
 }
 
+// NextID reserves the ID of the next card, so that its fields can be encrypted
+// bound to the ID before the row is inserted.
+//
+// Returns:
+//   - The reserved ID or an error if the query fails.
+func (c *CardStorage) NextID(ctx context.Context) (int64, error) {
+	return nextID(ctx, c.db, "cards")
+}
+
 // CreateCard inserts a new card record into the database.
 //
 // Parameters:
-//   - body: A CreateCardDTO struct containing card details such as bank name, number, CVV, expiration date, and cardholder name.
+//   - body: A CreateCardDTO struct containing the ID reserved by NextID and card details such as bank name,
+//     number, CVV, expiration date, cardholder name and the detected card brand.
 //
 // Returns:
 //   - An error if the operation fails.
 func (c *CardStorage) CreateCard(ctx context.Context, body dto.CreateCardDTO) error {
 	query := `
-		INSERT INTO cards (user_id, bank_name, num, cvv, exp_date, card_holder_name) 
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO cards (id, user_id, bank_name, num, cvv, exp_date, card_holder_name, brand) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
 	_, err := c.db.ExecContext(
 		ctx,
 		query,
+		body.ID,
 		body.UserID,
 		body.BankName,
 		body.Num,
 		body.CVV,
 		body.ExpDate,
 		body.CardHolderName,
+		body.Brand,
 	)
 	if err != nil {
 		return err
@@ -64,7 +77,7 @@ func (c *CardStorage) CreateCard(ctx context.Context, body dto.CreateCardDTO) er
 //   - A slice of Card entities containing the user's stored cards.
 //   - An error if the retrieval fails.
 func (c *CardStorage) GetAllCardsByUserId(ctx context.Context, userID int64) ([]entities.Card, error) {
-	query := `SELECT id, user_id, bank_name, num, cvv, exp_date, card_holder_name, created_at, updated_at 
+	query := `SELECT id, user_id, bank_name, num, cvv, exp_date, card_holder_name, brand, created_at, updated_at 
               FROM cards WHERE user_id = $1`
 
 	rows, err := c.db.QueryContext(ctx, query, userID)
@@ -84,6 +97,7 @@ func (c *CardStorage) GetAllCardsByUserId(ctx context.Context, userID int64) ([]
 			&card.CVV,
 			&card.ExpDate,
 			&card.CardHolderName,
+			&card.Brand,
 			&card.CreatedAt,
 			&card.UpdatedAt,
 		)
@@ -100,20 +114,55 @@ func (c *CardStorage) GetAllCardsByUserId(ctx context.Context, userID int64) ([]
 	return cards, nil
 }
 
-// UpdateCard modifies an existing card record in the database.
+// GetCardByID retrieves a single stored card by its identifier.
+//
+// Parameters:
+//   - cardID: The unique identifier of the card.
+//
+// Returns:
+//   - A pointer to the Card entity, or nil if no card with the given ID exists.
+//   - An error if the retrieval fails.
+func (c *CardStorage) GetCardByID(ctx context.Context, cardID int64) (*entities.Card, error) {
+	query := `SELECT id, user_id, bank_name, num, cvv, exp_date, card_holder_name, brand, created_at, updated_at 
+              FROM cards WHERE id = $1`
+
+	var card entities.Card
+	err := c.db.QueryRowContext(ctx, query, cardID).Scan(
+		&card.ID,
+		&card.UserID,
+		&card.BankName,
+		&card.Number,
+		&card.CVV,
+		&card.ExpDate,
+		&card.CardHolderName,
+		&card.Brand,
+		&card.CreatedAt,
+		&card.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+// UpdateCard modifies an existing card record of the user in the database.
 //
 // Parameters:
 //   - cardID: The unique identifier of the card to be updated.
-//   - body: An UpdateCardDTO struct containing the updated card details.
+//   - body: An UpdateCardDTO struct containing the owner's ID and the updated card details.
 //
 // Returns:
 //   - An error if the update operation fails.
 func (c *CardStorage) UpdateCard(ctx context.Context, cardID int64, body dto.UpdateCardDTO) error {
 	query := `UPDATE cards 
-              SET num = $1, cvv = $2, exp_date = $3, card_holder_name = $4, updated_at = NOW() 
-              WHERE id = $5`
+              SET num = $1, cvv = $2, exp_date = $3, card_holder_name = $4, brand = $5, updated_at = NOW() 
+              WHERE id = $6 AND user_id = $7`
 
-	_, err := c.db.ExecContext(ctx, query, body.Num, body.CVV, body.ExpDate, body.CardHolderName, cardID)
+	_, err := c.db.ExecContext(ctx, query, body.Num, body.CVV, body.ExpDate, body.CardHolderName, body.Brand, cardID, body.UserID)
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/Zrossiz/gophkeeper/internal/entities"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCardStorage_CreateCard(t *testing.T) {
@@ -25,6 +26,7 @@ func TestCardStorage_CreateCard(t *testing.T) {
 		CardHolderName: "Test User",
 	}
 
+	cardDTO.ID = reserveID(t, storage)
 	err := storage.CreateCard(context.Background(), cardDTO)
 	assert.NoError(t, err, "CreateCard should not return an error")
 
@@ -49,6 +51,7 @@ func TestCardStorage_GetAllCardsByUserId(t *testing.T) {
 		CardHolderName: "Test User",
 	}
 
+	cardDTO.ID = reserveID(t, storage)
 	err := storage.CreateCard(context.Background(), cardDTO)
 	assert.NoError(t, err, "CreateCard should not return an error")
 
@@ -77,10 +80,12 @@ func TestCardStorage_UpdateCard(t *testing.T) {
 		CardHolderName: "Test User",
 	}
 
+	cardDTO.ID = reserveID(t, storage)
 	err := storage.CreateCard(context.Background(), cardDTO)
 	assert.NoError(t, err, "CreateCard should not return an error")
 
 	updatedCardDTO := dto.UpdateCardDTO{
+		UserID:         1,
 		Num:            "8765432187654321",
 		CVV:            "321",
 		ExpDate:        "12/30",
@@ -100,3 +105,60 @@ func TestCardStorage_UpdateCard(t *testing.T) {
 	assert.Equal(t, updatedCardDTO.ExpDate, updatedCard.ExpDate, "Expiration date should be updated")
 	assert.Equal(t, updatedCardDTO.CardHolderName, updatedCard.CardHolderName, "Card holder name should be updated")
 }
+
+func TestCardStorage_UpdateCard_OtherUser(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewCardStorage(db)
+
+	cardID := reserveID(t, storage)
+	err := storage.CreateCard(context.Background(), dto.CreateCardDTO{
+		ID:             cardID,
+		UserID:         1,
+		Num:            "1234567812345678",
+		CVV:            "123",
+		ExpDate:        "12/25",
+		CardHolderName: "Test User",
+	})
+	require.NoError(t, err)
+
+	err = storage.UpdateCard(context.Background(), cardID, dto.UpdateCardDTO{UserID: 2, Num: "8765432187654321"})
+	assert.NoError(t, err, "UpdateCard should not return an error")
+
+	var num string
+	err = db.QueryRow("SELECT num FROM cards WHERE id = $1", cardID).Scan(&num)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567812345678", num, "Cards of other users should not be updated")
+}
+
+func TestCardStorage_GetCardByID(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewCardStorage(db)
+
+	cardDTO := dto.CreateCardDTO{
+		UserID:         1,
+		BankName:       "Test Bank",
+		Num:            "4111111111111111",
+		CVV:            "123",
+		ExpDate:        "12/30",
+		CardHolderName: "Test User",
+		Brand:          "visa",
+	}
+
+	cardDTO.ID = reserveID(t, storage)
+	err := storage.CreateCard(context.Background(), cardDTO)
+	assert.NoError(t, err, "CreateCard should not return an error")
+
+	card, err := storage.GetCardByID(context.Background(), 1)
+	assert.NoError(t, err, "GetCardByID should not return an error")
+	assert.NotNil(t, card, "Expected the card to be found")
+	assert.Equal(t, cardDTO.Num, card.Number, "Card number should match")
+	assert.Equal(t, cardDTO.Brand, card.Brand, "Card brand should match")
+
+	missing, err := storage.GetCardByID(context.Background(), 42)
+	assert.NoError(t, err, "GetCardByID should not return an error for a missing card")
+	assert.Nil(t, missing, "Expected no card for an unknown ID")
+}
@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// emergencyAccessColumns are the columns of emergency_access aliased e joined with the usernames of
+// the grantor (aliased g) and the grantee (aliased c).
+const emergencyAccessColumns = `
+	e.id, e.grantor_id, g.username, e.grantee_id, c.username, e.access_type, e.wait_days, e.status,
+	e.requested_at, e.wrapped_key, e.created_at, e.updated_at`
+
+// emergencyAccessFrom selects from emergency_access joined with the grantor and the grantee.
+const emergencyAccessFrom = `
+	FROM emergency_access e
+	JOIN users g ON g.id = e.grantor_id
+	JOIN users c ON c.id = e.grantee_id`
+
+// EmergencyStorage handles database operations of emergency access to users' vaults.
+type EmergencyStorage struct {
+	db *sql.DB // Database connection instance.
+}
+
+// NewEmergencyStorage initializes a new EmergencyStorage instance.
+//
+// Parameters:
+//   - db *sql.DB: The database connection.
+//
+// Returns:
+//   - *EmergencyStorage: A pointer to the initialized EmergencyStorage structure.
+func NewEmergencyStorage(db *sql.DB) *EmergencyStorage {
+	return &EmergencyStorage{db: db}
+}
+
+// CreateEmergencyAccess stores an emergency contact of a grantor.
+//
+// Parameters:
+//   - access entities.EmergencyAccess: The grantor and grantee IDs, the access type, the wait period
+//     and the grantor's data key sealed to the grantee.
+//
+// Returns:
+//   - *entities.EmergencyAccess: The stored emergency access with the usernames.
+//   - error: apperrors.ErrEmergencyAccessExists if the grantee already is a contact of the grantor, or a query error.
+func (e *EmergencyStorage) CreateEmergencyAccess(ctx context.Context, access entities.EmergencyAccess) (*entities.EmergencyAccess, error) {
+	query := `
+		INSERT INTO emergency_access (grantor_id, grantee_id, access_type, wait_days, wrapped_key, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (grantor_id, grantee_id) DO NOTHING
+		RETURNING id`
+	var id int64
+	err := e.db.QueryRowContext(ctx, query,
+		access.GrantorID, access.GranteeID, access.AccessType, access.WaitDays, access.WrappedKey, entities.EmergencyStatusIdle,
+	).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrEmergencyAccessExists
+		}
+		return nil, fmt.Errorf("store emergency access error: %v", err)
+	}
+
+	return e.GetEmergencyAccess(ctx, id)
+}
+
+// GetEmergencyAccess retrieves an emergency access by its ID.
+//
+// Parameters:
+//   - id int64: The ID of the emergency access.
+//
+// Returns:
+//   - *entities.EmergencyAccess: A pointer to the emergency access if found.
+//   - error: apperrors.ErrEmergencyAccessNotFound if it does not exist, or a query error.
+func (e *EmergencyStorage) GetEmergencyAccess(ctx context.Context, id int64) (*entities.EmergencyAccess, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE e.id = $1`, emergencyAccessColumns, emergencyAccessFrom)
+	access, err := scanEmergencyAccess(e.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrEmergencyAccessNotFound
+		}
+		return nil, err
+	}
+
+	return access, nil
+}
+
+// ListByGrantor retrieves the emergency contacts designated by a user.
+//
+// Parameters:
+//   - grantorID int64: The ID of the grantor.
+//
+// Returns:
+//   - []entities.EmergencyAccess: The emergency accesses, by grantee username.
+//   - error: An error if the query fails.
+func (e *EmergencyStorage) ListByGrantor(ctx context.Context, grantorID int64) ([]entities.EmergencyAccess, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE e.grantor_id = $1 ORDER BY c.username`, emergencyAccessColumns, emergencyAccessFrom)
+	return e.listEmergencyAccess(ctx, query, grantorID)
+}
+
+// ListByGrantee retrieves the emergency accesses a user was designated for.
+//
+// Parameters:
+//   - granteeID int64: The ID of the grantee.
+//
+// Returns:
+//   - []entities.EmergencyAccess: The emergency accesses, by grantor username.
+//   - error: An error if the query fails.
+func (e *EmergencyStorage) ListByGrantee(ctx context.Context, granteeID int64) ([]entities.EmergencyAccess, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE e.grantee_id = $1 ORDER BY g.username`, emergencyAccessColumns, emergencyAccessFrom)
+	return e.listEmergencyAccess(ctx, query, granteeID)
+}
+
+// UpdateEmergencyStatus changes the status of an emergency access if it still has the status it was
+// read with. The request time is set when the status becomes requested and cleared when it becomes idle.
+//
+// Parameters:
+//   - id int64: The ID of the emergency access.
+//   - from string: The status the emergency access was read with.
+//   - to string: The new status.
+//
+// Returns:
+//   - error: apperrors.ErrEmergencyAccessNotFound if it does not exist or its status changed meanwhile, or a query error.
+func (e *EmergencyStorage) UpdateEmergencyStatus(ctx context.Context, id int64, from, to string) error {
+	query := `
+		UPDATE emergency_access SET
+			status = $1,
+			requested_at = CASE $1 WHEN 'requested' THEN NOW() WHEN 'idle' THEN NULL ELSE requested_at END,
+			updated_at = NOW()
+		WHERE id = $2 AND status = $3`
+	res, err := e.db.ExecContext(ctx, query, to, id, from)
+	if err != nil {
+		return fmt.Errorf("update emergency access error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrEmergencyAccessNotFound
+	}
+
+	return nil
+}
+
+// DeleteEmergencyAccess deletes an emergency contact of a grantor.
+//
+// Parameters:
+//   - id int64: The ID of the emergency access.
+//   - grantorID int64: The ID of the grantor.
+//
+// Returns:
+//   - error: apperrors.ErrEmergencyAccessNotFound if the grantor has no such contact, or a query error.
+func (e *EmergencyStorage) DeleteEmergencyAccess(ctx context.Context, id, grantorID int64) error {
+	res, err := e.db.ExecContext(ctx, `DELETE FROM emergency_access WHERE id = $1 AND grantor_id = $2`, id, grantorID)
+	if err != nil {
+		return fmt.Errorf("delete emergency access error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrEmergencyAccessNotFound
+	}
+
+	return nil
+}
+
+// listEmergencyAccess runs a query selecting emergencyAccessColumns and scans the emergency accesses.
+func (e *EmergencyStorage) listEmergencyAccess(ctx context.Context, query string, args ...any) ([]entities.EmergencyAccess, error) {
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list emergency access error: %v", err)
+	}
+	defer rows.Close()
+
+	var accesses []entities.EmergencyAccess
+	for rows.Next() {
+		access, err := scanEmergencyAccess(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan emergency access error: %v", err)
+		}
+		accesses = append(accesses, *access)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list emergency access error: %v", err)
+	}
+
+	return accesses, nil
+}
+
+// scanEmergencyAccess scans a row of emergencyAccessColumns.
+func scanEmergencyAccess(row interface{ Scan(...any) error }) (*entities.EmergencyAccess, error) {
+	var (
+		access      entities.EmergencyAccess
+		requestedAt sql.NullTime
+	)
+	err := row.Scan(
+		&access.ID, &access.GrantorID, &access.GrantorUsername, &access.GranteeID, &access.GranteeUsername,
+		&access.AccessType, &access.WaitDays, &access.Status, &requestedAt, &access.WrappedKey,
+		&access.CreatedAt, &access.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if requestedAt.Valid {
+		access.RequestedAt = &requestedAt.Time
+	}
+
+	return &access, nil
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmergencyStorage(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	users, notes, storage := NewUserStorage(db), NewNotesStorage(db), NewEmergencyStorage(db)
+	setupSharing(t, ctx, users, notes)
+
+	access := entities.EmergencyAccess{GrantorID: 1, GranteeID: 2, AccessType: entities.EmergencyAccessTakeover, WaitDays: 7, WrappedKey: "s1.dek"}
+	created, err := storage.CreateEmergencyAccess(ctx, access)
+	require.NoError(t, err)
+	assert.Equal(t, "test", created.GrantorUsername)
+	assert.Equal(t, "bob", created.GranteeUsername)
+	assert.Equal(t, entities.EmergencyStatusIdle, created.Status)
+	assert.Nil(t, created.RequestedAt)
+
+	_, err = storage.CreateEmergencyAccess(ctx, access)
+	assert.ErrorIs(t, err, apperrors.ErrEmergencyAccessExists)
+
+	require.NoError(t, storage.UpdateEmergencyStatus(ctx, created.ID, entities.EmergencyStatusIdle, entities.EmergencyStatusRequested))
+	err = storage.UpdateEmergencyStatus(ctx, created.ID, entities.EmergencyStatusIdle, entities.EmergencyStatusRequested)
+	assert.ErrorIs(t, err, apperrors.ErrEmergencyAccessNotFound, "A status changed meanwhile should not be overwritten")
+
+	granted, err := storage.ListByGrantee(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, granted, 1)
+	assert.Equal(t, entities.EmergencyStatusRequested, granted[0].Status)
+	assert.NotNil(t, granted[0].RequestedAt)
+	assert.Equal(t, "s1.dek", granted[0].WrappedKey)
+
+	require.NoError(t, storage.UpdateEmergencyStatus(ctx, created.ID, entities.EmergencyStatusRequested, entities.EmergencyStatusIdle))
+	idle, err := storage.GetEmergencyAccess(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Nil(t, idle.RequestedAt, "A rejected request should clear its request time")
+
+	assert.ErrorIs(t, storage.DeleteEmergencyAccess(ctx, created.ID, 2), apperrors.ErrEmergencyAccessNotFound, "Only the grantor should delete a contact")
+	require.NoError(t, storage.DeleteEmergencyAccess(ctx, created.ID, 1))
+
+	contacts, err := storage.ListByGrantor(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, contacts)
+}
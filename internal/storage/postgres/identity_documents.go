@@ -0,0 +1,145 @@
+// Package postgres provides database storage implementations for various entities.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// IdentityDocumentStorage provides methods for managing identity documents in the PostgreSQL database.
+type IdentityDocumentStorage struct {
+	db *sql.DB // SQL database connection.
+}
+
+// NewIdentityDocumentStorage initializes and returns a new IdentityDocumentStorage instance.
+//
+// Parameters:
+//   - db: An active SQL database connection.
+//
+// Returns:
+//   - A pointer to an initialized IdentityDocumentStorage instance.
+func NewIdentityDocumentStorage(db *sql.DB) *IdentityDocumentStorage {
+	return &IdentityDocumentStorage{db: db}
+}
+
+// NextID reserves the ID of the next identity document, so that its fields can be encrypted
+// bound to the ID before the row is inserted.
+//
+// Returns:
+//   - The reserved ID or an error if the query fails.
+func (i *IdentityDocumentStorage) NextID(ctx context.Context) (int64, error) {
+	return nextID(ctx, i.db, "identity_documents")
+}
+
+// CreateIdentityDocument inserts a new identity document record into the database.
+//
+// Parameters:
+//   - body: A CreateIdentityDocumentDTO struct containing the ID reserved by NextID, the document type,
+//     number, holder name, issuing country and issue/expiry dates.
+//
+// Returns:
+//   - An error if the operation fails.
+func (i *IdentityDocumentStorage) CreateIdentityDocument(ctx context.Context, body dto.CreateIdentityDocumentDTO) error {
+	query := `
+		INSERT INTO identity_documents (id, user_id, doc_type, number, holder_name, issuing_country, issue_date, expiry_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := i.db.ExecContext(
+		ctx,
+		query,
+		body.ID,
+		body.UserID,
+		body.DocType,
+		body.Number,
+		body.HolderName,
+		body.IssuingCountry,
+		body.IssueDate,
+		body.ExpiryDate,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAllIdentityDocumentsByUserId retrieves all stored identity documents for a given user.
+//
+// Parameters:
+//   - userID: The unique identifier of the user.
+//
+// Returns:
+//   - A slice of IdentityDocument entities containing the user's stored documents.
+//   - An error if the retrieval fails.
+func (i *IdentityDocumentStorage) GetAllIdentityDocumentsByUserId(ctx context.Context, userID int64) ([]entities.IdentityDocument, error) {
+	query := `SELECT id, user_id, doc_type, number, holder_name, issuing_country, issue_date, expiry_date, created_at, updated_at
+              FROM identity_documents WHERE user_id = $1`
+
+	rows, err := i.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []entities.IdentityDocument
+	for rows.Next() {
+		var document entities.IdentityDocument
+		err := rows.Scan(
+			&document.ID,
+			&document.UserID,
+			&document.DocType,
+			&document.Number,
+			&document.HolderName,
+			&document.IssuingCountry,
+			&document.IssueDate,
+			&document.ExpiryDate,
+			&document.CreatedAt,
+			&document.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// UpdateIdentityDocument modifies an existing identity document record of the user in the database.
+//
+// Parameters:
+//   - documentID: The unique identifier of the document to be updated.
+//   - body: An UpdateIdentityDocumentDTO struct containing the owner's ID and the updated document details.
+//
+// Returns:
+//   - An error if the update operation fails.
+func (i *IdentityDocumentStorage) UpdateIdentityDocument(ctx context.Context, documentID int64, body dto.UpdateIdentityDocumentDTO) error {
+	query := `UPDATE identity_documents
+              SET number = $1, holder_name = $2, issuing_country = $3, issue_date = $4, expiry_date = $5, updated_at = NOW()
+              WHERE id = $6 AND user_id = $7`
+
+	_, err := i.db.ExecContext(
+		ctx,
+		query,
+		body.Number,
+		body.HolderName,
+		body.IssuingCountry,
+		body.IssueDate,
+		body.ExpiryDate,
+		documentID,
+		body.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityDocumentStorage_CreateIdentityDocument(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewIdentityDocumentStorage(db)
+
+	body := dto.CreateIdentityDocumentDTO{
+		UserID:         1,
+		DocType:        entities.DocumentTypePassport,
+		Number:         "123456789",
+		HolderName:     "Test User",
+		IssuingCountry: "DE",
+		IssueDate:      "2020-01-15",
+		ExpiryDate:     "2030-01-14",
+	}
+
+	body.ID = reserveID(t, storage)
+	err := storage.CreateIdentityDocument(context.Background(), body)
+	assert.NoError(t, err, "CreateIdentityDocument should not return an error")
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM identity_documents WHERE user_id = $1 AND doc_type = $2", body.UserID, body.DocType).Scan(&count)
+	assert.NoError(t, err, "Failed to query identity_documents table")
+	assert.Equal(t, 1, count, "Expected one identity document to be inserted")
+}
+
+func TestIdentityDocumentStorage_GetAllIdentityDocumentsByUserId(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewIdentityDocumentStorage(db)
+
+	body := dto.CreateIdentityDocumentDTO{
+		UserID:         1,
+		DocType:        entities.DocumentTypeDriverLicence,
+		Number:         "B072RRE2I55",
+		HolderName:     "Test User",
+		IssuingCountry: "DE",
+		IssueDate:      "2019-05-01",
+		ExpiryDate:     "2034-04-30",
+	}
+
+	body.ID = reserveID(t, storage)
+	err := storage.CreateIdentityDocument(context.Background(), body)
+	assert.NoError(t, err, "CreateIdentityDocument should not return an error")
+
+	documents, err := storage.GetAllIdentityDocumentsByUserId(context.Background(), int64(body.UserID))
+	assert.NoError(t, err, "GetAllIdentityDocumentsByUserId should not return an error")
+	assert.Len(t, documents, 1, "Expected one identity document for the user")
+
+	assert.Equal(t, body.DocType, documents[0].DocType, "Document type should match")
+	assert.Equal(t, body.Number, documents[0].Number, "Number should match")
+	assert.Equal(t, body.IssuingCountry, documents[0].IssuingCountry, "Issuing country should match")
+	assert.Equal(t, body.ExpiryDate, documents[0].ExpiryDate, "Expiry date should match")
+}
+
+func TestIdentityDocumentStorage_UpdateIdentityDocument(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewIdentityDocumentStorage(db)
+
+	body := dto.CreateIdentityDocumentDTO{
+		UserID:         1,
+		DocType:        entities.DocumentTypePassport,
+		Number:         "123456789",
+		HolderName:     "Test User",
+		IssuingCountry: "DE",
+		IssueDate:      "2020-01-15",
+		ExpiryDate:     "2030-01-14",
+	}
+
+	body.ID = reserveID(t, storage)
+	err := storage.CreateIdentityDocument(context.Background(), body)
+	assert.NoError(t, err, "CreateIdentityDocument should not return an error")
+
+	updateBody := dto.UpdateIdentityDocumentDTO{
+		UserID:         1,
+		Number:         "987654321",
+		HolderName:     "Updated User",
+		IssuingCountry: "FR",
+		IssueDate:      "2021-02-01",
+		ExpiryDate:     "2031-01-31",
+	}
+
+	err = storage.UpdateIdentityDocument(context.Background(), 1, updateBody)
+	assert.NoError(t, err, "UpdateIdentityDocument should not return an error")
+
+	var updated entities.IdentityDocument
+	err = db.QueryRow("SELECT number, holder_name, issuing_country, issue_date, expiry_date FROM identity_documents WHERE id = $1", 1).
+		Scan(&updated.Number, &updated.HolderName, &updated.IssuingCountry, &updated.IssueDate, &updated.ExpiryDate)
+	assert.NoError(t, err, "Failed to query updated identity document")
+
+	assert.Equal(t, updateBody.Number, updated.Number, "Number should be updated")
+	assert.Equal(t, updateBody.HolderName, updated.HolderName, "Holder name should be updated")
+	assert.Equal(t, updateBody.IssuingCountry, updated.IssuingCountry, "Issuing country should be updated")
+	assert.Equal(t, updateBody.IssueDate, updated.IssueDate, "Issue date should be updated")
+	assert.Equal(t, updateBody.ExpiryDate, updated.ExpiryDate, "Expiry date should be updated")
+}
@@ -26,17 +26,27 @@ func NewLogoPassStorage(db *sql.DB) *LogoPassStorage {
 	return &LogoPassStorage{db: db}
 }
 
+// NextID reserves the ID of the next application password record, so that its fields can be encrypted
+// bound to the ID before the row is inserted.
+//
+// Returns:
+//   - The reserved ID or an error if the query fails.
+func (l *LogoPassStorage) NextID(ctx context.Context) (int64, error) {
+	return nextID(ctx, l.db, "passwords")
+}
+
 // CreateLogoPass inserts a new application password record into the database.
 //
 // Parameters:
-//   - body: A CreateLogoPassDTO struct containing user ID, application name, username, and password.
+//   - body: A CreateLogoPassDTO struct containing the ID reserved by NextID, user ID, application name,
+//     username, password and the encrypted list of website URIs.
 //
 // Returns:
 //   - An error if the operation fails.
 func (l *LogoPassStorage) CreateLogoPass(ctx context.Context, body dto.CreateLogoPassDTO) error {
-	query := `INSERT INTO passwords (user_id, app_name, username, password, created_at, updated_at) 
-              VALUES ($1, $2, $3, $4, NOW(), NOW())`
-	_, err := l.db.ExecContext(ctx, query, body.UserId, body.AppName, body.Username, body.Password)
+	query := `INSERT INTO passwords (id, user_id, app_name, username, password, uris, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())`
+	_, err := l.db.ExecContext(ctx, query, body.ID, body.UserId, body.AppName, body.Username, body.Password, body.EncryptedURIs)
 	if err != nil {
 		return fmt.Errorf("failed to create logo pass: %w", err)
 	}
@@ -52,7 +62,7 @@ func (l *LogoPassStorage) CreateLogoPass(ctx context.Context, body dto.CreateLog
 //   - A slice of LogoPassword entities containing the user's stored credentials.
 //   - An error if the retrieval fails.
 func (l *LogoPassStorage) GetAllByUser(ctx context.Context, userID int64) ([]entities.LogoPassword, error) {
-	query := `SELECT id, user_id, app_name, username, password, created_at, updated_at 
+	query := `SELECT id, user_id, app_name, username, password, uris, created_at, updated_at 
               FROM passwords WHERE user_id = $1`
 
 	rows, err := l.db.QueryContext(ctx, query, userID)
@@ -64,7 +74,7 @@ func (l *LogoPassStorage) GetAllByUser(ctx context.Context, userID int64) ([]ent
 	var logoPasswords []entities.LogoPassword
 	for rows.Next() {
 		var lp entities.LogoPassword
-		err := rows.Scan(&lp.ID, &lp.UserID, &lp.AppName, &lp.Username, &lp.Password, &lp.CreatedAt, &lp.UpdatedAt)
+		err := rows.Scan(&lp.ID, &lp.UserID, &lp.AppName, &lp.Username, &lp.Password, &lp.EncryptedURIs, &lp.CreatedAt, &lp.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
@@ -78,19 +88,19 @@ func (l *LogoPassStorage) GetAllByUser(ctx context.Context, userID int64) ([]ent
 	return logoPasswords, nil
 }
 
-// UpdateLogoPass modifies an existing application password record in the database.
+// UpdateLogoPass modifies an existing application password record of the user in the database.
 //
 // Parameters:
 //   - id: The unique identifier of the password record to be updated.
-//   - body: An UpdateLogoPassDTO struct containing the updated username and password.
+//   - body: An UpdateLogoPassDTO struct containing the owner's ID and the updated username, password and encrypted URIs.
 //
 // Returns:
 //   - An error if the update operation fails.
 func (l *LogoPassStorage) UpdateLogoPass(ctx context.Context, id int64, body dto.UpdateLogoPassDTO) error {
 	query := `UPDATE passwords 
-              SET username = $1, password = $2, updated_at = NOW() 
-              WHERE id = $3`
-	_, err := l.db.ExecContext(ctx, query, body.Username, body.Password, id)
+              SET username = $1, password = $2, uris = $3, updated_at = NOW() 
+              WHERE id = $4 AND user_id = $5`
+	_, err := l.db.ExecContext(ctx, query, body.Username, body.Password, body.EncryptedURIs, id, body.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to update logo pass: %w", err)
 	}
@@ -23,6 +23,7 @@ func TestLogoPassStorage_CreateLogoPass(t *testing.T) {
 		Password: "testpassword",
 	}
 
+	body.ID = reserveID(t, storage)
 	err := storage.CreateLogoPass(context.Background(), body)
 	assert.NoError(t, err, "CreateLogoPass should not return an error")
 
@@ -45,6 +46,7 @@ func TestLogoPassStorage_GetAllByUser(t *testing.T) {
 	}
 
 	for _, body := range logoPassDTOs {
+		body.ID = reserveID(t, storage)
 		err := storage.CreateLogoPass(context.Background(), body)
 		assert.NoError(t, err, "CreateLogoPass should not return an error")
 	}
@@ -74,10 +76,12 @@ func TestLogoPassStorage_UpdateLogoPass(t *testing.T) {
 		Password: "testpassword",
 	}
 
+	body.ID = reserveID(t, storage)
 	err := storage.CreateLogoPass(context.Background(), body)
 	assert.NoError(t, err, "CreateLogoPass should not return an error")
 
 	updateBody := dto.UpdateLogoPassDTO{
+		UserID:   1,
 		Username: "updateduser",
 		Password: "updatedpassword",
 	}
@@ -93,3 +97,36 @@ func TestLogoPassStorage_UpdateLogoPass(t *testing.T) {
 	assert.Equal(t, updateBody.Username, updatedLogoPass.Username, "Username should be updated")
 	assert.Equal(t, updateBody.Password, updatedLogoPass.Password, "Password should be updated")
 }
+
+func TestLogoPassStorage_URIs(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	storage := NewLogoPassStorage(db)
+
+	err := storage.CreateLogoPass(context.Background(), dto.CreateLogoPassDTO{
+		ID:            reserveID(t, storage),
+		UserId:        1,
+		AppName:       "TestApp",
+		Username:      "testuser",
+		Password:      "testpassword",
+		EncryptedURIs: "encrypted-uris",
+	})
+	assert.NoError(t, err, "CreateLogoPass should not return an error")
+
+	logoPasses, err := storage.GetAllByUser(context.Background(), 1)
+	assert.NoError(t, err, "GetAllByUser should not return an error")
+	assert.Len(t, logoPasses, 1)
+	assert.Equal(t, "encrypted-uris", logoPasses[0].EncryptedURIs, "EncryptedURIs should match")
+
+	err = storage.UpdateLogoPass(context.Background(), int64(logoPasses[0].ID), dto.UpdateLogoPassDTO{
+		UserID:   1,
+		Username: "testuser",
+		Password: "testpassword",
+	})
+	assert.NoError(t, err, "UpdateLogoPass should not return an error")
+
+	logoPasses, err = storage.GetAllByUser(context.Background(), 1)
+	assert.NoError(t, err, "GetAllByUser should not return an error")
+	assert.Empty(t, logoPasses[0].EncryptedURIs, "EncryptedURIs should be cleared")
+}
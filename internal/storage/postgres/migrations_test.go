@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationsDir points to the SQL migrations relative to this package.
+const migrationsDir = "../../../migrations"
+
+// applyMigrations executes every migration file in migrationsDir in numeric order
+// (1_init.sql, 2_..., 10_...).
+func applyMigrations(db *sql.DB) error {
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return migrationNumber(files[i]) < migrationNumber(files[j])
+	})
+
+	for _, file := range files {
+		query, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration file %s: %w", file, err)
+		}
+
+		if _, err := db.Exec(string(query)); err != nil {
+			return fmt.Errorf("failed to execute migration %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// migrationNumber extracts the numeric prefix of a migration file name.
+func migrationNumber(path string) int {
+	prefix, _, _ := strings.Cut(filepath.Base(path), "_")
+	n, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
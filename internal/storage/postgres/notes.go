@@ -26,17 +26,26 @@ func NewNotesStorage(db *sql.DB) *NotesStorage {
 	return &NotesStorage{db: db}
 }
 
+// NextID reserves the ID of the next note, so that its fields can be encrypted
+// bound to the ID before the row is inserted.
+//
+// Returns:
+//   - The reserved ID or an error if the query fails.
+func (n *NotesStorage) NextID(ctx context.Context) (int64, error) {
+	return nextID(ctx, n.db, "notes")
+}
+
 // Create inserts a new note into the database.
 //
 // Parameters:
-//   - body dto.CreateNoteDTO: data transfer object containing the note details.
+//   - body dto.CreateNoteDTO: data transfer object containing the ID reserved by NextID and the note details.
 //
 // Returns:
 //   - error: an error if the insertion fails, otherwise nil.
 func (n *NotesStorage) Create(ctx context.Context, body dto.CreateNoteDTO) error {
-	query := `INSERT INTO notes (user_id, title, text_data) VALUES ($1, $2, $3)`
+	query := `INSERT INTO notes (id, user_id, title, text_data) VALUES ($1, $2, $3, $4)`
 
-	_, err := n.db.ExecContext(ctx, query, body.UserID, body.Title, body.TextData)
+	_, err := n.db.ExecContext(ctx, query, body.ID, body.UserID, body.Title, body.TextData)
 	if err != nil {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
@@ -44,18 +53,18 @@ func (n *NotesStorage) Create(ctx context.Context, body dto.CreateNoteDTO) error
 	return nil
 }
 
-// Update modifies an existing note in the database.
+// Update modifies an existing note of the user in the database.
 //
 // Parameters:
 //   - noteID int: the ID of the note to be updated.
-//   - body dto.UpdateNoteDTO: data transfer object containing the updated note details.
+//   - body dto.UpdateNoteDTO: data transfer object containing the owner's ID and the updated note details.
 //
 // Returns:
 //   - error: an error if the update fails, otherwise nil.
 func (n *NotesStorage) Update(ctx context.Context, noteID int, body dto.UpdateNoteDTO) error {
-	query := `UPDATE notes SET title = $1, text_data = $2, updated_at = NOW() WHERE id = $3`
+	query := `UPDATE notes SET title = $1, text_data = $2, updated_at = NOW() WHERE id = $3 AND user_id = $4`
 
-	_, err := n.db.ExecContext(ctx, query, body.Title, body.TextData, noteID)
+	_, err := n.db.ExecContext(ctx, query, body.Title, body.TextData, noteID, body.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to update note: %w", err)
 	}
@@ -22,6 +22,7 @@ func TestNotesStorage_Create(t *testing.T) {
 		TextData: "Test Note Data",
 	}
 
+	body.ID = reserveID(t, storage)
 	err := storage.Create(context.Background(), body)
 	assert.NoError(t, err, "Create should insert a note without error")
 
@@ -42,10 +43,12 @@ func TestNotesStorage_Update(t *testing.T) {
 		Title:    "Test Title",
 		TextData: "Test Note Data",
 	}
+	createBody.ID = reserveID(t, storage)
 	err := storage.Create(context.Background(), createBody)
 	assert.NoError(t, err, "Create should insert a note without error")
 
 	updateBody := dto.UpdateNoteDTO{
+		UserID:   1,
 		Title:    "Updated Title",
 		TextData: "Updated Note Data",
 	}
@@ -74,6 +77,7 @@ func TestNotesStorage_GetAllByUser(t *testing.T) {
 	}
 
 	for _, body := range notesDTOs {
+		body.ID = reserveID(t, storage)
 		err := storage.Create(context.Background(), body)
 		assert.NoError(t, err, "Create should insert a note without error")
 	}
@@ -0,0 +1,823 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// orgMemberColumns are the columns of org_members joined with the username, the member's public key
+// and the version of the organisation key.
+const orgMemberColumns = `
+	m.org_id, m.user_id, u.username, m.role, COALESCE(p.public_key, ''), m.wrapped_key, o.key_version,
+	m.created_at, m.updated_at`
+
+// orgMemberJoins joins org_members aliased m with the user, their key pair and the organisation.
+const orgMemberJoins = `
+	JOIN users u ON u.id = m.user_id
+	JOIN organisations o ON o.id = m.org_id
+	LEFT JOIN user_keypairs p ON p.user_id = m.user_id`
+
+// collectionItemColumns are the columns of collection_items aliased ci joined with the owner's username.
+const collectionItemColumns = `
+	ci.collection_id, ci.item_type, ci.item_id, ci.owner_id, u.username, ci.wrapped_key, ci.created_at, ci.updated_at`
+
+// OrgStorage handles database operations of organisation vaults: organisations, members, collections
+// and collection items.
+type OrgStorage struct {
+	db *sql.DB // Database connection instance.
+}
+
+// NewOrgStorage initializes a new OrgStorage instance.
+//
+// Parameters:
+//   - db *sql.DB: The database connection.
+//
+// Returns:
+//   - *OrgStorage: A pointer to the initialized OrgStorage structure.
+func NewOrgStorage(db *sql.DB) *OrgStorage {
+	return &OrgStorage{db: db}
+}
+
+// CreateOrg stores an organisation with its creator as its owner.
+//
+// Parameters:
+//   - name string: The name of the organisation.
+//   - ownerID int64: The ID of the creator.
+//   - wrappedKey string: The organisation key sealed to the creator's public key.
+//
+// Returns:
+//   - *entities.Organisation: The stored organisation.
+//   - error: An error if a query fails; nothing is stored in that case.
+func (o *OrgStorage) CreateOrg(ctx context.Context, name string, ownerID int64, wrappedKey string) (*entities.Organisation, error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create organisation error: %v", err)
+	}
+	defer tx.Rollback()
+
+	org := entities.Organisation{Name: name, Role: entities.OrgRoleOwner}
+	query := `INSERT INTO organisations (name) VALUES ($1) RETURNING id, key_version, created_at`
+	if err := tx.QueryRowContext(ctx, query, name).Scan(&org.ID, &org.KeyVersion, &org.CreatedAt); err != nil {
+		return nil, fmt.Errorf("store organisation error: %v", err)
+	}
+
+	query = `INSERT INTO org_members (org_id, user_id, role, wrapped_key) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, org.ID, ownerID, entities.OrgRoleOwner, wrappedKey); err != nil {
+		return nil, fmt.Errorf("store organisation owner error: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("create organisation error: %v", err)
+	}
+
+	return &org, nil
+}
+
+// ListOrgs retrieves the organisations a user is a member of, with their role.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - []entities.Organisation: The organisations, by name.
+//   - error: An error if the query fails.
+func (o *OrgStorage) ListOrgs(ctx context.Context, userID int64) ([]entities.Organisation, error) {
+	query := `
+		SELECT o.id, o.name, m.role, o.key_version, o.created_at
+		FROM org_members m JOIN organisations o ON o.id = m.org_id
+		WHERE m.user_id = $1 ORDER BY o.name, o.id`
+	rows, err := o.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list organisations error: %v", err)
+	}
+	defer rows.Close()
+
+	var orgs []entities.Organisation
+	for rows.Next() {
+		var org entities.Organisation
+		if err := rows.Scan(&org.ID, &org.Name, &org.Role, &org.KeyVersion, &org.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan organisation error: %v", err)
+		}
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list organisations error: %v", err)
+	}
+
+	return orgs, nil
+}
+
+// GetMember retrieves a user's membership of an organisation.
+//
+// Parameters:
+//   - orgID int64: The ID of the organisation.
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - *entities.OrgMember: The membership with the sealed organisation key and its version.
+//   - error: apperrors.ErrOrgMemberNotFound if the user is not a member, or a query error.
+func (o *OrgStorage) GetMember(ctx context.Context, orgID, userID int64) (*entities.OrgMember, error) {
+	query := fmt.Sprintf(`SELECT %s FROM org_members m %s WHERE m.org_id = $1 AND m.user_id = $2`, orgMemberColumns, orgMemberJoins)
+	member, err := scanOrgMember(o.db.QueryRowContext(ctx, query, orgID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrOrgMemberNotFound
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// ListMembers retrieves the members of an organisation with their public keys.
+//
+// Parameters:
+//   - orgID int64: The ID of the organisation.
+//
+// Returns:
+//   - []entities.OrgMember: The members, by username.
+//   - error: An error if the query fails.
+func (o *OrgStorage) ListMembers(ctx context.Context, orgID int64) ([]entities.OrgMember, error) {
+	query := fmt.Sprintf(`SELECT %s FROM org_members m %s WHERE m.org_id = $1 ORDER BY u.username`, orgMemberColumns, orgMemberJoins)
+	rows, err := o.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list organisation members error: %v", err)
+	}
+	defer rows.Close()
+
+	var members []entities.OrgMember
+	for rows.Next() {
+		member, err := scanOrgMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan organisation member error: %v", err)
+		}
+		members = append(members, *member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list organisation members error: %v", err)
+	}
+
+	return members, nil
+}
+
+// AddMember adds a user to an organisation.
+//
+// Parameters:
+//   - member entities.OrgMember: The organisation and user IDs, the role, the organisation key sealed
+//     to the user's public key and the version of that key.
+//
+// Returns:
+//   - error: apperrors.ErrOrgNotFound if the organisation does not exist, apperrors.ErrOrgKeyChanged if
+//     its key was rotated since member.KeyVersion, apperrors.ErrOrgMemberExists, or a query error.
+func (o *OrgStorage) AddMember(ctx context.Context, member entities.OrgMember) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("add organisation member error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var version int
+	query := `SELECT key_version FROM organisations WHERE id = $1 FOR SHARE`
+	if err := tx.QueryRowContext(ctx, query, member.OrgID).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrOrgNotFound
+		}
+		return fmt.Errorf("get organisation error: %v", err)
+	}
+	if version != member.KeyVersion {
+		return apperrors.ErrOrgKeyChanged
+	}
+
+	query = `
+		INSERT INTO org_members (org_id, user_id, role, wrapped_key) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (org_id, user_id) DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, member.OrgID, member.UserID, member.Role, member.WrappedKey)
+	if err != nil {
+		return fmt.Errorf("store organisation member error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrOrgMemberExists
+	}
+
+	return tx.Commit()
+}
+
+// UpdateMemberRole changes the role of an organisation member.
+//
+// Parameters:
+//   - orgID int64: The ID of the organisation.
+//   - userID int64: The ID of the member.
+//   - role string: The new role.
+//
+// Returns:
+//   - error: apperrors.ErrOrgMemberNotFound, apperrors.ErrLastOrgOwner if the organisation would have
+//     no owner left, or a query error; nothing is changed in that case.
+func (o *OrgStorage) UpdateMemberRole(ctx context.Context, orgID, userID int64, role string) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("update organisation member error: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOrg(ctx, tx, orgID); err != nil {
+		return err
+	}
+
+	query := `UPDATE org_members SET role = $1, updated_at = NOW() WHERE org_id = $2 AND user_id = $3`
+	res, err := tx.ExecContext(ctx, query, role, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("update organisation member error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrOrgMemberNotFound
+	}
+
+	if err := checkOrgOwner(ctx, tx, orgID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveMember removes a member from an organisation, together with their items in its collections,
+// and replaces the organisation key, so the member cannot unwrap item keys with a key they kept.
+//
+// Parameters:
+//   - body dto.RotateOrgKeyDTO: The member to remove, the replaced key version, and the new key
+//     sealed to every remaining member and wrapping every remaining collection item key.
+//
+// Returns:
+//   - error: apperrors.ErrOrgMemberNotFound, apperrors.ErrLastOrgOwner, apperrors.ErrOrgKeyChanged if
+//     the key was rotated, or a member or collection item added or changed, since body was built, or a
+//     query error; nothing is changed in that case.
+func (o *OrgStorage) RemoveMember(ctx context.Context, body dto.RotateOrgKeyDTO) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("remove organisation member error: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE organisations SET key_version = key_version + 1, updated_at = NOW() WHERE id = $1 AND key_version = $2`
+	res, err := tx.ExecContext(ctx, query, body.OrgID, body.KeyVersion)
+	if err != nil {
+		return fmt.Errorf("rotate organisation key error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrOrgKeyChanged
+	}
+
+	query = `DELETE FROM org_members WHERE org_id = $1 AND user_id = $2`
+	res, err = tx.ExecContext(ctx, query, body.OrgID, body.UserID)
+	if err != nil {
+		return fmt.Errorf("delete organisation member error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrOrgMemberNotFound
+	}
+
+	if err := checkOrgOwner(ctx, tx, body.OrgID); err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM collection_items ci USING collections c
+		WHERE c.id = ci.collection_id AND c.org_id = $1 AND ci.owner_id = $2`
+	if _, err := tx.ExecContext(ctx, query, body.OrgID, body.UserID); err != nil {
+		return fmt.Errorf("delete collection items error: %v", err)
+	}
+
+	for userID, sealed := range body.Members {
+		query = `UPDATE org_members SET wrapped_key = $1, updated_at = NOW() WHERE org_id = $2 AND user_id = $3`
+		if err := execOne(ctx, tx, query, sealed, body.OrgID, userID); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range body.Items {
+		query = `
+			UPDATE collection_items ci SET wrapped_key = $1, updated_at = NOW() FROM collections c
+			WHERE c.id = ci.collection_id AND c.org_id = $2 AND ci.item_type = $3 AND ci.item_id = $4 AND ci.wrapped_key = $5`
+		if err := execOne(ctx, tx, query, item.NewKey, body.OrgID, item.ItemType, item.ItemID, item.OldKey); err != nil {
+			return err
+		}
+	}
+
+	// Members and items added after body was built would keep the old key only.
+	var members, items int
+	query = `
+		SELECT
+			(SELECT COUNT(*) FROM org_members WHERE org_id = $1),
+			(SELECT COUNT(*) FROM collection_items ci JOIN collections c ON c.id = ci.collection_id WHERE c.org_id = $1)`
+	if err := tx.QueryRowContext(ctx, query, body.OrgID).Scan(&members, &items); err != nil {
+		return fmt.Errorf("count organisation members error: %v", err)
+	}
+	if members != len(body.Members) || items != len(body.Items) {
+		return apperrors.ErrOrgKeyChanged
+	}
+
+	return tx.Commit()
+}
+
+// CreateCollection stores a collection of an organisation.
+//
+// Parameters:
+//   - orgID int64: The ID of the organisation.
+//   - name string: The name of the collection, unique within the organisation.
+//
+// Returns:
+//   - *entities.Collection: The stored collection.
+//   - error: apperrors.ErrInvalidOrg if the name is already used, or a query error.
+func (o *OrgStorage) CreateCollection(ctx context.Context, orgID int64, name string) (*entities.Collection, error) {
+	collection := entities.Collection{OrgID: orgID, Name: name}
+	query := `
+		INSERT INTO collections (org_id, name) VALUES ($1, $2)
+		ON CONFLICT (org_id, name) DO NOTHING
+		RETURNING id, created_at`
+	err := o.db.QueryRowContext(ctx, query, orgID, name).Scan(&collection.ID, &collection.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrInvalidOrg
+		}
+		return nil, fmt.Errorf("store collection error: %v", err)
+	}
+
+	return &collection, nil
+}
+
+// ListCollections retrieves the collections of an organisation.
+//
+// Parameters:
+//   - orgID int64: The ID of the organisation.
+//
+// Returns:
+//   - []entities.Collection: The collections, by name.
+//   - error: An error if the query fails.
+func (o *OrgStorage) ListCollections(ctx context.Context, orgID int64) ([]entities.Collection, error) {
+	query := `SELECT id, org_id, name, created_at FROM collections WHERE org_id = $1 ORDER BY name`
+	rows, err := o.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list collections error: %v", err)
+	}
+	defer rows.Close()
+
+	var collections []entities.Collection
+	for rows.Next() {
+		var collection entities.Collection
+		if err := rows.Scan(&collection.ID, &collection.OrgID, &collection.Name, &collection.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan collection error: %v", err)
+		}
+		collections = append(collections, collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list collections error: %v", err)
+	}
+
+	return collections, nil
+}
+
+// GetCollection retrieves a collection by its ID.
+//
+// Parameters:
+//   - collectionID int64: The ID of the collection.
+//
+// Returns:
+//   - *entities.Collection: A pointer to the collection if found.
+//   - error: apperrors.ErrCollectionNotFound if it does not exist, or a query error.
+func (o *OrgStorage) GetCollection(ctx context.Context, collectionID int64) (*entities.Collection, error) {
+	query := `SELECT id, org_id, name, created_at FROM collections WHERE id = $1`
+	var collection entities.Collection
+	err := o.db.QueryRowContext(ctx, query, collectionID).Scan(&collection.ID, &collection.OrgID, &collection.Name, &collection.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrCollectionNotFound
+		}
+		return nil, err
+	}
+
+	return &collection, nil
+}
+
+// DeleteCollection deletes a collection. Its items are removed from the organisation but stay
+// encrypted with their item keys in their owners' vaults.
+//
+// Parameters:
+//   - collectionID int64: The ID of the collection.
+//
+// Returns:
+//   - error: apperrors.ErrCollectionNotFound if it does not exist, or a query error.
+func (o *OrgStorage) DeleteCollection(ctx context.Context, collectionID int64) error {
+	res, err := o.db.ExecContext(ctx, `DELETE FROM collections WHERE id = $1`, collectionID)
+	if err != nil {
+		return fmt.Errorf("delete collection error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrCollectionNotFound
+	}
+
+	return nil
+}
+
+// ListOrgItems retrieves the items of every collection of an organisation, with their wrapped item keys.
+//
+// Parameters:
+//   - orgID int64: The ID of the organisation.
+//
+// Returns:
+//   - []entities.CollectionItem: The collection items.
+//   - error: An error if the query fails.
+func (o *OrgStorage) ListOrgItems(ctx context.Context, orgID int64) ([]entities.CollectionItem, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM collection_items ci
+		JOIN collections c ON c.id = ci.collection_id
+		JOIN users u ON u.id = ci.owner_id
+		WHERE c.org_id = $1 ORDER BY ci.collection_id, ci.item_type, ci.item_id`, collectionItemColumns)
+	return o.listCollectionItems(ctx, query, orgID)
+}
+
+// AddCollectionItem adds an item to a collection. If body.NewItemKey is set, the item is first
+// re-encrypted with its new item key and the key is stored, in the same transaction.
+//
+// Parameters:
+//   - body dto.StoreCollectionItemDTO: The collection item with its item key wrapped by the owner's
+//     data key and by the organisation key.
+//   - reencryptText: Decrypts a text ciphertext with the owner's data key and encrypts it with the
+//     item key; only used when body.NewItemKey is set.
+//   - reencryptBinary: The same for binary ciphertexts.
+//
+// Returns:
+//   - error: apperrors.ErrCollectionItemNotFound if the owner has no such item,
+//     apperrors.ErrCollectionNotFound, apperrors.ErrItemInCollection, apperrors.ErrOrgKeyChanged if the
+//     item key or the organisation key was rotated since body was built, or an error if the item
+//     cannot be re-encrypted or a query fails; nothing is changed in that case.
+func (o *OrgStorage) AddCollectionItem(
+	ctx context.Context,
+	body dto.StoreCollectionItemDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) error {
+	table, err := shareableTable(body.ItemType)
+	if err != nil {
+		return apperrors.ErrInvalidOrg
+	}
+
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("add collection item error: %v", err)
+	}
+	defer tx.Rollback()
+
+	if body.NewItemKey {
+		found, err := rekeyItem(ctx, tx, table, body.OwnerID, body.ItemID, reencryptText, reencryptBinary)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return apperrors.ErrCollectionItemNotFound
+		}
+
+		query := `INSERT INTO item_keys (item_type, item_id, owner_id, wrapped_key) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.ExecContext(ctx, query, body.ItemType, body.ItemID, body.OwnerID, body.ItemKey); err != nil {
+			return fmt.Errorf("store item key error: %v", err)
+		}
+	} else {
+		// The item key is locked, so that a share revoked concurrently cannot rotate it.
+		var ownerID int64
+		query := `
+			SELECT owner_id FROM item_keys
+			WHERE item_type = $1 AND item_id = $2 AND owner_id = $3 AND wrapped_key = $4 FOR SHARE`
+		err := tx.QueryRowContext(ctx, query, body.ItemType, body.ItemID, body.OwnerID, body.ItemKey).Scan(&ownerID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.ErrOrgKeyChanged
+			}
+			return fmt.Errorf("get item key error: %v", err)
+		}
+	}
+
+	var version int
+	query := `
+		SELECT o.key_version FROM collections c JOIN organisations o ON o.id = c.org_id
+		WHERE c.id = $1 FOR SHARE OF o`
+	if err := tx.QueryRowContext(ctx, query, body.CollectionID).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrCollectionNotFound
+		}
+		return fmt.Errorf("get collection error: %v", err)
+	}
+	if version != body.KeyVersion {
+		return apperrors.ErrOrgKeyChanged
+	}
+
+	query = `
+		INSERT INTO collection_items (collection_id, item_type, item_id, owner_id, wrapped_key)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (item_type, item_id) DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, body.CollectionID, body.ItemType, body.ItemID, body.OwnerID, body.WrappedKey)
+	if err != nil {
+		return fmt.Errorf("store collection item error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrItemInCollection
+	}
+
+	return tx.Commit()
+}
+
+// RemoveCollectionItem removes an item from a collection. The item stays encrypted with its item key
+// in its owner's vault.
+//
+// Parameters:
+//   - collectionID int64: The ID of the collection.
+//   - itemType string: The table the item is stored in.
+//   - itemID int64: The ID of the item.
+//
+// Returns:
+//   - error: apperrors.ErrCollectionItemNotFound if the item is not in the collection, or a query error.
+func (o *OrgStorage) RemoveCollectionItem(ctx context.Context, collectionID int64, itemType string, itemID int64) error {
+	query := `DELETE FROM collection_items WHERE collection_id = $1 AND item_type = $2 AND item_id = $3`
+	res, err := o.db.ExecContext(ctx, query, collectionID, itemType, itemID)
+	if err != nil {
+		return fmt.Errorf("delete collection item error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrCollectionItemNotFound
+	}
+
+	return nil
+}
+
+// ListCollectionItems retrieves the items of a collection, as stored. Items that no longer exist
+// are skipped.
+//
+// Parameters:
+//   - collectionID int64: The ID of the collection.
+//
+// Returns:
+//   - []entities.OrgItem: The items with their columns.
+//   - error: An error if a query fails.
+func (o *OrgStorage) ListCollectionItems(ctx context.Context, collectionID int64) ([]entities.OrgItem, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM collection_items ci JOIN users u ON u.id = ci.owner_id
+		WHERE ci.collection_id = $1 ORDER BY ci.item_type, ci.item_id`, collectionItemColumns)
+	collectionItems, err := o.listCollectionItems(ctx, query, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]entities.OrgItem, 0, len(collectionItems))
+	for _, collectionItem := range collectionItems {
+		item, err := o.loadOrgItem(ctx, collectionItem)
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			items = append(items, *item)
+		}
+	}
+
+	return items, nil
+}
+
+// GetCollectionItem retrieves an item of a collection, as stored.
+//
+// Parameters:
+//   - collectionID int64: The ID of the collection.
+//   - itemType string: The table the item is stored in.
+//   - itemID int64: The ID of the item.
+//
+// Returns:
+//   - *entities.OrgItem: The item with its columns.
+//   - error: apperrors.ErrCollectionItemNotFound if the item is not in the collection, or a query error.
+func (o *OrgStorage) GetCollectionItem(ctx context.Context, collectionID int64, itemType string, itemID int64) (*entities.OrgItem, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM collection_items ci JOIN users u ON u.id = ci.owner_id
+		WHERE ci.collection_id = $1 AND ci.item_type = $2 AND ci.item_id = $3`, collectionItemColumns)
+	collectionItem, err := scanCollectionItem(o.db.QueryRowContext(ctx, query, collectionID, itemType, itemID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrCollectionItemNotFound
+		}
+		return nil, err
+	}
+
+	item, err := o.loadOrgItem(ctx, *collectionItem)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, apperrors.ErrCollectionItemNotFound
+	}
+
+	return item, nil
+}
+
+// UpdateCollectionItem stores new ciphertexts of encrypted columns of a collection item.
+//
+// Parameters:
+//   - collectionID int64: The ID of the collection.
+//   - itemType string: The table the item is stored in.
+//   - itemID int64: The ID of the item.
+//   - wrappedKey string: The wrapped item key the ciphertexts were encrypted with; the update is
+//     rejected if the item key or the organisation key was rotated meanwhile.
+//   - text map[string]string: New text ciphertexts by column name.
+//   - binary map[string][]byte: New binary ciphertexts by column name.
+//
+// Returns:
+//   - error: apperrors.ErrOrgKeyChanged if the item is no longer in the collection or a key was
+//     rotated, apperrors.ErrInvalidOrg for a column that is not encrypted, or a query error.
+func (o *OrgStorage) UpdateCollectionItem(
+	ctx context.Context,
+	collectionID int64,
+	itemType string,
+	itemID int64,
+	wrappedKey string,
+	text map[string]string,
+	binary map[string][]byte,
+) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("update collection item error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var ownerID int64
+	query := `
+		SELECT owner_id FROM collection_items
+		WHERE collection_id = $1 AND item_type = $2 AND item_id = $3 AND wrapped_key = $4 FOR SHARE`
+	err = tx.QueryRowContext(ctx, query, collectionID, itemType, itemID, wrappedKey).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrOrgKeyChanged
+		}
+		return fmt.Errorf("get collection item error: %v", err)
+	}
+
+	ok, err := updateItem(ctx, tx, itemType, itemID, ownerID, text, binary)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.ErrInvalidOrg
+	}
+
+	return tx.Commit()
+}
+
+// GetItemOrgMember retrieves the membership of an item's owner in the organisation whose collection
+// holds the item.
+//
+// Parameters:
+//   - itemType string: The table the item is stored in.
+//   - itemID int64: The ID of the item.
+//   - ownerID int64: The ID of the item's owner.
+//
+// Returns:
+//   - *entities.OrgMember: The owner's membership with the sealed organisation key and its version.
+//   - error: apperrors.ErrCollectionItemNotFound if the item is not in a collection, or a query error.
+func (s *SharingStorage) GetItemOrgMember(ctx context.Context, itemType string, itemID, ownerID int64) (*entities.OrgMember, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM collection_items ci
+		JOIN collections c ON c.id = ci.collection_id
+		JOIN org_members m ON m.org_id = c.org_id AND m.user_id = ci.owner_id %s
+		WHERE ci.item_type = $1 AND ci.item_id = $2 AND ci.owner_id = $3`, orgMemberColumns, orgMemberJoins)
+	member, err := scanOrgMember(s.db.QueryRowContext(ctx, query, itemType, itemID, ownerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrCollectionItemNotFound
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// listCollectionItems runs a query selecting collectionItemColumns and scans the items.
+func (o *OrgStorage) listCollectionItems(ctx context.Context, query string, args ...any) ([]entities.CollectionItem, error) {
+	rows, err := o.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list collection items error: %v", err)
+	}
+	defer rows.Close()
+
+	var items []entities.CollectionItem
+	for rows.Next() {
+		item, err := scanCollectionItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan collection item error: %v", err)
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list collection items error: %v", err)
+	}
+
+	return items, nil
+}
+
+// loadOrgItem reads the columns of a collection item; nil if the item no longer exists.
+func (o *OrgStorage) loadOrgItem(ctx context.Context, item entities.CollectionItem) (*entities.OrgItem, error) {
+	columns, err := loadItem(ctx, o.db, item.ItemType, item.ItemID, item.OwnerID)
+	if err != nil || columns == nil {
+		return nil, err
+	}
+
+	return &entities.OrgItem{Item: item, Plain: columns.plain, Text: columns.text, Binary: columns.binary}, nil
+}
+
+// lockOrg locks an organisation row within a transaction, serializing changes of its members.
+func lockOrg(ctx context.Context, tx *sql.Tx, orgID int64) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM organisations WHERE id = $1 FOR UPDATE`, orgID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrOrgNotFound
+		}
+		return fmt.Errorf("lock organisation error: %v", err)
+	}
+
+	return nil
+}
+
+// lockOrgKey locks the organisation key of the collection holding an item against rotation within a
+// transaction; apperrors.ErrOrgKeyChanged if the item is no longer in a collection or the key version
+// is not version.
+func lockOrgKey(ctx context.Context, tx *sql.Tx, itemType string, itemID int64, version int) error {
+	var current int
+	query := `
+		SELECT o.key_version FROM collection_items ci
+		JOIN collections c ON c.id = ci.collection_id
+		JOIN organisations o ON o.id = c.org_id
+		WHERE ci.item_type = $1 AND ci.item_id = $2 FOR SHARE OF o`
+	if err := tx.QueryRowContext(ctx, query, itemType, itemID).Scan(&current); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrOrgKeyChanged
+		}
+		return fmt.Errorf("get organisation key error: %v", err)
+	}
+	if current != version {
+		return apperrors.ErrOrgKeyChanged
+	}
+
+	return nil
+}
+
+// checkOrgOwner returns apperrors.ErrLastOrgOwner if an organisation has no owner left within a transaction.
+func checkOrgOwner(ctx context.Context, tx *sql.Tx, orgID int64) error {
+	var owners int
+	query := `SELECT COUNT(*) FROM org_members WHERE org_id = $1 AND role = $2`
+	if err := tx.QueryRowContext(ctx, query, orgID, entities.OrgRoleOwner).Scan(&owners); err != nil {
+		return fmt.Errorf("count organisation owners error: %v", err)
+	}
+	if owners == 0 {
+		return apperrors.ErrLastOrgOwner
+	}
+
+	return nil
+}
+
+// execOne runs a statement within a transaction that must change exactly one row;
+// apperrors.ErrOrgKeyChanged otherwise.
+func execOne(ctx context.Context, tx *sql.Tx, query string, args ...any) error {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update organisation key error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrOrgKeyChanged
+	}
+
+	return nil
+}
+
+// scanOrgMember scans a row of orgMemberColumns.
+func scanOrgMember(row interface{ Scan(...any) error }) (*entities.OrgMember, error) {
+	var member entities.OrgMember
+	err := row.Scan(
+		&member.OrgID, &member.UserID, &member.Username, &member.Role, &member.PublicKey,
+		&member.WrappedKey, &member.KeyVersion, &member.CreatedAt, &member.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// scanCollectionItem scans a row of collectionItemColumns.
+func scanCollectionItem(row interface{ Scan(...any) error }) (*entities.CollectionItem, error) {
+	var item entities.CollectionItem
+	err := row.Scan(
+		&item.CollectionID, &item.ItemType, &item.ItemID, &item.OwnerID, &item.OwnerUsername,
+		&item.WrappedKey, &item.CreatedAt, &item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgStorage_Members(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	users, notes, storage := NewUserStorage(db), NewNotesStorage(db), NewOrgStorage(db)
+	setupSharing(t, ctx, users, notes)
+
+	org, err := storage.CreateOrg(ctx, "Team", 1, "s1.test")
+	require.NoError(t, err)
+	assert.Equal(t, entities.OrgRoleOwner, org.Role)
+
+	bob := entities.OrgMember{OrgID: org.ID, UserID: 2, Role: entities.OrgRoleMember, WrappedKey: "s1.bob", KeyVersion: org.KeyVersion + 1}
+	assert.ErrorIs(t, storage.AddMember(ctx, bob), apperrors.ErrOrgKeyChanged, "A member sealed with a stale key should be rejected")
+
+	bob.KeyVersion = org.KeyVersion
+	require.NoError(t, storage.AddMember(ctx, bob))
+	assert.ErrorIs(t, storage.AddMember(ctx, bob), apperrors.ErrOrgMemberExists)
+
+	member, err := storage.GetMember(ctx, org.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", member.Username)
+	assert.Equal(t, "x1.bob", member.PublicKey)
+
+	err = storage.UpdateMemberRole(ctx, org.ID, 1, entities.OrgRoleAdmin)
+	assert.ErrorIs(t, err, apperrors.ErrLastOrgOwner)
+
+	orgs, err := storage.ListOrgs(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Equal(t, entities.OrgRoleMember, orgs[0].Role)
+}
+
+func TestOrgStorage_CollectionItems(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	users, notes, storage := NewUserStorage(db), NewNotesStorage(db), NewOrgStorage(db)
+	noteID := setupSharing(t, ctx, users, notes)
+
+	org, err := storage.CreateOrg(ctx, "Team", 1, "s1.test")
+	require.NoError(t, err)
+	require.NoError(t, storage.AddMember(ctx, entities.OrgMember{
+		OrgID: org.ID, UserID: 2, Role: entities.OrgRoleMember, WrappedKey: "s1.bob", KeyVersion: org.KeyVersion,
+	}))
+
+	collection, err := storage.CreateCollection(ctx, org.ID, "Shared")
+	require.NoError(t, err)
+	_, err = storage.CreateCollection(ctx, org.ID, "Shared")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidOrg)
+
+	toItemKey := func(_ dto.VaultFieldDTO, value string) (string, error) { return value + "@item", nil }
+	store := dto.StoreCollectionItemDTO{
+		CollectionID: collection.ID,
+		KeyVersion:   org.KeyVersion,
+		OwnerID:      1,
+		ItemType:     "notes",
+		ItemID:       noteID,
+		WrappedKey:   "w1.org",
+		ItemKey:      "w1.item",
+		NewItemKey:   true,
+	}
+	require.NoError(t, storage.AddCollectionItem(ctx, store, toItemKey, nil))
+
+	store.NewItemKey = false
+	assert.ErrorIs(t, storage.AddCollectionItem(ctx, store, nil, nil), apperrors.ErrItemInCollection)
+
+	items, err := storage.ListCollectionItems(ctx, collection.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, map[string]string{"title": "t@item", "text_data": "d@item"}, items[0].Text)
+	assert.Equal(t, "test", items[0].Item.OwnerUsername)
+
+	err = storage.UpdateCollectionItem(ctx, collection.ID, "notes", noteID, "w1.stale", map[string]string{"title": "new"}, nil)
+	assert.ErrorIs(t, err, apperrors.ErrOrgKeyChanged)
+	err = storage.UpdateCollectionItem(ctx, collection.ID, "notes", noteID, "w1.org", map[string]string{"user_id": "2"}, nil)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidOrg)
+	require.NoError(t, storage.UpdateCollectionItem(ctx, collection.ID, "notes", noteID, "w1.org", map[string]string{"title": "new"}, nil))
+
+	member, err := NewSharingStorage(db).GetItemOrgMember(ctx, "notes", noteID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, member.OrgID)
+
+	err = storage.RemoveMember(ctx, dto.RotateOrgKeyDTO{
+		OrgID:      org.ID,
+		UserID:     1,
+		KeyVersion: org.KeyVersion,
+		Members:    map[int64]string{2: "s1.bob2"},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrLastOrgOwner)
+
+	require.NoError(t, storage.UpdateMemberRole(ctx, org.ID, 2, entities.OrgRoleOwner))
+	require.NoError(t, storage.RemoveMember(ctx, dto.RotateOrgKeyDTO{
+		OrgID:      org.ID,
+		UserID:     1,
+		KeyVersion: org.KeyVersion,
+		Members:    map[int64]string{2: "s1.bob2"},
+	}))
+
+	items, err = storage.ListCollectionItems(ctx, collection.ID)
+	require.NoError(t, err)
+	assert.Empty(t, items, "The removed member's items should leave the organisation")
+
+	bob, err := storage.GetMember(ctx, org.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "s1.bob2", bob.WrappedKey)
+	assert.Equal(t, org.KeyVersion+1, bob.KeyVersion)
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// GetRecovery retrieves the data key of a user wrapped by their recovery key.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - *entities.UserRecovery: A pointer to the user's recovery-wrapped key if found.
+//   - error: apperrors.ErrRecoveryNotFound if the user has no recovery key, or a query error.
+func (u *UserStorage) GetRecovery(ctx context.Context, userID int64) (*entities.UserRecovery, error) {
+	query := `SELECT user_id, wrapped_key, created_at, updated_at FROM user_recovery WHERE user_id = $1`
+	row := u.db.QueryRowContext(ctx, query, userID)
+	var recovery entities.UserRecovery
+	err := row.Scan(&recovery.UserID, &recovery.WrappedKey, &recovery.CreatedAt, &recovery.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrRecoveryNotFound
+		}
+		return nil, err
+	}
+
+	return &recovery, nil
+}
+
+// ReplaceRecovery atomically replaces a user's recovery key and recovery codes; codes issued
+// before, used or not, stop working.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//   - body dto.RecoveryDTO: The data key wrapped by the new recovery key and the hashes of the new codes.
+//
+// Returns:
+//   - error: An error if a query fails; nothing is changed in that case.
+func (u *UserStorage) ReplaceRecovery(ctx context.Context, userID int64, body dto.RecoveryDTO) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("replace recovery error: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecovery(ctx, tx, userID, body); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("replace recovery error: %v", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of a user as used.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//   - codeHash string: The hash of the code, as stored.
+//
+// Returns:
+//   - bool: true if the code was unused and is now spent, false if it does not exist or was used before.
+//   - error: An error if the query fails.
+func (u *UserStorage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	query := `
+		UPDATE recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	res, err := u.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code error: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("use recovery code error: %v", err)
+	}
+
+	return n == 1, nil
+}
+
+// replaceRecovery stores a user's recovery-wrapped data key and replaces their recovery codes within a transaction.
+func replaceRecovery(ctx context.Context, tx *sql.Tx, userID int64, body dto.RecoveryDTO) error {
+	if err := upsertRecoveryKey(ctx, tx, userID, body.WrappedKey); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes error: %v", err)
+	}
+
+	for _, hash := range body.CodeHashes {
+		query := `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, userID, hash); err != nil {
+			return fmt.Errorf("store recovery code error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// upsertRecoveryKey stores a user's recovery-wrapped data key within a transaction, replacing any previous one
+// together with its recovery shares and recovery sessions.
+func upsertRecoveryKey(ctx context.Context, tx *sql.Tx, userID int64, wrappedKey string) error {
+	query := `
+		INSERT INTO user_recovery (user_id, wrapped_key)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET wrapped_key = EXCLUDED.wrapped_key, updated_at = NOW()
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, wrappedKey); err != nil {
+		return fmt.Errorf("store recovery key error: %v", err)
+	}
+
+	// Shares of the previous recovery key cannot reconstruct the new one.
+	if err := deleteRecoveryShares(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// recoveryShareColumns are the columns of recovery_shares aliased s joined with the usernames of the
+// owner (aliased o) and the officer (aliased f).
+const recoveryShareColumns = `
+	s.id, s.owner_id, o.username, s.officer_id, f.username, s.share_index, s.threshold, s.sealed_share, s.created_at`
+
+// recoveryShareFrom selects from recovery_shares joined with the owner and the officer.
+const recoveryShareFrom = `
+	FROM recovery_shares s
+	JOIN users o ON o.id = s.owner_id
+	JOIN users f ON f.id = s.officer_id`
+
+// RecoveryShareStorage handles database operations of recovery keys split into shares among officers.
+type RecoveryShareStorage struct {
+	db *sql.DB // Database connection instance.
+}
+
+// NewRecoveryShareStorage initializes a new RecoveryShareStorage instance.
+//
+// Parameters:
+//   - db *sql.DB: The database connection.
+//
+// Returns:
+//   - *RecoveryShareStorage: A pointer to the initialized RecoveryShareStorage structure.
+func NewRecoveryShareStorage(db *sql.DB) *RecoveryShareStorage {
+	return &RecoveryShareStorage{db: db}
+}
+
+// ReplaceRecoveryShares atomically replaces a user's recovery key with one split into shares. The
+// previous recovery key, its shares and recovery sessions stop working; recovery codes are kept.
+//
+// Parameters:
+//   - ownerID int64: The ID of the user.
+//   - wrappedKey string: The data key wrapped by the new recovery key.
+//   - shares []entities.RecoveryShare: The shares of the new recovery key sealed to their officers.
+//
+// Returns:
+//   - error: An error if a query fails; nothing is changed in that case.
+func (r *RecoveryShareStorage) ReplaceRecoveryShares(ctx context.Context, ownerID int64, wrappedKey string, shares []entities.RecoveryShare) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("replace recovery shares error: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertRecoveryKey(ctx, tx, ownerID, wrappedKey); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO recovery_shares (owner_id, officer_id, share_index, threshold, sealed_share)
+		VALUES ($1, $2, $3, $4, $5)`
+	for _, share := range shares {
+		if _, err := tx.ExecContext(ctx, query, ownerID, share.OfficerID, share.ShareIndex, share.Threshold, share.SealedShare); err != nil {
+			return fmt.Errorf("store recovery share error: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("replace recovery shares error: %v", err)
+	}
+
+	return nil
+}
+
+// ListSharesByOwner retrieves the shares of a user's recovery key.
+//
+// Parameters:
+//   - ownerID int64: The ID of the user.
+//
+// Returns:
+//   - []entities.RecoveryShare: The shares, by index.
+//   - error: An error if the query fails.
+func (r *RecoveryShareStorage) ListSharesByOwner(ctx context.Context, ownerID int64) ([]entities.RecoveryShare, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE s.owner_id = $1 ORDER BY s.share_index`, recoveryShareColumns, recoveryShareFrom)
+	return r.listRecoveryShares(ctx, query, ownerID)
+}
+
+// ListSharesByOfficer retrieves the recovery shares held by a user.
+//
+// Parameters:
+//   - officerID int64: The ID of the officer.
+//
+// Returns:
+//   - []entities.RecoveryShare: The shares, by owner username.
+//   - error: An error if the query fails.
+func (r *RecoveryShareStorage) ListSharesByOfficer(ctx context.Context, officerID int64) ([]entities.RecoveryShare, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE s.officer_id = $1 ORDER BY o.username`, recoveryShareColumns, recoveryShareFrom)
+	return r.listRecoveryShares(ctx, query, officerID)
+}
+
+// GetOfficerShare retrieves the share of a user's recovery key held by an officer.
+//
+// Parameters:
+//   - ownerID int64: The ID of the owner of the recovery key.
+//   - officerID int64: The ID of the officer.
+//
+// Returns:
+//   - *entities.RecoveryShare: A pointer to the share if found.
+//   - error: apperrors.ErrRecoveryShareNotFound if the officer holds no share of the owner, or a query error.
+func (r *RecoveryShareStorage) GetOfficerShare(ctx context.Context, ownerID, officerID int64) (*entities.RecoveryShare, error) {
+	query := fmt.Sprintf(`SELECT %s %s WHERE s.owner_id = $1 AND s.officer_id = $2`, recoveryShareColumns, recoveryShareFrom)
+	share, err := scanRecoveryShare(r.db.QueryRowContext(ctx, query, ownerID, officerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrRecoveryShareNotFound
+		}
+		return nil, err
+	}
+
+	return share, nil
+}
+
+// CreateRecoverySession starts a recovery session of a user whose recovery key is split into shares.
+//
+// Parameters:
+//   - username string: The username of the recovered account.
+//   - publicKey string: The public key the submitted shares are sealed to.
+//   - expiresAt time.Time: When the session expires.
+//
+// Returns:
+//   - *entities.RecoverySession: The created session.
+//   - error: apperrors.ErrRecoverySharesNotFound if the user does not exist or has no shares, or a query error.
+func (r *RecoveryShareStorage) CreateRecoverySession(ctx context.Context, username, publicKey string, expiresAt time.Time) (*entities.RecoverySession, error) {
+	query := `
+		INSERT INTO recovery_sessions (owner_id, public_key, expires_at)
+		SELECT u.id, $2, $3 FROM users u
+		WHERE u.username = $1 AND EXISTS (SELECT 1 FROM recovery_shares s WHERE s.owner_id = u.id)
+		RETURNING id`
+	var id int64
+	if err := r.db.QueryRowContext(ctx, query, username, publicKey, expiresAt).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrRecoverySharesNotFound
+		}
+		return nil, fmt.Errorf("create recovery session error: %v", err)
+	}
+
+	return r.GetRecoverySession(ctx, id)
+}
+
+// GetRecoverySession retrieves an unexpired recovery session with the threshold of the owner's shares
+// and the number of shares submitted.
+//
+// Parameters:
+//   - id int64: The ID of the session.
+//
+// Returns:
+//   - *entities.RecoverySession: A pointer to the session if found.
+//   - error: apperrors.ErrRecoverySessionNotFound if it does not exist or has expired, or a query error.
+func (r *RecoveryShareStorage) GetRecoverySession(ctx context.Context, id int64) (*entities.RecoverySession, error) {
+	query := `
+		SELECT r.id, r.owner_id, u.username, r.public_key, r.expires_at, r.created_at,
+			(SELECT COALESCE(MAX(s.threshold), 0) FROM recovery_shares s WHERE s.owner_id = r.owner_id),
+			(SELECT COUNT(*) FROM recovery_submissions m WHERE m.session_id = r.id)
+		FROM recovery_sessions r
+		JOIN users u ON u.id = r.owner_id
+		WHERE r.id = $1 AND r.expires_at > NOW()`
+	var session entities.RecoverySession
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&session.ID, &session.OwnerID, &session.OwnerUsername, &session.PublicKey, &session.ExpiresAt,
+		&session.CreatedAt, &session.Threshold, &session.Submitted,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrRecoverySessionNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// AddRecoverySubmission stores a recovery share submitted to a session.
+//
+// Parameters:
+//   - submission entities.RecoverySubmission: The session, the share index and officer and the share
+//     sealed to the session's public key.
+//
+// Returns:
+//   - error: apperrors.ErrRecoveryShareSubmitted if the share was submitted before, or a query error.
+func (r *RecoveryShareStorage) AddRecoverySubmission(ctx context.Context, submission entities.RecoverySubmission) error {
+	query := `
+		INSERT INTO recovery_submissions (session_id, share_index, officer_id, sealed_share)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (session_id, share_index) DO NOTHING`
+	res, err := r.db.ExecContext(ctx, query, submission.SessionID, submission.ShareIndex, submission.OfficerID, submission.SealedShare)
+	if err != nil {
+		return fmt.Errorf("store recovery submission error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrRecoveryShareSubmitted
+	}
+
+	return nil
+}
+
+// ListRecoverySubmissions retrieves the recovery shares submitted to a session.
+//
+// Parameters:
+//   - sessionID int64: The ID of the session.
+//
+// Returns:
+//   - []entities.RecoverySubmission: The submitted shares, by index.
+//   - error: An error if the query fails.
+func (r *RecoveryShareStorage) ListRecoverySubmissions(ctx context.Context, sessionID int64) ([]entities.RecoverySubmission, error) {
+	query := `
+		SELECT session_id, share_index, officer_id, sealed_share
+		FROM recovery_submissions WHERE session_id = $1 ORDER BY share_index`
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list recovery submissions error: %v", err)
+	}
+	defer rows.Close()
+
+	var submissions []entities.RecoverySubmission
+	for rows.Next() {
+		var submission entities.RecoverySubmission
+		if err := rows.Scan(&submission.SessionID, &submission.ShareIndex, &submission.OfficerID, &submission.SealedShare); err != nil {
+			return nil, fmt.Errorf("scan recovery submission error: %v", err)
+		}
+		submissions = append(submissions, submission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list recovery submissions error: %v", err)
+	}
+
+	return submissions, nil
+}
+
+// listRecoveryShares runs a query selecting recoveryShareColumns and scans the shares.
+func (r *RecoveryShareStorage) listRecoveryShares(ctx context.Context, query string, args ...any) ([]entities.RecoveryShare, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list recovery shares error: %v", err)
+	}
+	defer rows.Close()
+
+	var shares []entities.RecoveryShare
+	for rows.Next() {
+		share, err := scanRecoveryShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan recovery share error: %v", err)
+		}
+		shares = append(shares, *share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list recovery shares error: %v", err)
+	}
+
+	return shares, nil
+}
+
+// scanRecoveryShare scans a row of recoveryShareColumns.
+func scanRecoveryShare(row interface{ Scan(...any) error }) (*entities.RecoveryShare, error) {
+	var share entities.RecoveryShare
+	err := row.Scan(
+		&share.ID, &share.OwnerID, &share.OwnerUsername, &share.OfficerID, &share.OfficerUsername,
+		&share.ShareIndex, &share.Threshold, &share.SealedShare, &share.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+// deleteRecoveryShares deletes the recovery shares and recovery sessions of a user within a transaction.
+func deleteRecoveryShares(ctx context.Context, tx *sql.Tx, ownerID int64) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_sessions WHERE owner_id = $1`, ownerID); err != nil {
+		return fmt.Errorf("delete recovery sessions error: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_shares WHERE owner_id = $1`, ownerID); err != nil {
+		return fmt.Errorf("delete recovery shares error: %v", err)
+	}
+
+	return nil
+}
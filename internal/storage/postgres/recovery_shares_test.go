@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryShareStorage(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	users, notes, storage := NewUserStorage(db), NewNotesStorage(db), NewRecoveryShareStorage(db)
+	setupSharing(t, ctx, users, notes)
+
+	_, err := storage.CreateRecoverySession(ctx, "test", "x1.session", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, apperrors.ErrRecoverySharesNotFound, "A session needs shares to collect")
+
+	shares := []entities.RecoveryShare{
+		{OwnerID: 1, OfficerID: 2, ShareIndex: 1, Threshold: 2, SealedShare: "s1.first"},
+		{OwnerID: 1, OfficerID: 1, ShareIndex: 2, Threshold: 2, SealedShare: "s1.second"},
+	}
+	require.NoError(t, storage.ReplaceRecoveryShares(ctx, 1, "w1.recovery", shares))
+
+	held, err := storage.ListSharesByOfficer(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, held, 1)
+	assert.Equal(t, "test", held[0].OwnerUsername)
+	assert.Equal(t, "bob", held[0].OfficerUsername)
+
+	share, err := storage.GetOfficerShare(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "s1.first", share.SealedShare)
+
+	expired, err := storage.CreateRecoverySession(ctx, "test", "x1.old", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = storage.GetRecoverySession(ctx, expired.ID)
+	assert.ErrorIs(t, err, apperrors.ErrRecoverySessionNotFound, "An expired session should not be found")
+
+	session, err := storage.CreateRecoverySession(ctx, "test", "x1.session", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, session.Threshold)
+
+	submission := entities.RecoverySubmission{SessionID: session.ID, ShareIndex: 1, OfficerID: 2, SealedShare: "s1.session"}
+	require.NoError(t, storage.AddRecoverySubmission(ctx, submission))
+	assert.ErrorIs(t, storage.AddRecoverySubmission(ctx, submission), apperrors.ErrRecoveryShareSubmitted)
+
+	session, err = storage.GetRecoverySession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, session.Submitted)
+	assert.Equal(t, "x1.session", session.PublicKey)
+
+	submissions, err := storage.ListRecoverySubmissions(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []entities.RecoverySubmission{submission}, submissions)
+
+	require.NoError(t, storage.ReplaceRecoveryShares(ctx, 1, "w1.rotated", shares[:1]))
+
+	_, err = storage.GetRecoverySession(ctx, session.ID)
+	assert.ErrorIs(t, err, apperrors.ErrRecoverySessionNotFound, "Replacing the shares should drop open sessions")
+
+	owned, err := storage.ListSharesByOwner(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, owned, 1)
+}
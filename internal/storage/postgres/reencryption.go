@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/lib/pq"
+)
+
+// ReencryptionStorage handles the database operations of the background key rotation and
+// re-encryption job: its checkpoints and the batched walks over user keys and vault tables.
+type ReencryptionStorage struct {
+	db *sql.DB // Database connection instance.
+}
+
+// NewReencryptionStorage initializes a new ReencryptionStorage instance.
+//
+// Parameters:
+//   - db *sql.DB: The database connection.
+//
+// Returns:
+//   - *ReencryptionStorage: A pointer to the initialized ReencryptionStorage structure.
+func NewReencryptionStorage(db *sql.DB) *ReencryptionStorage {
+	return &ReencryptionStorage{db: db}
+}
+
+// reencryptionJobColumns lists the columns of reencryption_jobs in the order scanned by scanReencryptionJob.
+const reencryptionJobColumns = `id, status, phase, last_id, scanned, rewrapped, kdf_pending, outdated, flagged,
+	batch_size, batch_delay_ms, error, created_at, updated_at, finished_at`
+
+// GetLatestJob retrieves the most recently created re-encryption job.
+//
+// Returns:
+//   - *entities.ReencryptionJob: The job checkpoint.
+//   - error: apperrors.ErrReencryptionJobNotFound if no job was ever created, or a query error.
+func (s *ReencryptionStorage) GetLatestJob(ctx context.Context) (*entities.ReencryptionJob, error) {
+	query := fmt.Sprintf(`SELECT %s FROM reencryption_jobs ORDER BY id DESC LIMIT 1`, reencryptionJobColumns)
+	job, err := scanReencryptionJob(s.db.QueryRowContext(ctx, query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrReencryptionJobNotFound
+		}
+		return nil, fmt.Errorf("get reencryption job error: %v", err)
+	}
+
+	return job, nil
+}
+
+// CreateJob inserts a new re-encryption job.
+//
+// Parameters:
+//   - job entities.ReencryptionJob: The initial checkpoint; its ID and timestamps are ignored.
+//
+// Returns:
+//   - *entities.ReencryptionJob: The stored job with its ID and timestamps.
+//   - error: An error if the query fails.
+func (s *ReencryptionStorage) CreateJob(ctx context.Context, job entities.ReencryptionJob) (*entities.ReencryptionJob, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO reencryption_jobs (status, phase, last_id, batch_size, batch_delay_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING %s`, reencryptionJobColumns)
+	created, err := scanReencryptionJob(s.db.QueryRowContext(ctx, query,
+		job.Status, job.Phase, job.LastID, job.BatchSize, job.BatchDelayMS))
+	if err != nil {
+		return nil, fmt.Errorf("create reencryption job error: %v", err)
+	}
+
+	return created, nil
+}
+
+// SaveJob stores the checkpoint of a re-encryption job: its status, position, counters and throttle.
+//
+// Parameters:
+//   - job entities.ReencryptionJob: The job to store, identified by its ID.
+//
+// Returns:
+//   - error: An error if the query fails.
+func (s *ReencryptionStorage) SaveJob(ctx context.Context, job entities.ReencryptionJob) error {
+	query := `
+		UPDATE reencryption_jobs
+		SET status = $1, phase = $2, last_id = $3, scanned = $4, rewrapped = $5, kdf_pending = $6,
+			outdated = $7, flagged = $8, batch_size = $9, batch_delay_ms = $10, error = $11,
+			finished_at = $12, updated_at = NOW()
+		WHERE id = $13`
+	_, err := s.db.ExecContext(ctx, query,
+		job.Status, job.Phase, job.LastID, job.Scanned, job.Rewrapped, job.KDFPending,
+		job.Outdated, job.Flagged, job.BatchSize, job.BatchDelayMS, job.Error,
+		job.FinishedAt, job.ID)
+	if err != nil {
+		return fmt.Errorf("save reencryption job error: %v", err)
+	}
+
+	return nil
+}
+
+// ListUserKeys retrieves a batch of wrapped data keys in order of user ID.
+//
+// Parameters:
+//   - afterUserID int64: Only keys of users with a greater ID are returned.
+//   - limit int: The maximum number of keys to return.
+//
+// Returns:
+//   - []entities.UserKeys: The keys; fewer than limit once the end of the table is reached.
+//   - error: An error if the query fails.
+func (s *ReencryptionStorage) ListUserKeys(ctx context.Context, afterUserID int64, limit int) ([]entities.UserKeys, error) {
+	query := `
+		SELECT user_id, kdf, wrapped_key, aad_bound, reencrypt_pending, created_at, updated_at
+		FROM user_keys WHERE user_id > $1 ORDER BY user_id LIMIT $2`
+	rows, err := s.db.QueryContext(ctx, query, afterUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list user keys error: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []entities.UserKeys
+	for rows.Next() {
+		var k entities.UserKeys
+		err := rows.Scan(&k.UserID, &k.KDF, &k.WrappedKey, &k.AADBound, &k.ReencryptPending, &k.CreatedAt, &k.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan user keys error: %v", err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list user keys error: %v", err)
+	}
+
+	return keys, nil
+}
+
+// ReplaceWrappedKey replaces a user's wrapped data key, provided it has not changed since it was read,
+// so that a key re-wrapped by a concurrent login or password change is never overwritten.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//   - oldWrapped string: The wrapped key the new one was derived from.
+//   - newWrapped string: The new wrapped key.
+//
+// Returns:
+//   - bool: Whether the key was replaced.
+//   - error: An error if the query fails.
+func (s *ReencryptionStorage) ReplaceWrappedKey(ctx context.Context, userID int64, oldWrapped, newWrapped string) (bool, error) {
+	query := `UPDATE user_keys SET wrapped_key = $1, updated_at = NOW() WHERE user_id = $2 AND wrapped_key = $3`
+	res, err := s.db.ExecContext(ctx, query, newWrapped, userID, oldWrapped)
+	if err != nil {
+		return false, fmt.Errorf("replace wrapped key error: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("replace wrapped key error: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+// ListVaultRows retrieves a batch of rows of a vault table in order of ID, with the values
+// of their encrypted columns. Shared items are skipped: they are encrypted with their own item key
+// and re-encrypted whenever a share is revoked, not on their owner's login.
+//
+// Parameters:
+//   - table string: The name of the vault table.
+//   - afterID int64: Only rows with a greater ID are returned.
+//   - limit int: The maximum number of rows to return.
+//
+// Returns:
+//   - []dto.VaultRowDTO: The rows; fewer than limit once the end of the table is reached.
+//   - error: An error if the table is not a vault table or the query fails.
+func (s *ReencryptionStorage) ListVaultRows(ctx context.Context, table string, afterID int64, limit int) ([]dto.VaultRowDTO, error) {
+	vt, ok := findVaultTable(table)
+	if !ok {
+		return nil, fmt.Errorf("unknown vault table %q", table)
+	}
+
+	columns := append(append([]string{}, vt.text...), vt.binary...)
+	query := fmt.Sprintf(
+		`SELECT id, user_id, %s FROM %s WHERE id > $1 AND %s ORDER BY id LIMIT $2`,
+		strings.Join(columns, ", "), vt.name, notShared(vt),
+	)
+	rows, err := s.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list %s error: %v", vt.name, err)
+	}
+	defer rows.Close()
+
+	var result []dto.VaultRowDTO
+	for rows.Next() {
+		r := dto.VaultRowDTO{
+			Text:   make([]string, len(vt.text)),
+			Binary: make([][]byte, len(vt.binary)),
+		}
+
+		dest := []any{&r.ItemID, &r.UserID}
+		for i := range r.Text {
+			dest = append(dest, &r.Text[i])
+		}
+		for i := range r.Binary {
+			dest = append(dest, &r.Binary[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scan %s error: %v", vt.name, err)
+		}
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list %s error: %v", vt.name, err)
+	}
+
+	return result, nil
+}
+
+// FlagReencrypt marks the vaults of the given users for re-encryption on their next login.
+// Users without a data key are skipped: their vault is migrated on their next login anyway.
+//
+// Parameters:
+//   - userIDs []int64: The IDs of the users.
+//
+// Returns:
+//   - int64: The number of users that were not flagged yet.
+//   - error: An error if the query fails.
+func (s *ReencryptionStorage) FlagReencrypt(ctx context.Context, userIDs []int64) (int64, error) {
+	query := `
+		UPDATE user_keys SET reencrypt_pending = TRUE
+		WHERE user_id = ANY($1) AND NOT reencrypt_pending`
+	res, err := s.db.ExecContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return 0, fmt.Errorf("flag reencrypt error: %v", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("flag reencrypt error: %v", err)
+	}
+
+	return affected, nil
+}
+
+// scanReencryptionJob scans a row of reencryptionJobColumns.
+func scanReencryptionJob(row *sql.Row) (*entities.ReencryptionJob, error) {
+	var (
+		job        entities.ReencryptionJob
+		finishedAt sql.NullTime
+	)
+
+	err := row.Scan(&job.ID, &job.Status, &job.Phase, &job.LastID, &job.Scanned, &job.Rewrapped, &job.KDFPending,
+		&job.Outdated, &job.Flagged, &job.BatchSize, &job.BatchDelayMS, &job.Error, &job.CreatedAt, &job.UpdatedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReencryptionStorage_Jobs(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	storage := NewReencryptionStorage(db)
+
+	_, err := storage.GetLatestJob(ctx)
+	assert.ErrorIs(t, err, apperrors.ErrReencryptionJobNotFound)
+
+	job, err := storage.CreateJob(ctx, entities.ReencryptionJob{Status: "running", Phase: "user_keys", BatchSize: 100, BatchDelayMS: 50})
+	require.NoError(t, err, "CreateJob should not return an error")
+	assert.NotZero(t, job.ID)
+	assert.Nil(t, job.FinishedAt)
+
+	finishedAt := time.Now()
+	job.Status, job.Phase, job.LastID, job.Scanned, job.Flagged, job.FinishedAt = "completed", "notes", 42, 120, 3, &finishedAt
+	require.NoError(t, storage.SaveJob(ctx, *job), "SaveJob should not return an error")
+
+	latest, err := storage.GetLatestJob(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, latest.ID)
+	assert.Equal(t, "completed", latest.Status)
+	assert.Equal(t, "notes", latest.Phase)
+	assert.Equal(t, int64(42), latest.LastID)
+	assert.Equal(t, int64(120), latest.Scanned)
+	assert.Equal(t, int64(3), latest.Flagged)
+	assert.NotNil(t, latest.FinishedAt)
+}
+
+func TestReencryptionStorage_UserKeys(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	err := NewVaultStorage(db).Rekey(ctx, dto.VaultRekeyDTO{UserID: 1, Keys: dto.UserKeysDTO{KDF: "$argon2id$k", WrappedKey: "sw2.1.old"}}, nil, nil)
+	require.NoError(t, err)
+
+	storage := NewReencryptionStorage(db)
+
+	keys, err := storage.ListUserKeys(ctx, 0, 10)
+	require.NoError(t, err, "ListUserKeys should not return an error")
+	require.Len(t, keys, 1)
+	assert.Equal(t, "sw2.1.old", keys[0].WrappedKey)
+
+	keys, err = storage.ListUserKeys(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, keys, "Keys up to the cursor should be skipped")
+
+	replaced, err := storage.ReplaceWrappedKey(ctx, 1, "sw2.1.old", "sw2.2.new")
+	require.NoError(t, err)
+	assert.True(t, replaced)
+
+	replaced, err = storage.ReplaceWrappedKey(ctx, 1, "sw2.1.old", "sw2.2.other")
+	require.NoError(t, err)
+	assert.False(t, replaced, "A key changed since it was read should not be overwritten")
+
+	stored, err := NewUserStorage(db).GetUserKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "sw2.2.new", stored.WrappedKey)
+}
+
+func TestReencryptionStorage_VaultRows(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	err := NewVaultStorage(db).Rekey(ctx, dto.VaultRekeyDTO{UserID: 1, Keys: dto.UserKeysDTO{KDF: "$argon2id$k", WrappedKey: "w1.k"}}, nil, nil)
+	require.NoError(t, err)
+
+	binaries := NewBinaryStorage(db)
+	fileID := reserveID(t, binaries)
+	err = binaries.Create(ctx, dto.SetStorageBinaryDTO{ID: fileID, UserID: 1, Title: "f", Data: []byte("b")})
+	require.NoError(t, err)
+
+	storage := NewReencryptionStorage(db)
+
+	rows, err := storage.ListVaultRows(ctx, "binary_data", 0, 10)
+	require.NoError(t, err, "ListVaultRows should not return an error")
+	require.Len(t, rows, 1)
+	assert.Equal(t, dto.VaultRowDTO{UserID: 1, ItemID: fileID, Text: []string{"f"}, Binary: [][]byte{[]byte("b")}}, rows[0])
+
+	_, err = storage.ListVaultRows(ctx, "users", 0, 10)
+	assert.Error(t, err, "Only vault tables should be listed")
+
+	flagged, err := storage.FlagReencrypt(ctx, []int64{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), flagged)
+
+	flagged, err = storage.FlagReencrypt(ctx, []int64{1})
+	require.NoError(t, err)
+	assert.Zero(t, flagged, "A flagged user should not be counted twice")
+
+	keys, err := NewUserStorage(db).GetUserKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, keys.ReencryptPending)
+
+	err = NewVaultStorage(db).Rekey(ctx, dto.VaultRekeyDTO{UserID: 1, Keys: dto.UserKeysDTO{KDF: "$argon2id$k", WrappedKey: "w1.k", AADBound: true}},
+		func(_ dto.VaultFieldDTO, value string) (string, error) { return value, nil },
+		func(_ dto.VaultFieldDTO, value []byte) ([]byte, error) { return value, nil },
+	)
+	require.NoError(t, err)
+
+	keys, err = NewUserStorage(db).GetUserKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, keys.ReencryptPending, "Re-encrypting the vault should clear the flag")
+}
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// CreateSession stores a new session, started by a login.
+//
+// Parameters:
+//   - session entities.Session: The session ID, its user and device, the ID of its first refresh token
+//     and its expiration time.
+//
+// Returns:
+//   - error: An error if the query fails.
+func (u *UserStorage) CreateSession(ctx context.Context, session entities.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, device_name, user_agent, ip, current_token_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := u.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.DeviceName,
+		session.UserAgent,
+		session.IP,
+		session.CurrentTokenID,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create session error: %v", err)
+	}
+
+	return nil
+}
+
+// GetSession retrieves a session, revoked and expired ones included.
+//
+// Parameters:
+//   - id string: The ID of the session.
+//
+// Returns:
+//   - *entities.Session: A pointer to the session if found.
+//   - error: apperrors.ErrSessionNotFound if the session does not exist, or a query error.
+func (u *UserStorage) GetSession(ctx context.Context, id string) (*entities.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, user_agent, ip, current_token_id, expires_at, revoked_at, created_at, last_seen_at
+		FROM sessions WHERE id = $1`
+	session, err := scanSession(u.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("get session error: %v", err)
+	}
+
+	return session, nil
+}
+
+// ListSessions retrieves the active sessions of a user, most recently seen first. Revoked and
+// expired sessions are left out.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - []entities.Session: The user's active sessions.
+//   - error: An error if the query fails.
+func (u *UserStorage) ListSessions(ctx context.Context, userID int64) ([]entities.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, user_agent, ip, current_token_id, expires_at, revoked_at, created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC, created_at DESC`
+	rows, err := u.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions error: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []entities.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list sessions error: %v", err)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list sessions error: %v", err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records that an active session of a user was just used.
+//
+// Parameters:
+//   - userID int64: The ID of the user the session must belong to.
+//   - id string: The ID of the session.
+//
+// Returns:
+//   - error: apperrors.ErrSessionNotFound if the user has no such session or it is revoked or expired,
+//     or a query error.
+func (u *UserStorage) TouchSession(ctx context.Context, userID int64, id string) error {
+	query := `
+		UPDATE sessions SET last_seen_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()`
+	return u.execSessionUpdate(ctx, "touch session", query, id, userID)
+}
+
+// RotateRefreshToken replaces the current refresh token of a session and extends the session's
+// expiration time. The token is only replaced if oldTokenID is still current and the session is neither
+// revoked nor expired, so that of two concurrent refreshes with the same token only one succeeds.
+//
+// Parameters:
+//   - id string: The ID of the session.
+//   - oldTokenID string: The ID of the token being rotated.
+//   - newTokenID string: The ID of the token replacing it.
+//   - expiresAt time.Time: The new expiration time of the session.
+//
+// Returns:
+//   - error: apperrors.ErrRefreshTokenReused if oldTokenID is not the current token of a valid session,
+//     or a query error.
+func (u *UserStorage) RotateRefreshToken(ctx context.Context, id, oldTokenID, newTokenID string, expiresAt time.Time) error {
+	query := `
+		UPDATE sessions
+		SET current_token_id = $3, expires_at = $4, last_seen_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND current_token_id = $2 AND revoked_at IS NULL AND expires_at > NOW()`
+	err := u.execSessionUpdate(ctx, "rotate refresh token", query, id, oldTokenID, newTokenID, expiresAt)
+	if err == apperrors.ErrSessionNotFound {
+		return apperrors.ErrRefreshTokenReused
+	}
+
+	return err
+}
+
+// RevokeSession revokes a session; none of its tokens are accepted afterwards.
+// Revoking a revoked session keeps the time it was first revoked at.
+//
+// Parameters:
+//   - id string: The ID of the session.
+//
+// Returns:
+//   - error: An error if the query fails.
+func (u *UserStorage) RevokeSession(ctx context.Context, id string) error {
+	query := `
+		UPDATE sessions SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`
+	if _, err := u.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("revoke session error: %v", err)
+	}
+
+	return nil
+}
+
+// RevokeUserSession revokes an active session of a user.
+//
+// Parameters:
+//   - userID int64: The ID of the user the session must belong to.
+//   - id string: The ID of the session.
+//
+// Returns:
+//   - error: apperrors.ErrSessionNotFound if the user has no such active session, or a query error.
+func (u *UserStorage) RevokeUserSession(ctx context.Context, userID int64, id string) error {
+	query := `
+		UPDATE sessions SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()`
+	return u.execSessionUpdate(ctx, "revoke session", query, id, userID)
+}
+
+// execSessionUpdate executes an update of one session.
+//
+// Returns:
+//   - error: apperrors.ErrSessionNotFound if no session was updated, or a query error prefixed with op.
+func (u *UserStorage) execSessionUpdate(ctx context.Context, op, query string, args ...any) error {
+	res, err := u.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s error: %v", op, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s error: %v", op, err)
+	}
+
+	if rows == 0 {
+		return apperrors.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// scanSession scans a sessions row selected with the columns of GetSession.
+func scanSession(row interface{ Scan(...any) error }) (*entities.Session, error) {
+	var session entities.Session
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.DeviceName,
+		&session.UserAgent,
+		&session.IP,
+		&session.CurrentTokenID,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStorage_Sessions(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	storage := NewUserStorage(db)
+
+	_, err := storage.GetSession(ctx, "fam")
+	assert.ErrorIs(t, err, apperrors.ErrSessionNotFound)
+
+	require.NoError(t, storage.CreateSession(ctx, entities.Session{
+		ID:             "fam",
+		UserID:         1,
+		DeviceName:     "laptop",
+		UserAgent:      "curl/8.0",
+		IP:             "192.0.2.1",
+		CurrentTokenID: "t1",
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}))
+
+	require.NoError(t, storage.RotateRefreshToken(ctx, "fam", "t1", "t2", time.Now().Add(2*time.Hour)))
+	assert.ErrorIs(t, storage.RotateRefreshToken(ctx, "fam", "t1", "t3", time.Now().Add(2*time.Hour)),
+		apperrors.ErrRefreshTokenReused, "A rotated token should not be rotated again")
+
+	session, err := storage.GetSession(ctx, "fam")
+	require.NoError(t, err)
+	assert.Equal(t, "t2", session.CurrentTokenID)
+	assert.Equal(t, "laptop", session.DeviceName)
+	assert.Nil(t, session.RevokedAt)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), session.ExpiresAt, time.Minute)
+
+	require.NoError(t, storage.TouchSession(ctx, 1, "fam"))
+	assert.ErrorIs(t, storage.TouchSession(ctx, 2, "fam"), apperrors.ErrSessionNotFound, "Another user's session should not be accepted")
+
+	sessions, err := storage.ListSessions(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "192.0.2.1", sessions[0].IP)
+
+	require.NoError(t, storage.RevokeSession(ctx, "fam"))
+	assert.ErrorIs(t, storage.RotateRefreshToken(ctx, "fam", "t2", "t3", time.Now().Add(2*time.Hour)),
+		apperrors.ErrRefreshTokenReused, "A revoked session should not be rotated")
+	assert.ErrorIs(t, storage.TouchSession(ctx, 1, "fam"), apperrors.ErrSessionNotFound)
+	assert.ErrorIs(t, storage.RevokeUserSession(ctx, 1, "fam"), apperrors.ErrSessionNotFound)
+
+	session, err = storage.GetSession(ctx, "fam")
+	require.NoError(t, err)
+	assert.NotNil(t, session.RevokedAt)
+
+	sessions, err = storage.ListSessions(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
@@ -0,0 +1,722 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// shareableTables lists the vault tables whose items can be shared, with their unencrypted columns
+// shown to recipients next to the encrypted ones.
+var shareableTables = map[string][]string{
+	"passwords":   {"app_name"},
+	"cards":       {"bank_name", "brand"},
+	"notes":       nil,
+	"binary_data": nil,
+
+	"bank_accounts":      {"bank_name"},
+	"identity_documents": {"doc_type"},
+}
+
+// itemShareColumns are the columns of item_shares joined with the usernames and the recipient's public key.
+const itemShareColumns = `
+	s.id, s.item_type, s.item_id, s.owner_id, o.username, s.recipient_id, r.username,
+	COALESCE(p.public_key, ''), s.permission, s.wrapped_key, s.created_at, s.updated_at`
+
+// itemShareJoins joins item_shares aliased s with the owner, the recipient and the recipient's key pair.
+const itemShareJoins = `
+	JOIN users o ON o.id = s.owner_id
+	JOIN users r ON r.id = s.recipient_id
+	LEFT JOIN user_keypairs p ON p.user_id = s.recipient_id`
+
+// SharingStorage handles database operations of vault item sharing: key pairs, item keys and shares.
+type SharingStorage struct {
+	db *sql.DB // Database connection instance.
+}
+
+// NewSharingStorage initializes a new SharingStorage instance.
+//
+// Parameters:
+//   - db *sql.DB: The database connection.
+//
+// Returns:
+//   - *SharingStorage: A pointer to the initialized SharingStorage structure.
+func NewSharingStorage(db *sql.DB) *SharingStorage {
+	return &SharingStorage{db: db}
+}
+
+// GetKeyPair retrieves the key pair of a user.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - *entities.UserKeyPair: A pointer to the user's key pair if found.
+//   - error: apperrors.ErrKeyPairNotFound if the user has no key pair, or a query error.
+func (s *SharingStorage) GetKeyPair(ctx context.Context, userID int64) (*entities.UserKeyPair, error) {
+	return getKeyPair(ctx, s.db, userID)
+}
+
+// GetRecipient retrieves the key pair of the user with the given username, to share an item with them.
+//
+// Parameters:
+//   - username string: The username of the recipient.
+//
+// Returns:
+//   - *entities.UserKeyPair: A pointer to the recipient's key pair.
+//   - error: apperrors.ErrShareRecipientNotFound if the user does not exist or has no key pair, or a query error.
+func (s *SharingStorage) GetRecipient(ctx context.Context, username string) (*entities.UserKeyPair, error) {
+	query := `
+		SELECT p.user_id, p.public_key, p.wrapped_private_key, p.created_at
+		FROM users u JOIN user_keypairs p ON p.user_id = u.id
+		WHERE u.username = $1`
+	var pair entities.UserKeyPair
+	err := s.db.QueryRowContext(ctx, query, username).Scan(&pair.UserID, &pair.PublicKey, &pair.WrappedPrivateKey, &pair.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrShareRecipientNotFound
+		}
+		return nil, err
+	}
+
+	return &pair, nil
+}
+
+// GetItemKey retrieves the item key of a shared item.
+//
+// Parameters:
+//   - itemType string: The table the item is stored in.
+//   - itemID int64: The ID of the item.
+//
+// Returns:
+//   - *entities.ItemKey: A pointer to the item key wrapped by its owner's data key.
+//   - error: apperrors.ErrItemKeyNotFound if the item is not shared, or a query error.
+func (s *SharingStorage) GetItemKey(ctx context.Context, itemType string, itemID int64) (*entities.ItemKey, error) {
+	query := `
+		SELECT item_type, item_id, owner_id, wrapped_key, created_at, updated_at
+		FROM item_keys WHERE item_type = $1 AND item_id = $2`
+	var key entities.ItemKey
+	err := s.db.QueryRowContext(ctx, query, itemType, itemID).
+		Scan(&key.ItemType, &key.ItemID, &key.OwnerID, &key.WrappedKey, &key.CreatedAt, &key.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrItemKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// GetItemKeys retrieves the item keys of the shared items of one type owned by a user.
+//
+// Parameters:
+//   - ownerID int64: The ID of the owner.
+//   - itemType string: The table the items are stored in.
+//
+// Returns:
+//   - map[int64]string: The item keys wrapped by the owner's data key, by item ID.
+//   - error: An error if the query fails.
+func (s *SharingStorage) GetItemKeys(ctx context.Context, ownerID int64, itemType string) (map[int64]string, error) {
+	query := `SELECT item_id, wrapped_key FROM item_keys WHERE owner_id = $1 AND item_type = $2`
+	rows, err := s.db.QueryContext(ctx, query, ownerID, itemType)
+	if err != nil {
+		return nil, fmt.Errorf("get item keys error: %v", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[int64]string)
+	for rows.Next() {
+		var (
+			itemID  int64
+			wrapped string
+		)
+		if err := rows.Scan(&itemID, &wrapped); err != nil {
+			return nil, fmt.Errorf("scan item key error: %v", err)
+		}
+		keys[itemID] = wrapped
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get item keys error: %v", err)
+	}
+
+	return keys, nil
+}
+
+// CreateShare shares an item with a recipient, or replaces the permission and sealed key of an existing
+// share. If body.ItemKey is set, the item is shared for the first time: it is re-encrypted with the
+// new item key and the key is stored, in the same transaction.
+//
+// Parameters:
+//   - body dto.CreateShareDTO: The share and, on the first share of the item, its wrapped item key.
+//   - reencryptText: Decrypts a text ciphertext with the owner's data key and encrypts it with the
+//     item key; only used when body.ItemKey is set.
+//   - reencryptBinary: The same for binary ciphertexts.
+//
+// Returns:
+//   - *entities.ItemShare: The stored share.
+//   - error: apperrors.ErrShareItemNotFound if the owner has no such item, or an error if the item
+//     cannot be re-encrypted or a query fails; nothing is changed in that case.
+func (s *SharingStorage) CreateShare(
+	ctx context.Context,
+	body dto.CreateShareDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) (*entities.ItemShare, error) {
+	table, err := shareableTable(body.ItemType)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create share error: %v", err)
+	}
+	defer tx.Rollback()
+
+	if body.ItemKey != "" {
+		found, err := rekeyItem(ctx, tx, table, body.OwnerID, body.ItemID, reencryptText, reencryptBinary)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, apperrors.ErrShareItemNotFound
+		}
+
+		query := `INSERT INTO item_keys (item_type, item_id, owner_id, wrapped_key) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.ExecContext(ctx, query, body.ItemType, body.ItemID, body.OwnerID, body.ItemKey); err != nil {
+			return nil, fmt.Errorf("store item key error: %v", err)
+		}
+	}
+
+	var shareID int64
+	query := `
+		INSERT INTO item_shares (item_type, item_id, owner_id, recipient_id, wrapped_key, permission)
+		SELECT $1::text, $2::int, $3::int, $4::int, $5::text, $6::text
+		WHERE EXISTS (SELECT 1 FROM item_keys WHERE item_type = $1 AND item_id = $2 AND owner_id = $3)
+		ON CONFLICT (item_type, item_id, recipient_id) DO UPDATE
+		SET wrapped_key = EXCLUDED.wrapped_key, permission = EXCLUDED.permission, updated_at = NOW()
+		RETURNING id`
+	err = tx.QueryRowContext(ctx, query, body.ItemType, body.ItemID, body.OwnerID, body.RecipientID, body.WrappedKey, body.Permission).
+		Scan(&shareID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrShareItemNotFound
+		}
+		return nil, fmt.Errorf("store share error: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("create share error: %v", err)
+	}
+
+	return s.GetShare(ctx, shareID)
+}
+
+// GetShare retrieves a share by its ID.
+//
+// Parameters:
+//   - shareID int64: The ID of the share.
+//
+// Returns:
+//   - *entities.ItemShare: A pointer to the share if found.
+//   - error: apperrors.ErrShareNotFound if the share does not exist, or a query error.
+func (s *SharingStorage) GetShare(ctx context.Context, shareID int64) (*entities.ItemShare, error) {
+	query := fmt.Sprintf(`SELECT %s FROM item_shares s %s WHERE s.id = $1`, itemShareColumns, itemShareJoins)
+	share, err := scanItemShare(s.db.QueryRowContext(ctx, query, shareID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrShareNotFound
+		}
+		return nil, err
+	}
+
+	return share, nil
+}
+
+// ListSharesByOwner retrieves the shares of every item owned by a user.
+//
+// Parameters:
+//   - ownerID int64: The ID of the owner.
+//
+// Returns:
+//   - []entities.ItemShare: The shares, by item and recipient.
+//   - error: An error if the query fails.
+func (s *SharingStorage) ListSharesByOwner(ctx context.Context, ownerID int64) ([]entities.ItemShare, error) {
+	query := fmt.Sprintf(
+		`SELECT %s FROM item_shares s %s WHERE s.owner_id = $1 ORDER BY s.item_type, s.item_id, r.username`,
+		itemShareColumns, itemShareJoins,
+	)
+	return s.listShares(ctx, query, ownerID)
+}
+
+// ListItemShares retrieves the shares of one item, with the recipients' public keys.
+//
+// Parameters:
+//   - itemType string: The table the item is stored in.
+//   - itemID int64: The ID of the item.
+//
+// Returns:
+//   - []entities.ItemShare: The shares of the item.
+//   - error: An error if the query fails.
+func (s *SharingStorage) ListItemShares(ctx context.Context, itemType string, itemID int64) ([]entities.ItemShare, error) {
+	query := fmt.Sprintf(
+		`SELECT %s FROM item_shares s %s WHERE s.item_type = $1 AND s.item_id = $2 ORDER BY s.id`,
+		itemShareColumns, itemShareJoins,
+	)
+	return s.listShares(ctx, query, itemType, itemID)
+}
+
+// RevokeShare deletes a share and re-encrypts the item, so the recipient cannot read it again with an
+// item key they kept: with a new item key sealed again for the remaining recipients and wrapped by
+// the organisation key if the item is in a collection, or with the owner's data key if body.ItemKey
+// is empty, in which case the item is no longer shared.
+//
+// Parameters:
+//   - body dto.RevokeShareDTO: The share to revoke, the new wrapped item key and the keys resealed
+//     for the remaining shares.
+//   - reencryptText: Decrypts a text ciphertext with the old item key and encrypts it with the new key.
+//   - reencryptBinary: The same for binary ciphertexts.
+//
+// Returns:
+//   - error: apperrors.ErrShareNotFound if the owner has no such share, apperrors.ErrOrgKeyChanged if
+//     the item was added to or removed from a collection, or the organisation key rotated, since
+//     body was built, or an error if the item cannot be re-encrypted or a query fails; nothing is
+//     changed in that case.
+func (s *SharingStorage) RevokeShare(
+	ctx context.Context,
+	body dto.RevokeShareDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) error {
+	table, err := shareableTable(body.ItemType)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("revoke share error: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM item_shares WHERE id = $1 AND owner_id = $2 AND item_type = $3 AND item_id = $4`
+	res, err := tx.ExecContext(ctx, query, body.ShareID, body.OwnerID, body.ItemType, body.ItemID)
+	if err != nil {
+		return fmt.Errorf("delete share error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return apperrors.ErrShareNotFound
+	}
+
+	// The item key is locked first, so the item cannot be added to a collection concurrently.
+	var inCollection bool
+	query = `
+		SELECT EXISTS (SELECT 1 FROM collection_items WHERE item_type = $1 AND item_id = $2)
+		FROM item_keys WHERE item_type = $1 AND item_id = $2 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, query, body.ItemType, body.ItemID).Scan(&inCollection); err != nil {
+		return fmt.Errorf("get item key error: %v", err)
+	}
+	if inCollection != (body.CollectionKey != "") {
+		return apperrors.ErrOrgKeyChanged
+	}
+
+	if _, err := rekeyItem(ctx, tx, table, body.OwnerID, body.ItemID, reencryptText, reencryptBinary); err != nil {
+		return err
+	}
+
+	if body.ItemKey == "" {
+		query = `DELETE FROM item_keys WHERE item_type = $1 AND item_id = $2`
+		if _, err := tx.ExecContext(ctx, query, body.ItemType, body.ItemID); err != nil {
+			return fmt.Errorf("delete item key error: %v", err)
+		}
+	} else {
+		query = `UPDATE item_keys SET wrapped_key = $1, updated_at = NOW() WHERE item_type = $2 AND item_id = $3`
+		if _, err := tx.ExecContext(ctx, query, body.ItemKey, body.ItemType, body.ItemID); err != nil {
+			return fmt.Errorf("update item key error: %v", err)
+		}
+	}
+
+	for shareID, sealed := range body.Resealed {
+		query = `UPDATE item_shares SET wrapped_key = $1, updated_at = NOW() WHERE id = $2 AND item_type = $3 AND item_id = $4`
+		if _, err := tx.ExecContext(ctx, query, sealed, shareID, body.ItemType, body.ItemID); err != nil {
+			return fmt.Errorf("update share key error: %v", err)
+		}
+	}
+
+	if body.CollectionKey != "" {
+		if err := lockOrgKey(ctx, tx, body.ItemType, body.ItemID, body.KeyVersion); err != nil {
+			return err
+		}
+
+		query = `UPDATE collection_items SET wrapped_key = $1, updated_at = NOW() WHERE item_type = $2 AND item_id = $3`
+		if _, err := tx.ExecContext(ctx, query, body.CollectionKey, body.ItemType, body.ItemID); err != nil {
+			return fmt.Errorf("update collection item key error: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListSharedWith retrieves every item shared with a user, as stored.
+//
+// Parameters:
+//   - recipientID int64: The ID of the recipient.
+//
+// Returns:
+//   - []entities.SharedItem: The shared items with their shares.
+//   - error: An error if a query fails.
+func (s *SharingStorage) ListSharedWith(ctx context.Context, recipientID int64) ([]entities.SharedItem, error) {
+	query := fmt.Sprintf(`SELECT %s FROM item_shares s %s WHERE s.recipient_id = $1 ORDER BY s.id`, itemShareColumns, itemShareJoins)
+	shares, err := s.listShares(ctx, query, recipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]entities.SharedItem, 0, len(shares))
+	for _, share := range shares {
+		item, err := s.loadSharedItem(ctx, share)
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			items = append(items, *item)
+		}
+	}
+
+	return items, nil
+}
+
+// GetSharedWith retrieves an item shared with a user, as stored.
+//
+// Parameters:
+//   - shareID int64: The ID of the share.
+//   - recipientID int64: The ID of the recipient.
+//
+// Returns:
+//   - *entities.SharedItem: The shared item with its share.
+//   - error: apperrors.ErrShareNotFound if the item is not shared with the user, or a query error.
+func (s *SharingStorage) GetSharedWith(ctx context.Context, shareID, recipientID int64) (*entities.SharedItem, error) {
+	share, err := s.GetShare(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.RecipientID != recipientID {
+		return nil, apperrors.ErrShareNotFound
+	}
+
+	item, err := s.loadSharedItem(ctx, *share)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, apperrors.ErrShareNotFound
+	}
+
+	return item, nil
+}
+
+// UpdateSharedItem stores new ciphertexts of encrypted columns of an item shared with write permission.
+//
+// Parameters:
+//   - shareID int64: The ID of the share.
+//   - recipientID int64: The ID of the recipient making the change.
+//   - wrappedKey string: The sealed item key the ciphertexts were encrypted with; the update is
+//     rejected if the item key was rotated meanwhile.
+//   - text map[string]string: New text ciphertexts by column name.
+//   - binary map[string][]byte: New binary ciphertexts by column name.
+//
+// Returns:
+//   - error: apperrors.ErrShareNotFound if the item is not shared with the user or its key was rotated, apperrors.ErrShareReadOnly
+//     if it is shared read-only, apperrors.ErrInvalidSharedField for a column that is not encrypted,
+//     or a query error.
+func (s *SharingStorage) UpdateSharedItem(
+	ctx context.Context,
+	shareID, recipientID int64,
+	wrappedKey string,
+	text map[string]string,
+	binary map[string][]byte,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("update shared item error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		itemType, permission string
+		itemID, ownerID      int64
+	)
+	query := `
+		SELECT item_type, item_id, owner_id, permission FROM item_shares
+		WHERE id = $1 AND recipient_id = $2 AND wrapped_key = $3 FOR SHARE`
+	err = tx.QueryRowContext(ctx, query, shareID, recipientID, wrappedKey).Scan(&itemType, &itemID, &ownerID, &permission)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrShareNotFound
+		}
+		return fmt.Errorf("get share error: %v", err)
+	}
+	if permission != entities.SharePermissionWrite {
+		return apperrors.ErrShareReadOnly
+	}
+
+	ok, err := updateItem(ctx, tx, itemType, itemID, ownerID, text, binary)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.ErrInvalidSharedField
+	}
+
+	return tx.Commit()
+}
+
+// GetKeyPair retrieves the sharing key pair of a user.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - *entities.UserKeyPair: A pointer to the user's key pair if found.
+//   - error: apperrors.ErrKeyPairNotFound if the user has no key pair, or a query error.
+func (u *UserStorage) GetKeyPair(ctx context.Context, userID int64) (*entities.UserKeyPair, error) {
+	return getKeyPair(ctx, u.db, userID)
+}
+
+// CreateKeyPair stores the sharing key pair of a user registered before sharing existed. A key pair
+// stored concurrently is kept.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//   - body dto.KeyPairDTO: The public key and the private key wrapped by the user's data key.
+//
+// Returns:
+//   - error: An error if the query fails.
+func (u *UserStorage) CreateKeyPair(ctx context.Context, userID int64, body dto.KeyPairDTO) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("create key pair error: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertKeyPair(ctx, tx, userID, body); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// listShares runs a query selecting itemShareColumns and scans the shares.
+func (s *SharingStorage) listShares(ctx context.Context, query string, args ...any) ([]entities.ItemShare, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list shares error: %v", err)
+	}
+	defer rows.Close()
+
+	var shares []entities.ItemShare
+	for rows.Next() {
+		share, err := scanItemShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan share error: %v", err)
+		}
+		shares = append(shares, *share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list shares error: %v", err)
+	}
+
+	return shares, nil
+}
+
+// loadSharedItem reads the columns of a shared item; nil if the item no longer exists.
+func (s *SharingStorage) loadSharedItem(ctx context.Context, share entities.ItemShare) (*entities.SharedItem, error) {
+	columns, err := loadItem(ctx, s.db, share.ItemType, share.ItemID, share.OwnerID)
+	if err != nil || columns == nil {
+		return nil, err
+	}
+
+	return &entities.SharedItem{Share: share, Plain: columns.plain, Text: columns.text, Binary: columns.binary}, nil
+}
+
+// itemColumns holds the columns of a vault item as stored: its unencrypted values and the ciphertexts
+// of its encrypted columns, by column name.
+type itemColumns struct {
+	plain  map[string]string
+	text   map[string]string
+	binary map[string][]byte
+}
+
+// loadItem reads the columns of an item of a shareable table owned by a user; nil if the item no
+// longer exists.
+func loadItem(ctx context.Context, db *sql.DB, itemType string, itemID, ownerID int64) (*itemColumns, error) {
+	table, err := shareableTable(itemType)
+	if err != nil {
+		return nil, err
+	}
+	plain := shareableTables[itemType]
+
+	columns := make([]string, 0, len(plain)+len(table.text)+len(table.binary))
+	for _, column := range plain {
+		columns = append(columns, fmt.Sprintf("COALESCE(%s, '')", column))
+	}
+	columns = append(append(columns, table.text...), table.binary...)
+
+	var (
+		plainValues  = make([]string, len(plain))
+		textValues   = make([]string, len(table.text))
+		binaryValues = make([][]byte, len(table.binary))
+		dest         []any
+	)
+	for i := range plainValues {
+		dest = append(dest, &plainValues[i])
+	}
+	for i := range textValues {
+		dest = append(dest, &textValues[i])
+	}
+	for i := range binaryValues {
+		dest = append(dest, &binaryValues[i])
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1 AND user_id = $2`, strings.Join(columns, ", "), table.name)
+	err = db.QueryRowContext(ctx, query, itemID, ownerID).Scan(dest...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get %s item error: %v", table.name, err)
+	}
+
+	item := itemColumns{
+		plain:  make(map[string]string, len(plain)),
+		text:   make(map[string]string, len(table.text)),
+		binary: make(map[string][]byte, len(table.binary)),
+	}
+	for i, column := range plain {
+		item.plain[column] = plainValues[i]
+	}
+	for i, column := range table.text {
+		item.text[column] = textValues[i]
+	}
+	for i, column := range table.binary {
+		item.binary[column] = binaryValues[i]
+	}
+
+	return &item, nil
+}
+
+// updateItem stores new ciphertexts of encrypted columns of an item of a shareable table within a
+// transaction.
+//
+// Returns:
+//   - bool: false, with nothing changed, if a column is not an encrypted column of the table.
+//   - error: An error if the query fails.
+func updateItem(
+	ctx context.Context,
+	tx *sql.Tx,
+	itemType string,
+	itemID, ownerID int64,
+	text map[string]string,
+	binary map[string][]byte,
+) (bool, error) {
+	table, err := shareableTable(itemType)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		assignments []string
+		args        []any
+	)
+	for _, column := range table.text {
+		if value, ok := text[column]; ok {
+			args = append(args, value)
+			assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+	for _, column := range table.binary {
+		if value, ok := binary[column]; ok {
+			args = append(args, value)
+			assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+	if len(assignments) != len(text)+len(binary) {
+		return false, nil
+	}
+	if len(assignments) == 0 {
+		return true, nil
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET %s, updated_at = NOW() WHERE id = $%d AND user_id = $%d`,
+		table.name, strings.Join(assignments, ", "), len(args)+1, len(args)+2,
+	)
+	if _, err := tx.ExecContext(ctx, query, append(args, itemID, ownerID)...); err != nil {
+		return false, fmt.Errorf("update %s item error: %v", table.name, err)
+	}
+
+	return true, nil
+}
+
+// shareableTable returns the vault table of a shareable item type.
+func shareableTable(itemType string) (vaultTable, error) {
+	if _, ok := shareableTables[itemType]; !ok {
+		return vaultTable{}, apperrors.ErrInvalidShare
+	}
+
+	table, ok := findVaultTable(itemType)
+	if !ok {
+		return vaultTable{}, apperrors.ErrInvalidShare
+	}
+
+	return table, nil
+}
+
+// scanItemShare scans a row of itemShareColumns.
+func scanItemShare(row interface{ Scan(...any) error }) (*entities.ItemShare, error) {
+	var share entities.ItemShare
+	err := row.Scan(
+		&share.ID, &share.ItemType, &share.ItemID, &share.OwnerID, &share.OwnerUsername,
+		&share.RecipientID, &share.RecipientUsername, &share.RecipientPublicKey,
+		&share.Permission, &share.WrappedKey, &share.CreatedAt, &share.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+// getKeyPair retrieves the key pair of a user; apperrors.ErrKeyPairNotFound if there is none.
+func getKeyPair(ctx context.Context, db *sql.DB, userID int64) (*entities.UserKeyPair, error) {
+	query := `SELECT user_id, public_key, wrapped_private_key, created_at FROM user_keypairs WHERE user_id = $1`
+	var pair entities.UserKeyPair
+	err := db.QueryRowContext(ctx, query, userID).Scan(&pair.UserID, &pair.PublicKey, &pair.WrappedPrivateKey, &pair.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrKeyPairNotFound
+		}
+		return nil, err
+	}
+
+	return &pair, nil
+}
+
+// insertKeyPair stores a user's key pair within a transaction unless they already have one.
+func insertKeyPair(ctx context.Context, tx *sql.Tx, userID int64, pair dto.KeyPairDTO) error {
+	query := `
+		INSERT INTO user_keypairs (user_id, public_key, wrapped_private_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, userID, pair.PublicKey, pair.WrappedPrivateKey); err != nil {
+		return fmt.Errorf("store key pair error: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSharing adds a recipient "bob" (ID 2) with a key pair and a note of user 1, and returns the note ID.
+func setupSharing(t *testing.T, ctx context.Context, users *UserStorage, notes *NotesStorage) int64 {
+	t.Helper()
+
+	err := users.Create(ctx, dto.UserDTO{
+		Username: "bob",
+		Password: "hash",
+		KeyPair:  &dto.KeyPairDTO{PublicKey: "x1.bob", WrappedPrivateKey: "w1.bob"},
+	})
+	require.NoError(t, err)
+
+	noteID := reserveID(t, notes)
+	err = notes.Create(ctx, dto.CreateNoteDTO{ID: noteID, UserID: 1, Title: "t", TextData: "d"})
+	require.NoError(t, err)
+
+	return noteID
+}
+
+func TestSharingStorage_KeyPairs(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	users, storage := NewUserStorage(db), NewSharingStorage(db)
+
+	_, err := users.GetKeyPair(ctx, 1)
+	assert.ErrorIs(t, err, apperrors.ErrKeyPairNotFound)
+	_, err = storage.GetRecipient(ctx, "test")
+	assert.ErrorIs(t, err, apperrors.ErrShareRecipientNotFound, "Users without a key pair cannot receive shares")
+
+	require.NoError(t, users.CreateKeyPair(ctx, 1, dto.KeyPairDTO{PublicKey: "x1.first", WrappedPrivateKey: "w1.first"}))
+	require.NoError(t, users.CreateKeyPair(ctx, 1, dto.KeyPairDTO{PublicKey: "x1.second", WrappedPrivateKey: "w1.second"}))
+
+	pair, err := storage.GetRecipient(ctx, "test")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pair.UserID)
+	assert.Equal(t, "x1.first", pair.PublicKey, "An existing key pair should be kept")
+}
+
+func TestSharingStorage_ShareAndRevoke(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	users, notes, storage := NewUserStorage(db), NewNotesStorage(db), NewSharingStorage(db)
+	noteID := setupSharing(t, ctx, users, notes)
+
+	toItemKey := func(_ dto.VaultFieldDTO, value string) (string, error) { return value + "@item", nil }
+	share, err := storage.CreateShare(ctx, dto.CreateShareDTO{
+		OwnerID:     1,
+		RecipientID: 2,
+		ItemType:    "notes",
+		ItemID:      noteID,
+		Permission:  "read",
+		WrappedKey:  "s1.bob",
+		ItemKey:     "w1.item",
+	}, toItemKey, nil)
+	require.NoError(t, err, "CreateShare should not return an error")
+	assert.Equal(t, "bob", share.RecipientUsername)
+	assert.Equal(t, "test", share.OwnerUsername)
+	assert.Equal(t, "x1.bob", share.RecipientPublicKey)
+
+	keys, err := storage.GetItemKeys(ctx, 1, "notes")
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]string{noteID: "w1.item"}, keys)
+
+	err = NewVaultStorage(db).Rekey(ctx, dto.VaultRekeyDTO{UserID: 1, Keys: dto.UserKeysDTO{KDF: "$argon2id$k", WrappedKey: "w1.dek"}},
+		func(_ dto.VaultFieldDTO, value string) (string, error) { return value + "@dek", nil },
+		func(_ dto.VaultFieldDTO, value []byte) ([]byte, error) { return value, nil },
+	)
+	require.NoError(t, err)
+
+	items, err := storage.ListSharedWith(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, map[string]string{"title": "t@item", "text_data": "d@item"}, items[0].Text,
+		"A shared item should be re-encrypted with its item key and skipped by the vault rekey")
+
+	err = storage.UpdateSharedItem(ctx, share.ID, 2, "s1.bob", map[string]string{"title": "new"}, nil)
+	assert.ErrorIs(t, err, apperrors.ErrShareReadOnly)
+
+	_, err = storage.CreateShare(ctx, dto.CreateShareDTO{
+		OwnerID: 1, RecipientID: 2, ItemType: "notes", ItemID: noteID, Permission: "write", WrappedKey: "s1.bob",
+	}, nil, nil)
+	require.NoError(t, err, "Sharing again should update the permission")
+
+	err = storage.UpdateSharedItem(ctx, share.ID, 2, "s1.bob", map[string]string{"user_id": "2"}, nil)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSharedField)
+	err = storage.UpdateSharedItem(ctx, share.ID, 2, "s1.stale", map[string]string{"title": "new"}, nil)
+	assert.ErrorIs(t, err, apperrors.ErrShareNotFound, "A write with a rotated item key should be rejected")
+	require.NoError(t, storage.UpdateSharedItem(ctx, share.ID, 2, "s1.bob", map[string]string{"title": "new"}, nil))
+
+	err = storage.RevokeShare(ctx, dto.RevokeShareDTO{ShareID: share.ID, OwnerID: 1, ItemType: "notes", ItemID: noteID},
+		func(_ dto.VaultFieldDTO, value string) (string, error) { return value + "@back", nil }, nil)
+	require.NoError(t, err, "RevokeShare should not return an error")
+
+	var title string
+	require.NoError(t, db.QueryRow("SELECT title FROM notes WHERE id = $1", noteID).Scan(&title))
+	assert.Equal(t, "new@back", title)
+
+	_, err = storage.GetItemKey(ctx, "notes", noteID)
+	assert.ErrorIs(t, err, apperrors.ErrItemKeyNotFound, "The last revoke should unshare the item")
+	_, err = storage.GetShare(ctx, share.ID)
+	assert.ErrorIs(t, err, apperrors.ErrShareNotFound)
+}
+
+func TestSharingStorage_CreateShare_OtherOwner(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	users, notes, storage := NewUserStorage(db), NewNotesStorage(db), NewSharingStorage(db)
+	noteID := setupSharing(t, ctx, users, notes)
+
+	_, err := storage.CreateShare(ctx, dto.CreateShareDTO{
+		OwnerID: 2, RecipientID: 1, ItemType: "notes", ItemID: noteID, Permission: "read", WrappedKey: "s1.test", ItemKey: "w1.item",
+	}, func(_ dto.VaultFieldDTO, value string) (string, error) { return value, nil }, nil)
+	assert.ErrorIs(t, err, apperrors.ErrShareItemNotFound)
+
+	_, err = storage.GetItemKey(ctx, "notes", noteID)
+	assert.ErrorIs(t, err, apperrors.ErrItemKeyNotFound, "Nothing should be stored")
+}
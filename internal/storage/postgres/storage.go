@@ -8,11 +8,19 @@ import (
 
 // Storage aggregates all storage components used for handling different types of data.
 type Storage struct {
-	Binary   BinaryStorage   // Handles storage operations for binary data.
-	Card     CardStorage     // Manages storage operations for card-related data.
-	LogoPass LogoPassStorage // Stores login credentials (username, password).
-	User     UserStorage     // Manages user-related storage operations.
-	Note     NotesStorage    // Handles note storage operations.
+	Binary           BinaryStorage           // Handles storage operations for binary data.
+	Card             CardStorage             // Manages storage operations for card-related data.
+	LogoPass         LogoPassStorage         // Stores login credentials (username, password).
+	User             UserStorage             // Manages user-related storage operations.
+	Note             NotesStorage            // Handles note storage operations.
+	BankAccount      BankAccountStorage      // Manages storage operations for bank accounts.
+	IdentityDocument IdentityDocumentStorage // Manages storage operations for identity documents.
+	Vault            VaultStorage            // Re-encrypts a user's whole vault.
+	Reencryption     ReencryptionStorage     // Checkpoints and batches of the background re-encryption job.
+	Sharing          SharingStorage          // Key pairs, item keys and shares of shared vault items.
+	Org              OrgStorage              // Organisations, members and collections of team vaults.
+	Emergency        EmergencyStorage        // Emergency contacts and their access requests.
+	RecoveryShare    RecoveryShareStorage    // Recovery keys split into shares among officers.
 }
 
 // New initializes a new Storage instance with the provided database connection.
@@ -24,11 +32,19 @@ type Storage struct {
 //   - *Storage: A pointer to the initialized Storage structure.
 func New(conn *sql.DB) *Storage {
 	return &Storage{
-		User:     *NewUserStorage(conn),
-		Card:     *NewCardStorage(conn),
-		LogoPass: *NewLogoPassStorage(conn),
-		Binary:   *NewBinaryStorage(conn),
-		Note:     *NewNotesStorage(conn),
+		User:             *NewUserStorage(conn),
+		Card:             *NewCardStorage(conn),
+		LogoPass:         *NewLogoPassStorage(conn),
+		Binary:           *NewBinaryStorage(conn),
+		Note:             *NewNotesStorage(conn),
+		BankAccount:      *NewBankAccountStorage(conn),
+		IdentityDocument: *NewIdentityDocumentStorage(conn),
+		Vault:            *NewVaultStorage(conn),
+		Reencryption:     *NewReencryptionStorage(conn),
+		Sharing:          *NewSharingStorage(conn),
+		Org:              *NewOrgStorage(conn),
+		Emergency:        *NewEmergencyStorage(conn),
+		RecoveryShare:    *NewRecoveryShareStorage(conn),
 	}
 }
 
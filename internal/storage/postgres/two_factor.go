@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+)
+
+// GetTOTP retrieves the TOTP secret of a user, enabled or still awaiting its first code.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - *entities.UserTOTP: A pointer to the user's TOTP secret if found.
+//   - error: apperrors.ErrTOTPNotFound if the user has not enrolled an authenticator, or a query error.
+func (u *UserStorage) GetTOTP(ctx context.Context, userID int64) (*entities.UserTOTP, error) {
+	query := `
+		SELECT user_id, secret, enabled_at, last_step, created_at, updated_at
+		FROM user_totp WHERE user_id = $1`
+	var totp entities.UserTOTP
+	err := u.db.QueryRowContext(ctx, query, userID).Scan(
+		&totp.UserID,
+		&totp.Secret,
+		&totp.EnabledAt,
+		&totp.LastStep,
+		&totp.CreatedAt,
+		&totp.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrTOTPNotFound
+		}
+		return nil, fmt.Errorf("get totp error: %v", err)
+	}
+
+	return &totp, nil
+}
+
+// SaveTOTPSecret stores a new TOTP secret of a user, replacing a secret that was not enabled yet.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//   - secret string: The secret in its stored form.
+//
+// Returns:
+//   - error: apperrors.ErrTwoFactorEnabled if the user has two-factor authentication enabled, or a query error.
+func (u *UserStorage) SaveTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_step = 0, created_at = NOW(), updated_at = NOW()
+		WHERE user_totp.enabled_at IS NULL`
+	res, err := u.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("save totp secret error: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("save totp secret error: %v", err)
+	}
+
+	if n == 0 {
+		return apperrors.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP enables two-factor authentication of a user after their first code.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//   - step int64: The time step of the first code, which cannot be used again.
+//
+// Returns:
+//   - error: apperrors.ErrTwoFactorEnabled if it was enabled meanwhile, apperrors.ErrTOTPNotFound if
+//     the user has no TOTP secret, or a query error; nothing is changed in that case.
+func (u *UserStorage) EnableTOTP(ctx context.Context, userID int64, step int64) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("enable totp error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var enabledAt sql.NullTime
+	query := `SELECT enabled_at FROM user_totp WHERE user_id = $1 FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, userID).Scan(&enabledAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrTOTPNotFound
+		}
+		return fmt.Errorf("enable totp error: %v", err)
+	}
+
+	if enabledAt.Valid {
+		return apperrors.ErrTwoFactorEnabled
+	}
+
+	query = `UPDATE user_totp SET enabled_at = NOW(), last_step = $2, updated_at = NOW() WHERE user_id = $1`
+	if _, err := tx.ExecContext(ctx, query, userID, step); err != nil {
+		return fmt.Errorf("enable totp error: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("enable totp error: %v", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code of a user.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//   - step int64: The time step of the code.
+//
+// Returns:
+//   - bool: true if the step is later than the last accepted one, false if a code of this or a later
+//     step was accepted before, so the code is a replay.
+//   - error: An error if the query fails.
+func (u *UserStorage) UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	query := `
+		UPDATE user_totp SET last_step = $2, updated_at = NOW()
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_step < $2`
+	res, err := u.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("use totp step error: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("use totp step error: %v", err)
+	}
+
+	return n == 1, nil
+}
+
+// DeleteTOTP turns two-factor authentication of a user off: their TOTP secret and pending login
+// challenges are deleted.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - error: An error if a query fails; nothing is changed in that case.
+func (u *UserStorage) DeleteTOTP(ctx context.Context, userID int64) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("delete totp error: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM user_totp WHERE user_id = $1`,
+		`DELETE FROM login_challenges WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("delete totp error: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete totp error: %v", err)
+	}
+
+	return nil
+}
+
+// CreateLoginChallenge stores a login awaiting its second factor.
+//
+// Parameters:
+//   - challenge entities.LoginChallenge: The challenge ID, its user, the wrapped data key and its expiration time.
+//
+// Returns:
+//   - error: An error if the query fails.
+func (u *UserStorage) CreateLoginChallenge(ctx context.Context, challenge entities.LoginChallenge) error {
+	query := `
+		INSERT INTO login_challenges (id, user_id, wrapped_key, expires_at)
+		VALUES ($1, $2, $3, $4)`
+	_, err := u.db.ExecContext(ctx, query, challenge.ID, challenge.UserID, challenge.WrappedKey, challenge.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("create login challenge error: %v", err)
+	}
+
+	return nil
+}
+
+// GetLoginChallenge retrieves a login challenge that has not expired.
+//
+// Parameters:
+//   - id string: The ID of the challenge.
+//
+// Returns:
+//   - *entities.LoginChallenge: A pointer to the challenge if found.
+//   - error: apperrors.ErrLoginChallengeNotFound if there is no such challenge or it has expired, or a query error.
+func (u *UserStorage) GetLoginChallenge(ctx context.Context, id string) (*entities.LoginChallenge, error) {
+	query := `
+		SELECT id, user_id, wrapped_key, attempts, expires_at, created_at
+		FROM login_challenges WHERE id = $1 AND expires_at > NOW()`
+	var challenge entities.LoginChallenge
+	err := u.db.QueryRowContext(ctx, query, id).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.WrappedKey,
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+		&challenge.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrLoginChallengeNotFound
+		}
+		return nil, fmt.Errorf("get login challenge error: %v", err)
+	}
+
+	return &challenge, nil
+}
+
+// AddLoginChallengeAttempt counts a code entered for a login challenge, before it is checked. The
+// counter is incremented atomically, so that concurrent requests cannot check more codes than allowed.
+//
+// Parameters:
+//   - id string: The ID of the challenge.
+//
+// Returns:
+//   - int: The number of codes entered so far, including this one.
+//   - error: apperrors.ErrLoginChallengeNotFound if the challenge does not exist anymore or has expired,
+//     or a query error.
+func (u *UserStorage) AddLoginChallengeAttempt(ctx context.Context, id string) (int, error) {
+	var attempts int
+	query := `
+		UPDATE login_challenges SET attempts = attempts + 1
+		WHERE id = $1 AND expires_at > NOW()
+		RETURNING attempts`
+	err := u.db.QueryRowContext(ctx, query, id).Scan(&attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, apperrors.ErrLoginChallengeNotFound
+		}
+		return 0, fmt.Errorf("add login challenge attempt error: %v", err)
+	}
+
+	return attempts, nil
+}
+
+// DeleteLoginChallenge deletes a login challenge, together with the expired challenges of its user.
+//
+// Parameters:
+//   - id string: The ID of the challenge.
+//
+// Returns:
+//   - bool: true if the challenge existed, so that of two concurrent logins with one challenge only one succeeds.
+//   - error: An error if the query fails.
+func (u *UserStorage) DeleteLoginChallenge(ctx context.Context, id string) (bool, error) {
+	var userID int64
+	query := `DELETE FROM login_challenges WHERE id = $1 RETURNING user_id`
+	err := u.db.QueryRowContext(ctx, query, id).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("delete login challenge error: %v", err)
+	}
+
+	query = `DELETE FROM login_challenges WHERE user_id = $1 AND expires_at <= NOW()`
+	if _, err := u.db.ExecContext(ctx, query, userID); err != nil {
+		return false, fmt.Errorf("delete login challenge error: %v", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStorage_TwoFactor(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	storage := NewUserStorage(db)
+
+	_, err := storage.GetTOTP(ctx, 1)
+	assert.ErrorIs(t, err, apperrors.ErrTOTPNotFound)
+	assert.ErrorIs(t, storage.EnableTOTP(ctx, 1, 10), apperrors.ErrTOTPNotFound)
+
+	require.NoError(t, storage.SaveTOTPSecret(ctx, 1, "ps1.first"))
+	require.NoError(t, storage.SaveTOTPSecret(ctx, 1, "ps1.second"), "A secret that is not enabled should be replaced")
+
+	fresh, err := storage.UseTOTPStep(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.False(t, fresh, "Codes should not be accepted before two-factor authentication is enabled")
+
+	require.NoError(t, storage.EnableTOTP(ctx, 1, 10))
+	assert.ErrorIs(t, storage.EnableTOTP(ctx, 1, 11), apperrors.ErrTwoFactorEnabled)
+	assert.ErrorIs(t, storage.SaveTOTPSecret(ctx, 1, "ps1.third"), apperrors.ErrTwoFactorEnabled)
+
+	stored, err := storage.GetTOTP(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "ps1.second", stored.Secret)
+	assert.NotNil(t, stored.EnabledAt)
+	assert.Equal(t, int64(10), stored.LastStep)
+
+	fresh, err = storage.UseTOTPStep(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.False(t, fresh, "The step of the first code should not be accepted again")
+	fresh, err = storage.UseTOTPStep(ctx, 1, 11)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	require.NoError(t, storage.CreateLoginChallenge(ctx, entities.LoginChallenge{
+		ID: "expired", UserID: 1, WrappedKey: "w1.old", ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	require.NoError(t, storage.CreateLoginChallenge(ctx, entities.LoginChallenge{
+		ID: "chal", UserID: 1, WrappedKey: "w1.challenge", ExpiresAt: time.Now().Add(time.Minute),
+	}))
+
+	_, err = storage.GetLoginChallenge(ctx, "expired")
+	assert.ErrorIs(t, err, apperrors.ErrLoginChallengeNotFound)
+
+	attempts, err := storage.AddLoginChallengeAttempt(ctx, "chal")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+
+	challenge, err := storage.GetLoginChallenge(ctx, "chal")
+	require.NoError(t, err)
+	assert.Equal(t, "w1.challenge", challenge.WrappedKey)
+	assert.Equal(t, 1, challenge.Attempts)
+
+	deleted, err := storage.DeleteLoginChallenge(ctx, "chal")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = storage.DeleteLoginChallenge(ctx, "chal")
+	require.NoError(t, err)
+	assert.False(t, deleted, "A challenge should only be used once")
+
+	var left int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM login_challenges`).Scan(&left))
+	assert.Zero(t, left, "Expired challenges should be cleaned up")
+
+	require.NoError(t, storage.DeleteTOTP(ctx, 1))
+	_, err = storage.GetTOTP(ctx, 1)
+	assert.ErrorIs(t, err, apperrors.ErrTOTPNotFound)
+}
@@ -29,19 +29,51 @@ func NewUserStorage(db *sql.DB) *UserStorage {
 	}
 }
 
-// Create inserts a new user record into the database.
+// Create inserts a new user record into the database. If body.Keys is set, the user's
+// wrapped data key is stored in the same transaction, as are their recovery kit if body.Recovery is set
+// and their sharing key pair if body.KeyPair is set.
 //
 // Parameters:
-//   - body dto.UserDTO: The user data transfer object containing the username and password.
+//   - body dto.UserDTO: The user data transfer object containing the username, password, keys,
+//     recovery kit and key pair.
 //
 // Returns:
 //   - error: An error if the operation fails, otherwise nil.
 func (u *UserStorage) Create(ctx context.Context, body dto.UserDTO) error {
-	query := `INSERT INTO users (username, password) VALUES ($1, $2)`
-	_, err := u.db.ExecContext(ctx, query, body.Username, body.Password)
+	tx, err := u.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("create user error: %v", err)
 	}
+	defer tx.Rollback()
+
+	var userID int64
+	query := `INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id`
+	err = tx.QueryRowContext(ctx, query, body.Username, body.Password).Scan(&userID)
+	if err != nil {
+		return fmt.Errorf("create user error: %v", err)
+	}
+
+	if body.Keys != nil {
+		if err := upsertUserKeys(ctx, tx, userID, *body.Keys); err != nil {
+			return err
+		}
+	}
+
+	if body.Recovery != nil {
+		if err := replaceRecovery(ctx, tx, userID, *body.Recovery); err != nil {
+			return err
+		}
+	}
+
+	if body.KeyPair != nil {
+		if err := insertKeyPair(ctx, tx, userID, *body.KeyPair); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("create user error: %v", err)
+	}
 
 	return nil
 }
@@ -55,10 +87,10 @@ func (u *UserStorage) Create(ctx context.Context, body dto.UserDTO) error {
 //   - *entities.User: A pointer to the retrieved user entity if found.
 //   - error: Returns an error if the user is not found or if a query error occurs.
 func (u *UserStorage) GetUserByUsername(ctx context.Context, username string) (*entities.User, error) {
-	query := `SELECT id, username, password FROM users WHERE username = $1`
+	query := `SELECT id, username, password, kdf FROM users WHERE username = $1`
 	row := u.db.QueryRowContext(ctx, query, username)
 	var user entities.User
-	err := row.Scan(&user.ID, &user.Username, &user.Password)
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.KDF)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, apperrors.ErrUserNotFound
@@ -68,3 +100,66 @@ func (u *UserStorage) GetUserByUsername(ctx context.Context, username string) (*
 
 	return &user, nil
 }
+
+// GetUserByID retrieves a user record by its ID.
+//
+// Parameters:
+//   - userID int64: The ID of the user to retrieve.
+//
+// Returns:
+//   - *entities.User: A pointer to the retrieved user entity if found.
+//   - error: Returns apperrors.ErrUserNotFound if the user does not exist, or a query error.
+func (u *UserStorage) GetUserByID(ctx context.Context, userID int64) (*entities.User, error) {
+	query := `SELECT id, username, password, kdf FROM users WHERE id = $1`
+	row := u.db.QueryRowContext(ctx, query, userID)
+	var user entities.User
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.KDF)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUserKeys retrieves the wrapped data key of a user.
+//
+// Parameters:
+//   - userID int64: The ID of the user.
+//
+// Returns:
+//   - *entities.UserKeys: A pointer to the user's keys if found.
+//   - error: apperrors.ErrUserKeysNotFound if the user has no data key yet, or a query error.
+func (u *UserStorage) GetUserKeys(ctx context.Context, userID int64) (*entities.UserKeys, error) {
+	query := `
+		SELECT user_id, kdf, wrapped_key, aad_bound, reencrypt_pending, created_at, updated_at
+		FROM user_keys WHERE user_id = $1`
+	row := u.db.QueryRowContext(ctx, query, userID)
+	var keys entities.UserKeys
+	err := row.Scan(&keys.UserID, &keys.KDF, &keys.WrappedKey, &keys.AADBound, &keys.ReencryptPending, &keys.CreatedAt, &keys.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrUserKeysNotFound
+		}
+		return nil, err
+	}
+
+	return &keys, nil
+}
+
+// upsertUserKeys stores a user's wrapped data key within a transaction, replacing any previous one.
+func upsertUserKeys(ctx context.Context, tx *sql.Tx, userID int64, keys dto.UserKeysDTO) error {
+	query := `
+		INSERT INTO user_keys (user_id, kdf, wrapped_key, aad_bound)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET kdf = EXCLUDED.kdf, wrapped_key = EXCLUDED.wrapped_key, aad_bound = EXCLUDED.aad_bound, updated_at = NOW()
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, keys.KDF, keys.WrappedKey, keys.AADBound); err != nil {
+		return fmt.Errorf("store user keys error: %v", err)
+	}
+
+	return nil
+}
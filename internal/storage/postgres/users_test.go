@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
@@ -71,16 +72,8 @@ func setupUserDB() (*sql.DB, func(), error) {
 		return nil, nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
 	}
 
-	migrationPath := "/Users/zrossiz/Desktop/GoProjects/praktikum/projects/gophkeeper/migrations/1_init.sql"
-
-	query, err := os.ReadFile(migrationPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read migration file: %w", err)
-	}
-
-	_, err = db.Exec(string(query))
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to execute migration: %w", err)
+	if err := applyMigrations(db); err != nil {
+		return nil, nil, err
 	}
 
 	return db, func() {
@@ -133,3 +126,115 @@ func TestUserStorage_GetUserByUsername(t *testing.T) {
 
 	clearDB()
 }
+
+func TestUserStorage_GetUserByID(t *testing.T) {
+	storage := NewUserStorage(db)
+
+	err := storage.Create(context.Background(), dto.UserDTO{Username: "byid", Password: "testpassword"})
+	assert.NoError(t, err, "Create should insert a user without error")
+
+	created, err := storage.GetUserByUsername(context.Background(), "byid")
+	assert.NoError(t, err, "GetUserByUsername should return user without error")
+
+	user, err := storage.GetUserByID(context.Background(), int64(created.ID))
+	assert.NoError(t, err, "GetUserByID should return user without error")
+	assert.Equal(t, "byid", user.Username, "Username should match")
+
+	_, err = storage.GetUserByID(context.Background(), int64(created.ID)+1)
+	assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+
+	clearDB()
+}
+
+func TestUserStorage_CreateWithKeys(t *testing.T) {
+	storage := NewUserStorage(db)
+
+	err := storage.Create(context.Background(), dto.UserDTO{
+		Username: "keysuser",
+		Password: "testpassword",
+		Keys: &dto.UserKeysDTO{
+			KDF:        "$argon2id$v=19$m=65536,t=3,p=2$MDEyMzQ1Njc4OWFiY2RlZg",
+			WrappedKey: "w1.wrapped",
+		},
+	})
+	assert.NoError(t, err, "Create should insert a user without error")
+
+	user, err := storage.GetUserByUsername(context.Background(), "keysuser")
+	assert.NoError(t, err, "GetUserByUsername should return user without error")
+
+	keys, err := storage.GetUserKeys(context.Background(), int64(user.ID))
+	assert.NoError(t, err, "GetUserKeys should return keys without error")
+	assert.Equal(t, user.ID, keys.UserID, "UserID should match")
+	assert.Equal(t, "$argon2id$v=19$m=65536,t=3,p=2$MDEyMzQ1Njc4OWFiY2RlZg", keys.KDF, "KDF should match")
+	assert.Equal(t, "w1.wrapped", keys.WrappedKey, "WrappedKey should match")
+
+	clearDB()
+}
+
+func TestUserStorage_GetUserKeys_NotFound(t *testing.T) {
+	storage := NewUserStorage(db)
+
+	err := storage.Create(context.Background(), dto.UserDTO{Username: "nokeys", Password: "testpassword"})
+	assert.NoError(t, err, "Create should insert a user without error")
+
+	user, err := storage.GetUserByUsername(context.Background(), "nokeys")
+	assert.NoError(t, err, "GetUserByUsername should return user without error")
+
+	_, err = storage.GetUserKeys(context.Background(), int64(user.ID))
+	assert.ErrorIs(t, err, apperrors.ErrUserKeysNotFound)
+
+	clearDB()
+}
+
+func TestUserStorage_Recovery(t *testing.T) {
+	storage := NewUserStorage(db)
+	ctx := context.Background()
+
+	err := storage.Create(ctx, dto.UserDTO{
+		Username: "recoveryuser",
+		Password: "testpassword",
+		Keys:     &dto.UserKeysDTO{KDF: "$argon2id$k", WrappedKey: "w1.wrapped"},
+		Recovery: &dto.RecoveryDTO{WrappedKey: "w1.recovery", CodeHashes: []string{"hash1", "hash2"}},
+	})
+	assert.NoError(t, err, "Create should insert a user without error")
+
+	user, err := storage.GetUserByUsername(ctx, "recoveryuser")
+	assert.NoError(t, err)
+	userID := int64(user.ID)
+
+	recovery, err := storage.GetRecovery(ctx, userID)
+	assert.NoError(t, err, "GetRecovery should return the recovery key without error")
+	assert.Equal(t, "w1.recovery", recovery.WrappedKey)
+
+	used, err := storage.UseRecoveryCode(ctx, userID, "hash1")
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	used, err = storage.UseRecoveryCode(ctx, userID, "hash1")
+	assert.NoError(t, err)
+	assert.False(t, used, "A recovery code should only be usable once")
+
+	err = storage.ReplaceRecovery(ctx, userID, dto.RecoveryDTO{WrappedKey: "w1.replaced", CodeHashes: []string{"hash3"}})
+	assert.NoError(t, err, "ReplaceRecovery should not return an error")
+
+	recovery, err = storage.GetRecovery(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "w1.replaced", recovery.WrappedKey)
+
+	used, err = storage.UseRecoveryCode(ctx, userID, "hash2")
+	assert.NoError(t, err)
+	assert.False(t, used, "Replaced recovery codes should stop working")
+
+	used, err = storage.UseRecoveryCode(ctx, userID, "hash3")
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	clearDB()
+}
+
+func TestUserStorage_GetRecovery_NotFound(t *testing.T) {
+	storage := NewUserStorage(db)
+
+	_, err := storage.GetRecovery(context.Background(), 42)
+	assert.ErrorIs(t, err, apperrors.ErrRecoveryNotFound)
+}
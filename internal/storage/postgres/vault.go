@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+)
+
+// vaultTable describes the encrypted columns of a vault table.
+type vaultTable struct {
+	name   string
+	text   []string // Columns holding base64 ciphertexts.
+	binary []string // BYTEA columns holding raw ciphertexts.
+}
+
+// vaultTables lists every table and column encrypted with the user's vault key.
+// It must be kept in sync with the services whenever an encrypted column is added.
+var vaultTables = []vaultTable{
+	{name: "passwords", text: []string{"username", "password", "uris"}},
+	{name: "cards", text: []string{"num", "cvv", "exp_date", "card_holder_name"}},
+	{name: "notes", text: []string{"title", "text_data"}},
+	{name: "binary_data", text: []string{"title"}, binary: []string{"binary_data"}},
+	{name: "bank_accounts", text: []string{"account_holder", "iban", "bic"}},
+	{name: "identity_documents", text: []string{"number", "holder_name", "issuing_country", "issue_date", "expiry_date"}},
+}
+
+// findVaultTable returns the vault table with the given name.
+func findVaultTable(name string) (vaultTable, bool) {
+	for _, table := range vaultTables {
+		if table.name == name {
+			return table, true
+		}
+	}
+
+	return vaultTable{}, false
+}
+
+// notShared returns an SQL condition on the rows of a vault table matching the items that are not
+// shared: shared items are encrypted with their own item key instead of the owner's data key.
+func notShared(table vaultTable) string {
+	return fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM item_keys k WHERE k.item_type = '%s' AND k.item_id = %s.id)`, table.name, table.name)
+}
+
+// VaultStorage performs operations spanning every encrypted table of a user's vault.
+type VaultStorage struct {
+	db *sql.DB // Database connection instance.
+}
+
+// NewVaultStorage initializes a new VaultStorage instance.
+//
+// Parameters:
+//   - db *sql.DB: The database connection.
+//
+// Returns:
+//   - *VaultStorage: A pointer to the initialized VaultStorage structure.
+func NewVaultStorage(db *sql.DB) *VaultStorage {
+	return &VaultStorage{db: db}
+}
+
+// Rekey atomically replaces a user's wrapped data key and, optionally, their password hash and
+// recovery-wrapped data key.
+// If reencryptText is set, every encrypted value of the user's vault is re-encrypted in the same
+// transaction, so the vault is never left half-migrated, and the vault is no longer flagged for
+// re-encryption. Empty text values (e.g. a login entry without URIs) are left untouched, and so are
+// shared items, which are encrypted with their own item key.
+//
+// Parameters:
+//   - body dto.VaultRekeyDTO: The user ID, the new wrapped data key, the optional new password hash and
+//     the optional data key wrapped by a new recovery key.
+//   - reencryptText: Decrypts a text ciphertext with the old key and encrypts it with the new one,
+//     bound to the field it is stored in; nil when only the data key is re-wrapped.
+//   - reencryptBinary: The same for binary ciphertexts.
+//
+// Returns:
+//   - error: An error if any value cannot be re-encrypted or a query fails; nothing is changed in that case.
+func (v *VaultStorage) Rekey(
+	ctx context.Context,
+	body dto.VaultRekeyDTO,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) error {
+	tx, err := v.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin rekey transaction error: %v", err)
+	}
+	defer tx.Rollback()
+
+	if reencryptText != nil {
+		for _, table := range vaultTables {
+			if err := rekeyTable(ctx, tx, table, body.UserID, reencryptText, reencryptBinary); err != nil {
+				return err
+			}
+		}
+	}
+
+	if body.PasswordHash != "" {
+		query := `UPDATE users SET password = $1, updated_at = NOW() WHERE id = $2`
+		if _, err := tx.ExecContext(ctx, query, body.PasswordHash, body.UserID); err != nil {
+			return fmt.Errorf("update user password error: %v", err)
+		}
+	}
+
+	if err := upsertUserKeys(ctx, tx, body.UserID, body.Keys); err != nil {
+		return err
+	}
+
+	if body.RecoveryKey != "" {
+		if err := upsertRecoveryKey(ctx, tx, body.UserID, body.RecoveryKey); err != nil {
+			return err
+		}
+	}
+
+	if body.RevokeSessions {
+		query := `
+			UPDATE sessions SET revoked_at = NOW(), updated_at = NOW()
+			WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+		if _, err := tx.ExecContext(ctx, query, body.UserID, body.KeepSessionID); err != nil {
+			return fmt.Errorf("revoke sessions error: %v", err)
+		}
+	}
+
+	if reencryptText != nil {
+		query := `UPDATE user_keys SET reencrypt_pending = FALSE WHERE user_id = $1`
+		if _, err := tx.ExecContext(ctx, query, body.UserID); err != nil {
+			return fmt.Errorf("clear reencrypt flag error: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// rekeyTable re-encrypts the encrypted columns of every row of one table owned by the user,
+// except the shared items.
+func rekeyTable(
+	ctx context.Context,
+	tx *sql.Tx,
+	table vaultTable,
+	userID int64,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) error {
+	_, err := rekeyRows(ctx, tx, table, userID, notShared(table), nil, reencryptText, reencryptBinary)
+	return err
+}
+
+// rekeyItem re-encrypts the encrypted columns of one item owned by the user.
+//
+// Returns:
+//   - bool: false if the user has no such item.
+//   - error: An error if a value cannot be re-encrypted or a query fails.
+func rekeyItem(
+	ctx context.Context,
+	tx *sql.Tx,
+	table vaultTable,
+	userID, itemID int64,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) (bool, error) {
+	n, err := rekeyRows(ctx, tx, table, userID, "id = $2", []any{itemID}, reencryptText, reencryptBinary)
+	return n == 1, err
+}
+
+// rekeyRows re-encrypts the encrypted columns of the rows of one table owned by the user that match
+// the SQL condition filter, whose placeholders start at $2.
+//
+// Returns:
+//   - int: The number of re-encrypted rows.
+//   - error: An error if a value cannot be re-encrypted or a query fails.
+func rekeyRows(
+	ctx context.Context,
+	tx *sql.Tx,
+	table vaultTable,
+	userID int64,
+	filter string,
+	filterArgs []any,
+	reencryptText func(dto.VaultFieldDTO, string) (string, error),
+	reencryptBinary func(dto.VaultFieldDTO, []byte) ([]byte, error),
+) (int, error) {
+	columns := append(append([]string{}, table.text...), table.binary...)
+
+	query := fmt.Sprintf(`SELECT id, %s FROM %s WHERE user_id = $1 AND %s FOR UPDATE`, strings.Join(columns, ", "), table.name, filter)
+	rows, err := tx.QueryContext(ctx, query, append([]any{userID}, filterArgs...)...)
+	if err != nil {
+		return 0, fmt.Errorf("select %s error: %v", table.name, err)
+	}
+
+	type row struct {
+		id     int64
+		text   []string
+		binary [][]byte
+	}
+
+	var records []row
+	for rows.Next() {
+		r := row{
+			text:   make([]string, len(table.text)),
+			binary: make([][]byte, len(table.binary)),
+		}
+
+		dest := []any{&r.id}
+		for i := range r.text {
+			dest = append(dest, &r.text[i])
+		}
+		for i := range r.binary {
+			dest = append(dest, &r.binary[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan %s error: %v", table.name, err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("read %s error: %v", table.name, err)
+	}
+	rows.Close()
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	update := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d`, table.name, strings.Join(assignments, ", "), len(columns)+1)
+
+	for _, r := range records {
+		args := make([]any, 0, len(columns)+1)
+
+		for i, value := range r.text {
+			if value != "" {
+				field := dto.VaultFieldDTO{UserID: userID, Table: table.name, ItemID: r.id, Column: table.text[i]}
+				if value, err = reencryptText(field, value); err != nil {
+					return 0, fmt.Errorf("reencrypt %s.%s id=%d error: %w", table.name, table.text[i], r.id, err)
+				}
+			}
+			args = append(args, value)
+		}
+		for i, value := range r.binary {
+			field := dto.VaultFieldDTO{UserID: userID, Table: table.name, ItemID: r.id, Column: table.binary[i]}
+			if value, err = reencryptBinary(field, value); err != nil {
+				return 0, fmt.Errorf("reencrypt %s.%s id=%d error: %w", table.name, table.binary[i], r.id, err)
+			}
+			args = append(args, value)
+		}
+
+		args = append(args, r.id)
+		if _, err := tx.ExecContext(ctx, update, args...); err != nil {
+			return 0, fmt.Errorf("update %s error: %v", table.name, err)
+		}
+	}
+
+	return len(records), nil
+}
+
+// nextID reserves the next value of the ID sequence of a table.
+func nextID(ctx context.Context, db *sql.DB, table string) (int64, error) {
+	var id int64
+	query := `SELECT nextval(pg_get_serial_sequence($1, 'id'))`
+	if err := db.QueryRowContext(ctx, query, table).Scan(&id); err != nil {
+		return 0, fmt.Errorf("reserve %s id error: %v", table, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idReserver is implemented by the storages of every kind of vault item.
+type idReserver interface {
+	NextID(ctx context.Context) (int64, error)
+}
+
+// reserveID reserves the ID of a new item, as the services do before encrypting it.
+func reserveID(t *testing.T, storage idReserver) int64 {
+	t.Helper()
+	id, err := storage.NextID(context.Background())
+	require.NoError(t, err, "NextID should not return an error")
+	return id
+}
+
+func TestVaultStorage_Rekey(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	logoPasses, notes, binaries := NewLogoPassStorage(db), NewNotesStorage(db), NewBinaryStorage(db)
+	noteID := reserveID(t, notes)
+
+	err := logoPasses.CreateLogoPass(ctx, dto.CreateLogoPassDTO{
+		ID:       reserveID(t, logoPasses),
+		UserId:   1,
+		AppName:  "App",
+		Username: "u",
+		Password: "p",
+	})
+	require.NoError(t, err)
+	err = notes.Create(ctx, dto.CreateNoteDTO{ID: noteID, UserID: 1, Title: "t", TextData: "d"})
+	require.NoError(t, err)
+	err = binaries.Create(ctx, dto.SetStorageBinaryDTO{ID: reserveID(t, binaries), UserID: 1, Title: "f", Data: []byte("b")})
+	require.NoError(t, err)
+
+	var fields []dto.VaultFieldDTO
+	storage := NewVaultStorage(db)
+	err = storage.Rekey(ctx, dto.VaultRekeyDTO{UserID: 1, Keys: dto.UserKeysDTO{KDF: "$argon2id$new", WrappedKey: "w1.new", AADBound: true}},
+		func(field dto.VaultFieldDTO, value string) (string, error) {
+			fields = append(fields, field)
+			return value + "!", nil
+		},
+		func(_ dto.VaultFieldDTO, value []byte) ([]byte, error) { return append(value, '!'), nil },
+	)
+	require.NoError(t, err, "Rekey should not return an error")
+	assert.Contains(t, fields, dto.VaultFieldDTO{UserID: 1, Table: "notes", ItemID: noteID, Column: "text_data"},
+		"Every value should be reported with its location")
+
+	var username, password, uris string
+	err = db.QueryRow("SELECT username, password, uris FROM passwords WHERE user_id = 1").Scan(&username, &password, &uris)
+	require.NoError(t, err)
+	assert.Equal(t, "u!", username)
+	assert.Equal(t, "p!", password)
+	assert.Empty(t, uris, "Empty values should be left untouched")
+
+	var title, textData string
+	err = db.QueryRow("SELECT title, text_data FROM notes WHERE user_id = 1").Scan(&title, &textData)
+	require.NoError(t, err)
+	assert.Equal(t, "t!", title)
+	assert.Equal(t, "d!", textData)
+
+	var binaryTitle string
+	var binaryData []byte
+	err = db.QueryRow("SELECT title, binary_data FROM binary_data WHERE user_id = 1").Scan(&binaryTitle, &binaryData)
+	require.NoError(t, err)
+	assert.Equal(t, "f!", binaryTitle)
+	assert.Equal(t, []byte("b!"), binaryData)
+
+	keys, err := NewUserStorage(db).GetUserKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "$argon2id$new", keys.KDF)
+	assert.Equal(t, "w1.new", keys.WrappedKey)
+	assert.True(t, keys.AADBound)
+}
+
+func TestVaultStorage_Rekey_RollsBackOnError(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	logoPasses := NewLogoPassStorage(db)
+	err := logoPasses.CreateLogoPass(ctx, dto.CreateLogoPassDTO{
+		ID:       reserveID(t, logoPasses),
+		UserId:   1,
+		AppName:  "App",
+		Username: "u",
+		Password: "p",
+	})
+	require.NoError(t, err)
+
+	storage := NewVaultStorage(db)
+	err = storage.Rekey(ctx, dto.VaultRekeyDTO{UserID: 1, Keys: dto.UserKeysDTO{KDF: "$argon2id$new", WrappedKey: "w1.new"}},
+		func(_ dto.VaultFieldDTO, value string) (string, error) {
+			if value == "p" {
+				return "", errors.New("cipher: message authentication failed")
+			}
+			return value + "!", nil
+		},
+		func(_ dto.VaultFieldDTO, value []byte) ([]byte, error) { return value, nil },
+	)
+	assert.Error(t, err, "Rekey should fail when a value cannot be re-encrypted")
+
+	var username string
+	err = db.QueryRow("SELECT username FROM passwords WHERE user_id = 1").Scan(&username)
+	require.NoError(t, err)
+	assert.Equal(t, "u", username, "Changes should be rolled back")
+
+	_, err = NewUserStorage(db).GetUserKeys(ctx, 1)
+	assert.ErrorIs(t, err, apperrors.ErrUserKeysNotFound, "Keys should not be stored")
+}
+
+func TestVaultStorage_Rekey_PasswordOnly(t *testing.T) {
+	db, cleanup := setupDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	logoPasses := NewLogoPassStorage(db)
+	err := logoPasses.CreateLogoPass(ctx, dto.CreateLogoPassDTO{
+		ID:       reserveID(t, logoPasses),
+		UserId:   1,
+		AppName:  "App",
+		Username: "u",
+		Password: "p",
+	})
+	require.NoError(t, err)
+
+	users := NewUserStorage(db)
+	require.NoError(t, users.CreateSession(ctx, entities.Session{ID: "fam", UserID: 1, CurrentTokenID: "t1", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, users.CreateSession(ctx, entities.Session{ID: "phone", UserID: 1, CurrentTokenID: "t2", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	storage := NewVaultStorage(db)
+	err = storage.Rekey(ctx, dto.VaultRekeyDTO{
+		UserID:         1,
+		Keys:           dto.UserKeysDTO{KDF: "$argon2id$new", WrappedKey: "w1.rewrapped"},
+		PasswordHash:   "new-hash",
+		RevokeSessions: true,
+		KeepSessionID:  "phone",
+	}, nil, nil)
+	require.NoError(t, err, "Rekey should not return an error")
+
+	var username, password string
+	err = db.QueryRow("SELECT username FROM passwords WHERE user_id = 1").Scan(&username)
+	require.NoError(t, err)
+	assert.Equal(t, "u", username, "Vault items should not be re-encrypted")
+
+	err = db.QueryRow("SELECT password FROM users WHERE id = 1").Scan(&password)
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", password)
+
+	keys, err := users.GetUserKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "w1.rewrapped", keys.WrappedKey)
+
+	session, err := users.GetSession(ctx, "fam")
+	require.NoError(t, err)
+	assert.NotNil(t, session.RevokedAt, "The sessions of the user should be revoked")
+
+	session, err = users.GetSession(ctx, "phone")
+	require.NoError(t, err)
+	assert.Nil(t, session.RevokedAt, "The kept session should not be revoked")
+}
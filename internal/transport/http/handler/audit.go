@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type AuditHandler struct {
+	service AuditService
+	log     *zap.Logger
+}
+
+type AuditService interface {
+	Report(ctx context.Context, userID int64, key string, opts dto.AuditOptionsDTO) (*dto.AuditReportDTO, error)
+}
+
+func NewAuditHandler(service AuditService, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Отчет о безопасности хранилища
+// @Description Расшифровывает логины-пароли и карты пользователя и возвращает отчет:
+// @Description повторяющиеся, слабые, утекшие и давно не менявшиеся пароли, карты с истекающим сроком
+// @Description и записи, которые не удалось расшифровать. Сами пароли в отчет не попадают.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer {token})
+// @Param userID path int true "ID пользователя"
+// @Param stale_days query int false "Пароль считается устаревшим, если не менялся столько дней (по умолчанию 180)"
+// @Param expiring_days query int false "Карта считается истекающей, если срок истекает в течение стольких дней (по умолчанию 60)"
+// @Param min_entropy query number false "Минимальная оценка энтропии пароля в битах (по умолчанию 60)"
+// @Success 200 {object} dto.AuditReportDTO "Отчет о безопасности"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /audit/user/{userID} [get]
+// @Security BearerAuth
+func (a *AuditHandler) Report(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	intUserID, err := strconv.Atoi(userID)
+	if err != nil {
+		http.Error(rw, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseAuditOptions(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := a.service.Report(r.Context(), int64(intUserID), key, opts)
+	if err != nil {
+		a.log.Sugar().Errorf("build audit report error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(report)
+}
+
+// parseAuditOptions reads the optional report thresholds from the query string.
+func parseAuditOptions(r *http.Request) (dto.AuditOptionsDTO, error) {
+	var opts dto.AuditOptionsDTO
+	query := r.URL.Query()
+
+	if v := query.Get("stale_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return opts, fmt.Errorf("invalid query parameter stale_days")
+		}
+		opts.StaleDays = days
+	}
+
+	if v := query.Get("expiring_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return opts, fmt.Errorf("invalid query parameter expiring_days")
+		}
+		opts.ExpiringDays = days
+	}
+
+	if v := query.Get("min_entropy"); v != "" {
+		bits, err := strconv.ParseFloat(v, 64)
+		if err != nil || bits < 0 {
+			return opts, fmt.Errorf("invalid query parameter min_entropy")
+		}
+		opts.MinEntropy = bits
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Report(ctx context.Context, userID int64, key string, opts dto.AuditOptionsDTO) (*dto.AuditReportDTO, error) {
+	args := m.Called(userID, key, opts)
+	if report, ok := args.Get(0).(*dto.AuditReportDTO); ok {
+		return report, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func auditRequest(target string) *http.Request {
+	req := httptest.NewRequest("GET", target, nil)
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("userID", "1")
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestAuditReport_Success(t *testing.T) {
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService, zap.NewNop())
+
+	opts := dto.AuditOptionsDTO{StaleDays: 90, ExpiringDays: 30, MinEntropy: 72.5}
+	mockService.On("Report", int64(1), "testkey", opts).
+		Return(&dto.AuditReportDTO{Summary: dto.AuditSummaryDTO{LogoPasses: 3, Weak: 1}}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Report(rec, auditRequest("/audit/user/1?stale_days=90&expiring_days=30&min_entropy=72.5"))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var report dto.AuditReportDTO
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	assert.Equal(t, 3, report.Summary.LogoPasses)
+	assert.Equal(t, 1, report.Summary.Weak)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuditReport_InvalidQuery(t *testing.T) {
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService, zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	handler.Report(rec, auditRequest("/audit/user/1?stale_days=soon"))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "stale_days")
+	mockService.AssertNotCalled(t, "Report", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuditReport_MissingCookie(t *testing.T) {
+	handler := NewAuditHandler(new(MockAuditService), zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	handler.Report(rec, httptest.NewRequest("GET", "/audit/user/1", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type BankAccountHandler struct {
+	service BankAccountService
+	log     *zap.Logger
+}
+
+type BankAccountService interface {
+	Create(ctx context.Context, body dto.CreateBankAccountDTO) error
+	Update(ctx context.Context, accountID int64, body dto.UpdateBankAccountDTO) error
+	GetAll(ctx context.Context, userID int64, key string) ([]entities.BankAccount, error)
+}
+
+func NewBankAccountHandler(service BankAccountService, logger *zap.Logger) *BankAccountHandler {
+	return &BankAccountHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Создать банковский счет
+// @Description Создает новый банковский счет пользователя (IBAN/BIC)
+// @Tags bank-account
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateBankAccountDTO true "Данные для создания банковского счета"
+// @Success 201 {string} string "Created"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /bank-account [post]
+// @Security BearerAuth
+func (b *BankAccountHandler) Create(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	var body dto.CreateBankAccountDTO
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	userID, ok := authorizedUserID(rw, r, int64(body.UserID))
+	if !ok {
+		return
+	}
+	body.UserID = int(userID)
+	body.Key = key
+
+	err = b.service.Create(ctx, body)
+	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b.log.Sugar().Errorf("create bank account error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+}
+
+// @Summary Обновить банковский счет
+// @Description Обновляет данные банковского счета
+// @Tags bank-account
+// @Accept json
+// @Produce json
+// @Param accountID path int true "ID банковского счета"
+// @Param body body dto.UpdateBankAccountDTO true "Данные для обновления банковского счета"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /bank-account/{accountID} [put]
+// @Security BearerAuth
+func (b *BankAccountHandler) Update(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	var body dto.UpdateBankAccountDTO
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.Key = key
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
+
+	accountID := chi.URLParam(r, "accountID")
+	intAccountID, err := strconv.Atoi(accountID)
+	if err != nil {
+		http.Error(rw, "invalid bank account id", http.StatusBadRequest)
+		return
+	}
+
+	err = b.service.Update(ctx, int64(intAccountID), body)
+	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b.log.Sugar().Errorf("update bank account error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// @Summary Получить все банковские счета пользователя
+// @Description Возвращает список всех банковских счетов пользователя
+// @Tags bank-account
+// @Accept json
+// @Produce json
+// @Param userID path int true "ID пользователя"
+// @Success 200 {array} entities.BankAccount "Список банковских счетов"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /bank-account/user/{userID} [get]
+// @Security BearerAuth
+func (b *BankAccountHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID := chi.URLParam(r, "userID")
+	intUserID, err := strconv.Atoi(userID)
+	if err != nil {
+		http.Error(rw, "invalid user id ", http.StatusBadRequest)
+		return
+	}
+
+	items, err := b.service.GetAll(ctx, int64(intUserID), key)
+	if err != nil {
+		b.log.Sugar().Errorf("get all bank accounts error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(items); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -39,10 +39,11 @@ func NewBinaryHandler(service BinaryService, logger *zap.Logger) *BinaryHandler
 // @Produce json
 // @Param Authorization header string true "Bearer токен" default(Bearer {token})
 // @Param file formData file true "Файл для загрузки"
-// @Param user_id formData int true "ID пользователя"
+// @Param user_id formData int false "ID пользователя; должен совпадать с текущим пользователем"
 // @Success 201 {string} string "File uploaded successfully!"
 // @Failure 400 {string} string "Bad Request"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /binary/ [post]
 // @Security BearerAuth
@@ -54,7 +55,7 @@ func (b *BinaryHandler) Create(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -73,17 +74,25 @@ func (b *BinaryHandler) Create(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.FormValue("user_id")
-	intUserID, err := strconv.Atoi(userID)
-	if err != nil {
-		http.Error(rw, "invalid user id", http.StatusBadRequest)
+	var requestedID int
+	if value := r.FormValue("user_id"); value != "" {
+		requestedID, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(rw, "invalid user id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	userID, ok := authorizedUserID(rw, r, int64(requestedID))
+	if !ok {
+		return
 	}
 
 	body := dto.CreateBinaryDTO{
 		Title:  header.Filename,
 		Data:   fileData,
-		UserID: intUserID,
-		Key:    key.Value,
+		UserID: int(userID),
+		Key:    key,
 	}
 
 	err = b.service.Create(ctx, body)
@@ -120,13 +129,13 @@ func (b *BinaryHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
 	}
 
-	items, err := b.service.GetAll(ctx, int64(intUserID), key.Value)
+	items, err := b.service.GetAll(ctx, int64(intUserID), key)
 	if err != nil {
 		b.log.Sugar().Errorf("error get all binaries: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
@@ -23,6 +25,7 @@ type CardService interface {
 	Create(ctx context.Context, body dto.CreateCardDTO) error
 	Update(ctx context.Context, cardID int64, body dto.UpdateCardDTO) error
 	GetAll(ctx context.Context, userID int64, key string) ([]entities.Card, error)
+	Reveal(ctx context.Context, userID, cardID int64, key string) (*entities.Card, error)
 }
 
 func NewCardHandler(service CardService, logger *zap.Logger) *CardHandler {
@@ -40,11 +43,13 @@ func NewCardHandler(service CardService, logger *zap.Logger) *CardHandler {
 // @Param body body dto.CreateCardDTO true "Данные для создания карточки"
 // @Success 201 {string} string "Created"
 // @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /card [post]
 // @Security BearerAuth
 func (c *CardHandler) Create(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -59,10 +64,19 @@ func (c *CardHandler) Create(rw http.ResponseWriter, r *http.Request) {
 		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
 		return
 	}
-	body.Key = key.Value
+	userID, ok := authorizedUserID(rw, r, int64(body.UserID))
+	if !ok {
+		return
+	}
+	body.UserID = int(userID)
+	body.Key = key
 
 	err = c.service.Create(ctx, body)
 	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
 		c.log.Sugar().Errorf("create card error: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
 		return
@@ -80,11 +94,12 @@ func (c *CardHandler) Create(rw http.ResponseWriter, r *http.Request) {
 // @Param body body dto.UpdateCardDTO true "Данные для обновления карточки"
 // @Success 200 {string} string "OK"
 // @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /card/{cardID} [put]
 // @Security BearerAuth
 func (c *CardHandler) Update(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -98,7 +113,14 @@ func (c *CardHandler) Update(rw http.ResponseWriter, r *http.Request) {
 		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
 		return
 	}
-	body.Key = key.Value
+	body.Key = key
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
 
 	cardID := chi.URLParam(r, "cardID")
 	intCardID, err := strconv.Atoi(cardID)
@@ -109,6 +131,10 @@ func (c *CardHandler) Update(rw http.ResponseWriter, r *http.Request) {
 
 	err = c.service.Update(ctx, int64(intCardID), body)
 	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
 		c.log.Sugar().Errorf("update card error: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
 		return
@@ -118,7 +144,7 @@ func (c *CardHandler) Update(rw http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Получить все карточки пользователя
-// @Description Возвращает список всех карточек пользователя
+// @Description Возвращает список всех карточек пользователя. Номер карты маскируется, CVV не возвращается
 // @Tags card
 // @Accept json
 // @Produce json
@@ -129,7 +155,7 @@ func (c *CardHandler) Update(rw http.ResponseWriter, r *http.Request) {
 // @Router /card/user/{userID} [get]
 // @Security BearerAuth
 func (c *CardHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -144,7 +170,7 @@ func (c *CardHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	items, err := c.service.GetAll(ctx, int64(intUserID), key.Value)
+	items, err := c.service.GetAll(ctx, int64(intUserID), key)
 	if err != nil {
 		c.log.Sugar().Errorf("get all cards error: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
@@ -157,3 +183,56 @@ func (c *CardHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
 		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+// @Summary Показать полные данные карточки
+// @Description Возвращает карточку текущего пользователя с полным номером и CVV
+// @Tags card
+// @Accept json
+// @Produce json
+// @Param cardID path int true "ID карточки"
+// @Success 200 {object} entities.Card "Карточка"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /card/{cardID}/reveal [get]
+// @Security BearerAuth
+func (c *CardHandler) Reveal(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cardID := chi.URLParam(r, "cardID")
+	intCardID, err := strconv.Atoi(cardID)
+	if err != nil {
+		http.Error(rw, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	card, err := c.service.Reveal(ctx, userID, int64(intCardID), key)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrCardNotFound) {
+			http.Error(rw, err.Error(), http.StatusNotFound)
+			return
+		}
+		c.log.Sugar().Errorf("reveal card error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(card); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
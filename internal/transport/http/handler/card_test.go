@@ -9,8 +9,11 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
@@ -35,6 +38,14 @@ func (m *MockCardService) GetAll(ctx context.Context, userID int64, key string)
 	return args.Get(0).([]entities.Card), args.Error(1)
 }
 
+func (m *MockCardService) Reveal(ctx context.Context, userID, cardID int64, key string) (*entities.Card, error) {
+	args := m.Called(userID, cardID, key)
+	if card, ok := args.Get(0).(*entities.Card); ok {
+		return card, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func setupCardTestHandler() (*CardHandler, *MockCardService) {
 	mockService := new(MockCardService)
 	logger := zap.NewNop()
@@ -53,6 +64,7 @@ func TestCardCreate_Success(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/card", bytes.NewBuffer(bodyBytes))
 	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
@@ -117,3 +129,76 @@ func TestCardGetAll_InvalidUserID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestCardCreate_ValidationError(t *testing.T) {
+	handler, mockService := setupCardTestHandler()
+
+	mockService.On("Create", mock.AnythingOfType("dto.CreateCardDTO")).Return(apperrors.ErrInvalidCardNumber)
+
+	body := dto.CreateCardDTO{Num: "1234", ExpDate: "12/30", CVV: "123"}
+	bodyBytes, _ := json.Marshal(body)
+
+	req := httptest.NewRequest("POST", "/card", bytes.NewBuffer(bodyBytes))
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
+	rec := httptest.NewRecorder()
+
+	handler.Create(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), apperrors.ErrInvalidCardNumber.Error())
+}
+
+func TestCardReveal_Success(t *testing.T) {
+	handler, mockService := setupCardTestHandler()
+
+	mockService.On("Reveal", int64(1), int64(5), "testkey").
+		Return(&entities.Card{ID: 5, Number: "4111111111111111", CVV: "123"}, nil)
+
+	req := httptest.NewRequest("GET", "/card/5/reveal", nil)
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("cardID", "5")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(context.WithValue(ctx, middleware.UserIDContextKey, int64(1)))
+	rec := httptest.NewRecorder()
+
+	handler.Reveal(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"cvv":"123"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestCardReveal_NotFound(t *testing.T) {
+	handler, mockService := setupCardTestHandler()
+
+	mockService.On("Reveal", int64(1), int64(5), "testkey").Return(nil, apperrors.ErrCardNotFound)
+
+	req := httptest.NewRequest("GET", "/card/5/reveal", nil)
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("cardID", "5")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(context.WithValue(ctx, middleware.UserIDContextKey, int64(1)))
+	rec := httptest.NewRecorder()
+
+	handler.Reveal(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCardReveal_Unauthorized(t *testing.T) {
+	handler, _ := setupCardTestHandler()
+
+	req := httptest.NewRequest("GET", "/card/5/reveal", nil)
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("cardID", "5")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+
+	handler.Reveal(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
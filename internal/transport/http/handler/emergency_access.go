@@ -0,0 +1,405 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type EmergencyHandler struct {
+	service EmergencyService
+	log     *zap.Logger
+}
+
+type EmergencyService interface {
+	Invite(ctx context.Context, body dto.CreateEmergencyAccessDTO) (*entities.EmergencyAccess, error)
+	ListContacts(ctx context.Context, grantorID int64) ([]entities.EmergencyAccess, error)
+	ListGrantors(ctx context.Context, granteeID int64) ([]entities.EmergencyAccess, error)
+	Delete(ctx context.Context, grantorID, id int64) error
+	Approve(ctx context.Context, grantorID, id int64) error
+	Reject(ctx context.Context, grantorID, id int64) error
+	Request(ctx context.Context, granteeID, id int64) (*entities.EmergencyAccess, error)
+	View(ctx context.Context, granteeID, id int64, key string) (*dto.EmergencyVaultDTO, error)
+	Takeover(ctx context.Context, body dto.EmergencyTakeoverDTO) error
+}
+
+func NewEmergencyHandler(service EmergencyService, logger *zap.Logger) *EmergencyHandler {
+	return &EmergencyHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Добавить экстренный контакт
+// @Description Назначает пользователя экстренным контактом с доступом view (по умолчанию) или takeover. Ключ хранилища запечатывается открытым ключом контакта. Если wait_days не указан, используется период ожидания по умолчанию
+// @Tags emergency
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateEmergencyAccessDTO true "Имя пользователя, тип доступа и период ожидания в днях"
+// @Success 201 {object} entities.EmergencyAccess "Экстренный доступ"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/contacts [post]
+// @Security BearerAuth
+func (e *EmergencyHandler) Invite(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body dto.CreateEmergencyAccessDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.GrantorID = userID
+	body.Key = key
+
+	access, err := e.service.Invite(ctx, body)
+	if err != nil {
+		e.writeError(rw, "invite emergency contact error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusCreated, access)
+}
+
+// @Summary Получить экстренные контакты
+// @Description Возвращает экстренные контакты текущего пользователя и статус их запросов доступа
+// @Tags emergency
+// @Produce json
+// @Success 200 {array} entities.EmergencyAccess "Список экстренных контактов"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/contacts [get]
+// @Security BearerAuth
+func (e *EmergencyHandler) ListContacts(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accesses, err := e.service.ListContacts(ctx, userID)
+	if err != nil {
+		e.writeError(rw, "list emergency contacts error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, accesses)
+}
+
+// @Summary Удалить экстренный контакт
+// @Description Удаляет экстренный контакт текущего пользователя вместе с запечатанным ключом хранилища
+// @Tags emergency
+// @Param id path int true "ID экстренного доступа"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/contacts/{id} [delete]
+// @Security BearerAuth
+func (e *EmergencyHandler) Delete(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid emergency access id", http.StatusBadRequest)
+		return
+	}
+
+	if err := e.service.Delete(ctx, userID, id); err != nil {
+		e.writeError(rw, "delete emergency contact error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Одобрить запрос экстренного доступа
+// @Description Предоставляет запрошенный экстренный доступ до окончания периода ожидания
+// @Tags emergency
+// @Param id path int true "ID экстренного доступа"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/contacts/{id}/approve [post]
+// @Security BearerAuth
+func (e *EmergencyHandler) Approve(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid emergency access id", http.StatusBadRequest)
+		return
+	}
+
+	if err := e.service.Approve(ctx, userID, id); err != nil {
+		e.writeError(rw, "approve emergency access error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Отклонить запрос экстренного доступа
+// @Description Отклоняет запрос экстренного доступа или отзывает предоставленный доступ. Контакт может запросить доступ повторно
+// @Tags emergency
+// @Param id path int true "ID экстренного доступа"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/contacts/{id}/reject [post]
+// @Security BearerAuth
+func (e *EmergencyHandler) Reject(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid emergency access id", http.StatusBadRequest)
+		return
+	}
+
+	if err := e.service.Reject(ctx, userID, id); err != nil {
+		e.writeError(rw, "reject emergency access error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Получить предоставленные экстренные доступы
+// @Description Возвращает хранилища, экстренным контактом которых назначен текущий пользователь, и статус доступа к ним
+// @Tags emergency
+// @Produce json
+// @Success 200 {array} entities.EmergencyAccess "Список экстренных доступов"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/granted [get]
+// @Security BearerAuth
+func (e *EmergencyHandler) ListGrantors(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accesses, err := e.service.ListGrantors(ctx, userID)
+	if err != nil {
+		e.writeError(rw, "list emergency grantors error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, accesses)
+}
+
+// @Summary Запросить экстренный доступ
+// @Description Запрашивает экстренный доступ к хранилищу. Если владелец не отклонит запрос в течение периода ожидания, доступ будет предоставлен
+// @Tags emergency
+// @Produce json
+// @Param id path int true "ID экстренного доступа"
+// @Success 200 {object} entities.EmergencyAccess "Экстренный доступ с временем предоставления"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/granted/{id}/request [post]
+// @Security BearerAuth
+func (e *EmergencyHandler) Request(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid emergency access id", http.StatusBadRequest)
+		return
+	}
+
+	access, err := e.service.Request(ctx, userID, id)
+	if err != nil {
+		e.writeError(rw, "request emergency access error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, access)
+}
+
+// @Summary Получить хранилище по экстренному доступу
+// @Description Возвращает расшифрованное хранилище владельца, если экстренный доступ предоставлен
+// @Tags emergency
+// @Produce json
+// @Param id path int true "ID экстренного доступа"
+// @Success 200 {object} dto.EmergencyVaultDTO "Хранилище владельца"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/granted/{id}/vault [get]
+// @Security BearerAuth
+func (e *EmergencyHandler) View(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid emergency access id", http.StatusBadRequest)
+		return
+	}
+
+	vault, err := e.service.View(ctx, userID, id, key)
+	if err != nil {
+		e.writeError(rw, "view emergency vault error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, vault)
+}
+
+// @Summary Получить контроль над аккаунтом
+// @Description Устанавливает новый мастер-пароль аккаунта владельца, если предоставлен экстренный доступ типа takeover. Содержимое хранилища сохраняется
+// @Tags emergency
+// @Accept json
+// @Param id path int true "ID экстренного доступа"
+// @Param body body dto.EmergencyTakeoverDTO true "Новый мастер-пароль"
+// @Success 204 "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /emergency/granted/{id}/takeover [post]
+// @Security BearerAuth
+func (e *EmergencyHandler) Takeover(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid emergency access id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.EmergencyTakeoverDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.NewPassword == "" {
+		http.Error(rw, "password can not be empty", http.StatusBadRequest)
+		return
+	}
+	body.ID = id
+	body.GranteeID = userID
+	body.Key = key
+
+	if err := e.service.Takeover(ctx, body); err != nil {
+		e.writeError(rw, "emergency takeover error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (e *EmergencyHandler) writeError(rw http.ResponseWriter, msg string, err error) {
+	if writePasswordPolicyError(rw, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidEmergencyAccess):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrEmergencyAccessNotApproved),
+		errors.Is(err, apperrors.ErrEmergencyTakeoverNotAllowed):
+		http.Error(rw, err.Error(), http.StatusForbidden)
+	case errors.Is(err, apperrors.ErrEmergencyContactNotFound),
+		errors.Is(err, apperrors.ErrEmergencyAccessNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrEmergencyAccessExists),
+		errors.Is(err, apperrors.ErrKeyPairNotFound),
+		errors.Is(err, apperrors.ErrKeyDerivation):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	default:
+		e.log.Sugar().Errorf("%s: %v", msg, err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockEmergencyService struct {
+	mock.Mock
+}
+
+func (m *MockEmergencyService) Invite(ctx context.Context, body dto.CreateEmergencyAccessDTO) (*entities.EmergencyAccess, error) {
+	args := m.Called(body)
+	if access, ok := args.Get(0).(*entities.EmergencyAccess); ok {
+		return access, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockEmergencyService) ListContacts(ctx context.Context, grantorID int64) ([]entities.EmergencyAccess, error) {
+	args := m.Called(grantorID)
+	return args.Get(0).([]entities.EmergencyAccess), args.Error(1)
+}
+
+func (m *MockEmergencyService) ListGrantors(ctx context.Context, granteeID int64) ([]entities.EmergencyAccess, error) {
+	args := m.Called(granteeID)
+	return args.Get(0).([]entities.EmergencyAccess), args.Error(1)
+}
+
+func (m *MockEmergencyService) Delete(ctx context.Context, grantorID, id int64) error {
+	args := m.Called(grantorID, id)
+	return args.Error(0)
+}
+
+func (m *MockEmergencyService) Approve(ctx context.Context, grantorID, id int64) error {
+	args := m.Called(grantorID, id)
+	return args.Error(0)
+}
+
+func (m *MockEmergencyService) Reject(ctx context.Context, grantorID, id int64) error {
+	args := m.Called(grantorID, id)
+	return args.Error(0)
+}
+
+func (m *MockEmergencyService) Request(ctx context.Context, granteeID, id int64) (*entities.EmergencyAccess, error) {
+	args := m.Called(granteeID, id)
+	if access, ok := args.Get(0).(*entities.EmergencyAccess); ok {
+		return access, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockEmergencyService) View(ctx context.Context, granteeID, id int64, key string) (*dto.EmergencyVaultDTO, error) {
+	args := m.Called(granteeID, id, key)
+	if vault, ok := args.Get(0).(*dto.EmergencyVaultDTO); ok {
+		return vault, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockEmergencyService) Takeover(ctx context.Context, body dto.EmergencyTakeoverDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func TestEmergencyInvite(t *testing.T) {
+	mockService := new(MockEmergencyService)
+	handler := NewEmergencyHandler(mockService, zap.NewNop())
+
+	mockService.On("Invite", mock.MatchedBy(func(body dto.CreateEmergencyAccessDTO) bool {
+		return body.GrantorID == 1 && body.Key == "testkey" && body.Username == "bob" && body.AccessType == "takeover" && body.WaitDays != nil && *body.WaitDays == 3
+	})).
+		Return(&entities.EmergencyAccess{ID: 5, GranteeUsername: "bob", AccessType: "takeover", WrappedKey: "s1.secret"}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Invite(rec, newOrgRequest("POST", "/api/emergency/contacts", `{"username":"bob","access_type":"takeover","wait_days":3}`, nil))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"grantee":"bob"`)
+	assert.NotContains(t, rec.Body.String(), "s1.secret", "The sealed vault key should not be returned")
+	mockService.AssertExpectations(t)
+}
+
+func TestEmergencyInvite_Errors(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{apperrors.ErrInvalidEmergencyAccess, http.StatusBadRequest},
+		{apperrors.ErrEmergencyContactNotFound, http.StatusNotFound},
+		{apperrors.ErrEmergencyAccessExists, http.StatusConflict},
+		{apperrors.ErrKeyPairNotFound, http.StatusConflict},
+		{assert.AnError, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			mockService := new(MockEmergencyService)
+			handler := NewEmergencyHandler(mockService, zap.NewNop())
+			mockService.On("Invite", mock.Anything).Return(nil, tc.err)
+
+			rec := httptest.NewRecorder()
+			handler.Invite(rec, newOrgRequest("POST", "/api/emergency/contacts", `{"username":"bob"}`, nil))
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestEmergencyReject(t *testing.T) {
+	mockService := new(MockEmergencyService)
+	handler := NewEmergencyHandler(mockService, zap.NewNop())
+
+	mockService.On("Reject", int64(1), int64(5)).Return(nil)
+	mockService.On("Reject", int64(1), int64(6)).Return(apperrors.ErrEmergencyAccessNotFound)
+
+	rec := httptest.NewRecorder()
+	handler.Reject(rec, newOrgRequest("POST", "/api/emergency/contacts/5/reject", "", map[string]string{"id": "5"}))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Reject(rec, newOrgRequest("POST", "/api/emergency/contacts/6/reject", "", map[string]string{"id": "6"}))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Reject(rec, newOrgRequest("POST", "/api/emergency/contacts/x/reject", "", map[string]string{"id": "x"}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestEmergencyView(t *testing.T) {
+	mockService := new(MockEmergencyService)
+	handler := NewEmergencyHandler(mockService, zap.NewNop())
+
+	mockService.On("View", int64(1), int64(5), "testkey").Return(&dto.EmergencyVaultDTO{
+		LogoPasses: []entities.LogoPassword{{ID: 1, AppName: "mail", Password: "secret"}},
+	}, nil)
+	mockService.On("View", int64(1), int64(6), "testkey").Return(nil, apperrors.ErrEmergencyAccessNotApproved)
+
+	rec := httptest.NewRecorder()
+	handler.View(rec, newOrgRequest("GET", "/api/emergency/granted/5/vault", "", map[string]string{"id": "5"}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "secret")
+
+	rec = httptest.NewRecorder()
+	handler.View(rec, newOrgRequest("GET", "/api/emergency/granted/6/vault", "", map[string]string{"id": "6"}))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestEmergencyTakeover(t *testing.T) {
+	mockService := new(MockEmergencyService)
+	handler := NewEmergencyHandler(mockService, zap.NewNop())
+
+	mockService.On("Takeover", dto.EmergencyTakeoverDTO{ID: 5, GranteeID: 1, Key: "testkey", NewPassword: "newpassword"}).Return(nil)
+	mockService.On("Takeover", mock.MatchedBy(func(body dto.EmergencyTakeoverDTO) bool { return body.ID == 6 })).
+		Return(apperrors.ErrEmergencyTakeoverNotAllowed)
+
+	rec := httptest.NewRecorder()
+	handler.Takeover(rec, newOrgRequest("POST", "/api/emergency/granted/5/takeover", `{"new_password":"newpassword"}`, map[string]string{"id": "5"}))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Takeover(rec, newOrgRequest("POST", "/api/emergency/granted/6/takeover", `{"new_password":"newpassword"}`, map[string]string{"id": "6"}))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Takeover(rec, newOrgRequest("POST", "/api/emergency/granted/5/takeover", `{}`, map[string]string{"id": "5"}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
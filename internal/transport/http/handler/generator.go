@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"go.uber.org/zap"
+)
+
+type GeneratorHandler struct {
+	service GeneratorService
+	log     *zap.Logger
+}
+
+type GeneratorService interface {
+	GeneratePassword(ctx context.Context, body dto.GeneratePasswordDTO) (*dto.GeneratedSecretDTO, error)
+	GeneratePassphrase(ctx context.Context, body dto.GeneratePassphraseDTO) (*dto.GeneratedSecretDTO, error)
+}
+
+func NewGeneratorHandler(service GeneratorService, logger *zap.Logger) *GeneratorHandler {
+	return &GeneratorHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Сгенерировать пароль
+// @Description Генерирует случайный пароль по политике (длина, классы символов, исключения).
+// @Description Если передан save_as, пароль сразу сохраняется как новая запись логина-пароля
+// @Description в хранилище авторизованного пользователя; user_id, если указан, должен совпадать с ним.
+// @Tags generator
+// @Accept json
+// @Produce json
+// @Param body body dto.GeneratePasswordDTO true "Политика генерации пароля"
+// @Success 200 {object} dto.GeneratedSecretDTO "Сгенерированный пароль"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /generator/password [post]
+// @Security BearerAuth
+func (g *GeneratorHandler) Password(rw http.ResponseWriter, r *http.Request) {
+	var body dto.GeneratePasswordDTO
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.SaveAs != nil {
+		key, err := vaultKey(r)
+		if err != nil {
+			http.Error(rw, "key not found", http.StatusBadRequest)
+			return
+		}
+		userID, ok := authorizedUserID(rw, r, int64(body.SaveAs.UserId))
+		if !ok {
+			return
+		}
+		body.SaveAs.UserId = int(userID)
+		body.Key = key
+	}
+
+	generated, err := g.service.GeneratePassword(r.Context(), body)
+	if err != nil {
+		g.writeError(rw, "generate password error", err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(generated)
+}
+
+// @Summary Сгенерировать парольную фразу
+// @Description Генерирует парольную фразу из случайных слов встроенного словаря EFF.
+// @Description Если передан save_as, фраза сразу сохраняется как новая запись логина-пароля
+// @Description в хранилище авторизованного пользователя; user_id, если указан, должен совпадать с ним.
+// @Tags generator
+// @Accept json
+// @Produce json
+// @Param body body dto.GeneratePassphraseDTO true "Политика генерации парольной фразы"
+// @Success 200 {object} dto.GeneratedSecretDTO "Сгенерированная парольная фраза"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /generator/passphrase [post]
+// @Security BearerAuth
+func (g *GeneratorHandler) Passphrase(rw http.ResponseWriter, r *http.Request) {
+	var body dto.GeneratePassphraseDTO
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.SaveAs != nil {
+		key, err := vaultKey(r)
+		if err != nil {
+			http.Error(rw, "key not found", http.StatusBadRequest)
+			return
+		}
+		userID, ok := authorizedUserID(rw, r, int64(body.SaveAs.UserId))
+		if !ok {
+			return
+		}
+		body.SaveAs.UserId = int(userID)
+		body.Key = key
+	}
+
+	generated, err := g.service.GeneratePassphrase(r.Context(), body)
+	if err != nil {
+		g.writeError(rw, "generate passphrase error", err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(generated)
+}
+
+// writeError answers policy errors with 400 and logs everything else as an internal error.
+func (g *GeneratorHandler) writeError(rw http.ResponseWriter, msg string, err error) {
+	if isValidationError(err) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	g.log.Sugar().Errorf("%s: %v", msg, err)
+	http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockGeneratorService struct {
+	mock.Mock
+}
+
+func (m *MockGeneratorService) GeneratePassword(ctx context.Context, body dto.GeneratePasswordDTO) (*dto.GeneratedSecretDTO, error) {
+	args := m.Called(body)
+	if result, ok := args.Get(0).(*dto.GeneratedSecretDTO); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockGeneratorService) GeneratePassphrase(ctx context.Context, body dto.GeneratePassphraseDTO) (*dto.GeneratedSecretDTO, error) {
+	args := m.Called(body)
+	if result, ok := args.Get(0).(*dto.GeneratedSecretDTO); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// newGeneratorRequest builds a generator request of user 1 with a vault key.
+func newGeneratorRequest(path string, body any) *http.Request {
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(bodyBytes))
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
+}
+
+func TestGeneratorHandler_Password_SaveAs(t *testing.T) {
+	mockService := new(MockGeneratorService)
+	handler := NewGeneratorHandler(mockService, zap.NewNop())
+
+	mockService.On("GeneratePassword", mock.MatchedBy(func(body dto.GeneratePasswordDTO) bool {
+		return body.SaveAs != nil && body.SaveAs.UserId == 1 && body.Key == "testkey"
+	})).Return(&dto.GeneratedSecretDTO{Secret: "s3cret", Saved: true}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Password(rec, newGeneratorRequest("/generator/password", dto.GeneratePasswordDTO{
+		Length: 16,
+		SaveAs: &dto.SaveGeneratedDTO{AppName: "mail"},
+	}))
+
+	assert.Equal(t, http.StatusOK, rec.Code, "The entry should be saved to the authenticated user's vault")
+	mockService.AssertExpectations(t)
+}
+
+func TestGeneratorHandler_Password_SaveAsOtherUser(t *testing.T) {
+	mockService := new(MockGeneratorService)
+	handler := NewGeneratorHandler(mockService, zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	handler.Password(rec, newGeneratorRequest("/generator/password", dto.GeneratePasswordDTO{
+		Length: 16,
+		SaveAs: &dto.SaveGeneratedDTO{UserId: 2, AppName: "mail"},
+	}))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "GeneratePassword", mock.Anything)
+}
+
+func TestGeneratorHandler_Passphrase_SaveAsOtherUser(t *testing.T) {
+	mockService := new(MockGeneratorService)
+	handler := NewGeneratorHandler(mockService, zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	handler.Passphrase(rec, newGeneratorRequest("/generator/passphrase", dto.GeneratePassphraseDTO{
+		Words:  5,
+		SaveAs: &dto.SaveGeneratedDTO{UserId: 2, AppName: "mail"},
+	}))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "GeneratePassphrase", mock.Anything)
+}
+
+func TestGeneratorHandler_Passphrase_SaveAs(t *testing.T) {
+	mockService := new(MockGeneratorService)
+	handler := NewGeneratorHandler(mockService, zap.NewNop())
+
+	mockService.On("GeneratePassphrase", mock.MatchedBy(func(body dto.GeneratePassphraseDTO) bool {
+		return body.SaveAs != nil && body.SaveAs.UserId == 1 && body.Key == "testkey"
+	})).Return(&dto.GeneratedSecretDTO{Secret: "correct-horse-battery", Saved: true}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Passphrase(rec, newGeneratorRequest("/generator/passphrase", dto.GeneratePassphraseDTO{
+		Words:  5,
+		SaveAs: &dto.SaveGeneratedDTO{UserId: 1, AppName: "mail"},
+	}))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
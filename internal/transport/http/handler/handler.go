@@ -1,29 +1,158 @@
 package handler
 
-import "go.uber.org/zap"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"go.uber.org/zap"
+)
 
 type Handler struct {
-	User     UserHandler
-	LogoPass LogoPassHandler
-	Binary   BinaryHandler
-	Card     CardHandler
-	Note     NoteHandler
+	User             UserHandler
+	LogoPass         LogoPassHandler
+	Binary           BinaryHandler
+	Card             CardHandler
+	Note             NoteHandler
+	BankAccount      BankAccountHandler
+	IdentityDocument IdentityDocumentHandler
+	Generator        GeneratorHandler
+	Audit            AuditHandler
+	Reencryption     ReencryptionHandler
+	Sharing          SharingHandler
+	Org              OrgHandler
+	Emergency        EmergencyHandler
+	RecoveryShare    RecoveryShareHandler
+	Session          SessionHandler
 }
 
 type Service struct {
-	User     UserService
-	Card     CardService
-	Binary   BinaryService
-	LogoPass LogoPassService
-	Note     NoteService
+	User             UserService
+	Card             CardService
+	Binary           BinaryService
+	LogoPass         LogoPassService
+	Note             NoteService
+	BankAccount      BankAccountService
+	IdentityDocument IdentityDocumentService
+	Generator        GeneratorService
+	Audit            AuditService
+	Reencryption     ReencryptionService
+	Sharing          SharingService
+	Org              OrgService
+	Emergency        EmergencyService
+	RecoveryShare    RecoveryShareService
+	Session          SessionService
 }
 
 func New(serv Service, logger *zap.Logger) *Handler {
 	return &Handler{
-		User:     *NewUserHandler(serv.User, logger),
-		Binary:   *NewBinaryHandler(serv.Binary, logger),
-		Card:     *NewCardHandler(serv.Card, logger),
-		LogoPass: *NewLogoPassHandler(serv.LogoPass, logger),
-		Note:     *NewNoteHandler(serv.Note, logger),
+		User:             *NewUserHandler(serv.User, logger),
+		Binary:           *NewBinaryHandler(serv.Binary, logger),
+		Card:             *NewCardHandler(serv.Card, logger),
+		LogoPass:         *NewLogoPassHandler(serv.LogoPass, logger),
+		Note:             *NewNoteHandler(serv.Note, logger),
+		BankAccount:      *NewBankAccountHandler(serv.BankAccount, logger),
+		IdentityDocument: *NewIdentityDocumentHandler(serv.IdentityDocument, logger),
+		Generator:        *NewGeneratorHandler(serv.Generator, logger),
+		Audit:            *NewAuditHandler(serv.Audit, logger),
+		Reencryption:     *NewReencryptionHandler(serv.Reencryption, logger),
+		Sharing:          *NewSharingHandler(serv.Sharing, logger),
+		Org:              *NewOrgHandler(serv.Org, logger),
+		Emergency:        *NewEmergencyHandler(serv.Emergency, logger),
+		RecoveryShare:    *NewRecoveryShareHandler(serv.RecoveryShare, logger),
+		Session:          *NewSessionHandler(serv.Session, logger),
+	}
+}
+
+// validationErrors lists the errors caused by invalid user input; handlers answer them with 400.
+var validationErrors = []error{
+	apperrors.ErrInvalidIBAN,
+	apperrors.ErrInvalidBIC,
+	apperrors.ErrInvalidCountryCode,
+	apperrors.ErrInvalidDate,
+	apperrors.ErrInvalidDocumentType,
+	apperrors.ErrInvalidDocumentNumber,
+	apperrors.ErrInvalidCardNumber,
+	apperrors.ErrInvalidCVV,
+	apperrors.ErrCardExpired,
+	apperrors.ErrInvalidPasswordPolicy,
+	apperrors.ErrInvalidURI,
+}
+
+// isValidationError reports whether err is (or wraps) one of the validation errors.
+func isValidationError(err error) bool {
+	for _, target := range validationErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writePasswordPolicyError answers a request whose new master password does not meet the password
+// policy with 400 and the failed rules.
+//
+// Returns:
+//   - true if err is (or wraps) an *apperrors.PasswordPolicyError and the request was answered.
+func writePasswordPolicyError(rw http.ResponseWriter, err error) bool {
+	var policyErr *apperrors.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
 	}
+
+	response := dto.PasswordPolicyViolationDTO{Error: apperrors.ErrWeakPassword.Error()}
+	for _, failure := range policyErr.Failures {
+		response.FailedRules = append(response.FailedRules, dto.PasswordRuleDTO{Rule: failure.Rule, Message: failure.Message})
+	}
+
+	writeJSON(rw, http.StatusBadRequest, response)
+
+	return true
+}
+
+// authorizedUserID returns the ID of the authenticated user for a request creating an item in their
+// vault. requestedID is the user ID named by the request, 0 if none: it must be the authenticated
+// user's, so that nobody can write to another user's vault.
+//
+// Returns:
+//   - The user ID and true, or false after answering 401 without an authenticated user and 403 for
+//     another user's ID.
+func authorizedUserID(rw http.ResponseWriter, r *http.Request, requestedID int64) (int64, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	if requestedID != 0 && requestedID != userID {
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// VaultKeyHeader is the request header non-browser clients pass the vault key in, in place of
+// the "key" cookie.
+const VaultKeyHeader = "X-Vault-Key"
+
+// vaultKey returns the vault key of a request: the X-Vault-Key header if set, or else the value of
+// the "key" cookie.
+//
+// Returns:
+//   - http.ErrNoCookie if the request carries no vault key.
+func vaultKey(r *http.Request) (string, error) {
+	if key := r.Header.Get(VaultKeyHeader); key != "" {
+		return key, nil
+	}
+
+	cookie, err := r.Cookie("key")
+	if err != nil {
+		return "", err
+	}
+
+	return cookie.Value, nil
 }
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type IdentityDocumentHandler struct {
+	service IdentityDocumentService
+	log     *zap.Logger
+}
+
+type IdentityDocumentService interface {
+	Create(ctx context.Context, body dto.CreateIdentityDocumentDTO) error
+	Update(ctx context.Context, documentID int64, body dto.UpdateIdentityDocumentDTO) error
+	GetAll(ctx context.Context, userID int64, key string) ([]entities.IdentityDocument, error)
+}
+
+func NewIdentityDocumentHandler(service IdentityDocumentService, logger *zap.Logger) *IdentityDocumentHandler {
+	return &IdentityDocumentHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Создать документ, удостоверяющий личность
+// @Description Создает новый документ пользователя (паспорт, водительское удостоверение, ID-карта)
+// @Tags identity-document
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateIdentityDocumentDTO true "Данные для создания документа"
+// @Success 201 {string} string "Created"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /identity-document [post]
+// @Security BearerAuth
+func (i *IdentityDocumentHandler) Create(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	var body dto.CreateIdentityDocumentDTO
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	userID, ok := authorizedUserID(rw, r, int64(body.UserID))
+	if !ok {
+		return
+	}
+	body.UserID = int(userID)
+	body.Key = key
+
+	err = i.service.Create(ctx, body)
+	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		i.log.Sugar().Errorf("create identity document error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+}
+
+// @Summary Обновить документ
+// @Description Обновляет данные документа
+// @Tags identity-document
+// @Accept json
+// @Produce json
+// @Param documentID path int true "ID документа"
+// @Param body body dto.UpdateIdentityDocumentDTO true "Данные для обновления документа"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /identity-document/{documentID} [put]
+// @Security BearerAuth
+func (i *IdentityDocumentHandler) Update(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	var body dto.UpdateIdentityDocumentDTO
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.Key = key
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
+
+	documentID := chi.URLParam(r, "documentID")
+	intDocumentID, err := strconv.Atoi(documentID)
+	if err != nil {
+		http.Error(rw, "invalid identity document id", http.StatusBadRequest)
+		return
+	}
+
+	err = i.service.Update(ctx, int64(intDocumentID), body)
+	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		i.log.Sugar().Errorf("update identity document error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// @Summary Получить все документы пользователя
+// @Description Возвращает список всех документов пользователя
+// @Tags identity-document
+// @Accept json
+// @Produce json
+// @Param userID path int true "ID пользователя"
+// @Success 200 {array} entities.IdentityDocument "Список документов"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /identity-document/user/{userID} [get]
+// @Security BearerAuth
+func (i *IdentityDocumentHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID := chi.URLParam(r, "userID")
+	intUserID, err := strconv.Atoi(userID)
+	if err != nil {
+		http.Error(rw, "invalid user id ", http.StatusBadRequest)
+		return
+	}
+
+	items, err := i.service.GetAll(ctx, int64(intUserID), key)
+	if err != nil {
+		i.log.Sugar().Errorf("get all identity documents error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(items); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
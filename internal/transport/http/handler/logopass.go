@@ -3,12 +3,14 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
@@ -19,9 +21,11 @@ type LogoPassHandler struct {
 }
 
 type LogoPassService interface {
-	Create(ctx context.Context, body dto.CreateLogoPassDTO) error
-	Update(ctx context.Context, userID int64, body dto.UpdateLogoPassDTO) error
+	Create(ctx context.Context, body dto.CreateLogoPassDTO) (*dto.BreachCheckDTO, error)
+	Update(ctx context.Context, userID int64, body dto.UpdateLogoPassDTO) (*dto.BreachCheckDTO, error)
 	GetAll(ctx context.Context, userID int64, key string) ([]entities.LogoPassword, error)
+	CheckBreach(password string) (*dto.BreachCheckDTO, error)
+	Match(ctx context.Context, userID int64, key, rawURL string) ([]dto.LogoPassMatchDTO, error)
 }
 
 func NewLogoPassHandler(service LogoPassService, logger *zap.Logger) *LogoPassHandler {
@@ -32,20 +36,22 @@ func NewLogoPassHandler(service LogoPassService, logger *zap.Logger) *LogoPassHa
 }
 
 // @Summary Создать логин-пароль
-// @Description Создает новую запись логина и пароля пользователя
+// @Description Создает новую запись логина и пароля пользователя.
+// @Description В ответе указано, найден ли пароль в локальной базе утечек.
 // @Tags logopass
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer токен" default(Bearer {token})
 // @Param body body dto.CreateLogoPassDTO true "Данные для создания логина и пароля"
-// @Success 201 {string} string "Created"
+// @Success 201 {object} dto.BreachCheckDTO "Результат проверки пароля по базе утечек"
 // @Failure 400 {string} string "Bad Request"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /logo-pass [post]
 // @Security BearerAuth
 func (l *LogoPassHandler) Create(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -60,34 +66,46 @@ func (l *LogoPassHandler) Create(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body.Key = key.Value
+	userID, ok := authorizedUserID(rw, r, int64(body.UserId))
+	if !ok {
+		return
+	}
+	body.UserId = int(userID)
+	body.Key = key
 
-	err = l.service.Create(ctx, body)
+	breach, err := l.service.Create(ctx, body)
 	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
 		l.log.Sugar().Errorf("create logo pass error: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 
+	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusCreated)
+	json.NewEncoder(rw).Encode(breach)
 }
 
 // @Summary Обновить логин-пароль
-// @Description Обновляет существующую запись логина и пароля
+// @Description Обновляет существующую запись логина и пароля.
+// @Description В ответе указано, найден ли новый пароль в локальной базе утечек.
 // @Tags logopass
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer токен" default(Bearer {token})
 // @Param logoPassID path int true "ID логина-пароля"
 // @Param body body dto.UpdateLogoPassDTO true "Данные для обновления"
-// @Success 200 {string} string "OK"
+// @Success 200 {object} dto.BreachCheckDTO "Результат проверки пароля по базе утечек"
 // @Failure 400 {string} string "Bad Request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /logo-pass/{logoPassID} [put]
 // @Security BearerAuth
 func (l *LogoPassHandler) Update(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -109,16 +127,68 @@ func (l *LogoPassHandler) Update(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body.Key = key.Value
+	body.Key = key
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
 
-	err = l.service.Update(ctx, int64(intLogoPassID), body)
+	breach, err := l.service.Update(ctx, int64(intLogoPassID), body)
 	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
 		l.log.Sugar().Errorf("update logo pass error: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
 		return
 	}
 
+	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(breach)
+}
+
+// @Summary Проверить пароль по базе утечек
+// @Description Проверяет пароль по локальной базе утекших паролей (формат HIBP).
+// @Description Пароль не передается во внешние сервисы.
+// @Tags logopass
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer {token})
+// @Param body body dto.CheckPasswordDTO true "Пароль для проверки"
+// @Success 200 {object} dto.BreachCheckDTO "Результат проверки"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Failure 503 {string} string "Service Unavailable"
+// @Router /logo-pass/check-breach [post]
+// @Security BearerAuth
+func (l *LogoPassHandler) CheckBreach(rw http.ResponseWriter, r *http.Request) {
+	var body dto.CheckPasswordDTO
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	breach, err := l.service.CheckBreach(body.Password)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrBreachCheckUnavailable) {
+			http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		l.log.Sugar().Errorf("check breached password error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(breach)
 }
 
 // @Summary Получить все логин-пароли пользователя
@@ -135,7 +205,7 @@ func (l *LogoPassHandler) Update(rw http.ResponseWriter, r *http.Request) {
 // @Router /logo-pass/user/{userID} [get]
 // @Security BearerAuth
 func (l *LogoPassHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -150,7 +220,7 @@ func (l *LogoPassHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	items, err := l.service.GetAll(ctx, int64(intUserID), key.Value)
+	items, err := l.service.GetAll(ctx, int64(intUserID), key)
 	if err != nil {
 		l.log.Sugar().Errorf("get all logo pass error: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
@@ -161,3 +231,55 @@ func (l *LogoPassHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
 	rw.WriteHeader(http.StatusOK)
 	json.NewEncoder(rw).Encode(items)
 }
+
+// @Summary Найти логины-пароли для сайта
+// @Description Возвращает записи логинов и паролей, адреса которых совпадают с переданным URL.
+// @Description Режимы сравнения: exact (полный адрес), host (хост и порт), domain (базовый домен
+// @Description по списку публичных суффиксов), regex. Результаты упорядочены от наиболее точного совпадения.
+// @Tags logopass
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен" default(Bearer {token})
+// @Param userID path int true "ID пользователя"
+// @Param url query string true "URL сайта"
+// @Success 200 {array} dto.LogoPassMatchDTO "Подходящие логины и пароли"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /logo-pass/user/{userID}/match [get]
+// @Security BearerAuth
+func (l *LogoPassHandler) Match(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	intUserID, err := strconv.Atoi(userID)
+	if err != nil {
+		http.Error(rw, "invalid user id ", http.StatusBadRequest)
+		return
+	}
+
+	rawURL := r.URL.Query().Get("url")
+	if rawURL == "" {
+		http.Error(rw, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	matches, err := l.service.Match(r.Context(), int64(intUserID), key, rawURL)
+	if err != nil {
+		if isValidationError(err) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l.log.Sugar().Errorf("match logo pass error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(matches)
+}
@@ -9,8 +9,10 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -22,14 +24,28 @@ type MockLogoPassService struct {
 	mock.Mock
 }
 
-func (m *MockLogoPassService) Create(ctx context.Context, body dto.CreateLogoPassDTO) error {
+func (m *MockLogoPassService) Create(ctx context.Context, body dto.CreateLogoPassDTO) (*dto.BreachCheckDTO, error) {
 	args := m.Called(body)
-	return args.Error(0)
+	if result, ok := args.Get(0).(*dto.BreachCheckDTO); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
-func (m *MockLogoPassService) Update(ctx context.Context, userID int64, body dto.UpdateLogoPassDTO) error {
+func (m *MockLogoPassService) Update(ctx context.Context, userID int64, body dto.UpdateLogoPassDTO) (*dto.BreachCheckDTO, error) {
 	args := m.Called(userID, body)
-	return args.Error(0)
+	if result, ok := args.Get(0).(*dto.BreachCheckDTO); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockLogoPassService) CheckBreach(password string) (*dto.BreachCheckDTO, error) {
+	args := m.Called(password)
+	if result, ok := args.Get(0).(*dto.BreachCheckDTO); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
 func (m *MockLogoPassService) GetAll(ctx context.Context, userID int64, key string) ([]entities.LogoPassword, error) {
@@ -37,6 +53,11 @@ func (m *MockLogoPassService) GetAll(ctx context.Context, userID int64, key stri
 	return args.Get(0).([]entities.LogoPassword), args.Error(1)
 }
 
+func (m *MockLogoPassService) Match(ctx context.Context, userID int64, key, rawURL string) ([]dto.LogoPassMatchDTO, error) {
+	args := m.Called(userID, key, rawURL)
+	return args.Get(0).([]dto.LogoPassMatchDTO), args.Error(1)
+}
+
 func setupTestHandler() (*LogoPassHandler, *MockLogoPassService) {
 	mockService := new(MockLogoPassService)
 	logger := zap.NewNop()
@@ -46,7 +67,7 @@ func setupTestHandler() (*LogoPassHandler, *MockLogoPassService) {
 
 func TestCreate_Success(t *testing.T) {
 	handler, mockService := setupTestHandler()
-	mockService.On("Create", mock.Anything).Return(nil)
+	mockService.On("Create", mock.Anything).Return(&dto.BreachCheckDTO{Checked: true, Breached: true, Occurrences: 3}, nil)
 
 	body := dto.CreateLogoPassDTO{
 		Username: "testuser",
@@ -56,11 +77,17 @@ func TestCreate_Success(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/logo-pass", bytes.NewBuffer(bodyBytes))
 	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
 
 	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var breach dto.BreachCheckDTO
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&breach))
+	assert.True(t, breach.Breached)
+	assert.Equal(t, uint32(3), breach.Occurrences)
 	mockService.AssertExpectations(t)
 }
 
@@ -90,13 +117,14 @@ func TestCreate_InvalidJSON(t *testing.T) {
 
 func TestCreate_ServiceError(t *testing.T) {
 	handler, mockService := setupTestHandler()
-	mockService.On("Create", mock.Anything).Return(errors.New("internal error"))
+	mockService.On("Create", mock.Anything).Return(nil, errors.New("internal error"))
 
 	body := dto.CreateLogoPassDTO{Username: "testuser", Password: "testpass"}
 	bodyBytes, _ := json.Marshal(body)
 
 	req := httptest.NewRequest("POST", "/logo-pass", bytes.NewBuffer(bodyBytes))
 	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
 	rec := httptest.NewRecorder()
 
 	handler.Create(rec, req)
@@ -107,7 +135,9 @@ func TestCreate_ServiceError(t *testing.T) {
 
 func TestUpdate_Success(t *testing.T) {
 	handler, mockService := setupTestHandler()
-	mockService.On("Update", int64(1), mock.Anything).Return(nil)
+	mockService.On("Update", int64(1), mock.MatchedBy(func(body dto.UpdateLogoPassDTO) bool {
+		return body.UserID == 2
+	})).Return(&dto.BreachCheckDTO{}, nil)
 
 	body := dto.UpdateLogoPassDTO{Password: "newpass"}
 	bodyBytes, _ := json.Marshal(body)
@@ -117,7 +147,8 @@ func TestUpdate_Success(t *testing.T) {
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("logoPassID", "1")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(context.WithValue(ctx, middleware.UserIDContextKey, int64(2)))
 
 	rec := httptest.NewRecorder()
 	handler.Update(rec, req)
@@ -169,3 +200,106 @@ func TestGetAll_InvalidUserID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestCheckBreach(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     *dto.BreachCheckDTO
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "breached",
+			result:     &dto.BreachCheckDTO{Checked: true, Breached: true, Occurrences: 9545824},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "not configured",
+			err:        apperrors.ErrBreachCheckUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "lookup error",
+			err:        errors.New("read error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockService := setupTestHandler()
+			mockService.On("CheckBreach", "password").Return(tt.result, tt.err)
+
+			bodyBytes, _ := json.Marshal(dto.CheckPasswordDTO{Password: "password"})
+			req := httptest.NewRequest("POST", "/logo-pass/check-breach", bytes.NewBuffer(bodyBytes))
+			rec := httptest.NewRecorder()
+
+			handler.CheckBreach(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.result != nil {
+				var got dto.BreachCheckDTO
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				assert.Equal(t, *tt.result, got)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		matches    []dto.LogoPassMatchDTO
+		err        error
+		wantStatus int
+	}{
+		{
+			name:  "success",
+			query: "?url=https%3A%2F%2Fgithub.com%2Flogin",
+			matches: []dto.LogoPassMatchDTO{
+				{LogoPassword: entities.LogoPassword{ID: 1, AppName: "github"}, MatchedURI: "github.com", Match: "host", Score: 3},
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing url",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid url",
+			query:      "?url=%3A%2F%2F",
+			matches:    []dto.LogoPassMatchDTO(nil),
+			err:        apperrors.ErrInvalidURI,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockService := setupTestHandler()
+			if tt.matches != nil || tt.err != nil {
+				mockService.On("Match", int64(1), "testkey", mock.Anything).Return(tt.matches, tt.err)
+			}
+
+			req := httptest.NewRequest("GET", "/logo-pass/user/1/match"+tt.query, nil)
+			req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("userID", "1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+
+			handler.Match(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				var got []dto.LogoPassMatchDTO
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+				assert.Equal(t, tt.matches, got)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
@@ -41,11 +42,12 @@ func NewNoteHandler(service NoteService, log *zap.Logger) *NoteHandler {
 // @Success 201 {string} string "Created"
 // @Failure 400 {string} string "Bad Request"
 // @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /note [post]
 // @Security BearerAuth
 func (n *NoteHandler) Create(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -58,7 +60,12 @@ func (n *NoteHandler) Create(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body.Key = key.Value
+	userID, ok := authorizedUserID(rw, r, int64(body.UserID))
+	if !ok {
+		return
+	}
+	body.UserID = int(userID)
+	body.Key = key
 
 	err = n.service.Create(r.Context(), body)
 	if err != nil {
@@ -92,7 +99,7 @@ func (n *NoteHandler) Update(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -105,7 +112,14 @@ func (n *NoteHandler) Update(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body.Key = key.Value
+	body.Key = key
+
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
 
 	err = n.service.Update(r.Context(), intNoteID, body)
 	if err != nil {
@@ -131,7 +145,7 @@ func (n *NoteHandler) Update(rw http.ResponseWriter, r *http.Request) {
 // @Router /note/user/{userID} [get]
 // @Security BearerAuth
 func (n *NoteHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
-	key, err := r.Cookie("key")
+	key, err := vaultKey(r)
 	if err != nil {
 		http.Error(rw, "key not found", http.StatusBadRequest)
 		return
@@ -146,7 +160,7 @@ func (n *NoteHandler) GetAll(rw http.ResponseWriter, r *http.Request) {
 
 	n.log.Info("url valid")
 
-	items, err := n.service.GetAll(r.Context(), intUserID, key.Value)
+	items, err := n.service.GetAll(r.Context(), intUserID, key)
 	if err != nil {
 		n.log.Sugar().Errorf("get all notes error: %v", err)
 		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
@@ -12,6 +12,7 @@ import (
 	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
 	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -46,6 +47,7 @@ func TestNoteHandler_Create_Success(t *testing.T) {
 	handler := NewNoteHandler(mockService, logger)
 
 	noteData := dto.CreateNoteDTO{
+		UserID:   1,
 		Title:    "Test Note",
 		TextData: "This is a test note",
 		Key:      "test-key",
@@ -56,6 +58,7 @@ func TestNoteHandler_Create_Success(t *testing.T) {
 	body, _ := json.Marshal(noteData)
 	req := httptest.NewRequest(http.MethodPost, "/note", bytes.NewReader(body))
 	req.AddCookie(&http.Cookie{Name: "key", Value: "test-key"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -64,6 +67,41 @@ func TestNoteHandler_Create_Success(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, rec.Code)
 }
 
+func TestNoteHandler_Create_OtherUser(t *testing.T) {
+	mockService := new(MockNoteService)
+	handler := NewNoteHandler(mockService, zap.NewNop())
+
+	body, _ := json.Marshal(dto.CreateNoteDTO{UserID: 2, Title: "Test Note"})
+	req := httptest.NewRequest(http.MethodPost, "/note", bytes.NewReader(body))
+	req.AddCookie(&http.Cookie{Name: "key", Value: "test-key"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
+	rec := httptest.NewRecorder()
+
+	handler.Create(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestNoteHandler_Create_KeyHeader(t *testing.T) {
+	mockService := new(MockNoteService)
+	handler := NewNoteHandler(mockService, zap.NewNop())
+
+	mockService.On("Create", dto.CreateNoteDTO{UserID: 1, Title: "Test Note", Key: "header-key"}).Return(nil)
+
+	body, _ := json.Marshal(dto.CreateNoteDTO{Title: "Test Note"})
+	req := httptest.NewRequest(http.MethodPost, "/note", bytes.NewReader(body))
+	req.Header.Set(VaultKeyHeader, "header-key")
+	req.AddCookie(&http.Cookie{Name: "key", Value: "cookie-key"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
+	rec := httptest.NewRecorder()
+
+	handler.Create(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
 func TestNoteHandler_Create_MissingKeyCookie(t *testing.T) {
 	mockService := new(MockNoteService)
 	logger := zap.NewNop()
@@ -109,6 +147,7 @@ func TestNoteHandler_Create_InternalServerError(t *testing.T) {
 	handler := NewNoteHandler(mockService, logger)
 
 	noteData := dto.CreateNoteDTO{
+		UserID:   1,
 		Title:    "Test Note",
 		TextData: "This is a test note",
 		Key:      "test-key",
@@ -119,6 +158,7 @@ func TestNoteHandler_Create_InternalServerError(t *testing.T) {
 	body, _ := json.Marshal(noteData)
 	req := httptest.NewRequest(http.MethodPost, "/note", bytes.NewReader(body))
 	req.AddCookie(&http.Cookie{Name: "key", Value: "test-key"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -139,7 +179,9 @@ func TestNoteHandler_Update_Success(t *testing.T) {
 		Key:      "test-key",
 	}
 
-	mockService.On("Update", 1, updateData).Return(nil)
+	expected := updateData
+	expected.UserID = 2
+	mockService.On("Update", 1, expected).Return(nil)
 
 	body, _ := json.Marshal(updateData)
 	req := httptest.NewRequest(http.MethodPut, "/note/1", bytes.NewReader(body))
@@ -149,12 +191,33 @@ func TestNoteHandler_Update_Success(t *testing.T) {
 	// Chi router нужен для парсинга параметров
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("noteID", "1")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(context.WithValue(ctx, middleware.UserIDContextKey, int64(2)))
 	rec := httptest.NewRecorder()
 
 	handler.Update(rec, req)
 
 	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestNoteHandler_Update_Unauthorized(t *testing.T) {
+	mockService := new(MockNoteService)
+	handler := NewNoteHandler(mockService, zap.NewNop())
+
+	body, _ := json.Marshal(dto.UpdateNoteDTO{Title: "Updated Title"})
+	req := httptest.NewRequest(http.MethodPut, "/note/1", bytes.NewReader(body))
+	req.AddCookie(&http.Cookie{Name: "key", Value: "test-key"})
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("noteID", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+
+	handler.Update(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	mockService.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 }
 
 func TestNoteHandler_Update_InvalidNoteID(t *testing.T) {
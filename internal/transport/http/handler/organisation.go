@@ -0,0 +1,651 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type OrgHandler struct {
+	service OrgService
+	log     *zap.Logger
+}
+
+type OrgService interface {
+	Create(ctx context.Context, body dto.CreateOrgDTO) (*entities.Organisation, error)
+	List(ctx context.Context, userID int64) ([]entities.Organisation, error)
+	ListMembers(ctx context.Context, orgID, actorID int64) ([]entities.OrgMember, error)
+	AddMember(ctx context.Context, body dto.AddOrgMemberDTO) (*entities.OrgMember, error)
+	UpdateMember(ctx context.Context, body dto.UpdateOrgMemberDTO) error
+	RemoveMember(ctx context.Context, body dto.RemoveOrgMemberDTO) error
+	CreateCollection(ctx context.Context, body dto.CreateCollectionDTO) (*entities.Collection, error)
+	ListCollections(ctx context.Context, orgID, actorID int64) ([]entities.Collection, error)
+	DeleteCollection(ctx context.Context, collectionID, actorID int64) error
+	AddItem(ctx context.Context, body dto.AddCollectionItemDTO) (*entities.CollectionItem, error)
+	RemoveItem(ctx context.Context, collectionID, actorID int64, itemType string, itemID int64) error
+	ListItems(ctx context.Context, collectionID, actorID int64, key string) ([]dto.CollectionItemDTO, error)
+	UpdateItem(ctx context.Context, body dto.UpdateCollectionItemDTO) error
+}
+
+func NewOrgHandler(service OrgService, logger *zap.Logger) *OrgHandler {
+	return &OrgHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Создать организацию
+// @Description Создает организацию, владельцем которой становится текущий пользователь. Ключ организации запечатывается открытым ключом владельца
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateOrgDTO true "Название организации"
+// @Success 201 {object} entities.Organisation "Организация"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs [post]
+// @Security BearerAuth
+func (o *OrgHandler) Create(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body dto.CreateOrgDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.UserID = userID
+
+	org, err := o.service.Create(ctx, body)
+	if err != nil {
+		o.writeError(rw, "create organisation error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusCreated, org)
+}
+
+// @Summary Получить организации
+// @Description Возвращает организации, в которых состоит текущий пользователь, с его ролью
+// @Tags organisations
+// @Produce json
+// @Success 200 {array} entities.Organisation "Список организаций"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs [get]
+// @Security BearerAuth
+func (o *OrgHandler) List(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgs, err := o.service.List(ctx, userID)
+	if err != nil {
+		o.writeError(rw, "list organisations error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, orgs)
+}
+
+// @Summary Получить участников организации
+// @Description Возвращает участников организации с их ролями
+// @Tags organisations
+// @Produce json
+// @Param orgID path int true "ID организации"
+// @Success 200 {array} entities.OrgMember "Список участников"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs/{orgID}/members [get]
+// @Security BearerAuth
+func (o *OrgHandler) ListMembers(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "orgID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid organisation id", http.StatusBadRequest)
+		return
+	}
+
+	members, err := o.service.ListMembers(ctx, orgID, userID)
+	if err != nil {
+		o.writeError(rw, "list organisation members error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, members)
+}
+
+// @Summary Добавить участника организации
+// @Description Добавляет пользователя в организацию с ролью owner, admin, member (по умолчанию) или read-only. Доступно администраторам; добавить владельца может только владелец
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param orgID path int true "ID организации"
+// @Param body body dto.AddOrgMemberDTO true "Имя пользователя и роль"
+// @Success 201 {object} entities.OrgMember "Участник"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs/{orgID}/members [post]
+// @Security BearerAuth
+func (o *OrgHandler) AddMember(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "orgID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid organisation id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.AddOrgMemberDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.OrgID = orgID
+	body.ActorID = userID
+	body.Key = key
+
+	member, err := o.service.AddMember(ctx, body)
+	if err != nil {
+		o.writeError(rw, "add organisation member error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusCreated, member)
+}
+
+// @Summary Изменить роль участника
+// @Description Изменяет роль участника организации. Доступно администраторам; роль владельца назначает и снимает только владелец
+// @Tags organisations
+// @Accept json
+// @Param orgID path int true "ID организации"
+// @Param userID path int true "ID участника"
+// @Param body body dto.UpdateOrgMemberDTO true "Новая роль"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs/{orgID}/members/{userID} [put]
+// @Security BearerAuth
+func (o *OrgHandler) UpdateMember(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actorID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "orgID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid organisation id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.UpdateOrgMemberDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.OrgID = orgID
+	body.ActorID = actorID
+	body.UserID = userID
+
+	if err := o.service.UpdateMember(ctx, body); err != nil {
+		o.writeError(rw, "update organisation member error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// @Summary Удалить участника организации
+// @Description Удаляет участника из организации вместе с его записями в коллекциях; участник может выйти из организации сам. Ключ организации меняется
+// @Tags organisations
+// @Param orgID path int true "ID организации"
+// @Param userID path int true "ID участника"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs/{orgID}/members/{userID} [delete]
+// @Security BearerAuth
+func (o *OrgHandler) RemoveMember(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	actorID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "orgID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid organisation id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	body := dto.RemoveOrgMemberDTO{OrgID: orgID, ActorID: actorID, UserID: userID, Key: key}
+	if err := o.service.RemoveMember(ctx, body); err != nil {
+		o.writeError(rw, "remove organisation member error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Создать коллекцию
+// @Description Создает коллекцию организации. Доступно администраторам
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param orgID path int true "ID организации"
+// @Param body body dto.CreateCollectionDTO true "Название коллекции"
+// @Success 201 {object} entities.Collection "Коллекция"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs/{orgID}/collections [post]
+// @Security BearerAuth
+func (o *OrgHandler) CreateCollection(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "orgID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid organisation id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.CreateCollectionDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.OrgID = orgID
+	body.ActorID = userID
+
+	collection, err := o.service.CreateCollection(ctx, body)
+	if err != nil {
+		o.writeError(rw, "create collection error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusCreated, collection)
+}
+
+// @Summary Получить коллекции организации
+// @Description Возвращает коллекции организации
+// @Tags organisations
+// @Produce json
+// @Param orgID path int true "ID организации"
+// @Success 200 {array} entities.Collection "Список коллекций"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /orgs/{orgID}/collections [get]
+// @Security BearerAuth
+func (o *OrgHandler) ListCollections(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "orgID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid organisation id", http.StatusBadRequest)
+		return
+	}
+
+	collections, err := o.service.ListCollections(ctx, orgID, userID)
+	if err != nil {
+		o.writeError(rw, "list collections error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, collections)
+}
+
+// @Summary Удалить коллекцию
+// @Description Удаляет коллекцию. Записи остаются в хранилищах их владельцев. Доступно администраторам
+// @Tags organisations
+// @Param collectionID path int true "ID коллекции"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /collections/{collectionID} [delete]
+// @Security BearerAuth
+func (o *OrgHandler) DeleteCollection(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	if err := o.service.DeleteCollection(ctx, collectionID, userID); err != nil {
+		o.writeError(rw, "delete collection error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Добавить запись в коллекцию
+// @Description Добавляет запись текущего пользователя (logopass, card, note, binary, bank_account, identity_document) в коллекцию. Недоступно участникам с ролью read-only
+// @Tags organisations
+// @Accept json
+// @Produce json
+// @Param collectionID path int true "ID коллекции"
+// @Param body body dto.AddCollectionItemDTO true "Запись"
+// @Success 201 {object} entities.CollectionItem "Запись коллекции"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /collections/{collectionID}/items [post]
+// @Security BearerAuth
+func (o *OrgHandler) AddItem(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.AddCollectionItemDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.CollectionID = collectionID
+	body.ActorID = userID
+	body.Key = key
+
+	item, err := o.service.AddItem(ctx, body)
+	if err != nil {
+		o.writeError(rw, "add collection item error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusCreated, item)
+}
+
+// @Summary Получить записи коллекции
+// @Description Возвращает расшифрованные записи коллекции. Бинарные поля кодируются в base64
+// @Tags organisations
+// @Produce json
+// @Param collectionID path int true "ID коллекции"
+// @Success 200 {array} dto.CollectionItemDTO "Список записей"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /collections/{collectionID}/items [get]
+// @Security BearerAuth
+func (o *OrgHandler) ListItems(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	items, err := o.service.ListItems(ctx, collectionID, userID, key)
+	if err != nil {
+		o.writeError(rw, "list collection items error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, items)
+}
+
+// @Summary Изменить запись коллекции
+// @Description Изменяет зашифрованные поля записи коллекции. Недоступно участникам с ролью read-only
+// @Tags organisations
+// @Accept json
+// @Param collectionID path int true "ID коллекции"
+// @Param itemType path string true "Тип записи"
+// @Param itemID path int true "ID записи"
+// @Param body body dto.UpdateCollectionItemDTO true "Новые значения полей"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /collections/{collectionID}/items/{itemType}/{itemID} [put]
+// @Security BearerAuth
+func (o *OrgHandler) UpdateItem(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.UpdateCollectionItemDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.CollectionID = collectionID
+	body.ActorID = userID
+	body.Key = key
+	body.ItemType = chi.URLParam(r, "itemType")
+	body.ItemID = itemID
+
+	if err := o.service.UpdateItem(ctx, body); err != nil {
+		o.writeError(rw, "update collection item error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// @Summary Удалить запись из коллекции
+// @Description Удаляет запись из коллекции; запись остается в хранилище владельца. Доступно владельцу записи и администраторам
+// @Tags organisations
+// @Param collectionID path int true "ID коллекции"
+// @Param itemType path string true "Тип записи"
+// @Param itemID path int true "ID записи"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /collections/{collectionID}/items/{itemType}/{itemID} [delete]
+// @Security BearerAuth
+func (o *OrgHandler) RemoveItem(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := strconv.ParseInt(chi.URLParam(r, "collectionID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid collection id", http.StatusBadRequest)
+		return
+	}
+
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	if err := o.service.RemoveItem(ctx, collectionID, userID, chi.URLParam(r, "itemType"), itemID); err != nil {
+		o.writeError(rw, "remove collection item error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// writeError answers an organisation error with its status code, logging unexpected errors.
+func (o *OrgHandler) writeError(rw http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidOrg):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrOrgForbidden):
+		http.Error(rw, err.Error(), http.StatusForbidden)
+	case errors.Is(err, apperrors.ErrOrgNotFound),
+		errors.Is(err, apperrors.ErrOrgMemberNotFound),
+		errors.Is(err, apperrors.ErrCollectionNotFound),
+		errors.Is(err, apperrors.ErrCollectionItemNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrOrgMemberExists),
+		errors.Is(err, apperrors.ErrLastOrgOwner),
+		errors.Is(err, apperrors.ErrOrgKeyChanged),
+		errors.Is(err, apperrors.ErrItemInCollection),
+		errors.Is(err, apperrors.ErrKeyPairNotFound),
+		errors.Is(err, apperrors.ErrKeyDerivation):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	default:
+		o.log.Sugar().Errorf("%s: %v", msg, err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+	}
+}
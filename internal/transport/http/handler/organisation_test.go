@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockOrgService struct {
+	mock.Mock
+}
+
+func (m *MockOrgService) Create(ctx context.Context, body dto.CreateOrgDTO) (*entities.Organisation, error) {
+	args := m.Called(body)
+	if org, ok := args.Get(0).(*entities.Organisation); ok {
+		return org, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgService) List(ctx context.Context, userID int64) ([]entities.Organisation, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entities.Organisation), args.Error(1)
+}
+
+func (m *MockOrgService) ListMembers(ctx context.Context, orgID, actorID int64) ([]entities.OrgMember, error) {
+	args := m.Called(orgID, actorID)
+	return args.Get(0).([]entities.OrgMember), args.Error(1)
+}
+
+func (m *MockOrgService) AddMember(ctx context.Context, body dto.AddOrgMemberDTO) (*entities.OrgMember, error) {
+	args := m.Called(body)
+	if member, ok := args.Get(0).(*entities.OrgMember); ok {
+		return member, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgService) UpdateMember(ctx context.Context, body dto.UpdateOrgMemberDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockOrgService) RemoveMember(ctx context.Context, body dto.RemoveOrgMemberDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockOrgService) CreateCollection(ctx context.Context, body dto.CreateCollectionDTO) (*entities.Collection, error) {
+	args := m.Called(body)
+	if collection, ok := args.Get(0).(*entities.Collection); ok {
+		return collection, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgService) ListCollections(ctx context.Context, orgID, actorID int64) ([]entities.Collection, error) {
+	args := m.Called(orgID, actorID)
+	return args.Get(0).([]entities.Collection), args.Error(1)
+}
+
+func (m *MockOrgService) DeleteCollection(ctx context.Context, collectionID, actorID int64) error {
+	args := m.Called(collectionID, actorID)
+	return args.Error(0)
+}
+
+func (m *MockOrgService) AddItem(ctx context.Context, body dto.AddCollectionItemDTO) (*entities.CollectionItem, error) {
+	args := m.Called(body)
+	if item, ok := args.Get(0).(*entities.CollectionItem); ok {
+		return item, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrgService) RemoveItem(ctx context.Context, collectionID, actorID int64, itemType string, itemID int64) error {
+	args := m.Called(collectionID, actorID, itemType, itemID)
+	return args.Error(0)
+}
+
+func (m *MockOrgService) ListItems(ctx context.Context, collectionID, actorID int64, key string) ([]dto.CollectionItemDTO, error) {
+	args := m.Called(collectionID, actorID, key)
+	return args.Get(0).([]dto.CollectionItemDTO), args.Error(1)
+}
+
+func (m *MockOrgService) UpdateItem(ctx context.Context, body dto.UpdateCollectionItemDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+// newOrgRequest builds an authenticated request of user 1 with the vault key cookie and URL parameters.
+func newOrgRequest(method, target, body string, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	rctx := chi.NewRouteContext()
+	for name, value := range params {
+		rctx.URLParams.Add(name, value)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	return req.WithContext(context.WithValue(ctx, middleware.UserIDContextKey, int64(1)))
+}
+
+func TestOrgCreate(t *testing.T) {
+	mockService := new(MockOrgService)
+	handler := NewOrgHandler(mockService, zap.NewNop())
+
+	mockService.On("Create", dto.CreateOrgDTO{UserID: 1, Name: "Team"}).
+		Return(&entities.Organisation{ID: 3, Name: "Team", Role: "owner"}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Create(rec, newOrgRequest("POST", "/api/orgs", `{"name":"Team"}`, nil))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"role":"owner"`)
+
+	rec = httptest.NewRecorder()
+	handler.Create(rec, httptest.NewRequest("POST", "/api/orgs", strings.NewReader(`{"name":"Team"}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestOrgAddMember(t *testing.T) {
+	mockService := new(MockOrgService)
+	handler := NewOrgHandler(mockService, zap.NewNop())
+
+	mockService.On("AddMember", dto.AddOrgMemberDTO{OrgID: 3, ActorID: 1, Key: "testkey", Username: "bob", Role: "admin"}).
+		Return(&entities.OrgMember{OrgID: 3, UserID: 2, Username: "bob", Role: "admin", WrappedKey: "s1.secret"}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.AddMember(rec, newOrgRequest("POST", "/api/orgs/3/members", `{"username":"bob","role":"admin"}`, map[string]string{"orgID": "3"}))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "s1.secret", "The sealed organisation key should not be returned")
+	mockService.AssertExpectations(t)
+}
+
+func TestOrgAddMember_Errors(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{apperrors.ErrInvalidOrg, http.StatusBadRequest},
+		{apperrors.ErrOrgForbidden, http.StatusForbidden},
+		{apperrors.ErrOrgNotFound, http.StatusNotFound},
+		{apperrors.ErrOrgMemberNotFound, http.StatusNotFound},
+		{apperrors.ErrOrgMemberExists, http.StatusConflict},
+		{apperrors.ErrOrgKeyChanged, http.StatusConflict},
+		{assert.AnError, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			mockService := new(MockOrgService)
+			handler := NewOrgHandler(mockService, zap.NewNop())
+			mockService.On("AddMember", mock.Anything).Return(nil, tc.err)
+
+			rec := httptest.NewRecorder()
+			handler.AddMember(rec, newOrgRequest("POST", "/api/orgs/3/members", `{"username":"bob"}`, map[string]string{"orgID": "3"}))
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestOrgRemoveMember(t *testing.T) {
+	mockService := new(MockOrgService)
+	handler := NewOrgHandler(mockService, zap.NewNop())
+
+	mockService.On("RemoveMember", dto.RemoveOrgMemberDTO{OrgID: 3, ActorID: 1, UserID: 2, Key: "testkey"}).Return(nil)
+
+	rec := httptest.NewRecorder()
+	handler.RemoveMember(rec, newOrgRequest("DELETE", "/api/orgs/3/members/2", "", map[string]string{"orgID": "3", "userID": "2"}))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.RemoveMember(rec, newOrgRequest("DELETE", "/api/orgs/3/members/x", "", map[string]string{"orgID": "3", "userID": "x"}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestOrgListItems(t *testing.T) {
+	mockService := new(MockOrgService)
+	handler := NewOrgHandler(mockService, zap.NewNop())
+
+	mockService.On("ListItems", int64(7), int64(1), "testkey").Return([]dto.CollectionItemDTO{{
+		CollectionItem: entities.CollectionItem{CollectionID: 7, ItemType: "logopass", ItemID: 5, OwnerUsername: "alice"},
+		Fields:         map[string]string{"app_name": "mail", "password": "secret"},
+	}}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.ListItems(rec, newOrgRequest("GET", "/api/collections/7/items", "", map[string]string{"collectionID": "7"}))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"owner":"alice"`)
+	assert.Contains(t, rec.Body.String(), `"password":"secret"`)
+}
+
+func TestOrgUpdateItem(t *testing.T) {
+	mockService := new(MockOrgService)
+	handler := NewOrgHandler(mockService, zap.NewNop())
+
+	mockService.On("UpdateItem", dto.UpdateCollectionItemDTO{
+		CollectionID: 7,
+		ActorID:      1,
+		Key:          "testkey",
+		ItemType:     "note",
+		ItemID:       5,
+		Fields:       map[string]string{"text_data": "new"},
+	}).Return(nil)
+	mockService.On("UpdateItem", mock.MatchedBy(func(body dto.UpdateCollectionItemDTO) bool { return body.ItemID == 6 })).
+		Return(apperrors.ErrOrgForbidden)
+
+	params := map[string]string{"collectionID": "7", "itemType": "note", "itemID": "5"}
+	rec := httptest.NewRecorder()
+	handler.UpdateItem(rec, newOrgRequest("PUT", "/api/collections/7/items/note/5", `{"fields":{"text_data":"new"}}`, params))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	params["itemID"] = "6"
+	rec = httptest.NewRecorder()
+	handler.UpdateItem(rec, newOrgRequest("PUT", "/api/collections/7/items/note/6", `{"fields":{"text_data":"new"}}`, params))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
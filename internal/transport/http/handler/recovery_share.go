@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type RecoveryShareHandler struct {
+	service RecoveryShareService
+	log     *zap.Logger
+}
+
+type RecoveryShareService interface {
+	Create(ctx context.Context, body dto.CreateRecoverySharesDTO) ([]entities.RecoveryShare, error)
+	List(ctx context.Context, ownerID int64) ([]entities.RecoveryShare, error)
+	ListHeld(ctx context.Context, officerID int64) ([]entities.RecoveryShare, error)
+	StartSession(ctx context.Context, username string) (*dto.RecoverySessionDTO, error)
+	Submit(ctx context.Context, body dto.SubmitRecoveryShareDTO) (*entities.RecoverySession, error)
+	Complete(ctx context.Context, body dto.CompleteRecoveryDTO) (*dto.GeneratedJwt, error)
+}
+
+func NewRecoveryShareHandler(service RecoveryShareService, logger *zap.Logger) *RecoveryShareHandler {
+	return &RecoveryShareHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Разделить ключ восстановления
+// @Description Заменяет ключ восстановления текущего пользователя новым, разделенным между доверенными лицами по схеме Шамира: любые threshold из них могут восстановить доступ.
+// @Description Новый ключ восстановления не возвращается; доля каждого доверенного лица запечатывается его открытым ключом. Прежний ключ восстановления перестает работать
+// @Tags recovery
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateRecoverySharesDTO true "Порог и имена доверенных лиц"
+// @Success 201 {array} entities.RecoveryShare "Доли ключа восстановления"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /recovery/shares [post]
+// @Security BearerAuth
+func (h *RecoveryShareHandler) Create(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body dto.CreateRecoverySharesDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.UserID = userID
+	body.Key = key
+
+	shares, err := h.service.Create(ctx, body)
+	if err != nil {
+		h.writeError(rw, "create recovery shares error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusCreated, shares)
+}
+
+// @Summary Получить доли своего ключа восстановления
+// @Description Возвращает доли ключа восстановления текущего пользователя и их доверенных лиц
+// @Tags recovery
+// @Produce json
+// @Success 200 {array} entities.RecoveryShare "Доли ключа восстановления"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /recovery/shares [get]
+// @Security BearerAuth
+func (h *RecoveryShareHandler) List(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shares, err := h.service.List(ctx, userID)
+	if err != nil {
+		h.writeError(rw, "list recovery shares error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, shares)
+}
+
+// @Summary Получить хранимые доли
+// @Description Возвращает доли ключей восстановления других пользователей, доверенным лицом которых является текущий пользователь
+// @Tags recovery
+// @Produce json
+// @Success 200 {array} entities.RecoveryShare "Хранимые доли"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /recovery/shares/held [get]
+// @Security BearerAuth
+func (h *RecoveryShareHandler) ListHeld(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shares, err := h.service.ListHeld(ctx, userID)
+	if err != nil {
+		h.writeError(rw, "list held recovery shares error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, shares)
+}
+
+// @Summary Начать восстановление по долям
+// @Description Начинает сессию сбора долей ключа восстановления аккаунта. Ключ сессии возвращается один раз и нужен для завершения восстановления.
+// @Description Сессия действует 24 часа
+// @Tags recovery
+// @Accept json
+// @Produce json
+// @Param body body dto.StartRecoverySessionDTO true "Логин восстанавливаемого аккаунта"
+// @Success 201 {object} dto.RecoverySessionDTO "Сессия восстановления"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 429 {string} string "Too Many Requests"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /recovery/sessions [post]
+func (h *RecoveryShareHandler) StartSession(rw http.ResponseWriter, r *http.Request) {
+	var body dto.StartRecoverySessionDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.Username == "" {
+		http.Error(rw, "login can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.service.StartSession(r.Context(), body.Username)
+	if err != nil {
+		h.writeError(rw, "start recovery session error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusCreated, session)
+}
+
+// @Summary Передать долю
+// @Description Передает долю ключа восстановления текущего пользователя в сессию восстановления. Доля запечатывается открытым ключом сессии
+// @Tags recovery
+// @Produce json
+// @Param id path int true "ID сессии восстановления"
+// @Success 200 {object} entities.RecoverySession "Сессия с числом переданных долей"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /recovery/sessions/{id}/shares [post]
+// @Security BearerAuth
+func (h *RecoveryShareHandler) Submit(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid recovery session id", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.service.Submit(ctx, dto.SubmitRecoveryShareDTO{SessionID: sessionID, OfficerID: userID, Key: key})
+	if err != nil {
+		h.writeError(rw, "submit recovery share error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, session)
+}
+
+// @Summary Завершить восстановление по долям
+// @Description Восстанавливает ключ восстановления из переданных долей и сбрасывает забытый мастер-пароль без потери данных хранилища.
+// @Description Доли и сессии аккаунта удаляются; новый ключ восстановления возвращается в ответе один раз. Авторизует пользователя так же, как вход по паролю
+// @Tags recovery
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сессии восстановления"
+// @Param body body dto.CompleteRecoveryDTO true "Ключ сессии и новый пароль"
+// @Param return_tokens query bool false "Вернуть токены в теле ответа"
+// @Success 200
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 429 {string} string "Too Many Requests"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /recovery/sessions/{id}/complete [post]
+func (h *RecoveryShareHandler) Complete(rw http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid recovery session id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.CompleteRecoveryDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.SessionKey == "" {
+		http.Error(rw, "session key can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	if body.NewPassword == "" {
+		http.Error(rw, "password can not be empty", http.StatusBadRequest)
+		return
+	}
+	body.SessionID = sessionID
+	body.DeviceDTO = requestDevice(r, body.DeviceName)
+
+	generatedJwt, err := h.service.Complete(r.Context(), body)
+	if err != nil {
+		h.writeError(rw, "complete recovery error", err)
+		return
+	}
+
+	setAuthCookies(rw, generatedJwt)
+
+	response := map[string]any{
+		"hash":         generatedJwt.Hash,
+		"recovery_key": generatedJwt.RecoveryKey,
+	}
+	addTokens(r, response, generatedJwt)
+
+	writeJSON(rw, http.StatusOK, response)
+}
+
+func (h *RecoveryShareHandler) writeError(rw http.ResponseWriter, msg string, err error) {
+	if writePasswordPolicyError(rw, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidRecoveryShares):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrInvalidRecoveryKey):
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, apperrors.ErrRecoveryOfficerNotFound),
+		errors.Is(err, apperrors.ErrRecoverySharesNotFound),
+		errors.Is(err, apperrors.ErrRecoveryShareNotFound),
+		errors.Is(err, apperrors.ErrRecoverySessionNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrRecoveryShareSubmitted),
+		errors.Is(err, apperrors.ErrNotEnoughRecoveryShares),
+		errors.Is(err, apperrors.ErrKeyPairNotFound),
+		errors.Is(err, apperrors.ErrKeyDerivation):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	default:
+		h.log.Sugar().Errorf("%s: %v", msg, err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockRecoveryShareService struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryShareService) Create(ctx context.Context, body dto.CreateRecoverySharesDTO) ([]entities.RecoveryShare, error) {
+	args := m.Called(body)
+	return args.Get(0).([]entities.RecoveryShare), args.Error(1)
+}
+
+func (m *MockRecoveryShareService) List(ctx context.Context, ownerID int64) ([]entities.RecoveryShare, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]entities.RecoveryShare), args.Error(1)
+}
+
+func (m *MockRecoveryShareService) ListHeld(ctx context.Context, officerID int64) ([]entities.RecoveryShare, error) {
+	args := m.Called(officerID)
+	return args.Get(0).([]entities.RecoveryShare), args.Error(1)
+}
+
+func (m *MockRecoveryShareService) StartSession(ctx context.Context, username string) (*dto.RecoverySessionDTO, error) {
+	args := m.Called(username)
+	if session, ok := args.Get(0).(*dto.RecoverySessionDTO); ok {
+		return session, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecoveryShareService) Submit(ctx context.Context, body dto.SubmitRecoveryShareDTO) (*entities.RecoverySession, error) {
+	args := m.Called(body)
+	if session, ok := args.Get(0).(*entities.RecoverySession); ok {
+		return session, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecoveryShareService) Complete(ctx context.Context, body dto.CompleteRecoveryDTO) (*dto.GeneratedJwt, error) {
+	args := m.Called(body)
+	if tokens, ok := args.Get(0).(*dto.GeneratedJwt); ok {
+		return tokens, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestRecoveryShareCreate(t *testing.T) {
+	mockService := new(MockRecoveryShareService)
+	handler := NewRecoveryShareHandler(mockService, zap.NewNop())
+
+	mockService.On("Create", dto.CreateRecoverySharesDTO{UserID: 1, Key: "testkey", Threshold: 2, Officers: []string{"bob", "carol"}}).
+		Return([]entities.RecoveryShare{{ID: 3, OfficerUsername: "bob", ShareIndex: 1, Threshold: 2, SealedShare: "s1.secret"}}, nil)
+	mockService.On("Create", mock.MatchedBy(func(body dto.CreateRecoverySharesDTO) bool { return body.Threshold == 1 })).
+		Return([]entities.RecoveryShare(nil), apperrors.ErrInvalidRecoveryShares)
+
+	rec := httptest.NewRecorder()
+	handler.Create(rec, newOrgRequest("POST", "/api/recovery/shares", `{"threshold":2,"officers":["bob","carol"]}`, nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"officer":"bob"`)
+	assert.NotContains(t, rec.Body.String(), "s1.secret", "The sealed share should not be returned")
+
+	rec = httptest.NewRecorder()
+	handler.Create(rec, newOrgRequest("POST", "/api/recovery/shares", `{"threshold":1,"officers":["bob"]}`, nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRecoveryShareStartSession(t *testing.T) {
+	mockService := new(MockRecoveryShareService)
+	handler := NewRecoveryShareHandler(mockService, zap.NewNop())
+
+	mockService.On("StartSession", "alice").Return(&dto.RecoverySessionDTO{ID: 9, SessionKey: "k1.session", Threshold: 2}, nil)
+	mockService.On("StartSession", "bob").Return(nil, apperrors.ErrRecoverySharesNotFound)
+
+	rec := httptest.NewRecorder()
+	handler.StartSession(rec, httptest.NewRequest("POST", "/api/recovery/sessions", strings.NewReader(`{"username":"alice"}`)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), "k1.session")
+
+	rec = httptest.NewRecorder()
+	handler.StartSession(rec, httptest.NewRequest("POST", "/api/recovery/sessions", strings.NewReader(`{"username":"bob"}`)))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.StartSession(rec, httptest.NewRequest("POST", "/api/recovery/sessions", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRecoveryShareSubmit(t *testing.T) {
+	mockService := new(MockRecoveryShareService)
+	handler := NewRecoveryShareHandler(mockService, zap.NewNop())
+
+	mockService.On("Submit", dto.SubmitRecoveryShareDTO{SessionID: 9, OfficerID: 1, Key: "testkey"}).
+		Return(&entities.RecoverySession{ID: 9, Threshold: 2, Submitted: 1}, nil)
+	mockService.On("Submit", dto.SubmitRecoveryShareDTO{SessionID: 10, OfficerID: 1, Key: "testkey"}).
+		Return(nil, apperrors.ErrRecoveryShareSubmitted)
+
+	rec := httptest.NewRecorder()
+	handler.Submit(rec, newOrgRequest("POST", "/api/recovery/sessions/9/shares", "", map[string]string{"id": "9"}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Submit(rec, newOrgRequest("POST", "/api/recovery/sessions/10/shares", "", map[string]string{"id": "10"}))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Submit(rec, newOrgRequest("POST", "/api/recovery/sessions/x/shares", "", map[string]string{"id": "x"}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRecoveryShareComplete(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		err  error
+		code int
+	}{
+		{"success", `{"session_key":"k1.session","new_password":"newpassword"}`, nil, http.StatusOK},
+		{"missing session key", `{"new_password":"newpassword"}`, nil, http.StatusBadRequest},
+		{"missing password", `{"session_key":"k1.session"}`, nil, http.StatusBadRequest},
+		{"not enough shares", `{"session_key":"k1.session","new_password":"newpassword"}`, apperrors.ErrNotEnoughRecoveryShares, http.StatusConflict},
+		{"wrong session key", `{"session_key":"k1.session","new_password":"newpassword"}`, apperrors.ErrInvalidRecoveryKey, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(MockRecoveryShareService)
+			handler := NewRecoveryShareHandler(mockService, zap.NewNop())
+			if tc.err != nil {
+				mockService.On("Complete", mock.Anything).Return(nil, tc.err)
+			} else {
+				mockService.On("Complete", dto.CompleteRecoveryDTO{SessionID: 9, SessionKey: "k1.session", NewPassword: "newpassword", DeviceDTO: dto.DeviceDTO{IP: "192.0.2.1"}}).
+					Return(&dto.GeneratedJwt{AccessToken: "access", RefreshToken: "refresh", Hash: "k1.dek", RecoveryKey: "CCCC-DDDD"}, nil)
+			}
+
+			req := httptest.NewRequest("POST", "/api/recovery/sessions/9/complete", strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "9")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rec := httptest.NewRecorder()
+			handler.Complete(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+			if tc.code == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "CCCC-DDDD")
+				assert.NotEmpty(t, rec.Result().Cookies())
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"go.uber.org/zap"
+)
+
+type ReencryptionHandler struct {
+	service ReencryptionService
+	log     *zap.Logger
+}
+
+type ReencryptionService interface {
+	Status(ctx context.Context) (*entities.ReencryptionJob, error)
+	Start(ctx context.Context, throttle dto.ReencryptionThrottleDTO) (*entities.ReencryptionJob, error)
+	Pause(ctx context.Context) (*entities.ReencryptionJob, error)
+	Throttle(throttle dto.ReencryptionThrottleDTO) (dto.ReencryptionThrottleDTO, error)
+}
+
+func NewReencryptionHandler(service ReencryptionService, logger *zap.Logger) *ReencryptionHandler {
+	return &ReencryptionHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Прогресс перешифрования
+// @Description Возвращает состояние последнего запуска фоновой ротации ключей и перешифрования:
+// @Description статус, текущую таблицу и позицию в ней, счетчики и настройки скорости.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} entities.ReencryptionJob "Состояние задачи"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Задача еще не запускалась"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/reencryption [get]
+// @Security BearerAuth
+func (h *ReencryptionHandler) Status(rw http.ResponseWriter, r *http.Request) {
+	job, err := h.service.Status(r.Context())
+	if err != nil {
+		h.writeError(rw, err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, job)
+}
+
+// @Summary Запуск перешифрования
+// @Description Запускает фоновую задачу: ключи данных, зашифрованные старой версией мастер-ключа сервера,
+// @Description перешифровываются сразу, а хранилища с записями в устаревшем формате или шифре помечаются
+// @Description и перешифровываются при следующем входе владельца. Приостановленная или упавшая задача
+// @Description продолжается с последней контрольной точки.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body dto.ReencryptionThrottleDTO false "Размер пачки и пауза между пачками"
+// @Success 202 {object} entities.ReencryptionJob "Задача запущена"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 409 {string} string "Задача уже выполняется"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/reencryption [post]
+// @Security BearerAuth
+func (h *ReencryptionHandler) Start(rw http.ResponseWriter, r *http.Request) {
+	var throttle dto.ReencryptionThrottleDTO
+	if err := json.NewDecoder(r.Body).Decode(&throttle); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.Start(r.Context(), throttle)
+	if err != nil {
+		h.writeError(rw, err)
+		return
+	}
+
+	writeJSON(rw, http.StatusAccepted, job)
+}
+
+// @Summary Приостановка перешифрования
+// @Description Останавливает задачу после текущей пачки. Повторный запуск продолжит ее с того же места.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} entities.ReencryptionJob "Задача приостановлена"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 409 {string} string "Задача не выполняется"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/reencryption/pause [post]
+// @Security BearerAuth
+func (h *ReencryptionHandler) Pause(rw http.ResponseWriter, r *http.Request) {
+	job, err := h.service.Pause(r.Context())
+	if err != nil {
+		h.writeError(rw, err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, job)
+}
+
+// @Summary Скорость перешифрования
+// @Description Меняет размер пачки и паузу между пачками выполняющейся задачи, начиная со следующей пачки.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body dto.ReencryptionThrottleDTO true "Размер пачки и пауза между пачками"
+// @Success 200 {object} dto.ReencryptionThrottleDTO "Действующие настройки"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 409 {string} string "Задача не выполняется"
+// @Router /admin/reencryption/throttle [put]
+// @Security BearerAuth
+func (h *ReencryptionHandler) Throttle(rw http.ResponseWriter, r *http.Request) {
+	var throttle dto.ReencryptionThrottleDTO
+	if err := json.NewDecoder(r.Body).Decode(&throttle); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	current, err := h.service.Throttle(throttle)
+	if err != nil {
+		h.writeError(rw, err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, current)
+}
+
+// writeError answers an error of the re-encryption service.
+func (h *ReencryptionHandler) writeError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidThrottle):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrReencryptionJobNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrReencryptionJobRunning), errors.Is(err, apperrors.ErrReencryptionJobNotRunning):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	default:
+		h.log.Error(err.Error())
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(v)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockReencryptionService struct {
+	mock.Mock
+}
+
+func (m *MockReencryptionService) Status(ctx context.Context) (*entities.ReencryptionJob, error) {
+	args := m.Called()
+	if job, ok := args.Get(0).(*entities.ReencryptionJob); ok {
+		return job, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReencryptionService) Start(ctx context.Context, throttle dto.ReencryptionThrottleDTO) (*entities.ReencryptionJob, error) {
+	args := m.Called(throttle)
+	if job, ok := args.Get(0).(*entities.ReencryptionJob); ok {
+		return job, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReencryptionService) Pause(ctx context.Context) (*entities.ReencryptionJob, error) {
+	args := m.Called()
+	if job, ok := args.Get(0).(*entities.ReencryptionJob); ok {
+		return job, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReencryptionService) Throttle(throttle dto.ReencryptionThrottleDTO) (dto.ReencryptionThrottleDTO, error) {
+	args := m.Called(throttle)
+	return args.Get(0).(dto.ReencryptionThrottleDTO), args.Error(1)
+}
+
+func TestReencryptionStart_Success(t *testing.T) {
+	mockService := new(MockReencryptionService)
+	handler := NewReencryptionHandler(mockService, zap.NewNop())
+
+	size := 50
+	mockService.On("Start", dto.ReencryptionThrottleDTO{BatchSize: &size}).
+		Return(&entities.ReencryptionJob{ID: 1, Status: "running", Phase: "user_keys", BatchSize: 50}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Start(rec, httptest.NewRequest("POST", "/api/admin/reencryption", strings.NewReader(`{"batch_size":50}`)))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	var job entities.ReencryptionJob
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	assert.Equal(t, "running", job.Status)
+	assert.Equal(t, 50, job.BatchSize)
+	mockService.AssertExpectations(t)
+}
+
+func TestReencryptionStart_EmptyBody(t *testing.T) {
+	mockService := new(MockReencryptionService)
+	handler := NewReencryptionHandler(mockService, zap.NewNop())
+
+	mockService.On("Start", dto.ReencryptionThrottleDTO{}).Return(nil, apperrors.ErrReencryptionJobRunning)
+
+	rec := httptest.NewRecorder()
+	handler.Start(rec, httptest.NewRequest("POST", "/api/admin/reencryption", nil))
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestReencryptionStatus_NotFound(t *testing.T) {
+	mockService := new(MockReencryptionService)
+	handler := NewReencryptionHandler(mockService, zap.NewNop())
+
+	mockService.On("Status").Return(nil, apperrors.ErrReencryptionJobNotFound)
+
+	rec := httptest.NewRecorder()
+	handler.Status(rec, httptest.NewRequest("GET", "/api/admin/reencryption", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestReencryptionThrottle_Invalid(t *testing.T) {
+	mockService := new(MockReencryptionService)
+	handler := NewReencryptionHandler(mockService, zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	handler.Throttle(rec, httptest.NewRequest("PUT", "/api/admin/reencryption/throttle", strings.NewReader(`{"batch_size":"fast"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	zero := 0
+	mockService.On("Throttle", dto.ReencryptionThrottleDTO{BatchSize: &zero}).Return(dto.ReencryptionThrottleDTO{}, apperrors.ErrInvalidThrottle)
+
+	rec = httptest.NewRecorder()
+	handler.Throttle(rec, httptest.NewRequest("PUT", "/api/admin/reencryption/throttle", strings.NewReader(`{"batch_size":0}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
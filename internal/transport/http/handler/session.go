@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type SessionHandler struct {
+	service SessionService
+	log     *zap.Logger
+}
+
+type SessionService interface {
+	List(ctx context.Context, userID int64, currentID string) ([]entities.Session, error)
+	Revoke(ctx context.Context, userID int64, id string) error
+}
+
+func NewSessionHandler(service SessionService, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Получить активные сессии
+// @Description Возвращает активные сессии текущего пользователя: устройство, user agent, IP, время входа и последней активности.
+// @Description Сессия, с которой выполнен запрос, отмечена полем current
+// @Tags sessions
+// @Produce json
+// @Success 200 {array} entities.Session "Активные сессии"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /sessions [get]
+// @Security BearerAuth
+func (h *SessionHandler) List(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, _ := ctx.Value(middleware.SessionIDContextKey).(string)
+
+	sessions, err := h.service.List(ctx, userID, sessionID)
+	if err != nil {
+		h.writeError(rw, "list sessions error", err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, sessions)
+}
+
+// @Summary Завершить сессию
+// @Description Отзывает активную сессию текущего пользователя, например на потерянном устройстве. Ее токены перестают приниматься
+// @Tags sessions
+// @Param id path string true "ID сессии"
+// @Success 204 "No Content"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /sessions/{id} [delete]
+// @Security BearerAuth
+func (h *SessionHandler) Revoke(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.Revoke(ctx, userID, chi.URLParam(r, "id")); err != nil {
+		h.writeError(rw, "revoke session error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SessionHandler) writeError(rw http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrSessionNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	default:
+		h.log.Sugar().Errorf("%s: %v", msg, err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+	}
+}
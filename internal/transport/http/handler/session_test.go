@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) List(ctx context.Context, userID int64, currentID string) ([]entities.Session, error) {
+	args := m.Called(userID, currentID)
+	return args.Get(0).([]entities.Session), args.Error(1)
+}
+
+func (m *MockSessionService) Revoke(ctx context.Context, userID int64, id string) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func TestSessionList(t *testing.T) {
+	mockService := new(MockSessionService)
+	handler := NewSessionHandler(mockService, zap.NewNop())
+
+	mockService.On("List", int64(1), "laptop").Return([]entities.Session{
+		{ID: "laptop", DeviceName: "Laptop", CurrentTokenID: "t1", Current: true},
+	}, nil)
+
+	req := newOrgRequest("GET", "/api/sessions", "", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.SessionIDContextKey, "laptop"))
+	rec := httptest.NewRecorder()
+	handler.List(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"current":true`)
+	assert.NotContains(t, rec.Body.String(), "t1", "The refresh token ID should not be returned")
+}
+
+func TestSessionRevoke(t *testing.T) {
+	mockService := new(MockSessionService)
+	handler := NewSessionHandler(mockService, zap.NewNop())
+
+	mockService.On("Revoke", int64(1), "phone").Return(nil)
+	mockService.On("Revoke", int64(1), "other").Return(apperrors.ErrSessionNotFound)
+
+	rec := httptest.NewRecorder()
+	handler.Revoke(rec, newOrgRequest("DELETE", "/api/sessions/phone", "", map[string]string{"id": "phone"}))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Revoke(rec, newOrgRequest("DELETE", "/api/sessions/other", "", map[string]string{"id": "other"}))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
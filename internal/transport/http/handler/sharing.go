@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type SharingHandler struct {
+	service SharingService
+	log     *zap.Logger
+}
+
+type SharingService interface {
+	Share(ctx context.Context, body dto.ShareItemDTO) (*entities.ItemShare, error)
+	ListShares(ctx context.Context, ownerID int64) ([]entities.ItemShare, error)
+	Revoke(ctx context.Context, ownerID, shareID int64, key string) error
+	ListReceived(ctx context.Context, recipientID int64, key string) ([]dto.SharedItemDTO, error)
+	UpdateReceived(ctx context.Context, body dto.UpdateSharedItemDTO) error
+}
+
+func NewSharingHandler(service SharingService, logger *zap.Logger) *SharingHandler {
+	return &SharingHandler{
+		service: service,
+		log:     logger,
+	}
+}
+
+// @Summary Поделиться записью
+// @Description Открывает доступ к записи хранилища (logopass, card, note, binary, bank_account, identity_document) другому пользователю с правом read или write. При первой передаче запись перешифровывается отдельным ключом записи
+// @Tags sharing
+// @Accept json
+// @Produce json
+// @Param body body dto.ShareItemDTO true "Запись, получатель и право доступа"
+// @Success 201 {object} entities.ItemShare "Доступ"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /shares [post]
+// @Security BearerAuth
+func (s *SharingHandler) Share(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body dto.ShareItemDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.OwnerID = userID
+	body.Key = key
+
+	share, err := s.service.Share(ctx, body)
+	if err != nil {
+		s.writeError(rw, "share item error", err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(rw).Encode(share); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Получить выданные доступы
+// @Description Возвращает список доступов к записям текущего пользователя
+// @Tags sharing
+// @Produce json
+// @Success 200 {array} entities.ItemShare "Список доступов"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /shares [get]
+// @Security BearerAuth
+func (s *SharingHandler) ListShares(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shares, err := s.service.ListShares(ctx, userID)
+	if err != nil {
+		s.log.Sugar().Errorf("list shares error: %v", err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(shares); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Отозвать доступ
+// @Description Отзывает доступ к записи. Ключ записи меняется, запись перешифровывается
+// @Tags sharing
+// @Param shareID path int true "ID доступа"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /shares/{shareID} [delete]
+// @Security BearerAuth
+func (s *SharingHandler) Revoke(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shareID, err := strconv.ParseInt(chi.URLParam(r, "shareID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid share id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.service.Revoke(ctx, userID, shareID, key); err != nil {
+		s.writeError(rw, "revoke share error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Получить доступные записи
+// @Description Возвращает расшифрованные записи, к которым текущему пользователю открыт доступ. Бинарные поля кодируются в base64
+// @Tags sharing
+// @Produce json
+// @Success 200 {array} dto.SharedItemDTO "Список записей"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /shares/received [get]
+// @Security BearerAuth
+func (s *SharingHandler) ListReceived(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	items, err := s.service.ListReceived(ctx, userID, key)
+	if err != nil {
+		s.writeError(rw, "list received shares error", err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(items); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Изменить доступную запись
+// @Description Изменяет зашифрованные поля записи, к которой текущему пользователю открыт доступ с правом write
+// @Tags sharing
+// @Accept json
+// @Param shareID path int true "ID доступа"
+// @Param body body dto.UpdateSharedItemDTO true "Новые значения полей"
+// @Success 200 {string} string "OK"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /shares/received/{shareID} [put]
+// @Security BearerAuth
+func (s *SharingHandler) UpdateReceived(rw http.ResponseWriter, r *http.Request) {
+	key, err := vaultKey(r)
+	if err != nil {
+		http.Error(rw, "key not found", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shareID, err := strconv.ParseInt(chi.URLParam(r, "shareID"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid share id", http.StatusBadRequest)
+		return
+	}
+
+	var body dto.UpdateSharedItemDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+	body.ShareID = shareID
+	body.RecipientID = userID
+	body.Key = key
+
+	if err := s.service.UpdateReceived(ctx, body); err != nil {
+		s.writeError(rw, "update shared item error", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// writeError answers a sharing error with its status code, logging unexpected errors.
+func (s *SharingHandler) writeError(rw http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidShare), errors.Is(err, apperrors.ErrInvalidSharedField):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrShareReadOnly):
+		http.Error(rw, err.Error(), http.StatusForbidden)
+	case errors.Is(err, apperrors.ErrShareRecipientNotFound),
+		errors.Is(err, apperrors.ErrShareItemNotFound),
+		errors.Is(err, apperrors.ErrShareNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrKeyPairNotFound),
+		errors.Is(err, apperrors.ErrKeyDerivation),
+		errors.Is(err, apperrors.ErrOrgKeyChanged):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	default:
+		s.log.Sugar().Errorf("%s: %v", msg, err)
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/entities"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockSharingService struct {
+	mock.Mock
+}
+
+func (m *MockSharingService) Share(ctx context.Context, body dto.ShareItemDTO) (*entities.ItemShare, error) {
+	args := m.Called(body)
+	if share, ok := args.Get(0).(*entities.ItemShare); ok {
+		return share, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSharingService) ListShares(ctx context.Context, ownerID int64) ([]entities.ItemShare, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]entities.ItemShare), args.Error(1)
+}
+
+func (m *MockSharingService) Revoke(ctx context.Context, ownerID, shareID int64, key string) error {
+	args := m.Called(ownerID, shareID, key)
+	return args.Error(0)
+}
+
+func (m *MockSharingService) ListReceived(ctx context.Context, recipientID int64, key string) ([]dto.SharedItemDTO, error) {
+	args := m.Called(recipientID, key)
+	return args.Get(0).([]dto.SharedItemDTO), args.Error(1)
+}
+
+func (m *MockSharingService) UpdateReceived(ctx context.Context, body dto.UpdateSharedItemDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+// newSharingRequest builds an authenticated request of user 1 with the vault key cookie and a shareID URL parameter.
+func newSharingRequest(method, target, body, shareID string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.AddCookie(&http.Cookie{Name: "key", Value: "testkey"})
+	rctx := chi.NewRouteContext()
+	if shareID != "" {
+		rctx.URLParams.Add("shareID", shareID)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	return req.WithContext(context.WithValue(ctx, middleware.UserIDContextKey, int64(1)))
+}
+
+func TestSharingShare_Success(t *testing.T) {
+	mockService := new(MockSharingService)
+	handler := NewSharingHandler(mockService, zap.NewNop())
+
+	mockService.On("Share", dto.ShareItemDTO{
+		OwnerID:    1,
+		Key:        "testkey",
+		ItemType:   "note",
+		ItemID:     5,
+		Username:   "bob",
+		Permission: "write",
+	}).Return(&entities.ItemShare{ID: 9, ItemType: "note", ItemID: 5, RecipientUsername: "bob", Permission: "write", WrappedKey: "s1.secret"}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.Share(rec, newSharingRequest("POST", "/api/shares", `{"item_type":"note","item_id":5,"username":"bob","permission":"write"}`, ""))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"recipient":"bob"`)
+	assert.NotContains(t, rec.Body.String(), "s1.secret", "The sealed item key should not be returned")
+	mockService.AssertExpectations(t)
+}
+
+func TestSharingShare_Errors(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{apperrors.ErrInvalidShare, http.StatusBadRequest},
+		{apperrors.ErrShareRecipientNotFound, http.StatusNotFound},
+		{apperrors.ErrShareItemNotFound, http.StatusNotFound},
+		{apperrors.ErrKeyPairNotFound, http.StatusConflict},
+		{assert.AnError, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			mockService := new(MockSharingService)
+			handler := NewSharingHandler(mockService, zap.NewNop())
+			mockService.On("Share", mock.Anything).Return(nil, tc.err)
+
+			rec := httptest.NewRecorder()
+			handler.Share(rec, newSharingRequest("POST", "/api/shares", `{"item_type":"note","item_id":5,"username":"bob"}`, ""))
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestSharingRevoke(t *testing.T) {
+	mockService := new(MockSharingService)
+	handler := NewSharingHandler(mockService, zap.NewNop())
+
+	mockService.On("Revoke", int64(1), int64(9), "testkey").Return(nil)
+	mockService.On("Revoke", int64(1), int64(10), "testkey").Return(apperrors.ErrShareNotFound)
+
+	rec := httptest.NewRecorder()
+	handler.Revoke(rec, newSharingRequest("DELETE", "/api/shares/9", "", "9"))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Revoke(rec, newSharingRequest("DELETE", "/api/shares/10", "", "10"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSharingListReceived(t *testing.T) {
+	mockService := new(MockSharingService)
+	handler := NewSharingHandler(mockService, zap.NewNop())
+
+	mockService.On("ListReceived", int64(1), "testkey").Return([]dto.SharedItemDTO{{
+		ItemShare: entities.ItemShare{ID: 9, ItemType: "logopass", OwnerUsername: "alice", Permission: "read"},
+		Fields:    map[string]string{"app_name": "mail", "password": "secret"},
+	}}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.ListReceived(rec, newSharingRequest("GET", "/api/shares/received", "", ""))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"owner":"alice"`)
+	assert.Contains(t, rec.Body.String(), `"password":"secret"`)
+}
+
+func TestSharingUpdateReceived(t *testing.T) {
+	mockService := new(MockSharingService)
+	handler := NewSharingHandler(mockService, zap.NewNop())
+
+	mockService.On("UpdateReceived", dto.UpdateSharedItemDTO{
+		ShareID:     9,
+		RecipientID: 1,
+		Key:         "testkey",
+		Fields:      map[string]string{"text_data": "new"},
+	}).Return(nil)
+	mockService.On("UpdateReceived", mock.MatchedBy(func(body dto.UpdateSharedItemDTO) bool { return body.ShareID == 10 })).
+		Return(apperrors.ErrShareReadOnly)
+
+	rec := httptest.NewRecorder()
+	handler.UpdateReceived(rec, newSharingRequest("PUT", "/api/shares/received/9", `{"fields":{"text_data":"new"}}`, "9"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.UpdateReceived(rec, newSharingRequest("PUT", "/api/shares/received/10", `{"fields":{"text_data":"new"}}`, "10"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+)
+
+// @Summary Подтверждение входа вторым фактором
+// @Description Завершает вход пользователя с включенной двухфакторной аутентификацией: токен подтверждения,
+// @Description полученный при входе по паролю, обменивается на сессию вместе с кодом TOTP или неиспользованным
+// @Description кодом восстановления. После нескольких неверных кодов токен перестает действовать
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param request body dto.TwoFactorLoginDTO true "Токен подтверждения и код"
+// @Param return_tokens query bool false "Вернуть токены в теле ответа"
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 429
+// @Failure 500
+// @Router /api/user/login/2fa [post]
+func (u *UserHandler) LoginTwoFactor(rw http.ResponseWriter, r *http.Request) {
+	var body dto.TwoFactorLoginDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.ChallengeToken == "" || body.Code == "" {
+		http.Error(rw, "challenge token and code can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	body.DeviceDTO = requestDevice(r, body.DeviceName)
+
+	generatedJwt, err := u.service.LoginTwoFactor(r.Context(), body)
+	if err != nil {
+		u.writeTwoFactorError(rw, err)
+		return
+	}
+
+	setAuthCookies(rw, generatedJwt)
+
+	response := map[string]any{"hash": generatedJwt.Hash}
+	addTokens(r, response, generatedJwt)
+
+	writeJSON(rw, http.StatusOK, response)
+}
+
+// @Summary Подключение приложения-аутентификатора
+// @Description Создает секрет TOTP и возвращает otpauth URI для приложения-аутентификатора.
+// @Description Двухфакторная аутентификация включается после подтверждения первого кода
+// @Tags user
+// @Produce  json
+// @Success 200 {object} dto.TOTPEnrollmentDTO
+// @Failure 401
+// @Failure 409
+// @Failure 500
+// @Router /api/user/2fa [post]
+// @Security BearerAuth
+func (u *UserHandler) EnrollTOTP(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := u.service.EnrollTOTP(r.Context(), userID)
+	if err != nil {
+		u.writeTwoFactorError(rw, err)
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, enrollment)
+}
+
+// @Summary Включение двухфакторной аутентификации
+// @Description Проверяет первый код приложения-аутентификатора и включает двухфакторную аутентификацию.
+// @Description Код TOTP заменяют одноразовые коды восстановления, выданные при регистрации или вместе с новым ключом восстановления
+// @Tags user
+// @Accept  json
+// @Param request body dto.TOTPCodeDTO true "Код из приложения"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router /api/user/2fa/confirm [post]
+// @Security BearerAuth
+func (u *UserHandler) ConfirmTOTP(rw http.ResponseWriter, r *http.Request) {
+	var body dto.TOTPCodeDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.Code == "" {
+		http.Error(rw, "code can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
+
+	if err := u.service.ConfirmTOTP(r.Context(), body); err != nil {
+		u.writeTwoFactorError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Отключение двухфакторной аутентификации
+// @Description Отключает двухфакторную аутентификацию. Требуются мастер-пароль и код TOTP или код восстановления
+// @Tags user
+// @Accept  json
+// @Param request body dto.DisableTwoFactorDTO true "Пароль и код"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Router /api/user/2fa [delete]
+// @Security BearerAuth
+func (u *UserHandler) DisableTOTP(rw http.ResponseWriter, r *http.Request) {
+	var body dto.DisableTwoFactorDTO
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.Password == "" || body.Code == "" {
+		http.Error(rw, "password and code can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
+
+	if err := u.service.DisableTOTP(r.Context(), body); err != nil {
+		u.writeTwoFactorError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// writeTwoFactorError answers a failed two-factor request with the status matching err.
+func (u *UserHandler) writeTwoFactorError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidTwoFactorCode), errors.Is(err, apperrors.ErrLoginChallengeNotFound):
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, apperrors.ErrInvalidPassword):
+		http.Error(rw, err.Error(), http.StatusForbidden)
+	case errors.Is(err, apperrors.ErrTOTPNotFound), errors.Is(err, apperrors.ErrUserNotFound):
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrTwoFactorEnabled):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	default:
+		u.log.Error(err.Error())
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+	}
+}
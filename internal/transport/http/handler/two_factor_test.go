@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// authorizedRequest returns a request of the authenticated user 1.
+func authorizedRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
+}
+
+func TestUserHandler_Login_TwoFactorChallenge(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	expiresAt := time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC)
+	mockService.On("Login", mock.Anything).Return(&dto.GeneratedJwt{
+		Challenge: &dto.LoginChallengeDTO{Token: "chal.k1.challenge", ExpiresAt: expiresAt},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewBufferString(`{"username":"alice","password":"password123"}`))
+	rec := httptest.NewRecorder()
+
+	handler.Login(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"two_factor_required":true,"challenge_token":"chal.k1.challenge","expires_at":"2026-03-01T12:05:00Z"}`, rec.Body.String())
+	assert.Empty(t, rec.Result().Cookies(), "No cookies should be set before the second factor")
+}
+
+func TestUserHandler_LoginTwoFactor(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("LoginTwoFactor", dto.TwoFactorLoginDTO{ChallengeToken: "chal.key", Code: "123456", DeviceDTO: testDevice}).
+		Return(&dto.GeneratedJwt{AccessToken: "access", RefreshToken: "refresh", Hash: "k1.dek"}, nil)
+	mockService.On("LoginTwoFactor", dto.TwoFactorLoginDTO{ChallengeToken: "chal.key", Code: "000000", DeviceDTO: testDevice}).
+		Return(nil, apperrors.ErrInvalidTwoFactorCode)
+	mockService.On("LoginTwoFactor", dto.TwoFactorLoginDTO{ChallengeToken: "gone.key", Code: "123456", DeviceDTO: testDevice}).
+		Return(nil, apperrors.ErrLoginChallengeNotFound)
+
+	for name, tc := range map[string]struct {
+		body string
+		code int
+	}{
+		"valid code":      {`{"challenge_token":"chal.key","code":"123456"}`, http.StatusOK},
+		"wrong code":      {`{"challenge_token":"chal.key","code":"000000"}`, http.StatusUnauthorized},
+		"stale challenge": {`{"challenge_token":"gone.key","code":"123456"}`, http.StatusUnauthorized},
+		"missing code":    {`{"challenge_token":"chal.key"}`, http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.LoginTwoFactor(rec, httptest.NewRequest(http.MethodPost, "/api/user/login/2fa", bytes.NewBufferString(tc.body)))
+
+			assert.Equal(t, tc.code, rec.Code)
+			if tc.code == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "k1.dek")
+				assert.NotEmpty(t, rec.Result().Cookies())
+			}
+		})
+	}
+}
+
+func TestUserHandler_EnrollTOTP(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("EnrollTOTP", int64(1)).Return(&dto.TOTPEnrollmentDTO{Secret: "JBSWY3DP", URI: "otpauth://totp/GophKeeper:alice?secret=JBSWY3DP"}, nil).Once()
+	mockService.On("EnrollTOTP", int64(1)).Return(nil, apperrors.ErrTwoFactorEnabled)
+
+	rec := httptest.NewRecorder()
+	handler.EnrollTOTP(rec, authorizedRequest(http.MethodPost, "/api/user/2fa", ""))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"otpauth_uri":"otpauth://totp/GophKeeper:alice?secret=JBSWY3DP"`)
+
+	rec = httptest.NewRecorder()
+	handler.EnrollTOTP(rec, authorizedRequest(http.MethodPost, "/api/user/2fa", ""))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestUserHandler_ConfirmTOTP(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("ConfirmTOTP", dto.TOTPCodeDTO{UserID: 1, Code: "123456"}).Return(nil)
+	mockService.On("ConfirmTOTP", dto.TOTPCodeDTO{UserID: 1, Code: "000000"}).Return(apperrors.ErrInvalidTwoFactorCode)
+
+	rec := httptest.NewRecorder()
+	handler.ConfirmTOTP(rec, authorizedRequest(http.MethodPost, "/api/user/2fa/confirm", `{"code":"123456"}`))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ConfirmTOTP(rec, authorizedRequest(http.MethodPost, "/api/user/2fa/confirm", `{"code":"000000"}`))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ConfirmTOTP(rec, authorizedRequest(http.MethodPost, "/api/user/2fa/confirm", `{}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestUserHandler_DisableTOTP(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("DisableTOTP", dto.DisableTwoFactorDTO{UserID: 1, Password: "password123", Code: "123456"}).Return(nil)
+	mockService.On("DisableTOTP", dto.DisableTwoFactorDTO{UserID: 1, Password: "wrong", Code: "123456"}).Return(apperrors.ErrInvalidPassword)
+
+	rec := httptest.NewRecorder()
+	handler.DisableTOTP(rec, authorizedRequest(http.MethodDelete, "/api/user/2fa", `{"password":"password123","code":"123456"}`))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.DisableTOTP(rec, authorizedRequest(http.MethodDelete, "/api/user/2fa", `{"password":"wrong","code":"123456"}`))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.DisableTOTP(rec, httptest.NewRequest(http.MethodDelete, "/api/user/2fa", bytes.NewBufferString(`{"password":"password123","code":"123456"}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -3,11 +3,16 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"go.uber.org/zap"
 )
 
@@ -19,21 +24,36 @@ type UserHandler struct {
 type UserService interface {
 	Registration(ctx context.Context, registrationDTO dto.UserDTO) (*dto.GeneratedJwt, error)
 	Login(ctx context.Context, loginDTO dto.UserDTO) (*dto.GeneratedJwt, error)
+	ChangePassword(ctx context.Context, body dto.ChangePasswordDTO) (string, error)
+	RegenerateRecovery(ctx context.Context, body dto.RegenerateRecoveryDTO) (*dto.RecoveryKitDTO, error)
+	Recover(ctx context.Context, body dto.RecoverAccountDTO) (*dto.GeneratedJwt, error)
+	Refresh(ctx context.Context, refreshToken string) (*dto.GeneratedJwt, error)
+	Logout(ctx context.Context, userID int64, sessionID string) error
+	LoginTwoFactor(ctx context.Context, body dto.TwoFactorLoginDTO) (*dto.GeneratedJwt, error)
+	EnrollTOTP(ctx context.Context, userID int64) (*dto.TOTPEnrollmentDTO, error)
+	ConfirmTOTP(ctx context.Context, body dto.TOTPCodeDTO) error
+	DisableTOTP(ctx context.Context, body dto.DisableTwoFactorDTO) error
 }
 
 func NewUserHandler(serv UserService, log *zap.Logger) *UserHandler {
-	return &UserHandler{service: serv}
+	return &UserHandler{service: serv, log: log}
 }
 
 // @Summary Регистрация пользователя
-// @Description Создает нового пользователя в системе
+// @Description Создает нового пользователя в системе. В ответе один раз возвращаются ключ восстановления
+// @Description и одноразовые коды восстановления: сервер хранит только их зашифрованные или хешированные формы.
+// @Description Код восстановления заменяет код двухфакторной аутентификации, если приложение-аутентификатор потеряно.
+// @Description С return_tokens=true access и refresh токены также возвращаются в теле ответа.
+// @Description Пароль должен соответствовать политике паролей: минимальная длина и энтропия, не из списка распространенных паролей, не совпадает с логином.
 // @Tags user
 // @Accept  json
 // @Produce  json
 // @Param request body dto.UserDTO true "Данные пользователя"
+// @Param return_tokens query bool false "Вернуть токены в теле ответа"
 // @Success 200
-// @Failure 400
+// @Failure 400 {object} dto.PasswordPolicyViolationDTO "Некорректный запрос или пароль не соответствует политике паролей"
 // @Failure 409
+// @Failure 429
 // @Failure 500
 // @Router /api/user/register [post]
 func (u *UserHandler) Registration(rw http.ResponseWriter, r *http.Request) {
@@ -55,14 +75,20 @@ func (u *UserHandler) Registration(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	registrationDTO.DeviceDTO = requestDevice(r, registrationDTO.DeviceName)
+
 	generatedJwt, err := u.service.Registration(r.Context(), registrationDTO)
 	if err != nil {
+		if writePasswordPolicyError(rw, err) {
+			return
+		}
+
 		switch err {
 		case apperrors.ErrUserAlreadyExists:
 			http.Error(rw, err.Error(), http.StatusConflict)
 		case apperrors.ErrDBQuery:
 			http.Error(rw, "internal server error", http.StatusInternalServerError)
-		case apperrors.ErrHashPassword, apperrors.ErrJWTGeneration:
+		case apperrors.ErrHashPassword, apperrors.ErrKeyDerivation, apperrors.ErrJWTGeneration:
 			http.Error(rw, "error processing request", http.StatusInternalServerError)
 		default:
 			u.log.Error(err.Error())
@@ -71,39 +97,13 @@ func (u *UserHandler) Registration(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	refreshTokenCokie := http.Cookie{
-		Name:     "refreshtoken",
-		Value:    generatedJwt.RefreshToken,
-		Path:     "/",
-		Expires:  time.Now().Add(2 * time.Hour * 24 * 30),
-		HttpOnly: true,
-		Secure:   false,
-	}
-
-	accessTokenCookie := http.Cookie{
-		Name:     "accesstoken",
-		Value:    generatedJwt.AccessToken,
-		Path:     "/",
-		Expires:  time.Now().Add(15 * time.Minute),
-		HttpOnly: true,
-		Secure:   false,
-	}
-
-	keyCookie := http.Cookie{
-		Name:     "key",
-		Value:    generatedJwt.Hash,
-		Path:     "/",
-		Expires:  time.Now().Add(10000 * time.Hour),
-		HttpOnly: true,
-		Secure:   false,
-	}
-
-	http.SetCookie(rw, &refreshTokenCokie)
-	http.SetCookie(rw, &accessTokenCookie)
-	http.SetCookie(rw, &keyCookie)
-	response := map[string]string{
-		"hash": generatedJwt.Hash,
+	setAuthCookies(rw, generatedJwt)
+	response := map[string]any{
+		"hash":           generatedJwt.Hash,
+		"recovery_key":   generatedJwt.RecoveryKey,
+		"recovery_codes": generatedJwt.RecoveryCodes,
 	}
+	addTokens(r, response, generatedJwt)
 
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
@@ -113,14 +113,20 @@ func (u *UserHandler) Registration(rw http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Авторизация пользователя
-// @Description Аутентифицирует пользователя по логину и паролю
+// @Description Аутентифицирует пользователя по логину и паролю. С return_tokens=true access и refresh токены
+// @Description и ключ хранилища возвращаются в теле ответа для клиентов без cookie.
+// @Description Если включена двухфакторная аутентификация, возвращается 202 с токеном подтверждения входа,
+// @Description который вместе с кодом передается в /api/user/login/2fa
 // @Tags user
 // @Accept  json
 // @Produce  json
 // @Param request body dto.UserDTO true "Данные пользователя"
+// @Param return_tokens query bool false "Вернуть токены в теле ответа"
 // @Success 200
+// @Success 202 {object} dto.LoginChallengeDTO "Требуется второй фактор"
 // @Failure 400
 // @Failure 401
+// @Failure 429 "Слишком много попыток; заголовок Retry-After содержит время ожидания в секундах"
 // @Failure 500
 // @Router /api/user/login [post]
 func (u *UserHandler) Login(rw http.ResponseWriter, r *http.Request) {
@@ -142,8 +148,17 @@ func (u *UserHandler) Login(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	loginDTO.DeviceDTO = requestDevice(r, loginDTO.DeviceName)
+
 	generatedJwt, err := u.service.Login(r.Context(), loginDTO)
 	if err != nil {
+		var locked *apperrors.LoginLockedError
+		if errors.As(err, &locked) {
+			rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			http.Error(rw, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
 		switch err {
 		case apperrors.ErrInvalidPassword:
 			http.Error(rw, "unauthorized", http.StatusUnauthorized)
@@ -151,7 +166,7 @@ func (u *UserHandler) Login(rw http.ResponseWriter, r *http.Request) {
 			http.Error(rw, "user not found", http.StatusBadRequest)
 		case apperrors.ErrDBQuery:
 			http.Error(rw, "internal server error", http.StatusInternalServerError)
-		case apperrors.ErrHashPassword, apperrors.ErrJWTGeneration:
+		case apperrors.ErrHashPassword, apperrors.ErrKeyDerivation, apperrors.ErrJWTGeneration:
 			http.Error(rw, "error processing request", http.StatusInternalServerError)
 		default:
 			u.log.Error(err.Error())
@@ -160,23 +175,369 @@ func (u *UserHandler) Login(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	refreshTokenCookie := http.Cookie{
-		Name:     "refreshtoken",
-		Value:    generatedJwt.RefreshToken,
-		Path:     "/",
-		Expires:  time.Now().Add(2 * time.Hour * 24 * 30),
-		HttpOnly: true,
-		Secure:   false,
+	if generatedJwt.Challenge != nil {
+		writeJSON(rw, http.StatusAccepted, map[string]any{
+			"two_factor_required": true,
+			"challenge_token":     generatedJwt.Challenge.Token,
+			"expires_at":          generatedJwt.Challenge.ExpiresAt,
+		})
+		return
 	}
 
-	accessTokenCookie := http.Cookie{
-		Name:     "accesstoken",
-		Value:    generatedJwt.AccessToken,
+	setAuthCookies(rw, generatedJwt)
+
+	if tokensRequested(r) {
+		response := map[string]any{"hash": generatedJwt.Hash}
+		addTokens(r, response, generatedJwt)
+		writeJSON(rw, http.StatusOK, response)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+}
+
+// @Summary Смена мастер-пароля
+// @Description Проверяет текущий пароль, заменяет его новым и перешифровывает ключ хранилища.
+// @Description Все изменения выполняются в одной транзакции. Возвращает ключ хранилища, который также устанавливается в cookie.
+// @Description Все остальные сессии пользователя завершаются.
+// @Description Новый пароль должен соответствовать политике паролей: минимальная длина и энтропия, не из списка распространенных паролей, не совпадает с логином.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ChangePasswordDTO true "Текущий и новый пароли"
+// @Success 200
+// @Failure 400 {object} dto.PasswordPolicyViolationDTO "Некорректный запрос или пароль не соответствует политике паролей"
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /api/user/password [put]
+// @Security BearerAuth
+func (u *UserHandler) ChangePassword(rw http.ResponseWriter, r *http.Request) {
+	var body dto.ChangePasswordDTO
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.OldPassword == "" || body.NewPassword == "" {
+		http.Error(rw, "password can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
+
+	sessionID, ok := r.Context().Value(middleware.SessionIDContextKey).(string)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.SessionID = sessionID
+
+	key, err := u.service.ChangePassword(r.Context(), body)
+	if err != nil {
+		if writePasswordPolicyError(rw, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidPassword):
+			http.Error(rw, err.Error(), http.StatusForbidden)
+		case errors.Is(err, apperrors.ErrUserNotFound):
+			http.Error(rw, err.Error(), http.StatusNotFound)
+		default:
+			u.log.Error(err.Error())
+			http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	keyCookie := http.Cookie{
+		Name:     "key",
+		Value:    key,
 		Path:     "/",
-		Expires:  time.Now().Add(15 * time.Minute),
+		Expires:  time.Now().Add(10000 * time.Hour),
 		HttpOnly: true,
 		Secure:   false,
 	}
+	http.SetCookie(rw, &keyCookie)
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(map[string]string{"hash": key}); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Новый ключ и коды восстановления
+// @Description Проверяет пароль и выпускает новый ключ восстановления и новые одноразовые коды.
+// @Description Прежние ключ и коды перестают действовать. Ответ показывается пользователю один раз.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param request body dto.RegenerateRecoveryDTO true "Текущий пароль"
+// @Success 200 {object} dto.RecoveryKitDTO "Ключ и коды восстановления"
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 500
+// @Router /api/user/recovery [post]
+// @Security BearerAuth
+func (u *UserHandler) RegenerateRecovery(rw http.ResponseWriter, r *http.Request) {
+	var body dto.RegenerateRecoveryDTO
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.Password == "" {
+		http.Error(rw, "password can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body.UserID = userID
+
+	kit, err := u.service.RegenerateRecovery(r.Context(), body)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidPassword):
+			http.Error(rw, err.Error(), http.StatusForbidden)
+		case errors.Is(err, apperrors.ErrUserNotFound):
+			http.Error(rw, err.Error(), http.StatusNotFound)
+		default:
+			u.log.Error(err.Error())
+			http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(kit); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Восстановление доступа
+// @Description Сбрасывает забытый мастер-пароль с помощью ключа восстановления без потери данных хранилища.
+// @Description Использованный ключ восстановления заменяется новым, который возвращается в ответе один раз.
+// @Description Авторизует пользователя так же, как вход по паролю.
+// @Description Новый пароль должен соответствовать политике паролей: минимальная длина и энтропия, не из списка распространенных паролей, не совпадает с логином.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param request body dto.RecoverAccountDTO true "Логин, ключ восстановления и новый пароль"
+// @Param return_tokens query bool false "Вернуть токены в теле ответа"
+// @Success 200
+// @Failure 400 {object} dto.PasswordPolicyViolationDTO "Некорректный запрос или пароль не соответствует политике паролей"
+// @Failure 401
+// @Failure 429
+// @Failure 500
+// @Router /api/user/recover [post]
+func (u *UserHandler) Recover(rw http.ResponseWriter, r *http.Request) {
+	var body dto.RecoverAccountDTO
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		http.Error(rw, apperrors.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if body.Username == "" || body.RecoveryKey == "" {
+		http.Error(rw, "login and recovery key can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	if body.NewPassword == "" {
+		http.Error(rw, "password can not be empty", http.StatusBadRequest)
+		return
+	}
+
+	body.DeviceDTO = requestDevice(r, body.DeviceName)
+
+	generatedJwt, err := u.service.Recover(r.Context(), body)
+	if err != nil {
+		if writePasswordPolicyError(rw, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidRecoveryKey):
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+		default:
+			u.log.Error(err.Error())
+			http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	setAuthCookies(rw, generatedJwt)
+
+	response := map[string]any{
+		"hash":         generatedJwt.Hash,
+		"recovery_key": generatedJwt.RecoveryKey,
+	}
+	addTokens(r, response, generatedJwt)
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(response); err != nil {
+		http.Error(rw, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// @Summary Обновление токенов
+// @Description Выдает новую пару access/refresh токенов по refresh токену из cookie или, для клиентов без cookie,
+// @Description из тела запроса. Refresh токен одноразовый: повторное использование уже замененного токена
+// @Description отзывает все токены, выданные с того же входа. С return_tokens=true новые токены возвращаются в теле ответа
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Param request body dto.RefreshTokenDTO false "Refresh токен, если он не передан в cookie"
+// @Param return_tokens query bool false "Вернуть токены в теле ответа"
+// @Success 200
+// @Success 204
+// @Failure 401
+// @Failure 429
+// @Failure 500
+// @Router /api/user/refresh [post]
+func (u *UserHandler) Refresh(rw http.ResponseWriter, r *http.Request) {
+	var refreshToken string
+	if cookie, err := r.Cookie("refreshtoken"); err == nil {
+		refreshToken = cookie.Value
+	} else {
+		var body dto.RefreshTokenDTO
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			refreshToken = body.RefreshToken
+		}
+	}
+
+	if refreshToken == "" {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	generatedJwt, err := u.service.Refresh(r.Context(), refreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidRefreshToken), errors.Is(err, apperrors.ErrRefreshTokenReused):
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+		default:
+			u.log.Error(err.Error())
+			http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	setTokenCookies(rw, generatedJwt)
+
+	if tokensRequested(r) {
+		response := map[string]any{}
+		addTokens(r, response, generatedJwt)
+		writeJSON(rw, http.StatusOK, response)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Выход
+// @Description Завершает текущую сессию: ее access и refresh токены перестают приниматься, cookie удаляются
+// @Tags user
+// @Success 204
+// @Failure 401
+// @Failure 500
+// @Router /api/user/logout [post]
+// @Security BearerAuth
+func (u *UserHandler) Logout(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ctx.Value(middleware.UserIDContextKey).(int64)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, ok := ctx.Value(middleware.SessionIDContextKey).(string)
+	if !ok {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := u.service.Logout(ctx, userID, sessionID)
+	if err != nil && !errors.Is(err, apperrors.ErrSessionNotFound) {
+		u.log.Error(err.Error())
+		http.Error(rw, apperrors.ErrInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	clearAuthCookies(rw)
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// requestDevice returns the device a request is made from, with the device name chosen by the client.
+func requestDevice(r *http.Request, name string) dto.DeviceDTO {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return dto.DeviceDTO{DeviceName: name, UserAgent: r.UserAgent(), IP: ip}
+}
+
+// tokensRequested reports whether the client asked with the return_tokens query parameter for the
+// tokens in the response body, as clients that do not keep cookies do.
+func tokensRequested(r *http.Request) bool {
+	requested, _ := strconv.ParseBool(r.URL.Query().Get("return_tokens"))
+	return requested
+}
+
+// addTokens adds the access and refresh tokens and their expiration times to the response of
+// a request that asked for them.
+func addTokens(r *http.Request, response map[string]any, generatedJwt *dto.GeneratedJwt) {
+	if !tokensRequested(r) {
+		return
+	}
+
+	response["token_type"] = "Bearer"
+	response["access_token"] = generatedJwt.AccessToken
+	response["access_expires_at"] = generatedJwt.AccessExpiresAt
+	response["refresh_token"] = generatedJwt.RefreshToken
+	response["refresh_expires_at"] = generatedJwt.RefreshExpiresAt
+}
+
+// clearAuthCookies deletes the refresh token, access token and vault key cookies.
+func clearAuthCookies(rw http.ResponseWriter) {
+	for _, name := range []string{"refreshtoken", "accesstoken", "key"} {
+		http.SetCookie(rw, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   false,
+		})
+	}
+}
+
+// setAuthCookies sets the refresh token, access token and vault key cookies of an authenticated user.
+func setAuthCookies(rw http.ResponseWriter, generatedJwt *dto.GeneratedJwt) {
+	setTokenCookies(rw, generatedJwt)
 
 	keyCookie := http.Cookie{
 		Name:     "key",
@@ -187,10 +548,29 @@ func (u *UserHandler) Login(rw http.ResponseWriter, r *http.Request) {
 		Secure:   false,
 	}
 
-	http.SetCookie(rw, &refreshTokenCookie)
-	http.SetCookie(rw, &accessTokenCookie)
 	http.SetCookie(rw, &keyCookie)
+}
 
-	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(http.StatusOK)
+// setTokenCookies sets the refresh token and access token cookies, expiring with their tokens.
+func setTokenCookies(rw http.ResponseWriter, generatedJwt *dto.GeneratedJwt) {
+	refreshTokenCookie := http.Cookie{
+		Name:     "refreshtoken",
+		Value:    generatedJwt.RefreshToken,
+		Path:     "/",
+		Expires:  generatedJwt.RefreshExpiresAt,
+		HttpOnly: true,
+		Secure:   false,
+	}
+
+	accessTokenCookie := http.Cookie{
+		Name:     "accesstoken",
+		Value:    generatedJwt.AccessToken,
+		Path:     "/",
+		Expires:  generatedJwt.AccessExpiresAt,
+		HttpOnly: true,
+		Secure:   false,
+	}
+
+	http.SetCookie(rw, &refreshTokenCookie)
+	http.SetCookie(rw, &accessTokenCookie)
 }
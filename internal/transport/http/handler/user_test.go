@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/dto"
+	"github.com/Zrossiz/gophkeeper/internal/transport/http/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
@@ -35,6 +37,75 @@ func (m *MockUserService) Login(ctx context.Context, loginDTO dto.UserDTO) (*dto
 	return nil, args.Error(1)
 }
 
+func (m *MockUserService) ChangePassword(ctx context.Context, body dto.ChangePasswordDTO) (string, error) {
+	args := m.Called(body)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) RegenerateRecovery(ctx context.Context, body dto.RegenerateRecoveryDTO) (*dto.RecoveryKitDTO, error) {
+	args := m.Called(body)
+	if kit, ok := args.Get(0).(*dto.RecoveryKitDTO); ok {
+		return kit, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) Recover(ctx context.Context, body dto.RecoverAccountDTO) (*dto.GeneratedJwt, error) {
+	args := m.Called(body)
+	if jwt, ok := args.Get(0).(*dto.GeneratedJwt); ok {
+		return jwt, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) Refresh(ctx context.Context, refreshToken string) (*dto.GeneratedJwt, error) {
+	args := m.Called(refreshToken)
+	if jwt, ok := args.Get(0).(*dto.GeneratedJwt); ok {
+		return jwt, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) Logout(ctx context.Context, userID int64, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LoginTwoFactor(ctx context.Context, body dto.TwoFactorLoginDTO) (*dto.GeneratedJwt, error) {
+	args := m.Called(body)
+	if jwt, ok := args.Get(0).(*dto.GeneratedJwt); ok {
+		return jwt, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) EnrollTOTP(ctx context.Context, userID int64) (*dto.TOTPEnrollmentDTO, error) {
+	args := m.Called(userID)
+	if enrollment, ok := args.Get(0).(*dto.TOTPEnrollmentDTO); ok {
+		return enrollment, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) ConfirmTOTP(ctx context.Context, body dto.TOTPCodeDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+func (m *MockUserService) DisableTOTP(ctx context.Context, body dto.DisableTwoFactorDTO) error {
+	args := m.Called(body)
+	return args.Error(0)
+}
+
+// testDevice is the device httptest requests are made from.
+var testDevice = dto.DeviceDTO{IP: "192.0.2.1"}
+
+// withDevice returns the user data a handler passes on for a request from testDevice.
+func withDevice(userData dto.UserDTO) dto.UserDTO {
+	userData.DeviceDTO = testDevice
+	return userData
+}
+
 func TestUserHandler_Registration_Success(t *testing.T) {
 	mockService := new(MockUserService)
 	logger := zap.NewNop()
@@ -46,12 +117,14 @@ func TestUserHandler_Registration_Success(t *testing.T) {
 	}
 
 	mockJWT := &dto.GeneratedJwt{
-		AccessToken:  "access-token",
-		RefreshToken: "refresh-token",
-		Hash:         "hash-value",
+		AccessToken:   "access-token",
+		RefreshToken:  "refresh-token",
+		Hash:          "hash-value",
+		RecoveryKey:   "AAAA-BBBB",
+		RecoveryCodes: []string{"CODE-ONE", "CODE-TWO"},
 	}
 
-	mockService.On("Registration", userData).Return(mockJWT, nil)
+	mockService.On("Registration", withDevice(userData)).Return(mockJWT, nil)
 
 	body, _ := json.Marshal(userData)
 	req := httptest.NewRequest(http.MethodPost, "/api/user/register", bytes.NewReader(body))
@@ -62,6 +135,8 @@ func TestUserHandler_Registration_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), `"hash":"hash-value"`)
+	assert.Contains(t, rec.Body.String(), `"recovery_key":"AAAA-BBBB"`)
+	assert.Contains(t, rec.Body.String(), `"recovery_codes":["CODE-ONE","CODE-TWO"]`)
 }
 
 func TestUserHandler_Registration_EmptyUsername(t *testing.T) {
@@ -116,7 +191,7 @@ func TestUserHandler_Registration_UserExists(t *testing.T) {
 		Password: "password123",
 	}
 
-	mockService.On("Registration", userData).Return(nil, apperrors.ErrUserAlreadyExists)
+	mockService.On("Registration", withDevice(userData)).Return(nil, apperrors.ErrUserAlreadyExists)
 
 	body, _ := json.Marshal(userData)
 	req := httptest.NewRequest(http.MethodPost, "/api/user/register", bytes.NewReader(body))
@@ -129,6 +204,35 @@ func TestUserHandler_Registration_UserExists(t *testing.T) {
 	assert.Contains(t, rec.Body.String(), apperrors.ErrUserAlreadyExists.Error())
 }
 
+func TestUserHandler_Registration_WeakPassword(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	userData := dto.UserDTO{Username: "testuser", Password: "qwerty"}
+	mockService.On("Registration", withDevice(userData)).Return(nil, &apperrors.PasswordPolicyError{
+		Failures: []apperrors.PasswordRuleFailure{
+			{Rule: "min_length", Message: "must be at least 12 characters long"},
+			{Rule: "common_password", Message: "is one of the most common passwords"},
+		},
+	})
+
+	body, _ := json.Marshal(userData)
+	req := httptest.NewRequest(http.MethodPost, "/api/user/register", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.Registration(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response dto.PasswordPolicyViolationDTO
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, apperrors.ErrWeakPassword.Error(), response.Error)
+	assert.Equal(t, []dto.PasswordRuleDTO{
+		{Rule: "min_length", Message: "must be at least 12 characters long"},
+		{Rule: "common_password", Message: "is one of the most common passwords"},
+	}, response.FailedRules)
+}
+
 func TestUserHandler_Registration_DBError(t *testing.T) {
 	mockService := new(MockUserService)
 	logger := zap.NewNop()
@@ -139,7 +243,7 @@ func TestUserHandler_Registration_DBError(t *testing.T) {
 		Password: "password123",
 	}
 
-	mockService.On("Registration", userData).Return(nil, apperrors.ErrDBQuery)
+	mockService.On("Registration", withDevice(userData)).Return(nil, apperrors.ErrDBQuery)
 
 	body, _ := json.Marshal(userData)
 	req := httptest.NewRequest(http.MethodPost, "/api/user/register", bytes.NewReader(body))
@@ -168,7 +272,7 @@ func TestUserHandler_Login_Success(t *testing.T) {
 		Hash:         "hash-value",
 	}
 
-	mockService.On("Login", userData).Return(mockJWT, nil)
+	mockService.On("Login", withDevice(userData)).Return(mockJWT, nil)
 
 	body, _ := json.Marshal(userData)
 	req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewReader(body))
@@ -190,7 +294,7 @@ func TestUserHandler_Login_InvalidPassword(t *testing.T) {
 		Password: "wrongpassword",
 	}
 
-	mockService.On("Login", userData).Return(nil, apperrors.ErrInvalidPassword)
+	mockService.On("Login", withDevice(userData)).Return(nil, apperrors.ErrInvalidPassword)
 
 	body, _ := json.Marshal(userData)
 	req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewReader(body))
@@ -203,6 +307,23 @@ func TestUserHandler_Login_InvalidPassword(t *testing.T) {
 	assert.Contains(t, rec.Body.String(), "unauthorized")
 }
 
+func TestUserHandler_Login_Locked(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	userData := dto.UserDTO{Username: "testuser", Password: "password123"}
+	mockService.On("Login", withDevice(userData)).Return(nil, &apperrors.LoginLockedError{RetryAfter: 1500 * time.Millisecond})
+
+	body, _ := json.Marshal(userData)
+	req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	handler.Login(rec, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+}
+
 func TestUserHandler_Login_UserNotFound(t *testing.T) {
 	mockService := new(MockUserService)
 	logger := zap.NewNop()
@@ -213,7 +334,7 @@ func TestUserHandler_Login_UserNotFound(t *testing.T) {
 		Password: "password123",
 	}
 
-	mockService.On("Login", userData).Return(nil, apperrors.ErrUserAlreadyExists)
+	mockService.On("Login", withDevice(userData)).Return(nil, apperrors.ErrUserAlreadyExists)
 
 	body, _ := json.Marshal(userData)
 	req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewReader(body))
@@ -225,3 +346,293 @@ func TestUserHandler_Login_UserNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Contains(t, rec.Body.String(), "user not found")
 }
+
+func TestUserHandler_ChangePassword_Success(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("ChangePassword", dto.ChangePasswordDTO{
+		UserID:      1,
+		SessionID:   "phone",
+		OldPassword: "old-password",
+		NewPassword: "new-password",
+	}).Return("k1.dek", nil)
+
+	body := `{"old_password":"old-password","new_password":"new-password"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/user/password", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1))
+	req = req.WithContext(context.WithValue(ctx, middleware.SessionIDContextKey, "phone"))
+	rec := httptest.NewRecorder()
+
+	handler.ChangePassword(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"hash":"k1.dek"`)
+
+	var keyCookie *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "key" {
+			keyCookie = cookie
+		}
+	}
+	if assert.NotNil(t, keyCookie, "Key cookie should be set") {
+		assert.Equal(t, "k1.dek", keyCookie.Value)
+	}
+}
+
+func TestUserHandler_ChangePassword_WrongOldPassword(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("ChangePassword", mock.Anything).Return("", apperrors.ErrInvalidPassword)
+
+	body := `{"old_password":"wrong","new_password":"new-password"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/user/password", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1))
+	req = req.WithContext(context.WithValue(ctx, middleware.SessionIDContextKey, "phone"))
+	rec := httptest.NewRecorder()
+
+	handler.ChangePassword(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestUserHandler_ChangePassword_EmptyPassword(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	body := `{"old_password":"old-password","new_password":""}`
+	req := httptest.NewRequest(http.MethodPut, "/api/user/password", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1))
+	req = req.WithContext(context.WithValue(ctx, middleware.SessionIDContextKey, "phone"))
+	rec := httptest.NewRecorder()
+
+	handler.ChangePassword(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "ChangePassword", mock.Anything)
+}
+
+func TestUserHandler_ChangePassword_Unauthorized(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	body := `{"old_password":"old-password","new_password":"new-password"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/user/password", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	handler.ChangePassword(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestUserHandler_Recover_Success(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Recover", dto.RecoverAccountDTO{
+		Username:    "alice",
+		RecoveryKey: "OLD-KEY",
+		NewPassword: "new-password",
+		DeviceDTO:   testDevice,
+	}).Return(&dto.GeneratedJwt{AccessToken: "access-token", RefreshToken: "refresh-token", Hash: "k1.dek", RecoveryKey: "NEW-KEY"}, nil)
+
+	body := `{"username":"alice","recovery_key":"OLD-KEY","new_password":"new-password"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/user/recover", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	handler.Recover(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"recovery_key":"NEW-KEY"`)
+	assert.Len(t, rec.Result().Cookies(), 3, "The user should be signed in")
+}
+
+func TestUserHandler_Recover_InvalidRecoveryKey(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Recover", mock.Anything).Return(nil, apperrors.ErrInvalidRecoveryKey)
+
+	body := `{"username":"alice","recovery_key":"WRONG","new_password":"new-password"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/user/recover", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	handler.Recover(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+}
+
+func TestUserHandler_Recover_EmptyFields(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	body := `{"username":"alice","recovery_key":"","new_password":"new-password"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/user/recover", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	handler.Recover(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "Recover", mock.Anything)
+}
+
+func TestUserHandler_RegenerateRecovery(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("RegenerateRecovery", dto.RegenerateRecoveryDTO{UserID: 1, Password: "password123"}).
+		Return(&dto.RecoveryKitDTO{RecoveryKey: "NEW-KEY", RecoveryCodes: []string{"CODE-ONE"}}, nil)
+	mockService.On("RegenerateRecovery", dto.RegenerateRecoveryDTO{UserID: 1, Password: "wrong"}).
+		Return(nil, apperrors.ErrInvalidPassword)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/recovery", bytes.NewBufferString(`{"password":"password123"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
+	rec := httptest.NewRecorder()
+
+	handler.RegenerateRecovery(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var kit dto.RecoveryKitDTO
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&kit))
+	assert.Equal(t, dto.RecoveryKitDTO{RecoveryKey: "NEW-KEY", RecoveryCodes: []string{"CODE-ONE"}}, kit)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/user/recovery", bytes.NewBufferString(`{"password":"wrong"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1)))
+	rec = httptest.NewRecorder()
+
+	handler.RegenerateRecovery(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestUserHandler_Refresh_Success(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Refresh", "old-refresh").Return(&dto.GeneratedJwt{AccessToken: "new-access", RefreshToken: "new-refresh"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refreshtoken", Value: "old-refresh"})
+	rec := httptest.NewRecorder()
+
+	handler.Refresh(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	cookies := map[string]string{}
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	assert.Equal(t, map[string]string{"accesstoken": "new-access", "refreshtoken": "new-refresh"}, cookies,
+		"The vault key cookie should be left as is")
+}
+
+func TestUserHandler_Refresh_Unauthorized(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Refresh", "reused").Return(nil, apperrors.ErrRefreshTokenReused)
+	mockService.On("Refresh", "invalid").Return(nil, apperrors.ErrInvalidRefreshToken)
+
+	for _, token := range []string{"reused", "invalid"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "refreshtoken", Value: token})
+		rec := httptest.NewRecorder()
+
+		handler.Refresh(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Refresh(rec, httptest.NewRequest(http.MethodPost, "/api/user/refresh", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestUserHandler_Refresh_TokenInBody(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Refresh", "old-refresh").Return(&dto.GeneratedJwt{AccessToken: "new-access", RefreshToken: "new-refresh"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/refresh?return_tokens=true", bytes.NewBufferString(`{"refresh_token":"old-refresh"}`))
+	rec := httptest.NewRecorder()
+
+	handler.Refresh(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response map[string]any
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "new-access", response["access_token"])
+	assert.Equal(t, "new-refresh", response["refresh_token"])
+	assert.Equal(t, "Bearer", response["token_type"])
+}
+
+func TestUserHandler_Login_ReturnTokens(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Login", mock.Anything).Return(&dto.GeneratedJwt{AccessToken: "access", RefreshToken: "refresh", Hash: "hash-value"}, nil)
+
+	body := `{"username":"testuser","password":"password123"}`
+
+	rec := httptest.NewRecorder()
+	handler.Login(rec, httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String(), "Tokens should only be returned on request")
+
+	rec = httptest.NewRecorder()
+	handler.Login(rec, httptest.NewRequest(http.MethodPost, "/api/user/login?return_tokens=true", bytes.NewBufferString(body)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response map[string]any
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "access", response["access_token"])
+	assert.Equal(t, "refresh", response["refresh_token"])
+	assert.Equal(t, "hash-value", response["hash"])
+	assert.NotEmpty(t, rec.Result().Cookies(), "Cookies should still be set")
+}
+
+func TestUserHandler_Login_PassesDevice(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Login", dto.UserDTO{
+		Username:  "testuser",
+		Password:  "password123",
+		DeviceDTO: dto.DeviceDTO{DeviceName: "laptop", UserAgent: "gophkeeper-cli/1.0", IP: "198.51.100.7"},
+	}).Return(&dto.GeneratedJwt{Hash: "hash-value"}, nil)
+
+	body := `{"username":"testuser","password":"password123","device_name":"laptop"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/user/login", bytes.NewBufferString(body))
+	req.Header.Set("User-Agent", "gophkeeper-cli/1.0")
+	req.RemoteAddr = "198.51.100.7:52100"
+	rec := httptest.NewRecorder()
+
+	handler.Login(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_Logout(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService, zap.NewNop())
+
+	mockService.On("Logout", int64(1), "session").Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/logout", nil)
+	ctx := context.WithValue(req.Context(), middleware.UserIDContextKey, int64(1))
+	ctx = context.WithValue(ctx, middleware.SessionIDContextKey, "session")
+	rec := httptest.NewRecorder()
+
+	handler.Logout(rec, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	for _, cookie := range rec.Result().Cookies() {
+		assert.Empty(t, cookie.Value)
+		assert.Negative(t, cookie.MaxAge, "The %s cookie should be deleted", cookie.Name)
+	}
+	assert.Len(t, rec.Result().Cookies(), 3)
+	mockService.AssertExpectations(t)
+}
@@ -2,13 +2,24 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/config"
 	"github.com/Zrossiz/gophkeeper/internal/utils"
+	"github.com/Zrossiz/gophkeeper/pkg/ratelimit"
+	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
 )
@@ -22,30 +33,55 @@ const (
 
 	// UserNameContextKey is the context key for storing the username.
 	UserNameContextKey contextKey = "userName"
+
+	// SessionIDContextKey is the context key for storing the ID of the session of the access token.
+	SessionIDContextKey contextKey = "sessionID"
 )
 
 // Middleware provides middleware functions for handling authentication and request processing.
 type Middleware struct {
-	cfg config.Config // Application configuration settings.
-	log *zap.Logger   // Logger instance for logging events and errors.
+	cfg      config.Config    // Application configuration settings.
+	sessions SessionValidator // Validates the sessions of access tokens.
+	limiter  RateLimiter      // Token buckets of the authentication rate limits; nil disables them.
+	log      *zap.Logger      // Logger instance for logging events and errors.
+}
+
+// SessionValidator defines the check that the session of an access token is still active.
+type SessionValidator interface {
+	// Validate returns apperrors.ErrSessionNotFound if the session was logged out, revoked or has expired.
+	Validate(ctx context.Context, userID int64, sessionID string) error
 }
 
+// RateLimiter defines the token buckets of the authentication rate limits. ratelimit.MemoryStore
+// implements it for a single server; servers behind a load balancer need a store they share.
+type RateLimiter interface {
+	// Take takes a token from the bucket of a key and returns 0, or else how long until a token is available.
+	Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (time.Duration, error)
+}
+
+// maxUsernameBody is the size of the request body read to find the username of a request for its rate limit.
+const maxUsernameBody = 64 << 10
+
 // New creates a new Middleware instance.
 //
 // Parameters:
 //   - cfg config.Config: The application configuration.
+//   - sessions SessionValidator: Validates the session of every access token.
+//   - limiter RateLimiter: Optional store of the authentication rate limits; nil disables them.
 //   - log *zap.Logger: Logger for structured logging.
 //
 // Returns:
 //   - *Middleware: A pointer to the initialized Middleware struct.
-func New(cfg config.Config, log *zap.Logger) *Middleware {
-	return &Middleware{cfg: cfg, log: log}
+func New(cfg config.Config, sessions SessionValidator, limiter RateLimiter, log *zap.Logger) *Middleware {
+	return &Middleware{cfg: cfg, sessions: sessions, limiter: limiter, log: log}
 }
 
-// Auth is an HTTP middleware that validates JWT tokens from request cookies.
+// Auth is an HTTP middleware that validates JWT access tokens.
 //
-// It extracts the "accesstoken" cookie, parses the JWT token, and validates its signature
-// and expiration time. If the token is valid, the user ID and username are stored in the request context.
+// It takes the token from the "Authorization: Bearer" header, used by non-browser clients, or else
+// from the "accesstoken" cookie, parses the JWT token, and validates its signature
+// and expiration time and that its session, identified by the jti claim, is still active.
+// If the token is valid, the user ID, username and session ID are stored in the request context.
 //
 // Parameters:
 //   - next http.Handler: The next handler to call after authentication.
@@ -54,15 +90,14 @@ func New(cfg config.Config, log *zap.Logger) *Middleware {
 //   - http.Handler: A handler that performs authentication before calling the next handler.
 func (m *Middleware) Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Retrieve the access token from cookies.
-		cookie, err := r.Cookie("accesstoken")
+		// Retrieve the access token from the Authorization header or cookies.
+		tokenStr, err := accessToken(r)
 		if err != nil {
-			m.log.Warn("No access token cookie", zap.Error(err))
+			m.log.Warn("No access token", zap.Error(err))
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		tokenStr := cookie.Value
 		secretKey := []byte(m.cfg.AccessSecret)
 
 		// Parse and validate the JWT token.
@@ -93,12 +128,193 @@ func (m *Middleware) Auth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Check that the session was not logged out or revoked.
+		if claims.ID == "" {
+			m.log.Warn("Token without session", zap.Int64("userID", claims.UserID))
+			http.Error(w, "unauthorized: invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		if err := m.sessions.Validate(r.Context(), claims.UserID, claims.ID); err != nil {
+			if errors.Is(err, apperrors.ErrSessionNotFound) {
+				m.log.Warn("Session revoked", zap.Int64("userID", claims.UserID), zap.String("sessionID", claims.ID))
+				http.Error(w, "unauthorized: session revoked", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, apperrors.ErrInternalServer, http.StatusInternalServerError)
+			return
+		}
+
 		m.log.Info("Token is valid", zap.Int64("userID", claims.UserID), zap.String("username", claims.Username))
 
 		// Store user details in request context.
 		ctx := context.WithValue(r.Context(), UserIDContextKey, claims.UserID)
 		ctx = context.WithValue(ctx, UserNameContextKey, claims.Username)
+		ctx = context.WithValue(ctx, SessionIDContextKey, claims.ID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// accessToken returns the access token of a request: the bearer token of the Authorization header
+// if there is one, or else the value of the "accesstoken" cookie.
+//
+// Returns:
+//   - An error if the Authorization header is not a bearer token or there is no access token at all.
+func accessToken(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return "", errors.New("authorization header is not a bearer token")
+		}
+		return token, nil
+	}
+
+	cookie, err := r.Cookie("accesstoken")
+	if err != nil {
+		return "", err
+	}
+
+	return cookie.Value, nil
+}
+
+// Admin is an HTTP middleware restricting a route to the administrators listed in
+// config.Config.AdminUserIDs. It must be chained after Auth, which stores the user ID in the context.
+//
+// Parameters:
+//   - next http.Handler: The next handler to call for administrators.
+//
+// Returns:
+//   - http.Handler: A handler answering 401 without an authenticated user and 403 for other users.
+func (m *Middleware) Admin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(UserIDContextKey).(int64)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		for _, adminID := range m.cfg.AdminUserIDs {
+			if adminID == userID {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		m.log.Warn("Admin access denied", zap.Int64("userID", userID))
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+}
+
+// Self is an HTTP middleware restricting a route with a {userID} URL parameter to that user, so
+// that nobody can read another user's vault. It must be chained after Auth, which stores the user
+// ID in the context.
+//
+// Parameters:
+//   - next http.Handler: The next handler to call when the user ID is the authenticated user's.
+//
+// Returns:
+//   - http.Handler: A handler answering 401 without an authenticated user, 400 for an invalid user ID
+//     and 403 for another user's ID.
+func (m *Middleware) Self(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(UserIDContextKey).(int64)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		requestedID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+
+		if requestedID != userID {
+			m.log.Warn("Access to another user denied", zap.Int64("userID", userID), zap.Int64("requestedID", requestedID))
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimit is an HTTP middleware throttling unauthenticated authentication requests, such as logins,
+// registrations, token refreshes and account recoveries, against password and token guessing. Every request takes a token from the bucket of its
+// client IP and, if its JSON body has a "username" field, from the bucket of that username, so that
+// one username cannot be attacked from many addresses either. The limits are configured by
+// config.Config.AuthIPBurst, AuthIPInterval, AuthUserBurst and AuthUserInterval.
+//
+// Parameters:
+//   - next http.Handler: The next handler to call for requests within the limits.
+//
+// Returns:
+//   - http.Handler: A handler answering 429 with a Retry-After header to requests over a limit.
+func (m *Middleware) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		keys := []string{"ip:" + ip}
+		limits := []ratelimit.Limit{{Burst: m.cfg.AuthIPBurst, Interval: m.cfg.AuthIPInterval}}
+		if username := requestUsername(r); username != "" {
+			keys = append(keys, "user:"+username)
+			limits = append(limits, ratelimit.Limit{Burst: m.cfg.AuthUserBurst, Interval: m.cfg.AuthUserInterval})
+		}
+
+		now := time.Now()
+		for i, key := range keys {
+			if !limits[i].Enabled() {
+				continue
+			}
+
+			wait, err := m.limiter.Take(r.Context(), key, limits[i], now)
+			if err != nil {
+				m.log.Error("Rate limit check failed", zap.Error(err))
+				http.Error(w, apperrors.ErrInternalServer, http.StatusInternalServerError)
+				return
+			}
+
+			if wait > 0 {
+				m.log.Warn("Rate limit exceeded", zap.String("key", key), zap.String("path", r.URL.Path))
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestUsername returns the "username" field of the JSON body of a request, or "" if there is
+// none. The body is left intact for the next handler.
+func requestUsername(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxUsernameBody))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return ""
+	}
+
+	var fields struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+
+	return fields.Username
+}
@@ -1,13 +1,20 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
 	"github.com/Zrossiz/gophkeeper/internal/config"
 	"github.com/Zrossiz/gophkeeper/internal/utils"
+	"github.com/Zrossiz/gophkeeper/pkg/ratelimit"
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -18,13 +25,26 @@ func generateValidToken(secret string, userID int64, username string, duration t
 		Exprires: time.Now().Add(duration),
 		UserID:   userID,
 		Username: username,
+		ID:       "session",
 	})
 }
 
+// stubSessions is a SessionValidator accepting the sessions it holds.
+type stubSessions map[string]error
+
+func (s stubSessions) Validate(ctx context.Context, userID int64, sessionID string) error {
+	if err, ok := s[sessionID]; ok {
+		return err
+	}
+	return apperrors.ErrSessionNotFound
+}
+
+var activeSession = stubSessions{"session": nil}
+
 func TestAuthMiddleware_ValidToken(t *testing.T) {
 	cfg := config.Config{AccessSecret: "testsecret"}
 	logger := zap.NewNop()
-	middleware := New(cfg, logger)
+	middleware := New(cfg, activeSession, nil, logger)
 
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := r.Context().Value(UserIDContextKey).(int64)
@@ -35,6 +55,10 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 		assert.True(t, ok, "Username not found in context")
 		assert.Equal(t, "testuser", username)
 
+		sessionID, ok := r.Context().Value(SessionIDContextKey).(string)
+		assert.True(t, ok, "Session ID not found in context")
+		assert.Equal(t, "session", sessionID)
+
 		w.WriteHeader(http.StatusOK)
 	})
 
@@ -53,7 +77,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 func TestAuthMiddleware_NoToken(t *testing.T) {
 	cfg := config.Config{AccessSecret: "testsecret"}
 	logger := zap.NewNop()
-	middleware := New(cfg, logger)
+	middleware := New(cfg, activeSession, nil, logger)
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "http://localhost/", nil)
@@ -67,7 +91,7 @@ func TestAuthMiddleware_NoToken(t *testing.T) {
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	cfg := config.Config{AccessSecret: "testsecret"}
 	logger := zap.NewNop()
-	middleware := New(cfg, logger)
+	middleware := New(cfg, activeSession, nil, logger)
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "http://localhost/", nil)
@@ -82,7 +106,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 func TestAuthMiddleware_ExpiredToken(t *testing.T) {
 	cfg := config.Config{AccessSecret: "testsecret"}
 	logger := zap.NewNop()
-	middleware := New(cfg, logger)
+	middleware := New(cfg, activeSession, nil, logger)
 
 	expiredToken, err := generateValidToken(cfg.AccessSecret, 1, "testuser", -1*time.Hour)
 	assert.NoError(t, err)
@@ -96,3 +120,186 @@ func TestAuthMiddleware_ExpiredToken(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
 	assert.Contains(t, rec.Body.String(), "unauthorized: invalid token")
 }
+
+func TestAuthMiddleware_Sessions(t *testing.T) {
+	cfg := config.Config{AccessSecret: "testsecret"}
+	sessions := stubSessions{"active": nil, "broken": errors.New("connection reset")}
+	middleware := New(cfg, sessions, nil, zap.NewNop())
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for name, tc := range map[string]struct {
+		sessionID string
+		code      int
+	}{
+		"active session":  {"active", http.StatusOK},
+		"revoked session": {"revoked", http.StatusUnauthorized},
+		"no session":      {"", http.StatusUnauthorized},
+		"check failure":   {"broken", http.StatusInternalServerError},
+	} {
+		t.Run(name, func(t *testing.T) {
+			token, err := utils.GenerateJWT(utils.GenerateJWTProps{
+				Secret:   []byte(cfg.AccessSecret),
+				Exprires: time.Now().Add(time.Hour),
+				UserID:   1,
+				ID:       tc.sessionID,
+			})
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "http://localhost/", nil)
+			req.AddCookie(&http.Cookie{Name: "accesstoken", Value: token})
+			rec := httptest.NewRecorder()
+
+			middleware.Auth(testHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_BearerToken(t *testing.T) {
+	cfg := config.Config{AccessSecret: "testsecret"}
+	middleware := New(cfg, activeSession, nil, zap.NewNop())
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	validToken, err := generateValidToken(cfg.AccessSecret, 1, "testuser", time.Hour)
+	assert.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		header string
+		cookie string
+		code   int
+	}{
+		"bearer token":            {"Bearer " + validToken, "", http.StatusOK},
+		"lower case scheme":       {"bearer " + validToken, "", http.StatusOK},
+		"header before cookie":    {"Bearer invalid.token.here", validToken, http.StatusUnauthorized},
+		"basic credentials":       {"Basic dGVzdDp0ZXN0", "", http.StatusUnauthorized},
+		"bearer without token":    {"Bearer ", "", http.StatusUnauthorized},
+		"cookie without a header": {"", validToken, http.StatusOK},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "accesstoken", Value: tc.cookie})
+			}
+			rec := httptest.NewRecorder()
+
+			middleware.Auth(testHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	middleware := New(config.Config{AdminUserIDs: []int64{1}}, activeSession, nil, zap.NewNop())
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for name, tc := range map[string]struct {
+		ctx  context.Context
+		code int
+	}{
+		"admin":         {context.WithValue(context.Background(), UserIDContextKey, int64(1)), http.StatusOK},
+		"other user":    {context.WithValue(context.Background(), UserIDContextKey, int64(2)), http.StatusForbidden},
+		"not logged in": {context.Background(), http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost/", nil).WithContext(tc.ctx)
+			rec := httptest.NewRecorder()
+
+			middleware.Admin(testHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestSelfMiddleware(t *testing.T) {
+	middleware := New(config.Config{}, activeSession, nil, zap.NewNop())
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for name, tc := range map[string]struct {
+		loggedIn bool
+		userID   string
+		code     int
+	}{
+		"own vault":     {true, "1", http.StatusOK},
+		"other vault":   {true, "2", http.StatusForbidden},
+		"invalid id":    {true, "me", http.StatusBadRequest},
+		"not logged in": {false, "1", http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("userID", tc.userID)
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+			if tc.loggedIn {
+				ctx = context.WithValue(ctx, UserIDContextKey, int64(1))
+			}
+			req := httptest.NewRequest("GET", "http://localhost/user/"+tc.userID, nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			middleware.Self(testHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	cfg := config.Config{
+		AuthIPBurst:      2,
+		AuthIPInterval:   time.Minute,
+		AuthUserBurst:    1,
+		AuthUserInterval: time.Minute,
+	}
+	middleware := New(cfg, activeSession, ratelimit.NewMemoryStore(), zap.NewNop())
+
+	handler := middleware.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `"password"`, "The body should reach the handler intact")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	login := func(ip, username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/login",
+			strings.NewReader(`{"username":"`+username+`","password":"secret"}`))
+		req.RemoteAddr = ip + ":40000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, login("192.0.2.1", "alice").Code)
+
+	rec := login("192.0.2.2", "alice")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "A username should be limited across IPs")
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, login("192.0.2.1", "bob").Code)
+	assert.Equal(t, http.StatusTooManyRequests, login("192.0.2.1", "carol").Code, "An IP should be limited across usernames")
+	assert.Equal(t, http.StatusOK, login("192.0.2.3", "dave").Code)
+}
+
+func TestRateLimitMiddleware_Disabled(t *testing.T) {
+	middleware := New(config.Config{}, activeSession, nil, zap.NewNop())
+	handler := middleware.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for range 10 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/user/login", strings.NewReader(`{"username":"alice"}`)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,48 @@
+// Package router defines the HTTP routing structure for handling vault audit requests.
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AuditRouter provides route registration for vault audit HTTP handlers.
+type AuditRouter struct {
+	h AuditHandler // Handler for vault audit operations.
+	m Middleware   // Middleware for authentication and request processing.
+}
+
+// AuditHandler defines the interface for handling vault audit requests.
+type AuditHandler interface {
+	// Report builds a security report over a user's vault.
+	Report(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewAuditRouter initializes a new AuditRouter instance.
+//
+// Parameters:
+//   - h AuditHandler: The handler for vault audit operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *AuditRouter: A pointer to the initialized AuditRouter.
+func NewAuditRouter(h AuditHandler, m Middleware) *AuditRouter {
+	return &AuditRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for vault audit operations.
+//
+// Routes:
+//   - GET /api/audit/user/{userID} - Requires authentication as that user. Calls the Report handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (a *AuditRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/audit", func(r chi.Router) {
+		r.With(a.m.Auth, a.m.Self).Get("/user/{userID}", a.h.Report) // Build a security report for a user
+	})
+}
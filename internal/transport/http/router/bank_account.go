@@ -0,0 +1,58 @@
+// Package router defines the HTTP routing structure for handling bank account requests.
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BankAccountRouter provides route registration for bank account HTTP handlers.
+type BankAccountRouter struct {
+	h BankAccountHandler // Handler for bank account operations.
+	m Middleware         // Middleware for authentication and request processing.
+}
+
+// BankAccountHandler defines the interface for handling bank account requests.
+type BankAccountHandler interface {
+	// GetAll retrieves all stored bank accounts for a specific user.
+	GetAll(rw http.ResponseWriter, r *http.Request)
+
+	// Update modifies an existing bank account entry.
+	Update(rw http.ResponseWriter, r *http.Request)
+
+	// Create adds a new bank account entry to the storage.
+	Create(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewBankAccountRouter initializes a new BankAccountRouter instance.
+//
+// Parameters:
+//   - h BankAccountHandler: The handler for bank account operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *BankAccountRouter: A pointer to the initialized BankAccountRouter.
+func NewBankAccountRouter(h BankAccountHandler, m Middleware) *BankAccountRouter {
+	return &BankAccountRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for bank account operations.
+//
+// Routes:
+//   - POST /api/bank-account/ - Requires authentication. Calls the Create handler.
+//   - GET /api/bank-account/user/{userID} - Requires authentication as that user. Calls the GetAll handler.
+//   - PUT /api/bank-account/{accountID} - Requires authentication. Calls the Update handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (b *BankAccountRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/bank-account", func(r chi.Router) {
+		r.With(b.m.Auth).Post("/", b.h.Create)                       // Create a new bank account entry
+		r.With(b.m.Auth, b.m.Self).Get("/user/{userID}", b.h.GetAll) // Get all bank accounts for a user
+		r.With(b.m.Auth).Put("/{accountID}", b.h.Update)             // Update an existing bank account entry
+	})
+}
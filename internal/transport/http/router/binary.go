@@ -41,13 +41,13 @@ func NewBinaryRouter(h BinaryHandler, m Middleware) *BinaryRouter {
 //
 // Routes:
 //   - POST /api/binary/ - Requires authentication. Calls the Create handler.
-//   - GET /api/binary/user/{userID} - Requires authentication. Calls the GetAll handler.
+//   - GET /api/binary/user/{userID} - Requires authentication as that user. Calls the GetAll handler.
 //
 // Parameters:
 //   - r chi.Router: The router where the routes will be registered.
 func (b *BinaryRouter) RegisterRoutes(r chi.Router) {
 	r.Route("/api/binary", func(r chi.Router) {
-		r.With(b.m.Auth).Post("/", b.h.Create)             // Create binary data
-		r.With(b.m.Auth).Get("/user/{userID}", b.h.GetAll) // Get all binary data for a user
+		r.With(b.m.Auth).Post("/", b.h.Create)                       // Create binary data
+		r.With(b.m.Auth, b.m.Self).Get("/user/{userID}", b.h.GetAll) // Get all binary data for a user
 	})
 }
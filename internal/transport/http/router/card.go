@@ -23,6 +23,9 @@ type CardHandler interface {
 
 	// Create adds a new card entry to the storage.
 	Create(rw http.ResponseWriter, r *http.Request)
+
+	// Reveal returns a single card with its full number and CVV.
+	Reveal(rw http.ResponseWriter, r *http.Request)
 }
 
 // NewCardRouter initializes a new CardRouter instance.
@@ -44,15 +47,17 @@ func NewCardRouter(h CardHandler, m Middleware) *CardRouter {
 //
 // Routes:
 //   - POST /api/card/ - Requires authentication. Calls the Create handler.
-//   - GET /api/card/user/{userID} - Requires authentication. Calls the GetAll handler.
+//   - GET /api/card/user/{userID} - Requires authentication as that user. Calls the GetAll handler.
 //   - PUT /api/card/{cardID} - Requires authentication. Calls the Update handler.
+//   - GET /api/card/{cardID}/reveal - Requires authentication. Calls the Reveal handler.
 //
 // Parameters:
 //   - r chi.Router: The router where the routes will be registered.
 func (c *CardRouter) RegisterRoutes(r chi.Router) {
 	r.Route("/api/card", func(r chi.Router) {
-		r.With(c.m.Auth).Post("/", c.h.Create)             // Create a new card entry
-		r.With(c.m.Auth).Get("/user/{userID}", c.h.GetAll) // Get all cards for a user
-		r.With(c.m.Auth).Put("/{cardID}", c.h.Update)      // Update an existing card entry
+		r.With(c.m.Auth).Post("/", c.h.Create)                       // Create a new card entry
+		r.With(c.m.Auth, c.m.Self).Get("/user/{userID}", c.h.GetAll) // Get all cards for a user
+		r.With(c.m.Auth).Put("/{cardID}", c.h.Update)                // Update an existing card entry
+		r.With(c.m.Auth).Get("/{cardID}/reveal", c.h.Reveal)         // Reveal full card details
 	})
 }
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// EmergencyRouter provides route registration for emergency access HTTP handlers.
+type EmergencyRouter struct {
+	h EmergencyHandler // Handler for emergency access operations.
+	m Middleware       // Middleware for authentication and request processing.
+}
+
+// EmergencyHandler defines the interface for handling emergency access requests.
+type EmergencyHandler interface {
+	// Invite designates a user as an emergency contact of the authenticated user.
+	Invite(rw http.ResponseWriter, r *http.Request)
+
+	// ListContacts lists the emergency contacts of the authenticated user.
+	ListContacts(rw http.ResponseWriter, r *http.Request)
+
+	// Delete deletes an emergency contact.
+	Delete(rw http.ResponseWriter, r *http.Request)
+
+	// Approve grants a requested emergency access before its wait period ends.
+	Approve(rw http.ResponseWriter, r *http.Request)
+
+	// Reject rejects a requested emergency access or revokes an approved one.
+	Reject(rw http.ResponseWriter, r *http.Request)
+
+	// ListGrantors lists the emergency accesses the authenticated user was designated for.
+	ListGrantors(rw http.ResponseWriter, r *http.Request)
+
+	// Request requests emergency access to a vault.
+	Request(rw http.ResponseWriter, r *http.Request)
+
+	// View returns the decrypted vault of an approved emergency access.
+	View(rw http.ResponseWriter, r *http.Request)
+
+	// Takeover sets a new master password for the account of an approved takeover access.
+	Takeover(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewEmergencyRouter initializes a new EmergencyRouter instance.
+//
+// Parameters:
+//   - h EmergencyHandler: The handler for emergency access operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *EmergencyRouter: A pointer to the initialized EmergencyRouter.
+func NewEmergencyRouter(h EmergencyHandler, m Middleware) *EmergencyRouter {
+	return &EmergencyRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for emergency access. Every route requires authentication;
+// /contacts routes act on the authenticated user's own emergency contacts, /granted routes on the
+// vaults the user is an emergency contact of.
+//
+// Routes:
+//   - POST /api/emergency/contacts - Calls the Invite handler.
+//   - GET /api/emergency/contacts - Calls the ListContacts handler.
+//   - DELETE /api/emergency/contacts/{id} - Calls the Delete handler.
+//   - POST /api/emergency/contacts/{id}/approve - Calls the Approve handler.
+//   - POST /api/emergency/contacts/{id}/reject - Calls the Reject handler.
+//   - GET /api/emergency/granted - Calls the ListGrantors handler.
+//   - POST /api/emergency/granted/{id}/request - Calls the Request handler.
+//   - GET /api/emergency/granted/{id}/vault - Calls the View handler.
+//   - POST /api/emergency/granted/{id}/takeover - Calls the Takeover handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (e *EmergencyRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/emergency", func(r chi.Router) {
+		r.With(e.m.Auth).Post("/contacts", e.h.Invite)                // Designate an emergency contact
+		r.With(e.m.Auth).Get("/contacts", e.h.ListContacts)           // List own emergency contacts
+		r.With(e.m.Auth).Delete("/contacts/{id}", e.h.Delete)         // Delete an emergency contact
+		r.With(e.m.Auth).Post("/contacts/{id}/approve", e.h.Approve)  // Approve an access request
+		r.With(e.m.Auth).Post("/contacts/{id}/reject", e.h.Reject)    // Reject or revoke access
+		r.With(e.m.Auth).Get("/granted", e.h.ListGrantors)            // List vaults granted to the user
+		r.With(e.m.Auth).Post("/granted/{id}/request", e.h.Request)   // Request access
+		r.With(e.m.Auth).Get("/granted/{id}/vault", e.h.View)         // View the granted vault
+		r.With(e.m.Auth).Post("/granted/{id}/takeover", e.h.Takeover) // Take over the account
+	})
+}
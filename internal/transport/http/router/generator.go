@@ -0,0 +1,53 @@
+// Package router defines the HTTP routing structure for handling password generation requests.
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GeneratorRouter provides route registration for password generation HTTP handlers.
+type GeneratorRouter struct {
+	h GeneratorHandler // Handler for password generation operations.
+	m Middleware       // Middleware for authentication and request processing.
+}
+
+// GeneratorHandler defines the interface for handling password generation requests.
+type GeneratorHandler interface {
+	// Password generates a random password according to a policy.
+	Password(rw http.ResponseWriter, r *http.Request)
+
+	// Passphrase generates a diceware-style passphrase.
+	Passphrase(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewGeneratorRouter initializes a new GeneratorRouter instance.
+//
+// Parameters:
+//   - h GeneratorHandler: The handler for password generation operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *GeneratorRouter: A pointer to the initialized GeneratorRouter.
+func NewGeneratorRouter(h GeneratorHandler, m Middleware) *GeneratorRouter {
+	return &GeneratorRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for password generation operations.
+//
+// Routes:
+//   - POST /api/generator/password - Requires authentication. Calls the Password handler.
+//   - POST /api/generator/passphrase - Requires authentication. Calls the Passphrase handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (g *GeneratorRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/generator", func(r chi.Router) {
+		r.With(g.m.Auth).Post("/password", g.h.Password)     // Generate a password
+		r.With(g.m.Auth).Post("/passphrase", g.h.Passphrase) // Generate a passphrase
+	})
+}
@@ -0,0 +1,58 @@
+// Package router defines the HTTP routing structure for handling identity document requests.
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// IdentityDocumentRouter provides route registration for identity document HTTP handlers.
+type IdentityDocumentRouter struct {
+	h IdentityDocumentHandler // Handler for identity document operations.
+	m Middleware              // Middleware for authentication and request processing.
+}
+
+// IdentityDocumentHandler defines the interface for handling identity document requests.
+type IdentityDocumentHandler interface {
+	// GetAll retrieves all stored identity documents for a specific user.
+	GetAll(rw http.ResponseWriter, r *http.Request)
+
+	// Update modifies an existing identity document entry.
+	Update(rw http.ResponseWriter, r *http.Request)
+
+	// Create adds a new identity document entry to the storage.
+	Create(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewIdentityDocumentRouter initializes a new IdentityDocumentRouter instance.
+//
+// Parameters:
+//   - h IdentityDocumentHandler: The handler for identity document operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *IdentityDocumentRouter: A pointer to the initialized IdentityDocumentRouter.
+func NewIdentityDocumentRouter(h IdentityDocumentHandler, m Middleware) *IdentityDocumentRouter {
+	return &IdentityDocumentRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for identity document operations.
+//
+// Routes:
+//   - POST /api/identity-document/ - Requires authentication. Calls the Create handler.
+//   - GET /api/identity-document/user/{userID} - Requires authentication as that user. Calls the GetAll handler.
+//   - PUT /api/identity-document/{documentID} - Requires authentication. Calls the Update handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (i *IdentityDocumentRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/identity-document", func(r chi.Router) {
+		r.With(i.m.Auth).Post("/", i.h.Create)                       // Create a new identity document entry
+		r.With(i.m.Auth, i.m.Self).Get("/user/{userID}", i.h.GetAll) // Get all identity documents for a user
+		r.With(i.m.Auth).Put("/{documentID}", i.h.Update)            // Update an existing identity document entry
+	})
+}
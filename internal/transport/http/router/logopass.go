@@ -23,6 +23,12 @@ type LogoPassHandler interface {
 
 	// Create adds a new logo-password entry to the storage.
 	Create(rw http.ResponseWriter, r *http.Request)
+
+	// CheckBreach checks a password against the local breached-password corpus.
+	CheckBreach(rw http.ResponseWriter, r *http.Request)
+
+	// Match finds the logo-password entries matching a website URL.
+	Match(rw http.ResponseWriter, r *http.Request)
 }
 
 // NewLogoPassRouter initializes a new LogoPassRouter instance.
@@ -44,15 +50,19 @@ func NewLogoPassRouter(h LogoPassHandler, m Middleware) *LogoPassRouter {
 //
 // Routes:
 //   - POST /api/logo-pass/ - Requires authentication. Calls the Create handler.
-//   - GET /api/logo-pass/user/{userID} - Requires authentication. Calls the GetAll handler.
+//   - GET /api/logo-pass/user/{userID} - Requires authentication as that user. Calls the GetAll handler.
 //   - PUT /api/logo-pass/{logoPassID} - Requires authentication. Calls the Update handler.
+//   - POST /api/logo-pass/check-breach - Requires authentication. Calls the CheckBreach handler.
+//   - GET /api/logo-pass/user/{userID}/match?url= - Requires authentication as that user. Calls the Match handler.
 //
 // Parameters:
 //   - r chi.Router: The router where the routes will be registered.
 func (c *LogoPassRouter) RegisterRoutes(r chi.Router) {
 	r.Route("/api/logo-pass", func(r chi.Router) {
-		r.With(c.m.Auth).Post("/", c.h.Create)             // Create a new logo-password entry
-		r.With(c.m.Auth).Get("/user/{userID}", c.h.GetAll) // Get all logo-passwords for a user
-		r.With(c.m.Auth).Put("/{logoPassID}", c.h.Update)  // Update an existing logo-password entry
+		r.With(c.m.Auth).Post("/", c.h.Create)                            // Create a new logo-password entry
+		r.With(c.m.Auth, c.m.Self).Get("/user/{userID}", c.h.GetAll)      // Get all logo-passwords for a user
+		r.With(c.m.Auth).Put("/{logoPassID}", c.h.Update)                 // Update an existing logo-password entry
+		r.With(c.m.Auth).Post("/check-breach", c.h.CheckBreach)           // Check a password against the breach corpus
+		r.With(c.m.Auth, c.m.Self).Get("/user/{userID}/match", c.h.Match) // Find logo-passwords for a website URL
 	})
 }
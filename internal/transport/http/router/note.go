@@ -44,15 +44,15 @@ func NewNoteRouter(h NoteHandler, m Middleware) *NoteRouter {
 //
 // Routes:
 //   - POST /api/note/ - Requires authentication. Calls the Create handler.
-//   - GET /api/note/user/{userID} - Requires authentication. Calls the GetAll handler.
+//   - GET /api/note/user/{userID} - Requires authentication as that user. Calls the GetAll handler.
 //   - PUT /api/note/{noteID} - Requires authentication. Calls the Update handler.
 //
 // Parameters:
 //   - r chi.Router: The router where the routes will be registered.
 func (n *NoteRouter) RegisterRoutes(r chi.Router) {
 	r.Route("/api/note", func(r chi.Router) {
-		r.With(n.m.Auth).Post("/", n.h.Create)             // Create a new note
-		r.With(n.m.Auth).Get("/user/{userID}", n.h.GetAll) // Get all notes for a user
-		r.With(n.m.Auth).Put("/{noteID}", n.h.Update)      // Update an existing note
+		r.With(n.m.Auth).Post("/", n.h.Create)                       // Create a new note
+		r.With(n.m.Auth, n.m.Self).Get("/user/{userID}", n.h.GetAll) // Get all notes for a user
+		r.With(n.m.Auth).Put("/{noteID}", n.h.Update)                // Update an existing note
 	})
 }
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OrgRouter provides route registration for organisation vault HTTP handlers.
+type OrgRouter struct {
+	h OrgHandler // Handler for organisation operations.
+	m Middleware // Middleware for authentication and request processing.
+}
+
+// OrgHandler defines the interface for handling organisation vault requests.
+type OrgHandler interface {
+	// Create creates an organisation owned by the authenticated user.
+	Create(rw http.ResponseWriter, r *http.Request)
+
+	// List lists the organisations of the authenticated user.
+	List(rw http.ResponseWriter, r *http.Request)
+
+	// ListMembers lists the members of an organisation.
+	ListMembers(rw http.ResponseWriter, r *http.Request)
+
+	// AddMember adds a user to an organisation.
+	AddMember(rw http.ResponseWriter, r *http.Request)
+
+	// UpdateMember changes the role of an organisation member.
+	UpdateMember(rw http.ResponseWriter, r *http.Request)
+
+	// RemoveMember removes a member from an organisation and rotates its key.
+	RemoveMember(rw http.ResponseWriter, r *http.Request)
+
+	// CreateCollection creates a collection of an organisation.
+	CreateCollection(rw http.ResponseWriter, r *http.Request)
+
+	// ListCollections lists the collections of an organisation.
+	ListCollections(rw http.ResponseWriter, r *http.Request)
+
+	// DeleteCollection deletes a collection.
+	DeleteCollection(rw http.ResponseWriter, r *http.Request)
+
+	// AddItem adds an item of the authenticated user to a collection.
+	AddItem(rw http.ResponseWriter, r *http.Request)
+
+	// ListItems lists the decrypted items of a collection.
+	ListItems(rw http.ResponseWriter, r *http.Request)
+
+	// UpdateItem changes an item of a collection.
+	UpdateItem(rw http.ResponseWriter, r *http.Request)
+
+	// RemoveItem removes an item from a collection.
+	RemoveItem(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewOrgRouter initializes a new OrgRouter instance.
+//
+// Parameters:
+//   - h OrgHandler: The handler for organisation operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *OrgRouter: A pointer to the initialized OrgRouter.
+func NewOrgRouter(h OrgHandler, m Middleware) *OrgRouter {
+	return &OrgRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for organisation vaults. Every route requires authentication;
+// the handlers check the role of the authenticated user in the organisation.
+//
+// Routes:
+//   - POST /api/orgs/ - Calls the Create handler.
+//   - GET /api/orgs/ - Calls the List handler.
+//   - GET /api/orgs/{orgID}/members - Calls the ListMembers handler.
+//   - POST /api/orgs/{orgID}/members - Calls the AddMember handler.
+//   - PUT /api/orgs/{orgID}/members/{userID} - Calls the UpdateMember handler.
+//   - DELETE /api/orgs/{orgID}/members/{userID} - Calls the RemoveMember handler.
+//   - POST /api/orgs/{orgID}/collections - Calls the CreateCollection handler.
+//   - GET /api/orgs/{orgID}/collections - Calls the ListCollections handler.
+//   - DELETE /api/collections/{collectionID} - Calls the DeleteCollection handler.
+//   - POST /api/collections/{collectionID}/items - Calls the AddItem handler.
+//   - GET /api/collections/{collectionID}/items - Calls the ListItems handler.
+//   - PUT /api/collections/{collectionID}/items/{itemType}/{itemID} - Calls the UpdateItem handler.
+//   - DELETE /api/collections/{collectionID}/items/{itemType}/{itemID} - Calls the RemoveItem handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (o *OrgRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/orgs", func(r chi.Router) {
+		r.With(o.m.Auth).Post("/", o.h.Create)                                 // Create an organisation
+		r.With(o.m.Auth).Get("/", o.h.List)                                    // List own organisations
+		r.With(o.m.Auth).Get("/{orgID}/members", o.h.ListMembers)              // List members
+		r.With(o.m.Auth).Post("/{orgID}/members", o.h.AddMember)               // Add a member
+		r.With(o.m.Auth).Put("/{orgID}/members/{userID}", o.h.UpdateMember)    // Change a member's role
+		r.With(o.m.Auth).Delete("/{orgID}/members/{userID}", o.h.RemoveMember) // Remove a member or leave
+		r.With(o.m.Auth).Post("/{orgID}/collections", o.h.CreateCollection)    // Create a collection
+		r.With(o.m.Auth).Get("/{orgID}/collections", o.h.ListCollections)      // List collections
+	})
+
+	r.Route("/api/collections", func(r chi.Router) {
+		r.With(o.m.Auth).Delete("/{collectionID}", o.h.DeleteCollection)                     // Delete a collection
+		r.With(o.m.Auth).Post("/{collectionID}/items", o.h.AddItem)                          // Add an item
+		r.With(o.m.Auth).Get("/{collectionID}/items", o.h.ListItems)                         // List decrypted items
+		r.With(o.m.Auth).Put("/{collectionID}/items/{itemType}/{itemID}", o.h.UpdateItem)    // Update an item
+		r.With(o.m.Auth).Delete("/{collectionID}/items/{itemType}/{itemID}", o.h.RemoveItem) // Remove an item
+	})
+}
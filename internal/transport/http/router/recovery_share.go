@@ -0,0 +1,73 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RecoveryShareRouter provides route registration for recovery share HTTP handlers.
+type RecoveryShareRouter struct {
+	h RecoveryShareHandler // Handler for recovery share operations.
+	m Middleware           // Middleware for authentication and request processing.
+}
+
+// RecoveryShareHandler defines the interface for handling recovery share requests.
+type RecoveryShareHandler interface {
+	// Create splits the authenticated user's recovery key among officers.
+	Create(rw http.ResponseWriter, r *http.Request)
+
+	// List lists the shares of the authenticated user's recovery key.
+	List(rw http.ResponseWriter, r *http.Request)
+
+	// ListHeld lists the recovery shares held by the authenticated user.
+	ListHeld(rw http.ResponseWriter, r *http.Request)
+
+	// StartSession starts collecting the recovery shares of an account.
+	StartSession(rw http.ResponseWriter, r *http.Request)
+
+	// Submit submits the authenticated officer's share to a recovery session.
+	Submit(rw http.ResponseWriter, r *http.Request)
+
+	// Complete resets the master password of an account with the submitted shares.
+	Complete(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewRecoveryShareRouter initializes a new RecoveryShareRouter instance.
+//
+// Parameters:
+//   - h RecoveryShareHandler: The handler for recovery share operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *RecoveryShareRouter: A pointer to the initialized RecoveryShareRouter.
+func NewRecoveryShareRouter(h RecoveryShareHandler, m Middleware) *RecoveryShareRouter {
+	return &RecoveryShareRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for recovery shares. Starting and completing a recovery session
+// is public, as the owner of the account cannot sign in; every other route requires authentication.
+//
+// Routes:
+//   - POST /api/recovery/shares - Calls the Create handler.
+//   - GET /api/recovery/shares - Calls the List handler.
+//   - GET /api/recovery/shares/held - Calls the ListHeld handler.
+//   - POST /api/recovery/sessions - Calls the StartSession handler.
+//   - POST /api/recovery/sessions/{id}/shares - Calls the Submit handler.
+//   - POST /api/recovery/sessions/{id}/complete - Calls the Complete handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (rs *RecoveryShareRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/recovery", func(r chi.Router) {
+		r.With(rs.m.Auth).Post("/shares", rs.h.Create)                        // Split the recovery key
+		r.With(rs.m.Auth).Get("/shares", rs.h.List)                           // List own shares
+		r.With(rs.m.Auth).Get("/shares/held", rs.h.ListHeld)                  // List shares held as an officer
+		r.With(rs.m.RateLimit).Post("/sessions", rs.h.StartSession)           // Start a recovery session
+		r.With(rs.m.Auth).Post("/sessions/{id}/shares", rs.h.Submit)          // Submit a share
+		r.With(rs.m.RateLimit).Post("/sessions/{id}/complete", rs.h.Complete) // Complete the recovery
+	})
+}
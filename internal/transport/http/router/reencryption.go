@@ -0,0 +1,64 @@
+// Package router defines the HTTP routing structure for handling the admin endpoints of the re-encryption job.
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ReencryptionRouter provides route registration for the re-encryption job HTTP handlers.
+type ReencryptionRouter struct {
+	h ReencryptionHandler // Handler for re-encryption job operations.
+	m Middleware          // Middleware for authentication and request processing.
+}
+
+// ReencryptionHandler defines the interface for handling re-encryption job requests.
+type ReencryptionHandler interface {
+	// Status reports the progress of the latest job.
+	Status(rw http.ResponseWriter, r *http.Request)
+
+	// Start starts or resumes the job.
+	Start(rw http.ResponseWriter, r *http.Request)
+
+	// Pause pauses the running job.
+	Pause(rw http.ResponseWriter, r *http.Request)
+
+	// Throttle changes the batch size and delay of the running job.
+	Throttle(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewReencryptionRouter initializes a new ReencryptionRouter instance.
+//
+// Parameters:
+//   - h ReencryptionHandler: The handler for re-encryption job operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *ReencryptionRouter: A pointer to the initialized ReencryptionRouter.
+func NewReencryptionRouter(h ReencryptionHandler, m Middleware) *ReencryptionRouter {
+	return &ReencryptionRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for re-encryption job operations.
+//
+// Routes:
+//   - GET /api/admin/reencryption - Requires an administrator. Calls the Status handler.
+//   - POST /api/admin/reencryption - Requires an administrator. Calls the Start handler.
+//   - POST /api/admin/reencryption/pause - Requires an administrator. Calls the Pause handler.
+//   - PUT /api/admin/reencryption/throttle - Requires an administrator. Calls the Throttle handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (re *ReencryptionRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/admin/reencryption", func(r chi.Router) {
+		r.Use(re.m.Auth, re.m.Admin)
+		r.Get("/", re.h.Status)           // Get the progress of the latest job
+		r.Post("/", re.h.Start)           // Start or resume the job
+		r.Post("/pause", re.h.Pause)      // Pause the running job
+		r.Put("/throttle", re.h.Throttle) // Change the batch size and delay
+	})
+}
@@ -10,26 +10,55 @@ import (
 
 // Router holds all the sub-routers responsible for handling different API routes.
 type Router struct {
-	Card     CardRouter     // Routes for card-related operations.
-	User     UserRouter     // Routes for user-related operations.
-	Binary   BinaryRouter   // Routes for binary data operations.
-	LogoPass LogoPassRouter // Routes for logo password operations.
-	Note     NoteRouter     // Routes for note-related operations.
+	Card             CardRouter             // Routes for card-related operations.
+	User             UserRouter             // Routes for user-related operations.
+	Binary           BinaryRouter           // Routes for binary data operations.
+	LogoPass         LogoPassRouter         // Routes for logo password operations.
+	Note             NoteRouter             // Routes for note-related operations.
+	BankAccount      BankAccountRouter      // Routes for bank account operations.
+	IdentityDocument IdentityDocumentRouter // Routes for identity document operations.
+	Generator        GeneratorRouter        // Routes for password generation.
+	Audit            AuditRouter            // Routes for vault audit reports.
+	Reencryption     ReencryptionRouter     // Routes for the re-encryption job.
+	Sharing          SharingRouter          // Routes for vault item sharing.
+	Org              OrgRouter              // Routes for organisation vaults.
+	Emergency        EmergencyRouter        // Routes for emergency access.
+	RecoveryShare    RecoveryShareRouter    // Routes for recovery shares.
+	Session          SessionRouter          // Routes for login sessions.
 }
 
 // Handler contains the handlers required for processing API requests.
 type Handler struct {
-	User     UserHandler     // Handler for user-related operations.
-	Card     CardHandler     // Handler for card-related operations.
-	Binary   BinaryHandler   // Handler for binary data operations.
-	LogoPass LogoPassHandler // Handler for logo password operations.
-	Note     NoteHandler     // Handler for note-related operations.
+	User             UserHandler             // Handler for user-related operations.
+	Card             CardHandler             // Handler for card-related operations.
+	Binary           BinaryHandler           // Handler for binary data operations.
+	LogoPass         LogoPassHandler         // Handler for logo password operations.
+	Note             NoteHandler             // Handler for note-related operations.
+	BankAccount      BankAccountHandler      // Handler for bank account operations.
+	IdentityDocument IdentityDocumentHandler // Handler for identity document operations.
+	Generator        GeneratorHandler        // Handler for password generation.
+	Audit            AuditHandler            // Handler for vault audit reports.
+	Reencryption     ReencryptionHandler     // Handler for the re-encryption job.
+	Sharing          SharingHandler          // Handler for vault item sharing.
+	Org              OrgHandler              // Handler for organisation vaults.
+	Emergency        EmergencyHandler        // Handler for emergency access.
+	RecoveryShare    RecoveryShareHandler    // Handler for recovery shares.
+	Session          SessionHandler          // Handler for login sessions.
 }
 
 // Middleware defines an interface for handling authentication middleware.
 type Middleware interface {
 	// Auth applies authentication middleware to a given HTTP handler.
 	Auth(next http.Handler) http.Handler
+
+	// Admin restricts a given HTTP handler to administrators; it must follow Auth.
+	Admin(next http.Handler) http.Handler
+
+	// Self restricts a given HTTP handler with a {userID} URL parameter to that user; it must follow Auth.
+	Self(next http.Handler) http.Handler
+
+	// RateLimit throttles a given unauthenticated HTTP handler per client IP and username.
+	RateLimit(next http.Handler) http.Handler
 }
 
 // New initializes a new HTTP router with registered routes for handling API requests.
@@ -45,11 +74,21 @@ func New(h Handler, m Middleware) *chi.Mux {
 
 	// Initialize and assign routers for different functionalities.
 	router := &Router{
-		Card:     *NewCardRouter(h.Card, m),
-		User:     *NewUserRouter(h.User),
-		Binary:   *NewBinaryRouter(h.Binary, m),
-		LogoPass: *NewLogoPassRouter(h.LogoPass, m),
-		Note:     *NewNoteRouter(h.Note, m),
+		Card:             *NewCardRouter(h.Card, m),
+		User:             *NewUserRouter(h.User, m),
+		Binary:           *NewBinaryRouter(h.Binary, m),
+		LogoPass:         *NewLogoPassRouter(h.LogoPass, m),
+		Note:             *NewNoteRouter(h.Note, m),
+		BankAccount:      *NewBankAccountRouter(h.BankAccount, m),
+		IdentityDocument: *NewIdentityDocumentRouter(h.IdentityDocument, m),
+		Generator:        *NewGeneratorRouter(h.Generator, m),
+		Audit:            *NewAuditRouter(h.Audit, m),
+		Reencryption:     *NewReencryptionRouter(h.Reencryption, m),
+		Sharing:          *NewSharingRouter(h.Sharing, m),
+		Org:              *NewOrgRouter(h.Org, m),
+		Emergency:        *NewEmergencyRouter(h.Emergency, m),
+		RecoveryShare:    *NewRecoveryShareRouter(h.RecoveryShare, m),
+		Session:          *NewSessionRouter(h.Session, m),
 	}
 
 	// Register routes for each module.
@@ -58,6 +97,16 @@ func New(h Handler, m Middleware) *chi.Mux {
 	router.LogoPass.RegisterRoutes(r)
 	router.Binary.RegisterRoutes(r)
 	router.Note.RegisterRoutes(r)
+	router.BankAccount.RegisterRoutes(r)
+	router.IdentityDocument.RegisterRoutes(r)
+	router.Generator.RegisterRoutes(r)
+	router.Audit.RegisterRoutes(r)
+	router.Reencryption.RegisterRoutes(r)
+	router.Sharing.RegisterRoutes(r)
+	router.Org.RegisterRoutes(r)
+	router.Emergency.RegisterRoutes(r)
+	router.RecoveryShare.RegisterRoutes(r)
+	router.Session.RegisterRoutes(r)
 
 	// Register Swagger documentation handler.
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
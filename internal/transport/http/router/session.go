@@ -0,0 +1,53 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionRouter provides route registration for login session HTTP handlers.
+type SessionRouter struct {
+	h SessionHandler // Handler for session operations.
+	m Middleware     // Middleware for authentication and request processing.
+}
+
+// SessionHandler defines the interface for handling login session requests.
+type SessionHandler interface {
+	// List lists the active sessions of the authenticated user.
+	List(rw http.ResponseWriter, r *http.Request)
+
+	// Revoke revokes an active session of the authenticated user.
+	Revoke(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewSessionRouter initializes a new SessionRouter instance.
+//
+// Parameters:
+//   - h SessionHandler: The handler for session operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *SessionRouter: A pointer to the initialized SessionRouter.
+func NewSessionRouter(h SessionHandler, m Middleware) *SessionRouter {
+	return &SessionRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for login sessions. Every route requires authentication and
+// acts on the authenticated user's own sessions; the current session is ended with /api/user/logout.
+//
+// Routes:
+//   - GET /api/sessions - Calls the List handler.
+//   - DELETE /api/sessions/{id} - Calls the Revoke handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (s *SessionRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/sessions", func(r chi.Router) {
+		r.With(s.m.Auth).Get("/", s.h.List)          // List active sessions
+		r.With(s.m.Auth).Delete("/{id}", s.h.Revoke) // Revoke a session
+	})
+}
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SharingRouter provides route registration for vault item sharing HTTP handlers.
+type SharingRouter struct {
+	h SharingHandler // Handler for sharing operations.
+	m Middleware     // Middleware for authentication and request processing.
+}
+
+// SharingHandler defines the interface for handling vault item sharing requests.
+type SharingHandler interface {
+	// Share shares an item of the authenticated user with another user.
+	Share(rw http.ResponseWriter, r *http.Request)
+
+	// ListShares lists the shares of the authenticated user's items.
+	ListShares(rw http.ResponseWriter, r *http.Request)
+
+	// Revoke revokes a share and re-encrypts the item.
+	Revoke(rw http.ResponseWriter, r *http.Request)
+
+	// ListReceived lists the decrypted items shared with the authenticated user.
+	ListReceived(rw http.ResponseWriter, r *http.Request)
+
+	// UpdateReceived changes an item shared with the authenticated user with write permission.
+	UpdateReceived(rw http.ResponseWriter, r *http.Request)
+}
+
+// NewSharingRouter initializes a new SharingRouter instance.
+//
+// Parameters:
+//   - h SharingHandler: The handler for sharing operations.
+//   - m Middleware: Middleware for handling authentication and authorization.
+//
+// Returns:
+//   - *SharingRouter: A pointer to the initialized SharingRouter.
+func NewSharingRouter(h SharingHandler, m Middleware) *SharingRouter {
+	return &SharingRouter{
+		h: h,
+		m: m,
+	}
+}
+
+// RegisterRoutes registers the routes for vault item sharing.
+//
+// Routes:
+//   - POST /api/shares/ - Requires authentication. Calls the Share handler.
+//   - GET /api/shares/ - Requires authentication. Calls the ListShares handler.
+//   - DELETE /api/shares/{shareID} - Requires authentication. Calls the Revoke handler.
+//   - GET /api/shares/received - Requires authentication. Calls the ListReceived handler.
+//   - PUT /api/shares/received/{shareID} - Requires authentication. Calls the UpdateReceived handler.
+//
+// Parameters:
+//   - r chi.Router: The router where the routes will be registered.
+func (s *SharingRouter) RegisterRoutes(r chi.Router) {
+	r.Route("/api/shares", func(r chi.Router) {
+		r.With(s.m.Auth).Post("/", s.h.Share)                           // Share an item
+		r.With(s.m.Auth).Get("/", s.h.ListShares)                       // List the shares of own items
+		r.With(s.m.Auth).Delete("/{shareID}", s.h.Revoke)               // Revoke a share
+		r.With(s.m.Auth).Get("/received", s.h.ListReceived)             // List items shared with the user
+		r.With(s.m.Auth).Put("/received/{shareID}", s.h.UpdateReceived) // Update an item shared with the user
+	})
+}
@@ -10,6 +10,7 @@ import (
 // UserRouter defines routes related to user authentication and registration.
 type UserRouter struct {
 	handler UserHandler // Handler for processing user-related requests.
+	m       Middleware  // Middleware for authentication of account management routes.
 }
 
 // UserHandler defines the methods required for user authentication and registration.
@@ -19,17 +20,45 @@ type UserHandler interface {
 
 	// Registration handles new user registration requests.
 	Registration(rw http.ResponseWriter, r *http.Request)
+
+	// ChangePassword handles master password change requests.
+	ChangePassword(rw http.ResponseWriter, r *http.Request)
+
+	// RegenerateRecovery handles requests for a new recovery key and recovery codes.
+	RegenerateRecovery(rw http.ResponseWriter, r *http.Request)
+
+	// Recover handles master password resets with a recovery key.
+	Recover(rw http.ResponseWriter, r *http.Request)
+
+	// Refresh handles access token renewal with a refresh token.
+	Refresh(rw http.ResponseWriter, r *http.Request)
+
+	// Logout ends the session of the authenticated user.
+	Logout(rw http.ResponseWriter, r *http.Request)
+
+	// LoginTwoFactor completes a login with a second factor.
+	LoginTwoFactor(rw http.ResponseWriter, r *http.Request)
+
+	// EnrollTOTP handles requests for a new TOTP secret.
+	EnrollTOTP(rw http.ResponseWriter, r *http.Request)
+
+	// ConfirmTOTP enables two-factor authentication with a first TOTP code.
+	ConfirmTOTP(rw http.ResponseWriter, r *http.Request)
+
+	// DisableTOTP turns two-factor authentication off.
+	DisableTOTP(rw http.ResponseWriter, r *http.Request)
 }
 
 // NewUserRouter creates a new instance of UserRouter.
 //
 // Parameters:
 //   - h UserHandler: The handler implementing user authentication and registration logic.
+//   - m Middleware: Middleware for handling authentication and authorization.
 //
 // Returns:
 //   - *UserRouter: A new instance of UserRouter.
-func NewUserRouter(h UserHandler) *UserRouter {
-	return &UserRouter{handler: h}
+func NewUserRouter(h UserHandler, m Middleware) *UserRouter {
+	return &UserRouter{handler: h, m: m}
 }
 
 // RegisterRoutes registers user-related routes for authentication and registration.
@@ -38,7 +67,17 @@ func NewUserRouter(h UserHandler) *UserRouter {
 //   - r chi.Router: The router instance where user routes will be registered.
 func (u *UserRouter) RegisterRoutes(r chi.Router) {
 	r.Route("/api/user", func(r chi.Router) {
-		r.Post("/register", u.handler.Registration) // Endpoint for user registration.
-		r.Post("/login", u.handler.Login)           // Endpoint for user authentication.
+		r.With(u.m.RateLimit).Post("/register", u.handler.Registration)    // Endpoint for user registration.
+		r.With(u.m.RateLimit).Post("/login", u.handler.Login)              // Endpoint for user authentication.
+		r.With(u.m.RateLimit).Post("/recover", u.handler.Recover)          // Endpoint for master password reset with a recovery key.
+		r.With(u.m.RateLimit).Post("/refresh", u.handler.Refresh)          // Endpoint for token renewal with a refresh token.
+		r.With(u.m.RateLimit).Post("/login/2fa", u.handler.LoginTwoFactor) // Endpoint for the second factor of a login.
+
+		r.With(u.m.Auth).Post("/logout", u.handler.Logout)               // Endpoint for ending the current session.
+		r.With(u.m.Auth).Put("/password", u.handler.ChangePassword)      // Endpoint for master password change.
+		r.With(u.m.Auth).Post("/recovery", u.handler.RegenerateRecovery) // Endpoint for a new recovery kit.
+		r.With(u.m.Auth).Post("/2fa", u.handler.EnrollTOTP)              // Endpoint for enrolling an authenticator app.
+		r.With(u.m.Auth).Post("/2fa/confirm", u.handler.ConfirmTOTP)     // Endpoint for enabling two-factor authentication.
+		r.With(u.m.Auth).Delete("/2fa", u.handler.DisableTOTP)           // Endpoint for turning two-factor authentication off.
 	})
 }
@@ -2,6 +2,7 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,17 +11,20 @@ import (
 
 // CustomClaims represents the custom claims embedded in a JWT token.
 type CustomClaims struct {
-	UserID   int64  `json:"userID"`   // User's unique identifier.
-	Username string `json:"userName"` // User's username.
+	UserID    int64  `json:"userID"`        // User's unique identifier.
+	Username  string `json:"userName"`      // User's username.
+	SessionID string `json:"sid,omitempty"` // Session a refresh token belongs to; refresh tokens only.
 	jwt.RegisteredClaims
 }
 
 // GenerateJWTProps defines the properties required to generate a JWT token.
 type GenerateJWTProps struct {
-	Secret   []byte    // Secret key used to sign the JWT.
-	Exprires time.Time // Expiration time of the token.
-	UserID   int64     // User's unique identifier.
-	Username string    // User's username.
+	Secret    []byte    // Secret key used to sign the JWT.
+	Exprires  time.Time // Expiration time of the token.
+	UserID    int64     // User's unique identifier.
+	Username  string    // User's username.
+	ID        string    // Unique token ID (jti claim); optional.
+	SessionID string    // Session the token belongs to (sid claim); optional.
 }
 
 // GenerateJWT generates a new JWT token using the provided properties.
@@ -37,9 +41,11 @@ func GenerateJWT(props GenerateJWTProps) (string, error) {
 	}
 
 	claims := &CustomClaims{
-		UserID:   props.UserID,
-		Username: props.Username,
+		UserID:    props.UserID,
+		Username:  props.Username,
+		SessionID: props.SessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        props.ID,
 			ExpiresAt: jwt.NewNumericDate(props.Exprires),
 			Issuer:    "exampleIssuer",
 		},
@@ -54,3 +60,31 @@ func GenerateJWT(props GenerateJWTProps) (string, error) {
 
 	return tokenString, nil
 }
+
+// ParseJWT parses a JWT token signed with HMAC and validates its signature and expiration time.
+//
+// Parameters:
+//   - tokenString string: The JWT token to parse.
+//   - secret []byte: The secret key the token must be signed with.
+//
+// Returns:
+//   - *CustomClaims: The claims of the token if it is valid.
+//   - error: An error if the token is malformed, signed with another key or method, or expired.
+func ParseJWT(tokenString string, secret []byte) (*CustomClaims, error) {
+	claims := &CustomClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.ExpiresAt == nil {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
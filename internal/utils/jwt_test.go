@@ -71,3 +71,30 @@ func TestGenerateJWTWithExpiredTime(t *testing.T) {
 	assert.NotNil(t, token)
 	assert.False(t, token.Valid)
 }
+
+func TestParseJWT(t *testing.T) {
+	secret := []byte("testsecret")
+	tokenString, err := GenerateJWT(GenerateJWTProps{
+		Secret:    secret,
+		Exprires:  time.Now().Add(time.Hour),
+		UserID:    123,
+		Username:  "testuser",
+		ID:        "token",
+		SessionID: "family",
+	})
+	assert.NoError(t, err)
+
+	claims, err := ParseJWT(tokenString, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123), claims.UserID)
+	assert.Equal(t, "token", claims.ID)
+	assert.Equal(t, "family", claims.SessionID)
+
+	_, err = ParseJWT(tokenString, []byte("othersecret"))
+	assert.Error(t, err)
+
+	expired, err := GenerateJWT(GenerateJWTProps{Secret: secret, Exprires: time.Now().Add(-time.Hour), UserID: 123})
+	assert.NoError(t, err)
+	_, err = ParseJWT(expired, secret)
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+)
+
+// Card brands detected from the card number prefix (IIN range).
+const (
+	CardBrandVisa       = "visa"
+	CardBrandMastercard = "mastercard"
+	CardBrandMir        = "mir"
+	CardBrandAmex       = "amex"
+	CardBrandDiscover   = "discover"
+	CardBrandJCB        = "jcb"
+	CardBrandUnionPay   = "unionpay"
+	CardBrandDiners     = "diners"
+	CardBrandMaestro    = "maestro"
+	CardBrandUnknown    = "unknown"
+)
+
+// cardBrandRange describes an inclusive IIN prefix range belonging to a card brand.
+type cardBrandRange struct {
+	brand    string
+	from, to int // inclusive prefix range
+	digits   int // number of leading digits compared against the range
+}
+
+// cardBrandRanges is checked in order, so narrower ranges must precede wider ones
+// (for example Mir 2200-2204 before Mastercard 2221-2720, Discover 6011 before Maestro 6).
+var cardBrandRanges = []cardBrandRange{
+	{brand: CardBrandMir, from: 2200, to: 2204, digits: 4},
+	{brand: CardBrandMastercard, from: 2221, to: 2720, digits: 4},
+	{brand: CardBrandMastercard, from: 51, to: 55, digits: 2},
+	{brand: CardBrandAmex, from: 34, to: 34, digits: 2},
+	{brand: CardBrandAmex, from: 37, to: 37, digits: 2},
+	{brand: CardBrandJCB, from: 3528, to: 3589, digits: 4},
+	{brand: CardBrandDiners, from: 300, to: 305, digits: 3},
+	{brand: CardBrandDiners, from: 36, to: 36, digits: 2},
+	{brand: CardBrandDiners, from: 38, to: 39, digits: 2},
+	{brand: CardBrandVisa, from: 4, to: 4, digits: 1},
+	{brand: CardBrandDiscover, from: 6011, to: 6011, digits: 4},
+	{brand: CardBrandDiscover, from: 644, to: 649, digits: 3},
+	{brand: CardBrandDiscover, from: 65, to: 65, digits: 2},
+	{brand: CardBrandUnionPay, from: 62, to: 62, digits: 2},
+	{brand: CardBrandMaestro, from: 50, to: 50, digits: 2},
+	{brand: CardBrandMaestro, from: 56, to: 69, digits: 2},
+}
+
+// NormalizeCardNumber removes spaces and dashes from a card number.
+func NormalizeCardNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(number))
+}
+
+// ValidateCardNumber checks that a card number consists of 12 to 19 digits
+// and passes the Luhn checksum. The number is normalized before validation.
+//
+// Returns:
+//   - An error wrapping apperrors.ErrInvalidCardNumber if the number is invalid.
+func ValidateCardNumber(number string) error {
+	number = NormalizeCardNumber(number)
+
+	if len(number) < 12 || len(number) > 19 {
+		return fmt.Errorf("%w: must contain 12 to 19 digits", apperrors.ErrInvalidCardNumber)
+	}
+
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w: only digits are allowed", apperrors.ErrInvalidCardNumber)
+		}
+	}
+
+	if !luhnValid(number) {
+		return fmt.Errorf("%w: checksum mismatch", apperrors.ErrInvalidCardNumber)
+	}
+
+	return nil
+}
+
+// DetectCardBrand returns the card brand for a normalized card number,
+// or CardBrandUnknown if the prefix does not match a known brand.
+func DetectCardBrand(number string) string {
+	number = NormalizeCardNumber(number)
+
+	for _, r := range cardBrandRanges {
+		if len(number) < r.digits {
+			continue
+		}
+
+		prefix, err := strconv.Atoi(number[:r.digits])
+		if err != nil {
+			return CardBrandUnknown
+		}
+
+		if prefix >= r.from && prefix <= r.to {
+			return r.brand
+		}
+	}
+
+	return CardBrandUnknown
+}
+
+// ValidateCVV checks that a card security code has 3 digits, or 4 digits for American Express.
+//
+// Returns:
+//   - An error wrapping apperrors.ErrInvalidCVV if the code is invalid.
+func ValidateCVV(cvv, brand string) error {
+	expected := 3
+	if brand == CardBrandAmex {
+		expected = 4
+	}
+
+	if len(cvv) != expected {
+		return fmt.Errorf("%w: must contain %d digits", apperrors.ErrInvalidCVV, expected)
+	}
+
+	for _, r := range cvv {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w: only digits are allowed", apperrors.ErrInvalidCVV)
+		}
+	}
+
+	return nil
+}
+
+// ParseCardExpiry parses a card expiry date in the MM/YY format and rejects cards
+// that expired before now. A card is valid through the last moment of its expiry month.
+//
+// Returns:
+//   - The first instant after the card stops being valid.
+//   - An error wrapping apperrors.ErrInvalidDate if the value is malformed,
+//     or apperrors.ErrCardExpired if the card has already expired.
+func ParseCardExpiry(value string, now time.Time) (time.Time, error) {
+	expiresAt, err := CardExpiry(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !now.Before(expiresAt) {
+		return time.Time{}, fmt.Errorf("%w: %s", apperrors.ErrCardExpired, strings.TrimSpace(value))
+	}
+
+	return expiresAt, nil
+}
+
+// CardExpiry parses a card expiry date in the MM/YY format without checking it against the current time.
+//
+// Returns:
+//   - The first instant (UTC) of the month following the expiry month.
+//   - An error wrapping apperrors.ErrInvalidDate if the value is malformed.
+func CardExpiry(value string) (time.Time, error) {
+	month, year, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok || !isTwoDigits(month) || !isTwoDigits(year) {
+		return time.Time{}, fmt.Errorf("%w: %q, expected MM/YY", apperrors.ErrInvalidDate, value)
+	}
+
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return time.Time{}, fmt.Errorf("%w: %q, month must be between 01 and 12", apperrors.ErrInvalidDate, value)
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q, expected MM/YY", apperrors.ErrInvalidDate, value)
+	}
+
+	return time.Date(2000+y, time.Month(m)+1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// MaskCardNumber hides all but the last four digits of a card number,
+// keeping groups of four for readability (e.g. "**** **** **** 1111").
+func MaskCardNumber(number string) string {
+	number = NormalizeCardNumber(number)
+	if len(number) <= 4 {
+		return number
+	}
+
+	masked := strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+
+	var b strings.Builder
+	for i, r := range masked {
+		if i > 0 && (len(masked)-i)%4 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// isTwoDigits reports whether s consists of exactly two ASCII digits.
+func isTwoDigits(s string) bool {
+	return len(s) == 2 && s[0] >= '0' && s[0] <= '9' && s[1] >= '0' && s[1] <= '9'
+}
+
+// luhnValid reports whether a string of digits passes the Luhn (mod 10) checksum.
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCardNumber(t *testing.T) {
+	assert.NoError(t, ValidateCardNumber("4111 1111 1111 1111"))
+	assert.NoError(t, ValidateCardNumber("5555-5555-5555-4444"))
+	assert.NoError(t, ValidateCardNumber("378282246310005"))
+
+	assert.ErrorIs(t, ValidateCardNumber("4111 1111 1111 1112"), apperrors.ErrInvalidCardNumber)
+	assert.ErrorIs(t, ValidateCardNumber("4111 1111 1111 111a"), apperrors.ErrInvalidCardNumber)
+	assert.ErrorIs(t, ValidateCardNumber("4111"), apperrors.ErrInvalidCardNumber)
+}
+
+func TestDetectCardBrand(t *testing.T) {
+	tests := map[string]string{
+		"4111111111111111":    CardBrandVisa,
+		"5555555555554444":    CardBrandMastercard,
+		"2221000000000009":    CardBrandMastercard,
+		"2200000000000004":    CardBrandMir,
+		"378282246310005":     CardBrandAmex,
+		"6011111111111117":    CardBrandDiscover,
+		"3530111333300000":    CardBrandJCB,
+		"30569309025904":      CardBrandDiners,
+		"6200000000000005":    CardBrandUnionPay,
+		"6759649826438453":    CardBrandMaestro,
+		"1234567812345670":    CardBrandUnknown,
+		"2200 0000 0000 0004": CardBrandMir,
+	}
+
+	for number, brand := range tests {
+		assert.Equal(t, brand, DetectCardBrand(number), number)
+	}
+}
+
+func TestValidateCVV(t *testing.T) {
+	assert.NoError(t, ValidateCVV("123", CardBrandVisa))
+	assert.NoError(t, ValidateCVV("1234", CardBrandAmex))
+	assert.ErrorIs(t, ValidateCVV("1234", CardBrandVisa), apperrors.ErrInvalidCVV)
+	assert.ErrorIs(t, ValidateCVV("123", CardBrandAmex), apperrors.ErrInvalidCVV)
+	assert.ErrorIs(t, ValidateCVV("12a", CardBrandVisa), apperrors.ErrInvalidCVV)
+}
+
+func TestParseCardExpiry(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	expiresAt, err := ParseCardExpiry("10/26", now)
+	assert.NoError(t, err, "card is valid through the end of its expiry month")
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), expiresAt)
+
+	expiresAt, err = ParseCardExpiry("12/26", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC), expiresAt)
+
+	_, err = ParseCardExpiry("09/26", now)
+	assert.ErrorIs(t, err, apperrors.ErrCardExpired)
+
+	_, err = ParseCardExpiry("13/26", now)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDate)
+
+	_, err = ParseCardExpiry("1/26", now)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDate)
+}
+
+func TestCardExpiry_RejectsNonDigits(t *testing.T) {
+	for _, value := range []string{"+5/26", "-1/26", "05/+7", "05/-1", " 5/26", "٠٥/26", "ab/cd"} {
+		_, err := CardExpiry(value)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidDate, value)
+	}
+}
+
+func TestMaskCardNumber(t *testing.T) {
+	assert.Equal(t, "**** **** **** 1111", MaskCardNumber("4111 1111 1111 1111"))
+	assert.Equal(t, "*** **** **** 0005", MaskCardNumber("378282246310005"))
+	assert.Equal(t, "1234", MaskCardNumber("1234"))
+}
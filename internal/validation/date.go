@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+)
+
+// DateLayout is the layout used for calendar dates in requests and stored values (ISO 8601).
+const DateLayout = "2006-01-02"
+
+// ParseDate parses a calendar date in the YYYY-MM-DD format.
+//
+// Returns:
+//   - The parsed date at midnight UTC.
+//   - An error wrapping apperrors.ErrInvalidDate if the value cannot be parsed.
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q, expected YYYY-MM-DD", apperrors.ErrInvalidDate, value)
+	}
+
+	return date, nil
+}
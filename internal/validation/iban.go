@@ -0,0 +1,120 @@
+// Package validation provides format and checksum validation for the structured
+// secret types stored in GophKeeper, such as bank accounts and identity documents.
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+)
+
+// ibanLengths holds the expected IBAN length for each country that uses IBANs.
+// Countries missing from the table are validated by checksum only.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22,
+	"DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18, "FO": 18, "FR": 27,
+	"GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27, "GT": 28, "HR": 21, "HU": 28,
+	"IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20,
+	"LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MD": 24,
+	"ME": 22, "MK": 19, "MR": 27, "MT": 31, "MU": 30, "NL": 18, "NO": 15, "PK": 24,
+	"PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24,
+	"SC": 31, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "TL": 23, "TN": 24, "TR": 26,
+	"UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// NormalizeIBAN removes spaces and converts the IBAN to upper case.
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// ValidateIBAN checks the structure, country-specific length and ISO 7064
+// mod 97-10 checksum of an IBAN. The IBAN is normalized before validation.
+//
+// Returns:
+//   - An error wrapping apperrors.ErrInvalidIBAN if the IBAN is invalid.
+func ValidateIBAN(iban string) error {
+	iban = NormalizeIBAN(iban)
+
+	if len(iban) < 15 || len(iban) > 34 {
+		return fmt.Errorf("%w: length must be between 15 and 34 characters", apperrors.ErrInvalidIBAN)
+	}
+
+	for _, r := range iban {
+		if !isUpperAlnum(r) {
+			return fmt.Errorf("%w: only latin letters and digits are allowed", apperrors.ErrInvalidIBAN)
+		}
+	}
+
+	country := iban[:2]
+	if !IsCountryCode(country) {
+		return fmt.Errorf("%w: unknown country code %q", apperrors.ErrInvalidIBAN, country)
+	}
+
+	if iban[2] < '0' || iban[2] > '9' || iban[3] < '0' || iban[3] > '9' {
+		return fmt.Errorf("%w: check digits must be numeric", apperrors.ErrInvalidIBAN)
+	}
+
+	if expected, ok := ibanLengths[country]; ok && len(iban) != expected {
+		return fmt.Errorf("%w: %s IBAN must be %d characters long", apperrors.ErrInvalidIBAN, country, expected)
+	}
+
+	if ibanMod97(iban[4:]+iban[:4]) != 1 {
+		return fmt.Errorf("%w: checksum mismatch", apperrors.ErrInvalidIBAN)
+	}
+
+	return nil
+}
+
+// ValidateBIC checks that a BIC (SWIFT code) consists of a 4-letter institution code,
+// a valid ISO country code, a 2-character location code and an optional 3-character branch code.
+//
+// Returns:
+//   - An error wrapping apperrors.ErrInvalidBIC if the BIC is invalid.
+func ValidateBIC(bic string) error {
+	bic = strings.ToUpper(strings.TrimSpace(bic))
+
+	if len(bic) != 8 && len(bic) != 11 {
+		return fmt.Errorf("%w: length must be 8 or 11 characters", apperrors.ErrInvalidBIC)
+	}
+
+	for _, r := range bic[:4] {
+		if r < 'A' || r > 'Z' {
+			return fmt.Errorf("%w: institution code must contain letters only", apperrors.ErrInvalidBIC)
+		}
+	}
+
+	if !IsCountryCode(bic[4:6]) {
+		return fmt.Errorf("%w: unknown country code %q", apperrors.ErrInvalidBIC, bic[4:6])
+	}
+
+	for _, r := range bic[6:] {
+		if !isUpperAlnum(r) {
+			return fmt.Errorf("%w: location and branch codes must be alphanumeric", apperrors.ErrInvalidBIC)
+		}
+	}
+
+	return nil
+}
+
+// ibanMod97 computes the remainder of the numeric representation of the rearranged IBAN
+// divided by 97, processing the digits incrementally to avoid big integer arithmetic.
+func ibanMod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			value := int(r-'A') + 10
+			remainder = (remainder*100 + value) % 97
+			continue
+		}
+		remainder = (remainder*10 + int(r-'0')) % 97
+	}
+
+	return remainder
+}
+
+// isUpperAlnum reports whether r is an upper-case latin letter or a decimal digit.
+func isUpperAlnum(r rune) bool {
+	return (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
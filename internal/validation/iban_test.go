@@ -0,0 +1,93 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		wantErr bool
+	}{
+		{name: "valid german iban", iban: "DE89370400440532013000"},
+		{name: "valid iban with spaces and lower case", iban: "gb82 west 1234 5698 7654 32"},
+		{name: "valid norwegian iban", iban: "NO9386011117947"},
+		{name: "wrong checksum", iban: "DE89370400440532013001", wantErr: true},
+		{name: "wrong country length", iban: "DE8937040044053201300", wantErr: true},
+		{name: "unknown country", iban: "ZZ89370400440532013000", wantErr: true},
+		{name: "non numeric check digits", iban: "DEAB370400440532013000", wantErr: true},
+		{name: "invalid characters", iban: "DE89-3704-0044-0532-0130-00", wantErr: true},
+		{name: "too short", iban: "DE89", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIBAN(tt.iban)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrInvalidIBAN)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNormalizeIBAN(t *testing.T) {
+	assert.Equal(t, "GB82WEST12345698765432", NormalizeIBAN(" gb82 west 1234 5698 7654 32 "))
+}
+
+func TestValidateBIC(t *testing.T) {
+	tests := []struct {
+		name    string
+		bic     string
+		wantErr bool
+	}{
+		{name: "eight characters", bic: "NWBKGB2L"},
+		{name: "eleven characters", bic: "COBADEFFXXX"},
+		{name: "lower case", bic: "cobadeffxxx"},
+		{name: "wrong length", bic: "COBADEFFXX", wantErr: true},
+		{name: "digits in institution code", bic: "C0BADEFFXXX", wantErr: true},
+		{name: "unknown country", bic: "COBAZZFFXXX", wantErr: true},
+		{name: "invalid branch", bic: "COBADEFF-XX", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBIC(tt.bic)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrInvalidBIC)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateCountryCode(t *testing.T) {
+	code, err := ValidateCountryCode(" de ")
+	assert.NoError(t, err)
+	assert.Equal(t, "DE", code)
+
+	_, err = ValidateCountryCode("DEU")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidCountryCode)
+
+	_, err = ValidateCountryCode("QQ")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidCountryCode)
+}
+
+func TestParseDate(t *testing.T) {
+	date, err := ParseDate("2024-02-29")
+	assert.NoError(t, err)
+	assert.Equal(t, 2024, date.Year())
+	assert.Equal(t, 29, date.Day())
+
+	_, err = ParseDate("2023-02-29")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDate)
+
+	_, err = ParseDate("29.02.2024")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDate)
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/Zrossiz/gophkeeper/pkg/passgen"
+)
+
+// Rules of the master password policy, reported in apperrors.PasswordRuleFailure.Rule.
+const (
+	PasswordRuleMinLength  = "min_length"      // The password has fewer characters than PasswordPolicy.MinLength.
+	PasswordRuleMinEntropy = "min_entropy"     // The estimated entropy is below PasswordPolicy.MinEntropy.
+	PasswordRuleDenylist   = "common_password" // The password is on the denylist of common passwords.
+	PasswordRuleUsername   = "not_username"    // The password equals the username.
+)
+
+// PasswordPolicy is the policy master passwords must meet.
+type PasswordPolicy struct {
+	MinLength  int  // Minimum number of characters; 0 disables the rule.
+	MinEntropy int  // Minimum passgen.EstimateEntropy score in bits; 0 disables the rule.
+	Denylist   bool // Whether passwords on the embedded denylist of common passwords are rejected.
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords is the denylist of common passwords, in lower case.
+var commonPasswords = parseDenylist(commonPasswordsFile)
+
+// parseDenylist reads a denylist with one password per line, skipping blank lines and # comments.
+func parseDenylist(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+
+	return passwords
+}
+
+// ValidateMasterPassword checks a master password against a policy. Every rule is checked, so that
+// the user learns about all of them at once. A password never fails for being equal to an empty username.
+//
+// Parameters:
+//   - policy: The policy to enforce.
+//   - password: The master password.
+//   - username: The username of the account; compared case-insensitively.
+//
+// Returns:
+//   - nil if the password meets the policy, or else *apperrors.PasswordPolicyError listing the
+//     failed rules in the order of the rule constants.
+func ValidateMasterPassword(policy PasswordPolicy, password, username string) error {
+	var failures []apperrors.PasswordRuleFailure
+
+	if policy.MinLength > 0 && utf8.RuneCountInString(password) < policy.MinLength {
+		failures = append(failures, apperrors.PasswordRuleFailure{
+			Rule:    PasswordRuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters long", policy.MinLength),
+		})
+	}
+
+	if policy.MinEntropy > 0 && passgen.EstimateEntropy(password) < float64(policy.MinEntropy) {
+		failures = append(failures, apperrors.PasswordRuleFailure{
+			Rule:    PasswordRuleMinEntropy,
+			Message: "is too predictable: use more characters or mix letter case, digits and symbols",
+		})
+	}
+
+	if policy.Denylist {
+		if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+			failures = append(failures, apperrors.PasswordRuleFailure{
+				Rule:    PasswordRuleDenylist,
+				Message: "is one of the most common passwords",
+			})
+		}
+	}
+
+	if username = strings.TrimSpace(username); username != "" && strings.EqualFold(strings.TrimSpace(password), username) {
+		failures = append(failures, apperrors.PasswordRuleFailure{
+			Rule:    PasswordRuleUsername,
+			Message: "must not be the username",
+		})
+	}
+
+	if len(failures) > 0 {
+		return &apperrors.PasswordPolicyError{Failures: failures}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Zrossiz/gophkeeper/internal/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMasterPassword(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 12, MinEntropy: 50, Denylist: true}
+
+	tests := []struct {
+		name      string
+		password  string
+		username  string
+		wantRules []string
+	}{
+		{name: "strong password", password: "correct-Horse-battery-7", username: "alice"},
+		{name: "long lower case password", password: "correcthorsebattery", username: "alice"},
+		{name: "too short", password: "c0rrect-H0r", username: "alice", wantRules: []string{PasswordRuleMinLength}},
+		{name: "low entropy", password: "aaaaaaaaaaaaaaaa", username: "alice", wantRules: []string{PasswordRuleMinEntropy}},
+		{name: "common password", password: "Password1234", username: "alice", wantRules: []string{PasswordRuleDenylist}},
+		{name: "username", password: "Alice.Wonderland-1", username: "alice.wonderland-1", wantRules: []string{PasswordRuleUsername}},
+		{
+			name:      "all rules",
+			password:  "qwerty",
+			username:  "QWERTY",
+			wantRules: []string{PasswordRuleMinLength, PasswordRuleMinEntropy, PasswordRuleDenylist, PasswordRuleUsername},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMasterPassword(policy, tt.password, tt.username)
+			if tt.wantRules == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *apperrors.PasswordPolicyError
+			require.True(t, errors.As(err, &policyErr))
+			assert.ErrorIs(t, err, apperrors.ErrWeakPassword)
+
+			rules := make([]string, len(policyErr.Failures))
+			for i, failure := range policyErr.Failures {
+				rules[i] = failure.Rule
+				assert.NotEmpty(t, failure.Message)
+			}
+			assert.Equal(t, tt.wantRules, rules)
+		})
+	}
+}
+
+func TestValidateMasterPassword_DisabledRules(t *testing.T) {
+	assert.NoError(t, ValidateMasterPassword(PasswordPolicy{}, "password", "alice"),
+		"Disabled rules should not reject a password")
+	assert.Error(t, ValidateMasterPassword(PasswordPolicy{}, "alice", "alice"),
+		"A password equal to the username should always be rejected")
+	assert.NoError(t, ValidateMasterPassword(PasswordPolicy{}, "", ""))
+}
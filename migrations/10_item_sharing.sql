@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS user_keypairs (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    wrapped_private_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS item_keys (
+    item_type TEXT NOT NULL,
+    item_id INT NOT NULL,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (item_type, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS item_keys_owner_idx ON item_keys (owner_id, item_type);
+
+CREATE TABLE IF NOT EXISTS item_shares (
+    id SERIAL PRIMARY KEY,
+    item_type TEXT NOT NULL,
+    item_id INT NOT NULL,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    permission TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (item_type, item_id, recipient_id),
+    FOREIGN KEY (item_type, item_id) REFERENCES item_keys (item_type, item_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS item_shares_recipient_idx ON item_shares (recipient_id);
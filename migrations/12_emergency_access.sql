@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS emergency_access (
+    id SERIAL PRIMARY KEY,
+    grantor_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    grantee_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    access_type TEXT NOT NULL,
+    wait_days INT NOT NULL,
+    wrapped_key TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'idle',
+    requested_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (grantor_id, grantee_id)
+);
+
+CREATE INDEX IF NOT EXISTS emergency_access_grantee_idx ON emergency_access (grantee_id);
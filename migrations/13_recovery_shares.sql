@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS recovery_shares (
+    id SERIAL PRIMARY KEY,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    officer_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    share_index SMALLINT NOT NULL,
+    threshold SMALLINT NOT NULL,
+    sealed_share TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (owner_id, officer_id),
+    UNIQUE (owner_id, share_index)
+);
+
+CREATE INDEX IF NOT EXISTS recovery_shares_officer_idx ON recovery_shares (officer_id);
+
+CREATE TABLE IF NOT EXISTS recovery_sessions (
+    id SERIAL PRIMARY KEY,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS recovery_submissions (
+    session_id INT NOT NULL REFERENCES recovery_sessions(id) ON DELETE CASCADE,
+    share_index SMALLINT NOT NULL,
+    officer_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sealed_share TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (session_id, share_index)
+);
@@ -0,0 +1,7 @@
+ALTER TABLE IF EXISTS refresh_token_families RENAME TO sessions;
+ALTER INDEX IF EXISTS refresh_token_families_user_idx RENAME TO sessions_user_idx;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ DEFAULT NOW();
@@ -0,0 +1 @@
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS brand TEXT NOT NULL DEFAULT 'unknown';
@@ -0,0 +1 @@
+ALTER TABLE passwords ADD COLUMN IF NOT EXISTS uris TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS user_keys (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    kdf TEXT NOT NULL,
+    wrapped_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS aad_bound BOOLEAN NOT NULL DEFAULT FALSE;
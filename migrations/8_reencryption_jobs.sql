@@ -0,0 +1,19 @@
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS reencrypt_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS reencryption_jobs (
+    id SERIAL PRIMARY KEY,
+    status TEXT NOT NULL,
+    phase TEXT NOT NULL,
+    last_id BIGINT NOT NULL DEFAULT 0,
+    scanned BIGINT NOT NULL DEFAULT 0,
+    rewrapped BIGINT NOT NULL DEFAULT 0,
+    kdf_pending BIGINT NOT NULL DEFAULT 0,
+    outdated BIGINT NOT NULL DEFAULT 0,
+    flagged BIGINT NOT NULL DEFAULT 0,
+    batch_size INT NOT NULL,
+    batch_delay_ms INT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
@@ -0,0 +1,129 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestBuildAndLookup(t *testing.T) {
+	dir := t.TempDir()
+
+	// Full-hash corpus, deliberately unsorted, with CRLF line endings and a line without a count.
+	corpus := filepath.Join(dir, "pwned.txt")
+	writeFile(t, corpus, strings.Join([]string{
+		sha1Hex("password") + ":9545824",
+		strings.ToLower(sha1Hex("123456")) + ":37359195",
+		sha1Hex("qwerty"),
+		"",
+	}, "\r\n"))
+
+	// Range file: the file name holds the first five hash characters.
+	rangeDir := filepath.Join(dir, "ranges")
+	require.NoError(t, os.Mkdir(rangeDir, 0o700))
+	letMeIn := sha1Hex("letmein")
+	writeFile(t, filepath.Join(rangeDir, letMeIn[:5]+".txt"), letMeIn[5:]+":42\n")
+
+	indexPath := filepath.Join(dir, "pwned.idx")
+	count, err := Build(indexPath, corpus, rangeDir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), count)
+
+	idx, err := Open(indexPath)
+	require.NoError(t, err)
+	defer idx.Close()
+
+	assert.Equal(t, uint64(4), idx.Len())
+
+	tests := []struct {
+		password string
+		want     uint32
+	}{
+		{password: "password", want: 9545824},
+		{password: "123456", want: 37359195},
+		{password: "qwerty", want: 1},
+		{password: "letmein", want: 42},
+		{password: "correct horse battery staple", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			got, err := idx.Lookup(tt.password)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBuild_ExternalMerge(t *testing.T) {
+	dir := t.TempDir()
+
+	var lines []string
+	for i := 0; i < 1000; i++ {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprint("password", i)), i+1))
+	}
+	// Duplicate hash in another chunk keeps the highest count.
+	lines = append(lines, sha1Hex("password7")+":500")
+
+	corpus := filepath.Join(dir, "pwned.txt")
+	writeFile(t, corpus, strings.Join(lines, "\n"))
+
+	indexPath := filepath.Join(dir, "pwned.idx")
+	builder := &Builder{ChunkRecords: 64, TempDir: dir}
+	count, err := builder.Build(indexPath, corpus)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), count)
+
+	idx, err := Open(indexPath)
+	require.NoError(t, err)
+	defer idx.Close()
+
+	for i := 0; i < 1000; i++ {
+		got, err := idx.Lookup(fmt.Sprint("password", i))
+		require.NoError(t, err)
+
+		want := uint32(i + 1)
+		if i == 7 {
+			want = 500
+		}
+		assert.Equal(t, want, got)
+	}
+
+	chunks, err := filepath.Glob(filepath.Join(dir, "breach-chunk-*"))
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+}
+
+func TestBuild_InvalidLine(t *testing.T) {
+	dir := t.TempDir()
+	corpus := filepath.Join(dir, "pwned.txt")
+	writeFile(t, corpus, "not-a-hash:1\n")
+
+	_, err := Build(filepath.Join(dir, "pwned.idx"), corpus)
+	assert.ErrorContains(t, err, "pwned.txt:1")
+}
+
+func TestOpen_InvalidIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.idx")
+	writeFile(t, path, "definitely not an index")
+
+	_, err := Open(path)
+	assert.ErrorIs(t, err, ErrInvalidIndex)
+}
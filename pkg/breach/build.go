@@ -0,0 +1,464 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultChunkRecords is the number of records sorted in memory at once (~96 MiB) while building an index.
+const DefaultChunkRecords = 1 << 22
+
+// prefixLen is the length of the hash prefix used by HIBP range files (e.g. "21BD1.txt").
+const prefixLen = 5
+
+// record is a single hash entry of the index.
+type record struct {
+	hash  [hashSize]byte
+	count uint32
+}
+
+// Builder converts HIBP-style text corpora into a binary index.
+//
+// Supported inputs:
+//   - Files with one "SHA1:COUNT" line per hash (the "ordered by hash" or "ordered by count" downloads).
+//   - Range files named after a 5-character hash prefix (e.g. "21BD1.txt") containing "SUFFIX:COUNT" lines,
+//     or directories of such files, as produced by the HIBP range API downloader.
+//
+// The count is optional and defaults to 1. Inputs do not need to be sorted: records are
+// sorted in chunks of ChunkRecords and merged on disk, so memory use stays bounded.
+type Builder struct {
+	ChunkRecords int    // Records sorted in memory per chunk; DefaultChunkRecords if zero.
+	TempDir      string // Directory for intermediate chunks; os.TempDir() if empty.
+
+	buf    []record
+	chunks []string
+}
+
+// Build converts the sources into an index at dst using the default Builder settings.
+//
+// Returns:
+//   - The number of distinct hashes written.
+//   - An error if a source cannot be read or parsed, or the index cannot be written.
+func Build(dst string, sources ...string) (uint64, error) {
+	return (&Builder{}).Build(dst, sources...)
+}
+
+// Build converts the sources (files or directories) into an index at dst.
+// The index is written to a temporary file and atomically renamed, so readers never see a partial index.
+//
+// Returns:
+//   - The number of distinct hashes written.
+//   - An error if a source cannot be read or parsed, or the index cannot be written.
+func (b *Builder) Build(dst string, sources ...string) (uint64, error) {
+	if b.ChunkRecords <= 0 {
+		b.ChunkRecords = DefaultChunkRecords
+	}
+	b.buf = make([]record, 0, min(b.ChunkRecords, 1<<16))
+	b.chunks = nil
+
+	defer func() {
+		for _, chunk := range b.chunks {
+			os.Remove(chunk)
+		}
+	}()
+
+	for _, source := range sources {
+		if err := b.addSource(source); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(b.chunks) == 0 {
+		sortRecords(b.buf)
+		return writeIndex(dst, sliceIterator(b.buf))
+	}
+
+	if err := b.flush(); err != nil {
+		return 0, err
+	}
+
+	next, closeChunks, err := mergeChunks(b.chunks)
+	if err != nil {
+		return 0, err
+	}
+	defer closeChunks()
+
+	return writeIndex(dst, next)
+}
+
+// addSource adds a corpus file, or every regular file of a corpus directory.
+func (b *Builder) addSource(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return b.addFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := b.addFile(filepath.Join(path, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addFile parses a corpus file line by line.
+func (b *Builder) addFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	prefix := rangePrefix(path)
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		rec, err := parseLine(text, prefix)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		if err := b.add(rec); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// add buffers a record, spilling a sorted chunk to disk when the buffer is full.
+func (b *Builder) add(rec record) error {
+	b.buf = append(b.buf, rec)
+	if len(b.buf) < b.ChunkRecords {
+		return nil
+	}
+
+	return b.flush()
+}
+
+// flush sorts the buffered records and writes them to a temporary chunk file.
+func (b *Builder) flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+
+	sortRecords(b.buf)
+
+	file, err := os.CreateTemp(b.TempDir, "breach-chunk-*")
+	if err != nil {
+		return err
+	}
+	b.chunks = append(b.chunks, file.Name())
+
+	w := bufio.NewWriter(file)
+	for _, rec := range b.buf {
+		if err := writeRecord(w, rec); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	b.buf = b.buf[:0]
+
+	return nil
+}
+
+// rangePrefix returns the upper-cased hash prefix encoded in an HIBP range file name,
+// or an empty string if the name is not a 5-character hex prefix.
+func rangePrefix(path string) string {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	if len(name) != prefixLen {
+		return ""
+	}
+	if _, err := hex.DecodeString(name + "0"); err != nil {
+		return ""
+	}
+
+	return strings.ToUpper(name)
+}
+
+// parseLine parses a "HASH[:COUNT]" line; 35-character suffixes are completed with the range prefix.
+func parseLine(line, prefix string) (record, error) {
+	var rec record
+
+	hash, count, hasCount := strings.Cut(line, ":")
+	hash = strings.TrimSpace(hash)
+
+	switch {
+	case len(hash) == hashSize*2:
+	case len(hash) == hashSize*2-prefixLen && prefix != "":
+		hash = prefix + hash
+	default:
+		return rec, fmt.Errorf("unexpected hash length %d", len(hash))
+	}
+
+	if _, err := hex.Decode(rec.hash[:], []byte(hash)); err != nil {
+		return rec, fmt.Errorf("invalid hash: %w", err)
+	}
+
+	rec.count = 1
+	if hasCount {
+		n, err := strconv.ParseUint(strings.TrimSpace(count), 10, 32)
+		if err != nil {
+			return rec, fmt.Errorf("invalid count: %w", err)
+		}
+		rec.count = uint32(n)
+	}
+
+	return rec, nil
+}
+
+// sortRecords sorts records by hash.
+func sortRecords(records []record) {
+	slices.SortFunc(records, func(a, b record) int {
+		return bytes.Compare(a.hash[:], b.hash[:])
+	})
+}
+
+// writeRecord encodes a single record.
+func writeRecord(w io.Writer, rec record) error {
+	var buf [recordSize]byte
+	copy(buf[:], rec.hash[:])
+	binary.BigEndian.PutUint32(buf[hashSize:], rec.count)
+
+	_, err := w.Write(buf[:])
+	return err
+}
+
+// readRecord decodes a single record.
+func readRecord(r io.Reader) (record, error) {
+	var (
+		buf [recordSize]byte
+		rec record
+	)
+
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return rec, err
+	}
+
+	copy(rec.hash[:], buf[:hashSize])
+	rec.count = binary.BigEndian.Uint32(buf[hashSize:])
+
+	return rec, nil
+}
+
+// iterator yields records in hash order; ok is false once the records are exhausted.
+type iterator func() (rec record, ok bool, err error)
+
+// sliceIterator iterates over sorted in-memory records.
+func sliceIterator(records []record) iterator {
+	return func() (record, bool, error) {
+		if len(records) == 0 {
+			return record{}, false, nil
+		}
+
+		rec := records[0]
+		records = records[1:]
+
+		return rec, true, nil
+	}
+}
+
+// chunkHead is the current record of a chunk taking part in the k-way merge.
+type chunkHead struct {
+	rec    record
+	reader *bufio.Reader
+}
+
+// chunkHeap orders chunk heads by hash.
+type chunkHeap []chunkHead
+
+func (h chunkHeap) Len() int           { return len(h) }
+func (h chunkHeap) Less(i, j int) bool { return bytes.Compare(h[i].rec.hash[:], h[j].rec.hash[:]) < 0 }
+func (h chunkHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x any)        { *h = append(*h, x.(chunkHead)) }
+func (h *chunkHeap) Pop() any {
+	old := *h
+	head := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return head
+}
+
+// mergeChunks performs a k-way merge of sorted chunk files.
+//
+// Returns:
+//   - An iterator over the merged records and a function closing the chunk files.
+func mergeChunks(paths []string) (iterator, func(), error) {
+	files := make([]*os.File, 0, len(paths))
+	closeAll := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+
+	h := make(chunkHeap, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		files = append(files, file)
+
+		reader := bufio.NewReader(file)
+		rec, err := readRecord(reader)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("read chunk %s: %w", path, err)
+		}
+		h = append(h, chunkHead{rec: rec, reader: reader})
+	}
+	heap.Init(&h)
+
+	next := func() (record, bool, error) {
+		if h.Len() == 0 {
+			return record{}, false, nil
+		}
+
+		rec := h[0].rec
+
+		following, err := readRecord(h[0].reader)
+		switch {
+		case err == io.EOF:
+			heap.Pop(&h)
+		case err != nil:
+			return record{}, false, fmt.Errorf("read chunk: %w", err)
+		default:
+			h[0].rec = following
+			heap.Fix(&h, 0)
+		}
+
+		return rec, true, nil
+	}
+
+	return next, closeAll, nil
+}
+
+// writeIndex writes sorted records to dst, merging duplicate hashes (keeping the highest count).
+func writeIndex(dst string, next iterator) (uint64, error) {
+	tmp := dst + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	if _, err := file.Seek(recordsBase, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var (
+		w       = bufio.NewWriter(file)
+		fanout  [fanoutSize]uint64
+		count   uint64
+		pending record
+		has     bool
+	)
+
+	emit := func(rec record) error {
+		fanout[binary.BigEndian.Uint16(rec.hash[:2])]++
+		count++
+		return writeRecord(w, rec)
+	}
+
+	for {
+		rec, ok, err := next()
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+
+		if has && rec.hash == pending.hash {
+			pending.count = max(pending.count, rec.count)
+			continue
+		}
+
+		if has {
+			if err := emit(pending); err != nil {
+				return 0, err
+			}
+		}
+		pending, has = rec, true
+	}
+
+	if has {
+		if err := emit(pending); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+
+	for i := 1; i < fanoutSize; i++ {
+		fanout[i] += fanout[i-1]
+	}
+
+	var head bytes.Buffer
+	head.WriteString(magic)
+	binary.Write(&head, binary.BigEndian, count)
+	binary.Write(&head, binary.BigEndian, fanout)
+
+	if _, err := file.WriteAt(head.Bytes(), 0); err != nil {
+		return 0, err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
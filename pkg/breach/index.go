@@ -0,0 +1,162 @@
+// Package breach implements an offline breached-password check against a local
+// corpus of SHA-1 password hashes in the Have I Been Pwned (HIBP) format.
+//
+// The text corpus is converted once by Build into a compact binary index that is
+// searched directly on disk, so lookups need neither network access nor loading
+// the (multi-gigabyte) corpus into memory.
+//
+// Index layout (all integers big-endian):
+//
+//	header   8 bytes magic "GKPWNED1" + uint64 record count
+//	fanout   65536 x uint64, fanout[i] = number of records whose first two hash bytes are <= i
+//	records  record count x (20-byte SHA-1 + uint32 occurrence count), sorted by hash
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	magic       = "GKPWNED1"
+	headerSize  = len(magic) + 8
+	fanoutSize  = 1 << 16
+	hashSize    = sha1.Size
+	recordSize  = hashSize + 4
+	recordsBase = int64(headerSize + fanoutSize*8)
+)
+
+// ErrInvalidIndex is returned when a file is not a valid breach index.
+var ErrInvalidIndex = errors.New("invalid breach index")
+
+// Index is a read-only, on-disk breached-password index. It is safe for concurrent use.
+type Index struct {
+	file   *os.File
+	count  uint64
+	fanout [fanoutSize]uint64
+}
+
+// Open opens an index previously written by Build. Only the header and the
+// fanout table (512 KiB) are read into memory.
+//
+// Returns:
+//   - A pointer to the opened Index.
+//   - An error wrapping ErrInvalidIndex if the file is truncated or has an unknown format.
+func Open(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := readIndex(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	return idx, nil
+}
+
+// readIndex validates the header and loads the fanout table of an index file.
+func readIndex(file *os.File) (*Index, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(file, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: read header: %v", ErrInvalidIndex, err)
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidIndex)
+	}
+
+	idx := &Index{
+		file:  file,
+		count: binary.BigEndian.Uint64(header[len(magic):]),
+	}
+
+	if err := binary.Read(file, binary.BigEndian, &idx.fanout); err != nil {
+		return nil, fmt.Errorf("%w: read fanout: %v", ErrInvalidIndex, err)
+	}
+	if idx.fanout[fanoutSize-1] != idx.count {
+		return nil, fmt.Errorf("%w: fanout does not match record count", ErrInvalidIndex)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != recordsBase+int64(idx.count)*recordSize {
+		return nil, fmt.Errorf("%w: unexpected file size", ErrInvalidIndex)
+	}
+
+	return idx, nil
+}
+
+// Len returns the number of distinct hashes in the index.
+func (i *Index) Len() uint64 {
+	return i.count
+}
+
+// Lookup reports how many times a plaintext password occurs in the breach corpus.
+//
+// Returns:
+//   - The occurrence count, or 0 if the password is not in the corpus.
+//   - An error if the index cannot be read.
+func (i *Index) Lookup(password string) (uint32, error) {
+	return i.LookupHash(sha1.Sum([]byte(password)))
+}
+
+// LookupHash reports how many times a password with the given SHA-1 hash occurs in the breach corpus.
+//
+// Returns:
+//   - The occurrence count, or 0 if the hash is not in the corpus.
+//   - An error if the index cannot be read.
+func (i *Index) LookupHash(hash [hashSize]byte) (uint32, error) {
+	bucket := int(binary.BigEndian.Uint16(hash[:2]))
+
+	var lo uint64
+	if bucket > 0 {
+		lo = i.fanout[bucket-1]
+	}
+	hi := i.fanout[bucket]
+
+	var (
+		record  [recordSize]byte
+		readErr error
+	)
+
+	n := sort.Search(int(hi-lo), func(n int) bool {
+		if readErr != nil {
+			return true
+		}
+		if _, err := i.file.ReadAt(record[:], recordsBase+int64(lo+uint64(n))*recordSize); err != nil {
+			readErr = err
+			return true
+		}
+		return bytes.Compare(record[:hashSize], hash[:]) >= 0
+	})
+	if readErr != nil {
+		return 0, fmt.Errorf("read breach index: %w", readErr)
+	}
+	if uint64(n) == hi-lo {
+		return 0, nil
+	}
+
+	if _, err := i.file.ReadAt(record[:], recordsBase+int64(lo+uint64(n))*recordSize); err != nil {
+		return 0, fmt.Errorf("read breach index: %w", err)
+	}
+	if !bytes.Equal(record[:hashSize], hash[:]) {
+		return 0, nil
+	}
+
+	return binary.BigEndian.Uint32(record[hashSize:]), nil
+}
+
+// Close releases the index file.
+func (i *Index) Close() error {
+	return i.file.Close()
+}
@@ -0,0 +1,68 @@
+package passgen
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// otherPoolSize is the pool size assumed for characters outside the ASCII classes (letters with diacritics, Cyrillic, ...).
+const otherPoolSize = 100
+
+// EstimateEntropy returns a character-pool estimate of a password's entropy in bits:
+// length * log2(size of the union of the character classes the password uses).
+//
+// Runs of the same character count once, so "aaaaaaaa" is not mistaken for a strong password.
+// The estimate is an upper bound for human-chosen passwords: dictionary words and common
+// substitutions are not detected, which is what the breached-password check is for.
+func EstimateEntropy(password string) float64 {
+	if password == "" {
+		return 0
+	}
+
+	var (
+		lower, upper, digits, symbols, other bool
+		length                               int
+		previous                             rune = utf8.RuneError
+	)
+
+	for _, r := range password {
+		if r != previous {
+			length++
+		}
+		previous = r
+
+		switch {
+		case strings.ContainsRune(classAlphabets[ClassLower], r):
+			lower = true
+		case strings.ContainsRune(classAlphabets[ClassUpper], r):
+			upper = true
+		case strings.ContainsRune(classAlphabets[ClassDigits], r):
+			digits = true
+		case r < utf8.RuneSelf && unicode.IsPrint(r):
+			symbols = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digits {
+		pool += 10
+	}
+	if symbols {
+		pool += 33 // printable ASCII punctuation and space
+	}
+	if other {
+		pool += otherPoolSize
+	}
+
+	return float64(length) * math.Log2(float64(pool))
+}
@@ -0,0 +1,152 @@
+package passgen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordlist(t *testing.T) {
+	words := wordlist()
+	assert.Len(t, words, 7776)
+	assert.Equal(t, "abacus", words[0])
+	assert.Equal(t, "zoom", words[len(words)-1])
+}
+
+func TestPassword(t *testing.T) {
+	g := New()
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		length  int
+		entropy float64
+		check   func(t *testing.T, password string)
+	}{
+		{
+			name:    "defaults use every class",
+			policy:  Policy{},
+			length:  DefaultLength,
+			entropy: 129.5,
+			check: func(t *testing.T, password string) {
+				assert.True(t, strings.ContainsAny(password, classAlphabets[ClassLower]))
+				assert.True(t, strings.ContainsAny(password, classAlphabets[ClassUpper]))
+				assert.True(t, strings.ContainsAny(password, classAlphabets[ClassDigits]))
+				assert.True(t, strings.ContainsAny(password, classAlphabets[ClassSymbols]))
+			},
+		},
+		{
+			name:    "digits only",
+			policy:  Policy{Length: 8, Classes: []string{ClassDigits}},
+			length:  8,
+			entropy: 26.6,
+			check: func(t *testing.T, password string) {
+				for _, r := range password {
+					assert.True(t, unicode.IsDigit(r))
+				}
+			},
+		},
+		{
+			name:    "ambiguous and custom exclusions",
+			policy:  Policy{Length: 64, Classes: []string{ClassLower, ClassUpper, ClassDigits}, ExcludeAmbiguous: true, Exclude: "xyz"},
+			length:  64,
+			entropy: 366.6,
+			check: func(t *testing.T, password string) {
+				assert.False(t, strings.ContainsAny(password, Ambiguous+"xyz"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, entropy, err := g.Password(tt.policy)
+			assert.NoError(t, err)
+			assert.Len(t, password, tt.length)
+			assert.InDelta(t, tt.entropy, entropy, 0.1)
+			tt.check(t, password)
+		})
+	}
+}
+
+func TestPassword_InvalidPolicy(t *testing.T) {
+	g := New()
+
+	tests := []struct {
+		name   string
+		policy Policy
+	}{
+		{name: "too short", policy: Policy{Length: 3}},
+		{name: "too long", policy: Policy{Length: MaxLength + 1}},
+		{name: "unknown class", policy: Policy{Classes: []string{"emoji"}}},
+		{name: "class fully excluded", policy: Policy{Classes: []string{ClassDigits}, Exclude: "0123456789"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := g.Password(tt.policy)
+			assert.ErrorIs(t, err, ErrInvalidPolicy)
+		})
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	g := New()
+
+	phrase, entropy, err := g.Passphrase(PassphrasePolicy{Words: 5, Separator: " ", Capitalize: true})
+	assert.NoError(t, err)
+	assert.InDelta(t, 64.6, entropy, 0.1)
+
+	words := strings.Split(phrase, " ")
+	assert.Len(t, words, 5)
+	for _, word := range words {
+		assert.True(t, unicode.IsUpper([]rune(word)[0]))
+	}
+
+	// Some EFF words contain a hyphen ("yo-yo"), so count words with an unambiguous separator.
+	phrase, entropy, err = g.Passphrase(PassphrasePolicy{Separator: ".", WithNumber: true})
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(phrase, "."), DefaultWords)
+	assert.True(t, strings.ContainsAny(phrase, "0123456789"))
+	assert.Greater(t, entropy, 77.5)
+}
+
+func TestPassphrase_InvalidPolicy(t *testing.T) {
+	_, _, err := New().Passphrase(PassphrasePolicy{Words: MaxWords + 1})
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("no entropy")
+}
+
+func TestPassword_RandomError(t *testing.T) {
+	g := &Generator{random: failingReader{}}
+
+	_, _, err := g.Password(Policy{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidPolicy)
+}
+
+func TestEstimateEntropy(t *testing.T) {
+	tests := []struct {
+		password string
+		want     float64
+	}{
+		{password: "", want: 0},
+		{password: "aaaaaaaa", want: 4.7},
+		{password: "password", want: 32.9},
+		{password: "Password1!", want: 59.1},
+		{password: "пароль", want: 39.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			assert.InDelta(t, tt.want, EstimateEntropy(tt.password), 0.1)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops full buckets and forgotten failures, so that keys
+// of past clients do not pile up.
+const sweepInterval = time.Minute
+
+// MemoryStore is a Store kept in the memory of a single server.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	failures  map[string]*failureEntry
+	lastSweep time.Time
+}
+
+// bucket is the state of a token bucket.
+type bucket struct {
+	tokens  float64   // Tokens available at updated.
+	updated time.Time // Time the tokens were last computed.
+	full    time.Time // Time the bucket is full again, after which it can be dropped.
+}
+
+// failureEntry holds the failures of a key until they are forgotten.
+type failureEntry struct {
+	failures Failures
+	expires  time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		failures: make(map[string]*failureEntry),
+	}
+}
+
+// Take takes a token from the bucket of a key; see Store.
+func (m *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	burst := float64(limit.Burst)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		m.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = min(burst, b.tokens+float64(elapsed)/float64(limit.Interval))
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) * float64(limit.Interval)), nil
+	}
+
+	b.tokens--
+	b.full = now.Add(time.Duration((burst - b.tokens) * float64(limit.Interval)))
+
+	return 0, nil
+}
+
+// AddFailure counts a failed attempt of a key; see Store.
+func (m *MemoryStore) AddFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (Failures, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	entry, ok := m.failures[key]
+	if !ok || !now.Before(entry.expires) {
+		entry = &failureEntry{}
+		m.failures[key] = entry
+	}
+
+	entry.failures.Count++
+	entry.failures.Last = now
+	entry.expires = now.Add(ttl)
+
+	return entry.failures, nil
+}
+
+// GetFailures returns the failures of a key that are not forgotten yet; see Store.
+func (m *MemoryStore) GetFailures(ctx context.Context, key string, now time.Time) (Failures, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.failures[key]
+	if !ok {
+		return Failures{}, nil
+	}
+
+	if !now.Before(entry.expires) {
+		delete(m.failures, key)
+		return Failures{}, nil
+	}
+
+	return entry.failures, nil
+}
+
+// ResetFailures forgets the failures of a key; see Store.
+func (m *MemoryStore) ResetFailures(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.failures, key)
+
+	return nil
+}
+
+// sweep drops full buckets and forgotten failures, at most once per sweepInterval. The caller
+// must hold m.mu.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+
+	for key, entry := range m.failures {
+		if !now.Before(entry.expires) {
+			delete(m.failures, key)
+		}
+	}
+}
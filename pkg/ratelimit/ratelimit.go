@@ -0,0 +1,88 @@
+// Package ratelimit throttles authentication: token buckets limit the rate of requests per key, and
+// counters of failed attempts drive progressive delays and a temporary lockout. State is kept in a
+// Store, either the in-memory MemoryStore of a single server or a store shared by several servers.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests are allowed at once, and one more becomes available
+// every Interval.
+type Limit struct {
+	Burst    int           // Size of the bucket; 0 disables the limit.
+	Interval time.Duration // Time in which one token is added to the bucket.
+}
+
+// Enabled reports whether the limit restricts requests at all.
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Interval > 0
+}
+
+// Failures counts the failed attempts of a key since its last success.
+type Failures struct {
+	Count int       // Number of failed attempts.
+	Last  time.Time // Time of the last failed attempt.
+}
+
+// Store keeps token buckets and failure counters by key. Implementations must be safe for
+// concurrent use; a store shared by several servers, e.g. in Redis, must update a key atomically.
+type Store interface {
+	// Take takes a token from the bucket of a key, which starts full.
+	//
+	// Returns:
+	//   - 0 if the request is allowed, or else how long until a token is available; no token is taken then.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (time.Duration, error)
+
+	// AddFailure counts a failed attempt of a key. Failures are forgotten ttl after the last one.
+	AddFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (Failures, error)
+
+	// GetFailures returns the failures of a key that are not forgotten yet.
+	GetFailures(ctx context.Context, key string, now time.Time) (Failures, error)
+
+	// ResetFailures forgets the failures of a key, e.g. after a successful attempt.
+	ResetFailures(ctx context.Context, key string) error
+}
+
+// Lockout derives how long a key must wait before its next attempt from its failures: every
+// failure doubles the delay, starting at BaseDelay, and after Threshold failures the key is locked
+// for Duration. Failures should be stored with Duration as their ttl, so that counting starts over
+// once a lockout has ended.
+type Lockout struct {
+	Threshold int           // Failures after which the key is locked; 0 disables the lockout.
+	Duration  time.Duration // Duration of a lockout, which also caps the delays.
+	BaseDelay time.Duration // Delay after the first failure; 0 disables the delays.
+}
+
+// RetryAfter returns how long a key with the given failures must wait at now before its next attempt.
+//
+// Returns:
+//   - 0 if the key may try now.
+func (l Lockout) RetryAfter(f Failures, now time.Time) time.Duration {
+	if f.Count == 0 {
+		return 0
+	}
+
+	wait := l.Duration
+	if l.Threshold <= 0 || f.Count < l.Threshold {
+		wait = l.delay(f.Count)
+	}
+
+	if until := f.Last.Add(wait); now.Before(until) {
+		return until.Sub(now)
+	}
+
+	return 0
+}
+
+// delay returns the delay after count failures below the threshold: BaseDelay doubled for every
+// failure after the first, capped at Duration.
+func (l Lockout) delay(count int) time.Duration {
+	delay := l.BaseDelay
+	for i := 1; i < count && delay < l.Duration; i++ {
+		delay *= 2
+	}
+
+	return min(delay, l.Duration)
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Burst: 3, Interval: 10 * time.Second}
+	ctx := context.Background()
+
+	for i := range 3 {
+		wait, err := store.Take(ctx, "ip:192.0.2.1", limit, start)
+		require.NoError(t, err)
+		assert.Zero(t, wait, "request %d of the burst should be allowed", i+1)
+	}
+
+	wait, err := store.Take(ctx, "ip:192.0.2.1", limit, start)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, wait, "An empty bucket should report when the next token is added")
+
+	wait, err = store.Take(ctx, "ip:192.0.2.2", limit, start)
+	require.NoError(t, err)
+	assert.Zero(t, wait, "Every key should have its own bucket")
+
+	wait, err = store.Take(ctx, "ip:192.0.2.1", limit, start.Add(4*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Second, wait)
+
+	wait, err = store.Take(ctx, "ip:192.0.2.1", limit, start.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, wait, "A token should be added after the interval")
+
+	wait, err = store.Take(ctx, "ip:192.0.2.1", limit, start.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.NotZero(t, wait)
+}
+
+func TestMemoryStore_SweepsFullBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Burst: 2, Interval: time.Second}
+	ctx := context.Background()
+
+	_, err := store.Take(ctx, "user:alice", limit, start)
+	require.NoError(t, err)
+	_, err = store.AddFailure(ctx, "alice", start, time.Minute)
+	require.NoError(t, err)
+
+	_, err = store.Take(ctx, "user:bob", limit, start.Add(2*sweepInterval))
+	require.NoError(t, err)
+
+	assert.NotContains(t, store.buckets, "user:alice", "A full bucket should be dropped")
+	assert.NotContains(t, store.failures, "alice", "Forgotten failures should be dropped")
+	assert.Contains(t, store.buckets, "user:bob")
+}
+
+func TestMemoryStore_Failures(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	failures, err := store.GetFailures(ctx, "alice", start)
+	require.NoError(t, err)
+	assert.Zero(t, failures.Count)
+
+	_, err = store.AddFailure(ctx, "alice", start, time.Minute)
+	require.NoError(t, err)
+	failures, err = store.AddFailure(ctx, "alice", start.Add(time.Second), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, Failures{Count: 2, Last: start.Add(time.Second)}, failures)
+
+	failures, err = store.GetFailures(ctx, "alice", start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, failures.Count, "Failures should be kept for ttl after the last one")
+
+	failures, err = store.GetFailures(ctx, "alice", start.Add(time.Minute+time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, failures.Count, "Failures should be forgotten ttl after the last one")
+
+	failures, err = store.AddFailure(ctx, "alice", start.Add(2*time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures.Count, "Counting should start over after failures are forgotten")
+
+	require.NoError(t, store.ResetFailures(ctx, "alice"))
+	failures, err = store.GetFailures(ctx, "alice", start.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, failures.Count)
+}
+
+func TestLockout_RetryAfter(t *testing.T) {
+	lockout := Lockout{Threshold: 5, Duration: 15 * time.Minute, BaseDelay: time.Second}
+
+	tests := []struct {
+		name     string
+		failures Failures
+		now      time.Time
+		want     time.Duration
+	}{
+		{name: "no failures", failures: Failures{}, now: start, want: 0},
+		{name: "first failure", failures: Failures{Count: 1, Last: start}, now: start, want: time.Second},
+		{name: "delay doubles", failures: Failures{Count: 3, Last: start}, now: start, want: 4 * time.Second},
+		{name: "delay elapsing", failures: Failures{Count: 3, Last: start}, now: start.Add(3 * time.Second), want: time.Second},
+		{name: "delay elapsed", failures: Failures{Count: 4, Last: start}, now: start.Add(8 * time.Second), want: 0},
+		{name: "locked", failures: Failures{Count: 5, Last: start}, now: start.Add(time.Minute), want: 14 * time.Minute},
+		{name: "lockout ended", failures: Failures{Count: 6, Last: start}, now: start.Add(15 * time.Minute), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lockout.RetryAfter(tt.failures, tt.now))
+		})
+	}
+}
+
+func TestLockout_DelayCappedAtDuration(t *testing.T) {
+	lockout := Lockout{Duration: time.Minute, BaseDelay: time.Second}
+
+	assert.Equal(t, time.Minute, lockout.RetryAfter(Failures{Count: 40, Last: start}, start),
+		"Without a threshold the delays should grow up to the lockout duration")
+	assert.Zero(t, Lockout{Threshold: 3, Duration: time.Minute}.RetryAfter(Failures{Count: 2, Last: start}, start),
+		"Without a base delay only the lockout should apply")
+}
@@ -0,0 +1,153 @@
+// Package totp implements time-based one-time passwords (RFC 6238) as used by authenticator apps:
+// HMAC-SHA1, 6 digits and a 30 second time step.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parameters of the generated codes. Authenticator apps assume them when an otpauth URI does not
+// name other ones.
+const (
+	SecretSize = 20               // Size of a generated secret in bytes, the HMAC-SHA1 block recommendation of RFC 4226.
+	Digits     = 6                // Number of digits of a code.
+	Period     = 30 * time.Second // Time step of a code.
+)
+
+// Skew is the number of time steps before and after the current one whose codes are accepted as
+// well, to allow for clock drift between the server and the authenticator.
+const Skew = 1
+
+// ErrInvalidSecret is returned when a secret is not valid base32.
+var ErrInvalidSecret = errors.New("invalid totp secret")
+
+// encoding is the alphabet of secrets: base32 without padding, as expected by otpauth URIs.
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a random secret shared with an authenticator app.
+//
+// Returns:
+//   - The base32 encoded secret, or an error if the random source fails.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI returns the otpauth URI of a secret, which authenticator apps import, usually from a QR code.
+//
+// Parameters:
+//   - issuer: The name of the service, shown by the app.
+//   - account: The name of the account, usually the username.
+//   - secret: The base32 encoded secret.
+func URI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// Step returns the time step of t, the counter the code of t is computed from.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code computes the code of a secret for a time step.
+//
+// Returns:
+//   - The zero-padded code, or ErrInvalidSecret.
+func Code(secret string, step int64) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, step), nil
+}
+
+// Validate checks a code against the time steps around t.
+//
+// Parameters:
+//   - secret: The base32 encoded secret.
+//   - code: The code entered by the user; spaces are ignored.
+//   - t: The current time.
+//
+// Returns:
+//   - The time step the code belongs to and true if it is valid. Callers should reject codes of a
+//     step that was already used, so that an intercepted code cannot be replayed.
+//   - ErrInvalidSecret if the secret is malformed.
+func Validate(secret, code string, t time.Time) (int64, bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != Digits {
+		return 0, false, nil
+	}
+
+	current := Step(t)
+	for step := current - Skew; step <= current+Skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step)), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// hotp computes the HOTP value (RFC 4226) of a key and counter.
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for range Digits {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%modulo)
+}
+
+// decodeSecret decodes a base32 secret, ignoring spaces, padding and letter case.
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "="))
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	return key, nil
+}
@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 secret of the RFC 6238 test vectors, "12345678901234567890".
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes; the 6 digit codes are their last six digits.
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		got, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current, err := Code(rfcSecret, Step(now))
+	require.NoError(t, err)
+	previous, err := Code(rfcSecret, Step(now)-1)
+	require.NoError(t, err)
+	stale, err := Code(rfcSecret, Step(now)-2)
+	require.NoError(t, err)
+
+	step, ok, err := Validate(rfcSecret, current, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	step, ok, _ = Validate(rfcSecret, previous[:3]+" "+previous[3:], now)
+	assert.True(t, ok, "A code of the previous step should be accepted for clock drift")
+	assert.Equal(t, Step(now)-1, step)
+
+	_, ok, _ = Validate(rfcSecret, stale, now)
+	assert.False(t, ok, "A code outside the skew should be rejected")
+
+	_, ok, _ = Validate(rfcSecret, "12345", now)
+	assert.False(t, ok)
+
+	_, _, err = Validate("not base32!", current, now)
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	key, err := decodeSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, SecretSize)
+
+	u, err := url.Parse(URI("GophKeeper", "alice", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/GophKeeper:alice", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+	assert.Equal(t, "GophKeeper", u.Query().Get("issuer"))
+}
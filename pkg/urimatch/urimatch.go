@@ -0,0 +1,189 @@
+// Package urimatch matches website URLs against the URI patterns stored with login entries.
+//
+// Four match modes are supported, from the most to the least specific:
+//   - exact:  the whole URL (scheme, host, port, path and query) must be equal;
+//   - host:   the host name and port must be equal;
+//   - domain: the registrable domain ("eTLD+1" from the public suffix list) must be equal,
+//     so "https://accounts.example.co.uk" matches "example.co.uk";
+//   - regex:  the pattern is a regular expression matched against the full URL.
+package urimatch
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Match modes.
+const (
+	ModeExact  = "exact"
+	ModeHost   = "host"
+	ModeDomain = "domain"
+	ModeRegex  = "regex"
+)
+
+// DefaultMode is used when a pattern has no explicit match mode.
+const DefaultMode = ModeDomain
+
+// Specificity scores returned by Match; a higher score is a more specific match.
+const (
+	ScoreNone   = 0
+	ScoreRegex  = 1
+	ScoreDomain = 2
+	ScoreHost   = 3
+	ScoreExact  = 4
+)
+
+// ErrInvalidPattern is returned when a URI pattern or its match mode is invalid.
+var ErrInvalidPattern = errors.New("invalid uri pattern")
+
+// Validate checks that a pattern can be used with the given match mode.
+// An empty mode means DefaultMode.
+//
+// Returns:
+//   - An error wrapping ErrInvalidPattern if the mode is unknown, the URL cannot be parsed
+//     or the regular expression does not compile.
+func Validate(mode, pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("%w: empty pattern", ErrInvalidPattern)
+	}
+
+	switch mode {
+	case "", ModeExact, ModeHost, ModeDomain:
+		u, err := Parse(pattern)
+		if err != nil {
+			return err
+		}
+		if mode != ModeExact && u.Hostname() == "" {
+			return fmt.Errorf("%w: %q has no host", ErrInvalidPattern, pattern)
+		}
+	case ModeRegex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown match mode %q", ErrInvalidPattern, mode)
+	}
+
+	return nil
+}
+
+// Parse parses a URL or bare host name; values without a scheme are treated as https URLs.
+// The scheme and host are lower-cased and the fragment is dropped.
+//
+// Returns:
+//   - The parsed URL or an error wrapping ErrInvalidPattern.
+func Parse(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u, nil
+}
+
+// Match reports how specifically a pattern matches the target URL.
+// An empty mode means DefaultMode.
+//
+// Returns:
+//   - One of the Score constants; ScoreNone if the pattern does not match.
+//   - An error wrapping ErrInvalidPattern if the pattern is invalid.
+func Match(mode, pattern string, target *url.URL) (int, error) {
+	if mode == "" {
+		mode = DefaultMode
+	}
+
+	if mode == ModeRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return ScoreNone, fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+		}
+		if re.MatchString(target.String()) {
+			return ScoreRegex, nil
+		}
+		return ScoreNone, nil
+	}
+
+	u, err := Parse(pattern)
+	if err != nil {
+		return ScoreNone, err
+	}
+
+	switch mode {
+	case ModeExact:
+		if canonical(u) == canonical(target) {
+			return ScoreExact, nil
+		}
+	case ModeHost:
+		if hostPort(u) == hostPort(target) {
+			return ScoreHost, nil
+		}
+	case ModeDomain:
+		if d := BaseDomain(u.Hostname()); d != "" && d == BaseDomain(target.Hostname()) {
+			return ScoreDomain, nil
+		}
+	default:
+		return ScoreNone, fmt.Errorf("%w: unknown match mode %q", ErrInvalidPattern, mode)
+	}
+
+	return ScoreNone, nil
+}
+
+// BaseDomain returns the registrable domain of a host using the public suffix list
+// (e.g. "example.co.uk" for "login.example.co.uk"). IP addresses, single-label hosts
+// such as "localhost" and bare public suffixes are returned unchanged.
+func BaseDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+
+	return domain
+}
+
+// hostPort returns the host with the default port of the scheme made explicit.
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// canonical returns the URL used for exact comparison: explicit port, no trailing slash, no fragment.
+func canonical(u *url.URL) string {
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+
+	c := u.Scheme + "://" + hostPort(u) + path
+	if u.RawQuery != "" {
+		c += "?" + u.RawQuery
+	}
+
+	return c
+}
@@ -0,0 +1,79 @@
+package urimatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	target, err := Parse("HTTPS://Accounts.Example.co.uk/login/?next=%2F#top")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		mode    string
+		pattern string
+		want    int
+	}{
+		{name: "exact", mode: ModeExact, pattern: "https://accounts.example.co.uk:443/login?next=%2F", want: ScoreExact},
+		{name: "exact other path", mode: ModeExact, pattern: "https://accounts.example.co.uk/logout", want: ScoreNone},
+		{name: "host", mode: ModeHost, pattern: "accounts.example.co.uk", want: ScoreHost},
+		{name: "host other port", mode: ModeHost, pattern: "https://accounts.example.co.uk:8443", want: ScoreNone},
+		{name: "host other subdomain", mode: ModeHost, pattern: "www.example.co.uk", want: ScoreNone},
+		{name: "domain", mode: ModeDomain, pattern: "https://www.example.co.uk/", want: ScoreDomain},
+		{name: "default mode is domain", mode: "", pattern: "example.co.uk", want: ScoreDomain},
+		{name: "domain does not cross public suffix", mode: ModeDomain, pattern: "other.co.uk", want: ScoreNone},
+		{name: "regex", mode: ModeRegex, pattern: `^https://[a-z]+\.example\.co\.uk/login`, want: ScoreRegex},
+		{name: "regex no match", mode: ModeRegex, pattern: `^http://`, want: ScoreNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.mode, tt.pattern, target)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMatch_LocalHosts(t *testing.T) {
+	target, err := Parse("http://localhost:8080/admin")
+	require.NoError(t, err)
+
+	score, err := Match(ModeDomain, "http://localhost:3000", target)
+	require.NoError(t, err)
+	assert.Equal(t, ScoreDomain, score)
+
+	score, err = Match(ModeHost, "http://localhost:3000", target)
+	require.NoError(t, err)
+	assert.Equal(t, ScoreNone, score)
+
+	ip, err := Parse("https://192.168.1.1/")
+	require.NoError(t, err)
+
+	score, err = Match(ModeDomain, "192.168.1.2", ip)
+	require.NoError(t, err)
+	assert.Equal(t, ScoreNone, score)
+}
+
+func TestBaseDomain(t *testing.T) {
+	assert.Equal(t, "example.com", BaseDomain("a.b.Example.com."))
+	assert.Equal(t, "example.co.uk", BaseDomain("login.example.co.uk"))
+	assert.Equal(t, "user.github.io", BaseDomain("www.user.github.io"))
+	assert.Equal(t, "localhost", BaseDomain("localhost"))
+	assert.Equal(t, "10.0.0.1", BaseDomain("10.0.0.1"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(ModeHost, "example.com"))
+	assert.NoError(t, Validate("", "https://example.com/login"))
+	assert.NoError(t, Validate(ModeRegex, `^https://(www\.)?example\.com/`))
+
+	assert.ErrorIs(t, Validate(ModeHost, ""), ErrInvalidPattern)
+	assert.ErrorIs(t, Validate("prefix", "example.com"), ErrInvalidPattern)
+	assert.ErrorIs(t, Validate(ModeRegex, "("), ErrInvalidPattern)
+	assert.ErrorIs(t, Validate(ModeDomain, "https://"), ErrInvalidPattern)
+	assert.ErrorIs(t, Validate(ModeHost, "http://[::1"), ErrInvalidPattern)
+}